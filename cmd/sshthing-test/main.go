@@ -58,7 +58,11 @@ func main() {
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if fm, ok := final.(app.Model); ok {
+		fm.Shutdown()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/authtoken"
+	"github.com/Vansh-Raja/SSHThing/internal/config"
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+// backupFiles lists the files a .tar.gz backup holds: the encrypted
+// database, the config and the token vault.
+func backupFiles() ([]db.ArchiveFile, error) {
+	dbPath, err := db.DBPath()
+	if err != nil {
+		return nil, err
+	}
+	cfgPath, err := config.Path()
+	if err != nil {
+		return nil, err
+	}
+	vaultPath, err := authtoken.VaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return []db.ArchiveFile{
+		{Name: "hosts.db", Path: dbPath},
+		{Name: "config.json", Path: cfgPath},
+		{Name: "tokens.json", Path: vaultPath},
+	}, nil
+}
+
+func runBackup(args []string, w io.Writer) error {
+	output := ""
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--output" || a == "-o":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for %s", a)
+			}
+			output = strings.TrimSpace(args[i])
+		case strings.HasPrefix(a, "--output="):
+			output = strings.TrimSpace(strings.TrimPrefix(a, "--output="))
+		default:
+			return fmt.Errorf("unknown backup flag: %s", a)
+		}
+	}
+	if output == "" {
+		p, err := db.DefaultBackupPath(time.Now())
+		if err != nil {
+			return err
+		}
+		output = p
+	}
+
+	if db.IsArchivePath(output) {
+		files, err := backupFiles()
+		if err != nil {
+			return err
+		}
+		manifest, err := db.BackupArchive(output, version, files)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "Backup written to %s\n", output); err != nil {
+			return err
+		}
+		for _, f := range manifest.Files {
+			if _, err := fmt.Fprintf(w, "  %-12s SHA-256 %s\n", f.Name, f.SHA256); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	sum, err := db.Backup(output)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Backup written to %s\nSHA-256 %s (%s)\n", output, sum, output+db.ChecksumSuffix)
+	return err
+}
+
+func runRestore(args []string, stdin io.Reader, w io.Writer) error {
+	from := ""
+	force := false
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--from":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --from")
+			}
+			from = strings.TrimSpace(args[i])
+		case strings.HasPrefix(a, "--from="):
+			from = strings.TrimSpace(strings.TrimPrefix(a, "--from="))
+		case a == "--force":
+			force = true
+		default:
+			return fmt.Errorf("unknown restore flag: %s", a)
+		}
+	}
+	if from == "" {
+		return fmt.Errorf("usage: sshthing restore --from <path> [--force]")
+	}
+
+	var files []db.ArchiveFile
+	if db.IsArchivePath(from) {
+		var err error
+		if files, err = backupFiles(); err != nil {
+			return err
+		}
+	} else {
+		dbPath, err := db.DBPath()
+		if err != nil {
+			return err
+		}
+		files = []db.ArchiveFile{{Name: "hosts.db", Path: dbPath}}
+	}
+	if !force && anyFileExists(files) {
+		fmt.Fprint(os.Stderr, "This will overwrite your current data. Continue? [y/N] ")
+		answer, _ := readLine(stdin)
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return fmt.Errorf("restore cancelled")
+		}
+		force = true
+	}
+
+	if db.IsArchivePath(from) {
+		if _, err := db.RestoreArchive(from, files, force); err != nil {
+			return err
+		}
+	} else if err := db.Restore(from, force); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, "Restored successfully")
+	return err
+}
+
+func anyFileExists(files []db.ArchiveFile) bool {
+	for _, f := range files {
+		if _, err := os.Stat(f.Path); err == nil {
+			return true
+		}
+	}
+	return false
+}
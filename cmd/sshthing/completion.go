@@ -0,0 +1,410 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Vansh-Raja/SSHThing/internal/authtoken"
+)
+
+// completionTargetsArg is the hidden `sshthing completion` argument the
+// scripts call to complete `exec -t` labels.
+const completionTargetsArg = "__targets"
+
+const completionHelp = `Usage: sshthing completion <bash|zsh|fish>
+
+Prints a shell completion script to stdout. Subcommands and flags are
+completed, and "exec -t" completes the target labels granted by your
+automation tokens.
+
+Install:
+  bash  echo 'source <(sshthing completion bash)' >> ~/.bashrc
+  zsh   echo 'source <(sshthing completion zsh)' >> ~/.zshrc
+        (or save it as _sshthing in a directory on $fpath)
+  fish  sshthing completion fish > ~/.config/fish/completions/sshthing.fish
+
+Open a new shell afterwards.`
+
+func runCompletion(args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: sshthing completion <bash|zsh|fish> (see --help)")
+	}
+	switch args[0] {
+	case "bash":
+		_, err := io.WriteString(w, bashCompletion)
+		return err
+	case "zsh":
+		_, err := io.WriteString(w, zshCompletion)
+		return err
+	case "fish":
+		_, err := io.WriteString(w, fishCompletion)
+		return err
+	case "--help", "-h", "help":
+		_, err := fmt.Fprintln(w, completionHelp)
+		return err
+	case completionTargetsArg:
+		return printCompletionTargets(w)
+	default:
+		return fmt.Errorf("unsupported shell %q (use bash, zsh or fish)", args[0])
+	}
+}
+
+// printCompletionTargets lists the labels usable with `exec -t`, one per
+// line. The token vault stores labels in the clear, so no unlock is needed.
+func printCompletionTargets(w io.Writer) error {
+	vault, err := authtoken.LoadVault()
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	var labels []string
+	for _, t := range vault.Tokens {
+		if !t.IsUsable() {
+			continue
+		}
+		for _, h := range t.Hosts {
+			label := strings.TrimSpace(h.DisplayLabel)
+			if label == "" || seen[label] {
+				continue
+			}
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	for _, l := range labels {
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const bashCompletion = `# bash completion for sshthing
+_sshthing() {
+    local cur prev sub i
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    sub=""
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            --profile) ((i++)) ;;
+            -*) ;;
+            *) sub="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+
+    case "$prev" in
+        -t|--target)
+            local IFS=$'\n'
+            COMPREPLY=($(compgen -W "$(sshthing completion __targets 2>/dev/null)" -- "$cur"))
+            return ;;
+        --auth-file|--output|--print-public|--from|--ansible|--terraform|--old-pepper-file|--new-pepper-file)
+            COMPREPLY=($(compgen -f -- "$cur"))
+            return ;;
+        --format)
+            COMPREPLY=($(compgen -W "text json csv" -- "$cur"))
+            return ;;
+        --type)
+            COMPREPLY=($(compgen -W "ed25519 rsa ecdsa" -- "$cur"))
+            return ;;
+
+        --profile|--auth|--ttl|--comment|--name|--host|--id|--max-uses|--filter|--tag-filter|--timeout|--count)
+            return ;;
+    esac
+
+    case "$sub" in
+        "")
+            COMPREPLY=($(compgen -W "exec connect ping keygen backup restore session token sync list export import config completion version help --profile --version --help" -- "$cur")) ;;
+        exec)
+            COMPREPLY=($(compgen -W "-t --target --auth --auth-file --auth-stdin" -- "$cur")) ;;
+        connect)
+            COMPREPLY=($(compgen -W "--password-stdin --sftp" -- "$cur")) ;;
+        ping)
+            COMPREPLY=($(compgen -W "--timeout --count --password-stdin" -- "$cur")) ;;
+        keygen)
+            COMPREPLY=($(compgen -W "--type --comment --output --print-public" -- "$cur")) ;;
+        backup)
+            COMPREPLY=($(compgen -W "--output" -- "$cur")) ;;
+        restore)
+            COMPREPLY=($(compgen -W "--from --force" -- "$cur")) ;;
+        session)
+            if [[ " ${COMP_WORDS[*]} " == *" unlock "* ]]; then
+                COMPREPLY=($(compgen -W "--password-stdin --ttl" -- "$cur"))
+            elif [[ " ${COMP_WORDS[*]} " == *" rotate-pepper "* ]]; then
+                COMPREPLY=($(compgen -W "--old-pepper-file --new-pepper-file --tokens-stdin" -- "$cur"))
+            else
+                COMPREPLY=($(compgen -W "unlock lock status rotate-pepper" -- "$cur"))
+            fi ;;
+        token)
+            case " ${COMP_WORDS[*]} " in
+                *" create "*) COMPREPLY=($(compgen -W "--name --host --password-stdin --ttl --max-uses --sync --bind-device" -- "$cur")) ;;
+                *" list "*) COMPREPLY=($(compgen -W "--json --include-revoked" -- "$cur")) ;;
+                *" status "*) COMPREPLY=($(compgen -W "--id --json" -- "$cur")) ;;
+                *" revoke "*) COMPREPLY=($(compgen -W "--id" -- "$cur")) ;;
+                *" activate "*) COMPREPLY=($(compgen -W "--id --password-stdin" -- "$cur")) ;;
+                *) COMPREPLY=($(compgen -W "create list revoke activate status" -- "$cur")) ;;
+            esac ;;
+        sync)
+            COMPREPLY=($(compgen -W "--dry-run --verbose --password-stdin --pull-only --push-only" -- "$cur")) ;;
+        list)
+            COMPREPLY=($(compgen -W "--format --filter --unlock-stdin" -- "$cur")) ;;
+        export)
+            COMPREPLY=($(compgen -W "--json --include-keys --ansible --ansible-ping --ssh-config-live --key-dir --password-stdin" -- "$cur")) ;;
+        import)
+            COMPREPLY=($(compgen -W "--terraform --tag-filter --json --kubeconfig --default-user --vagrant-config --vagrant-stdin --password-stdin" -- "$cur")) ;;
+        config)
+            if [[ " ${COMP_WORDS[*]} " == *" get "* ]]; then
+                COMPREPLY=($(compgen -W "--json" -- "$cur"))
+            elif [[ " ${COMP_WORDS[*]} " != *" set "* ]]; then
+                COMPREPLY=($(compgen -W "get set" -- "$cur"))
+            fi ;;
+        completion)
+            COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+    esac
+}
+complete -F _sshthing sshthing
+`
+
+const zshCompletion = `#compdef sshthing
+
+_sshthing_targets() {
+  local -a targets
+  targets=("${(@f)$(sshthing completion __targets 2>/dev/null)}")
+  compadd -a targets
+}
+
+_sshthing() {
+  local -a subcommands
+  local state
+  subcommands=(
+    'exec:run one token-auth command'
+    'connect:open an ssh session to a host'
+    'ping:check that a host answers on its ssh port'
+    'keygen:generate an ssh key pair'
+    'backup:back up the encrypted database'
+    'restore:restore the database from a backup'
+    'session:manage the unlock session cache'
+    'token:manage automation tokens'
+    'sync:run or preview a git sync'
+    'list:print hosts'
+    'export:export hosts as json, an ansible inventory or ssh config'
+    'import:import hosts from terraform, json, kubeconfig or vagrant'
+    'config:get or set a config.json value'
+    'completion:print a shell completion script'
+    'version:print version'
+    'help:show help'
+  )
+
+  _arguments -C \
+    '--profile[use a separate profile]:profile:' \
+    '(- *)--version[print version]' \
+    '(- *)--help[show help]' \
+    '1: :->cmd' \
+    '*:: :->args'
+
+  case $state in
+    cmd)
+      _describe -t commands 'sshthing command' subcommands ;;
+    args)
+      case $words[1] in
+        exec)
+          _arguments \
+            '(-t --target)'{-t,--target}'[target label]:target:_sshthing_targets' \
+            '--auth[auth token]:token:' \
+            '--auth-file[read the token from a file]:file:_files' \
+            '--auth-stdin[read the token from stdin]' \
+            '*:command:' ;;
+        connect)
+          _arguments \
+            '1:host label:' \
+            '--password-stdin[read the master password from stdin]' \
+            '--sftp[open an sftp session instead]' ;;
+        ping)
+          _arguments \
+            '1:host label:' \
+            '--timeout[time to wait for each attempt]:duration:' \
+            '--count[number of attempts]:count:' \
+            '--password-stdin[read the master password from stdin]' ;;
+        keygen)
+          _arguments \
+            '--type[key type]:type:(ed25519 rsa ecdsa)' \
+            '--comment[key comment]:comment:' \
+            '--output[private key path, or - for stdout]:file:_files' \
+            '--print-public[print the public key of a private key file]:file:_files' ;;
+        backup)
+          _arguments '--output[backup file path]:file:_files' ;;
+        restore)
+          _arguments \
+            '--from[backup file to restore]:file:_files' \
+            '--force[replace an existing database]' ;;
+        session)
+          _arguments \
+            '1:action:(unlock lock status rotate-pepper)' \
+            '--password-stdin[read the master password from stdin]' \
+            '--ttl[session lifetime]:duration:' \
+            '--old-pepper-file[device pepper the tokens are bound to]:file:_files' \
+            '--new-pepper-file[device pepper to bind them to]:file:_files' \
+            '--tokens-stdin[read raw tokens from stdin]' ;;
+        token)
+          _arguments \
+            '1:action:(create list revoke activate status)' \
+            '--name[token name]:name:' \
+            '*--host[host label to grant]:label:' \
+            '--id[token id]:id:' \
+            '--ttl[token lifetime, e.g. 30d]:duration:' \
+            '--max-uses[maximum number of uses]:count:' \
+            '--sync[sync the token definition]' \
+            '--bind-device[fail unless the token can be bound to this device]' \
+            '--include-revoked[list revoked tokens too]' \
+            '--json[print json]' \
+            '--password-stdin[read the master password from stdin]' ;;
+        sync)
+          _arguments \
+            '--dry-run[preview changes without applying them]' \
+            '(--push-only)--pull-only[import remote changes without pushing]' \
+            '(--pull-only)--push-only[push local changes without importing]' \
+            '(-v --verbose)'{-v,--verbose}'[print each sync stage]' \
+            '--password-stdin[read the master password from stdin]' ;;
+        list)
+          _arguments \
+            '--format[output format]:format:(text json csv)' \
+            '--filter[only hosts matching a filter such as tag:prod]:filter:' \
+            '--unlock-stdin[read the master password from stdin]' ;;
+        export)
+          _arguments \
+            '--json[print every host as JSON]' \
+            '--include-keys[include decrypted private keys in the JSON]' \
+            '--ansible[write an ansible inventory, to a file or stdout]::file:_files' \
+            '--ansible-ping[run ansible ping against the inventory]' \
+            '--ssh-config-live[print ssh config blocks for every host]' \
+            '--key-dir[directory for the private keys]:directory:_files -/' \
+            '--password-stdin[read the master password from stdin]' ;;
+        import)
+          _arguments \
+            '--terraform[terraform state file]:file:_files' \
+            '--tag-filter[only instances with this tag]:KEY=VALUE:' \
+            '--json[hosts from sshthing export --json]:file:_files' \
+            '--kubeconfig[kubeconfig file (default ~/.kube/config)]::file:_files' \
+            '--default-user[ssh user when the kubeconfig has none]:user:' \
+            '--vagrant-config[saved vagrant ssh-config output]:file:_files' \
+            '--vagrant-stdin[read vagrant ssh-config output from stdin]' \
+            '--password-stdin[read the master password from stdin]' ;;
+        config)
+          _arguments \
+            '1:action:(get set)' \
+            '2:key:' \
+            '--json[print the whole config as JSON]' ;;
+        completion)
+          _arguments '1:shell:(bash zsh fish)' ;;
+      esac ;;
+  esac
+}
+
+if [ "$funcstack[1]" = "_sshthing" ]; then
+  _sshthing "$@"
+else
+  compdef _sshthing sshthing
+fi
+`
+
+const fishCompletion = `# fish completion for sshthing
+function __sshthing_targets
+    sshthing completion __targets 2>/dev/null
+end
+
+set -l cmds exec connect ping keygen backup restore session token sync list export import config completion version help
+complete -c sshthing -f
+complete -c sshthing -l profile -x -d 'Use a separate profile'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -l version -d 'Print version'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -l help -d 'Show help'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a exec -d 'Run one token-auth command'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a connect -d 'Open an SSH session to a host'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a ping -d 'Check that a host answers on its SSH port'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a keygen -d 'Generate an SSH key pair'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a backup -d 'Back up the encrypted database'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a restore -d 'Restore the database from a backup'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a session -d 'Manage the unlock session cache'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a token -d 'Manage automation tokens'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a sync -d 'Run or preview a Git sync'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a list -d 'Print hosts'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a export -d 'Export hosts as JSON, an Ansible inventory or ssh config'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a import -d 'Import hosts from Terraform, JSON, kubeconfig or Vagrant'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a config -d 'Get or set a config.json value'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a completion -d 'Print a shell completion script'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a version -d 'Print version'
+complete -c sshthing -n "not __fish_seen_subcommand_from $cmds" -a help -d 'Show help'
+
+complete -c sshthing -n "__fish_seen_subcommand_from exec" -s t -l target -x -a '(__sshthing_targets)' -d 'Target label'
+complete -c sshthing -n "__fish_seen_subcommand_from exec" -l auth -x -d 'Auth token'
+complete -c sshthing -n "__fish_seen_subcommand_from exec" -l auth-file -r -F -d 'Read the token from a file'
+complete -c sshthing -n "__fish_seen_subcommand_from exec" -l auth-stdin -d 'Read the token from stdin'
+
+complete -c sshthing -n "__fish_seen_subcommand_from connect" -l password-stdin -d 'Read the master password from stdin'
+complete -c sshthing -n "__fish_seen_subcommand_from connect" -l sftp -d 'Open an SFTP session instead'
+
+complete -c sshthing -n "__fish_seen_subcommand_from ping" -l timeout -x -d 'Time to wait for each attempt'
+complete -c sshthing -n "__fish_seen_subcommand_from ping" -l count -x -d 'Number of attempts'
+complete -c sshthing -n "__fish_seen_subcommand_from ping" -l password-stdin -d 'Read the master password from stdin'
+
+complete -c sshthing -n "__fish_seen_subcommand_from keygen" -l type -x -a 'ed25519 rsa ecdsa' -d 'Key type'
+complete -c sshthing -n "__fish_seen_subcommand_from keygen" -l comment -x -d 'Key comment'
+complete -c sshthing -n "__fish_seen_subcommand_from keygen" -l output -r -F -d 'Private key path, or - for stdout'
+complete -c sshthing -n "__fish_seen_subcommand_from keygen" -l print-public -r -F -d 'Print the public key of a private key file'
+
+complete -c sshthing -n "__fish_seen_subcommand_from backup" -l output -r -F -d 'Backup file path'
+complete -c sshthing -n "__fish_seen_subcommand_from restore" -l from -r -F -d 'Backup file to restore'
+complete -c sshthing -n "__fish_seen_subcommand_from restore" -l force -d 'Replace an existing database'
+
+complete -c sshthing -n "__fish_seen_subcommand_from session; and not __fish_seen_subcommand_from unlock lock status rotate-pepper" -a 'unlock lock status rotate-pepper'
+complete -c sshthing -n "__fish_seen_subcommand_from unlock" -l password-stdin -d 'Read the master password from stdin'
+complete -c sshthing -n "__fish_seen_subcommand_from unlock" -l ttl -x -d 'Session lifetime'
+complete -c sshthing -n "__fish_seen_subcommand_from rotate-pepper" -l old-pepper-file -r -F -d 'Device pepper the tokens are bound to'
+complete -c sshthing -n "__fish_seen_subcommand_from rotate-pepper" -l new-pepper-file -r -F -d 'Device pepper to bind them to'
+complete -c sshthing -n "__fish_seen_subcommand_from rotate-pepper" -l tokens-stdin -d 'Read raw tokens from stdin'
+
+complete -c sshthing -n "__fish_seen_subcommand_from token; and not __fish_seen_subcommand_from create list revoke activate status" -a 'create list revoke activate status'
+complete -c sshthing -n "__fish_seen_subcommand_from token; and __fish_seen_subcommand_from create" -l name -x -d 'Token name'
+complete -c sshthing -n "__fish_seen_subcommand_from token; and __fish_seen_subcommand_from create" -l host -x -d 'Host label to grant'
+complete -c sshthing -n "__fish_seen_subcommand_from token; and __fish_seen_subcommand_from create" -l ttl -x -d 'Token lifetime, e.g. 30d'
+complete -c sshthing -n "__fish_seen_subcommand_from token; and __fish_seen_subcommand_from create" -l max-uses -x -d 'Maximum number of uses'
+complete -c sshthing -n "__fish_seen_subcommand_from token; and __fish_seen_subcommand_from create" -l sync -d 'Sync the token definition'
+complete -c sshthing -n "__fish_seen_subcommand_from token; and __fish_seen_subcommand_from create" -l bind-device -d 'Fail unless the token can be bound to this device'
+complete -c sshthing -n "__fish_seen_subcommand_from token; and __fish_seen_subcommand_from list" -l include-revoked -d 'List revoked tokens too'
+complete -c sshthing -n "__fish_seen_subcommand_from token; and __fish_seen_subcommand_from create activate" -l password-stdin -d 'Read the master password from stdin'
+complete -c sshthing -n "__fish_seen_subcommand_from token; and __fish_seen_subcommand_from revoke activate status" -l id -x -d 'Token id'
+complete -c sshthing -n "__fish_seen_subcommand_from token; and __fish_seen_subcommand_from list status" -l json -d 'Print JSON'
+
+complete -c sshthing -n "__fish_seen_subcommand_from sync" -l dry-run -d 'Preview changes without applying them'
+complete -c sshthing -n "__fish_seen_subcommand_from sync" -l pull-only -d 'Import remote changes without pushing'
+complete -c sshthing -n "__fish_seen_subcommand_from sync" -l push-only -d 'Push local changes without importing'
+complete -c sshthing -n "__fish_seen_subcommand_from sync" -s v -l verbose -d 'Print each sync stage'
+complete -c sshthing -n "__fish_seen_subcommand_from sync" -l password-stdin -d 'Read the master password from stdin'
+
+complete -c sshthing -n "__fish_seen_subcommand_from list" -l format -x -a 'text json csv' -d 'Output format'
+complete -c sshthing -n "__fish_seen_subcommand_from list" -l filter -x -d 'Only hosts matching a filter such as tag:prod'
+complete -c sshthing -n "__fish_seen_subcommand_from list" -l unlock-stdin -d 'Read the master password from stdin'
+
+complete -c sshthing -n "__fish_seen_subcommand_from export" -l json -d 'Print every host as JSON'
+complete -c sshthing -n "__fish_seen_subcommand_from export" -l include-keys -d 'Include decrypted private keys in the JSON'
+complete -c sshthing -n "__fish_seen_subcommand_from export" -l ansible -r -F -d 'Write an Ansible inventory to a file or stdout'
+complete -c sshthing -n "__fish_seen_subcommand_from export" -l ansible-ping -d 'Run ansible ping against the inventory'
+complete -c sshthing -n "__fish_seen_subcommand_from export" -l ssh-config-live -d 'Print ssh config blocks for every host'
+complete -c sshthing -n "__fish_seen_subcommand_from export" -l key-dir -r -a '(__fish_complete_directories)' -d 'Directory for the private keys'
+complete -c sshthing -n "__fish_seen_subcommand_from export" -l password-stdin -d 'Read the master password from stdin'
+complete -c sshthing -n "__fish_seen_subcommand_from import" -l terraform -r -F -d 'Terraform state file'
+complete -c sshthing -n "__fish_seen_subcommand_from import" -l tag-filter -x -d 'Only instances with this KEY=VALUE tag'
+complete -c sshthing -n "__fish_seen_subcommand_from import" -l json -r -F -d 'Hosts from sshthing export --json'
+complete -c sshthing -n "__fish_seen_subcommand_from import" -l kubeconfig -F -d 'Kubeconfig file (default ~/.kube/config)'
+complete -c sshthing -n "__fish_seen_subcommand_from import" -l default-user -x -d 'SSH user when the kubeconfig has none'
+complete -c sshthing -n "__fish_seen_subcommand_from import" -l vagrant-config -r -F -d 'Saved vagrant ssh-config output'
+complete -c sshthing -n "__fish_seen_subcommand_from import" -l vagrant-stdin -d 'Read vagrant ssh-config output from stdin'
+complete -c sshthing -n "__fish_seen_subcommand_from import" -l password-stdin -d 'Read the master password from stdin'
+
+complete -c sshthing -n "__fish_seen_subcommand_from config; and not __fish_seen_subcommand_from get set" -a 'get set'
+complete -c sshthing -n "__fish_seen_subcommand_from config; and __fish_seen_subcommand_from get" -l json -d 'Print the whole config as JSON'
+
+complete -c sshthing -n "__fish_seen_subcommand_from completion" -a 'bash zsh fish'
+`
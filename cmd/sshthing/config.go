@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Vansh-Raja/SSHThing/internal/config"
+)
+
+const configUsage = "usage: sshthing config get <key> | sshthing config get --json | sshthing config set <key> <value>"
+
+func runConfig(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf(configUsage)
+	}
+	switch args[0] {
+	case "get":
+		return runConfigGet(args[1:], w)
+	case "set":
+		return runConfigSet(args[1:])
+	default:
+		return fmt.Errorf("unknown config action %q (%s)", args[0], configUsage)
+	}
+}
+
+func runConfigGet(args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf(configUsage)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if args[0] == "--json" {
+		b, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+	v, err := config.Get(cfg, args[0])
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, v)
+	return err
+}
+
+func runConfigSet(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf(configUsage)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := config.Set(&cfg, args[0], args[1]); err != nil {
+		return err
+	}
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+	"github.com/Vansh-Raja/SSHThing/internal/ssh"
+	syncpkg "github.com/Vansh-Raja/SSHThing/internal/sync"
+)
+
+func runExport(args []string, w io.Writer) error {
+	ansible := false
+	sshConfig := false
+	keyDir := ""
+	asJSON := false
+	includeKeys := false
+	path := ""
+	ping := false
+	readStdin := false
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--ansible":
+			ansible = true
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+				i++
+				path = strings.TrimSpace(args[i])
+			}
+		case strings.HasPrefix(a, "--ansible="):
+			ansible = true
+			path = strings.TrimSpace(strings.TrimPrefix(a, "--ansible="))
+		case a == "--ansible-ping":
+			ping = true
+		case a == "--ssh-config-live":
+			sshConfig = true
+		case a == "--json":
+			asJSON = true
+		case a == "--include-keys":
+			includeKeys = true
+		case a == "--key-dir":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --key-dir")
+			}
+			keyDir = strings.TrimSpace(args[i])
+		case strings.HasPrefix(a, "--key-dir="):
+			keyDir = strings.TrimSpace(strings.TrimPrefix(a, "--key-dir="))
+		case a == "--password-stdin":
+			readStdin = true
+		default:
+			return fmt.Errorf("unknown export flag: %s", a)
+		}
+	}
+	if includeKeys && !asJSON {
+		return fmt.Errorf("--include-keys is only used with --json")
+	}
+	if asJSON {
+		if ansible || ping || sshConfig || keyDir != "" {
+			return fmt.Errorf("--json cannot be combined with other export formats")
+		}
+		return runExportJSON(includeKeys, readStdin, w)
+	}
+	if sshConfig {
+		if ansible || ping {
+			return fmt.Errorf("--ssh-config-live cannot be combined with --ansible")
+		}
+		if keyDir == "" {
+			return fmt.Errorf("--ssh-config-live requires --key-dir PATH")
+		}
+		return runExportSSHConfig(keyDir, readStdin, w)
+	}
+	if keyDir != "" {
+		return fmt.Errorf("--key-dir is only used with --ssh-config-live")
+	}
+	if !ansible {
+		return fmt.Errorf("usage: sshthing export --ansible [PATH|-] [--ansible-ping] [--password-stdin]\n       sshthing export --ssh-config-live --key-dir PATH [--password-stdin]\n       sshthing export --json [--include-keys] [--password-stdin]")
+	}
+	if path == "-" {
+		path = ""
+	}
+	if ping && path == "" {
+		path = "inventory.yml"
+	}
+	if ping {
+		if _, err := exec.LookPath("ansible"); err != nil {
+			return fmt.Errorf("ansible not found in PATH")
+		}
+	}
+
+	pw, err := readMasterPassword(readStdin, "--password-stdin")
+	if err != nil {
+		return err
+	}
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	defer store.Close()
+
+	var buf bytes.Buffer
+	ansibleKeyDir, err := syncpkg.ExportAnsibleInventory(store, &buf)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		if ansibleKeyDir != "" {
+			fmt.Fprintf(os.Stderr, "Private keys are in %s; delete it when done\n", ansibleKeyDir)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write inventory: %w", err)
+	}
+	fmt.Fprintf(w, "Inventory written to %s\n", path)
+	if ansibleKeyDir != "" {
+		fmt.Fprintf(w, "Private keys are in %s; delete it when done\n", ansibleKeyDir)
+	}
+	if !ping {
+		return nil
+	}
+
+	cmd := exec.Command("ansible", "all", "-i", path, "-m", "ping")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ansible ping failed: %w", err)
+	}
+	return nil
+}
+
+// runExportSSHConfig writes every host's private key into keyDir and prints
+// ssh config blocks that point at them. Earlier key files in keyDir are
+// replaced, so the command can be rerun on a schedule.
+func runExportSSHConfig(keyDir string, readStdin bool, w io.Writer) error {
+	keyDir, err := filepath.Abs(keyDir)
+	if err != nil {
+		return err
+	}
+
+	pw, err := readMasterPassword(readStdin, "--password-stdin")
+	if err != nil {
+		return err
+	}
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	defer store.Close()
+
+	hosts, err := store.GetHosts()
+	if err != nil {
+		return fmt.Errorf("failed to get hosts: %w", err)
+	}
+	out, err := ssh.GenerateSSHConfig(hosts, keyDir)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(keyDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	old, _ := filepath.Glob(filepath.Join(keyDir, "host_*"))
+	for _, p := range old {
+		_ = ssh.SecureDeleteFile(p)
+	}
+	for _, h := range hosts {
+		if !ssh.HasConfigKey(h) {
+			continue
+		}
+		key, err := store.GetHostSecret(h.ID)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt key for %s: %w", h.Hostname, err)
+		}
+		if err := ssh.WritePrivateKeyFile(filepath.Join(keyDir, ssh.ConfigKeyFileName(h.ID)), key); err != nil {
+			return err
+		}
+	}
+
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+// exportedHost is one host in `sshthing export --json`; db.ImportJSONHosts
+// reads the same fields back.
+type exportedHost struct {
+	ID            int        `json:"id"`
+	Label         string     `json:"label"`
+	Group         string     `json:"group"`
+	Tags          []string   `json:"tags"`
+	Hostname      string     `json:"hostname"`
+	Username      string     `json:"username"`
+	Port          int        `json:"port"`
+	KeyType       string     `json:"key_type"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastConnected *time.Time `json:"last_connected"`
+	PrivateKey    string     `json:"private_key,omitempty"`
+}
+
+// runExportJSON prints every host as a JSON array sorted by ID. With
+// includeKeys, hosts with a stored key also carry it in plaintext.
+func runExportJSON(includeKeys, readStdin bool, w io.Writer) error {
+	pw, err := readMasterPassword(readStdin, "--password-stdin")
+	if err != nil {
+		return err
+	}
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	defer store.Close()
+
+	hosts, err := store.GetHosts()
+	if err != nil {
+		return fmt.Errorf("failed to get hosts: %w", err)
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].ID < hosts[j].ID })
+
+	out := make([]exportedHost, 0, len(hosts))
+	for _, h := range hosts {
+		tags := h.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		eh := exportedHost{
+			ID:            h.ID,
+			Label:         h.Label,
+			Group:         h.GroupName,
+			Tags:          tags,
+			Hostname:      h.Hostname,
+			Username:      h.Username,
+			Port:          h.Port,
+			KeyType:       h.KeyType,
+			CreatedAt:     h.CreatedAt.UTC(),
+			LastConnected: h.LastConnected,
+		}
+		if includeKeys && ssh.HasConfigKey(h) {
+			key, err := store.GetHostSecret(h.ID)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt key for %s: %w", h.Hostname, err)
+			}
+			eh.PrivateKey = key
+		}
+		out = append(out, eh)
+	}
+	if includeKeys {
+		fmt.Fprintln(os.Stderr, "WARNING: the output contains decrypted private keys. Anyone who can read it can log in to these hosts; never commit or share it, and delete it when done.")
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
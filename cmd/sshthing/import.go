@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func runImport(args []string, w io.Writer) error {
+	path := ""
+	jsonPath := ""
+	tagFilter := ""
+	kubePath := ""
+	useKube := false
+	defaultUser := ""
+	vagrantPath := ""
+	vagrantStdin := false
+	readStdin := false
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--terraform":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --terraform")
+			}
+			path = strings.TrimSpace(args[i])
+		case strings.HasPrefix(a, "--terraform="):
+			path = strings.TrimSpace(strings.TrimPrefix(a, "--terraform="))
+		case a == "--json":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --json")
+			}
+			jsonPath = strings.TrimSpace(args[i])
+		case strings.HasPrefix(a, "--json="):
+			jsonPath = strings.TrimSpace(strings.TrimPrefix(a, "--json="))
+		case a == "--tag-filter":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --tag-filter")
+			}
+			tagFilter = args[i]
+		case strings.HasPrefix(a, "--tag-filter="):
+			tagFilter = strings.TrimPrefix(a, "--tag-filter=")
+		case a == "--kubeconfig":
+			useKube = true
+			// The path is optional: a following flag (or nothing) means the
+			// default ~/.kube/config.
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+				i++
+				kubePath = strings.TrimSpace(args[i])
+			}
+		case strings.HasPrefix(a, "--kubeconfig="):
+			useKube = true
+			kubePath = strings.TrimSpace(strings.TrimPrefix(a, "--kubeconfig="))
+		case a == "--default-user":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --default-user")
+			}
+			defaultUser = strings.TrimSpace(args[i])
+		case strings.HasPrefix(a, "--default-user="):
+			defaultUser = strings.TrimSpace(strings.TrimPrefix(a, "--default-user="))
+		case a == "--vagrant-config":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --vagrant-config")
+			}
+			vagrantPath = strings.TrimSpace(args[i])
+		case strings.HasPrefix(a, "--vagrant-config="):
+			vagrantPath = strings.TrimSpace(strings.TrimPrefix(a, "--vagrant-config="))
+		case a == "--vagrant-stdin":
+			vagrantStdin = true
+		case a == "--password-stdin":
+			readStdin = true
+		default:
+			return fmt.Errorf("unknown import flag: %s", a)
+		}
+	}
+	if vagrantPath != "" || vagrantStdin {
+		if vagrantPath != "" && vagrantStdin {
+			return fmt.Errorf("--vagrant-config and --vagrant-stdin cannot be combined")
+		}
+		if path != "" || jsonPath != "" || tagFilter != "" || useKube || defaultUser != "" {
+			return fmt.Errorf("--vagrant-config/--vagrant-stdin cannot be combined with other import sources")
+		}
+		if vagrantStdin && readStdin {
+			return fmt.Errorf("--vagrant-stdin reads stdin, so use 'sshthing session unlock' instead of --password-stdin")
+		}
+		return runImportVagrant(vagrantPath, readStdin, w)
+	}
+	if useKube {
+		if path != "" || jsonPath != "" || tagFilter != "" {
+			return fmt.Errorf("--kubeconfig cannot be combined with --terraform, --json or --tag-filter")
+		}
+		return runImportKubeconfig(kubePath, defaultUser, readStdin, w)
+	}
+	if defaultUser != "" {
+		return fmt.Errorf("--default-user only applies to --kubeconfig")
+	}
+	if jsonPath != "" {
+		if path != "" || tagFilter != "" {
+			return fmt.Errorf("--json cannot be combined with --terraform or --tag-filter")
+		}
+		return runImportJSON(jsonPath, readStdin, w)
+	}
+	if path == "" {
+		return fmt.Errorf("usage: sshthing import --terraform PATH [--tag-filter KEY=VALUE] [--password-stdin]\n       sshthing import --json PATH [--password-stdin]\n       sshthing import --kubeconfig [PATH] [--default-user NAME] [--password-stdin]\n       sshthing import --vagrant-config PATH [--password-stdin]\n       vagrant ssh-config | sshthing import --vagrant-stdin")
+	}
+	tagKey, tagValue := "", ""
+	if tagFilter != "" {
+		k, v, ok := strings.Cut(tagFilter, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return fmt.Errorf("invalid --tag-filter %q (use KEY=VALUE)", tagFilter)
+		}
+		tagKey, tagValue = strings.TrimSpace(k), strings.TrimSpace(v)
+	}
+
+	state, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	pw, err := readMasterPassword(readStdin, "--password-stdin")
+	if err != nil {
+		return err
+	}
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	defer store.Close()
+
+	added, err := db.ImportTerraformStateTagged(store, state, tagKey, tagValue)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Imported %d hosts from %s\n", added, path)
+	return nil
+}
+
+// runImportJSON adds the hosts from an `sshthing export --json` file. Hosts
+// whose label is already in use are skipped.
+func runImportJSON(path string, readStdin bool, w io.Writer) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	pw, err := readMasterPassword(readStdin, "--password-stdin")
+	if err != nil {
+		return err
+	}
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	defer store.Close()
+
+	sum, err := db.ImportJSONHosts(store, data, db.ImportSkip)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Imported %d hosts from %s", sum.Added, path)
+	if sum.Skipped > 0 {
+		fmt.Fprintf(w, " (%d skipped: label already in use)", sum.Skipped)
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// runImportKubeconfig adds a host for each cluster API server in a
+// kubeconfig, ~/.kube/config when path is empty.
+func runImportKubeconfig(path, defaultUser string, readStdin bool, w io.Writer) error {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to find home directory: %w", err)
+		}
+		path = filepath.Join(home, ".kube", "config")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+
+	pw, err := readMasterPassword(readStdin, "--password-stdin")
+	if err != nil {
+		return err
+	}
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	defer store.Close()
+
+	added, err := db.ImportKubeconfigUser(store, path, defaultUser)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Imported %d hosts from %s\n", added, path)
+	return nil
+}
+
+// runImportVagrant adds the machines from `vagrant ssh-config` output, read
+// from path or, when path is empty, from stdin.
+func runImportVagrant(path string, readStdin bool, w io.Writer) error {
+	var in io.Reader = os.Stdin
+	source := "stdin"
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		defer f.Close()
+		in, source = f, path
+	}
+
+	pw, err := readMasterPassword(readStdin, "--password-stdin")
+	if err != nil {
+		return err
+	}
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	defer store.Close()
+
+	added, err := db.ImportVagrantSSHConfig(store, in)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Imported %d hosts from %s\n", added, source)
+	return nil
+}
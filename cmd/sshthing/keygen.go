@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Vansh-Raja/SSHThing/internal/ssh"
+)
+
+// keygenDelimiter separates the private and public key when both are printed
+// with --output -.
+const keygenDelimiter = "----- PUBLIC KEY -----"
+
+func runKeygen(args []string, w io.Writer) error {
+	keyType := ssh.KeyTypeEd25519
+	comment := ""
+	output := ""
+	printPublic := ""
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		name, value, hasValue := strings.Cut(a, "=")
+		switch name {
+		case "--type", "--comment", "--output", "--print-public":
+		default:
+			return fmt.Errorf("unknown keygen flag: %s", a)
+		}
+		if !hasValue {
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for %s", name)
+			}
+			value = args[i]
+		}
+		switch name {
+		case "--type":
+			keyType = ssh.KeyType(strings.ToLower(strings.TrimSpace(value)))
+		case "--comment":
+			comment = value
+		case "--output":
+			output = strings.TrimSpace(value)
+		case "--print-public":
+			printPublic = strings.TrimSpace(value)
+		}
+	}
+
+	if printPublic != "" {
+		b, err := os.ReadFile(printPublic)
+		if err != nil {
+			return fmt.Errorf("failed to read private key: %w", err)
+		}
+		pub, err := ssh.GetPublicKeyFromPrivate(string(b))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, pub)
+		return err
+	}
+
+	switch keyType {
+	case ssh.KeyTypeEd25519, ssh.KeyTypeRSA, ssh.KeyTypeECDSA:
+	default:
+		return fmt.Errorf("unsupported key type %q (use ed25519, rsa or ecdsa)", keyType)
+	}
+	if output == "" {
+		return fmt.Errorf("usage: sshthing keygen --output <path|-> [--type ed25519|rsa|ecdsa] [--comment text]")
+	}
+	if output != "-" {
+		for _, p := range []string{output, output + ".pub"} {
+			if _, err := os.Stat(p); err == nil {
+				return fmt.Errorf("%s already exists", p)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	priv, pub, err := ssh.GenerateKey(keyType, comment)
+	if err != nil {
+		return err
+	}
+	if output == "-" {
+		_, err = fmt.Fprintf(w, "%s\n%s\n%s\n", strings.TrimSpace(priv), keygenDelimiter, pub)
+		return err
+	}
+	if err := os.WriteFile(output, []byte(priv), 0600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+	if err := os.WriteFile(output+".pub", []byte(pub+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
+	}
+	_, err = fmt.Fprintln(w, pub)
+	return err
+}
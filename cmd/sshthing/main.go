@@ -1,12 +1,19 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/Vansh-Raja/SSHThing/internal/app"
@@ -15,6 +22,7 @@ import (
 	"github.com/Vansh-Raja/SSHThing/internal/db"
 	"github.com/Vansh-Raja/SSHThing/internal/securestore"
 	"github.com/Vansh-Raja/SSHThing/internal/ssh"
+	syncpkg "github.com/Vansh-Raja/SSHThing/internal/sync"
 	"github.com/Vansh-Raja/SSHThing/internal/unlock"
 	"github.com/Vansh-Raja/SSHThing/internal/update"
 	tea "github.com/charmbracelet/bubbletea"
@@ -40,6 +48,22 @@ func main() {
 		return
 	}
 
+	args, profile, err := extractProfileFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if profile != "" {
+		_ = os.Setenv(config.ProfileEnv, profile)
+	}
+	if p := config.ActiveProfile(); p != "" {
+		if err := config.ValidateProfile(p); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	os.Args = append(os.Args[:1], args...)
+
 	if len(os.Args) > 1 && os.Args[1] == "exec" {
 		if err := runExec(os.Args[2:]); err != nil {
 			fmt.Fprintf(os.Stderr, "exec error: %v\n", err)
@@ -47,6 +71,48 @@ func main() {
 		}
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "connect" {
+		if err := runConnect(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "connect error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "keygen" {
+		if err := runKeygen(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "keygen error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		if err := runBackup(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "backup error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if err := runRestore(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "restore error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "export error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		if err := runToken(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "token error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	if len(os.Args) > 1 && os.Args[1] == "session" {
 		if err := runSession(os.Args[2:]); err != nil {
 			fmt.Fprintf(os.Stderr, "session error: %v\n", err)
@@ -54,6 +120,53 @@ func main() {
 		}
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "list" {
+		if err := runList(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "list error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "import error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfig(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "completion" {
+		if err := runCompletion(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "completion error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "ping" {
+		if err := runPing(os.Args[2:], os.Stdout); err != nil {
+			if !errors.Is(err, errHostUnreachable) {
+				fmt.Fprintf(os.Stderr, "ping error: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "sync" {
+		if err := runSync(os.Args[2:], os.Stdout); err != nil {
+			if errors.Is(err, errSyncConflicts) {
+				os.Exit(2)
+			}
+			fmt.Fprintf(os.Stderr, "sync error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
@@ -66,8 +179,21 @@ func main() {
 			fmt.Println("Usage:")
 			fmt.Println("  sshthing            Run the TUI")
 			fmt.Println("  sshthing exec       Run one token-auth command")
+			fmt.Println("  sshthing connect    Open an SSH (or SFTP) session to a host by label")
+			fmt.Println("  sshthing ping       Check that a host answers on its SSH port")
+			fmt.Println("  sshthing keygen     Generate an SSH key pair without adding a host")
+			fmt.Println("  sshthing backup     Copy the encrypted database (or all data, as .tar.gz) to a backup file")
+			fmt.Println("  sshthing restore    Replace the database with a verified backup")
 			fmt.Println("  sshthing session    Manage local unlock session cache")
+			fmt.Println("  sshthing token      Create, list, revoke and activate automation tokens")
+			fmt.Println("  sshthing sync       Run a Git sync (or preview it with --dry-run)")
+			fmt.Println("  sshthing list       Print hosts (--format text|json|csv)")
+			fmt.Println("  sshthing export     Export hosts as JSON, an Ansible inventory or ssh config")
+			fmt.Println("  sshthing import     Import hosts from Terraform, JSON, kubeconfig or Vagrant")
+			fmt.Println("  sshthing config     Get or set a config.json value")
+			fmt.Println("  sshthing completion <bash|zsh|fish>  Print a shell completion script (see 'sshthing completion --help')")
 			fmt.Println("  sshthing --version  Print version")
+			fmt.Println("  sshthing --profile <name> ...  Use a separate profile (or set SSHTHING_PROFILE)")
 			fmt.Println("  sshthing --help     Show this help")
 			fmt.Println()
 			fmt.Println("Exec Usage:")
@@ -75,10 +201,76 @@ func main() {
 			fmt.Println("  sshthing exec -t <target_label> --auth-file <path> \"command\"")
 			fmt.Println("  sshthing exec -t <target_label> --auth-stdin \"command\"")
 			fmt.Println()
+			fmt.Println("Connect Usage:")
+			fmt.Println("  sshthing connect <label|hostname>")
+			fmt.Println("  printf 'MASTER_PASSWORD\\n' | sshthing connect web-1 --password-stdin")
+			fmt.Println("  sshthing connect web-1 --sftp")
+			fmt.Println()
+			fmt.Println("Ping Usage:")
+			fmt.Println("  sshthing ping web-1                       (exit 0 reachable, 1 unreachable)")
+			fmt.Println("  sshthing ping web-1 --count 3 --timeout 2s")
+			fmt.Println()
+			fmt.Println("Keygen Usage:")
+			fmt.Println("  sshthing keygen --output ~/.ssh/id_deploy [--type ed25519|rsa|ecdsa] [--comment \"user@host\"]")
+			fmt.Println("  sshthing keygen --type rsa --output -")
+			fmt.Println("  sshthing keygen --print-public ~/.ssh/id_deploy")
+			fmt.Println()
+			fmt.Println("Backup Usage:")
+			fmt.Println("  sshthing backup --output ~/backups/hosts.db   (default: backups/ next to the database)")
+			fmt.Println("  sshthing restore --from ~/backups/hosts.db --force")
+			fmt.Println("  sshthing backup --output ~/backups/sshthing.tar.gz   (database, config and token vault)")
+			fmt.Println("  sshthing restore --from ~/backups/sshthing.tar.gz    (asks before overwriting unless --force)")
+			fmt.Println()
 			fmt.Println("Session Usage:")
 			fmt.Println("  printf 'MASTER_PASSWORD' | sshthing session unlock --password-stdin --ttl 15m")
+			fmt.Println("  printf 'MASTER_PASSWORD' | sshthing session unlock --password-stdin --profile work")
 			fmt.Println("  sshthing session status")
 			fmt.Println("  sshthing session lock")
+			fmt.Println("  sshthing session rotate-pepper --old-pepper-file old.txt --tokens-stdin < tokens.txt")
+			fmt.Println()
+			fmt.Println("Token Usage:")
+			fmt.Println("  printf 'MASTER_PASSWORD' | sshthing token create --name deploy --host web-1 --host web-2 --password-stdin --ttl 30d --max-uses 100 [--sync] [--bind-device]")
+			fmt.Println("  sshthing token list [--json] [--include-revoked]")
+			fmt.Println("  sshthing token status --id <token_id> [--json]")
+			fmt.Println("  sshthing token revoke --id <token_id>")
+			fmt.Println("  printf 'MASTER_PASSWORD' | sshthing token activate --id <token_id> --password-stdin")
+			fmt.Println()
+			fmt.Println("List Usage:")
+			fmt.Println("  sshthing list")
+			fmt.Println("  printf 'MASTER_PASSWORD' | sshthing list --format json --unlock-stdin")
+			fmt.Println("  sshthing list --filter tag:production     (same syntax as virtual groups)")
+			fmt.Println()
+			fmt.Println("Export Usage:")
+			fmt.Println("  sshthing export --ansible                 (YAML inventory to stdout)")
+			fmt.Println("  sshthing export --ansible inventory.yml --ansible-ping")
+			fmt.Println("  sshthing export --json > hosts.json         (sorted by id; --include-keys adds private keys)")
+			fmt.Println("  sshthing export --ssh-config-live --key-dir ~/.ssh/sshthing-keys > ~/.ssh/sshthing.conf")
+			fmt.Println("    (add 'Include ~/.ssh/sshthing.conf' to ~/.ssh/config; uses the unlock session)")
+			fmt.Println("  Regenerate on a schedule (cron, every 30 minutes):")
+			fmt.Println("    */30 * * * * sshthing export --ssh-config-live --key-dir $HOME/.ssh/sshthing-keys > $HOME/.ssh/sshthing.conf.tmp && mv $HOME/.ssh/sshthing.conf.tmp $HOME/.ssh/sshthing.conf")
+			fmt.Println("  Regenerate on a schedule (launchd, ~/Library/LaunchAgents/com.sshthing.sshconfig.plist):")
+			fmt.Println("    <key>ProgramArguments</key><array><string>/bin/sh</string><string>-c</string>")
+			fmt.Println("      <string>sshthing export --ssh-config-live --key-dir $HOME/.ssh/sshthing-keys &gt; $HOME/.ssh/sshthing.conf.tmp &amp;&amp; mv $HOME/.ssh/sshthing.conf.tmp $HOME/.ssh/sshthing.conf</string></array>")
+			fmt.Println("    <key>StartInterval</key><integer>1800</integer>")
+			fmt.Println()
+			fmt.Println("Import Usage:")
+			fmt.Println("  sshthing import --terraform terraform.tfstate")
+			fmt.Println("  sshthing import --terraform terraform.tfstate --tag-filter env=prod")
+			fmt.Println("  sshthing import --json hosts.json          (output of export --json; existing labels are skipped)")
+			fmt.Println("  sshthing import --kubeconfig               (cluster API servers from ~/.kube/config)")
+			fmt.Println("  sshthing import --kubeconfig ./config --default-user ubuntu")
+			fmt.Println("  vagrant ssh-config | sshthing import --vagrant-stdin")
+			fmt.Println()
+			fmt.Println("Config Usage:")
+			fmt.Println("  sshthing config get ssh.host_key_policy   (keys are JSON paths in config.json)")
+			fmt.Println("  sshthing config get --json")
+			fmt.Println("  sshthing config set sync.repo_url git@github.com:user/hosts.git")
+			fmt.Println()
+			fmt.Println("Sync Usage:")
+			fmt.Println("  sshthing sync [--verbose]                 (exit 0 ok, 1 error, 2 conflicts)")
+			fmt.Println("  sshthing sync --pull-only | --push-only")
+			fmt.Println("  sshthing sync --dry-run")
+			fmt.Println("  printf 'MASTER_PASSWORD' | sshthing sync --dry-run --password-stdin")
 			return
 		}
 	}
@@ -103,7 +295,11 @@ func main() {
 	)
 
 	// Run the program
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if fm, ok := final.(app.Model); ok {
+		fm.Shutdown()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
 		os.Exit(1)
 	}
@@ -112,6 +308,34 @@ func main() {
 	fmt.Fprint(os.Stdout, "\x1b]111\x1b\\")
 }
 
+// extractProfileFlag removes `--profile <name>` (or `--profile=<name>`) from
+// args so it can appear before or after the subcommand. Arguments after a
+// literal "--" are left alone.
+func extractProfileFlag(args []string) (rest []string, profile string, err error) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--":
+			return append(rest, args[i:]...), profile, nil
+		case a == "--profile":
+			i++
+			if i >= len(args) {
+				return nil, "", fmt.Errorf("missing value for --profile")
+			}
+			profile = strings.TrimSpace(args[i])
+		case strings.HasPrefix(a, "--profile="):
+			profile = strings.TrimSpace(strings.TrimPrefix(a, "--profile="))
+		default:
+			rest = append(rest, a)
+			continue
+		}
+		if err := config.ValidateProfile(profile); err != nil {
+			return nil, "", err
+		}
+	}
+	return rest, profile, nil
+}
+
 func runExec(args []string) error {
 	target, token, command, authMode, err := parseExecArgs(args)
 	if err != nil {
@@ -129,6 +353,9 @@ func runExec(args []string) error {
 	if err != nil {
 		return err
 	}
+	if !resolved.AllowsCommand(command) {
+		return fmt.Errorf("command not allowed by token for %s", resolved.HostLabel)
+	}
 	tokenIdx := resolved.TokenIndex
 
 	if resolved.LegacyPayload != nil {
@@ -155,6 +382,12 @@ func runExec(args []string) error {
 			defer tempKey.Cleanup()
 		}
 		err = cmd.Run()
+		vault.RecordAudit(tokenIdx, authtoken.AuditEntry{
+			Timestamp:   time.Now().UTC(),
+			TargetLabel: target,
+			Command:     command,
+			Success:     err == nil,
+		})
 		vault.MarkUsed(tokenIdx)
 		_ = authtoken.SaveVault(vault)
 		if err == nil {
@@ -196,13 +429,7 @@ func runExec(args []string) error {
 	if cfgErr != nil {
 		cfg = config.Default()
 	}
-	term := ""
-	switch cfg.SSH.TermMode {
-	case config.TermXterm:
-		term = "xterm-256color"
-	case config.TermCustom:
-		term = strings.TrimSpace(cfg.SSH.TermCustom)
-	}
+	term := sshTerm(cfg, host)
 
 	conn := ssh.Connection{
 		Hostname:            host.Hostname,
@@ -210,14 +437,19 @@ func runExec(args []string) error {
 		Port:                host.Port,
 		PasswordBackendUnix: string(cfg.SSH.PasswordBackendUnix),
 		HostKeyPolicy:       string(cfg.SSH.HostKeyPolicy),
-		KeepAliveSeconds:    cfg.SSH.KeepAliveSeconds,
+		KeepAliveSeconds:    sshKeepAlive(cfg, host),
+		ProxyJumps:          sshJumps(store, host),
 		Term:                term,
+		ForwardAgent:        cfg.SSH.ForwardAgent,
+		IdentitiesOnly:      sshIdentitiesOnly(cfg, host),
+		Options:             host.SSHOptions,
 	}
 	if host.KeyType == "password" {
 		conn.Password = secret
 	} else {
 		conn.PrivateKey = secret
 	}
+	applyControlPath(&conn, cfg)
 
 	cmd, tempKey, err := ssh.ConnectExec(conn, command)
 	if err != nil {
@@ -229,6 +461,12 @@ func runExec(args []string) error {
 	err = cmd.Run()
 	ttl := time.Duration(cfg.Automation.SessionTTLSeconds) * time.Second
 	_ = unlock.Save(dbUnlock, ttl)
+	vault.RecordAudit(tokenIdx, authtoken.AuditEntry{
+		Timestamp:   time.Now().UTC(),
+		TargetLabel: target,
+		Command:     command,
+		Success:     err == nil,
+	})
 	vault.MarkUsed(tokenIdx)
 	_ = authtoken.SaveVault(vault)
 	if err == nil {
@@ -241,6 +479,70 @@ func runExec(args []string) error {
 	return err
 }
 
+// applyControlPath points conn at the control socket the app uses for a host,
+// so exec multiplexes through a session that is already open there.
+func applyControlPath(conn *ssh.Connection, cfg config.Config) {
+	if !cfg.SSH.ControlMasterEnabled || runtime.GOOS == "windows" {
+		return
+	}
+	dir, err := ssh.ControlSocketDir()
+	if err != nil {
+		return
+	}
+	conn.ControlMaster = true
+	conn.ControlPath = filepath.Join(dir, "%C")
+}
+
+// sshIdentitiesOnly resolves the host's IdentitiesOnly override against the
+// global setting.
+func sshIdentitiesOnly(cfg config.Config, host *db.HostModel) bool {
+	switch host.IdentitiesOnly {
+	case "on":
+		return true
+	case "off":
+		return false
+	}
+	return cfg.SSH.IdentitiesOnly
+}
+
+// sshTerm returns the host's TERM override, or the TERM for the global
+// TERM mode when it has none.
+func sshTerm(cfg config.Config, host *db.HostModel) string {
+	if t := strings.TrimSpace(host.TermOverride); t != "" {
+		return t
+	}
+	switch cfg.SSH.TermMode {
+	case config.TermXterm:
+		return "xterm-256color"
+	case config.TermCustom:
+		return strings.TrimSpace(cfg.SSH.TermCustom)
+	}
+	return ""
+}
+
+// sshKeepAlive returns the host's keepalive override, or the global setting
+// when the host has none.
+func sshKeepAlive(cfg config.Config, host *db.HostModel) int {
+	if host.KeepAliveSeconds > 0 {
+		return host.KeepAliveSeconds
+	}
+	return cfg.SSH.KeepAliveSeconds
+}
+
+// sshJumps resolves the host's jump chain, skipping jump hosts that no
+// longer exist.
+func sshJumps(store *db.Store, host *db.HostModel) []ssh.Connection {
+	var jumps []ssh.Connection
+	for _, id := range host.ProxyHosts {
+		j, err := store.GetHostByID(id)
+		if err != nil {
+			continue
+		}
+		jumps = append(jumps, ssh.Connection{Hostname: j.Hostname, Username: j.Username, Port: j.Port})
+	}
+	return jumps
+}
+
 func parseExecArgs(args []string) (target string, token string, command string, authMode string, err error) {
 	var authFile string
 	remaining := make([]string, 0)
@@ -324,7 +626,7 @@ func parseExecArgs(args []string) (target string, token string, command string,
 
 func runSession(args []string) error {
 	if len(args) == 0 {
-		return fmt.Errorf("usage: sshthing session <unlock|lock|status>")
+		return fmt.Errorf("usage: sshthing session <unlock|lock|status|rotate-pepper>")
 	}
 	switch args[0] {
 	case "lock":
@@ -379,7 +681,521 @@ func runSession(args []string) error {
 		}
 		fmt.Println("session: unlocked")
 		return nil
+	case "rotate-pepper":
+		return runRotatePepper(args[1:], os.Stdin, os.Stdout)
 	default:
 		return fmt.Errorf("unknown session command: %s", args[0])
 	}
 }
+
+// runRotatePepper re-wraps device-bound tokens for a new device pepper. The
+// raw tokens are read from stdin, one per line.
+func runRotatePepper(args []string, stdin io.Reader, w io.Writer) error {
+	oldPath, newPath := "", ""
+	readTokens := false
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--old-pepper-file":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --old-pepper-file")
+			}
+			oldPath = args[i]
+		case strings.HasPrefix(a, "--old-pepper-file="):
+			oldPath = strings.TrimPrefix(a, "--old-pepper-file=")
+		case a == "--new-pepper-file":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --new-pepper-file")
+			}
+			newPath = args[i]
+		case strings.HasPrefix(a, "--new-pepper-file="):
+			newPath = strings.TrimPrefix(a, "--new-pepper-file=")
+		case a == "--tokens-stdin":
+			readTokens = true
+		default:
+			return fmt.Errorf("unknown rotate-pepper flag: %s", a)
+		}
+	}
+	if oldPath == "" || !readTokens {
+		return fmt.Errorf("usage: sshthing session rotate-pepper --old-pepper-file PATH [--new-pepper-file PATH] --tokens-stdin")
+	}
+
+	readPepper := func(path string) ([]byte, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pepper file: %w", err)
+		}
+		p, err := securestore.DecodeDevicePepper(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pepper in %s: %w", path, err)
+		}
+		return p, nil
+	}
+	oldPepper, err := readPepper(oldPath)
+	if err != nil {
+		return err
+	}
+	var newPepper []byte
+	if newPath != "" {
+		newPepper, err = readPepper(newPath)
+	} else {
+		newPepper, err = securestore.GetOrCreateDevicePepper(rand.Reader)
+	}
+	if err != nil {
+		return err
+	}
+
+	b, err := io.ReadAll(stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read tokens from stdin: %w", err)
+	}
+	var raws []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			raws = append(raws, line)
+		}
+	}
+	if len(raws) == 0 {
+		return fmt.Errorf("no tokens on stdin")
+	}
+
+	vault, err := authtoken.LoadVault()
+	if err != nil {
+		return err
+	}
+	rotated, err := vault.RotateDevicePepper(raws, oldPepper, newPepper)
+	if err != nil {
+		return err
+	}
+	if err := authtoken.SaveVault(vault); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "rotated %d token(s) to the new device pepper\n", len(rotated))
+
+	done := make(map[string]bool, len(rotated))
+	for _, id := range rotated {
+		done[id] = true
+	}
+	for _, t := range vault.Tokens {
+		if t.UnlockBound && t.DeletedAt == nil && t.RevokedAt == nil && !done[t.TokenID] {
+			fmt.Fprintf(w, "not rotated: %s (%s); run 'sshthing token activate --id %s' to reissue it\n", t.TokenID, t.Name, t.TokenID)
+		}
+	}
+	return nil
+}
+
+// errSyncConflicts makes `sshthing sync` exit with status 2: the sync ran
+// (or would run) but some hosts changed on both sides.
+var errSyncConflicts = errors.New("conflicts detected")
+
+// syncStageNames maps the manager's stages to the steps --verbose reports.
+var syncStageNames = map[string]string{
+	"pulling":    "pull",
+	"importing":  "import",
+	"exporting":  "export",
+	"committing": "commit",
+	"pushing":    "push",
+	"notifying":  "webhook",
+}
+
+func runSync(args []string, w io.Writer) error {
+	dryRun := false
+	readStdin := false
+	verbose := false
+	pullOnly, pushOnly := false, false
+	for _, a := range args {
+		switch a {
+		case "--dry-run":
+			dryRun = true
+		case "--pull-only":
+			pullOnly = true
+		case "--push-only":
+			pushOnly = true
+		case "--password-stdin":
+			readStdin = true
+		case "--verbose", "-v":
+			verbose = true
+		default:
+			return fmt.Errorf("unknown sync flag: %s", a)
+		}
+	}
+	if pullOnly && pushOnly {
+		return fmt.Errorf("--pull-only and --push-only cannot be combined")
+	}
+
+	pw, err := readMasterPassword(readStdin, "--password-stdin")
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if !cfg.Sync.Enabled {
+		return fmt.Errorf("sync is disabled in settings")
+	}
+	// The flags override the configured mode for this run only.
+	if pullOnly || pushOnly {
+		cfg.Sync.PullOnly, cfg.Sync.PushOnly = pullOnly, pushOnly
+	}
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	defer store.Close()
+
+	mgr, err := syncpkg.NewManager(&cfg, store, pw)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		res, err := mgr.DryRun()
+		if err != nil {
+			return err
+		}
+		printDryRun(w, res)
+		if len(res.Conflicts) > 0 {
+			return errSyncConflicts
+		}
+		return nil
+	}
+
+	// A stage is complete once the next one starts.
+	current := ""
+	if verbose {
+		mgr.SetStageHook(func(stage string) {
+			if current != "" {
+				fmt.Fprintf(w, "%s: done\n", current)
+			}
+			current = syncStageNames[stage]
+		})
+	}
+	res := mgr.Sync()
+	if !res.Success {
+		return errors.New(res.Message)
+	}
+	if current != "" {
+		fmt.Fprintf(w, "%s: done\n", current)
+	}
+	fmt.Fprintf(w, "sync: %d added, %d updated, %d pushed\n", res.HostsAdded, res.HostsUpdated, res.HostsPushed)
+	if res.Warning != "" {
+		fmt.Fprintf(w, "warning: %s\n", res.Warning)
+	}
+	for _, c := range res.Conflicts {
+		fmt.Fprintf(w, "conflict: %s (id %d) kept %s\n", c.Hostname, c.HostID, c.Resolution)
+	}
+	if len(res.Conflicts) > 0 {
+		return errSyncConflicts
+	}
+	return nil
+}
+
+// readMasterPassword reads the master password from stdin when fromStdin is
+// set, and otherwise falls back to the cached unlock session. stdinFlag names
+// the flag to suggest in the error.
+func readMasterPassword(fromStdin bool, stdinFlag string) (string, error) {
+	pw := ""
+	if fromStdin {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		pw = strings.TrimSpace(string(b))
+	} else if cached, _, ok, _ := unlock.Load(); ok {
+		pw = strings.TrimSpace(cached)
+	}
+	if pw == "" {
+		return "", fmt.Errorf("no unlock session is available; run 'sshthing session unlock' or pass %s", stdinFlag)
+	}
+	return pw, nil
+}
+
+func runConnect(args []string) error {
+	label := ""
+	readStdin := false
+	useSFTP := false
+	for _, a := range args {
+		switch {
+		case a == "--password-stdin":
+			readStdin = true
+		case a == "--sftp":
+			useSFTP = true
+		case strings.HasPrefix(a, "-"):
+			return fmt.Errorf("unknown connect flag: %s", a)
+		case label != "":
+			return fmt.Errorf("expected one host label, got %q and %q", label, a)
+		default:
+			label = a
+		}
+	}
+	if strings.TrimSpace(label) == "" {
+		return fmt.Errorf("usage: sshthing connect <label|hostname> [--password-stdin] [--sftp]")
+	}
+
+	pw := ""
+	if readStdin {
+		// Only the first line is consumed so anything after it still reaches
+		// the remote session.
+		line, err := readLine(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		pw = line
+	}
+	if pw == "" {
+		var err error
+		if pw, err = readMasterPassword(false, "--password-stdin"); err != nil {
+			return err
+		}
+	}
+	if err := ssh.CheckPrereqs(); err != nil {
+		return err
+	}
+
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	defer store.Close()
+
+	host, err := store.GetHostByLabel(label)
+	if err != nil {
+		return err
+	}
+	secret, err := store.GetHostSecret(host.ID)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt host secret: %w", err)
+	}
+	cfg, cfgErr := config.Load()
+	if cfgErr != nil {
+		cfg = config.Default()
+	}
+
+	conn := ssh.Connection{
+		Hostname:            host.Hostname,
+		Username:            host.Username,
+		Port:                host.Port,
+		PasswordBackendUnix: string(cfg.SSH.PasswordBackendUnix),
+		HostKeyPolicy:       string(cfg.SSH.HostKeyPolicy),
+		KeepAliveSeconds:    sshKeepAlive(cfg, host),
+		ProxyJumps:          sshJumps(store, host),
+		Term:                sshTerm(cfg, host),
+		ForwardAgent:        cfg.SSH.ForwardAgent,
+		IdentitiesOnly:      sshIdentitiesOnly(cfg, host),
+		Options:             host.SSHOptions,
+		MaxRetries:          cfg.SSH.ConnectRetries,
+		ForwardX11:          host.ForwardX11 || cfg.SSH.ForwardX11,
+		TrustX11:            cfg.SSH.TrustX11,
+	}
+	if host.KeyType == "password" {
+		conn.Password = secret
+	} else {
+		conn.PrivateKey = secret
+	}
+	_ = store.UpdateLastConnected(host.ID)
+
+	if useSFTP {
+		var cmd *exec.Cmd
+		var tempKey *ssh.TempKeyFile
+		cmd, tempKey, err = ssh.ConnectSFTP(conn)
+		if err == nil {
+			if tempKey != nil {
+				defer tempKey.Cleanup()
+			}
+			err = cmd.Run()
+		}
+	} else {
+		err = ssh.RunSSH(conn)
+	}
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ProcessState != nil {
+		os.Exit(exitErr.ProcessState.ExitCode())
+	}
+	return err
+}
+
+// readLine reads up to the first newline one byte at a time, leaving the rest
+// of r unread.
+func readLine(r io.Reader) (string, error) {
+	var buf []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				break
+			}
+			buf = append(buf, b[0])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+func runList(args []string, w io.Writer) error {
+	format := "text"
+	filterExpr := ""
+	readStdin := false
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--filter":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --filter")
+			}
+			filterExpr = args[i]
+		case strings.HasPrefix(a, "--filter="):
+			filterExpr = strings.TrimPrefix(a, "--filter=")
+		case a == "--format":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --format")
+			}
+			format = args[i]
+		case strings.HasPrefix(a, "--format="):
+			format = strings.TrimPrefix(a, "--format=")
+		case a == "--unlock-stdin":
+			readStdin = true
+		default:
+			return fmt.Errorf("unknown list flag: %s", a)
+		}
+	}
+	switch format {
+	case "text", "json", "csv":
+	default:
+		return fmt.Errorf("unsupported format %q (use text, json or csv)", format)
+	}
+	var filter *db.GroupFilter
+	if strings.TrimSpace(filterExpr) != "" {
+		f, err := db.ParseGroupFilter(filterExpr)
+		if err != nil {
+			return fmt.Errorf("invalid --filter: %w", err)
+		}
+		filter = &f
+	}
+
+	pw, err := readMasterPassword(readStdin, "--unlock-stdin")
+	if err != nil {
+		return err
+	}
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	defer store.Close()
+
+	hosts, err := store.GetHosts()
+	if err != nil {
+		return fmt.Errorf("failed to load hosts: %w", err)
+	}
+	if filter != nil {
+		matched := hosts[:0]
+		for _, h := range hosts {
+			if filter.Matches(h.Tags, h.GroupName, h.Hostname) {
+				matched = append(matched, h)
+			}
+		}
+		hosts = matched
+	}
+	return printHosts(w, hosts, format)
+}
+
+// listedHost is the JSON shape printed by `sshthing list --format json`.
+type listedHost struct {
+	ID            int        `json:"id"`
+	Label         string     `json:"label"`
+	Hostname      string     `json:"hostname"`
+	Username      string     `json:"username"`
+	Port          int        `json:"port"`
+	KeyType       string     `json:"key_type"`
+	Group         string     `json:"group"`
+	Tags          []string   `json:"tags"`
+	LastConnected *time.Time `json:"last_connected"`
+}
+
+func printHosts(w io.Writer, hosts []db.HostModel, format string) error {
+	switch format {
+	case "json":
+		out := make([]listedHost, 0, len(hosts))
+		for _, h := range hosts {
+			tags := h.Tags
+			if tags == nil {
+				tags = []string{}
+			}
+			out = append(out, listedHost{
+				ID:            h.ID,
+				Label:         h.Label,
+				Hostname:      h.Hostname,
+				Username:      h.Username,
+				Port:          h.Port,
+				KeyType:       h.KeyType,
+				Group:         h.GroupName,
+				Tags:          tags,
+				LastConnected: h.LastConnected,
+			})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"id", "label", "hostname", "username", "port", "key_type", "group", "tags", "last_connected"})
+		for _, h := range hosts {
+			last := ""
+			if h.LastConnected != nil {
+				last = h.LastConnected.UTC().Format(time.RFC3339)
+			}
+			_ = cw.Write([]string{
+				strconv.Itoa(h.ID), h.Label, h.Hostname, h.Username, strconv.Itoa(h.Port),
+				h.KeyType, h.GroupName, strings.Join(h.Tags, " "), last,
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		for _, h := range hosts {
+			label := h.Label
+			if label == "" {
+				label = h.Hostname
+			}
+			if _, err := fmt.Fprintf(w, "%s\t%s@%s:%d\n", label, h.Username, h.Hostname, h.Port); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func printDryRun(w io.Writer, res *syncpkg.ImportResult) {
+	if len(res.Changes) == 0 {
+		fmt.Fprintln(w, "sync: nothing to import")
+		return
+	}
+	conflicted := make(map[int]bool, len(res.Conflicts))
+	for _, c := range res.Conflicts {
+		conflicted[c.HostID] = true
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tID\tHOST\tCONFLICT")
+	for _, c := range res.Changes {
+		conflict := ""
+		if conflicted[c.HostID] {
+			conflict = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", c.Action, c.HostID, c.Hostname, conflict)
+	}
+	_ = tw.Flush()
+	fmt.Fprintf(w, "\n%d to add, %d to update, %d unchanged, %d conflicts\n", res.Added, res.Updated, res.Unchanged, len(res.Conflicts))
+}
@@ -1,9 +1,20 @@
 package main
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/Vansh-Raja/SSHThing/internal/authtoken"
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+	"github.com/Vansh-Raja/SSHThing/internal/ssh"
 )
 
 func TestParseExecArgsDirect(t *testing.T) {
@@ -38,3 +49,538 @@ func TestParseExecArgsRejectsMultipleAuthSources(t *testing.T) {
 		t.Fatalf("expected error for multiple auth sources")
 	}
 }
+
+func TestExtractProfileFlag(t *testing.T) {
+	rest, profile, err := extractProfileFlag([]string{"session", "unlock", "--password-stdin", "--profile", "work"})
+	if err != nil {
+		t.Fatalf("extractProfileFlag returned error: %v", err)
+	}
+	if profile != "work" || len(rest) != 3 || rest[2] != "--password-stdin" {
+		t.Fatalf("unexpected result: %q %q", rest, profile)
+	}
+
+	rest, profile, err = extractProfileFlag([]string{"--profile=personal", "exec", "-t", "web", "--auth-stdin", "--", "echo", "--profile", "x"})
+	if err != nil {
+		t.Fatalf("extractProfileFlag returned error: %v", err)
+	}
+	if profile != "personal" || len(rest) != 8 || rest[7] != "x" {
+		t.Fatalf("expected args after -- to be kept, got %q %q", rest, profile)
+	}
+
+	if _, _, err := extractProfileFlag([]string{"--profile", "../etc"}); err == nil {
+		t.Fatalf("expected invalid profile name to be rejected")
+	}
+	if _, _, err := extractProfileFlag([]string{"--profile"}); err == nil {
+		t.Fatalf("expected missing profile value to be rejected")
+	}
+}
+
+func TestRunCompletionScripts(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var out strings.Builder
+		if err := runCompletion([]string{shell}, &out); err != nil {
+			t.Fatalf("%s: %v", shell, err)
+		}
+		for _, want := range []string{"exec", "session", "auth-stdin", "completion " + completionTargetsArg} {
+			if !strings.Contains(out.String(), want) {
+				t.Fatalf("%s script missing %q", shell, want)
+			}
+		}
+	}
+	if err := runCompletion([]string{"powershell"}, io.Discard); err == nil {
+		t.Fatalf("expected unsupported shell to fail")
+	}
+}
+
+func TestRunConfigSetAndGet(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+
+	if err := runConfig([]string{"set", "sync.repo_url", "git@github.com:user/hosts.git"}, io.Discard); err != nil {
+		t.Fatalf("set sync.repo_url: %v", err)
+	}
+	if err := runConfig([]string{"set", "ssh.host_key_policy", "strict"}, io.Discard); err != nil {
+		t.Fatalf("set ssh.host_key_policy: %v", err)
+	}
+	if err := runConfig([]string{"set", "ui.vim_mode", "false"}, io.Discard); err != nil {
+		t.Fatalf("set ui.vim_mode: %v", err)
+	}
+	for _, bad := range [][]string{
+		{"set", "ssh.host_key_policy", "sometimes"},
+		{"set", "ssh.keepalive_seconds", "9000"},
+		{"set", "ui.vim_mode", "maybe"},
+		{"set", "ssh.no_such_key", "1"},
+		{"set", "ssh", "x"},
+	} {
+		if err := runConfig(bad, io.Discard); err == nil {
+			t.Fatalf("expected %v to fail", bad)
+		}
+	}
+
+	for key, want := range map[string]string{
+		"sync.repo_url":         "git@github.com:user/hosts.git",
+		"ssh.host_key_policy":   "strict",
+		"ui.vim_mode":           "false",
+		"ssh.keepalive_seconds": "60",
+	} {
+		var out strings.Builder
+		if err := runConfig([]string{"get", key}, &out); err != nil {
+			t.Fatalf("get %s: %v", key, err)
+		}
+		if got := strings.TrimSpace(out.String()); got != want {
+			t.Fatalf("get %s = %q, want %q", key, got, want)
+		}
+	}
+
+	var out strings.Builder
+	if err := runConfig([]string{"get", "--json"}, &out); err != nil {
+		t.Fatalf("get --json: %v", err)
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(out.String()), &cfg); err != nil {
+		t.Fatalf("get --json printed invalid JSON: %v\n%s", err, out.String())
+	}
+	if cfg["sync"].(map[string]any)["repo_url"] != "git@github.com:user/hosts.git" {
+		t.Fatalf("unexpected sync section: %v", cfg["sync"])
+	}
+}
+
+func TestPrintHostsFormats(t *testing.T) {
+	hosts := []db.HostModel{{ID: 3, Label: "web", Hostname: "web.example.com", Username: "ubuntu", Port: 22, KeyType: "ed25519", GroupName: "prod", Tags: []string{"linux"}}}
+
+	var text strings.Builder
+	if err := printHosts(&text, hosts, "text"); err != nil {
+		t.Fatalf("text: %v", err)
+	}
+	if text.String() != "web\tubuntu@web.example.com:22\n" {
+		t.Fatalf("unexpected text output: %q", text.String())
+	}
+
+	var js strings.Builder
+	if err := printHosts(&js, hosts, "json"); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal([]byte(js.String()), &decoded); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0]["group"] != "prod" || decoded[0]["last_connected"] != nil {
+		t.Fatalf("unexpected json: %s", js.String())
+	}
+
+	var csvOut strings.Builder
+	if err := printHosts(&csvOut, hosts, "csv"); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	if !strings.HasPrefix(csvOut.String(), "id,label,hostname,username,port,key_type,group,tags,last_connected\n3,web,") {
+		t.Fatalf("unexpected csv: %q", csvOut.String())
+	}
+}
+
+func TestRunKeygenWritesKeyPair(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+	path := filepath.Join(t.TempDir(), "id_test")
+	var out strings.Builder
+	if err := runKeygen([]string{"--type", "ed25519", "--comment", "ci@example", "--output", path}, &out); err != nil {
+		t.Fatalf("runKeygen failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("private key not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected 0600 private key, got %v", info.Mode().Perm())
+	}
+	pub, err := os.ReadFile(path + ".pub")
+	if err != nil {
+		t.Fatalf("public key not written: %v", err)
+	}
+	if strings.TrimSpace(string(pub)) != strings.TrimSpace(out.String()) || !strings.HasSuffix(strings.TrimSpace(out.String()), "ci@example") {
+		t.Fatalf("unexpected public key output: %q", out.String())
+	}
+
+	if err := runKeygen([]string{"--output", path}, io.Discard); err == nil {
+		t.Fatalf("expected existing output to be refused")
+	}
+
+	var extracted strings.Builder
+	if err := runKeygen([]string{"--print-public", path}, &extracted); err != nil {
+		t.Fatalf("--print-public failed: %v", err)
+	}
+	if !strings.HasPrefix(extracted.String(), "ssh-ed25519 ") {
+		t.Fatalf("unexpected extracted key: %q", extracted.String())
+	}
+}
+
+func TestRunTokenLifecycle(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // device pepper fallback store
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	h := &db.HostModel{Label: "web-1", Hostname: "web.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+	if err := store.CreateHost(h, "hunter2"); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	store.Close()
+
+	pwFile := filepath.Join(t.TempDir(), "pw")
+	if err := os.WriteFile(pwFile, []byte("testpassword123\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	stdin, err := os.Open(pwFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = oldStdin }()
+
+	var out strings.Builder
+	if err := runToken([]string{"create", "--name", "deploy", "--host", "web-1", "--password-stdin", "--ttl", "30d", "--max-uses=100"}, &out); err != nil {
+		t.Fatalf("token create failed: %v", err)
+	}
+	raw := strings.TrimSpace(out.String())
+	id, _, err := authtoken.Parse(raw)
+	if err != nil {
+		t.Fatalf("expected a raw token, got %q", raw)
+	}
+
+	out.Reset()
+	if err := runToken([]string{"list", "--json"}, &out); err != nil {
+		t.Fatalf("token list failed: %v", err)
+	}
+	var listed []listedToken
+	if err := json.Unmarshal([]byte(out.String()), &listed); err != nil {
+		t.Fatalf("invalid list json: %v", err)
+	}
+	if len(listed) != 1 || listed[0].ID != id || listed[0].Status != "active" || listed[0].MaxUses != 100 || listed[0].ExpiresAt == nil {
+		t.Fatalf("unexpected token list: %+v", listed)
+	}
+	if strings.Contains(out.String(), raw) {
+		t.Fatalf("list must not print the raw token")
+	}
+
+	out.Reset()
+	if err := runToken([]string{"revoke", "--id", id}, &out); err != nil {
+		t.Fatalf("token revoke failed: %v", err)
+	}
+	out.Reset()
+	if err := runToken([]string{"list", "--json"}, &out); err != nil || strings.TrimSpace(out.String()) != "[]" {
+		t.Fatalf("expected revoked token hidden from list, got %q (%v)", out.String(), err)
+	}
+	out.Reset()
+	if err := runToken([]string{"list", "--json", "--include-revoked"}, &out); err != nil || !strings.Contains(out.String(), id) {
+		t.Fatalf("expected --include-revoked to list the token, got %q (%v)", out.String(), err)
+	}
+
+	out.Reset()
+	if err := runToken([]string{"status", "--id", id}, &out); err != nil {
+		t.Fatalf("token status failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "revoked") || !strings.Contains(out.String(), "web-1") {
+		t.Fatalf("unexpected status output:\n%s", out.String())
+	}
+
+	if err := runToken([]string{"create", "--name", "x", "--host", "web-1", "--bogus"}, io.Discard); err == nil {
+		t.Fatalf("expected unknown flag to fail")
+	}
+	if err := runToken([]string{"status", "--id", "missing"}, io.Discard); err == nil {
+		t.Fatalf("expected missing token to fail")
+	}
+}
+
+func TestRunPingReportsLastAttempt(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	oldInterval := pingInterval
+	pingInterval = 0
+	defer func() { pingInterval = oldInterval }()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = c.Write([]byte("SSH-2.0-test\r\n"))
+			c.Close()
+		}
+	}()
+	closedLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closedLn.Addr().(*net.TCPAddr).Port
+	closedLn.Close()
+
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	up := &db.HostModel{Label: "up", Hostname: "127.0.0.1", Username: "u", Port: ln.Addr().(*net.TCPAddr).Port, KeyType: "password"}
+	down := &db.HostModel{Label: "down", Hostname: "127.0.0.1", Username: "u", Port: closedPort, KeyType: "password"}
+	for _, h := range []*db.HostModel{up, down} {
+		if err := store.CreateHost(h, "pw"); err != nil {
+			t.Fatalf("CreateHost failed: %v", err)
+		}
+	}
+	store.Close()
+
+	withStdin := func() {
+		pwFile := filepath.Join(t.TempDir(), "pw")
+		if err := os.WriteFile(pwFile, []byte("testpassword123\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(pwFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		oldStdin := os.Stdin
+		os.Stdin = f
+		t.Cleanup(func() { os.Stdin = oldStdin })
+	}
+
+	withStdin()
+	var out strings.Builder
+	if err := runPing([]string{"up", "--count", "2", "--password-stdin"}, &out); err != nil {
+		t.Fatalf("expected reachable host to pass, got %v", err)
+	}
+	if n := strings.Count(out.String(), "up is reachable (latency "); n != 2 {
+		t.Fatalf("expected two reachable lines, got %q", out.String())
+	}
+
+	withStdin()
+	out.Reset()
+	if err := runPing([]string{"down", "--timeout=1s", "--password-stdin"}, &out); !errors.Is(err, errHostUnreachable) {
+		t.Fatalf("expected errHostUnreachable, got %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "down is unreachable: ") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+
+	if err := runPing([]string{"up", "--count", "0"}, io.Discard); err == nil {
+		t.Fatalf("expected --count 0 to be rejected")
+	}
+}
+
+func TestRunExportSSHConfigWritesKeys(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	priv, _, err := ssh.GenerateKey(ssh.KeyTypeEd25519, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	keyed := &db.HostModel{Label: "web", Hostname: "web.example.com", Username: "deploy", Port: 22, KeyType: "ed25519"}
+	pw := &db.HostModel{Label: "nas", Hostname: "nas.local", Username: "admin", Port: 22, KeyType: "password"}
+	if err := store.CreateHost(keyed, priv); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	if err := store.CreateHost(pw, "secret"); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	store.Close()
+
+	keyDir := filepath.Join(t.TempDir(), "keys")
+	for run := 0; run < 2; run++ {
+		pwFile := filepath.Join(t.TempDir(), "pw")
+		if err := os.WriteFile(pwFile, []byte("testpassword123\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(pwFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		oldStdin := os.Stdin
+		os.Stdin = f
+		var out strings.Builder
+		err = runExport([]string{"--ssh-config-live", "--key-dir", keyDir, "--password-stdin"}, &out)
+		os.Stdin = oldStdin
+		f.Close()
+		if err != nil {
+			t.Fatalf("export run %d failed: %v", run+1, err)
+		}
+		keyPath := filepath.Join(keyDir, ssh.ConfigKeyFileName(keyed.ID))
+		if !strings.Contains(out.String(), "Host web\n") || !strings.Contains(out.String(), "IdentityFile "+keyPath+"\n") {
+			t.Fatalf("unexpected config: %q", out.String())
+		}
+		if !strings.Contains(out.String(), "Host nas\n") {
+			t.Fatalf("expected the password host in the config: %q", out.String())
+		}
+		data, err := os.ReadFile(keyPath)
+		if err != nil || ssh.ValidatePrivateKey(string(data)) != nil {
+			t.Fatalf("expected a valid key file, err %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(keyDir, ssh.ConfigKeyFileName(pw.ID))); !os.IsNotExist(err) {
+			t.Fatalf("expected no key file for the password host")
+		}
+	}
+
+	if err := runExport([]string{"--ssh-config-live"}, io.Discard); err == nil {
+		t.Fatalf("expected --ssh-config-live without --key-dir to be rejected")
+	}
+}
+
+func TestRunExportJSONRoundTripsThroughImport(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	stdinPassword := func() {
+		pwFile := filepath.Join(t.TempDir(), "pw")
+		if err := os.WriteFile(pwFile, []byte("testpassword123\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(pwFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		oldStdin := os.Stdin
+		os.Stdin = f
+		t.Cleanup(func() { os.Stdin = oldStdin })
+	}
+
+	priv, _, err := ssh.GenerateKey(ssh.KeyTypeEd25519, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	web := &db.HostModel{Label: "web", Hostname: "web.example.com", Username: "deploy", Port: 2222, KeyType: "ed25519", GroupName: "Prod", Tags: []string{"eu"}}
+	nas := &db.HostModel{Label: "nas", Hostname: "nas.local", Username: "admin", Port: 22, KeyType: "password"}
+	if err := store.CreateHost(web, priv); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	if err := store.CreateHost(nas, "secret"); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	store.Close()
+
+	stdinPassword()
+	var plain strings.Builder
+	if err := runExport([]string{"--json", "--password-stdin"}, &plain); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if strings.Contains(plain.String(), "private_key") {
+		t.Fatalf("expected no keys without --include-keys: %s", plain.String())
+	}
+
+	stdinPassword()
+	var out strings.Builder
+	if err := runExport([]string{"--json", "--include-keys", "--password-stdin"}, &out); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out.String()), &rows); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["label"] != "web" || rows[1]["label"] != "nas" {
+		t.Fatalf("expected hosts sorted by id, got %v", rows)
+	}
+	for _, field := range []string{"id", "label", "group", "tags", "hostname", "username", "port", "key_type", "created_at", "last_connected"} {
+		if _, ok := rows[0][field]; !ok {
+			t.Fatalf("missing field %q in %v", field, rows[0])
+		}
+	}
+	if rows[0]["private_key"] != priv {
+		t.Fatalf("expected the decrypted key in the export")
+	}
+	if _, ok := rows[1]["private_key"]; ok {
+		t.Fatalf("expected no private_key for a password host")
+	}
+
+	exportFile := filepath.Join(t.TempDir(), "hosts.json")
+	if err := os.WriteFile(exportFile, []byte(out.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	stdinPassword()
+	var msg strings.Builder
+	if err := runImport([]string{"--json", exportFile, "--password-stdin"}, &msg); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if !strings.HasPrefix(msg.String(), "Imported 2 hosts") {
+		t.Fatalf("unexpected import output: %q", msg.String())
+	}
+
+	store, err = db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+	got, err := store.GetHostByLabel("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Port != 2222 || got.GroupName != "Prod" || len(got.Tags) != 1 || got.KeyType != "ed25519" {
+		t.Fatalf("unexpected imported host: %+v", got)
+	}
+	key, err := store.GetHostSecret(got.ID)
+	if err != nil || key != priv {
+		t.Fatalf("expected the key to survive the round trip, err %v", err)
+	}
+}
+
+func TestRunRotatePepperRewrapsListedTokens(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	oldPepper := []byte("0123456789abcdef0123456789abcdef")
+	newPepper := []byte("fedcba9876543210fedcba9876543210")
+	raw, rec, err := authtoken.CreateToken("deploy", []authtoken.HostGrant{{HostID: 1, DisplayLabel: "web"}}, "master-password",
+		authtoken.CreateOptions{DevicePepper: oldPepper, BindToDevice: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := authtoken.LoadVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.AddToken(raw, rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := authtoken.SaveVault(v); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	oldFile, newFile := filepath.Join(dir, "old"), filepath.Join(dir, "new")
+	if err := os.WriteFile(oldFile, []byte(base64.RawStdEncoding.EncodeToString(oldPepper)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newFile, []byte(base64.StdEncoding.EncodeToString(newPepper)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"--old-pepper-file", oldFile, "--new-pepper-file=" + newFile, "--tokens-stdin"}
+	if err := runRotatePepper(args, strings.NewReader("stk_bogus_token\n"), io.Discard); err == nil {
+		t.Fatalf("expected an unknown token to fail")
+	}
+	var out strings.Builder
+	if err := runRotatePepper(args, strings.NewReader(raw+"\n"), &out); err != nil {
+		t.Fatalf("rotate-pepper failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "rotated 1 token(s)") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+	v, err = authtoken.LoadVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Resolve(raw, "web", newPepper); err != nil {
+		t.Fatalf("expected saved token to resolve with the new pepper: %v", err)
+	}
+}
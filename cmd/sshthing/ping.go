@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+	"github.com/Vansh-Raja/SSHThing/internal/ssh"
+)
+
+// errHostUnreachable is returned by runPing when the last attempt failed. The
+// attempts have already been printed, so main only sets the exit code.
+var errHostUnreachable = errors.New("host unreachable")
+
+// pingInterval is the pause between attempts.
+var pingInterval = time.Second
+
+func runPing(args []string, w io.Writer) error {
+	label := ""
+	timeoutStr := "5s"
+	countStr := "1"
+	readStdin := false
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--timeout":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --timeout")
+			}
+			timeoutStr = args[i]
+		case strings.HasPrefix(a, "--timeout="):
+			timeoutStr = strings.TrimPrefix(a, "--timeout=")
+		case a == "--count":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("missing value for --count")
+			}
+			countStr = args[i]
+		case strings.HasPrefix(a, "--count="):
+			countStr = strings.TrimPrefix(a, "--count=")
+		case a == "--password-stdin":
+			readStdin = true
+		case strings.HasPrefix(a, "-"):
+			return fmt.Errorf("unknown ping flag: %s", a)
+		case label != "":
+			return fmt.Errorf("expected one host label, got %q and %q", label, a)
+		default:
+			label = a
+		}
+	}
+	if strings.TrimSpace(label) == "" {
+		return fmt.Errorf("usage: sshthing ping <label> [--timeout 5s] [--count 3] [--password-stdin]")
+	}
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil || timeout <= 0 {
+		return fmt.Errorf("invalid --timeout %q (use e.g. 5s)", timeoutStr)
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 1 {
+		return fmt.Errorf("invalid --count %q (use a positive number)", countStr)
+	}
+
+	pw, err := readMasterPassword(readStdin, "--password-stdin")
+	if err != nil {
+		return err
+	}
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	host, err := store.GetHostByLabel(label)
+	store.Close()
+	if err != nil {
+		return err
+	}
+
+	var last error
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			time.Sleep(pingInterval)
+		}
+		start := time.Now()
+		last = ssh.TestConnection(host.Hostname, host.Port, timeout)
+		if last != nil {
+			fmt.Fprintf(w, "%s is unreachable: %v\n", label, last)
+			continue
+		}
+		fmt.Fprintf(w, "%s is reachable (latency %dms)\n", label, time.Since(start).Milliseconds())
+	}
+	if last != nil {
+		return errHostUnreachable
+	}
+	return nil
+}
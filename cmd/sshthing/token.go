@@ -0,0 +1,402 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/authtoken"
+	"github.com/Vansh-Raja/SSHThing/internal/config"
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+	"github.com/Vansh-Raja/SSHThing/internal/securestore"
+)
+
+func runToken(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: sshthing token <create|list|revoke|activate|status>")
+	}
+	switch args[0] {
+	case "create":
+		return runTokenCreate(args[1:], w)
+	case "list":
+		return runTokenList(args[1:], w)
+	case "revoke":
+		return runTokenRevoke(args[1:], w)
+	case "activate":
+		return runTokenActivate(args[1:], w)
+	case "status":
+		return runTokenStatus(args[1:], w)
+	default:
+		return fmt.Errorf("unknown token command: %s", args[0])
+	}
+}
+
+// tokenFlags holds every flag the token subcommands accept; each subcommand
+// rejects the ones it does not use.
+type tokenFlags struct {
+	name      string
+	hosts     []string
+	id        string
+	ttl       time.Duration
+	maxUses   int
+	readStdin bool
+	json      bool
+
+	sync           bool // create: sync the definition even when the default is off
+	bindDevice     bool // create: fail instead of creating an unbound token
+	includeRevoked bool // list: show revoked tokens too
+}
+
+func parseTokenFlags(cmd string, args []string, allowed ...string) (tokenFlags, error) {
+	var f tokenFlags
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		name, value, hasValue := strings.Cut(a, "=")
+		ok := false
+		for _, n := range allowed {
+			ok = ok || n == name
+		}
+		if !ok {
+			return f, fmt.Errorf("unknown token %s flag: %s", cmd, a)
+		}
+		switch name {
+		case "--password-stdin":
+			f.readStdin = true
+			continue
+		case "--json":
+			f.json = true
+			continue
+		case "--sync":
+			f.sync = true
+			continue
+		case "--bind-device":
+			f.bindDevice = true
+			continue
+		case "--include-revoked":
+			f.includeRevoked = true
+			continue
+		}
+		if !hasValue {
+			i++
+			if i >= len(args) {
+				return f, fmt.Errorf("missing value for %s", name)
+			}
+			value = args[i]
+		}
+		value = strings.TrimSpace(value)
+		switch name {
+		case "--name":
+			f.name = value
+		case "--host":
+			f.hosts = append(f.hosts, value)
+		case "--id":
+			f.id = value
+		case "--ttl":
+			d, err := parseTTL(value)
+			if err != nil {
+				return f, err
+			}
+			f.ttl = d
+		case "--max-uses":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return f, fmt.Errorf("invalid --max-uses %q", value)
+			}
+			f.maxUses = n
+		}
+	}
+	return f, nil
+}
+
+// parseTTL accepts Go durations plus a whole-day suffix such as "30d".
+func parseTTL(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid ttl %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid ttl %q", s)
+	}
+	return d, nil
+}
+
+func runTokenCreate(args []string, w io.Writer) error {
+	f, err := parseTokenFlags("create", args, "--name", "--host", "--password-stdin", "--ttl", "--max-uses", "--sync", "--bind-device")
+	if err != nil {
+		return err
+	}
+	if f.name == "" || len(f.hosts) == 0 {
+		return fmt.Errorf("usage: sshthing token create --name NAME --host LABEL [--host LABEL] --password-stdin [--ttl 30d] [--max-uses 100] [--sync] [--bind-device]")
+	}
+	pw, err := readMasterPassword(f.readStdin, "--password-stdin")
+	if err != nil {
+		return err
+	}
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	defer store.Close()
+
+	grants := make([]authtoken.HostGrant, 0, len(f.hosts))
+	for _, label := range f.hosts {
+		h, err := store.GetHostByLabel(label)
+		if err != nil {
+			return err
+		}
+		display := h.Label
+		if strings.TrimSpace(display) == "" {
+			display = h.Hostname
+		}
+		secret, err := store.GetHostSecret(h.ID)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt secret for '%s': %w", display, err)
+		}
+		if strings.TrimSpace(secret) == "" {
+			return fmt.Errorf("host '%s' has no stored auth secret", display)
+		}
+		grants = append(grants, authtoken.HostGrant{HostID: h.ID, DisplayLabel: display})
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	pepper, pepperErr := securestore.GetOrCreateDevicePepper(rand.Reader)
+	if len(pepper) == 0 {
+		if f.bindDevice {
+			return fmt.Errorf("cannot bind token to this device: keychain unavailable: %v", pepperErr)
+		}
+		// stdout carries only the raw token, so scripts can capture it.
+		fmt.Fprintln(os.Stderr, "warning: keychain unavailable; the token is not bound to this device")
+	}
+	opts := authtoken.CreateOptions{
+		DevicePepper: pepper,
+		BindToDevice: len(pepper) > 0,
+		MaxUses:      f.maxUses,
+		SyncEnabled:  cfg.Automation.SyncTokenDefinitions || f.sync,
+	}
+	if f.ttl > 0 {
+		exp := time.Now().UTC().Add(f.ttl)
+		opts.ExpiresAt = &exp
+	}
+	raw, rec, err := authtoken.CreateToken(f.name, grants, pw, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create token: %w", err)
+	}
+	vault, err := authtoken.LoadVault()
+	if err != nil {
+		return fmt.Errorf("failed to load token vault: %w", err)
+	}
+	if err := vault.AddToken(raw, rec); err != nil {
+		return fmt.Errorf("failed to add token: %w", err)
+	}
+	if err := authtoken.SaveVault(vault); err != nil {
+		return fmt.Errorf("failed to save token vault: %w", err)
+	}
+	// The raw token is printed once and never stored.
+	_, err = fmt.Fprintln(w, raw)
+	return err
+}
+
+// listedToken is the JSON shape printed by `sshthing token list --json` and
+// `sshthing token status --json`.
+type listedToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	Hosts      []string   `json:"hosts"`
+	UseCount   int        `json:"use_count"`
+	MaxUses    int        `json:"max_uses,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Synced     bool       `json:"synced"`
+}
+
+// tokenStatus matches the scope column of the TUI token list.
+func tokenStatus(t authtoken.StoredToken) string {
+	switch {
+	case t.RevokedAt != nil:
+		return "revoked"
+	case !t.IsUsable():
+		return "inactive"
+	case t.IsLegacyPayload():
+		return "legacy"
+	default:
+		return "active"
+	}
+}
+
+func describeToken(t authtoken.StoredToken) listedToken {
+	hosts := make([]string, 0, len(t.Hosts))
+	for _, h := range t.Hosts {
+		hosts = append(hosts, h.DisplayLabel)
+	}
+	return listedToken{
+		ID:         t.TokenID,
+		Name:       t.Name,
+		Status:     tokenStatus(t),
+		Hosts:      hosts,
+		UseCount:   t.UseCount,
+		MaxUses:    t.MaxUses,
+		CreatedAt:  t.CreatedAt,
+		LastUsedAt: t.LastUsedAt,
+		ExpiresAt:  t.ExpiresAt,
+		RevokedAt:  t.RevokedAt,
+		Synced:     t.SyncEnabled,
+	}
+}
+
+func runTokenList(args []string, w io.Writer) error {
+	f, err := parseTokenFlags("list", args, "--json", "--include-revoked")
+	if err != nil {
+		return err
+	}
+	vault, err := authtoken.LoadVault()
+	if err != nil {
+		return fmt.Errorf("failed to load token vault: %w", err)
+	}
+	out := make([]listedToken, 0, len(vault.Tokens))
+	for _, t := range vault.Tokens {
+		if t.DeletedAt != nil || (t.RevokedAt != nil && !f.includeRevoked) {
+			continue
+		}
+		out = append(out, describeToken(t))
+	}
+	if f.json {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tHOSTS\tUSES\tLAST USED\tEXPIRES")
+	for _, t := range out {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n", t.ID, t.Name, t.Status, len(t.Hosts), tokenUses(t), formatTokenTime(t.LastUsedAt, "never"), formatTokenTime(t.ExpiresAt, "never"))
+	}
+	return tw.Flush()
+}
+
+func runTokenRevoke(args []string, w io.Writer) error {
+	f, err := parseTokenFlags("revoke", args, "--id")
+	if err != nil {
+		return err
+	}
+	if f.id == "" {
+		return fmt.Errorf("usage: sshthing token revoke --id TOKENID")
+	}
+	vault, err := authtoken.LoadVault()
+	if err != nil {
+		return fmt.Errorf("failed to load token vault: %w", err)
+	}
+	if !vault.RevokeToken(f.id) {
+		return fmt.Errorf("token not found")
+	}
+	if err := authtoken.SaveVault(vault); err != nil {
+		return fmt.Errorf("failed to save token vault: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "token %s revoked\n", f.id)
+	return err
+}
+
+func runTokenActivate(args []string, w io.Writer) error {
+	f, err := parseTokenFlags("activate", args, "--id", "--password-stdin")
+	if err != nil {
+		return err
+	}
+	if f.id == "" {
+		return fmt.Errorf("usage: sshthing token activate --id TOKENID --password-stdin")
+	}
+	pw, err := readMasterPassword(f.readStdin, "--password-stdin")
+	if err != nil {
+		return err
+	}
+	// Check the password before binding it into the token.
+	store, err := db.Init(pw)
+	if err != nil {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	store.Close()
+
+	vault, err := authtoken.LoadVault()
+	if err != nil {
+		return fmt.Errorf("failed to load token vault: %w", err)
+	}
+	pepper, _ := securestore.GetOrCreateDevicePepper(rand.Reader)
+	raw, err := vault.ActivateToken(f.id, pw, pepper)
+	if err != nil {
+		return err
+	}
+	if err := authtoken.SaveVault(vault); err != nil {
+		return fmt.Errorf("failed to save token vault: %w", err)
+	}
+	// Activation issues a new secret; like create, it is shown only once.
+	_, err = fmt.Fprintln(w, raw)
+	return err
+}
+
+func runTokenStatus(args []string, w io.Writer) error {
+	f, err := parseTokenFlags("status", args, "--id", "--json")
+	if err != nil {
+		return err
+	}
+	if f.id == "" {
+		return fmt.Errorf("usage: sshthing token status --id TOKENID [--json]")
+	}
+	vault, err := authtoken.LoadVault()
+	if err != nil {
+		return fmt.Errorf("failed to load token vault: %w", err)
+	}
+	for _, t := range vault.Tokens {
+		if t.TokenID != f.id || t.DeletedAt != nil {
+			continue
+		}
+		d := describeToken(t)
+		if f.json {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(d)
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "id:\t%s\n", d.ID)
+		fmt.Fprintf(tw, "name:\t%s\n", d.Name)
+		fmt.Fprintf(tw, "status:\t%s\n", d.Status)
+		fmt.Fprintf(tw, "hosts:\t%s\n", strings.Join(d.Hosts, ", "))
+		fmt.Fprintf(tw, "uses:\t%s\n", tokenUses(d))
+		fmt.Fprintf(tw, "created:\t%s\n", formatTokenTime(&d.CreatedAt, ""))
+		fmt.Fprintf(tw, "last used:\t%s\n", formatTokenTime(d.LastUsedAt, "never"))
+		fmt.Fprintf(tw, "expires:\t%s\n", formatTokenTime(d.ExpiresAt, "never"))
+		if d.RevokedAt != nil {
+			fmt.Fprintf(tw, "revoked:\t%s\n", formatTokenTime(d.RevokedAt, ""))
+		}
+		fmt.Fprintf(tw, "synced:\t%t\n", d.Synced)
+		return tw.Flush()
+	}
+	return fmt.Errorf("token not found")
+}
+
+func tokenUses(t listedToken) string {
+	if t.MaxUses > 0 {
+		return fmt.Sprintf("%d/%d", t.UseCount, t.MaxUses)
+	}
+	return strconv.Itoa(t.UseCount)
+}
+
+func formatTokenTime(t *time.Time, empty string) string {
+	if t == nil {
+		return empty
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/Vansh-Raja/SSHThing/internal/authtoken"
 	"github.com/Vansh-Raja/SSHThing/internal/config"
 	"github.com/Vansh-Raja/SSHThing/internal/db"
+	"github.com/Vansh-Raja/SSHThing/internal/metrics"
 	"github.com/Vansh-Raja/SSHThing/internal/mount"
+	"github.com/Vansh-Raja/SSHThing/internal/ssh"
 	syncpkg "github.com/Vansh-Raja/SSHThing/internal/sync"
 	"github.com/Vansh-Raja/SSHThing/internal/ui"
 	"github.com/Vansh-Raja/SSHThing/internal/update"
@@ -18,13 +20,18 @@ import (
 
 // Model represents the application state
 type Model struct {
-	store  *db.Store
-	hosts  []Host
-	groups []string
+	store        *db.Store
+	hosts        []Host
+	groups       []string
+	groupParents map[string]string // subgroup name -> parent group
+	groupColors  map[string]string // lower-cased group name -> hex list color
 
 	listItems   []ListItem
 	selectedIdx int
 	collapsed   map[string]bool
+	selectedSet map[int]bool // host IDs marked with space for bulk actions
+	sortBy      SortBy       // host order within each group; pinned hosts stay first
+	sortDesc    bool
 
 	// Navigation
 	page    int // PageHome, PageSettings, PageTokens
@@ -50,10 +57,47 @@ type Model struct {
 	setupFocus  int             // 0=password, 1=confirm, 2=submit
 	loginError  string
 
+	// First-run welcome
+	welcomeCursor int    // selected card, see welcomeCards
+	welcomeNext   string // import to open once the vault exists: "", "ssh-config" or "json"
+	welcomeHelp   bool   // help was opened from the welcome screen
+
 	// Search
 	searchQuery    string
 	spotlightItems []SpotlightItem
 
+	// Toasts for async operation results (sync, mounts, sessions, transfers)
+	toastQueue   []Toast
+	toastTicking bool
+
+	// Undo / redo of host create, edit and delete
+	undoStack []UndoEntry
+	redoStack []UndoEntry
+
+	// Copy connection string
+	copyNotice    string // transient footer notice, cleared by clearCopyNoticeMsg
+	copyNoticeSeq int
+	copyFallback  string // shown in a modal when no clipboard is available
+
+	// Public key viewer
+	keyViewHost      Host
+	keyViewPublic    string
+	keyViewCursor    int          // 0=copy, 1=download
+	keyViewPathField ui.FormField // destination for the download
+
+	// Host import wizard
+	importStep     int
+	importPath     ui.FormField
+	importFormat   string
+	importHosts    []db.HostModel
+	importConflict int // index into importConflictOptions
+	importErr      string
+	importing      bool
+
+	// Command palette (shares searchQuery with spotlight)
+	paletteCommands []CommandEntry
+	paletteCursor   int
+
 	// Add/Edit host form
 	formFields   []ui.FormField // [label, tags, hostname, port, username, authDetail]
 	formGroups   []string
@@ -62,6 +106,15 @@ type Model struct {
 	formAuthIdx  int
 	formKeyTypes []string
 	formKeyIdx   int
+	formRecOpts  []string // "default" | "on" | "off"
+	formRecIdx   int
+	formIdentIdx int // index into formRecOpts for the IdentitiesOnly override
+	formSyncExcl bool
+	formAdvanced bool // "advanced ssh options" section expanded
+	formX11      bool
+	formJumpIDs  []int    // host ID per jump option; 0 is "none"
+	formJumpOpts []string // jump option names, parallel to formJumpIDs
+	formJumpIdx  []int    // selected jump option per hop
 	formFocus    int
 	formEditing  bool
 	formEditIdx  int // -1 for add, >=0 for edit index
@@ -73,21 +126,118 @@ type Model struct {
 	groupInputValue   string
 	groupInputCursor  int
 	groupOldName      string
-	groupFocus        int // 0=input, 1=action, 2=cancel
-	groupDeleteCursor int // 0=delete, 1=cancel
+	groupParent       string // parent of the group being created; empty for top level
+	groupColor        string // list color picked in the group overlay; "" for none
+	groupFocus        int    // 0=input, 1=color, 2=action, 3=cancel
+	groupDeleteCursor int    // 0=delete, 1=cancel
 
 	// Quit overlay
 	quitCursor int // 0,1,2
 
+	// Port forward overlay
+	forwardFields [3]ui.FormField // [local port, remote host, remote port]
+	forwardFocus  int             // 0-2=fields, 3=submit
+	forwardHost   Host
+
+	// Tunnels overlay; bumping tunnelsGen stops its refresh timer
+	tunnelsCursor   int // 0..n-1=tunnels, n=new tunnel
+	tunnelsGen      int
+	tunnelsStopping int // local port being stopped, 0 when idle
+
+	// Key passphrase overlay
+	passphraseField ui.FormField
+	passphraseHost  Host
+	passphraseSFTP  bool   // resume SFTP instead of SSH after the prompt
+	keyPassphrase   []byte // consumed (and zeroed) by the next connect
+
+	// Mounts overlay (host with one or more active mounts)
+	mountsHost      Host
+	mountsCursor    int          // 0..n-1=existing mounts, n=add another mount
+	mountsPathField ui.FormField // remote path for the additional mount
+
+	// Background auto-sync timer; bumping the generation cancels older timers
+	autoSyncGen int
+
+	// Background host reachability checks; same generation scheme as auto-sync
+	pingManager *ssh.HostPingManager
+	pingGen     int
+	pinging     bool
+
+	// SSH banners being fetched for the details panel, by host ID
+	bannerFetching map[int]bool
+
+	// SSH connect retries (SSH: Connect retries); bumping sshRetryGen cancels
+	// a pending retry
+	sshRetryGen     int
+	sshRetryPending bool
+
+	// Wake-on-LAN: polls a woken host's SSH port until it answers
+	waking      bool
+	wakeHostID  int
+	wakeRunID   int
+	wakeAttempt int
+	wakeFrame   int
+
+	// Idle auto-lock
+	lastInputAt time.Time
+
+	// Unlock session cache (used by sshthing exec/list), shown in the footer
+	sessionUntil      time.Time // zero when there is no active session
+	sessionExpired    bool      // expired while the app was open
+	sessionGen        int
+	sessionRenewField ui.FormField
+	sessionRenewNext  string // "sync" or "bulk": resumed after renewing
+
+	// Sync dry-run overlay
+	syncDryRun       *syncpkg.ImportResult
+	syncDryRunCursor int
+
+	// Sync conflicts overlay: syncConflictPicks holds the side the user
+	// chose for each conflict, "" to keep what the sync picked.
+	syncConflicts        []syncpkg.SyncConflict
+	syncConflictPicks    []string
+	syncConflictCursor   int
+	syncConflictApplying bool
+
+	// Sync rollback confirmation
+	rollbackTarget syncpkg.CommitInfo
+	rollbackCursor int // 0=rollback, 1=cancel
+
+	// In-TUI SFTP file browser
+	sftpBrowser *SFTPBrowser
+
+	// Bulk actions on selectedSet
+	bulkDelete      bool // delete overlay applies to selectedSet
+	moveGroupCursor int  // index into moveGroupOptions()
+	bulkExec        *BulkExec
+
+	// Key rotation wizard opened from the edit form
+	keyRotation *KeyRotation
+
+	// Recent connections overlay
+	recentHosts  []Host
+	recentCursor int
+
 	// Armed modes
-	armedSFTP    bool
-	armedMount   bool
-	armedUnmount bool
+	armedSFTP     bool
+	armedMount    bool
+	mountReadOnly bool // read-only flag for the next mount (defaults from config)
+
+	// armedMountPath overrides the default remote path for the next
+	// spotlight mount; armedMountPathEditing is set while it is typed.
+	armedMountPath        string
+	armedMountPathEditing bool
 
 	// Mount
 	mountManager *mount.Manager
 	pendingMount *mount.PreparedMount
 
+	// Tunnels
+	tunnelManager *ssh.TunnelManager
+
+	// Prometheus endpoint; nil unless Metrics.Enabled
+	metrics *metrics.Server
+
 	// Settings
 	settingsItems     []ui.SettingsItem
 	settingsCursor    int
@@ -96,11 +246,23 @@ type Model struct {
 	settingsEditing   bool
 	settingsEditVal   string
 
+	// KDF benchmark run from settings; kdfSuggestion is its last result
+	kdfBenchmarking bool
+	kdfSuggestion   *db.KDFParams
+
+	// KDF re-key running in the background; kdfRekeyPending is an edited
+	// setting waiting for it to start
+	kdfRekeying     bool
+	kdfRekeyPending *db.KDFParams
+
 	// Tokens
 	tokenSummaries    []authtoken.TokenSummary
+	expiringTokens    []authtoken.TokenSummary // usable tokens expiring within the warning horizon
 	tokenIdx          int
 	tokenHostIdx      int
 	tokenHostPick     map[int]bool
+	tokenHostCmds     map[int]string // optional allowed-command pattern per picked host
+	tokenCmdEditing   bool
 	tokenMode         int
 	tokenNameValue    string
 	tokenRevealOpen   bool
@@ -121,6 +283,15 @@ type Model struct {
 	updateApplying bool
 	updateRunID    int
 	updateLast     *update.CheckResult
+	updateNotice   string // footer notice from the startup check; X dismisses it
+
+	// Release notes shown before applying an update
+	changelogTag     string
+	changelogLines   []ui.ChangelogLine
+	changelogErr     string
+	changelogLoading bool
+	changelogScroll  int
+	changelogCursor  int // 0=apply, 1=skip, 2=cancel
 
 	// Error
 	err    error
@@ -136,14 +307,14 @@ func NewModel() Model {
 func NewModelWithVersion(version string) Model {
 	cfg, _ := config.Load()
 
-	theme, themeIdx := ui.ThemeByName(cfg.UI.Theme)
+	theme, themeIdx := ui.ThemeFor(cfg.UI.Theme, cfg.UI.HighContrast)
 	icons, iconIdx := ui.IconSetByName(cfg.UI.IconSet)
 
 	// First-run detection
 	overlay := OverlayLogin
 	exists, _ := db.Exists()
 	if !exists {
-		overlay = OverlaySetup
+		overlay = OverlayWelcome
 	}
 
 	loginField := ui.NewMaskedField("password")
@@ -151,7 +322,7 @@ func NewModelWithVersion(version string) Model {
 	setupFields[0] = ui.NewMaskedField("password")
 	setupFields[1] = ui.NewMaskedField("confirm")
 
-	return Model{
+	m := Model{
 		cfg:            cfg,
 		cfgOriginal:    cfg,
 		hosts:          []Host{},
@@ -161,6 +332,9 @@ func NewModelWithVersion(version string) Model {
 		page:           PageHome,
 		overlay:        overlay,
 		collapsed:      map[string]bool{},
+		selectedSet:    map[int]bool{},
+		sortBy:         parseSortBy(cfg.UI.DefaultSort),
+		sortDesc:       cfg.UI.SortDesc,
 		theme:          theme,
 		themeIdx:       themeIdx,
 		icons:          icons,
@@ -169,21 +343,56 @@ func NewModelWithVersion(version string) Model {
 		setupFields:    setupFields,
 		quitCursor:     0,
 		mountManager:   mount.NewManager(),
+		tunnelManager:  ssh.NewTunnelManager(),
+		pingManager:    ssh.NewHostPingManager(),
+		bannerFetching: make(map[int]bool),
 		tokenSummaries: []authtoken.TokenSummary{},
 		tokenHostPick:  map[int]bool{},
+		tokenHostCmds:  map[int]string{},
 		tokenMode:      tokenModeList,
 		currentVersion: strings.TrimSpace(version),
 		formEditIdx:    -1,
 	}
+	if cfg.Metrics.Enabled {
+		srv, err := metrics.Start(cfg.Metrics.ListenAddr, tokenUseCounts)
+		if err != nil {
+			m.err = fmt.Errorf("\u26A0 metrics endpoint not started: %v", err)
+		}
+		m.metrics = srv
+	}
+	return m
+}
+
+// Shutdown releases background resources owned by the model, such as
+// port-forwarding tunnels. It is safe to call on a zero Model.
+func (m Model) Shutdown() {
+	if m.tunnelManager != nil {
+		m.tunnelManager.RemoveAll()
+	}
+	_ = m.metrics.Close()
 }
 
 // Init initializes the application
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(tickCmd(), tea.HideCursor)
+	return tea.Batch(tickCmd(), idleCheckCmd(), tea.HideCursor)
 }
 
-// Update handles messages and updates the model
+// Update handles messages and updates the model. Results of async
+// operations are also queued as toasts so they outlive the footer message.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	if !isAsyncResult(msg) {
+		return next, cmd
+	}
+	nm, ok := next.(Model)
+	if !ok || nm.err == nil {
+		return next, cmd
+	}
+	toastCmd := nm.pushToast(nm.err.Error())
+	return nm, tea.Batch(cmd, toastCmd)
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	prevErr := ""
 	if m.err != nil {
 		prevErr = m.err.Error()
@@ -191,6 +400,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.lastInputAt = time.Now()
 		// Global quit
 		if msg.String() == "ctrl+c" {
 			return m.requestQuit()
@@ -207,12 +417,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if !ok {
 			return nextModel, nextCmd
 		}
-		return nm, tea.Batch(nextCmd, nm.errorAutoClearCmd(prevErr))
+		bannerCmd := nm.refreshSelectedBanner()
+		return nm, tea.Batch(nextCmd, nm.errorAutoClearCmd(prevErr), bannerCmd)
 
 	case tickMsg:
 		m.tick++
+		m.publishMetrics()
 		return m, tickCmd()
 
+	case idleCheckMsg:
+		// A check delivered well after it fired means the event loop was
+		// blocked by an ssh/sftp session, which counts as activity.
+		if time.Since(msg.at) > idleCheckInterval/2 {
+			m.lastInputAt = time.Now()
+		}
+		if m.idleLockDue(time.Now()) {
+			m.lockApp()
+			m.err = fmt.Errorf("\u2139 Locked after %s of inactivity", autoSyncIntervalLabel(m.cfg.Security.IdleLockSeconds))
+		}
+		return m, idleCheckCmd()
+
 	case syncAnimTickMsg:
 		if !m.syncing || msg.runID != m.syncRunID {
 			return m, nil
@@ -243,16 +467,357 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.loadGroups()
 			m.rebuildListItems()
 			m.err = fmt.Errorf("\u2713 Sync: \u2193%d \u2191%d", msg.result.HostsPulled, msg.result.HostsPushed)
+			if msg.result.Warning != "" {
+				m.err = fmt.Errorf("\u26A0 Sync: \u2193%d \u2191%d \u2014 %s", msg.result.HostsPulled, msg.result.HostsPushed, msg.result.Warning)
+			}
+			if len(msg.result.Conflicts) > 0 && m.overlay == OverlayNone {
+				m.openSyncConflicts(msg.result.Conflicts)
+			}
 		} else {
 			m.err = fmt.Errorf("\u26A0 %s", msg.result.Message)
 		}
 		return m, m.errorAutoClearCmd(prevErr)
 
+	case autoSyncMsg:
+		if msg.gen != m.autoSyncGen {
+			return m, nil
+		}
+		next := autoSyncTickCmd(msg.gen, time.Duration(m.cfg.Automation.AutoSyncIntervalSeconds)*time.Second)
+		// Debounce: skip this tick if a sync is already running.
+		if m.syncing || m.kdfRekeying || m.store == nil || m.syncManager == nil || !m.syncManager.IsEnabled() {
+			return m, next
+		}
+		return m, tea.Batch(m.startSync(), next)
+
+	case bulkExecTickMsg:
+		if m.bulkExec == nil || !m.bulkExec.Started {
+			return m, nil
+		}
+		if done, total := m.bulkExec.Progress(); done == total {
+			return m, nil
+		}
+		return m, bulkExecTickCmd()
+
+	case bulkExecDoneMsg:
+		ok, failed := msg.run.Summary()
+		if failed > 0 {
+			m.err = fmt.Errorf("\u26A0 Bulk run finished: %d ok, %d failed", ok, failed)
+		} else {
+			m.err = fmt.Errorf("\u2713 Bulk run finished on %d hosts", ok)
+		}
+		return m, m.errorAutoClearCmd(prevErr)
+
+	case pingTickMsg:
+		if msg.gen != m.pingGen {
+			return m, nil
+		}
+		next := pingTickCmd(msg.gen, time.Duration(m.cfg.UI.PingIntervalSeconds)*time.Second)
+		if m.store == nil {
+			return m, next
+		}
+		return m, tea.Batch(m.startPing(), next)
+
+	case pingDoneMsg:
+		m.pinging = false
+		return m, nil
+
+	case bannerFetchedMsg:
+		delete(m.bannerFetching, msg.hostID)
+		m.applyFetchedBanner(msg)
+		return m, nil
+
+	case wakeSentMsg:
+		if !m.waking || msg.runID != m.wakeRunID {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.waking = false
+			m.err = fmt.Errorf("\u26A0 %v", msg.err)
+			return m, m.errorAutoClearCmd(prevErr)
+		}
+		return m, wakePollCmd(msg.runID)
+
+	case wakePollMsg:
+		if !m.waking || msg.runID != m.wakeRunID {
+			return m, nil
+		}
+		host, ok := m.hostByID(m.wakeHostID)
+		if !ok {
+			m.waking = false
+			return m, nil
+		}
+		m.wakeAttempt++
+		return m, runWakeProbeCmd(msg.runID, host.Hostname, host.Port)
+
+	case wakeProbeMsg:
+		if !m.waking || msg.runID != m.wakeRunID {
+			return m, nil
+		}
+		host, ok := m.hostByID(m.wakeHostID)
+		if !ok {
+			m.waking = false
+			return m, nil
+		}
+		if msg.err != nil {
+			if m.wakeAttempt < wakeMaxAttempts {
+				return m, wakePollCmd(msg.runID)
+			}
+			m.waking = false
+			m.err = fmt.Errorf("\u26A0 %s did not respond after %d checks", hostDisplayName(host), wakeMaxAttempts)
+			return m, m.errorAutoClearCmd(prevErr)
+		}
+		m.waking = false
+		if m.cfg.SSH.WakeAutoConnect && m.page == PageHome && m.overlay == OverlayNone {
+			return m.connectToHost(host)
+		}
+		m.err = fmt.Errorf("\u2713 %s is awake", hostDisplayName(host))
+		return m, m.errorAutoClearCmd(prevErr)
+
+	case keyRotationDeployedMsg:
+		run := msg.run
+		if run != m.keyRotation {
+			return m, nil
+		}
+		run.Busy = false
+		if msg.err != nil {
+			run.Status = ""
+			run.Err = fmt.Errorf("deploy failed: %v", msg.err)
+			return m, nil
+		}
+		run.oldPublic, run.newPrivate, run.newPublic = msg.oldPublic, msg.newPrivate, msg.newPublic
+		run.Step = rotateStepVerify
+		run.Status = "new key added to ~/.ssh/authorized_keys"
+		return m, nil
+
+	case keyRotationVerifiedMsg:
+		run := msg.run
+		if run != m.keyRotation {
+			return m, nil
+		}
+		run.Busy = false
+		if msg.err != nil {
+			run.Status = ""
+			run.Err = fmt.Errorf("login with the new key failed: %v", msg.err)
+			return m, nil
+		}
+		run.Step = rotateStepConfirm
+		run.Status = "login with the new key works"
+		return m, nil
+
+	case keyRotationRevokedMsg:
+		run := msg.run
+		if run != m.keyRotation {
+			return m, nil
+		}
+		run.Busy = false
+		run.Step = rotateStepDone
+		if msg.err != nil {
+			run.Status = "new key saved"
+			run.Err = fmt.Errorf("removing the old key failed: %v \u2014 remove it from authorized_keys by hand", msg.err)
+		} else {
+			run.Status = "new key saved and old key removed"
+		}
+		return m, nil
+
+	case keyRotationAbortedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("\u26A0 could not remove the new key from %s: %v", hostDisplayName(msg.host), msg.err)
+		} else {
+			m.err = fmt.Errorf("\u2713 Key rotation cancelled; %s is unchanged", hostDisplayName(msg.host))
+		}
+		return m, m.errorAutoClearCmd(prevErr)
+
+	case tunnelsTickMsg:
+		if msg.gen != m.tunnelsGen || m.overlay != OverlayTunnels {
+			return m, nil
+		}
+		return m, tunnelsTickCmd(msg.gen)
+
+	case tunnelStoppedMsg:
+		m.tunnelsStopping = 0
+		if msg.err != nil {
+			m.err = msg.err
+		} else {
+			m.err = fmt.Errorf("\u2713 Tunnel on localhost:%d stopped", msg.localPort)
+		}
+		return m, m.errorAutoClearCmd(prevErr)
+
+	case sessionTickMsg:
+		if msg.gen != m.sessionGen {
+			return m, nil
+		}
+		if m.refreshSession() {
+			m.err = fmt.Errorf("\u26A0 Session expired")
+		}
+		return m, sessionTickCmd(msg.gen)
+
+	case wakeAnimTickMsg:
+		if !m.waking || msg.runID != m.wakeRunID {
+			return m, nil
+		}
+		m.wakeFrame++
+		return m, wakeAnimTickCmd(msg.runID)
+
+	case syncRollbackMsg:
+		m.syncing = false
+		if msg.err != nil {
+			m.err = fmt.Errorf("\u26A0 rollback failed: %v", msg.err)
+			return m, m.errorAutoClearCmd(prevErr)
+		}
+		m.loadHosts()
+		m.loadGroups()
+		m.rebuildListItems()
+		m.err = fmt.Errorf("\u2713 Rolled back last sync: %d hosts restored", msg.result.Added+msg.result.Updated)
+		return m, m.errorAutoClearCmd(prevErr)
+
+	case importFinishedMsg:
+		m.closeImportWizard()
+		if msg.err != nil {
+			m.err = fmt.Errorf("\u26A0 import failed: %v", msg.err)
+			return m, m.errorAutoClearCmd(prevErr)
+		}
+		m.loadHosts()
+		m.loadGroups()
+		m.rebuildListItems()
+		notice := fmt.Sprintf("\u2713 Imported %d hosts", msg.summary.Added)
+		if msg.summary.Updated > 0 {
+			notice += fmt.Sprintf(", updated %d", msg.summary.Updated)
+		}
+		if msg.summary.Skipped > 0 {
+			notice += fmt.Sprintf(", skipped %d", msg.summary.Skipped)
+		}
+		if n := len(msg.summary.Warnings); n > 0 {
+			notice = fmt.Sprintf("\u26A0 %s; %d warnings, first: %s", strings.TrimPrefix(notice, "\u2713 "), n, msg.summary.Warnings[0])
+		}
+		m.err = fmt.Errorf("%s", notice)
+		return m, m.errorAutoClearCmd(prevErr)
+
+	case sftpOpenedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("\u26A0 SFTP failed for %s: %v", hostDisplayName(msg.host), msg.err)
+			return m, m.errorAutoClearCmd(prevErr)
+		}
+		m.closeSFTPBrowser()
+		m.sftpBrowser = &SFTPBrowser{
+			Host:    msg.host,
+			Session: msg.session,
+			Path:    msg.path,
+			Entries: msg.entries,
+		}
+		m.overlay = OverlaySFTPBrowser
+		m.err = nil
+		return m, nil
+
+	case sftpListMsg:
+		if m.sftpBrowser == nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.err = fmt.Errorf("\u26A0 %v", msg.err)
+			return m, m.errorAutoClearCmd(prevErr)
+		}
+		b := m.sftpBrowser
+		if msg.path != b.Path {
+			b.Cursor = 0
+		} else if b.Cursor >= len(msg.entries) {
+			b.Cursor = max(len(msg.entries)-1, 0)
+		}
+		b.Path = msg.path
+		b.Entries = msg.entries
+		m.err = nil
+		if msg.status != "" {
+			m.err = fmt.Errorf("\u2713 %s", msg.status)
+			return m, m.errorAutoClearCmd(prevErr)
+		}
+		return m, nil
+
+	case sftpTransferMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("\u26A0 %s of %s failed: %v", msg.action, msg.name, msg.err)
+			return m, m.errorAutoClearCmd(prevErr)
+		}
+		verb := "Downloaded"
+		if msg.action == "upload" {
+			verb = "Uploaded"
+		}
+		m.err = fmt.Errorf("\u2713 %s %s (%s) \u2192 %s", verb, msg.name, ui.FormatSize(msg.bytes), msg.dest)
+		if msg.action == "upload" && m.sftpBrowser != nil {
+			return m, tea.Batch(m.errorAutoClearCmd(prevErr), listSFTPCmd(m.sftpBrowser.Session, m.sftpBrowser.Path, ""))
+		}
+		return m, m.errorAutoClearCmd(prevErr)
+
+	case kdfBenchmarkMsg:
+		m.kdfBenchmarking = false
+		p := msg.params
+		m.kdfSuggestion = &p
+		m.err = fmt.Errorf("\u2139 About %dms here with time cost %d and %d MiB \u2014 set them under security", kdfBenchmarkTargetMillis, p.Time, p.Memory/1024)
+		if m.page == PageSettings {
+			m.settingsItems = m.buildSettingsItems()
+		}
+		return m, m.errorAutoClearCmd(prevErr)
+
+	case kdfRekeyedMsg:
+		m.kdfRekeying = false
+		switch {
+		case msg.err != nil && msg.upgrade:
+			m.err = fmt.Errorf("\u26A0 key derivation upgrade failed: %v", msg.err)
+		case msg.err != nil:
+			m.err = fmt.Errorf("\u26A0 key derivation change failed: %v", msg.err)
+		case msg.upgrade:
+			m.err = fmt.Errorf("\u2713 Key derivation upgraded to %s", msg.params)
+		default:
+			m.err = fmt.Errorf("\u2713 Key derivation set to %s \u2014 higher values are safer but slow down unlock", msg.params)
+		}
+		if m.page == PageSettings {
+			m.settingsItems = m.buildSettingsItems()
+		}
+		return m, m.errorAutoClearCmd(prevErr)
+
+	case syncConflictsAppliedMsg:
+		m.syncConflictApplying = false
+		m.overlay = OverlayNone
+		m.syncConflicts = nil
+		m.syncConflictPicks = nil
+		m.loadHosts()
+		m.rebuildListItems()
+		if msg.err != nil {
+			m.err = fmt.Errorf("\u26A0 Applied %d of %d conflict choices: %v", msg.applied, msg.total, msg.err)
+		} else {
+			m.err = fmt.Errorf("\u2713 Applied %d conflict choices \u2014 they will be pushed on the next sync", msg.applied)
+		}
+		return m, m.errorAutoClearCmd(prevErr)
+
+	case syncDryRunMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("\u26A0 sync dry run failed: %v", msg.err)
+			return m, m.errorAutoClearCmd(prevErr)
+		}
+		m.err = nil
+		m.syncDryRun = msg.result
+		m.syncDryRunCursor = 0
+		m.overlay = OverlaySyncDryRun
+		return m, nil
+
+	case webhookTestedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("\u26A0 webhook test failed: %v", msg.err)
+		} else {
+			m.err = fmt.Errorf("\u2713 Webhook test delivered")
+		}
+		return m, m.errorAutoClearCmd(prevErr)
+
 	case updateCheckedMsg:
 		if msg.runID != m.updateRunID {
 			return m, nil
 		}
 		m.updateChecking = false
+		if msg.background {
+			// Failures stay quiet; the next unlock tries again.
+			if msg.err == nil && msg.result != nil {
+				m.recordUpdateCheck(msg.result)
+			}
+			return m, nil
+		}
 		if msg.err != nil {
 			m.err = fmt.Errorf("\u26A0 update check failed: %v", msg.err)
 			return m, m.errorAutoClearCmd(prevErr)
@@ -266,13 +831,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.cfg.Updates.LastSeenVersion = msg.result.LatestVersion
 		m.cfg.Updates.LastSeenTag = msg.result.LatestTag
 		m.cfg.Updates.ETagLatest = msg.result.ETag
-		if msg.result.UpdateAvailable {
+		if msg.result.UpdateAvailable && msg.result.LatestTag == m.cfg.Updates.SkippedTag {
+			m.err = fmt.Errorf("\u2139 Update %s skipped", msg.result.LatestTag)
+		} else if msg.result.UpdateAvailable {
 			m.err = fmt.Errorf("\u2713 Update available: %s", msg.result.LatestTag)
 		} else {
 			m.err = fmt.Errorf("\u2713 Already on latest stable release")
 		}
 		return m, m.errorAutoClearCmd(prevErr)
 
+	case changelogFetchedMsg:
+		if m.overlay != OverlayChangelog || msg.tag != m.changelogTag {
+			return m, nil
+		}
+		m.changelogLoading = false
+		if msg.err != nil {
+			m.changelogErr = "release notes unavailable: " + msg.err.Error()
+			return m, nil
+		}
+		m.changelogLines = ui.ChangelogLines(msg.body)
+		return m, nil
+
 	case updateAppliedMsg:
 		if msg.runID != m.updateRunID {
 			return m, nil
@@ -318,12 +897,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case toastTickMsg:
+		m.toastQueue = activeToasts(m.toastQueue, time.Now())
+		if len(m.toastQueue) == 0 {
+			m.toastTicking = false
+			return m, nil
+		}
+		return m, toastTickCmd()
+
+	case clearCopyNoticeMsg:
+		if msg.seq == m.copyNoticeSeq {
+			m.copyNotice = ""
+		}
+		return m, nil
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		return m, nil
 
 	case sshFinishedMsg:
+		if m.cfg.SSH.ControlMasterEnabled {
+			cleanupControlSockets()
+		}
 		m.loadHosts()
 		m.overlay = OverlayNone
 		m.page = PageHome
@@ -338,6 +934,41 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, tea.Batch(tea.HideCursor, m.errorAutoClearCmd(prevErr))
 
+	case sshRetryMsg:
+		if m.cfg.SSH.ControlMasterEnabled {
+			cleanupControlSockets()
+		}
+		m.overlay = OverlayNone
+		m.page = PageHome
+		m.sshRetryGen++
+		m.sshRetryPending = true
+		delay := ssh.RetryDelay(0, msg.attempt)
+		remaining := m.cfg.SSH.ConnectRetries - msg.attempt + 1
+		m.err = fmt.Errorf("\u26A0 Connection to %s failed, retrying in %s (%d remaining)... esc to cancel", hostDisplayName(msg.host), delay, remaining)
+		return m, tea.Batch(tea.HideCursor, sshRetryTickCmd(m.sshRetryGen, msg.host, msg.attempt, delay))
+
+	case sshRetryTickMsg:
+		if msg.gen != m.sshRetryGen || !m.sshRetryPending {
+			return m, nil
+		}
+		m.sshRetryPending = false
+		m.err = nil
+		return m.connectToHostAttempt(msg.host, msg.attempt)
+
+	case proxyStartedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("SOCKS proxy via %s failed: %v", msg.host, msg.err)
+		} else {
+			m.err = fmt.Errorf("\u2713 SOCKS5 proxy on localhost:%d via %s", msg.port, msg.host)
+		}
+		return m, m.errorAutoClearCmd(prevErr)
+
+	case pagerFinishedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("pager exited: %v", msg.err)
+		}
+		return m, tea.Batch(tea.HideCursor, m.errorAutoClearCmd(prevErr))
+
 	case mountFinishedMsg:
 		m.overlay = OverlayNone
 		m.page = PageHome
@@ -364,19 +995,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			local := m.pendingMount.LocalPath
 			remote := m.pendingMount.RemotePath()
+			readOnly := m.pendingMount.ReadOnly
 			m.pendingMount = nil
-			m.err = fmt.Errorf("\u2713 Mounted at %s", local)
+			if readOnly {
+				m.err = fmt.Errorf("\u2713 Mounted (ro) at %s", local)
+			} else {
+				m.err = fmt.Errorf("\u2713 Mounted at %s", local)
+			}
 			if m.store != nil {
-				_ = m.store.UpsertMountState(msg.hostID, local, remote)
+				_ = m.store.UpsertMountState(msg.hostID, local, remote, readOnly)
 			}
 			return m, tea.Batch(tea.HideCursor, m.errorAutoClearCmd(prevErr))
 		case "unmount":
-			if err := m.mountManager.FinalizeUnmount(msg.hostID, msg.err); err != nil {
+			key := mount.MountKey{HostID: msg.hostID, RemotePath: msg.remote}
+			if err := m.mountManager.FinalizeUnmount(key, msg.err); err != nil {
 				m.err = err
 				return m, tea.Batch(tea.HideCursor, m.errorAutoClearCmd(prevErr))
 			}
 			if m.store != nil {
-				_ = m.store.DeleteMountState(msg.hostID)
+				_ = m.store.DeleteMountState(msg.hostID, msg.remote)
 			}
 			m.err = fmt.Errorf("\u2713 Unmounted")
 			return m, tea.Batch(tea.HideCursor, m.errorAutoClearCmd(prevErr))
@@ -392,8 +1029,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// View renders the UI
+// View renders the UI with any pending toasts drawn on top.
 func (m Model) View() string {
+	r := ui.Renderer{Theme: m.theme, Icons: m.icons, W: m.width, H: m.height, Tick: m.tick}
+	return r.OverlayToasts(m.view(), m.visibleToasts(time.Now()))
+}
+
+func (m Model) view() string {
 	r := ui.Renderer{
 		Theme: m.theme,
 		Icons: m.icons,
@@ -409,10 +1051,18 @@ func (m Model) View() string {
 	case OverlayLogin:
 		content = r.RenderLoginOverlay(ui.LoginViewParams{
 			Password: m.loginField,
+			Profile:  m.cfg.Profile,
 			Err:      m.loginError,
 		})
 		return r.WrapFull(content)
 
+	case OverlayWelcome:
+		content = r.RenderWelcomeOverlay(ui.WelcomeViewParams{
+			Cards:  welcomeCards,
+			Cursor: m.welcomeCursor,
+		})
+		return r.WrapFull(content)
+
 	case OverlaySetup:
 		content = r.RenderSetupOverlay(ui.SetupViewParams{
 			Password: m.setupFields[0],
@@ -426,36 +1076,60 @@ func (m Model) View() string {
 		content = r.RenderHelpOverlay()
 		return r.WrapFull(content)
 
+	case OverlayHostDetail:
+		content = r.RenderHostDetailOverlay(m.buildHomeViewParams())
+		return r.WrapFull(content)
+
 	case OverlaySearch:
 		content = r.RenderSearchOverlay(ui.SearchViewParams{
-			Query:        m.searchQuery,
-			Cursor:       m.selectedIdx,
-			Results:      m.buildSearchResults(),
-			ArmedSFTP:    m.armedSFTP,
-			ArmedMount:   m.armedMount,
-			ArmedUnmount: m.armedUnmount,
+			Query:      m.searchQuery,
+			Cursor:     m.selectedIdx,
+			Results:    m.buildSearchResults(),
+			ArmedSFTP:  m.armedSFTP,
+			ArmedMount: m.armedMount,
+
+			MountPath:        m.armedMountPath,
+			EditingMountPath: m.armedMountPathEditing,
 		})
 		return r.WrapFull(content)
 
 	case OverlayAddHost:
 		if m.formFields != nil {
 			content = r.RenderAddHostOverlay(ui.AddHostViewParams{
-				IsEdit:      m.formEditIdx >= 0,
-				Fields:      m.formFields,
-				Focus:       m.formFocus,
-				Editing:     m.formEditing,
-				Groups:      m.formGroups,
-				GroupIdx:    m.formGroupIdx,
-				AuthOptions: m.formAuthOpts,
-				AuthIdx:     m.formAuthIdx,
-				KeyTypes:    m.formKeyTypes,
-				KeyTypeIdx:  m.formKeyIdx,
-				Err:         m.err,
+				IsEdit:         m.formEditIdx >= 0,
+				Fields:         m.formFields,
+				Focus:          m.formFocus,
+				Editing:        m.formEditing,
+				Groups:         m.formGroups,
+				GroupIdx:       m.formGroupIdx,
+				AuthOptions:    m.formAuthOpts,
+				AuthIdx:        m.formAuthIdx,
+				KeyTypes:       m.formKeyTypes,
+				KeyTypeIdx:     m.formKeyIdx,
+				RecordOpts:     m.formRecOpts,
+				RecordIdx:      m.formRecIdx,
+				SyncExclude:    m.formSyncExcl,
+				Advanced:       m.formAdvanced,
+				ForwardX11:     m.formX11,
+				IdentitiesOpts: m.formRecOpts,
+				IdentitiesIdx:  m.formIdentIdx,
+				JumpOptions:    m.formJumpOpts,
+				JumpIdx:        m.formJumpIdx,
+				AutoLogin:      m.cfg.SSH.PasswordAutoLogin,
+				CanRotate:      m.formCanRotate(),
+				Err:            m.err,
 			})
 			return r.WrapFull(content)
 		}
 
 	case OverlayDeleteHost:
+		if m.bulkDelete {
+			content = r.RenderDeleteHostOverlay(ui.DeleteHostViewParams{
+				Count:        len(m.selectedSet),
+				DeleteCursor: m.deleteCursor,
+			})
+			return r.WrapFull(content)
+		}
 		host, ok := m.selectedHost()
 		if ok {
 			lbl := host.Label
@@ -473,8 +1147,10 @@ func (m Model) View() string {
 
 	case OverlayCreateGroup:
 		content = r.RenderCreateGroupOverlay(ui.GroupInputViewParams{
+			Parent:      m.groupParent,
 			InputValue:  m.groupInputValue,
 			InputCursor: m.groupInputCursor,
+			Color:       m.groupColor,
 			Focus:       m.groupFocus,
 			ActionLabel: "create",
 		})
@@ -484,6 +1160,7 @@ func (m Model) View() string {
 		content = r.RenderRenameGroupOverlay(ui.GroupInputViewParams{
 			InputValue:  m.groupInputValue,
 			InputCursor: m.groupInputCursor,
+			Color:       m.groupColor,
 			Focus:       m.groupFocus,
 			ActionLabel: "rename",
 		})
@@ -493,20 +1170,171 @@ func (m Model) View() string {
 		content = r.RenderDeleteGroupOverlay(ui.DeleteGroupViewParams{
 			GroupName:    m.groupOldName,
 			HostCount:    m.hostCountForGroup(m.groupOldName),
+			Subgroups:    len(m.subgroupsOf(m.groupOldName)),
 			TargetGroup:  m.findAlternateGroup(m.groupOldName),
 			DeleteCursor: m.groupDeleteCursor,
 		})
 		return r.WrapFull(content)
 
+	case OverlayForward:
+		errStr := ""
+		if m.err != nil {
+			errStr = m.err.Error()
+		}
+		content = r.RenderForwardOverlay(ui.ForwardViewParams{
+			HostLabel:  hostDisplayName(m.forwardHost),
+			LocalPort:  m.forwardFields[0],
+			RemoteHost: m.forwardFields[1],
+			RemotePort: m.forwardFields[2],
+			Focus:      m.forwardFocus,
+			Err:        errStr,
+		})
+		return r.WrapFull(content)
+
+	case OverlayKeyRotation:
+		content = r.RenderKeyRotationOverlay(m.buildKeyRotationViewParams())
+		return r.WrapFull(content)
+
+	case OverlaySessionRenew:
+		errStr := ""
+		if m.err != nil {
+			errStr = m.err.Error()
+		}
+		action := "sync"
+		if m.sessionRenewNext == "bulk" {
+			action = "run the command"
+		}
+		content = r.RenderSessionRenewOverlay(ui.SessionRenewViewParams{
+			Action: action,
+			Field:  m.sessionRenewField,
+			Err:    errStr,
+		})
+		return r.WrapFull(content)
+
+	case OverlayPassphrase:
+		errStr := ""
+		if m.err != nil {
+			errStr = m.err.Error()
+		}
+		content = r.RenderPassphraseOverlay(ui.PassphraseViewParams{
+			HostLabel: hostDisplayName(m.passphraseHost),
+			Field:     m.passphraseField,
+			Err:       errStr,
+		})
+		return r.WrapFull(content)
+
+	case OverlayTunnels:
+		content = r.RenderTunnelsOverlay(m.buildTunnelsViewParams())
+		return r.WrapFull(content)
+
+	case OverlayMounts:
+		errStr := ""
+		if m.err != nil {
+			errStr = m.err.Error()
+		}
+		var rows []ui.HomeMount
+		for _, mt := range m.mountsForHost(m.mountsHost) {
+			rows = append(rows, ui.HomeMount{LocalPath: mt.LocalPath, RemotePath: mt.RemotePath, ReadOnly: mt.ReadOnly})
+		}
+		content = r.RenderMountsOverlay(ui.MountsViewParams{
+			HostLabel: hostDisplayName(m.mountsHost),
+			Mounts:    rows,
+			PathField: m.mountsPathField,
+			ReadOnly:  m.mountReadOnly,
+			Cursor:    m.mountsCursor,
+			Err:       errStr,
+		})
+		return r.WrapFull(content)
+
+	case OverlaySyncDryRun:
+		content = r.RenderSyncDryRunOverlay(m.buildSyncDryRunViewParams())
+		return r.WrapFull(content)
+
+	case OverlaySyncConflicts:
+		content = r.RenderSyncConflictsOverlay(m.buildSyncConflictsViewParams())
+		return r.WrapFull(content)
+
+	case OverlaySyncRollback:
+		content = r.RenderSyncRollbackOverlay(ui.SyncRollbackViewParams{
+			Message: m.rollbackTarget.Message,
+			When:    m.rollbackTarget.When.Local().Format("2006-01-02 15:04"),
+			Cursor:  m.rollbackCursor,
+		})
+		return r.WrapFull(content)
+
+	case OverlaySFTPBrowser:
+		content = r.RenderSFTPBrowserOverlay(m.buildSFTPBrowserViewParams())
+		return r.WrapFull(content)
+
+	case OverlayKeyView:
+		errStr := ""
+		if m.err != nil {
+			errStr = m.err.Error()
+		}
+		content = r.RenderKeyViewModal(ui.KeyViewParams{
+			HostLabel: hostDisplayName(m.keyViewHost),
+			PublicKey: m.keyViewPublic,
+			Cursor:    m.keyViewCursor,
+			PathField: m.keyViewPathField,
+			Err:       errStr,
+		})
+		return r.WrapFull(content)
+
+	case OverlayCopyFallback:
+		content = r.RenderCopyFallbackOverlay(ui.CopyFallbackViewParams{Text: m.copyFallback})
+		return r.WrapFull(content)
+
+	case OverlayImportWizard:
+		content = r.RenderImportWizardModal(m.buildImportWizardViewParams())
+		return r.WrapFull(content)
+
+	case OverlayBulkExec:
+		content = r.RenderBulkExecOverlay(m.buildBulkExecViewParams())
+		return r.WrapFull(content)
+
+	case OverlayChangelog:
+		content = r.RenderChangelogModal(ui.ChangelogViewParams{
+			Tag:     m.changelogTag,
+			Lines:   m.changelogLines,
+			Loading: m.changelogLoading,
+			Err:     m.changelogErr,
+			Scroll:  m.changelogScroll,
+			Cursor:  m.changelogCursor,
+		})
+		return r.WrapFull(content)
+
+	case OverlayCommandPalette:
+		results, cursor := m.buildPaletteResults()
+		content = r.RenderSearchOverlay(ui.SearchViewParams{
+			Palette: true,
+			Query:   m.searchQuery,
+			Cursor:  cursor,
+			Results: results,
+		})
+		return r.WrapFull(content)
+
+	case OverlayRecent:
+		content = r.RenderSearchOverlay(ui.SearchViewParams{
+			Title:   "Recent Connections",
+			Cursor:  m.recentCursor,
+			Results: m.buildRecentResults(),
+			PadTo:   recentHostsLimit,
+		})
+		return r.WrapFull(content)
+
+	case OverlayMoveHosts:
+		content = r.RenderMoveHostsOverlay(ui.MoveHostsViewParams{
+			Count:  len(m.selectedSet),
+			Groups: m.moveGroupOptions(),
+			Cursor: m.moveGroupCursor,
+		})
+		return r.WrapFull(content)
+
 	case OverlayQuit:
 		var mountLines []string
 		if m.mountManager != nil {
 			for _, mt := range m.mountManager.ListActive() {
-				host := strings.TrimSpace(mt.Hostname)
-				if host == "" {
-					host = fmt.Sprintf("host_%d", mt.HostID)
-				}
-				mountLines = append(mountLines, fmt.Sprintf("%s \u2192 %s", host, mt.LocalPath))
+				mountLines = append(mountLines, fmt.Sprintf("%s \u2192 %s", mt.Name(), mt.LocalPath))
 			}
 		}
 		content = r.RenderQuitOverlay(ui.QuitViewParams{
@@ -569,9 +1397,10 @@ func (m Model) View() string {
 				errStr = m.err.Error()
 			}
 			content = r.RenderTokenSelectHostsOverlay(ui.TokenSelectHostsParams{
-				Hosts:  m.buildTokenHostItems(),
-				Cursor: m.tokenHostIdx,
-				Err:    errStr,
+				Hosts:   m.buildTokenHostItems(),
+				Cursor:  m.tokenHostIdx,
+				Editing: m.tokenCmdEditing,
+				Err:     errStr,
 			})
 			return r.WrapFull(content)
 		}
@@ -588,6 +1417,25 @@ func (m Model) View() string {
 	return r.WrapFull(content)
 }
 
+// subgroupsOf returns the groups nested under name, at any depth.
+func (m Model) subgroupsOf(name string) []string {
+	var out []string
+	seen := map[string]bool{strings.ToLower(name): true}
+	queue := []string{name}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, g := range m.groups {
+			if strings.EqualFold(m.groupParents[g], cur) && !seen[strings.ToLower(g)] {
+				seen[strings.ToLower(g)] = true
+				out = append(out, g)
+				queue = append(queue, g)
+			}
+		}
+	}
+	return out
+}
+
 func (m Model) findAlternateGroup(excluding string) string {
 	for _, g := range m.groups {
 		if !strings.EqualFold(g, excluding) {
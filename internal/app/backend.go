@@ -1,9 +1,16 @@
 package app
 
 import (
+	"bytes"
 	"crypto/rand"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
 	"sort"
@@ -14,12 +21,15 @@ import (
 	"github.com/Vansh-Raja/SSHThing/internal/authtoken"
 	"github.com/Vansh-Raja/SSHThing/internal/config"
 	"github.com/Vansh-Raja/SSHThing/internal/db"
+	"github.com/Vansh-Raja/SSHThing/internal/metrics"
 	"github.com/Vansh-Raja/SSHThing/internal/mount"
 	"github.com/Vansh-Raja/SSHThing/internal/securestore"
 	"github.com/Vansh-Raja/SSHThing/internal/ssh"
 	syncpkg "github.com/Vansh-Raja/SSHThing/internal/sync"
 	"github.com/Vansh-Raja/SSHThing/internal/ui"
+	"github.com/Vansh-Raja/SSHThing/internal/unlock"
 	"github.com/Vansh-Raja/SSHThing/internal/update"
+	"github.com/Vansh-Raja/SSHThing/internal/wol"
 	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -37,6 +47,9 @@ func (m *Model) loadHosts() {
 		return
 	}
 
+	fingerprints, _ := m.store.GetHostFingerprints()
+	banners, _ := m.store.GetHostBanners()
+
 	m.hosts = make([]Host, len(dbHosts))
 	for i, h := range dbHosts {
 		hasKey := h.KeyData != ""
@@ -45,17 +58,45 @@ func (m *Model) loadHosts() {
 		}
 		label := strings.TrimSpace(h.Label)
 		m.hosts[i] = Host{
-			ID:            h.ID,
-			Label:         label,
-			GroupName:     strings.TrimSpace(h.GroupName),
-			Tags:          append([]string(nil), h.Tags...),
-			Hostname:      h.Hostname,
-			Username:      h.Username,
-			Port:          h.Port,
-			HasKey:        hasKey,
-			KeyType:       h.KeyType,
-			CreatedAt:     h.CreatedAt,
-			LastConnected: h.LastConnected,
+			ID:               h.ID,
+			Label:            label,
+			GroupName:        strings.TrimSpace(h.GroupName),
+			Tags:             append([]string(nil), h.Tags...),
+			Hostname:         h.Hostname,
+			Username:         h.Username,
+			Port:             h.Port,
+			HasKey:           hasKey,
+			KeyType:          h.KeyType,
+			Recording:        h.Recording,
+			SyncExclude:      h.SyncExclude,
+			SSHOptions:       h.SSHOptions,
+			Pinned:           h.Pinned,
+			MACAddress:       h.MACAddress,
+			BroadcastAddr:    h.BroadcastAddr,
+			KeepAliveSeconds: h.KeepAliveSeconds,
+			ForwardX11:       h.ForwardX11,
+			ProxyHosts:       h.ProxyHosts,
+			TermOverride:     h.TermOverride,
+			Icon:             h.Icon,
+			IdentitiesOnly:   h.IdentitiesOnly,
+			ColorOverride:    h.ColorOverride,
+			CreatedAt:        h.CreatedAt,
+			LastConnected:    h.LastConnected,
+		}
+		if fp, ok := fingerprints[h.ID]; ok {
+			m.hosts[i].Fingerprint = fp.Fingerprint
+			m.hosts[i].FingerprintChanged = fp.Changed()
+		}
+		if b, ok := banners[h.ID]; ok {
+			m.hosts[i].Banner = b.Banner
+			m.hosts[i].BannerCheckedAt = b.CheckedAt
+		}
+	}
+
+	// Drop selections for hosts that no longer exist.
+	for id := range m.selectedSet {
+		if _, ok := m.hostByID(id); !ok {
+			delete(m.selectedSet, id)
 		}
 	}
 
@@ -64,6 +105,629 @@ func (m *Model) loadHosts() {
 	m.syncTokenLabelsWithHosts()
 }
 
+// togglePinned pins or unpins host and keeps the cursor on it as it moves.
+func (m Model) togglePinned(host Host) Model {
+	if m.store == nil {
+		return m
+	}
+	if err := m.store.SetHostPinned(host.ID, !host.Pinned); err != nil {
+		m.err = fmt.Errorf("failed to update host: %v", err)
+		return m
+	}
+	m.loadHosts()
+	for i, it := range m.listItems {
+		if it.Kind == ListItemHost && it.Host.ID == host.ID {
+			m.selectedIdx = i
+			break
+		}
+	}
+	if host.Pinned {
+		m.err = fmt.Errorf("\u2713 Unpinned %s", hostDisplayName(host))
+	} else {
+		m.err = fmt.Errorf("\u2713 Pinned %s", hostDisplayName(host))
+	}
+	return m
+}
+
+func (m Model) hostByID(id int) (Host, bool) {
+	for _, h := range m.hosts {
+		if h.ID == id {
+			return h, true
+		}
+	}
+	return Host{}, false
+}
+
+// ── Bulk actions ──────────────────────────────────────────────────────
+
+// selectedHosts returns the hosts in selectedSet, in list order.
+func (m Model) selectedHosts() []Host {
+	var hosts []Host
+	for _, h := range m.hosts {
+		if m.selectedSet[h.ID] {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+func (m Model) selectedIDs() []int {
+	var ids []int
+	for _, h := range m.selectedHosts() {
+		ids = append(ids, h.ID)
+	}
+	return ids
+}
+
+// toggleHostSelection marks or unmarks the host under the cursor.
+func (m *Model) toggleHostSelection() {
+	host, ok := m.selectedHost()
+	if !ok {
+		return
+	}
+	if m.selectedSet == nil {
+		m.selectedSet = map[int]bool{}
+	}
+	if m.selectedSet[host.ID] {
+		delete(m.selectedSet, host.ID)
+	} else {
+		m.selectedSet[host.ID] = true
+	}
+}
+
+// moveGroupOptions lists the move-to-group targets; index 0 is Ungrouped.
+func (m Model) moveGroupOptions() []string {
+	return append([]string{"Ungrouped"}, m.groups...)
+}
+
+func (m Model) bulkDeleteSelected() Model {
+	ids := m.selectedIDs()
+	if err := m.store.BulkDeleteHosts(ids); err != nil {
+		m.err = err
+		return m
+	}
+	m.selectedSet = map[int]bool{}
+	m.err = fmt.Errorf("\u2713 Deleted %d hosts", len(ids))
+	m.loadHosts()
+	if m.selectedIdx >= len(m.listItems) && len(m.listItems) > 0 {
+		m.selectedIdx = len(m.listItems) - 1
+	}
+	return m
+}
+
+func (m Model) moveSelectedToGroup(group string) Model {
+	ids := m.selectedIDs()
+	if strings.EqualFold(group, "Ungrouped") {
+		group = ""
+	}
+	if err := m.store.MoveHostsToGroup(ids, group); err != nil {
+		m.err = err
+		return m
+	}
+	m.selectedSet = map[int]bool{}
+	target := group
+	if target == "" {
+		target = "Ungrouped"
+	}
+	m.err = fmt.Errorf("\u2713 Moved %d hosts to %s", len(ids), target)
+	m.loadHosts()
+	return m
+}
+
+// openBulkExec opens the prompt for a command to run on every marked host.
+func (m Model) openBulkExec() Model {
+	run := &BulkExec{Field: ui.NewFormField("command")}
+	for _, h := range m.selectedHosts() {
+		run.Hosts = append(run.Hosts, &BulkExecHost{Host: h})
+	}
+	m.bulkExec = run
+	m.overlay = OverlayBulkExec
+	m.err = nil
+	return m
+}
+
+// startBulkExec prepares a connection per host and starts the command on all
+// of them in the background.
+func (m *Model) startBulkExec() tea.Cmd {
+	run := m.bulkExec
+	command := strings.TrimSpace(run.Field.Value)
+	if command == "" {
+		m.err = fmt.Errorf("enter a command to run")
+		return nil
+	}
+
+	jobs := make([]bulkExecJob, 0, len(run.Hosts))
+	for _, h := range run.Hosts {
+		conn, privateKey, _ := m.buildSSHConn(h.Host)
+		if privateKey != "" && ssh.IsPassphraseProtected(privateKey) {
+			h.Done = true
+			h.Err = fmt.Errorf("key has a passphrase; connect to this host directly")
+			h.ExitCode = -1
+			continue
+		}
+		// The TUI owns the terminal, so ssh must not stop to prompt.
+		if conn.Password == "" {
+			opts := map[string]string{"BatchMode": "yes"}
+			for k, v := range conn.Options {
+				opts[k] = v
+			}
+			conn.Options = opts
+		}
+		m.applyControlMaster(&conn, h.Host)
+		jobs = append(jobs, bulkExecJob{host: h, conn: conn})
+	}
+	run.Started = true
+	m.err = nil
+	return tea.Batch(runBulkExecCmd(run, jobs, command, m.cfg.SSH.BulkExecConcurrency), bulkExecTickCmd())
+}
+
+// openKeyRotation closes the edit form and opens the key rotation wizard for
+// host, which must have a stored private key without a passphrase.
+func (m Model) openKeyRotation(host Host) Model {
+	conn, privateKey, _ := m.buildSSHConn(host)
+	if privateKey == "" {
+		m.err = fmt.Errorf("\u26A0 %s has no usable stored key", hostDisplayName(host))
+		return m
+	}
+	if ssh.IsPassphraseProtected(privateKey) {
+		m.err = fmt.Errorf("\u26A0 key has a passphrase; rotation needs an unencrypted key")
+		return m
+	}
+	run := &KeyRotation{Host: host, oldConn: conn}
+	for i, kt := range rotateKeyTypes {
+		if kt == host.KeyType {
+			run.KeyIdx = i
+		}
+	}
+	m.keyRotation = run
+	m.formFields = nil
+	m.formEditing = false
+	m.overlay = OverlayKeyRotation
+	m.err = nil
+	return m
+}
+
+// formCanRotate reports whether the edit form's host has a key to rotate.
+func (m Model) formCanRotate() bool {
+	if m.formEditIdx < 0 {
+		return false
+	}
+	host, ok := m.selectedHost()
+	return ok && host.HasKey && host.KeyType != "password"
+}
+
+// newKeyConn is the rotation's connection with the new key in place of the
+// old one.
+func (r *KeyRotation) newKeyConn() ssh.Connection {
+	conn := r.oldConn
+	conn.PrivateKey = r.newPrivate
+	return conn
+}
+
+// saveRotatedKey replaces the host's stored key with the verified new one in
+// a single update.
+func (m *Model) saveRotatedKey(run *KeyRotation) error {
+	host, err := m.store.GetHostByID(run.Host.ID)
+	if err != nil {
+		return err
+	}
+	host.KeyType = rotateKeyTypes[run.KeyIdx]
+	if err := m.store.UpdateHostWithKey(host, run.newPrivate); err != nil {
+		return err
+	}
+	m.loadHosts()
+	m.rebuildListItems()
+	return nil
+}
+
+func (m Model) buildKeyRotationViewParams() ui.KeyRotationViewParams {
+	run := m.keyRotation
+	p := ui.KeyRotationViewParams{
+		HostLabel: hostDisplayName(run.Host),
+		Step:      run.Step,
+		KeyTypes:  rotateKeyTypes,
+		KeyIdx:    run.KeyIdx,
+		Busy:      run.Busy,
+		Status:    run.Status,
+	}
+	if run.Err != nil {
+		p.Err = run.Err.Error()
+	}
+	return p
+}
+
+// exportSelected copies the selected hosts to the clipboard as OpenSSH
+// config blocks. Secrets are never included.
+func (m Model) exportSelected() Model {
+	hosts := m.selectedHosts()
+	if err := clipboard.WriteAll(sshConfigBlocks(hosts)); err != nil {
+		m.err = fmt.Errorf("\u26A0 clipboard unavailable: %v", err)
+		return m
+	}
+	m.err = fmt.Errorf("\u2713 Copied %d hosts to clipboard as ssh config", len(hosts))
+	return m
+}
+
+// ── Toasts ────────────────────────────────────────────────────────────
+
+const (
+	maxVisibleToasts = 3
+	maxQueuedToasts  = 10
+	toastFadeWindow  = 750 * time.Millisecond
+)
+
+// isAsyncResult reports whether msg carries the outcome of a background
+// operation that should also be announced as a toast.
+func isAsyncResult(msg tea.Msg) bool {
+	switch msg.(type) {
+	case syncFinishedMsg, syncRollbackMsg, syncConflictsAppliedMsg, webhookTestedMsg, kdfRekeyedMsg, sftpTransferMsg, sshFinishedMsg, proxyStartedMsg, mountFinishedMsg, importFinishedMsg:
+		return true
+	}
+	return false
+}
+
+func toastLifetime(kind footerNoticeKind) time.Duration {
+	switch kind {
+	case noticeSuccess:
+		return 4 * time.Second
+	case noticeInfo:
+		return 5 * time.Second
+	}
+	return 8 * time.Second
+}
+
+// pushToast queues message and starts the drain tick if it is not running.
+func (m *Model) pushToast(message string) tea.Cmd {
+	kind := noticeKindOf(message)
+	m.toastQueue = append(m.toastQueue, Toast{
+		Message:   message,
+		Kind:      kind,
+		ExpiresAt: time.Now().Add(toastLifetime(kind)),
+	})
+	if len(m.toastQueue) > maxQueuedToasts {
+		m.toastQueue = m.toastQueue[len(m.toastQueue)-maxQueuedToasts:]
+	}
+	if m.toastTicking {
+		return nil
+	}
+	m.toastTicking = true
+	return toastTickCmd()
+}
+
+// activeToasts drops toasts that have expired by now.
+func activeToasts(queue []Toast, now time.Time) []Toast {
+	var out []Toast
+	for _, t := range queue {
+		if t.ExpiresAt.After(now) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// visibleToasts returns the newest toasts that fit on screen, newest first.
+// Toasts close to expiry are marked so they render dimmed.
+func (m Model) visibleToasts(now time.Time) []ui.ToastItem {
+	var out []ui.ToastItem
+	for i := len(m.toastQueue) - 1; i >= 0 && len(out) < maxVisibleToasts; i-- {
+		t := m.toastQueue[i]
+		if !t.ExpiresAt.After(now) {
+			continue
+		}
+		out = append(out, ui.ToastItem{Message: t.Message, Fading: t.ExpiresAt.Sub(now) < toastFadeWindow})
+	}
+	return out
+}
+
+// ── Undo / redo ───────────────────────────────────────────────────────
+
+// undoLimit caps how many host mutations can be undone.
+const undoLimit = 20
+
+// recordUndo pushes a host mutation onto the undo stack and clears redo.
+// before is the snapshot taken prior to the change; afterID (0 for deletes)
+// is re-read so the entry holds what the store actually saved.
+func (m *Model) recordUndo(kind string, before *db.HostModel, afterID int) {
+	if m.store == nil {
+		return
+	}
+	entry := UndoEntry{Kind: kind, Before: before}
+	if afterID != 0 {
+		after, err := m.store.GetHostByID(afterID)
+		if err != nil {
+			return
+		}
+		entry.After = after
+	}
+	if (kind != "create" && entry.Before == nil) || (kind != "delete" && entry.After == nil) {
+		return
+	}
+	m.undoStack = append(m.undoStack, entry)
+	if len(m.undoStack) > undoLimit {
+		m.undoStack = m.undoStack[len(m.undoStack)-undoLimit:]
+	}
+	m.redoStack = nil
+}
+
+// undoLast reverses the most recent host mutation.
+func (m Model) undoLast() Model {
+	if m.store == nil || m.rekeyBusy() {
+		return m
+	}
+	if len(m.undoStack) == 0 {
+		m.err = fmt.Errorf("\u2139 nothing to undo")
+		return m
+	}
+	entry := m.undoStack[len(m.undoStack)-1]
+	var err error
+	switch entry.Kind {
+	case "create":
+		err = m.store.DeleteHost(entry.After.ID)
+	case "edit":
+		err = m.restoreHostSnapshot(entry.Before, false)
+	case "delete":
+		err = m.restoreHostSnapshot(entry.Before, true)
+	}
+	if err != nil {
+		m.err = fmt.Errorf("undo failed: %v", err)
+		return m
+	}
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+	m.redoStack = append(m.redoStack, entry)
+	m.err = fmt.Errorf("\u21A9 Undid: %s %s", undoVerb(entry.Kind), undoHostname(entry))
+	m.reloadAfterUndo()
+	return m
+}
+
+// redoLast re-applies the most recently undone host mutation.
+func (m Model) redoLast() Model {
+	if m.store == nil || m.rekeyBusy() {
+		return m
+	}
+	if len(m.redoStack) == 0 {
+		m.err = fmt.Errorf("\u2139 nothing to redo")
+		return m
+	}
+	entry := m.redoStack[len(m.redoStack)-1]
+	var err error
+	switch entry.Kind {
+	case "create":
+		err = m.restoreHostSnapshot(entry.After, true)
+	case "edit":
+		err = m.restoreHostSnapshot(entry.After, false)
+	case "delete":
+		err = m.store.DeleteHost(entry.Before.ID)
+	}
+	if err != nil {
+		m.err = fmt.Errorf("redo failed: %v", err)
+		return m
+	}
+	m.redoStack = m.redoStack[:len(m.redoStack)-1]
+	m.undoStack = append(m.undoStack, entry)
+	m.err = fmt.Errorf("\u21AA Redid: %s %s", undoVerb(entry.Kind), undoHostname(entry))
+	m.reloadAfterUndo()
+	return m
+}
+
+// restoreHostSnapshot writes h back to the store, re-inserting it under its
+// original ID when recreate is set.
+func (m Model) restoreHostSnapshot(h *db.HostModel, recreate bool) error {
+	if h.GroupName != "" {
+		if err := m.store.UpsertGroup(h.GroupName); err != nil {
+			return err
+		}
+	}
+	if recreate {
+		return m.store.CreateHostWithID(h, h.KeyData)
+	}
+	return m.store.RestoreHost(h)
+}
+
+func (m *Model) reloadAfterUndo() {
+	m.loadHosts()
+	m.loadGroups()
+	m.rebuildListItems()
+	if m.selectedIdx >= len(m.listItems) && len(m.listItems) > 0 {
+		m.selectedIdx = len(m.listItems) - 1
+	}
+}
+
+func undoVerb(kind string) string {
+	switch kind {
+	case "create":
+		return "added"
+	case "edit":
+		return "edited"
+	default:
+		return "deleted"
+	}
+}
+
+func undoHostname(e UndoEntry) string {
+	if e.After != nil {
+		return e.After.Hostname
+	}
+	return e.Before.Hostname
+}
+
+// copyNoticeDuration is how long the "copied" footer notice stays visible.
+const copyNoticeDuration = 3 * time.Second
+
+// copyConnectionString copies an ssh command line for host to the clipboard,
+// falling back to a modal when no clipboard tool is available.
+func (m Model) copyConnectionString(host Host) (Model, tea.Cmd) {
+	line := connectionString(host)
+	if err := clipboard.WriteAll(line); err != nil {
+		m.copyFallback = line
+		m.overlay = OverlayCopyFallback
+		return m, nil
+	}
+	m.copyNoticeSeq++
+	seq := m.copyNoticeSeq
+	m.copyNotice = "\u2713 Copied to clipboard"
+	return m, tea.Tick(copyNoticeDuration, func(time.Time) tea.Msg {
+		return clearCopyNoticeMsg{seq: seq}
+	})
+}
+
+// connectionString formats host as an `ssh user@host -p port` command. Keys
+// live in the encrypted store rather than on disk, so key-based hosts get a
+// comment saying so instead of an -i path.
+func connectionString(h Host) string {
+	line := "ssh " + h.Username + "@" + h.Hostname
+	if h.Port != 0 && h.Port != 22 {
+		line += fmt.Sprintf(" -p %d", h.Port)
+	}
+	if h.HasKey && h.KeyType != "password" {
+		line += fmt.Sprintf("  # %s key stored in sshthing", h.KeyType)
+	}
+	return line
+}
+
+// openKeyView derives the public half of host's stored key and shows it in
+// the key viewer.
+func (m Model) openKeyView(host Host) Model {
+	if !host.HasKey || host.KeyType == "password" || m.store == nil {
+		m.err = fmt.Errorf("\u26A0 %s has no stored key", hostDisplayName(host))
+		return m
+	}
+	privateKey, err := m.store.GetHostSecret(host.ID)
+	if err != nil {
+		m.err = fmt.Errorf("\u26A0 failed to decrypt key: %v", err)
+		return m
+	}
+	if ssh.IsPassphraseProtected(privateKey) {
+		m.err = fmt.Errorf("\u26A0 key has a passphrase; its public key can't be derived here")
+		return m
+	}
+	pub, err := ssh.GetPublicKeyFromPrivate(privateKey)
+	if err != nil {
+		m.err = fmt.Errorf("\u26A0 %v", err)
+		return m
+	}
+	m.keyViewHost = host
+	m.keyViewPublic = strings.TrimSpace(pub)
+	m.keyViewCursor = 0
+	m.keyViewPathField = ui.NewFormField("path")
+	m.keyViewPathField.SetValue(filepath.Join("~", ".ssh", ssh.ConfigKeyFileName(host.ID)+".pub"))
+	m.overlay = OverlayKeyView
+	m.err = nil
+	return m
+}
+
+// copyPublicKey copies the viewed public key, falling back to the manual
+// copy modal when no clipboard tool is available.
+func (m Model) copyPublicKey() Model {
+	if err := clipboard.WriteAll(m.keyViewPublic); err != nil {
+		m.copyFallback = m.keyViewPublic
+		m.overlay = OverlayCopyFallback
+		return m
+	}
+	m.err = fmt.Errorf("\u2713 Public key copied to clipboard")
+	return m
+}
+
+// downloadPublicKey writes the viewed public key to the path field. An
+// existing file is left alone.
+func (m Model) downloadPublicKey() Model {
+	path := expandHome(strings.TrimSpace(m.keyViewPathField.Value))
+	if path == "" {
+		m.err = fmt.Errorf("\u26A0 enter a path to save the public key")
+		return m
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		m.err = fmt.Errorf("\u26A0 %v", err)
+		return m
+	}
+	_, err = f.WriteString(m.keyViewPublic + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		m.err = fmt.Errorf("\u26A0 %v", err)
+		return m
+	}
+	m.err = fmt.Errorf("\u2713 Public key saved to %s", path)
+	return m
+}
+
+// footerNotice returns the notice shown above the home footer, preferring
+// the transient copy confirmation over persistent warnings.
+func (m Model) footerNotice() string {
+	if m.copyNotice != "" {
+		return m.copyNotice
+	}
+	if m.sessionExpired {
+		return "\u26A0 Session expired \u2014 sshthing exec and list need a new unlock"
+	}
+	if m.cfg.SSH.ForwardAgent && ssh.AgentSocketPath() == "" {
+		return "\u26A0 No SSH agent socket found; agent forwarding disabled"
+	}
+	if notice := m.expiringTokensNotice(); notice != "" {
+		return notice
+	}
+	return m.updateNotice
+}
+
+// updateCheckInterval is how long the startup update check waits between
+// checks.
+const updateCheckInterval = 24 * time.Hour
+
+// scheduleUpdateCheck starts a background update check after unlock unless
+// one ran within updateCheckInterval. Dev builds are never checked.
+func (m *Model) scheduleUpdateCheck() tea.Cmd {
+	if v := strings.TrimSpace(m.currentVersion); v == "" || strings.EqualFold(v, "dev") || m.updateChecking {
+		return nil
+	}
+	if last, err := time.Parse(time.RFC3339, m.cfg.Updates.LastCheckedAt); err == nil && time.Since(last) < updateCheckInterval {
+		return nil
+	}
+	m.updateRunID++
+	m.updateChecking = true
+	return runBackgroundUpdateCheckCmd(m.updateRunID, m.currentVersion, m.cfg)
+}
+
+// recordUpdateCheck saves a startup check's result so the next one is
+// throttled, and raises the footer notice when a release is waiting.
+func (m *Model) recordUpdateCheck(result *update.CheckResult) {
+	m.updateLast = result
+	m.cfg.Updates.LastCheckedAt = result.CheckedAt.Format(time.RFC3339)
+	m.cfg.Updates.LastSeenVersion = result.LatestVersion
+	m.cfg.Updates.LastSeenTag = result.LatestTag
+	m.cfg.Updates.ETagLatest = result.ETag
+	m.cfgOriginal.Updates = m.cfg.Updates
+	_ = config.Save(m.cfg)
+	if result.UpdateAvailable && result.LatestTag != m.cfg.Updates.SkippedTag {
+		m.updateNotice = fmt.Sprintf("\U0001F4A1 Update available: %s \u2192 [,] Settings  [X] dismiss", result.LatestTag)
+	}
+}
+
+// sshConfigBlocks renders hosts as ~/.ssh/config "Host" entries.
+func sshConfigBlocks(hosts []Host) string {
+	var b strings.Builder
+	for i, h := range hosts {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		alias := strings.Join(strings.Fields(hostDisplayName(h)), "-")
+		fmt.Fprintf(&b, "Host %s\n", alias)
+		fmt.Fprintf(&b, "    HostName %s\n", h.Hostname)
+		if h.Username != "" {
+			fmt.Fprintf(&b, "    User %s\n", h.Username)
+		}
+		if h.Port != 0 && h.Port != 22 {
+			fmt.Fprintf(&b, "    Port %d\n", h.Port)
+		}
+		for _, line := range strings.Split(ssh.FormatOptions(h.SSHOptions), "\n") {
+			if k, v, ok := strings.Cut(line, "="); ok {
+				fmt.Fprintf(&b, "    %s %s\n", k, v)
+			}
+		}
+	}
+	return b.String()
+}
+
 func (m *Model) loadGroups() {
 	if m.store == nil {
 		return
@@ -73,7 +737,45 @@ func (m *Model) loadGroups() {
 		m.err = err
 		return
 	}
+	parents, err := m.store.GetGroupParents()
+	if err != nil {
+		m.err = err
+		return
+	}
+	colors, err := m.store.GetGroupColors()
+	if err != nil {
+		m.err = err
+		return
+	}
 	m.groups = groups
+	m.groupParents = parents
+	m.groupColors = make(map[string]string, len(colors))
+	for name, color := range colors {
+		m.groupColors[strings.ToLower(name)] = color
+	}
+}
+
+// cycleColorPreset steps dir places from cur through "no color" and
+// ui.HostColorPresets; a custom color starts the cycle from "no color".
+func cycleColorPreset(cur string, dir int) string {
+	opts := append([]string{""}, ui.HostColorPresets...)
+	idx := 0
+	for i, c := range opts {
+		if strings.EqualFold(c, strings.TrimSpace(cur)) {
+			idx = i
+			break
+		}
+	}
+	return opts[(idx+dir+len(opts))%len(opts)]
+}
+
+// hostColor returns the list color for host: its own override, else its
+// group's color, else "".
+func (m Model) hostColor(host Host) string {
+	if host.ColorOverride != "" {
+		return host.ColorOverride
+	}
+	return m.groupColors[strings.ToLower(strings.TrimSpace(host.GroupName))]
 }
 
 func hostDisplayName(h Host) string {
@@ -147,24 +849,51 @@ func (m *Model) rebuildListItems() {
 	})
 
 	for g := range hostsByGroup {
-		sort.Slice(hostsByGroup[g], func(i, j int) bool {
-			a := strings.ToLower(hostDisplayName(hostsByGroup[g][i]))
-			b := strings.ToLower(hostDisplayName(hostsByGroup[g][j]))
-			if a == b {
-				return strings.ToLower(hostsByGroup[g][i].Hostname) < strings.ToLower(hostsByGroup[g][j].Hostname)
-			}
-			return a < b
-		})
+		sortHostsForList(hostsByGroup[g], m.sortBy, m.sortDesc)
 	}
 
-	items := make([]ListItem, 0, len(m.hosts)+len(groups)+2)
+	// Subgroups are listed under their parent. A group whose parent is gone
+	// is shown at the top level.
+	known := make(map[string]bool, len(groups))
 	for _, g := range groups {
-		items = append(items, ListItem{Kind: ListItemGroup, GroupName: g, Count: counts[g]})
-		if !m.collapsed[g] {
-			for _, h := range hostsByGroup[g] {
-				items = append(items, ListItem{Kind: ListItemHost, GroupName: g, Host: h})
-			}
+		known[strings.ToLower(g)] = true
+	}
+	children := make(map[string][]string)
+	var roots []string
+	for _, g := range groups {
+		parent := strings.ToLower(m.groupParents[g])
+		if parent != "" && known[parent] && parent != strings.ToLower(g) {
+			children[parent] = append(children[parent], g)
+		} else {
+			roots = append(roots, g)
+		}
+	}
+
+	items := make([]ListItem, 0, len(m.hosts)+len(groups)+2)
+	visited := make(map[string]bool, len(groups))
+	var addGroup func(g string, depth int)
+	addGroup = func(g string, depth int) {
+		if visited[strings.ToLower(g)] {
+			return
+		}
+		visited[strings.ToLower(g)] = true
+		items = append(items, ListItem{Kind: ListItemGroup, GroupName: g, Count: counts[g], Depth: depth})
+		if m.collapsed[g] {
+			return
+		}
+		for _, h := range hostsByGroup[g] {
+			items = append(items, ListItem{Kind: ListItemHost, GroupName: g, Host: h, Depth: depth})
 		}
+		for _, c := range children[strings.ToLower(g)] {
+			addGroup(c, depth+1)
+		}
+	}
+	for _, g := range roots {
+		addGroup(g, 0)
+	}
+	// Groups in a parent cycle are never reached from a root.
+	for _, g := range groups {
+		addGroup(g, 0)
 	}
 
 	if len(hostsByGroup[""]) > 0 {
@@ -176,6 +905,8 @@ func (m *Model) rebuildListItems() {
 		}
 	}
 
+	items = append(items, m.virtualGroupItems()...)
+
 	items = append(items, ListItem{Kind: ListItemNewGroup})
 	m.listItems = items
 	if len(m.listItems) == 0 {
@@ -185,26 +916,133 @@ func (m *Model) rebuildListItems() {
 	}
 }
 
-// ── Selection helpers ─────────────────────────────────────────────────
+// virtualGroupItems builds the read-only groups defined by
+// cfg.VirtualGroupFilters. Hosts stay in their static group as well.
+func (m *Model) virtualGroupItems() []ListItem {
+	names := make([]string, 0, len(m.cfg.VirtualGroupFilters))
+	for name := range m.cfg.VirtualGroupFilters {
+		if strings.TrimSpace(name) != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
 
-func (m *Model) selectedListItem() (ListItem, bool) {
-	if m.selectedIdx < 0 || m.selectedIdx >= len(m.listItems) {
-		return ListItem{}, false
+	var items []ListItem
+	for _, name := range names {
+		expr := m.cfg.VirtualGroupFilters[name]
+		header := ListItem{Kind: ListItemGroup, GroupName: name, Virtual: true, Filter: expr}
+		filter, err := db.ParseGroupFilter(expr)
+		if err != nil {
+			header.FilterErr = err.Error()
+			items = append(items, header)
+			continue
+		}
+		var hosts []Host
+		for _, h := range m.hosts {
+			if filter.Matches(h.Tags, h.GroupName, h.Hostname) {
+				hosts = append(hosts, h)
+			}
+		}
+		sortHostsForList(hosts, m.sortBy, m.sortDesc)
+		header.Count = len(hosts)
+		items = append(items, header)
+		if m.collapsed[header.collapseKey()] {
+			continue
+		}
+		for _, h := range hosts {
+			items = append(items, ListItem{Kind: ListItemHost, GroupName: name, Host: h, Virtual: true})
+		}
 	}
-	return m.listItems[m.selectedIdx], true
+	return items
 }
 
-func (m *Model) selectedHost() (Host, bool) {
-	item, ok := m.selectedListItem()
-	if !ok || item.Kind != ListItemHost {
-		return Host{}, false
+// sortHostsForList orders hosts pinned first, then by the sort column, with
+// the display name breaking ties. Hosts never connected to sort as oldest.
+func sortHostsForList(hosts []Host, by SortBy, desc bool) {
+	byName := func(a, b Host) int {
+		if c := strings.Compare(strings.ToLower(hostDisplayName(a)), strings.ToLower(hostDisplayName(b))); c != 0 {
+			return c
+		}
+		return strings.Compare(strings.ToLower(a.Hostname), strings.ToLower(b.Hostname))
+	}
+	sort.SliceStable(hosts, func(i, j int) bool {
+		a, b := hosts[i], hosts[j]
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		c := 0
+		switch by {
+		case SortByHostname:
+			c = strings.Compare(strings.ToLower(a.Hostname), strings.ToLower(b.Hostname))
+		case SortByLastConnected:
+			var ta, tb time.Time
+			if a.LastConnected != nil {
+				ta = *a.LastConnected
+			}
+			if b.LastConnected != nil {
+				tb = *b.LastConnected
+			}
+			c = ta.Compare(tb)
+		case SortByGroup:
+			c = strings.Compare(strings.ToLower(a.GroupName), strings.ToLower(b.GroupName))
+		case SortByCreated:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if c == 0 {
+			c = byName(a, b)
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// sortLabel describes the host list order for the home header.
+func (m Model) sortLabel() string {
+	arrow := "\u2191"
+	if m.sortDesc {
+		arrow = "\u2193"
+	}
+	return "sort: " + strings.ReplaceAll(m.sortBy.String(), "_", " ") + " " + arrow
+}
+
+// setSort applies a new host order and remembers it in the config.
+func (m Model) setSort(by SortBy, desc bool) Model {
+	m.sortBy, m.sortDesc = by, desc
+	m.cfg.UI.DefaultSort = by.String()
+	m.cfg.UI.SortDesc = desc
+	m.cfgOriginal.UI.DefaultSort = m.cfg.UI.DefaultSort
+	m.cfgOriginal.UI.SortDesc = desc
+	if err := config.Save(m.cfg); err != nil {
+		m.err = fmt.Errorf("\u26A0 failed to save sort order: %v", err)
+	}
+	m.rebuildListItems()
+	return m
+}
+
+// ── Selection helpers ─────────────────────────────────────────────────
+
+func (m *Model) selectedListItem() (ListItem, bool) {
+	if m.selectedIdx < 0 || m.selectedIdx >= len(m.listItems) {
+		return ListItem{}, false
+	}
+	return m.listItems[m.selectedIdx], true
+}
+
+func (m *Model) selectedHost() (Host, bool) {
+	item, ok := m.selectedListItem()
+	if !ok || item.Kind != ListItemHost {
+		return Host{}, false
 	}
 	return item.Host, true
 }
 
 func (m *Model) selectedGroup() (string, bool) {
 	item, ok := m.selectedListItem()
-	if !ok || item.Kind != ListItemGroup {
+	if !ok || item.Kind != ListItemGroup || item.Virtual {
 		return "", false
 	}
 	if item.GroupName == "Ungrouped" {
@@ -237,7 +1075,7 @@ func (m *Model) hostCountForGroup(groupName string) int {
 func (m *Model) selectGroupInList(groupName string) {
 	m.rebuildListItems()
 	for i, it := range m.listItems {
-		if it.Kind != ListItemGroup {
+		if it.Kind != ListItemGroup || it.Virtual {
 			continue
 		}
 		if strings.EqualFold(strings.TrimSpace(it.GroupName), strings.TrimSpace(groupName)) {
@@ -277,7 +1115,7 @@ func (m *Model) restoreMountsFromDB() {
 			continue
 		}
 		if !ok {
-			_ = m.store.DeleteMountState(st.HostID)
+			_ = m.store.DeleteMountState(st.HostID, st.RemotePath)
 			continue
 		}
 		host, _ := byID[st.HostID]
@@ -285,12 +1123,17 @@ func (m *Model) restoreMountsFromDB() {
 		if hostname == "" {
 			hostname = fmt.Sprintf("host_%d", st.HostID)
 		}
-		toRestore = append(toRestore, mount.Mount{
+		mt := mount.Mount{
 			HostID:     st.HostID,
 			Hostname:   hostname,
 			LocalPath:  st.LocalPath,
 			RemotePath: st.RemotePath,
-		})
+			ReadOnly:   st.ReadOnly,
+		}
+		if host.ID != 0 {
+			mt.DisplayName = hostDisplayName(host)
+		}
+		toRestore = append(toRestore, mt)
 	}
 
 	if len(toRestore) > 0 {
@@ -300,6 +1143,267 @@ func (m *Model) restoreMountsFromDB() {
 
 // ── Sync helpers ──────────────────────────────────────────────────────
 
+// buildSyncDryRunViewParams converts the last dry-run result into rows for
+// the preview overlay.
+func (m Model) buildSyncDryRunViewParams() ui.SyncDryRunViewParams {
+	p := ui.SyncDryRunViewParams{Cursor: m.syncDryRunCursor}
+	res := m.syncDryRun
+	if res == nil {
+		return p
+	}
+	conflicted := make(map[int]bool, len(res.Conflicts))
+	for _, c := range res.Conflicts {
+		conflicted[c.HostID] = true
+	}
+	for _, c := range res.Changes {
+		p.Rows = append(p.Rows, ui.SyncDryRunRow{
+			Action:   c.Action,
+			Hostname: c.Hostname,
+			Conflict: conflicted[c.HostID],
+		})
+	}
+	p.Summary = fmt.Sprintf("%d to add \u00B7 %d to update \u00B7 %d unchanged \u00B7 %d conflicts",
+		res.Added, res.Updated, res.Unchanged, len(res.Conflicts))
+	return p
+}
+
+// openSyncConflicts shows the conflicts from a finished sync.
+func (m *Model) openSyncConflicts(conflicts []syncpkg.SyncConflict) {
+	m.syncConflicts = conflicts
+	m.syncConflictPicks = make([]string, len(conflicts))
+	m.syncConflictCursor = 0
+	m.syncConflictApplying = false
+	m.overlay = OverlaySyncConflicts
+}
+
+// pickSyncConflict sets the side to keep for the selected conflict. Picking
+// the side the sync already chose clears the override.
+func (m *Model) pickSyncConflict(side string) {
+	i := m.syncConflictCursor
+	if i < 0 || i >= len(m.syncConflicts) || i >= len(m.syncConflictPicks) {
+		return
+	}
+	if m.syncConflicts[i].Resolution == side {
+		side = ""
+	}
+	m.syncConflictPicks[i] = side
+}
+
+// syncConflictOverrides returns the conflicts whose side the user changed,
+// with Resolution set to the side to apply.
+func (m Model) syncConflictOverrides() []syncpkg.SyncConflict {
+	var out []syncpkg.SyncConflict
+	for i, c := range m.syncConflicts {
+		if i < len(m.syncConflictPicks) && m.syncConflictPicks[i] != "" {
+			c.Resolution = m.syncConflictPicks[i]
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// buildSyncConflictsViewParams converts the pending conflicts into rows for
+// the conflicts overlay.
+func (m Model) buildSyncConflictsViewParams() ui.SyncConflictsViewParams {
+	p := ui.SyncConflictsViewParams{Cursor: m.syncConflictCursor, Applying: m.syncConflictApplying}
+	for i, c := range m.syncConflicts {
+		row := ui.SyncConflictRow{
+			Hostname:   c.Hostname,
+			LocalTime:  c.LocalTime.Local().Format("2006-01-02 15:04"),
+			RemoteTime: c.RemoteTime.Local().Format("2006-01-02 15:04"),
+			Resolution: c.Resolution,
+		}
+		if i < len(m.syncConflictPicks) && m.syncConflictPicks[i] != "" {
+			row.Resolution = m.syncConflictPicks[i]
+			row.Overridden = true
+		}
+		p.Rows = append(p.Rows, row)
+	}
+	return p
+}
+
+// loadUnlockSession reads the unlock session cache; tests replace it.
+var loadUnlockSession = unlock.Load
+
+// refreshSession re-reads the unlock session cache and reports whether a
+// session shown in the footer has expired since the last refresh.
+func (m *Model) refreshSession() bool {
+	_, expiresAt, ok, _ := loadUnlockSession()
+	if ok {
+		m.sessionUntil = expiresAt.Local()
+		m.sessionExpired = false
+		return false
+	}
+	had := !m.sessionUntil.IsZero()
+	m.sessionUntil = time.Time{}
+	if had {
+		m.sessionExpired = true
+	}
+	return had
+}
+
+// scheduleSessionRefresh reads the unlock session now and every
+// sessionRefreshInterval after, cancelling any earlier timer.
+func (m *Model) scheduleSessionRefresh() tea.Cmd {
+	m.sessionGen++
+	m.refreshSession()
+	return sessionTickCmd(m.sessionGen)
+}
+
+// openSessionRenew asks for the master password before next ("sync" or
+// "bulk") runs, since the unlock session expired while the app was open.
+func (m Model) openSessionRenew(next string) Model {
+	m.sessionRenewField = ui.NewMaskedField("password")
+	m.sessionRenewNext = next
+	m.err = nil
+	m.overlay = OverlaySessionRenew
+	return m
+}
+
+// lockSession clears the unlock session cache without locking the app.
+func (m Model) lockSession() Model {
+	if err := unlock.Clear(); err != nil {
+		m.err = fmt.Errorf("\u26A0 failed to clear session: %v", err)
+		return m
+	}
+	m.sessionUntil = time.Time{}
+	m.sessionExpired = false
+	m.err = fmt.Errorf("\u2713 Session locked \u2014 sshthing exec and list need a new unlock")
+	return m
+}
+
+// startWake sends a Wake-on-LAN packet to host and starts polling its SSH
+// port, showing a spinner in the footer until it answers or gives up.
+func (m *Model) startWake(host Host) tea.Cmd {
+	m.waking = true
+	m.wakeRunID++
+	m.wakeHostID = host.ID
+	m.wakeAttempt = 0
+	m.wakeFrame = 0
+	runID := m.wakeRunID
+	return tea.Batch(runWakeCmd(runID, host.MACAddress, host.BroadcastAddr), wakeAnimTickCmd(runID))
+}
+
+// startSync kicks off an asynchronous sync and the footer activity animation.
+func (m *Model) startSync() tea.Cmd {
+	m.syncing = true
+	m.syncRunID++
+	m.syncAnimFrame = 0
+	m.syncProgress = 0.02
+	runID := m.syncRunID
+	return tea.Batch(runSyncCmd(runID, m.syncManager), syncAnimTickCmd(runID))
+}
+
+// openRollbackConfirm shows the confirmation modal for undoing the last sync.
+func (m *Model) openRollbackConfirm() {
+	if m.rekeyBusy() {
+		return
+	}
+	if m.syncing {
+		m.err = fmt.Errorf("\u2139 sync already in progress")
+		return
+	}
+	if m.syncManager == nil || !m.syncManager.IsEnabled() {
+		m.err = fmt.Errorf("\u26A0 sync is disabled \u2014 enable in settings")
+		return
+	}
+	target, err := m.syncManager.RollbackTarget()
+	if err != nil {
+		m.err = fmt.Errorf("\u26A0 %v", err)
+		return
+	}
+	m.rollbackTarget = target
+	m.rollbackCursor = 1 // default to cancel
+	m.overlay = OverlaySyncRollback
+	m.err = nil
+}
+
+// scheduleAutoSync (re)starts the background auto-sync timer, invalidating
+// any timer scheduled earlier. It returns nil when auto-sync is off.
+func (m *Model) scheduleAutoSync() tea.Cmd {
+	m.autoSyncGen++
+	interval := time.Duration(m.cfg.Automation.AutoSyncIntervalSeconds) * time.Second
+	if interval <= 0 || m.syncManager == nil || !m.syncManager.IsEnabled() {
+		return nil
+	}
+	return autoSyncTickCmd(m.autoSyncGen, interval)
+}
+
+// hostPing returns the latest reachability result for the details panel.
+func (m Model) hostPing(hostID int) ui.HostPing {
+	if m.pingManager == nil || m.cfg.UI.PingIntervalSeconds <= 0 {
+		return ui.HostPing{}
+	}
+	r, ok := m.pingManager.Result(hostID)
+	if !ok {
+		return ui.HostPing{}
+	}
+	return ui.HostPing{Checked: true, Reachable: r.Reachable, Latency: r.Latency}
+}
+
+// refreshSelectedBanner fetches the selected host's SSH banner in the
+// background when its cached copy is older than SSH: Banner refresh. Hosts
+// behind jump hosts are skipped, since their port is not reachable directly.
+func (m *Model) refreshSelectedBanner() tea.Cmd {
+	interval := time.Duration(m.cfg.SSH.BannerRefreshSeconds) * time.Second
+	if interval <= 0 || m.store == nil || m.page != PageHome || m.overlay != OverlayNone {
+		return nil
+	}
+	host, ok := m.selectedHost()
+	if !ok || len(host.ProxyHosts) > 0 || m.bannerFetching[host.ID] {
+		return nil
+	}
+	if !host.BannerCheckedAt.IsZero() && time.Since(host.BannerCheckedAt) < interval {
+		return nil
+	}
+	m.bannerFetching[host.ID] = true
+	return fetchBannerCmd(host.ID, ssh.Connection{Hostname: host.Hostname, Username: host.Username, Port: host.Port})
+}
+
+// applyFetchedBanner caches a fetched banner. A failed fetch keeps the old
+// banner but still waits a full refresh interval before trying again.
+func (m *Model) applyFetchedBanner(msg bannerFetchedMsg) {
+	for i := range m.hosts {
+		if m.hosts[i].ID != msg.hostID {
+			continue
+		}
+		m.hosts[i].BannerCheckedAt = time.Now()
+		if msg.err != nil {
+			return
+		}
+		m.hosts[i].Banner = msg.banner
+		if m.store != nil {
+			_ = m.store.SetHostBanner(msg.hostID, msg.banner)
+		}
+		m.rebuildListItems()
+		return
+	}
+}
+
+// schedulePing (re)starts background host reachability checks, invalidating
+// any round scheduled earlier. It returns nil when checks are off.
+func (m *Model) schedulePing() tea.Cmd {
+	m.pingGen++
+	interval := time.Duration(m.cfg.UI.PingIntervalSeconds) * time.Second
+	if interval <= 0 || m.store == nil {
+		return nil
+	}
+	return tea.Batch(m.startPing(), pingTickCmd(m.pingGen, interval))
+}
+
+// startPing checks every host once in the background.
+func (m *Model) startPing() tea.Cmd {
+	if m.pinging || m.pingManager == nil {
+		return nil
+	}
+	targets := make([]ssh.PingTarget, 0, len(m.hosts))
+	for _, h := range m.hosts {
+		targets = append(targets, ssh.PingTarget{HostID: h.ID, Hostname: h.Hostname, Port: h.Port})
+	}
+	m.pinging = true
+	return runPingCmd(m.pingManager, targets)
+}
+
 func (m *Model) initSyncManager() {
 	if m.store == nil {
 		return
@@ -332,13 +1436,7 @@ func (m *Model) buildSSHConn(host Host) (ssh.Connection, string, string) {
 		}
 	}
 
-	term := ""
-	switch m.cfg.SSH.TermMode {
-	case config.TermXterm:
-		term = "xterm-256color"
-	case config.TermCustom:
-		term = strings.TrimSpace(m.cfg.SSH.TermCustom)
-	}
+	term := m.hostTerm(host)
 
 	conn := ssh.Connection{
 		Hostname:            host.Hostname,
@@ -348,16 +1446,89 @@ func (m *Model) buildSSHConn(host Host) (ssh.Connection, string, string) {
 		Password:            password,
 		PasswordBackendUnix: string(m.cfg.SSH.PasswordBackendUnix),
 		HostKeyPolicy:       string(m.cfg.SSH.HostKeyPolicy),
-		KeepAliveSeconds:    m.cfg.SSH.KeepAliveSeconds,
+		KeepAliveSeconds:    m.hostKeepAlive(host),
+		ProxyJumps:          m.hostJumps(host),
 		Term:                term,
+		ForwardAgent:        m.cfg.SSH.ForwardAgent,
+		IdentitiesOnly:      m.hostIdentitiesOnly(host),
+		Options:             host.SSHOptions,
 	}
 	return conn, privateKey, password
 }
 
+// hostKeepAlive returns the host's keepalive override, falling back to the
+// global setting when it is 0.
+func (m Model) hostKeepAlive(host Host) int {
+	if host.KeepAliveSeconds > 0 {
+		return host.KeepAliveSeconds
+	}
+	return m.cfg.SSH.KeepAliveSeconds
+}
+
+// hostIdentitiesOnly resolves the host's IdentitiesOnly override against
+// the global setting. It only matters for hosts with a stored key.
+func (m Model) hostIdentitiesOnly(host Host) bool {
+	switch host.IdentitiesOnly {
+	case "on":
+		return true
+	case "off":
+		return false
+	}
+	return m.cfg.SSH.IdentitiesOnly
+}
+
+// hostTerm returns the TERM for the host's sessions: its own override, else
+// the global TERM mode ("" keeps the local TERM).
+func (m Model) hostTerm(host Host) string {
+	if t := strings.TrimSpace(host.TermOverride); t != "" {
+		return t
+	}
+	switch m.cfg.SSH.TermMode {
+	case config.TermXterm:
+		return "xterm-256color"
+	case config.TermCustom:
+		return strings.TrimSpace(m.cfg.SSH.TermCustom)
+	}
+	return ""
+}
+
+// hostJumpNames returns the display names of the host's jump chain.
+func (m Model) hostJumpNames(host Host) []string {
+	var names []string
+	for _, id := range host.ProxyHosts {
+		if j, ok := m.hostByID(id); ok {
+			names = append(names, hostDisplayName(j))
+		}
+	}
+	return names
+}
+
+// hostJumps resolves the host's jump chain to connections, first hop first.
+// Jump hosts that no longer exist are skipped.
+func (m Model) hostJumps(host Host) []ssh.Connection {
+	var jumps []ssh.Connection
+	for _, id := range host.ProxyHosts {
+		j, ok := m.hostByID(id)
+		if !ok {
+			continue
+		}
+		jumps = append(jumps, ssh.Connection{Hostname: j.Hostname, Username: j.Username, Port: j.Port})
+	}
+	return jumps
+}
+
 func (m Model) connectToHost(host Host) (tea.Model, tea.Cmd) {
+	return m.connectToHostAttempt(host, 0)
+}
+
+// connectToHostAttempt starts an SSH session; attempt counts the retries
+// already made. While retries remain, a refused or timed-out connection comes
+// back as an sshRetryMsg instead of ending the session.
+func (m Model) connectToHostAttempt(host Host, attempt int) (tea.Model, tea.Cmd) {
+	m.sshRetryPending = false
+	m.sshRetryGen++
 	m.armedSFTP = false
 	m.armedMount = false
-	m.armedUnmount = false
 
 	var privateKey string
 	var password string
@@ -373,6 +1544,9 @@ func (m Model) connectToHost(host Host) (tea.Model, tea.Cmd) {
 		}
 		privateKey = key
 	}
+	if privateKey != "" && ssh.IsPassphraseProtected(privateKey) && m.keyPassphrase == nil {
+		return m.promptKeyPassphrase(host, false), nil
+	}
 	if host.KeyType == "password" && m.cfg.SSH.PasswordAutoLogin {
 		secret, err := m.store.GetHostSecret(host.ID)
 		if err != nil {
@@ -382,13 +1556,7 @@ func (m Model) connectToHost(host Host) (tea.Model, tea.Cmd) {
 		password = secret
 	}
 
-	term := ""
-	switch m.cfg.SSH.TermMode {
-	case config.TermXterm:
-		term = "xterm-256color"
-	case config.TermCustom:
-		term = strings.TrimSpace(m.cfg.SSH.TermCustom)
-	}
+	term := m.hostTerm(host)
 	conn := ssh.Connection{
 		Hostname:            host.Hostname,
 		Username:            host.Username,
@@ -397,11 +1565,34 @@ func (m Model) connectToHost(host Host) (tea.Model, tea.Cmd) {
 		Password:            password,
 		PasswordBackendUnix: string(m.cfg.SSH.PasswordBackendUnix),
 		HostKeyPolicy:       string(m.cfg.SSH.HostKeyPolicy),
-		KeepAliveSeconds:    m.cfg.SSH.KeepAliveSeconds,
+		KeepAliveSeconds:    m.hostKeepAlive(host),
+		ProxyJumps:          m.hostJumps(host),
 		Term:                term,
+		ForwardAgent:        m.cfg.SSH.ForwardAgent,
+		IdentitiesOnly:      m.hostIdentitiesOnly(host),
+		Options:             host.SSHOptions,
+		ForwardX11:          host.ForwardX11 || m.cfg.SSH.ForwardX11,
+		TrustX11:            m.cfg.SSH.TrustX11,
+	}
+	if m.shouldRecordSession(host) {
+		path, err := sessionRecordingPath(host.ID)
+		if err != nil {
+			m.err = fmt.Errorf("failed to prepare session recording: %v", err)
+			return m, nil
+		}
+		conn.Recording = true
+		conn.RecordingPath = path
+	}
+	m.applyControlMaster(&conn, host)
+	conn.PassphrasePrompt = m.keyPassphrasePrompt()
+	if store := m.store; store != nil {
+		conn.OnFingerprint = func(fp string) {
+			_ = store.UpsertHostFingerprint(host.ID, fp)
+		}
 	}
 
 	cmd, tempKey, err := ssh.Connect(conn)
+	m.clearKeyPassphrase()
 	if err != nil {
 		m.err = fmt.Errorf("failed to prepare SSH connection: %v", err)
 		return m, nil
@@ -409,23 +1600,174 @@ func (m Model) connectToHost(host Host) (tea.Model, tea.Cmd) {
 
 	if m.store != nil {
 		m.store.UpdateLastConnected(host.ID)
+		if conn.Recording {
+			_ = m.store.AddSessionRecording(host.ID, conn.RecordingPath)
+		}
+	}
+
+	// Keep a copy of ssh's own messages to tell connection failures apart.
+	var stderr *bytes.Buffer
+	if attempt < m.cfg.SSH.ConnectRetries {
+		stderr = &bytes.Buffer{}
+		cmd.Stderr = io.MultiWriter(os.Stderr, stderr)
 	}
 
+	m.metrics.SessionStarted()
 	return m, tea.Sequence(
 		tea.ShowCursor,
 		tea.ExecProcess(cmd, func(err error) tea.Msg {
+			m.metrics.SessionEnded()
 			if tempKey != nil {
 				tempKey.Cleanup()
 			}
+			if stderr != nil && ssh.IsRetryableError(err, stderr.String()) {
+				return sshRetryMsg{host: host, attempt: attempt + 1, err: err}
+			}
 			return sshFinishedMsg{err: err, hostname: host.Hostname, proto: "SSH", keyType: host.KeyType}
 		}),
 	)
 }
 
+// promptKeyPassphrase opens the passphrase overlay for an encrypted host key;
+// submitting it resumes the SSH (or SFTP) connection.
+func (m Model) promptKeyPassphrase(host Host, sftp bool) Model {
+	m.passphraseHost = host
+	m.passphraseSFTP = sftp
+	m.passphraseField = ui.NewMaskedField("passphrase")
+	m.overlay = OverlayPassphrase
+	m.err = nil
+	return m
+}
+
+// keyPassphrasePrompt hands the pending passphrase to the ssh package, which
+// zeroes it once the key is decrypted.
+func (m Model) keyPassphrasePrompt() ssh.PassphrasePromptFunc {
+	if m.keyPassphrase == nil {
+		return nil
+	}
+	passphrase := m.keyPassphrase
+	return func() ([]byte, error) {
+		return passphrase, nil
+	}
+}
+
+func (m *Model) clearKeyPassphrase() {
+	for i := range m.keyPassphrase {
+		m.keyPassphrase[i] = 0
+	}
+	m.keyPassphrase = nil
+}
+
+// applyControlMaster enables ssh connection sharing for host when configured,
+// so repeat sessions reuse one authenticated master connection. ssh's %C
+// hash keeps sockets per host, port and user.
+func (m Model) applyControlMaster(conn *ssh.Connection, host Host) {
+	if !m.cfg.SSH.ControlMasterEnabled || runtime.GOOS == "windows" {
+		return
+	}
+	dir, err := ssh.ControlSocketDir()
+	if err != nil {
+		return
+	}
+	conn.ControlMaster = true
+	conn.ControlPath = filepath.Join(dir, "%C")
+}
+
+// acceptHostKey trusts host's latest host key fingerprint, clearing the
+// changed-key warning in the details panel.
+func (m *Model) acceptHostKey(host Host) {
+	if !host.FingerprintChanged {
+		m.err = fmt.Errorf("\u2139 The host key for %s has not changed", hostDisplayName(host))
+		return
+	}
+	if err := m.store.AcceptHostFingerprint(host.ID); err != nil {
+		m.err = fmt.Errorf("\u26A0 failed to accept host key: %v", err)
+		return
+	}
+	m.loadHosts()
+	m.rebuildListItems()
+	m.err = fmt.Errorf("\u2713 Accepted the new host key for %s", hostDisplayName(host))
+}
+
+// cleanupControlSockets drops stale control sockets in the background.
+func cleanupControlSockets() {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	go func() {
+		if dir, err := ssh.ControlSocketDir(); err == nil {
+			_ = ssh.CleanupControlSockets(dir)
+		}
+	}()
+}
+
+// shouldRecordSession resolves the per-host override against the global setting.
+func (m Model) shouldRecordSession(host Host) bool {
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	switch host.Recording {
+	case "on":
+		return true
+	case "off":
+		return false
+	}
+	return m.cfg.SSH.RecordSessions
+}
+
+func sessionRecordingPath(hostID int) (string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "recordings")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%d-%s.txt", hostID, time.Now().Format("20060102-150405"))
+	return filepath.Join(dir, name), nil
+}
+
+// openLastRecording shows the most recent session recording for host in $PAGER.
+func (m Model) openLastRecording(host Host) (tea.Model, tea.Cmd) {
+	if m.store == nil {
+		return m, nil
+	}
+	rec, err := m.store.LatestSessionRecording(host.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			m.err = fmt.Errorf("\u2139 no recordings for %s", hostDisplayName(host))
+		} else {
+			m.err = fmt.Errorf("failed to load recording: %v", err)
+		}
+		return m, nil
+	}
+	if _, err := os.Stat(rec.Path); err != nil {
+		m.err = fmt.Errorf("\u26A0 recording file missing: %s", rec.Path)
+		return m, nil
+	}
+
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less", "-R"}
+		if runtime.GOOS == "windows" {
+			pager = []string{"more"}
+		}
+	}
+	args := append(pager[1:], rec.Path)
+	cmd := exec.Command(pager[0], args...)
+
+	return m, tea.Sequence(
+		tea.ShowCursor,
+		tea.ExecProcess(cmd, func(err error) tea.Msg {
+			return pagerFinishedMsg{err: err}
+		}),
+	)
+}
+
 func (m Model) connectToHostSFTP(host Host) (tea.Model, tea.Cmd) {
 	m.armedSFTP = false
 	m.armedMount = false
-	m.armedUnmount = false
 
 	var privateKey string
 	var password string
@@ -441,6 +1783,9 @@ func (m Model) connectToHostSFTP(host Host) (tea.Model, tea.Cmd) {
 		}
 		privateKey = key
 	}
+	if privateKey != "" && ssh.IsPassphraseProtected(privateKey) && m.keyPassphrase == nil {
+		return m.promptKeyPassphrase(host, true), nil
+	}
 	if host.KeyType == "password" && m.cfg.SSH.PasswordAutoLogin {
 		secret, err := m.store.GetHostSecret(host.ID)
 		if err != nil {
@@ -450,13 +1795,7 @@ func (m Model) connectToHostSFTP(host Host) (tea.Model, tea.Cmd) {
 		password = secret
 	}
 
-	term := ""
-	switch m.cfg.SSH.TermMode {
-	case config.TermXterm:
-		term = "xterm-256color"
-	case config.TermCustom:
-		term = strings.TrimSpace(m.cfg.SSH.TermCustom)
-	}
+	term := m.hostTerm(host)
 	conn := ssh.Connection{
 		Hostname:            host.Hostname,
 		Username:            host.Username,
@@ -465,11 +1804,34 @@ func (m Model) connectToHostSFTP(host Host) (tea.Model, tea.Cmd) {
 		Password:            password,
 		PasswordBackendUnix: string(m.cfg.SSH.PasswordBackendUnix),
 		HostKeyPolicy:       string(m.cfg.SSH.HostKeyPolicy),
-		KeepAliveSeconds:    m.cfg.SSH.KeepAliveSeconds,
+		KeepAliveSeconds:    m.hostKeepAlive(host),
+		ProxyJumps:          m.hostJumps(host),
 		Term:                term,
+		ForwardAgent:        m.cfg.SSH.ForwardAgent,
+		IdentitiesOnly:      m.hostIdentitiesOnly(host),
+		Options:             host.SSHOptions,
+	}
+	m.applyControlMaster(&conn, host)
+	conn.PassphrasePrompt = m.keyPassphrasePrompt()
+
+	if host.KeyType != "password" || password != "" {
+		session, err := ssh.NewSFTPSession(conn)
+		m.clearKeyPassphrase()
+		if err != nil {
+			m.err = fmt.Errorf("failed to prepare SFTP session: %v", err)
+			return m, nil
+		}
+		if m.store != nil {
+			m.store.UpdateLastConnected(host.ID)
+		}
+		m.err = fmt.Errorf("\u2139 Opening SFTP browser for %s...", hostDisplayName(host))
+		return m, openSFTPCmd(host, session)
 	}
 
+	// Without a stored password the browser has no way to authenticate, so
+	// fall back to the system sftp client, which can prompt on the terminal.
 	cmd, tempKey, err := ssh.ConnectSFTP(conn)
+	m.clearKeyPassphrase()
 	if err != nil {
 		m.err = fmt.Errorf("failed to prepare SFTP session: %v", err)
 		return m, nil
@@ -479,9 +1841,11 @@ func (m Model) connectToHostSFTP(host Host) (tea.Model, tea.Cmd) {
 		m.store.UpdateLastConnected(host.ID)
 	}
 
+	m.metrics.SessionStarted()
 	return m, tea.Sequence(
 		tea.ShowCursor,
 		tea.ExecProcess(cmd, func(err error) tea.Msg {
+			m.metrics.SessionEnded()
 			if tempKey != nil {
 				tempKey.Cleanup()
 			}
@@ -490,35 +1854,151 @@ func (m Model) connectToHostSFTP(host Host) (tea.Model, tea.Cmd) {
 	)
 }
 
-func (m Model) handleMountEnter(host Host) (tea.Model, tea.Cmd) {
-	m.armedSFTP = false
+// buildSFTPBrowserViewParams converts the browser state into display rows.
+func (m Model) buildSFTPBrowserViewParams() ui.SFTPBrowserViewParams {
+	b := m.sftpBrowser
+	if b == nil {
+		return ui.SFTPBrowserViewParams{}
+	}
+	p := ui.SFTPBrowserViewParams{
+		HostLabel:     hostDisplayName(b.Host),
+		Path:          b.Path,
+		Cursor:        b.Cursor,
+		Uploading:     b.Uploading,
+		UploadField:   b.UploadField,
+		ConfirmDelete: b.ConfirmDelete,
+		Err:           m.err,
+	}
+	for _, e := range b.Entries {
+		p.Rows = append(p.Rows, ui.SFTPBrowserRow{Name: e.Name(), IsDir: e.IsDir(), Size: e.Size(), ModTime: e.ModTime()})
+	}
+	return p
+}
 
-	if m.mountManager == nil {
-		m.armedMount = false
-		m.armedUnmount = false
-		m.err = fmt.Errorf("\u26A0 mount manager not initialized")
+// closeSFTPBrowser disconnects the file browser session, if one is open.
+func (m *Model) closeSFTPBrowser() {
+	if m.sftpBrowser == nil {
+		return
+	}
+	session := m.sftpBrowser.Session
+	m.sftpBrowser = nil
+	if m.overlay == OverlaySFTPBrowser {
+		m.overlay = OverlayNone
+	}
+	go func() {
+		_ = session.Close()
+	}()
+}
+
+// sftpDownload copies the selected remote file into the local downloads
+// directory, yielding the terminal while the transfer runs.
+func (m Model) sftpDownload() (tea.Model, tea.Cmd) {
+	b := m.sftpBrowser
+	entry := b.Selected()
+	if entry == nil {
+		return m, nil
+	}
+	if entry.IsDir() {
+		m.err = fmt.Errorf("\u26A0 Only files can be downloaded")
+		return m, nil
+	}
+	dir, err := downloadDir()
+	if err != nil {
+		m.err = fmt.Errorf("\u26A0 %v", err)
 		return m, nil
 	}
+	remote := path.Join(b.Path, entry.Name())
+	local := filepath.Join(dir, entry.Name())
+	session := b.Session
+	t := &sftpTransfer{
+		label: fmt.Sprintf("Downloading %s \u2192 %s", remote, local),
+		run: func(progress io.Writer) (int64, error) {
+			return session.Download(remote, local, progress)
+		},
+	}
+	return m, tea.Exec(t, func(err error) tea.Msg {
+		return sftpTransferMsg{action: "download", name: entry.Name(), dest: local, bytes: t.n, err: err}
+	})
+}
 
-	// Unmount flow
-	if m.armedUnmount {
-		m.armedUnmount = false
-		cmd, localPath, err := m.mountManager.PrepareUnmount(host.ID)
-		if err != nil {
-			m.err = err
-			return m, nil
-		}
-		_ = localPath
-		return m, tea.Sequence(
-			tea.ShowCursor,
-			tea.ExecProcess(cmd, func(err error) tea.Msg {
-				return mountFinishedMsg{action: "unmount", hostID: host.ID, local: localPath, err: err}
-			}),
-		)
+// sftpUpload copies the local file named in the upload field into the
+// current remote directory.
+func (m Model) sftpUpload() (tea.Model, tea.Cmd) {
+	b := m.sftpBrowser
+	local := strings.TrimSpace(b.UploadField.Value)
+	if local == "" {
+		m.err = fmt.Errorf("\u26A0 Enter a local file path")
+		return m, nil
+	}
+	local = expandHome(local)
+	if _, err := os.Stat(local); err != nil {
+		m.err = fmt.Errorf("\u26A0 %v", err)
+		return m, nil
 	}
+	b.Uploading = false
+	b.UploadField = ui.NewFormField("local path")
+	remoteDir := b.Path
+	session := b.Session
+	t := &sftpTransfer{
+		label: fmt.Sprintf("Uploading %s \u2192 %s", local, remoteDir),
+		run: func(progress io.Writer) (int64, error) {
+			return session.Upload(local, remoteDir, progress)
+		},
+	}
+	return m, tea.Exec(t, func(err error) tea.Msg {
+		return sftpTransferMsg{action: "upload", name: filepath.Base(local), dest: remoteDir, bytes: t.n, err: err}
+	})
+}
 
-	// Mount flow
-	m.armedMount = false
+// downloadDir returns ~/Downloads when it exists, otherwise the home directory.
+func downloadDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(filepath.Join(home, "Downloads")); err == nil && info.IsDir() {
+		return filepath.Join(home, "Downloads"), nil
+	}
+	return home, nil
+}
+
+// expandHome replaces a leading ~/ with the user's home directory.
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, strings.TrimPrefix(p, "~"))
+}
+
+func (m Model) handleMountEnter(host Host) (tea.Model, tea.Cmd) {
+	m.armedSFTP = false
+	m.armedMount = false
+	remotePath := m.cfg.Mount.DefaultRemotePath
+	if p := strings.TrimSpace(m.armedMountPath); p != "" {
+		remotePath = p
+	}
+	m.armedMountPath = ""
+	return m.startMount(host, remotePath, m.mountReadOnly)
+}
+
+// mountArmedStatus is the status line shown while mount is armed.
+func (m Model) mountArmedStatus() error {
+	if m.mountReadOnly {
+		return fmt.Errorf("Mount (beta, read-only) armed \u2014 press Enter  \u00B7  R read-write")
+	}
+	return fmt.Errorf("Mount (beta) armed \u2014 press Enter  \u00B7  R read-only")
+}
+
+// startMount mounts remotePath of host ("" for the remote home directory).
+func (m Model) startMount(host Host, remotePath string, readOnly bool) (tea.Model, tea.Cmd) {
+	if m.mountManager == nil {
+		m.err = fmt.Errorf("\u26A0 mount manager not initialized")
+		return m, nil
+	}
 
 	var privateKey string
 	if host.HasKey && host.KeyType != "password" {
@@ -534,28 +2014,25 @@ func (m Model) handleMountEnter(host Host) (tea.Model, tea.Cmd) {
 		privateKey = key
 	}
 
-	remotePath := m.cfg.Mount.DefaultRemotePath
 	display := strings.TrimSpace(host.Label)
 	if display == "" {
 		display = host.Hostname
 	}
 
-	term := ""
-	switch m.cfg.SSH.TermMode {
-	case config.TermXterm:
-		term = "xterm-256color"
-	case config.TermCustom:
-		term = strings.TrimSpace(m.cfg.SSH.TermCustom)
-	}
+	term := m.hostTerm(host)
 	prep, err := m.mountManager.PrepareMount(host.ID, ssh.Connection{
 		Hostname:         host.Hostname,
 		Username:         host.Username,
 		Port:             host.Port,
 		PrivateKey:       privateKey,
 		HostKeyPolicy:    string(m.cfg.SSH.HostKeyPolicy),
-		KeepAliveSeconds: m.cfg.SSH.KeepAliveSeconds,
+		KeepAliveSeconds: m.hostKeepAlive(host),
+		ProxyJumps:       m.hostJumps(host),
 		Term:             term,
-	}, remotePath, display, m.cfg.Mount.LocalMountPath)
+		ForwardAgent:     m.cfg.SSH.ForwardAgent,
+		IdentitiesOnly:   m.hostIdentitiesOnly(host),
+		MaxRetries:       m.cfg.SSH.ConnectRetries,
+	}, remotePath, display, m.cfg.Mount.LocalMountPath, readOnly)
 	if err != nil {
 		m.err = err
 		return m, nil
@@ -565,11 +2042,192 @@ func (m Model) handleMountEnter(host Host) (tea.Model, tea.Cmd) {
 	return m, tea.Sequence(
 		tea.ShowCursor,
 		tea.ExecProcess(prep.Cmd(), func(err error) tea.Msg {
-			return mountFinishedMsg{action: "mount", hostID: host.ID, local: prep.LocalPath, err: err, stderr: prep.Stderr()}
+			return mountFinishedMsg{action: "mount", hostID: host.ID, remote: prep.RemotePath(), local: prep.LocalPath, err: err, stderr: prep.Stderr()}
 		}),
 	)
 }
 
+// mountsForHost returns the active mounts of host.
+func (m Model) mountsForHost(host Host) []mount.Mount {
+	if m.mountManager == nil {
+		return nil
+	}
+	return m.mountManager.MountsForHost(host.ID)
+}
+
+func (m Model) hostHasMounts(host Host) bool {
+	return len(m.mountsForHost(host)) > 0
+}
+
+// openMountsOverlay shows the mounts of host with the option to add another
+// mount at a custom remote path.
+func (m Model) openMountsOverlay(host Host) Model {
+	m.mountsHost = host
+	m.mountsCursor = 0
+	m.mountsPathField = ui.NewFormField("remote path")
+	m.mountReadOnly = m.cfg.Mount.DefaultReadOnly
+	m.err = nil
+	m.overlay = OverlayMounts
+	return m
+}
+
+// startUnmount unmounts a single mount of a host.
+func (m Model) startUnmount(key mount.MountKey) (tea.Model, tea.Cmd) {
+	if m.mountManager == nil {
+		m.err = fmt.Errorf("\u26A0 mount manager not initialized")
+		return m, nil
+	}
+	cmd, localPath, err := m.mountManager.PrepareUnmount(key)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	return m, tea.Sequence(
+		tea.ShowCursor,
+		tea.ExecProcess(cmd, func(err error) tea.Msg {
+			return mountFinishedMsg{action: "unmount", hostID: key.HostID, remote: key.RemotePath, local: localPath, err: err}
+		}),
+	)
+}
+
+// ── Port forwarding ───────────────────────────────────────────────────
+
+// openTunnels shows the active tunnels and starts the overlay's refresh timer.
+func (m Model) openTunnels() (Model, tea.Cmd) {
+	m.tunnelsGen++
+	m.tunnelsCursor = 0
+	m.tunnelsStopping = 0
+	m.armedSFTP = false
+	m.armedMount = false
+	m.err = nil
+	m.overlay = OverlayTunnels
+	return m, tunnelsTickCmd(m.tunnelsGen)
+}
+
+func (m Model) activeTunnels() []ssh.Tunnel {
+	if m.tunnelManager == nil {
+		return nil
+	}
+	return m.tunnelManager.List()
+}
+
+func (m Model) buildTunnelsViewParams() ui.TunnelsViewParams {
+	tunnels := m.activeTunnels()
+	p := ui.TunnelsViewParams{
+		Cursor:   min(m.tunnelsCursor, len(tunnels)),
+		Stopping: m.tunnelsStopping,
+	}
+	for _, t := range tunnels {
+		row := ui.TunnelRow{
+			LocalPort: t.LocalPort,
+			HostLabel: t.Hostname,
+			Hostname:  t.Hostname,
+			PID:       t.PID,
+			StartedAt: t.StartedAt,
+		}
+		if t.Type == ssh.TunnelTypeLocal {
+			row.Remote = fmt.Sprintf("%s:%d", t.RemoteHost, t.RemotePort)
+		}
+		for _, h := range m.hosts {
+			if h.ID == t.HostID {
+				row.HostLabel = hostDisplayName(h)
+				break
+			}
+		}
+		p.Tunnels = append(p.Tunnels, row)
+	}
+	if host, ok := m.selectedHost(); ok {
+		p.NewVia = hostDisplayName(host)
+	}
+	if m.err != nil {
+		p.Err = m.err.Error()
+	}
+	return p
+}
+
+// openForwardOverlay opens the form for a new local forward through host.
+func (m Model) openForwardOverlay(host Host) Model {
+	m.forwardHost = host
+	m.forwardFields[0] = ui.NewFormField("local port")
+	m.forwardFields[1] = ui.NewFormField("remote host")
+	m.forwardFields[1].SetValue("localhost")
+	m.forwardFields[2] = ui.NewFormField("remote port")
+	m.forwardFocus = 0
+	m.armedSFTP = false
+	m.armedMount = false
+	m.err = nil
+	m.overlay = OverlayForward
+	return m
+}
+
+func (m Model) startLocalForward() (tea.Model, tea.Cmd) {
+	if m.tunnelManager == nil {
+		m.err = fmt.Errorf("\u26A0 tunnel manager not initialized")
+		return m, nil
+	}
+	localPort, err := strconv.Atoi(strings.TrimSpace(m.forwardFields[0].Value))
+	if err != nil {
+		m.err = fmt.Errorf("local port must be a number")
+		m.forwardFocus = 0
+		return m, nil
+	}
+	remoteHost := strings.TrimSpace(m.forwardFields[1].Value)
+	remotePort, err := strconv.Atoi(strings.TrimSpace(m.forwardFields[2].Value))
+	if err != nil {
+		m.err = fmt.Errorf("remote port must be a number")
+		m.forwardFocus = 2
+		return m, nil
+	}
+
+	host := m.forwardHost
+	conn, _, _ := m.buildSSHConn(host)
+	t, err := m.tunnelManager.Add(host.ID, conn, localPort, remoteHost, remotePort)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	m.overlay = OverlayNone
+	m.err = fmt.Errorf("\u2713 Forwarding localhost:%d \u2192 %s:%d via %s", t.LocalPort, t.RemoteHost, t.RemotePort, hostDisplayName(host))
+	return m, nil
+}
+
+// toggleSocksProxy starts a SOCKS5 proxy through host on the configured port,
+// or stops the one already running for it.
+func (m Model) toggleSocksProxy(host Host) (tea.Model, tea.Cmd) {
+	if m.tunnelManager == nil {
+		m.err = fmt.Errorf("\u26A0 tunnel manager not initialized")
+		return m, nil
+	}
+
+	if t, ok := m.tunnelManager.Dynamic(host.ID); ok {
+		if err := m.tunnelManager.Remove(host.ID, t.LocalPort); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.err = fmt.Errorf("\u2713 SOCKS proxy on localhost:%d stopped", t.LocalPort)
+		return m, nil
+	}
+
+	conn, _, _ := m.buildSSHConn(host)
+	t, err := m.tunnelManager.AddDynamic(host.ID, conn, m.cfg.SSH.DefaultSocksPort)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	m.err = fmt.Errorf("\u2139 Starting SOCKS proxy on localhost:%d\u2026", t.LocalPort)
+	mgr := m.tunnelManager
+	name := hostDisplayName(host)
+	return m, func() tea.Msg {
+		if mgr.WaitListening(t, 10*time.Second) {
+			return proxyStartedMsg{hostID: host.ID, port: t.LocalPort, host: name}
+		}
+		_ = mgr.Remove(t.HostID, t.LocalPort)
+		return proxyStartedMsg{hostID: host.ID, port: t.LocalPort, host: name, err: fmt.Errorf("proxy did not start listening")}
+	}
+}
+
 // ── Search / spotlight ────────────────────────────────────────────────
 
 func fuzzyScore(query, candidate string) (int, bool) {
@@ -621,6 +2279,11 @@ func (m Model) buildSpotlightItems(query string) []SpotlightItem {
 		}
 		return out
 	}
+	if strings.HasPrefix(strings.ToLower(query), "tag:") {
+		if filter, err := db.ParseGroupFilter(query); err == nil {
+			return spotlightFilterHosts(m.hosts, filter)
+		}
+	}
 
 	type scoredGroup struct {
 		name  string
@@ -628,7 +2291,7 @@ func (m Model) buildSpotlightItems(query string) []SpotlightItem {
 	}
 	var groupScores []scoredGroup
 	for _, it := range m.listItems {
-		if it.Kind != ListItemGroup {
+		if it.Kind != ListItemGroup || it.Virtual {
 			continue
 		}
 		name := it.GroupName
@@ -689,6 +2352,18 @@ func (m Model) buildSpotlightItems(query string) []SpotlightItem {
 	return out
 }
 
+// spotlightFilterHosts lists the hosts matching a tag: query, which uses the
+// virtual group filter syntax (e.g. "tag:production && hostname:web").
+func spotlightFilterHosts(hosts []Host, filter db.GroupFilter) []SpotlightItem {
+	var out []SpotlightItem
+	for _, h := range hosts {
+		if filter.Matches(hostSearchTags(h), h.GroupName, h.Hostname) {
+			out = append(out, SpotlightItem{Kind: SpotlightItemHost, Host: h, GroupName: h.GroupName})
+		}
+	}
+	return out
+}
+
 // ── Token helpers ─────────────────────────────────────────────────────
 
 func (m *Model) syncTokenLabelsWithHosts() {
@@ -706,6 +2381,37 @@ func (m *Model) syncTokenLabelsWithHosts() {
 	_ = authtoken.SaveVault(vault)
 }
 
+// publishMetrics hands the current gauges to the metrics endpoint.
+func (m Model) publishMetrics() {
+	if m.metrics == nil {
+		return
+	}
+	snap := metrics.Snapshot{Hosts: len(m.hosts)}
+	if m.mountManager != nil {
+		snap.Mounts = len(m.mountManager.ListActive())
+	}
+	if m.syncManager != nil {
+		snap.SyncLastSuccess = m.syncManager.GetLastSync()
+	}
+	m.metrics.Set(snap)
+}
+
+// tokenUseCounts reads token use counts from the vault for a metrics scrape,
+// so uses by `sshthing exec` in other processes are included.
+func tokenUseCounts() map[string]int {
+	vault, err := authtoken.LoadVault()
+	if err != nil {
+		return nil
+	}
+	uses := map[string]int{}
+	for _, t := range vault.ListSummaries() {
+		if t.DeletedAt == nil {
+			uses[t.TokenID] = t.UseCount
+		}
+	}
+	return uses
+}
+
 func (m *Model) loadTokenSummaries() {
 	vault, err := authtoken.LoadVault()
 	if err != nil {
@@ -727,6 +2433,28 @@ func (m *Model) loadTokenSummaries() {
 	}
 }
 
+// checkExpiringTokens refreshes the tokens that expire within the configured
+// warning horizon. Vault errors are ignored; the token page reports them.
+func (m *Model) checkExpiringTokens() {
+	vault, err := authtoken.LoadVault()
+	if err != nil {
+		m.expiringTokens = nil
+		return
+	}
+	m.expiringTokens = authtoken.CheckExpiringTokens(vault, m.cfg.Automation.ExpiryWarningHorizon)
+}
+
+func (m Model) expiringTokensNotice() string {
+	switch n := len(m.expiringTokens); n {
+	case 0:
+		return ""
+	case 1:
+		return "\u26A0 1 token expires soon"
+	default:
+		return fmt.Sprintf("\u26A0 %d tokens expire soon", n)
+	}
+}
+
 func (m Model) selectedTokenHostGrants() ([]authtoken.HostGrant, error) {
 	grants := make([]authtoken.HostGrant, 0, len(m.tokenHostPick))
 	for _, h := range m.hosts {
@@ -743,7 +2471,11 @@ func (m Model) selectedTokenHostGrants() ([]authtoken.HostGrant, error) {
 		if strings.TrimSpace(secret) == "" {
 			return nil, fmt.Errorf("host '%s' has no usable auth secret", hostDisplayName(h))
 		}
-		grants = append(grants, authtoken.HostGrant{HostID: h.ID, DisplayLabel: hostDisplayName(h)})
+		grant := authtoken.HostGrant{HostID: h.ID, DisplayLabel: hostDisplayName(h)}
+		if pattern := strings.TrimSpace(m.tokenHostCmds[h.ID]); pattern != "" {
+			grant.AllowedCommands = []string{pattern}
+		}
+		grants = append(grants, grant)
 	}
 	if len(grants) == 0 {
 		return nil, fmt.Errorf("no eligible hosts selected")
@@ -783,11 +2515,21 @@ func (m Model) tokenManagerTokenRows() []ui.TokenViewItem {
 		if name == "" {
 			name = t.TokenID
 		}
+		var audit []ui.TokenAuditItem
+		for _, a := range t.RecentAudit {
+			audit = append(audit, ui.TokenAuditItem{
+				When:    a.Timestamp.Local().Format("2006-01-02 15:04"),
+				Target:  a.TargetLabel,
+				Command: a.Command,
+				Success: a.Success,
+			})
+		}
 		out = append(out, ui.TokenViewItem{
 			Name:    name,
 			Scope:   scope,
 			Created: t.CreatedAt.Local().Format("2006-01-02"),
 			LastUse: lastUsed,
+			Audit:   audit,
 		})
 	}
 	return out
@@ -801,6 +2543,7 @@ func (m Model) buildTokenHostItems() []ui.TokenHostItem {
 			Label:    hostDisplayName(h),
 			Detail:   fmt.Sprintf("%s@%s:%d", h.Username, h.Hostname, h.Port),
 			Selected: m.tokenHostPick[h.ID],
+			Command:  m.tokenHostCmds[h.ID],
 		})
 	}
 	return out
@@ -815,13 +2558,17 @@ func (m *Model) buildSettingsItems() []ui.SettingsItem {
 		}
 		return "off"
 	}
+	stats := m.dbSettingsStats()
+	kdf := m.kdfSettingsRows()
 
 	items := []ui.SettingsItem{
 		// UI
 		{Category: "ui", Label: "vim mode", Value: boolVal(m.cfg.UI.VimMode), Kind: 0},
 		{Category: "ui", Label: "show icons", Value: boolVal(m.cfg.UI.ShowIcons), Kind: 0},
-		{Category: "ui", Label: "theme", Value: m.cfg.UI.Theme, Kind: 1, Options: themeNames(), OptIdx: themeIdx(m.cfg.UI.Theme)},
+		{Category: "ui", Label: "theme", Value: m.cfg.UI.Theme, Kind: 1, Options: themeNames(), OptIdx: themeIdx(m.cfg.UI.Theme), Disabled: m.cfg.UI.HighContrast},
 		{Category: "ui", Label: "icon set", Value: m.cfg.UI.IconSet, Kind: 1, Options: iconSetNames(), OptIdx: iconSetIdx(m.cfg.UI.IconSet)},
+		{Category: "ui", Label: "host ping interval", Value: autoSyncIntervalLabel(m.cfg.UI.PingIntervalSeconds), Kind: 2},
+		{Category: "ui", Label: "high contrast", Value: boolVal(m.cfg.UI.HighContrast), Kind: 0},
 		// SSH
 		{Category: "ssh", Label: "host key policy", Value: string(m.cfg.SSH.HostKeyPolicy), Kind: 1, Options: []string{"accept-new", "strict", "off"}},
 		{Category: "ssh", Label: "keepalive seconds", Value: fmt.Sprintf("%d", m.cfg.SSH.KeepAliveSeconds), Kind: 2},
@@ -829,17 +2576,36 @@ func (m *Model) buildSettingsItems() []ui.SettingsItem {
 		{Category: "ssh", Label: "TERM custom", Value: m.cfg.SSH.TermCustom, Kind: 2, Disabled: m.cfg.SSH.TermMode != config.TermCustom},
 		{Category: "ssh", Label: "password auto-login", Value: boolVal(m.cfg.SSH.PasswordAutoLogin), Kind: 0},
 		{Category: "ssh", Label: "password backend (unix)", Value: string(m.cfg.SSH.PasswordBackendUnix), Kind: 1, Options: []string{"sshpass_first", "askpass_first"}, Disabled: runtime.GOOS == "windows" || !m.cfg.SSH.PasswordAutoLogin},
+		{Category: "ssh", Label: "record sessions", Value: boolVal(m.cfg.SSH.RecordSessions), Kind: 0, Disabled: runtime.GOOS == "windows"},
+		{Category: "ssh", Label: "socks proxy port", Value: fmt.Sprintf("%d", m.cfg.SSH.DefaultSocksPort), Kind: 2},
+		{Category: "ssh", Label: "connection sharing", Value: boolVal(m.cfg.SSH.ControlMasterEnabled), Kind: 0, Disabled: runtime.GOOS == "windows"},
+		{Category: "ssh", Label: "connect retries", Value: fmt.Sprintf("%d", m.cfg.SSH.ConnectRetries), Kind: 2},
+		{Category: "ssh", Label: "bulk run concurrency", Value: fmt.Sprintf("%d", m.cfg.SSH.BulkExecConcurrency), Kind: 2},
+		{Category: "ssh", Label: "connect after wake", Value: boolVal(m.cfg.SSH.WakeAutoConnect), Kind: 0},
+		{Category: "ssh", Label: "x11 forwarding", Value: boolVal(m.cfg.SSH.ForwardX11), Kind: 0, Disabled: runtime.GOOS == "windows"},
+		{Category: "ssh", Label: "trust x11", Value: boolVal(m.cfg.SSH.TrustX11), Kind: 0, Disabled: runtime.GOOS == "windows" || !m.cfg.SSH.ForwardX11},
+		{Category: "ssh", Label: "agent forwarding", Value: boolVal(m.cfg.SSH.ForwardAgent), Kind: 0},
+		{Category: "ssh", Label: "identities only", Value: boolVal(m.cfg.SSH.IdentitiesOnly), Kind: 0},
+		{Category: "ssh", Label: "banner refresh", Value: autoSyncIntervalLabel(m.cfg.SSH.BannerRefreshSeconds), Kind: 2},
 		// Mount
 		{Category: "mount", Label: "enable mounts", Value: boolVal(m.cfg.Mount.Enabled), Kind: 0},
 		{Category: "mount", Label: "default remote path", Value: m.cfg.Mount.DefaultRemotePath, Kind: 2},
 		{Category: "mount", Label: "local mount path", Value: m.cfg.Mount.LocalMountPath, Kind: 2},
 		{Category: "mount", Label: "quit behavior", Value: string(m.cfg.Mount.QuitBehavior), Kind: 1, Options: []string{"prompt", "always_unmount", "leave_mounted"}},
+		{Category: "mount", Label: "read-only by default", Value: boolVal(m.cfg.Mount.DefaultReadOnly), Kind: 0},
 		// Sync
 		{Category: "sync", Label: "enable sync", Value: boolVal(m.cfg.Sync.Enabled), Kind: 0},
 		{Category: "sync", Label: "repo url", Value: m.cfg.Sync.RepoURL, Kind: 2, Disabled: !m.cfg.Sync.Enabled},
 		{Category: "sync", Label: "ssh key path", Value: m.cfg.Sync.SSHKeyPath, Kind: 2, Disabled: !m.cfg.Sync.Enabled},
 		{Category: "sync", Label: "branch", Value: m.cfg.Sync.Branch, Kind: 2, Disabled: !m.cfg.Sync.Enabled},
 		{Category: "sync", Label: "local path", Value: m.cfg.Sync.LocalPath, Kind: 2, Disabled: !m.cfg.Sync.Enabled},
+		{Category: "sync", Label: "dry run", Value: "", Kind: 2, Disabled: !m.cfg.Sync.Enabled},
+		{Category: "sync", Label: "rollback last sync", Value: "", Kind: 2, Disabled: !m.cfg.Sync.Enabled},
+		{Category: "sync", Label: "encrypt sync file", Value: boolVal(m.cfg.Sync.EncryptPayload), Kind: 0, Disabled: !m.cfg.Sync.Enabled},
+		{Category: "sync", Label: "pull only", Value: boolVal(m.cfg.Sync.PullOnly), Kind: 0, Disabled: !m.cfg.Sync.Enabled},
+		{Category: "sync", Label: "push only", Value: boolVal(m.cfg.Sync.PushOnly), Kind: 0, Disabled: !m.cfg.Sync.Enabled},
+		{Category: "sync", Label: "webhook url", Value: m.cfg.Sync.WebhookURL, Kind: 2, Disabled: !m.cfg.Sync.Enabled},
+		{Category: "sync", Label: "test webhook", Value: "", Kind: 2, Disabled: !m.cfg.Sync.Enabled || m.cfg.Sync.WebhookURL == ""},
 		// Updates
 		{Category: "updates", Label: "channel", Value: m.updateSettingsState().ChannelLabel, Kind: 2},
 		{Category: "updates", Label: "version", Value: m.updateSettingsState().VersionLabel, Kind: 2},
@@ -851,6 +2617,21 @@ func (m *Model) buildSettingsItems() []ui.SettingsItem {
 		// Tokens
 		{Category: "tokens", Label: "manage tokens", Value: "", Kind: 2},
 		{Category: "tokens", Label: "sync token definitions", Value: boolVal(m.cfg.Automation.SyncTokenDefinitions), Kind: 0, Disabled: !m.cfg.Sync.Enabled},
+		{Category: "tokens", Label: "expiry warning horizon", Value: formatHorizon(m.cfg.Automation.ExpiryWarningHorizon), Kind: 2},
+		{Category: "tokens", Label: "auto-sync interval", Value: autoSyncIntervalLabel(m.cfg.Automation.AutoSyncIntervalSeconds), Kind: 2, Disabled: !m.cfg.Sync.Enabled},
+		// Database
+		{Category: "database", Label: "backup now", Value: "", Kind: 2, Disabled: m.store == nil},
+		{Category: "database", Label: "restore from file", Value: "", Kind: 2},
+		{Category: "database", Label: "hosts", Value: stats.hosts, Kind: 2, Disabled: true},
+		{Category: "database", Label: "groups", Value: stats.groups, Kind: 2, Disabled: true},
+		{Category: "database", Label: "file size", Value: stats.size, Kind: 2, Disabled: true},
+		{Category: "database", Label: "vacuum now", Value: "", Kind: 2, Disabled: m.store == nil},
+		// Security
+		{Category: "security", Label: "idle lock timeout", Value: autoSyncIntervalLabel(m.cfg.Security.IdleLockSeconds), Kind: 2},
+		{Category: "security", Label: "upgrade key derivation", Value: m.kdfSettingsValue(), Kind: 2, Disabled: m.store == nil || m.kdfRekeying || !m.store.KDFUpgradeAvailable()},
+		{Category: "security", Label: "KDF time cost", Value: kdf.time, Kind: 2, Disabled: m.store == nil || m.kdfRekeying},
+		{Category: "security", Label: "KDF memory (MiB)", Value: kdf.memory, Kind: 2, Disabled: m.store == nil || m.kdfRekeying},
+		{Category: "security", Label: "benchmark KDF", Value: kdf.benchmark, Kind: 2, Disabled: m.store == nil || m.kdfBenchmarking},
 	}
 	return items
 }
@@ -929,6 +2710,40 @@ func (m Model) updateSettingsState() updateSettingsStateInfo {
 	return state
 }
 
+// openChangelog shows the release notes modal for the pending update and
+// fetches the notes in the background.
+func (m *Model) openChangelog() tea.Cmd {
+	m.changelogTag = m.updateLast.LatestTag
+	m.changelogLines = nil
+	m.changelogErr = ""
+	m.changelogLoading = true
+	m.changelogScroll = 0
+	m.changelogCursor = 0
+	m.overlay = OverlayChangelog
+	return runChangelogFetchCmd(m.changelogTag)
+}
+
+func (m *Model) startUpdateApply() tea.Cmd {
+	if m.updateLast == nil || m.updateApplying {
+		return nil
+	}
+	m.updateRunID++
+	m.updateApplying = true
+	m.err = fmt.Errorf("\u2139 Applying update...")
+	m.settingsItems = m.buildSettingsItems()
+	return runUpdateApplyCmd(m.updateRunID, *m.updateLast)
+}
+
+// skipUpdate remembers the pending release so later checks don't announce it.
+func (m *Model) skipUpdate() {
+	m.cfg.Updates.SkippedTag = m.changelogTag
+	if err := config.Save(m.cfg); err != nil {
+		m.err = fmt.Errorf("\u26A0 failed to save config: %v", err)
+		return
+	}
+	m.err = fmt.Errorf("\u2139 Skipped %s", m.changelogTag)
+}
+
 // ── Token CRUD helpers (used by handlers) ─────────────────────────────
 
 func (m Model) createToken(name string) (string, error) {
@@ -1050,6 +2865,20 @@ func (m Model) modalSelectedGroupName() string {
 	return name
 }
 
+// parseKeepAliveField reads the per-host keepalive input; empty means 0,
+// which inherits the global setting.
+func parseKeepAliveField(val string) (int, error) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 || n > 600 {
+		return 0, fmt.Errorf("keepalive must be a number of seconds between 0 and 600")
+	}
+	return n, nil
+}
+
 func (m Model) validateForm() error {
 	if len(m.formFields) < 6 {
 		return fmt.Errorf("No form data")
@@ -1077,6 +2906,42 @@ func (m Model) validateForm() error {
 		return fmt.Errorf("\u26A0 Port must be between 1 and 65535")
 	}
 
+	if len(m.formFields) > ui.FFSSHOpts {
+		if _, err := ssh.ParseOptions(m.formFields[ui.FFSSHOpts].Value); err != nil {
+			return fmt.Errorf("\u26A0 SSH options: %v", err)
+		}
+	}
+	if len(m.formFields) > ui.FFKeepAlive {
+		if _, err := parseKeepAliveField(m.formFields[ui.FFKeepAlive].Value); err != nil {
+			return fmt.Errorf("\u26A0 %v", err)
+		}
+	}
+	if len(m.formFields) > ui.FFTerm {
+		if strings.ContainsAny(strings.TrimSpace(m.formFields[ui.FFTerm].Value), " \t=") {
+			return fmt.Errorf("\u26A0 TERM must be a single terminal name, e.g. vt100")
+		}
+	}
+	if len(m.formFields) > ui.FFIcon {
+		if !ui.ValidHostIcon(strings.TrimSpace(m.formFields[ui.FFIcon].Value)) {
+			return fmt.Errorf("\u26A0 Icon must be a single emoji, e.g. %s", ui.HostIconPresets[0])
+		}
+	}
+	if len(m.formFields) > ui.FFColor {
+		if !ui.ValidHostColor(strings.TrimSpace(m.formFields[ui.FFColor].Value)) {
+			return fmt.Errorf("\u26A0 Color must be a hex code, e.g. %s", ui.HostColorPresets[0])
+		}
+	}
+	if len(m.formFields) > ui.FFBroadcast {
+		if mac := strings.TrimSpace(m.formFields[ui.FFMAC].Value); mac != "" {
+			if _, err := wol.ParseMAC(mac); err != nil {
+				return fmt.Errorf("\u26A0 %v", err)
+			}
+		}
+		if _, err := wol.BroadcastTarget(m.formFields[ui.FFBroadcast].Value); err != nil {
+			return fmt.Errorf("\u26A0 %v", err)
+		}
+	}
+
 	switch m.formAuthIdx {
 	case 0: // password - optional
 	case 1: // paste key
@@ -1118,6 +2983,9 @@ func (m *Model) applySettingChange(idx int, action string) {
 	case 1: // show icons
 		m.cfg.UI.ShowIcons = !m.cfg.UI.ShowIcons
 	case 2: // theme
+		if m.cfg.UI.HighContrast {
+			break
+		}
 		names := themeNames()
 		cur := themeIdx(m.cfg.UI.Theme)
 		if action == "left" {
@@ -1126,7 +2994,7 @@ func (m *Model) applySettingChange(idx int, action string) {
 			cur = (cur + 1) % len(names)
 		}
 		m.cfg.UI.Theme = names[cur]
-		m.theme, m.themeIdx = ui.ThemeByName(m.cfg.UI.Theme)
+		m.theme, m.themeIdx = ui.ThemeFor(m.cfg.UI.Theme, m.cfg.UI.HighContrast)
 	case 3: // icon set
 		iNames := iconSetNames()
 		cur := iconSetIdx(m.cfg.UI.IconSet)
@@ -1137,7 +3005,11 @@ func (m *Model) applySettingChange(idx int, action string) {
 		}
 		m.cfg.UI.IconSet = iNames[cur]
 		m.icons, m.iconIdx = ui.IconSetByName(m.cfg.UI.IconSet)
-	case 4: // host key policy
+	case 4: // host ping interval - editable
+	case 5: // high contrast
+		m.cfg.UI.HighContrast = !m.cfg.UI.HighContrast
+		m.theme, m.themeIdx = ui.ThemeFor(m.cfg.UI.Theme, m.cfg.UI.HighContrast)
+	case 6: // host key policy
 		switch m.cfg.SSH.HostKeyPolicy {
 		case config.HostKeyAcceptNew:
 			m.cfg.SSH.HostKeyPolicy = config.HostKeyStrict
@@ -1146,13 +3018,13 @@ func (m *Model) applySettingChange(idx int, action string) {
 		default:
 			m.cfg.SSH.HostKeyPolicy = config.HostKeyAcceptNew
 		}
-	case 5: // keepalive - editable
+	case 7: // keepalive - editable
 		if action == "left" {
 			m.cfg.SSH.KeepAliveSeconds = max(10, m.cfg.SSH.KeepAliveSeconds-5)
 		} else if action == "right" {
 			m.cfg.SSH.KeepAliveSeconds = min(300, m.cfg.SSH.KeepAliveSeconds+5)
 		}
-	case 6: // TERM mode
+	case 8: // TERM mode
 		switch m.cfg.SSH.TermMode {
 		case config.TermAuto:
 			m.cfg.SSH.TermMode = config.TermXterm
@@ -1161,15 +3033,15 @@ func (m *Model) applySettingChange(idx int, action string) {
 		default:
 			m.cfg.SSH.TermMode = config.TermAuto
 		}
-	case 7: // TERM custom - editable
-	case 8: // password auto login
+	case 9: // TERM custom - editable
+	case 10: // password auto login
 		m.cfg.SSH.PasswordAutoLogin = !m.cfg.SSH.PasswordAutoLogin
 		if m.cfg.SSH.PasswordAutoLogin && (runtime.GOOS == "linux" || runtime.GOOS == "darwin") {
 			if err := ssh.CheckSSHPass(); err != nil {
 				m.err = fmt.Errorf("Tip: install sshpass for best password auto-login on %s", runtime.GOOS)
 			}
 		}
-	case 9: // password backend
+	case 11: // password backend
 		if runtime.GOOS != "windows" && m.cfg.SSH.PasswordAutoLogin {
 			switch m.cfg.SSH.PasswordBackendUnix {
 			case config.PasswordBackendSSHPassFirst:
@@ -1178,11 +3050,47 @@ func (m *Model) applySettingChange(idx int, action string) {
 				m.cfg.SSH.PasswordBackendUnix = config.PasswordBackendSSHPassFirst
 			}
 		}
-	case 10: // mount enabled
+	case 12: // record sessions
+		if runtime.GOOS != "windows" {
+			m.cfg.SSH.RecordSessions = !m.cfg.SSH.RecordSessions
+		}
+	case 13: // socks proxy port - editable
+	case 14: // connection sharing (ControlMaster)
+		if runtime.GOOS != "windows" {
+			m.cfg.SSH.ControlMasterEnabled = !m.cfg.SSH.ControlMasterEnabled
+		}
+	case 15: // connect retries - editable
+		if action == "left" {
+			m.cfg.SSH.ConnectRetries = max(0, m.cfg.SSH.ConnectRetries-1)
+		} else if action == "right" {
+			m.cfg.SSH.ConnectRetries = min(10, m.cfg.SSH.ConnectRetries+1)
+		}
+	case 16: // bulk run concurrency - editable
+		if action == "left" {
+			m.cfg.SSH.BulkExecConcurrency = max(1, m.cfg.SSH.BulkExecConcurrency-1)
+		} else if action == "right" {
+			m.cfg.SSH.BulkExecConcurrency = min(32, m.cfg.SSH.BulkExecConcurrency+1)
+		}
+	case 17: // wake auto-connect
+		m.cfg.SSH.WakeAutoConnect = !m.cfg.SSH.WakeAutoConnect
+	case 18: // x11 forwarding
+		if runtime.GOOS != "windows" {
+			m.cfg.SSH.ForwardX11 = !m.cfg.SSH.ForwardX11
+		}
+	case 19: // trust x11 (-Y)
+		if runtime.GOOS != "windows" && m.cfg.SSH.ForwardX11 {
+			m.cfg.SSH.TrustX11 = !m.cfg.SSH.TrustX11
+		}
+	case 20: // agent forwarding (-A)
+		m.cfg.SSH.ForwardAgent = !m.cfg.SSH.ForwardAgent
+	case 21: // identities only
+		m.cfg.SSH.IdentitiesOnly = !m.cfg.SSH.IdentitiesOnly
+	case 22: // banner refresh - editable
+	case 23: // mount enabled
 		m.cfg.Mount.Enabled = !m.cfg.Mount.Enabled
-	case 11: // mount remote path - editable
-	case 12: // mount local path - editable
-	case 13: // mount quit behavior
+	case 24: // mount remote path - editable
+	case 25: // mount local path - editable
+	case 26: // mount quit behavior
 		switch m.cfg.Mount.QuitBehavior {
 		case config.MountQuitPrompt:
 			m.cfg.Mount.QuitBehavior = config.MountQuitAlwaysUnmount
@@ -1191,7 +3099,9 @@ func (m *Model) applySettingChange(idx int, action string) {
 		default:
 			m.cfg.Mount.QuitBehavior = config.MountQuitPrompt
 		}
-	case 14: // sync enabled
+	case 27: // mount read-only by default
+		m.cfg.Mount.DefaultReadOnly = !m.cfg.Mount.DefaultReadOnly
+	case 28: // sync enabled
 		m.cfg.Sync.Enabled = !m.cfg.Sync.Enabled
 		if m.store != nil {
 			syncMgr, err := syncpkg.NewManager(&m.cfg, m.store, m.masterPassword)
@@ -1199,19 +3109,54 @@ func (m *Model) applySettingChange(idx int, action string) {
 				m.syncManager = syncMgr
 			}
 		}
-	case 15, 16, 17, 18: // sync repo/key/branch/local - editable
-	case 26: // manage tokens (opens token page)
-	case 27: // sync token definitions
+	case 29, 30, 31, 32: // sync repo/key/branch/local - editable
+	case 33: // sync dry run (opens preview)
+	case 34: // sync rollback (opens confirmation)
+	case 35: // encrypt sync file
+		if m.cfg.Sync.Enabled {
+			m.cfg.Sync.EncryptPayload = !m.cfg.Sync.EncryptPayload
+		}
+	case 36: // pull only (clears push only)
+		if m.cfg.Sync.Enabled {
+			m.cfg.Sync.PullOnly = !m.cfg.Sync.PullOnly
+			if m.cfg.Sync.PullOnly {
+				m.cfg.Sync.PushOnly = false
+			}
+		}
+	case 37: // push only (clears pull only)
+		if m.cfg.Sync.Enabled {
+			m.cfg.Sync.PushOnly = !m.cfg.Sync.PushOnly
+			if m.cfg.Sync.PushOnly {
+				m.cfg.Sync.PullOnly = false
+			}
+		}
+	case 38: // sync webhook url - editable
+	case 39: // test webhook (sends a sample payload)
+	case 47: // manage tokens (opens token page)
+	case 48: // sync token definitions
 		if m.cfg.Sync.Enabled {
 			m.cfg.Automation.SyncTokenDefinitions = !m.cfg.Automation.SyncTokenDefinitions
 		}
+	case 49: // expiry warning horizon - editable
+	case 50: // auto-sync interval - editable
 	}
 }
 
 func (m *Model) applySettingsEditValue(idx int, val string) bool {
 	val = strings.TrimSpace(val)
 	switch idx {
-	case 5: // keepalive
+	case 4: // host ping interval
+		if val == "" || val == "0" || strings.EqualFold(val, "off") {
+			m.cfg.UI.PingIntervalSeconds = 0
+			break
+		}
+		d, err := time.ParseDuration(val)
+		if err != nil || d < 5*time.Second {
+			m.err = fmt.Errorf("\u26A0 interval must be a duration of at least 5s (e.g. 30s, 5m), or 0 to disable")
+			return false
+		}
+		m.cfg.UI.PingIntervalSeconds = int(d / time.Second)
+	case 7: // keepalive
 		n, err := strconv.Atoi(val)
 		if err != nil {
 			m.err = fmt.Errorf("keepalive must be a number")
@@ -1224,15 +3169,47 @@ func (m *Model) applySettingsEditValue(idx int, val string) bool {
 			n = 600
 		}
 		m.cfg.SSH.KeepAliveSeconds = n
-	case 7: // TERM custom
+	case 9: // TERM custom
 		m.cfg.SSH.TermCustom = val
-	case 11: // mount remote path
+	case 13: // socks proxy port
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 || n > 65535 {
+			m.err = fmt.Errorf("socks port must be a number between 1 and 65535")
+			return false
+		}
+		m.cfg.SSH.DefaultSocksPort = n
+	case 15: // connect retries
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 || n > 10 {
+			m.err = fmt.Errorf("connect retries must be a number between 0 and 10")
+			return false
+		}
+		m.cfg.SSH.ConnectRetries = n
+	case 16: // bulk run concurrency
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 || n > 32 {
+			m.err = fmt.Errorf("bulk run concurrency must be a number between 1 and 32")
+			return false
+		}
+		m.cfg.SSH.BulkExecConcurrency = n
+	case 22: // banner refresh
+		if val == "" || val == "0" || strings.EqualFold(val, "off") {
+			m.cfg.SSH.BannerRefreshSeconds = 0
+			break
+		}
+		d, err := time.ParseDuration(val)
+		if err != nil || d < time.Minute {
+			m.err = fmt.Errorf("\u26A0 banner refresh must be a duration of at least 1m (e.g. 1h, 24h), or 0 to disable")
+			return false
+		}
+		m.cfg.SSH.BannerRefreshSeconds = int(d / time.Second)
+	case 24: // mount remote path
 		if val != "" && !strings.HasPrefix(val, "/") {
 			m.err = fmt.Errorf("\u26A0 remote path must be absolute (start with /)")
 			return false
 		}
 		m.cfg.Mount.DefaultRemotePath = val
-	case 12: // local mount path
+	case 25: // local mount path
 		if val != "" {
 			if !strings.HasPrefix(val, "/") {
 				m.err = fmt.Errorf("\u26A0 mount path must be absolute (start with /)")
@@ -1250,21 +3227,339 @@ func (m *Model) applySettingsEditValue(idx int, val string) bool {
 			}
 		}
 		m.cfg.Mount.LocalMountPath = val
-	case 15: // sync repo
+	case 29: // sync repo
 		m.cfg.Sync.RepoURL = val
-	case 16: // sync key path
+	case 30: // sync key path
 		m.cfg.Sync.SSHKeyPath = val
-	case 17: // sync branch
+	case 31: // sync branch
 		if val == "" {
 			val = "main"
 		}
 		m.cfg.Sync.Branch = val
-	case 18: // sync local path
+	case 32: // sync local path
 		m.cfg.Sync.LocalPath = val
+	case 38: // sync webhook url
+		if val != "" {
+			u, err := url.Parse(val)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				m.err = fmt.Errorf("\u26A0 webhook url must be an http:// or https:// URL")
+				return false
+			}
+		}
+		m.cfg.Sync.WebhookURL = val
+	case 49: // expiry warning horizon
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			m.err = fmt.Errorf("\u26A0 horizon must be a duration like 72h or 30m")
+			return false
+		}
+		m.cfg.Automation.ExpiryWarningHorizon = d
+		m.checkExpiringTokens()
+	case 50: // auto-sync interval
+		if val == "" || val == "0" || strings.EqualFold(val, "off") {
+			m.cfg.Automation.AutoSyncIntervalSeconds = 0
+			break
+		}
+		d, err := time.ParseDuration(val)
+		if err != nil || d < time.Minute {
+			m.err = fmt.Errorf("\u26A0 interval must be a duration of at least 1m (e.g. 15m), or 0 to disable")
+			return false
+		}
+		m.cfg.Automation.AutoSyncIntervalSeconds = int(d / time.Second)
+	case 52: // restore database from file
+		if val == "" {
+			return true
+		}
+		m.restoreDatabase(expandHome(val))
+	case 57: // idle lock timeout
+		if val == "" || val == "0" || strings.EqualFold(val, "off") {
+			m.cfg.Security.IdleLockSeconds = 0
+			break
+		}
+		d, err := time.ParseDuration(val)
+		if err != nil || d < time.Minute {
+			m.err = fmt.Errorf("\u26A0 timeout must be a duration of at least 1m (e.g. 5m, 30m, 1h), or 0 to disable")
+			return false
+		}
+		m.cfg.Security.IdleLockSeconds = int(d / time.Second)
+	case 59: // KDF time cost
+		if m.kdfRekeying {
+			m.err = fmt.Errorf("\u26A0 a key derivation change is already running")
+			return false
+		}
+		if m.syncing {
+			m.err = fmt.Errorf("\u2139 wait for the sync to finish")
+			return false
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 || n > kdfMaxTime {
+			m.err = fmt.Errorf("\u26A0 time cost must be a number from 1 to %d", kdfMaxTime)
+			return false
+		}
+		p := m.editableKDFParams()
+		p.Time = uint32(n)
+		m.setKDFParams(p)
+	case 60: // KDF memory (MiB)
+		if m.kdfRekeying {
+			m.err = fmt.Errorf("\u26A0 a key derivation change is already running")
+			return false
+		}
+		if m.syncing {
+			m.err = fmt.Errorf("\u2139 wait for the sync to finish")
+			return false
+		}
+		minMiB := int(db.DefaultKDFParams.Memory / 1024)
+		n, err := strconv.Atoi(val)
+		if err != nil || n < minMiB || n > kdfMaxMemoryMiB {
+			m.err = fmt.Errorf("\u26A0 memory must be a number of MiB from %d to %d", minMiB, kdfMaxMemoryMiB)
+			return false
+		}
+		p := m.editableKDFParams()
+		p.Memory = uint32(n) * 1024
+		m.setKDFParams(p)
+	}
+	return true
+}
+
+// Bounds for the KDF settings rows.
+const (
+	kdfMaxTime      = 20
+	kdfMaxMemoryMiB = 1024
+)
+
+// kdfSettingsValues holds the formatted KDF rows for the settings page.
+type kdfSettingsValues struct {
+	time, memory, benchmark string
+}
+
+func (m Model) kdfSettingsRows() kdfSettingsValues {
+	var v kdfSettingsValues
+	if m.store != nil {
+		if p := m.store.KDFParams(); !p.Legacy() {
+			v.time = fmt.Sprintf("%d", p.Time)
+			v.memory = fmt.Sprintf("%d", p.Memory/1024)
+		} else {
+			v.time, v.memory = "PBKDF2", "PBKDF2"
+		}
+	}
+	switch {
+	case m.kdfBenchmarking:
+		v.benchmark = "running\u2026"
+	case m.kdfSuggestion != nil:
+		v.benchmark = fmt.Sprintf("~%dms: time %d, %d MiB", kdfBenchmarkTargetMillis, m.kdfSuggestion.Time, m.kdfSuggestion.Memory/1024)
+	}
+	return v
+}
+
+// editableKDFParams returns the current KDF parameters as the base for an
+// edit; a PBKDF2 database starts from the Argon2id defaults.
+func (m Model) editableKDFParams() db.KDFParams {
+	p := m.store.KDFParams()
+	if p.Legacy() {
+		return db.DefaultKDFParams
+	}
+	return p
+}
+
+// setKDFParams queues a re-key of host secrets with p; the settings handler
+// starts it with takeKDFRekeyCmd once the edit is accepted.
+func (m *Model) setKDFParams(p db.KDFParams) {
+	if m.store == nil {
+		return
+	}
+	m.kdfRekeyPending = &p
+}
+
+// takeKDFRekeyCmd starts the queued re-key under the current master
+// password, or returns nil when none is queued.
+func (m *Model) takeKDFRekeyCmd() tea.Cmd {
+	if m.kdfRekeyPending == nil || m.store == nil {
+		m.kdfRekeyPending = nil
+		return nil
+	}
+	p := *m.kdfRekeyPending
+	m.kdfRekeyPending = nil
+	m.kdfRekeying = true
+	m.err = fmt.Errorf("\u2139 Re-keying host secrets...")
+	return rekeyKDFCmd(m.store, m.masterPassword, p, false)
+}
+
+// dbStatsValues holds the formatted database statistics for the settings page.
+type dbStatsValues struct {
+	hosts, groups, size string
+}
+
+func (m Model) dbSettingsStats() dbStatsValues {
+	if m.store == nil {
+		return dbStatsValues{}
+	}
+	st, err := m.store.Stats()
+	if err != nil {
+		return dbStatsValues{hosts: "?", groups: "?", size: "?"}
+	}
+	return dbStatsValues{
+		hosts:  strconv.Itoa(st.HostCount),
+		groups: strconv.Itoa(st.GroupCount),
+		size:   ui.FormatSize(int64(st.FileSizeBytes)),
+	}
+}
+
+// vacuumDatabase compacts the database and reports how much space was reclaimed.
+func (m *Model) vacuumDatabase() {
+	before, _ := m.store.Stats()
+	if err := m.store.Vacuum(); err != nil {
+		m.err = fmt.Errorf("\u26A0 vacuum failed: %v", err)
+		return
+	}
+	after, err := m.store.Stats()
+	if err != nil {
+		m.err = fmt.Errorf("\u2713 Database vacuumed")
+		return
+	}
+	m.err = fmt.Errorf("\u2713 Database vacuumed (%s \u2192 %s)", ui.FormatSize(int64(before.FileSizeBytes)), ui.FormatSize(int64(after.FileSizeBytes)))
+}
+
+// backupDatabase writes a timestamped copy of the encrypted database next to it.
+func (m *Model) backupDatabase() {
+	path, err := db.DefaultBackupPath(time.Now())
+	if err == nil && m.store != nil {
+		err = m.store.Checkpoint()
+	}
+	if err == nil {
+		_, err = db.Backup(path)
+	}
+	if err != nil {
+		m.err = fmt.Errorf("\u26A0 backup failed: %v", err)
+		return
+	}
+	m.err = fmt.Errorf("\u2713 Backup saved to %s", path)
+}
+
+func (m Model) kdfSettingsValue() string {
+	if m.store == nil {
+		return ""
+	}
+	if m.kdfRekeying {
+		return "re-keying\u2026"
 	}
+	if m.store.KDFUpgradeAvailable() {
+		return m.store.KDFParams().String() + " (upgrade available)"
+	}
+	return m.store.KDFParams().String()
+}
+
+// upgradeKDF re-encrypts host secrets with the default KDF parameters in
+// the background.
+func (m *Model) upgradeKDF() tea.Cmd {
+	m.kdfRekeying = true
+	m.err = fmt.Errorf("\u2139 Upgrading key derivation...")
+	return rekeyKDFCmd(m.store, m.masterPassword, db.DefaultKDFParams, true)
+}
+
+// rekeyBusy reports whether a key derivation change is running, telling the
+// user to wait if so. Host secrets, sync and locking all depend on the key it
+// is replacing.
+func (m *Model) rekeyBusy() bool {
+	if !m.kdfRekeying {
+		return false
+	}
+	m.err = fmt.Errorf("\u2139 wait for the key derivation change to finish")
 	return true
 }
 
+// restoreDatabase replaces the database with the backup at path and locks
+// the app, since the backup may use a different master password.
+func (m *Model) restoreDatabase(path string) {
+	if m.rekeyBusy() {
+		return
+	}
+	if m.store != nil {
+		m.store.Close()
+	}
+	if err := db.Restore(path, true); err != nil {
+		// The old database is still in place; reopen it.
+		if store, rerr := db.Init(m.masterPassword); rerr == nil {
+			m.store = store
+		} else {
+			m.store = nil
+		}
+		m.err = fmt.Errorf("\u26A0 restore failed: %v", err)
+		return
+	}
+	_ = unlock.Clear()
+	m.store = nil
+	_ = config.Save(m.cfg)
+	m.cfgOriginal = m.cfg
+	m.lockApp()
+	m.err = fmt.Errorf("\u2713 Restored successfully \u2014 unlock with the backup's password")
+}
+
+// idleLockDue reports whether the idle auto-lock timeout has passed. It
+// waits while a sync or re-key is using the store; the next idle check after
+// it finishes locks.
+func (m Model) idleLockDue(now time.Time) bool {
+	timeout := time.Duration(m.cfg.Security.IdleLockSeconds) * time.Second
+	if timeout <= 0 || m.store == nil || m.lastInputAt.IsZero() {
+		return false
+	}
+	if m.syncing || m.syncConflictApplying || m.kdfRekeying {
+		return false
+	}
+	if m.overlay == OverlayLogin || m.overlay == OverlaySetup {
+		return false
+	}
+	return now.Sub(m.lastInputAt) >= timeout
+}
+
+// lockApp closes the database, drops decrypted state and shows the login
+// prompt. Logging in again reloads hosts as on startup.
+func (m *Model) lockApp() {
+	if m.store != nil {
+		m.store.Close()
+	}
+	m.closeSFTPBrowser()
+	m.clearKeyPassphrase()
+	m.store = nil
+	m.syncManager = nil
+	m.autoSyncGen++
+	m.pingGen++
+	m.waking = false
+	m.wakeRunID++
+	m.sessionGen++
+	m.sessionUntil = time.Time{}
+	m.sessionExpired = false
+	m.masterPassword = ""
+	m.hosts = []Host{}
+	m.listItems = []ListItem{}
+	m.selectedSet = map[int]bool{}
+	m.undoStack = nil
+	m.redoStack = nil
+	m.settingsEditing = false
+	m.page = PageHome
+	m.loginField = ui.NewMaskedField("password")
+	m.loginError = ""
+	m.overlay = OverlayLogin
+}
+
+func autoSyncIntervalLabel(seconds int) string {
+	if seconds <= 0 {
+		return "off"
+	}
+	return formatHorizon(time.Duration(seconds) * time.Second)
+}
+
+// formatHorizon renders d without trailing zero units (72h instead of 72h0m0s).
+func formatHorizon(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
 // ── View data builders ────────────────────────────────────────────────
 
 func (m Model) buildHomeViewParams() ui.HomeViewParams {
@@ -1275,9 +3570,13 @@ func (m Model) buildHomeViewParams() ui.HomeViewParams {
 		case ListItemGroup:
 			items = append(items, ui.HomeListItem{
 				IsGroup:   true,
+				Indent:    it.Depth,
 				GroupName: it.GroupName,
-				Collapsed: m.collapsed[it.GroupName],
+				Collapsed: m.collapsed[it.collapseKey()],
 				HostCount: it.Count,
+				Virtual:   it.Virtual,
+				Filter:    it.Filter,
+				FilterErr: it.FilterErr,
 			})
 		case ListItemNewGroup:
 			items = append(items, ui.HomeListItem{
@@ -1285,23 +3584,27 @@ func (m Model) buildHomeViewParams() ui.HomeViewParams {
 			})
 		case ListItemHost:
 			host := it.Host
-			mounted := false
-			mountPath := ""
-			if m.mountManager != nil {
-				if ok, mt := m.mountManager.IsMounted(host.ID); ok && mt != nil {
-					mounted = true
-					mountPath = mt.LocalPath
+			var mounts []ui.HomeMount
+			for _, mt := range m.mountsForHost(host) {
+				mounts = append(mounts, ui.HomeMount{LocalPath: mt.LocalPath, RemotePath: mt.RemotePath, ReadOnly: mt.ReadOnly})
+			}
+
+			proxyPort := 0
+			if m.tunnelManager != nil {
+				if t, ok := m.tunnelManager.Dynamic(host.ID); ok {
+					proxyPort = t.LocalPort
 				}
 			}
 
 			status := 0 // offline
-			if mounted {
+			if len(mounts) > 0 {
 				status = 2 // connected
 			} else if host.LastConnected != nil && time.Since(*host.LastConnected) < 5*time.Minute {
 				status = 1 // idle
 			}
 
 			items = append(items, ui.HomeListItem{
+				Indent:        it.Depth,
 				Label:         host.Label,
 				GroupName:     host.GroupName,
 				Hostname:      host.Hostname,
@@ -1310,9 +3613,22 @@ func (m Model) buildHomeViewParams() ui.HomeViewParams {
 				KeyType:       host.KeyType,
 				Tags:          hostSearchTags(host),
 				Status:        status,
-				Mounted:       mounted,
-				MountPath:     mountPath,
+				Mounts:        mounts,
+				ProxyPort:     proxyPort,
 				LastConnected: host.LastConnected,
+				Marked:        m.selectedSet[host.ID],
+				Pinned:        host.Pinned,
+				Icon:          host.Icon,
+				Color:         m.hostColor(host),
+
+				Fingerprint:        host.Fingerprint,
+				FingerprintChanged: host.FingerprintChanged,
+
+				Ping:   m.hostPing(host.ID),
+				Banner: host.Banner,
+
+				MACAddress: host.MACAddress,
+				JumpChain:  m.hostJumpNames(host),
 			})
 		}
 	}
@@ -1328,15 +3644,50 @@ func (m Model) buildHomeViewParams() ui.HomeViewParams {
 		syncStage = m.syncManager.StageString()
 	}
 
+	var wake *ui.WakeActivity
+	if host, ok := m.hostByID(m.wakeHostID); ok && m.waking {
+		wake = &ui.WakeActivity{Active: true, Frame: m.wakeFrame, Host: hostDisplayName(host), Attempt: m.wakeAttempt, MaxAttempts: wakeMaxAttempts}
+	}
+
 	return ui.HomeViewParams{
 		Items:        items,
 		Cursor:       m.selectedIdx,
 		Err:          m.err,
 		SyncActivity: &ui.SyncActivity{Active: m.syncing, Frame: m.syncAnimFrame, Progress: m.syncProgress, Stage: syncStage},
+		WakeActivity: wake,
 		Page:         m.page,
 		HostCount:    len(m.hosts),
 		Connected:    connected,
+		FooterNotice: m.footerNotice(),
+		MarkedCount:  len(m.selectedSet),
+		SortLabel:    m.sortLabel(),
+		SessionUntil: m.sessionUntil,
+		Breadcrumbs:  m.breadcrumbs(),
+	}
+}
+
+// breadcrumbs returns the group path of the selected row, outermost group
+// first: the group itself for a header, the host's group for a host. It is
+// empty for ungrouped hosts and the "new group" row.
+func (m Model) breadcrumbs() []string {
+	if m.selectedIdx < 0 || m.selectedIdx >= len(m.listItems) {
+		return nil
 	}
+	it := m.listItems[m.selectedIdx]
+	group := it.GroupName
+	if it.Kind == ListItemNewGroup || group == "" {
+		return nil
+	}
+	if it.Virtual {
+		return []string{group}
+	}
+	crumbs := []string{group}
+	seen := map[string]bool{strings.ToLower(group): true}
+	for parent := m.groupParents[group]; parent != "" && !seen[strings.ToLower(parent)]; parent = m.groupParents[parent] {
+		seen[strings.ToLower(parent)] = true
+		crumbs = append([]string{parent}, crumbs...)
+	}
+	return crumbs
 }
 
 func (m Model) buildSearchResults() []ui.SearchResultItem {
@@ -1346,10 +3697,9 @@ func (m Model) buildSearchResults() []ui.SearchResultItem {
 			continue
 		}
 		status := 0
-		if m.mountManager != nil {
-			if ok, _ := m.mountManager.IsMounted(it.Host.ID); ok {
-				status = 2
-			}
+		mounted := m.hostHasMounts(it.Host)
+		if mounted {
+			status = 2
 		}
 		lbl := it.Host.Label
 		if lbl == "" {
@@ -1360,11 +3710,420 @@ func (m Model) buildSearchResults() []ui.SearchResultItem {
 			Hostname:  it.Host.Hostname,
 			GroupName: it.GroupName,
 			Status:    status,
+			Mounted:   mounted,
+		})
+	}
+	return results
+}
+
+// recentHostsLimit is how many hosts the recent connections overlay lists.
+const recentHostsLimit = 10
+
+// openRecentHosts loads the most recently connected hosts into the overlay.
+func (m Model) openRecentHosts() Model {
+	if m.store == nil {
+		return m
+	}
+	recent, err := m.store.GetRecentHosts(recentHostsLimit)
+	if err != nil {
+		m.err = fmt.Errorf("failed to load recent hosts: %v", err)
+		return m
+	}
+	m.recentHosts = m.recentHosts[:0]
+	for _, h := range recent {
+		if host, ok := m.hostByID(h.ID); ok {
+			m.recentHosts = append(m.recentHosts, host)
+		}
+	}
+	if len(m.recentHosts) == 0 {
+		m.err = fmt.Errorf("\u2139 no recent connections yet")
+		return m
+	}
+	m.recentCursor = 0
+	m.overlay = OverlayRecent
+	m.err = nil
+	return m
+}
+
+func (m Model) buildRecentResults() []ui.SearchResultItem {
+	var results []ui.SearchResultItem
+	for _, h := range m.recentHosts {
+		status := 0
+		if m.hostHasMounts(h) {
+			status = 2
+		}
+		hint := ""
+		if h.LastConnected != nil {
+			hint = ui.FormatTimeAgo(*h.LastConnected)
+		}
+		results = append(results, ui.SearchResultItem{
+			Label:     hostDisplayName(h),
+			Hostname:  h.Hostname,
+			GroupName: h.GroupName,
+			Hint:      hint,
+			Status:    status,
 		})
 	}
 	return results
 }
 
+func (m Model) buildBulkExecViewParams() ui.BulkExecViewParams {
+	run := m.bulkExec
+	if run == nil {
+		return ui.BulkExecViewParams{}
+	}
+	p := ui.BulkExecViewParams{
+		Field:   run.Field,
+		Started: run.Started,
+		Cursor:  run.Cursor,
+	}
+	if m.err != nil {
+		p.Err = m.err.Error()
+	}
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	for _, h := range run.Hosts {
+		row := ui.BulkExecRow{
+			Label:     hostDisplayName(h.Host),
+			Collapsed: h.Collapsed,
+		}
+		switch {
+		case h.Done && h.Err == nil:
+			row.Status, row.OK = "exit 0", true
+			p.Done++
+		case h.Done && h.ExitCode > 0:
+			row.Status, row.Failed = fmt.Sprintf("exit %d", h.ExitCode), true
+			p.Done++
+		case h.Done:
+			row.Status, row.Failed = h.Err.Error(), true
+			p.Done++
+		case h.Running:
+			row.Status = "running"
+		case run.Started:
+			row.Status = "queued"
+		}
+		if out := strings.TrimRight(h.Output.String(), "\n"); out != "" {
+			row.Lines = strings.Split(out, "\n")
+		}
+		if row.OK {
+			p.OK++
+		} else if row.Failed {
+			p.Failed++
+		}
+		p.Rows = append(p.Rows, row)
+	}
+	return p
+}
+
+// ── Host import ───────────────────────────────────────────────────────
+
+// importPreviewLimit is the number of parsed hosts shown in the wizard preview.
+const importPreviewLimit = 10
+
+var importConflictOptions = []struct {
+	label  string
+	policy db.ImportConflict
+}{
+	{"skip duplicates", db.ImportSkip},
+	{"overwrite existing", db.ImportOverwrite},
+	{"import renamed copies", db.ImportRename},
+}
+
+// openImportWizard starts the host import wizard at the file path step.
+func (m *Model) openImportWizard() {
+	m.importStep = importStepPath
+	m.importPath = ui.NewFormField("file")
+	m.importFormat = ""
+	m.importHosts = nil
+	m.importConflict = 0
+	m.importErr = ""
+	m.importing = false
+	m.overlay = OverlayImportWizard
+}
+
+// openWelcomeImport opens the import wizard picked on the welcome screen,
+// once setup has created the vault.
+func (m *Model) openWelcomeImport() {
+	switch m.welcomeNext {
+	case "ssh-config":
+		m.openImportWizard()
+		m.importPath.SetValue("~/.ssh/config")
+	case "json":
+		m.openImportWizard()
+	}
+	m.welcomeNext = ""
+}
+
+func (m *Model) closeImportWizard() {
+	m.overlay = OverlayNone
+	m.importHosts = nil
+	m.importErr = ""
+	m.importing = false
+}
+
+// loadImportPreview reads and parses the file at the entered path and moves
+// on to the preview step. Nothing is written to the store.
+func (m *Model) loadImportPreview() {
+	path := expandHome(strings.TrimSpace(m.importPath.Value))
+	if path == "" {
+		m.importErr = "enter a file path"
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		m.importErr = err.Error()
+		return
+	}
+	format := db.DetectImportFormat(path, data)
+	hosts, err := db.ParseImport(data, format)
+	if err != nil {
+		m.importErr = err.Error()
+		return
+	}
+	if len(hosts) == 0 {
+		m.importErr = "no hosts found in " + filepath.Base(path)
+		return
+	}
+	m.importFormat = format
+	m.importHosts = hosts
+	m.importErr = ""
+	m.importStep = importStepPreview
+}
+
+// startPuTTYImport imports a PuTTY registry export (.reg) or a directory of
+// PuTTY session files without a preview, since their keys are only converted
+// while importing. It returns nil for any other path.
+func (m *Model) startPuTTYImport() tea.Cmd {
+	path := expandHome(strings.TrimSpace(m.importPath.Value))
+	if path == "" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil || (!info.IsDir() && !strings.EqualFold(filepath.Ext(path), ".reg")) {
+		return nil
+	}
+	m.importing = true
+	m.importErr = ""
+	return runPuTTYImportCmd(m.store, path, info.IsDir())
+}
+
+// importHostExists reports whether imported would conflict with a saved
+// host: its label is in use, or a host already connects to the same hostname
+// and port.
+func (m Model) importHostExists(imported db.HostModel) bool {
+	port := imported.Port
+	if port == 0 {
+		port = 22
+	}
+	for _, h := range m.hosts {
+		if strings.EqualFold(hostDisplayName(h), imported.Label) {
+			return true
+		}
+		hp := h.Port
+		if hp == 0 {
+			hp = 22
+		}
+		if hp == port && strings.EqualFold(h.Hostname, strings.TrimSpace(imported.Hostname)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Model) buildImportWizardViewParams() ui.ImportWizardViewParams {
+	p := ui.ImportWizardViewParams{
+		Step:      m.importStep,
+		Path:      m.importPath,
+		Format:    m.importFormat,
+		Total:     len(m.importHosts),
+		Cursor:    m.importConflict,
+		Importing: m.importing,
+		Err:       m.importErr,
+	}
+	for _, o := range importConflictOptions {
+		p.Options = append(p.Options, o.label)
+	}
+	for i, h := range m.importHosts {
+		exists := m.importHostExists(h)
+		if exists {
+			p.Conflicts++
+		}
+		if i >= importPreviewLimit {
+			continue
+		}
+		target := h.Username + "@" + h.Hostname
+		if h.Port != 22 {
+			target += fmt.Sprintf(":%d", h.Port)
+		}
+		p.Rows = append(p.Rows, ui.ImportPreviewRow{
+			Label:  h.Label,
+			Target: target,
+			Group:  h.GroupName,
+			Exists: exists,
+		})
+	}
+	return p
+}
+
+// ── Command palette ───────────────────────────────────────────────────
+
+// paletteVisible is how many palette rows fit in the search overlay.
+const paletteVisible = 8
+
+// openCommandPalette shows every command, unfiltered.
+func (m Model) openCommandPalette() Model {
+	m.searchQuery = ""
+	m.paletteCommands = commandEntries()
+	m.paletteCursor = 0
+	m.armedSFTP = false
+	m.armedMount = false
+	m.overlay = OverlayCommandPalette
+	return m
+}
+
+// commandEntries lists every palette action. Most replay the home-screen key
+// binding so the palette and the keyboard never drift apart.
+func commandEntries() []CommandEntry {
+	return []CommandEntry{
+		{"Connect", "ssh into the selected host", homeKeyAction(tea.KeyMsg{Type: tea.KeyEnter})},
+		{"Browse files", "open the sftp browser for the selected host", selectedHostAction(func(m *Model, h Host) (tea.Model, tea.Cmd) { return m.connectToHostSFTP(h) })},
+		{"Mount", "mount the selected host or manage its mounts", homeKeyAction(runeKey('M'))},
+		{"Tunnels", "list, stop and start port forwards", homeKeyAction(runeKey('T'))},
+		{"SOCKS proxy", "start or stop a socks proxy through the selected host", homeKeyAction(runeKey('P'))},
+		{"Open last recording", "view the latest session recording in $PAGER", homeKeyAction(runeKey('L'))},
+		{"Copy ssh command", "copy the selected host's ssh command to the clipboard", homeKeyAction(runeKey('C'))},
+		{"Pin / unpin host", "keep the selected host at the top of its group", homeKeyAction(runeKey('p'))},
+		{"Recent connections", "hosts you connected to most recently", homeKeyAction(tea.KeyMsg{Type: tea.KeyCtrlR})},
+		{"Search hosts", "spotlight search", homeKeyAction(runeKey('/'))},
+		{"Add host", "create a new host", homeKeyAction(runeKey('a'))},
+		{"Edit host", "edit the selected host or rename the selected group", homeKeyAction(runeKey('e'))},
+		{"Delete host", "delete the selected host or group", homeKeyAction(runeKey('d'))},
+		{"Undo", "undo the last host add, edit or delete", homeKeyAction(tea.KeyMsg{Type: tea.KeyCtrlZ})},
+		{"Redo", "redo the last undone host change", homeKeyAction(tea.KeyMsg{Type: tea.KeyCtrlY})},
+		{"New group", "create a host group", homeKeyAction(tea.KeyMsg{Type: tea.KeyCtrlG})},
+		{"Import hosts", "import hosts from an ssh config, json or csv file", homeKeyAction(runeKey('I'))},
+		{"Delete marked hosts", "delete every host marked with space", markedHostsAction(runeKey('D'))},
+		{"Move marked hosts", "move every marked host to a group", markedHostsAction(runeKey('G'))},
+		{"Export marked hosts", "copy marked hosts to the clipboard as ssh config", markedHostsAction(runeKey('E'))},
+		{"Sync now", "sync hosts with the git repository", homeKeyAction(runeKey('Y'))},
+		{"Lock session", "forget the cached unlock used by sshthing exec and list", homeKeyAction(tea.KeyMsg{Type: tea.KeyCtrlL})},
+		{"Undo last sync", "roll back the most recent sync", homeKeyAction(runeKey('Z'))},
+		{"Settings", "open the settings page", homeKeyAction(runeKey(','))},
+		{"Tokens", "manage automation tokens", tokensAction()},
+		{"Create token", "create a new automation token", tokensAction(runeKey('a'))},
+		{"Help", "show key bindings", homeKeyAction(runeKey('?'))},
+		{"Quit", "exit sshthing", homeKeyAction(runeKey('q'))},
+	}
+}
+
+// filterCommands returns the commands matching query, best match first.
+func filterCommands(commands []CommandEntry, query string) []CommandEntry {
+	if strings.TrimSpace(query) == "" {
+		return commands
+	}
+	type scored struct {
+		cmd   CommandEntry
+		score int
+	}
+	var matches []scored
+	for _, c := range commands {
+		if score, ok := fuzzyScore(query, c.Label+" "+c.Description); ok {
+			matches = append(matches, scored{cmd: c, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	out := make([]CommandEntry, 0, len(matches))
+	for _, s := range matches {
+		out = append(out, s.cmd)
+	}
+	return out
+}
+
+// buildPaletteResults returns the visible window of palette rows and the
+// cursor position within it.
+func (m Model) buildPaletteResults() ([]ui.SearchResultItem, int) {
+	start := 0
+	if m.paletteCursor >= paletteVisible {
+		start = m.paletteCursor - paletteVisible + 1
+	}
+	end := min(len(m.paletteCommands), start+paletteVisible)
+	results := make([]ui.SearchResultItem, 0, end-start)
+	for _, c := range m.paletteCommands[start:end] {
+		results = append(results, ui.SearchResultItem{Label: c.Label, Hint: c.Description})
+	}
+	return results, m.paletteCursor - start
+}
+
+func runeKey(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+}
+
+// goHome switches to the home page, leaving settings (and saving them) first
+// when the palette was opened from there.
+func (m *Model) goHome() tea.Cmd {
+	var cmd tea.Cmd
+	if m.page == PageSettings {
+		var next tea.Model
+		next, cmd = m.handleSettingsKeys(tea.KeyMsg{Type: tea.KeyEsc})
+		*m = next.(Model)
+	}
+	m.page = PageHome
+	return cmd
+}
+
+// homeKeyAction replays msg on the home page.
+func homeKeyAction(msg tea.KeyMsg) func(m *Model) (tea.Model, tea.Cmd) {
+	return func(m *Model) (tea.Model, tea.Cmd) {
+		leaveCmd := m.goHome()
+		next, cmd := m.handleHomeKeys(msg)
+		return next, tea.Batch(leaveCmd, cmd)
+	}
+}
+
+func selectedHostAction(fn func(m *Model, h Host) (tea.Model, tea.Cmd)) func(m *Model) (tea.Model, tea.Cmd) {
+	return func(m *Model) (tea.Model, tea.Cmd) {
+		leaveCmd := m.goHome()
+		host, ok := m.selectedHost()
+		if !ok {
+			m.err = fmt.Errorf("select a host first")
+			return *m, leaveCmd
+		}
+		next, cmd := fn(m, host)
+		return next, tea.Batch(leaveCmd, cmd)
+	}
+}
+
+func markedHostsAction(msg tea.KeyMsg) func(m *Model) (tea.Model, tea.Cmd) {
+	return func(m *Model) (tea.Model, tea.Cmd) {
+		if len(m.selectedSet) == 0 {
+			m.err = fmt.Errorf("\u2139 mark hosts with space first")
+			return *m, nil
+		}
+		return homeKeyAction(msg)(m)
+	}
+}
+
+// tokensAction switches to the tokens page and replays msgs there.
+func tokensAction(msgs ...tea.KeyMsg) func(m *Model) (tea.Model, tea.Cmd) {
+	return func(m *Model) (tea.Model, tea.Cmd) {
+		var cmds []tea.Cmd
+		if m.page != PageTokens {
+			cmds = append(cmds, m.goHome())
+			m.page = PageTokens
+			m.tokenMode = tokenModeList
+			m.loadTokenSummaries()
+		}
+		var next tea.Model = *m
+		for _, msg := range msgs {
+			var cmd tea.Cmd
+			next, cmd = next.(Model).handleTokensKeys(msg)
+			cmds = append(cmds, cmd)
+		}
+		return next, tea.Batch(cmds...)
+	}
+}
+
 // ── Theme / icon lookup helpers ───────────────────────────────────────
 
 func themeNames() []string {
@@ -1,8 +1,11 @@
 package app
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"os"
+	"path"
+	"reflect"
 	"runtime"
 	"strings"
 	"time"
@@ -22,10 +25,14 @@ func (m Model) handleOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch m.overlay {
 	case OverlayLogin:
 		return m.handleLoginKeys(msg)
+	case OverlayWelcome:
+		return m.handleWelcomeKeys(msg)
 	case OverlaySetup:
 		return m.handleSetupKeys(msg)
 	case OverlayHelp:
 		return m.handleHelpKeys(msg)
+	case OverlayHostDetail:
+		return m.handleHelpKeys(msg)
 	case OverlaySearch:
 		return m.handleSearchKeys(msg)
 	case OverlayAddHost:
@@ -40,6 +47,42 @@ func (m Model) handleOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleDeleteGroupKeys(msg)
 	case OverlayQuit:
 		return m.handleQuitKeys(msg)
+	case OverlayForward:
+		return m.handleForwardKeys(msg)
+	case OverlayTunnels:
+		return m.handleTunnelsKeys(msg)
+	case OverlayPassphrase:
+		return m.handlePassphraseKeys(msg)
+	case OverlayMounts:
+		return m.handleMountsKeys(msg)
+	case OverlaySyncDryRun:
+		return m.handleSyncDryRunKeys(msg)
+	case OverlaySyncConflicts:
+		return m.handleSyncConflictsKeys(msg)
+	case OverlaySyncRollback:
+		return m.handleSyncRollbackKeys(msg)
+	case OverlaySFTPBrowser:
+		return m.handleSFTPBrowserKeys(msg)
+	case OverlayMoveHosts:
+		return m.handleMoveHostsKeys(msg)
+	case OverlayRecent:
+		return m.handleRecentKeys(msg)
+	case OverlayCommandPalette:
+		return m.handleCommandPaletteKeys(msg)
+	case OverlayCopyFallback:
+		return m.handleCopyFallbackKeys(msg)
+	case OverlayKeyView:
+		return m.handleKeyViewKeys(msg)
+	case OverlayImportWizard:
+		return m.handleImportWizardKeys(msg)
+	case OverlayChangelog:
+		return m.handleChangelogKeys(msg)
+	case OverlayBulkExec:
+		return m.handleBulkExecKeys(msg)
+	case OverlaySessionRenew:
+		return m.handleSessionRenewKeys(msg)
+	case OverlayKeyRotation:
+		return m.handleKeyRotationKeys(msg)
 	}
 	return m, nil
 }
@@ -80,6 +123,10 @@ func (m Model) handleLoginKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.masterPassword = password
 		m.loadHosts()
 		m.restoreMountsFromDB()
+		m.checkExpiringTokens()
+		if store.KDFUpgradeAvailable() {
+			m.err = fmt.Errorf("\u2139 Stronger key derivation available \u2014 Settings \u203A security \u203A upgrade key derivation")
+		}
 
 		if m.store != nil {
 			syncMgr, err := syncpkg.NewManager(&m.cfg, m.store, password)
@@ -91,7 +138,8 @@ func (m Model) handleLoginKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.overlay = OverlayNone
 		m.page = PageHome
 		_ = unlock.Save(password, time.Duration(m.cfg.Automation.SessionTTLSeconds)*time.Second)
-		return m, nil
+		cmd := tea.Batch(m.scheduleAutoSync(), m.schedulePing(), m.scheduleSessionRefresh(), m.scheduleUpdateCheck())
+		return m, cmd
 
 	case tea.KeyEsc:
 		return m, tea.Quit
@@ -153,6 +201,7 @@ func (m Model) handleSetupKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.masterPassword = password
 			m.loadHosts()
 			m.restoreMountsFromDB()
+			m.checkExpiringTokens()
 
 			if m.store != nil {
 				syncMgr, err := syncpkg.NewManager(&m.cfg, m.store, password)
@@ -163,11 +212,15 @@ func (m Model) handleSetupKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 			m.overlay = OverlayNone
 			m.page = PageHome
-			return m, nil
+			m.openWelcomeImport()
+			cmd := tea.Batch(m.scheduleAutoSync(), m.schedulePing(), m.scheduleUpdateCheck())
+			return m, cmd
 		}
 
 	case tea.KeyEsc:
-		return m, tea.Quit
+		m.loginError = ""
+		m.overlay = OverlayWelcome
+		return m, nil
 	}
 
 	// Forward to focused field
@@ -193,12 +246,80 @@ func (m Model) handleSetupKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 func (m Model) handleHelpKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	m.overlay = OverlayNone
+	if m.welcomeHelp {
+		m.welcomeHelp = false
+		m.overlay = OverlayWelcome
+	}
+	return m, nil
+}
+
+// ── Welcome overlay ───────────────────────────────────────────────────
+
+// welcomeCards are the first-run choices, in display order.
+var welcomeCards = []ui.WelcomeCard{
+	{Title: "get started", Desc: "create your encrypted vault"},
+	{Title: "import from ~/.ssh/config", Desc: "set up, then import hosts"},
+	{Title: "import from JSON backup", Desc: "set up, then load an export"},
+	{Title: "learn more (help)", Desc: "keyboard shortcuts"},
+}
+
+func (m Model) handleWelcomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n := len(welcomeCards)
+	switch msg.String() {
+	case "esc", "q":
+		return m, tea.Quit
+	case "tab", "down", "j", "right", "l":
+		m.welcomeCursor = (m.welcomeCursor + 1) % n
+	case "shift+tab", "up", "k", "left", "h":
+		m.welcomeCursor = (m.welcomeCursor + n - 1) % n
+	case "1", "2", "3", "4":
+		m.welcomeCursor = int(msg.String()[0] - '1')
+		return m.selectWelcomeCard()
+	case "enter", " ":
+		return m.selectWelcomeCard()
+	}
+	return m, nil
+}
+
+func (m Model) selectWelcomeCard() (tea.Model, tea.Cmd) {
+	switch m.welcomeCursor {
+	case 0:
+		m.welcomeNext = ""
+	case 1:
+		m.welcomeNext = "ssh-config"
+	case 2:
+		m.welcomeNext = "json"
+	case 3:
+		m.welcomeHelp = true
+		m.overlay = OverlayHelp
+		return m, nil
+	}
+	m.setupFocus = 0
+	m.overlay = OverlaySetup
 	return m, nil
 }
 
 // ── Search overlay ────────────────────────────────────────────────────
 
 func (m Model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.armedMountPathEditing {
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.armedMountPathEditing = false
+			m.armedMountPath = ""
+			return m, nil
+		case tea.KeyEnter:
+			// Mount with the typed path below.
+			m.armedMountPathEditing = false
+		case tea.KeyBackspace:
+			m.armedMountPath = removeLastRune(m.armedMountPath)
+			return m, nil
+		default:
+			m.armedMountPath += string(msg.Runes)
+			return m, nil
+		}
+	}
+
 	switch msg.String() {
 	case "esc":
 		m.overlay = OverlayNone
@@ -206,7 +327,7 @@ func (m Model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.spotlightItems = nil
 		m.armedSFTP = false
 		m.armedMount = false
-		m.armedUnmount = false
+		m.armedMountPath = ""
 		return m, nil
 
 	case "S":
@@ -217,7 +338,6 @@ func (m Model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		m.armedSFTP = !m.armedSFTP
 		m.armedMount = false
-		m.armedUnmount = false
 		if m.armedSFTP {
 			m.err = fmt.Errorf("SFTP armed \u2014 press Enter")
 		} else {
@@ -240,24 +360,21 @@ func (m Model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		host := item.Host
-		isMounted := false
-		if m.mountManager != nil {
-			isMounted, _ = m.mountManager.IsMounted(host.ID)
-		}
-		if m.armedMount || m.armedUnmount {
+		if m.armedMount {
 			m.armedMount = false
-			m.armedUnmount = false
 			m.err = nil
 			return m, nil
 		}
 		m.armedSFTP = false
-		if isMounted {
-			m.armedUnmount = true
-			m.err = fmt.Errorf("Unmount armed \u2014 press Enter")
-		} else {
-			m.armedMount = true
-			m.err = fmt.Errorf("Mount (beta) armed \u2014 press Enter")
+		if m.hostHasMounts(host) {
+			m.searchQuery = ""
+			m.spotlightItems = nil
+			return m.openMountsOverlay(host), nil
 		}
+		m.armedMount = true
+		m.armedMountPath = ""
+		m.mountReadOnly = m.cfg.Mount.DefaultReadOnly
+		m.err = m.mountArmedStatus()
 		return m, nil
 
 	case "enter":
@@ -276,7 +393,7 @@ func (m Model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.overlay = OverlayNone
 		m.searchQuery = ""
 		m.spotlightItems = nil
-		if m.armedMount || m.armedUnmount {
+		if m.armedMount {
 			return m.handleMountEnter(host)
 		}
 		if m.armedSFTP {
@@ -285,6 +402,24 @@ func (m Model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m.connectToHost(host)
 
+	case "R":
+		if !m.armedMount {
+			break
+		}
+		m.mountReadOnly = !m.mountReadOnly
+		m.err = m.mountArmedStatus()
+		return m, nil
+
+	case "P":
+		if !m.armedMount {
+			break
+		}
+		if m.armedMountPath == "" {
+			m.armedMountPath = m.cfg.Mount.DefaultRemotePath
+		}
+		m.armedMountPathEditing = true
+		return m, nil
+
 	case "up", "k":
 		if msg.String() == "k" && !m.cfg.UI.VimMode {
 			break
@@ -317,6 +452,51 @@ func (m Model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// ── Command palette overlay ───────────────────────────────────────────
+
+func (m Model) handleCommandPaletteKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+p":
+		m.overlay = OverlayNone
+		m.searchQuery = ""
+		m.paletteCommands = nil
+		return m, nil
+
+	case "up", "shift+tab":
+		if m.paletteCursor > 0 {
+			m.paletteCursor--
+		}
+		return m, nil
+
+	case "down", "tab":
+		if m.paletteCursor < len(m.paletteCommands)-1 {
+			m.paletteCursor++
+		}
+		return m, nil
+
+	case "enter":
+		if m.paletteCursor >= len(m.paletteCommands) {
+			return m, nil
+		}
+		cmd := m.paletteCommands[m.paletteCursor]
+		m.overlay = OverlayNone
+		m.searchQuery = ""
+		m.paletteCommands = nil
+		return cmd.Action(&m)
+	}
+
+	if msg.Type == tea.KeyBackspace {
+		m.searchQuery = removeLastRune(m.searchQuery)
+	} else {
+		for _, r := range msg.Runes {
+			m.searchQuery += string(r)
+		}
+	}
+	m.paletteCommands = filterCommands(commandEntries(), m.searchQuery)
+	m.paletteCursor = 0
+	return m, nil
+}
+
 func removeLastRune(s string) string {
 	if s == "" {
 		return ""
@@ -370,7 +550,7 @@ func (m Model) handleQuitKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if hasMounts {
 				if m.store != nil && m.mountManager != nil {
 					for _, mt := range m.mountManager.ListActive() {
-						_ = m.store.UpsertMountState(mt.HostID, mt.LocalPath, mt.RemotePath)
+						_ = m.store.UpsertMountState(mt.HostID, mt.LocalPath, mt.RemotePath, mt.ReadOnly)
 					}
 				}
 				return m, tea.Quit
@@ -392,13 +572,16 @@ func (m Model) handleQuitKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleAddHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	isTextField := func(f int) bool {
 		switch f {
-		case ui.FFLabel, ui.FFTags, ui.FFHostname, ui.FFPort, ui.FFUsername, ui.FFAuthDet:
+		case ui.FFLabel, ui.FFTags, ui.FFHostname, ui.FFPort, ui.FFUsername, ui.FFAuthDet, ui.FFSSHOpts, ui.FFKeepAlive, ui.FFTerm, ui.FFIcon, ui.FFColor, ui.FFMAC, ui.FFBroadcast:
 			return true
 		}
 		return false
 	}
 
 	submitAndClose := func() (tea.Model, tea.Cmd) {
+		if m.rekeyBusy() {
+			return m, nil
+		}
 		validationErr := m.validateForm()
 		if validationErr != nil {
 			m.err = validationErr
@@ -429,6 +612,13 @@ func (m Model) handleAddHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 		groupName := m.modalSelectedGroupName()
 		tags := db.ParseTagInput(m.formFields[ui.FFTags].Value)
+		sshOpts, _ := ssh.ParseOptions(m.formFields[ui.FFSSHOpts].Value) // checked by validateForm
+		mac := strings.TrimSpace(m.formFields[ui.FFMAC].Value)
+		broadcast := strings.TrimSpace(m.formFields[ui.FFBroadcast].Value)
+		keepAlive, _ := parseKeepAliveField(m.formFields[ui.FFKeepAlive].Value) // checked by validateForm
+		term := strings.TrimSpace(m.formFields[ui.FFTerm].Value)
+		icon := strings.TrimSpace(m.formFields[ui.FFIcon].Value)
+		color := strings.TrimSpace(m.formFields[ui.FFColor].Value)
 		if groupName != "" {
 			if err := m.store.UpsertGroup(groupName); err != nil {
 				m.err = err
@@ -439,35 +629,63 @@ func (m Model) handleAddHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.formEditIdx < 0 {
 			// Add new
 			host := &db.HostModel{
-				Label:     strings.TrimSpace(m.formFields[ui.FFLabel].Value),
-				GroupName: groupName,
-				Tags:      tags,
-				Hostname:  m.formFields[ui.FFHostname].Value,
-				Username:  m.formFields[ui.FFUsername].Value,
-				Port:      portInt,
-				KeyType:   keyType,
+				Label:       strings.TrimSpace(m.formFields[ui.FFLabel].Value),
+				GroupName:   groupName,
+				Tags:        tags,
+				Hostname:    m.formFields[ui.FFHostname].Value,
+				Username:    m.formFields[ui.FFUsername].Value,
+				Port:        portInt,
+				KeyType:     keyType,
+				Recording:   m.formRecordingValue(),
+				SyncExclude: m.formSyncExcl,
+				SSHOptions:  sshOpts,
+
+				MACAddress:       mac,
+				BroadcastAddr:    broadcast,
+				KeepAliveSeconds: keepAlive,
+				ForwardX11:       m.formX11,
+				ProxyHosts:       m.formJumpChain(),
+				TermOverride:     term,
+				Icon:             icon,
+				IdentitiesOnly:   m.formIdentitiesValue(),
+				ColorOverride:    color,
 			}
 			if err := m.store.CreateHost(host, plainKey); err != nil {
 				m.err = err
 				return m, nil
 			}
+			m.recordUndo("create", nil, host.ID)
 			m.err = fmt.Errorf("\u2713 Host '%s' added", host.Hostname)
 		} else {
 			// Edit
 			selectedHost, ok := m.selectedHost()
 			if ok {
+				before, _ := m.store.GetHostByID(selectedHost.ID)
 				keepExistingSecret := m.formAuthIdx == 0 &&
 					selectedHost.KeyType == "password" &&
 					plainKey == ""
 				host := &db.HostModel{
-					ID:        selectedHost.ID,
-					Label:     strings.TrimSpace(m.formFields[ui.FFLabel].Value),
-					GroupName: groupName,
-					Tags:      tags,
-					Hostname:  m.formFields[ui.FFHostname].Value,
-					Username:  m.formFields[ui.FFUsername].Value,
-					Port:      portInt,
-					KeyType:   keyType,
+					ID:          selectedHost.ID,
+					Label:       strings.TrimSpace(m.formFields[ui.FFLabel].Value),
+					GroupName:   groupName,
+					Tags:        tags,
+					Hostname:    m.formFields[ui.FFHostname].Value,
+					Username:    m.formFields[ui.FFUsername].Value,
+					Port:        portInt,
+					KeyType:     keyType,
+					Recording:   m.formRecordingValue(),
+					SyncExclude: m.formSyncExcl,
+					SSHOptions:  sshOpts,
+
+					MACAddress:       mac,
+					BroadcastAddr:    broadcast,
+					KeepAliveSeconds: keepAlive,
+					ForwardX11:       m.formX11,
+					ProxyHosts:       m.formJumpChain(),
+					TermOverride:     term,
+					Icon:             icon,
+					IdentitiesOnly:   m.formIdentitiesValue(),
+					ColorOverride:    color,
 				}
 				if keepExistingSecret {
 					if err := m.store.UpdateHost(host); err != nil {
@@ -485,6 +703,7 @@ func (m Model) handleAddHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 						return m, nil
 					}
 				}
+				m.recordUndo("edit", before, host.ID)
 				m.err = fmt.Errorf("\u2713 Host '%s' updated", host.Hostname)
 			}
 		}
@@ -514,7 +733,49 @@ func (m Model) handleAddHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.formAuthIdx = (m.formAuthIdx + dir + len(m.formAuthOpts)) % len(m.formAuthOpts)
 	}
 
-	formOrder := []int{ui.FFLabel, ui.FFGroup, ui.FFTags, ui.FFHostname, ui.FFPort, ui.FFUsername, ui.FFAuthMeth, ui.FFAuthDet, ui.FFSave}
+	cycleRecord := func(dir int) {
+		m.formRecIdx = (m.formRecIdx + dir + len(m.formRecOpts)) % len(m.formRecOpts)
+	}
+	cycleIdentities := func(dir int) {
+		m.formIdentIdx = (m.formIdentIdx + dir + len(m.formRecOpts)) % len(m.formRecOpts)
+	}
+
+	cycleIcon := func(dir int) {
+		// Position 0 is "no icon"; a custom icon starts the cycle from there.
+		opts := append([]string{""}, ui.HostIconPresets...)
+		cur := 0
+		for i, icon := range opts {
+			if icon == strings.TrimSpace(m.formFields[ui.FFIcon].Value) {
+				cur = i
+				break
+			}
+		}
+		m.formFields[ui.FFIcon].SetValue(opts[(cur+dir+len(opts))%len(opts)])
+	}
+	cycleColor := func(dir int) {
+		m.formFields[ui.FFColor].SetValue(cycleColorPreset(m.formFields[ui.FFColor].Value, dir))
+	}
+
+	isJumpField := func(f int) bool {
+		return f >= ui.FFJump1 && f < ui.FFJump1+len(m.formJumpIdx)
+	}
+	cycleJump := func(dir int) {
+		i := m.formFocus - ui.FFJump1
+		m.formJumpIdx[i] = (m.formJumpIdx[i] + dir + len(m.formJumpOpts)) % len(m.formJumpOpts)
+	}
+
+	formOrder := []int{ui.FFLabel, ui.FFIcon, ui.FFColor, ui.FFGroup, ui.FFTags, ui.FFHostname, ui.FFPort, ui.FFUsername, ui.FFAuthMeth, ui.FFAuthDet, ui.FFRecord, ui.FFSyncExclude, ui.FFAdvanced}
+	if m.formAdvanced {
+		formOrder = append(formOrder, ui.FFSSHOpts)
+		for i := range m.formJumpIdx {
+			formOrder = append(formOrder, ui.FFJump1+i)
+			if m.formJumpIdx[i] == 0 {
+				break
+			}
+		}
+		formOrder = append(formOrder, ui.FFKeepAlive, ui.FFTerm, ui.FFX11, ui.FFIdentities, ui.FFMAC, ui.FFBroadcast)
+	}
+	formOrder = append(formOrder, ui.FFSave)
 
 	findIdx := func(f int) int {
 		for i, v := range formOrder {
@@ -554,6 +815,20 @@ func (m Model) handleAddHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			cycleGroup(-1)
 		} else if m.formFocus == ui.FFAuthMeth {
 			cycleAuth(-1)
+		} else if m.formFocus == ui.FFRecord {
+			cycleRecord(-1)
+		} else if m.formFocus == ui.FFIdentities {
+			cycleIdentities(-1)
+		} else if m.formFocus == ui.FFSyncExclude {
+			m.formSyncExcl = !m.formSyncExcl
+		} else if m.formFocus == ui.FFX11 {
+			m.formX11 = !m.formX11
+		} else if isJumpField(m.formFocus) {
+			cycleJump(-1)
+		} else if m.formFocus == ui.FFIcon && !m.formEditing {
+			cycleIcon(-1)
+		} else if m.formFocus == ui.FFColor && !m.formEditing {
+			cycleColor(-1)
 		} else if m.formEditing && isTextField(m.formFocus) {
 			m.formFields[m.formFocus].MoveLeft()
 		}
@@ -564,6 +839,20 @@ func (m Model) handleAddHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			cycleGroup(1)
 		} else if m.formFocus == ui.FFAuthMeth {
 			cycleAuth(1)
+		} else if m.formFocus == ui.FFRecord {
+			cycleRecord(1)
+		} else if m.formFocus == ui.FFIdentities {
+			cycleIdentities(1)
+		} else if m.formFocus == ui.FFSyncExclude {
+			m.formSyncExcl = !m.formSyncExcl
+		} else if m.formFocus == ui.FFX11 {
+			m.formX11 = !m.formX11
+		} else if isJumpField(m.formFocus) {
+			cycleJump(1)
+		} else if m.formFocus == ui.FFIcon && !m.formEditing {
+			cycleIcon(1)
+		} else if m.formFocus == ui.FFColor && !m.formEditing {
+			cycleColor(1)
 		} else if m.formEditing && isTextField(m.formFocus) {
 			m.formFields[m.formFocus].MoveRight()
 		}
@@ -573,6 +862,19 @@ func (m Model) handleAddHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.formFocus == ui.FFSave {
 			return submitAndClose()
 		}
+		if m.formFocus == ui.FFAdvanced {
+			m.formAdvanced = !m.formAdvanced
+			return m, nil
+		}
+		if m.formFocus == ui.FFX11 {
+			m.formX11 = !m.formX11
+			return m, nil
+		}
+		if m.formFocus == ui.FFSSHOpts && m.formEditing {
+			// Multi-line field: enter starts a new Key=Value line, esc finishes.
+			m.formFields[ui.FFSSHOpts].InsertRune('\n')
+			return m, nil
+		}
 		if isTextField(m.formFocus) {
 			if m.formEditing {
 				m.formEditing = false
@@ -599,12 +901,30 @@ func (m Model) handleAddHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Spacebar for key gen type cycling
 	str := msg.String()
+	// R rotates the stored key of the host being edited
+	if str == "R" && m.formCanRotate() {
+		if host, ok := m.selectedHost(); ok {
+			return m.openKeyRotation(host), nil
+		}
+	}
+	// Spacebar for key gen type cycling
 	if str == " " && m.formFocus == ui.FFAuthDet && m.formAuthIdx == 2 {
 		m.formKeyIdx = (m.formKeyIdx + 1) % len(m.formKeyTypes)
 		return m, nil
 	}
+	if (str == " " || str == "x") && m.formFocus == ui.FFSyncExclude {
+		m.formSyncExcl = !m.formSyncExcl
+		return m, nil
+	}
+	if (str == " " || str == "x") && m.formFocus == ui.FFX11 {
+		m.formX11 = !m.formX11
+		return m, nil
+	}
+	if str == " " && m.formFocus == ui.FFAdvanced {
+		m.formAdvanced = !m.formAdvanced
+		return m, nil
+	}
 
 	// Vim nav for selectors
 	if m.cfg.UI.VimMode {
@@ -620,6 +940,24 @@ func (m Model) handleAddHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		} else if str == "l" && m.formFocus == ui.FFAuthMeth {
 			cycleAuth(1)
 			return m, nil
+		} else if str == "h" && m.formFocus == ui.FFRecord {
+			cycleRecord(-1)
+			return m, nil
+		} else if str == "l" && m.formFocus == ui.FFRecord {
+			cycleRecord(1)
+			return m, nil
+		} else if str == "h" && m.formFocus == ui.FFIdentities {
+			cycleIdentities(-1)
+			return m, nil
+		} else if str == "l" && m.formFocus == ui.FFIdentities {
+			cycleIdentities(1)
+			return m, nil
+		} else if str == "h" && isJumpField(m.formFocus) {
+			cycleJump(-1)
+			return m, nil
+		} else if str == "l" && isJumpField(m.formFocus) {
+			cycleJump(1)
+			return m, nil
 		}
 	}
 
@@ -630,10 +968,15 @@ func (m Model) handleAddHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 func (m Model) handleDeleteHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	doDelete := func() Model {
-		if host, ok := m.selectedHost(); ok {
+		if m.bulkDelete {
+			m.bulkDelete = false
+			m = m.bulkDeleteSelected()
+		} else if host, ok := m.selectedHost(); ok {
+			before, _ := m.store.GetHostByID(host.ID)
 			if err := m.store.DeleteHost(host.ID); err != nil {
 				m.err = err
 			} else {
+				m.recordUndo("delete", before, 0)
 				m.err = fmt.Errorf("\u2713 Host '%s' deleted", host.Hostname)
 				m.loadHosts()
 				m.loadGroups()
@@ -653,6 +996,7 @@ func (m Model) handleDeleteHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case "n", "N", "esc":
 		m.overlay = OverlayNone
+		m.bulkDelete = false
 		return m, nil
 	case "left", "h", "right", "l", "tab", "shift+tab":
 		m.deleteCursor = 1 - m.deleteCursor
@@ -662,9 +1006,227 @@ func (m Model) handleDeleteHostKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m = doDelete()
 		} else {
 			m.overlay = OverlayNone
+			m.bulkDelete = false
+		}
+		return m, nil
+	}
+	return m, nil
+}
+
+// ── Copy fallback overlay ─────────────────────────────────────────────
+
+func (m Model) handleCopyFallbackKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "enter", "q":
+		m.overlay = OverlayNone
+		m.copyFallback = ""
+	}
+	return m, nil
+}
+
+// ── Public key viewer overlay ─────────────────────────────────────────
+
+func (m Model) handleKeyViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.overlay = OverlayNone
+		m.keyViewPublic = ""
+		m.err = nil
+		return m, nil
+	case tea.KeyTab, tea.KeyShiftTab, tea.KeyUp, tea.KeyDown:
+		m.keyViewCursor = 1 - m.keyViewCursor
+		return m, nil
+	case tea.KeyEnter:
+		if m.keyViewCursor == 0 {
+			return m.copyPublicKey(), nil
+		}
+		return m.downloadPublicKey(), nil
+	}
+
+	if m.keyViewCursor == 0 {
+		switch msg.String() {
+		case "c", "C":
+			return m.copyPublicKey(), nil
+		case "d", "D":
+			m.keyViewCursor = 1
+		case "q":
+			m.overlay = OverlayNone
+			m.keyViewPublic = ""
+			m.err = nil
+		}
+		return m, nil
+	}
+
+	f := &m.keyViewPathField
+	switch msg.Type {
+	case tea.KeyBackspace:
+		f.DeleteBack()
+	case tea.KeyLeft:
+		f.MoveLeft()
+	case tea.KeyRight:
+		f.MoveRight()
+	default:
+		for _, r := range msg.Runes {
+			f.InsertRune(r)
+		}
+	}
+	m.err = nil
+	return m, nil
+}
+
+// ── Import wizard overlay ─────────────────────────────────────────────
+
+func (m Model) handleImportWizardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.importing {
+		return m, nil
+	}
+	if msg.Type == tea.KeyEsc {
+		m.closeImportWizard()
+		return m, nil
+	}
+
+	switch m.importStep {
+	case importStepPath:
+		switch msg.Type {
+		case tea.KeyEnter:
+			if cmd := m.startPuTTYImport(); cmd != nil {
+				return m, cmd
+			}
+			m.loadImportPreview()
+		case tea.KeyBackspace:
+			m.importPath.DeleteBack()
+			m.importErr = ""
+		case tea.KeyLeft:
+			m.importPath.MoveLeft()
+		case tea.KeyRight:
+			m.importPath.MoveRight()
+		default:
+			for _, r := range msg.Runes {
+				m.importPath.InsertRune(r)
+			}
+			m.importErr = ""
+		}
+
+	case importStepPreview:
+		switch msg.String() {
+		case "enter":
+			m.importStep = importStepConflict
+		case "backspace", "left", "h":
+			m.importStep = importStepPath
+		}
+
+	case importStepConflict:
+		switch msg.String() {
+		case "up", "k":
+			if m.importConflict > 0 {
+				m.importConflict--
+			}
+		case "down", "j":
+			if m.importConflict < len(importConflictOptions)-1 {
+				m.importConflict++
+			}
+		case "backspace", "left", "h":
+			m.importStep = importStepPreview
+		case "enter":
+			m.importing = true
+			return m, runImportCmd(m.store, m.importHosts, importConflictOptions[m.importConflict].policy)
+		}
+	}
+	return m, nil
+}
+
+// ── Bulk command overlay ──────────────────────────────────────────────
+
+func (m Model) handleBulkExecKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	run := m.bulkExec
+	if run == nil || msg.Type == tea.KeyEsc {
+		// A running batch keeps going; its summary shows when it ends.
+		m.bulkExec = nil
+		m.overlay = OverlayNone
+		m.err = nil
+		return m, nil
+	}
+
+	if !run.Started {
+		switch msg.Type {
+		case tea.KeyEnter:
+			return m, m.startBulkExec()
+		case tea.KeyBackspace:
+			run.Field.DeleteBack()
+		case tea.KeyLeft:
+			run.Field.MoveLeft()
+		case tea.KeyRight:
+			run.Field.MoveRight()
+		default:
+			for _, r := range msg.Runes {
+				run.Field.InsertRune(r)
+			}
 		}
+		m.err = nil
 		return m, nil
 	}
+
+	switch msg.String() {
+	case "up", "k":
+		if run.Cursor > 0 {
+			run.Cursor--
+		}
+	case "down", "j":
+		if run.Cursor < len(run.Hosts)-1 {
+			run.Cursor++
+		}
+	case "enter", " ":
+		run.mu.Lock()
+		run.Hosts[run.Cursor].Collapsed = !run.Hosts[run.Cursor].Collapsed
+		run.mu.Unlock()
+	}
+	return m, nil
+}
+
+// ── Recent connections overlay ────────────────────────────────────────
+
+func (m Model) handleRecentKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "ctrl+r":
+		m.overlay = OverlayNone
+	case "up", "k", "shift+tab":
+		if m.recentCursor > 0 {
+			m.recentCursor--
+		}
+	case "down", "j", "tab":
+		if m.recentCursor < len(m.recentHosts)-1 {
+			m.recentCursor++
+		}
+	case "enter":
+		m.overlay = OverlayNone
+		if m.recentCursor < len(m.recentHosts) {
+			return m.connectToHost(m.recentHosts[m.recentCursor])
+		}
+	}
+	return m, nil
+}
+
+// ── Move hosts overlay ────────────────────────────────────────────────
+
+func (m Model) handleMoveHostsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	options := m.moveGroupOptions()
+	switch msg.String() {
+	case "esc", "q":
+		m.overlay = OverlayNone
+	case "up", "k":
+		if m.moveGroupCursor > 0 {
+			m.moveGroupCursor--
+		}
+	case "down", "j":
+		if m.moveGroupCursor < len(options)-1 {
+			m.moveGroupCursor++
+		}
+	case "enter":
+		m.overlay = OverlayNone
+		if m.moveGroupCursor < len(options) {
+			m = m.moveSelectedToGroup(options[m.moveGroupCursor])
+		}
+	}
 	return m, nil
 }
 
@@ -697,6 +1259,12 @@ func (m Model) handleGroupInputKeys(msg tea.KeyMsg, isRename bool) (tea.Model, t
 				return m, nil
 			}
 			m.err = fmt.Errorf("\u2713 Group '%s' renamed", name)
+		} else if m.groupParent != "" {
+			if err := m.store.UpsertSubgroup(name, m.groupParent); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.err = fmt.Errorf("\u2713 Group '%s' created in '%s'", name, m.groupParent)
 		} else {
 			if err := m.store.UpsertGroup(name); err != nil {
 				m.err = err
@@ -704,6 +1272,12 @@ func (m Model) handleGroupInputKeys(msg tea.KeyMsg, isRename bool) (tea.Model, t
 			}
 			m.err = fmt.Errorf("\u2713 Group '%s' created", name)
 		}
+		if isRename || m.groupColor != "" {
+			if err := m.store.SetGroupColor(name, m.groupColor); err != nil {
+				m.err = err
+				return m, nil
+			}
+		}
 		m.loadHosts()
 		m.loadGroups()
 		m.rebuildListItems()
@@ -711,6 +1285,7 @@ func (m Model) handleGroupInputKeys(msg tea.KeyMsg, isRename bool) (tea.Model, t
 		m.overlay = OverlayNone
 		m.groupInputValue = ""
 		m.groupInputCursor = 0
+		m.groupColor = ""
 		return m, nil
 	}
 
@@ -718,6 +1293,7 @@ func (m Model) handleGroupInputKeys(msg tea.KeyMsg, isRename bool) (tea.Model, t
 		m.overlay = OverlayNone
 		m.groupInputValue = ""
 		m.groupInputCursor = 0
+		m.groupColor = ""
 		m.groupFocus = 0
 		m.err = nil
 		return m, nil
@@ -727,25 +1303,29 @@ func (m Model) handleGroupInputKeys(msg tea.KeyMsg, isRename bool) (tea.Model, t
 	case "esc":
 		return cancel()
 	case "tab":
-		m.groupFocus = (m.groupFocus + 1) % 3
+		m.groupFocus = (m.groupFocus + 1) % 4
 		return m, nil
 	case "shift+tab":
-		m.groupFocus = (m.groupFocus + 2) % 3
+		m.groupFocus = (m.groupFocus + 3) % 4
 		return m, nil
 	case "left", "h":
 		if m.groupFocus == 1 {
-			m.groupFocus = 2
+			m.groupColor = cycleColorPreset(m.groupColor, -1)
 		} else if m.groupFocus == 2 {
-			m.groupFocus = 1
+			m.groupFocus = 3
+		} else if m.groupFocus == 3 {
+			m.groupFocus = 2
 		} else if m.groupFocus == 0 {
 			m.groupInputCursor = max(0, m.groupInputCursor-1)
 		}
 		return m, nil
 	case "right", "l":
 		if m.groupFocus == 1 {
-			m.groupFocus = 2
+			m.groupColor = cycleColorPreset(m.groupColor, 1)
 		} else if m.groupFocus == 2 {
-			m.groupFocus = 1
+			m.groupFocus = 3
+		} else if m.groupFocus == 3 {
+			m.groupFocus = 2
 		} else if m.groupFocus == 0 {
 			runes := []rune(m.groupInputValue)
 			if m.groupInputCursor < len(runes) {
@@ -753,69 +1333,586 @@ func (m Model) handleGroupInputKeys(msg tea.KeyMsg, isRename bool) (tea.Model, t
 			}
 		}
 		return m, nil
-	case "enter":
-		if m.groupFocus == 2 {
-			return cancel()
-		}
-		return submit()
+	case "enter":
+		if m.groupFocus == 3 {
+			return cancel()
+		}
+		return submit()
+	}
+
+	// Text input
+	if m.groupFocus == 0 {
+		if msg.Type == tea.KeyBackspace {
+			runes := []rune(m.groupInputValue)
+			if m.groupInputCursor > 0 && m.groupInputCursor <= len(runes) {
+				m.groupInputValue = string(runes[:m.groupInputCursor-1]) + string(runes[m.groupInputCursor:])
+				m.groupInputCursor--
+			}
+		} else {
+			for _, r := range msg.Runes {
+				runes := []rune(m.groupInputValue)
+				if m.groupInputCursor > len(runes) {
+					m.groupInputCursor = len(runes)
+				}
+				m.groupInputValue = string(runes[:m.groupInputCursor]) + string(r) + string(runes[m.groupInputCursor:])
+				m.groupInputCursor++
+			}
+		}
+	}
+	return m, nil
+}
+
+// ── Delete group overlay ──────────────────────────────────────────────
+
+func (m Model) handleDeleteGroupKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "n", "N":
+		m.overlay = OverlayNone
+		m.groupDeleteCursor = 1
+		return m, nil
+	case "left", "right", "h", "l", "tab", "shift+tab":
+		m.groupDeleteCursor = 1 - m.groupDeleteCursor
+		return m, nil
+	case "y", "Y":
+		m.groupDeleteCursor = 0
+	}
+
+	if msg.String() == "enter" && m.groupDeleteCursor == 0 {
+		if err := m.store.DeleteGroup(m.groupOldName); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.err = fmt.Errorf("\u2713 Group '%s' deleted", m.groupOldName)
+		m.loadHosts()
+		m.loadGroups()
+		m.rebuildListItems()
+		m.overlay = OverlayNone
+		m.groupDeleteCursor = 1
+		return m, nil
+	}
+
+	if msg.String() == "enter" {
+		m.overlay = OverlayNone
+		m.groupDeleteCursor = 1
+	}
+
+	return m, nil
+}
+
+// ── Tunnels overlay ───────────────────────────────────────────────────
+
+func (m Model) handleTunnelsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	tunnels := m.activeTunnels()
+	addIdx := len(tunnels) // cursor position of the "new tunnel" row
+	if m.tunnelsCursor > addIdx {
+		m.tunnelsCursor = addIdx
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		m.overlay = OverlayNone
+		m.err = nil
+		return m, nil
+
+	case "down", "j", "tab":
+		m.tunnelsCursor = (m.tunnelsCursor + 1) % (addIdx + 1)
+		return m, nil
+
+	case "up", "k", "shift+tab":
+		m.tunnelsCursor = (m.tunnelsCursor + addIdx) % (addIdx + 1)
+		return m, nil
+
+	case "+":
+		m.tunnelsCursor = addIdx
+		fallthrough
+
+	case "enter":
+		if m.tunnelsCursor == addIdx {
+			host, ok := m.selectedHost()
+			if !ok {
+				m.err = fmt.Errorf("select a host first")
+				return m, nil
+			}
+			return m.openForwardOverlay(host), nil
+		}
+		if m.tunnelsStopping != 0 {
+			return m, nil
+		}
+		t := tunnels[m.tunnelsCursor]
+		m.tunnelsStopping = t.LocalPort
+		m.err = nil
+		return m, runStopTunnelCmd(m.tunnelManager, t)
+	}
+	return m, nil
+}
+
+// ── Port forward overlay ──────────────────────────────────────────────
+
+func (m Model) handleForwardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.overlay = OverlayNone
+		m.err = nil
+		return m, nil
+
+	case tea.KeyTab, tea.KeyDown:
+		m.forwardFocus = (m.forwardFocus + 1) % 4
+		return m, nil
+
+	case tea.KeyShiftTab, tea.KeyUp:
+		m.forwardFocus = (m.forwardFocus + 3) % 4
+		return m, nil
+
+	case tea.KeyEnter:
+		if m.forwardFocus < 3 {
+			m.forwardFocus++
+			return m, nil
+		}
+		return m.startLocalForward()
+	}
+
+	if m.forwardFocus < 3 {
+		f := &m.forwardFields[m.forwardFocus]
+		if msg.Type == tea.KeyBackspace {
+			f.DeleteBack()
+		} else if msg.Type == tea.KeyLeft {
+			f.MoveLeft()
+		} else if msg.Type == tea.KeyRight {
+			f.MoveRight()
+		} else {
+			for _, r := range msg.Runes {
+				f.InsertRune(r)
+			}
+		}
+		m.err = nil
+	}
+	return m, nil
+}
+
+// ── Key passphrase overlay ────────────────────────────────────────────
+
+func (m Model) handlePassphraseKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.passphraseField = ui.NewMaskedField("passphrase")
+		m.overlay = OverlayNone
+		m.err = nil
+		return m, nil
+
+	case tea.KeyEnter:
+		if m.passphraseField.Value == "" {
+			m.err = fmt.Errorf("passphrase is required")
+			return m, nil
+		}
+		m.keyPassphrase = []byte(m.passphraseField.Value)
+		m.passphraseField = ui.NewMaskedField("passphrase")
+		m.overlay = OverlayNone
+		m.err = nil
+		var next tea.Model
+		var cmd tea.Cmd
+		if m.passphraseSFTP {
+			next, cmd = m.connectToHostSFTP(m.passphraseHost)
+		} else {
+			next, cmd = m.connectToHost(m.passphraseHost)
+		}
+		// The passphrase is for this attempt only; connect paths that fail
+		// before reaching ssh would otherwise leave it set.
+		m.clearKeyPassphrase()
+		if nm, ok := next.(Model); ok {
+			nm.clearKeyPassphrase()
+			next = nm
+		}
+		return next, cmd
+
+	case tea.KeyBackspace:
+		m.passphraseField.DeleteBack()
+	case tea.KeyLeft:
+		m.passphraseField.MoveLeft()
+	case tea.KeyRight:
+		m.passphraseField.MoveRight()
+	default:
+		for _, r := range msg.Runes {
+			m.passphraseField.InsertRune(r)
+		}
+	}
+	m.err = nil
+	return m, nil
+}
+
+// ── Key rotation overlay ──────────────────────────────────────────────
+
+func (m Model) handleKeyRotationKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	run := m.keyRotation
+	if run.Busy {
+		return m, nil
+	}
+	switch msg.String() {
+	case "esc", "q":
+		m.keyRotation = nil
+		m.overlay = OverlayNone
+		switch run.Step {
+		case rotateStepVerify, rotateStepConfirm:
+			// The new key is on the host but was never saved; take it back off.
+			m.err = fmt.Errorf("\u2139 Key rotation cancelled \u2014 removing the new key from %s", hostDisplayName(run.Host))
+			return m, runKeyRotationAbortCmd(run.Host, run.oldConn, run.newPublic)
+		case rotateStepDone:
+			if run.Err == nil {
+				m.err = fmt.Errorf("\u2713 Rotated key for %s", hostDisplayName(run.Host))
+			}
+		default:
+			m.err = nil
+		}
+		return m, nil
+
+	case "left", "h":
+		if run.Step == rotateStepChoose {
+			run.KeyIdx = (run.KeyIdx - 1 + len(rotateKeyTypes)) % len(rotateKeyTypes)
+		}
+	case "right", "l", " ":
+		if run.Step == rotateStepChoose {
+			run.KeyIdx = (run.KeyIdx + 1) % len(rotateKeyTypes)
+		}
+
+	case "enter":
+		run.Err = nil
+		switch run.Step {
+		case rotateStepChoose:
+			run.Busy = true
+			run.Status = "generating and deploying the new key\u2026"
+			comment := fmt.Sprintf("%s@%s", run.Host.Username, run.Host.Hostname)
+			return m, runKeyRotationDeployCmd(run, rotateKeyTypes[run.KeyIdx], comment)
+		case rotateStepVerify:
+			run.Busy = true
+			run.Status = "logging in with the new key\u2026"
+			return m, runKeyRotationVerifyCmd(run, run.newKeyConn())
+		case rotateStepConfirm:
+			if m.kdfRekeying {
+				run.Err = fmt.Errorf("wait for the key derivation change to finish")
+				return m, nil
+			}
+			if err := m.saveRotatedKey(run); err != nil {
+				run.Err = fmt.Errorf("failed to save new key: %v", err)
+				return m, nil
+			}
+			run.Busy = true
+			run.Status = "new key saved; removing the old key\u2026"
+			return m, runKeyRotationRevokeCmd(run, run.newKeyConn(), run.oldPublic)
+		case rotateStepDone:
+			return m.handleKeyRotationKeys(tea.KeyMsg{Type: tea.KeyEsc})
+		}
+	}
+	return m, nil
+}
+
+// ── Session renew overlay ─────────────────────────────────────────────
+
+func (m Model) handleSessionRenewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.sessionRenewField = ui.NewMaskedField("password")
+		m.overlay = OverlayNone
+		m.err = nil
+		return m, nil
+
+	case tea.KeyEnter:
+		pw := m.sessionRenewField.Value
+		if subtle.ConstantTimeCompare([]byte(pw), []byte(m.masterPassword)) != 1 {
+			m.err = fmt.Errorf("incorrect password")
+			return m, nil
+		}
+		m.sessionRenewField = ui.NewMaskedField("password")
+		m.overlay = OverlayNone
+		m.err = nil
+		if err := unlock.Save(pw, time.Duration(m.cfg.Automation.SessionTTLSeconds)*time.Second); err != nil {
+			m.err = fmt.Errorf("\u26A0 failed to renew session: %v", err)
+			return m, nil
+		}
+		m.refreshSession()
+		if m.sessionRenewNext == "bulk" {
+			return m.openBulkExec(), nil
+		}
+		if m.rekeyBusy() {
+			return m, nil
+		}
+		m.err = fmt.Errorf("\u2139 Syncing...")
+		return m, m.startSync()
+
+	case tea.KeyBackspace:
+		m.sessionRenewField.DeleteBack()
+	case tea.KeyLeft:
+		m.sessionRenewField.MoveLeft()
+	case tea.KeyRight:
+		m.sessionRenewField.MoveRight()
+	default:
+		for _, r := range msg.Runes {
+			m.sessionRenewField.InsertRune(r)
+		}
+	}
+	m.err = nil
+	return m, nil
+}
+
+// ── Mounts overlay ────────────────────────────────────────────────────
+
+func (m Model) handleMountsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	mounts := m.mountsForHost(m.mountsHost)
+	addIdx := len(mounts) // cursor position of the "add another mount" field
+	if m.mountsCursor > addIdx {
+		m.mountsCursor = addIdx
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.overlay = OverlayNone
+		m.err = nil
+		return m, nil
+
+	case tea.KeyTab, tea.KeyDown:
+		m.mountsCursor = (m.mountsCursor + 1) % (addIdx + 1)
+		return m, nil
+
+	case tea.KeyShiftTab, tea.KeyUp:
+		m.mountsCursor = (m.mountsCursor + addIdx) % (addIdx + 1)
+		return m, nil
+
+	case tea.KeyCtrlR:
+		m.mountReadOnly = !m.mountReadOnly
+		return m, nil
+
+	case tea.KeyEnter:
+		m.overlay = OverlayNone
+		m.err = nil
+		if m.mountsCursor < addIdx {
+			return m.startUnmount(mounts[m.mountsCursor].Key())
+		}
+		return m.startMount(m.mountsHost, m.mountsPathField.Value, m.mountReadOnly)
+	}
+
+	if m.mountsCursor == addIdx {
+		f := &m.mountsPathField
+		if msg.Type == tea.KeyBackspace {
+			f.DeleteBack()
+		} else if msg.Type == tea.KeyLeft {
+			f.MoveLeft()
+		} else if msg.Type == tea.KeyRight {
+			f.MoveRight()
+		} else {
+			for _, r := range msg.Runes {
+				f.InsertRune(r)
+			}
+		}
+		m.err = nil
+	}
+	return m, nil
+}
+
+// ── Sync dry-run overlay ──────────────────────────────────────────────
+
+func (m Model) handleSyncDryRunKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n := 0
+	if m.syncDryRun != nil {
+		n = len(m.syncDryRun.Changes)
+	}
+	switch msg.String() {
+	case "esc", "enter", "q":
+		m.overlay = OverlayNone
+		m.syncDryRun = nil
+		return m, nil
+	case "up", "k":
+		if m.syncDryRunCursor > 0 {
+			m.syncDryRunCursor--
+		}
+	case "down", "j":
+		if m.syncDryRunCursor < n-1 {
+			m.syncDryRunCursor++
+		}
+	}
+	return m, nil
+}
+
+// ── Sync conflicts overlay ────────────────────────────────────────────
+
+func (m Model) handleSyncConflictsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.syncConflictApplying {
+		return m, nil
+	}
+	n := len(m.syncConflicts)
+	switch msg.String() {
+	case "esc", "q":
+		m.overlay = OverlayNone
+		m.syncConflicts = nil
+		m.syncConflictPicks = nil
+		return m, nil
+	case "up", "k":
+		if m.syncConflictCursor > 0 {
+			m.syncConflictCursor--
+		}
+	case "down", "j":
+		if m.syncConflictCursor < n-1 {
+			m.syncConflictCursor++
+		}
+	case "l", "L":
+		m.pickSyncConflict("local")
+	case "r", "R":
+		m.pickSyncConflict("remote")
+	case "enter":
+		overrides := m.syncConflictOverrides()
+		if len(overrides) == 0 {
+			m.overlay = OverlayNone
+			m.syncConflicts = nil
+			m.syncConflictPicks = nil
+			return m, nil
+		}
+		m.syncConflictApplying = true
+		return m, applySyncConflictsCmd(m.store, overrides)
+	}
+	return m, nil
+}
+
+// ── SFTP browser overlay ──────────────────────────────────────────────
+
+func (m Model) handleSFTPBrowserKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b := m.sftpBrowser
+	if b == nil {
+		m.overlay = OverlayNone
+		return m, nil
+	}
+
+	if b.Uploading {
+		f := &b.UploadField
+		switch msg.Type {
+		case tea.KeyEsc:
+			b.Uploading = false
+			m.err = nil
+		case tea.KeyEnter:
+			return m.sftpUpload()
+		case tea.KeyBackspace:
+			f.DeleteBack()
+		case tea.KeyLeft:
+			f.MoveLeft()
+		case tea.KeyRight:
+			f.MoveRight()
+		default:
+			for _, r := range msg.Runes {
+				f.InsertRune(r)
+			}
+		}
+		return m, nil
 	}
 
-	// Text input
-	if m.groupFocus == 0 {
-		if msg.Type == tea.KeyBackspace {
-			runes := []rune(m.groupInputValue)
-			if m.groupInputCursor > 0 && m.groupInputCursor <= len(runes) {
-				m.groupInputValue = string(runes[:m.groupInputCursor-1]) + string(runes[m.groupInputCursor:])
-				m.groupInputCursor--
-			}
-		} else {
-			for _, r := range msg.Runes {
-				runes := []rune(m.groupInputValue)
-				if m.groupInputCursor > len(runes) {
-					m.groupInputCursor = len(runes)
-				}
-				m.groupInputValue = string(runes[:m.groupInputCursor]) + string(r) + string(runes[m.groupInputCursor:])
-				m.groupInputCursor++
+	if b.ConfirmDelete {
+		switch msg.String() {
+		case "y", "Y":
+			b.ConfirmDelete = false
+			if entry := b.Selected(); entry != nil {
+				return m, deleteSFTPCmd(b.Session, b.Path, entry)
 			}
+		case "n", "N", "esc":
+			b.ConfirmDelete = false
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		m.closeSFTPBrowser()
+		m.err = fmt.Errorf("\u2139 SFTP disconnected from %s", hostDisplayName(b.Host))
+		return m, nil
+	case "up", "k":
+		if b.Cursor > 0 {
+			b.Cursor--
+		}
+	case "down", "j":
+		if b.Cursor < len(b.Entries)-1 {
+			b.Cursor++
+		}
+	case "enter", "right", "l":
+		if entry := b.Selected(); entry != nil && entry.IsDir() {
+			return m, listSFTPCmd(b.Session, path.Join(b.Path, entry.Name()), "")
+		}
+	case "backspace", "left", "h":
+		if parent := path.Dir(b.Path); parent != b.Path {
+			return m, listSFTPCmd(b.Session, parent, "")
+		}
+	case "d":
+		return m.sftpDownload()
+	case "u":
+		b.Uploading = true
+		b.UploadField = ui.NewFormField("local path")
+		m.err = nil
+	case "delete":
+		if b.Selected() != nil {
+			b.ConfirmDelete = true
+			m.err = nil
 		}
 	}
 	return m, nil
 }
 
-// ── Delete group overlay ──────────────────────────────────────────────
+// ── Sync rollback overlay ─────────────────────────────────────────────
+
+func (m Model) handleSyncRollbackKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	confirm := func() (tea.Model, tea.Cmd) {
+		m.overlay = OverlayNone
+		m.syncing = true
+		m.err = fmt.Errorf("\u2139 Rolling back last sync...")
+		return m, runSyncRollbackCmd(m.syncManager)
+	}
 
-func (m Model) handleDeleteGroupKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
-	case "esc", "n", "N":
+	case "y", "Y":
+		return confirm()
+	case "n", "N", "esc":
 		m.overlay = OverlayNone
-		m.groupDeleteCursor = 1
 		return m, nil
-	case "left", "right", "h", "l", "tab", "shift+tab":
-		m.groupDeleteCursor = 1 - m.groupDeleteCursor
+	case "left", "h", "right", "l", "tab", "shift+tab":
+		m.rollbackCursor = 1 - m.rollbackCursor
 		return m, nil
-	case "y", "Y":
-		m.groupDeleteCursor = 0
-	}
-
-	if msg.String() == "enter" && m.groupDeleteCursor == 0 {
-		if err := m.store.DeleteGroup(m.groupOldName); err != nil {
-			m.err = err
-			return m, nil
+	case "enter":
+		if m.rollbackCursor == 0 {
+			return confirm()
 		}
-		m.err = fmt.Errorf("\u2713 Group '%s' deleted", m.groupOldName)
-		m.loadHosts()
-		m.loadGroups()
-		m.rebuildListItems()
 		m.overlay = OverlayNone
-		m.groupDeleteCursor = 1
 		return m, nil
 	}
+	return m, nil
+}
 
-	if msg.String() == "enter" {
-		m.overlay = OverlayNone
-		m.groupDeleteCursor = 1
+func (m Model) handleChangelogKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	maxScroll := len(m.changelogLines) - ui.ChangelogVisibleLines(m.height)
+	if maxScroll < 0 {
+		maxScroll = 0
 	}
 
+	switch msg.String() {
+	case "esc", "q":
+		m.overlay = OverlayNone
+		return m, nil
+	case "up", "k":
+		if m.changelogScroll > 0 {
+			m.changelogScroll--
+		}
+	case "down", "j":
+		if m.changelogScroll < maxScroll {
+			m.changelogScroll++
+		}
+	case "pgup":
+		m.changelogScroll = max(m.changelogScroll-ui.ChangelogVisibleLines(m.height), 0)
+	case "pgdown", " ":
+		m.changelogScroll = min(m.changelogScroll+ui.ChangelogVisibleLines(m.height), maxScroll)
+	case "left", "h", "shift+tab":
+		m.changelogCursor = (m.changelogCursor + 2) % 3
+	case "right", "l", "tab":
+		m.changelogCursor = (m.changelogCursor + 1) % 3
+	case "enter":
+		m.overlay = OverlayNone
+		switch m.changelogCursor {
+		case 0:
+			return m, m.startUpdateApply()
+		case 1:
+			m.skipUpdate()
+		}
+	}
 	return m, nil
 }
 
@@ -839,10 +1936,20 @@ func (m Model) handleHomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case "tab":
+		// Wide layouts already show the detail panel beside the list.
+		if m.width < ui.LayoutBreakpointSingle && m.selectedIdx < len(m.listItems) {
+			m.overlay = OverlayHostDetail
+		}
+		return m, nil
+
+	case "X":
+		m.updateNotice = ""
+		return m, nil
+
 	case "S":
 		m.armedSFTP = !m.armedSFTP
 		m.armedMount = false
-		m.armedUnmount = false
 		if m.armedSFTP {
 			m.err = fmt.Errorf("SFTP armed \u2014 press Enter")
 		} else {
@@ -864,34 +1971,161 @@ func (m Model) handleHomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.err = fmt.Errorf("select a host first")
 			return m, nil
 		}
-		isMounted := false
-		if m.mountManager != nil {
-			isMounted, _ = m.mountManager.IsMounted(host.ID)
-		}
-		if m.armedMount || m.armedUnmount {
+		if m.armedMount {
 			m.armedMount = false
-			m.armedUnmount = false
 			m.err = nil
 			return m, nil
 		}
 		m.armedSFTP = false
-		if isMounted {
-			m.armedUnmount = true
-			m.err = fmt.Errorf("Unmount armed \u2014 press Enter")
-		} else {
-			m.armedMount = true
-			m.err = fmt.Errorf("Mount (beta) armed \u2014 press Enter")
+		if m.hostHasMounts(host) {
+			return m.openMountsOverlay(host), nil
+		}
+		m.armedMount = true
+		m.mountReadOnly = m.cfg.Mount.DefaultReadOnly
+		m.err = m.mountArmedStatus()
+		return m, nil
+
+	case "T":
+		return m.openTunnels()
+
+	case "P":
+		host, ok := m.selectedHost()
+		if !ok {
+			m.err = fmt.Errorf("select a host first")
+			return m, nil
+		}
+		m.armedSFTP = false
+		m.armedMount = false
+		return m.toggleSocksProxy(host)
+
+	case "L":
+		host, ok := m.selectedHost()
+		if !ok {
+			m.err = fmt.Errorf("select a host first")
+			return m, nil
+		}
+		return m.openLastRecording(host)
+
+	case "F":
+		host, ok := m.selectedHost()
+		if !ok {
+			m.err = fmt.Errorf("select a host first")
+			return m, nil
+		}
+		m.acceptHostKey(host)
+		return m, nil
+
+	case "W":
+		host, ok := m.selectedHost()
+		if !ok {
+			m.err = fmt.Errorf("select a host first")
+			return m, nil
+		}
+		if host.MACAddress == "" {
+			m.err = fmt.Errorf("\u26A0 No MAC address set for %s (edit \u2192 advanced ssh options)", hostDisplayName(host))
+			return m, nil
+		}
+		if m.waking {
+			m.err = fmt.Errorf("\u2139 Already waiting for a host to wake")
+			return m, nil
+		}
+		cmd := m.startWake(host)
+		return m, cmd
+
+	case "R":
+		if m.armedMount {
+			m.mountReadOnly = !m.mountReadOnly
+			m.err = m.mountArmedStatus()
+		} else if len(m.selectedSet) > 0 {
+			if m.sessionExpired {
+				return m.openSessionRenew("bulk"), nil
+			}
+			m = m.openBulkExec()
 		}
 		return m, nil
 
+	case "ctrl+l":
+		return m.lockSession(), nil
+
 	case "esc":
-		if m.armedSFTP || m.armedMount || m.armedUnmount {
+		if m.sshRetryPending {
+			m.sshRetryPending = false
+			m.sshRetryGen++
+			m.err = fmt.Errorf("Connection retry cancelled")
+			return m, nil
+		}
+		if m.armedSFTP || m.armedMount {
 			m.armedSFTP = false
 			m.armedMount = false
-			m.armedUnmount = false
 			m.err = nil
 			return m, nil
 		}
+		if len(m.selectedSet) > 0 {
+			m.selectedSet = map[int]bool{}
+			m.err = nil
+			return m, nil
+		}
+
+	case " ":
+		m.toggleHostSelection()
+		return m, nil
+
+	case "ctrl+r":
+		m = m.openRecentHosts()
+		return m, nil
+
+	case "ctrl+p":
+		return m.openCommandPalette(), nil
+
+	case "ctrl+z":
+		m = m.undoLast()
+		return m, nil
+
+	case "ctrl+y", "ctrl+shift+z":
+		m = m.redoLast()
+		return m, nil
+
+	case "o":
+		return m.setSort((m.sortBy+1)%numSortBy, m.sortDesc), nil
+
+	case "O":
+		return m.setSort(m.sortBy, !m.sortDesc), nil
+
+	case "p":
+		if host, ok := m.selectedHost(); ok {
+			m = m.togglePinned(host)
+		}
+		return m, nil
+
+	case "C":
+		host, ok := m.selectedHost()
+		if !ok {
+			m.err = fmt.Errorf("select a host first")
+			return m, nil
+		}
+		return m.copyConnectionString(host)
+
+	case "K":
+		host, ok := m.selectedHost()
+		if !ok {
+			m.err = fmt.Errorf("select a host first")
+			return m, nil
+		}
+		return m.openKeyView(host), nil
+
+	case "D":
+		if len(m.selectedSet) > 0 {
+			m.bulkDelete = true
+			m.deleteCursor = 1 // default to cancel
+			m.overlay = OverlayDeleteHost
+		}
+		return m, nil
+
+	case "E":
+		if len(m.selectedSet) > 0 {
+			m = m.exportSelected()
+		}
+		return m, nil
 
 	case "up", "k":
 		if key == "k" && !m.cfg.UI.VimMode {
@@ -924,6 +2158,11 @@ func (m Model) handleHomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.selectedIdx = 0
 
 	case "end", "G":
+		if key == "G" && len(m.selectedSet) > 0 {
+			m.moveGroupCursor = 0
+			m.overlay = OverlayMoveHosts
+			return m, nil
+		}
 		if key == "G" && !m.cfg.UI.VimMode {
 			return m, nil
 		}
@@ -941,7 +2180,9 @@ func (m Model) handleHomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if item, ok := m.selectedListItem(); ok && item.Kind == ListItemNewGroup {
 			m.groupInputValue = ""
 			m.groupInputCursor = 0
+			m.groupColor = ""
 			m.groupFocus = 0
+			m.groupParent = ""
 			m.overlay = OverlayCreateGroup
 			return m, nil
 		}
@@ -949,7 +2190,7 @@ func (m Model) handleHomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if g, ok := m.selectedGroup(); ok {
 			groupPrefill = g
 		}
-		m.initAddHostForm("", groupPrefill, "", "", "", "22", "", "")
+		m.initAddHostForm("", groupPrefill, "", "", "", "22", "", "", "", false)
 		m.overlay = OverlayAddHost
 		m.formEditIdx = -1
 
@@ -959,9 +2200,14 @@ func (m Model) handleHomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.err = fmt.Errorf("cannot rename Ungrouped")
 				return m, nil
 			}
+			if item.Virtual {
+				m.err = fmt.Errorf("\u2139 virtual groups are defined in config.json")
+				return m, nil
+			}
 			m.groupOldName = item.GroupName
 			m.groupInputValue = item.GroupName
 			m.groupInputCursor = len([]rune(item.GroupName))
+			m.groupColor = m.groupColors[strings.ToLower(item.GroupName)]
 			m.groupFocus = 0
 			m.overlay = OverlayRenameGroup
 			return m, nil
@@ -976,7 +2222,23 @@ func (m Model) handleHomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				}
 			}
 			tagInput := strings.Join(host.Tags, ", ")
-			m.initAddHostForm(host.Label, host.GroupName, tagInput, host.Hostname, host.Username, fmt.Sprintf("%d", host.Port), host.KeyType, existingKey)
+			m.initAddHostForm(host.Label, host.GroupName, tagInput, host.Hostname, host.Username, fmt.Sprintf("%d", host.Port), host.KeyType, existingKey, host.Recording, host.SyncExclude)
+			m.formFields[ui.FFSSHOpts].SetValue(ssh.FormatOptions(host.SSHOptions))
+			m.formFields[ui.FFMAC].SetValue(host.MACAddress)
+			m.formFields[ui.FFBroadcast].SetValue(host.BroadcastAddr)
+			m.formFields[ui.FFKeepAlive].SetValue(fmt.Sprintf("%d", host.KeepAliveSeconds))
+			m.formFields[ui.FFTerm].SetValue(host.TermOverride)
+			m.formFields[ui.FFIcon].SetValue(host.Icon)
+			m.formFields[ui.FFColor].SetValue(host.ColorOverride)
+			switch host.IdentitiesOnly {
+			case "on":
+				m.formIdentIdx = 1
+			case "off":
+				m.formIdentIdx = 2
+			}
+			m.formX11 = host.ForwardX11
+			m.setFormJumps(host.ID, host.ProxyHosts)
+			m.formAdvanced = len(host.SSHOptions) > 0 || host.MACAddress != "" || host.KeepAliveSeconds > 0 || host.TermOverride != "" || host.IdentitiesOnly != "" || host.ForwardX11 || len(host.ProxyHosts) > 0
 			m.formEditIdx = m.selectedIdx
 			m.overlay = OverlayAddHost
 		}
@@ -987,6 +2249,10 @@ func (m Model) handleHomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.err = fmt.Errorf("cannot delete Ungrouped")
 				return m, nil
 			}
+			if item.Virtual {
+				m.err = fmt.Errorf("\u2139 virtual groups are defined in config.json")
+				return m, nil
+			}
 			m.groupOldName = item.GroupName
 			m.groupDeleteCursor = 1 // default to cancel
 			m.overlay = OverlayDeleteGroup
@@ -997,9 +2263,22 @@ func (m Model) handleHomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.overlay = OverlayDeleteHost
 		}
 
+	case "I":
+		if m.store == nil {
+			return m, nil
+		}
+		m.openImportWizard()
+		return m, nil
+
 	case "ctrl+g":
+		// On a group header this creates a subgroup of that group.
+		m.groupParent = ""
+		if item, ok := m.selectedListItem(); ok && item.Kind == ListItemGroup && !item.Virtual && item.GroupName != "Ungrouped" {
+			m.groupParent = item.GroupName
+		}
 		m.groupInputValue = ""
 		m.groupInputCursor = 0
+		m.groupColor = ""
 		m.groupFocus = 0
 		m.overlay = OverlayCreateGroup
 		return m, nil
@@ -1010,19 +2289,20 @@ func (m Model) handleHomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		if item.Kind == ListItemGroup {
-			m.collapsed[item.GroupName] = !m.collapsed[item.GroupName]
+			m.collapsed[item.collapseKey()] = !m.collapsed[item.collapseKey()]
 			m.rebuildListItems()
 			return m, nil
 		}
 		if item.Kind == ListItemNewGroup {
 			m.groupInputValue = ""
 			m.groupInputCursor = 0
+			m.groupColor = ""
 			m.groupFocus = 0
 			m.overlay = OverlayCreateGroup
 			return m, nil
 		}
 		host := item.Host
-		if m.armedMount || m.armedUnmount {
+		if m.armedMount {
 			return m.handleMountEnter(host)
 		}
 		if m.armedSFTP {
@@ -1048,6 +2328,9 @@ func (m Model) handleHomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.err = fmt.Errorf("\u2139 sync already in progress")
 			return m, nil
 		}
+		if m.rekeyBusy() {
+			return m, nil
+		}
 		if m.syncManager == nil {
 			m.err = fmt.Errorf("\u26A0 sync manager is nil")
 			return m, nil
@@ -1056,13 +2339,15 @@ func (m Model) handleHomeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.err = fmt.Errorf("\u26A0 sync is disabled \u2014 enable in settings")
 			return m, nil
 		}
-		m.syncing = true
-		m.syncRunID++
-		m.syncAnimFrame = 0
-		m.syncProgress = 0.02
-		runID := m.syncRunID
+		if m.sessionExpired {
+			return m.openSessionRenew("sync"), nil
+		}
 		m.err = fmt.Errorf("\u2139 Syncing...")
-		return m, tea.Batch(runSyncCmd(runID, m.syncManager), syncAnimTickCmd(runID))
+		return m, m.startSync()
+
+	case "Z":
+		m.openRollbackConfirm()
+		return m, nil
 	}
 
 	return m, nil
@@ -1119,8 +2404,9 @@ func (m Model) handleSettingsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			m.settingsEditing = false
 			m.settingsEditVal = ""
+			cmd := m.takeKDFRekeyCmd()
 			m.settingsItems = m.buildSettingsItems()
-			return m, nil
+			return m, cmd
 		}
 		if msg.Type == tea.KeyBackspace {
 			m.settingsEditVal = removeLastRune(m.settingsEditVal)
@@ -1135,9 +2421,13 @@ func (m Model) handleSettingsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	filtered := m.filteredSettingsIdxs()
 
 	switch key {
+	case "ctrl+p":
+		return m.openCommandPalette(), nil
+
 	case "esc", "q", "Q":
 		// Auto-save when leaving
-		if m.cfg != m.cfgOriginal {
+		var cmd tea.Cmd
+		if !reflect.DeepEqual(m.cfg, m.cfgOriginal) {
 			if err := config.Save(m.cfg); err != nil {
 				m.err = fmt.Errorf("failed to save settings: %v", err)
 				return m, nil
@@ -1148,14 +2438,21 @@ func (m Model) handleSettingsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					m.syncManager = syncMgr
 				}
 			}
+			if m.cfg.Sync != m.cfgOriginal.Sync || m.cfg.Automation.AutoSyncIntervalSeconds != m.cfgOriginal.Automation.AutoSyncIntervalSeconds {
+				cmd = m.scheduleAutoSync()
+			}
+			if m.cfg.UI.PingIntervalSeconds != m.cfgOriginal.UI.PingIntervalSeconds {
+				cmd = tea.Batch(cmd, m.schedulePing())
+			}
 			m.err = fmt.Errorf("\u2713 Settings saved")
 		}
 		m.page = PageHome
-		return m, nil
+		return m, cmd
 
 	case "shift+tab":
 		// Save settings before navigating away
-		if m.cfg != m.cfgOriginal {
+		var cmd tea.Cmd
+		if !reflect.DeepEqual(m.cfg, m.cfgOriginal) {
 			if err := config.Save(m.cfg); err != nil {
 				m.err = fmt.Errorf("failed to save settings: %v", err)
 				return m, nil
@@ -1166,6 +2463,12 @@ func (m Model) handleSettingsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					m.syncManager = syncMgr
 				}
 			}
+			if m.cfg.Sync != m.cfgOriginal.Sync || m.cfg.Automation.AutoSyncIntervalSeconds != m.cfgOriginal.Automation.AutoSyncIntervalSeconds {
+				cmd = m.scheduleAutoSync()
+			}
+			if m.cfg.UI.PingIntervalSeconds != m.cfgOriginal.UI.PingIntervalSeconds {
+				cmd = tea.Batch(cmd, m.schedulePing())
+			}
 			m.err = fmt.Errorf("\u2713 Settings saved")
 		}
 		m.page = (m.page + 1) % NumPages
@@ -1175,7 +2478,7 @@ func (m Model) handleSettingsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.cfgOriginal = m.cfg
 			m.settingsItems = m.buildSettingsItems()
 		}
-		return m, nil
+		return m, cmd
 
 	case "/":
 		m.settingsSearching = true
@@ -1251,11 +2554,7 @@ func (m Model) handleSettingsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		case "apply update":
 			if m.updateLast != nil && m.updateLast.UpdateAvailable && !m.updateApplying {
-				m.updateRunID++
-				m.updateApplying = true
-				m.err = fmt.Errorf("\u2139 Applying update...")
-				m.settingsItems = m.buildSettingsItems()
-				return m, runUpdateApplyCmd(m.updateRunID, *m.updateLast)
+				return m, m.openChangelog()
 			}
 			return m, nil
 		case "fix PATH":
@@ -1267,10 +2566,57 @@ func (m Model) handleSettingsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				return m, runUpdatePathFixCmd(m.updateRunID, exe)
 			}
 			return m, nil
+		case "dry run":
+			if item.Disabled || m.syncing || m.rekeyBusy() {
+				return m, nil
+			}
+			m.err = fmt.Errorf("\u2139 Previewing sync...")
+			return m, runSyncDryRunCmd(m.syncManager)
+		case "test webhook":
+			if item.Disabled {
+				return m, nil
+			}
+			m.err = fmt.Errorf("\u2139 Sending test webhook...")
+			return m, testWebhookCmd(m.cfg.Sync.WebhookURL, m.cfg.Sync.WebhookSecret)
+		case "rollback last sync":
+			if !item.Disabled {
+				m.openRollbackConfirm()
+			}
+			return m, nil
 		case "manage tokens":
 			m.page = PageTokens
 			m.loadTokenSummaries()
 			return m, nil
+		case "backup now":
+			if !item.Disabled {
+				m.backupDatabase()
+			}
+			return m, nil
+		case "vacuum now":
+			if !item.Disabled {
+				m.vacuumDatabase()
+				m.settingsItems = m.buildSettingsItems()
+			}
+			return m, nil
+		case "upgrade key derivation":
+			if item.Disabled {
+				return m, nil
+			}
+			if m.syncing {
+				m.err = fmt.Errorf("\u2139 wait for the sync to finish")
+				return m, nil
+			}
+			cmd := m.upgradeKDF()
+			m.settingsItems = m.buildSettingsItems()
+			return m, cmd
+		case "benchmark KDF":
+			if item.Disabled {
+				return m, nil
+			}
+			m.kdfBenchmarking = true
+			m.err = fmt.Errorf("\u2139 Benchmarking key derivation...")
+			m.settingsItems = m.buildSettingsItems()
+			return m, runKDFBenchmarkCmd()
 		}
 		// Kind=2 editable text fields
 		if item.Kind == 2 && !item.Disabled {
@@ -1325,6 +2671,7 @@ func (m Model) handleTokensKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.tokenMode = tokenModeList
 			m.tokenNameValue = ""
 			m.tokenHostPick = map[int]bool{}
+			m.tokenHostCmds = map[int]string{}
 			m.err = nil
 			return m, nil
 		case "enter":
@@ -1335,6 +2682,7 @@ func (m Model) handleTokensKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			m.tokenMode = tokenModeCreateScope
 			m.tokenHostPick = map[int]bool{}
+			m.tokenHostCmds = map[int]string{}
 			m.tokenHostIdx = 0
 			m.err = fmt.Errorf("select hosts and press Enter to create token")
 			return m, nil
@@ -1351,10 +2699,22 @@ func (m Model) handleTokensKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	// Token create scope (host picker)
 	if m.tokenMode == tokenModeCreateScope {
+		if m.tokenCmdEditing {
+			return m.handleTokenCommandInput(msg)
+		}
 		switch key {
+		case "tab":
+			if len(m.hosts) > 0 && m.tokenHostPick[m.hosts[m.tokenHostIdx].ID] {
+				m.tokenCmdEditing = true
+				m.err = fmt.Errorf("type an allowed command (glob ok), enter to finish")
+			} else {
+				m.err = fmt.Errorf("select the host before restricting its commands")
+			}
+			return m, nil
 		case "esc":
 			m.tokenMode = tokenModeList
 			m.tokenHostPick = map[int]bool{}
+			m.tokenHostCmds = map[int]string{}
 			m.tokenHostIdx = 0
 			m.tokenNameValue = ""
 			m.err = nil
@@ -1380,6 +2740,7 @@ func (m Model) handleTokensKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				h := m.hosts[m.tokenHostIdx]
 				if m.tokenHostPick[h.ID] {
 					delete(m.tokenHostPick, h.ID)
+					delete(m.tokenHostCmds, h.ID)
 				} else {
 					m.tokenHostPick[h.ID] = true
 				}
@@ -1398,6 +2759,7 @@ func (m Model) handleTokensKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			m.tokenMode = tokenModeList
 			m.tokenHostPick = map[int]bool{}
+			m.tokenHostCmds = map[int]string{}
 			m.tokenNameValue = ""
 			m.loadTokenSummaries()
 			m.tokenRevealOpen = true
@@ -1411,6 +2773,9 @@ func (m Model) handleTokensKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	// Token list mode
 	switch key {
+	case "ctrl+p":
+		return m.openCommandPalette(), nil
+
 	case "esc", "q", "Q":
 		m.page = PageHome
 		m.err = nil
@@ -1447,6 +2812,7 @@ func (m Model) handleTokensKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "a":
 		m.tokenMode = tokenModeCreateName
 		m.tokenHostPick = map[int]bool{}
+		m.tokenHostCmds = map[int]string{}
 		m.tokenHostIdx = 0
 		m.tokenNameValue = ""
 		m.err = fmt.Errorf("enter token name and press Enter")
@@ -1493,12 +2859,35 @@ func (m Model) handleTokensKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleTokenCommandInput edits the allowed-command pattern of the host under
+// the cursor in the token scope picker.
+func (m Model) handleTokenCommandInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	id := m.hosts[m.tokenHostIdx].ID
+	switch msg.Type {
+	case tea.KeyEnter, tea.KeyTab, tea.KeyEsc:
+		m.tokenCmdEditing = false
+		m.tokenHostCmds[id] = strings.TrimSpace(m.tokenHostCmds[id])
+		if m.tokenHostCmds[id] == "" {
+			delete(m.tokenHostCmds, id)
+		}
+		m.err = fmt.Errorf("select hosts and press Enter to create token")
+	case tea.KeyBackspace:
+		m.tokenHostCmds[id] = removeLastRune(m.tokenHostCmds[id])
+	case tea.KeySpace:
+		m.tokenHostCmds[id] += " "
+	case tea.KeyRunes:
+		m.tokenHostCmds[id] += string(msg.Runes)
+	}
+	return m, nil
+}
+
 // ── Quit request ──────────────────────────────────────────────────────
 
 func (m Model) requestQuit() (tea.Model, tea.Cmd) {
 	if m.overlay == OverlayQuit {
 		return m, nil
 	}
+	m.closeSFTPBrowser()
 	// Check config-driven auto behavior only when mounts are active
 	if m.mountManager != nil {
 		if mounts := m.mountManager.ListActive(); len(mounts) > 0 {
@@ -1533,7 +2922,70 @@ func (m Model) quitAndUnmountAll() (tea.Model, tea.Cmd) {
 
 // ── Form initialization ───────────────────────────────────────────────
 
-func (m *Model) initAddHostForm(label, groupName, tags, hostname, username, port, keyType, existingKey string) {
+// formRecordingValue maps the record-sessions selector to the stored override.
+func (m Model) formRecordingValue() string {
+	if m.formRecIdx <= 0 || m.formRecIdx >= len(m.formRecOpts) {
+		return ""
+	}
+	return m.formRecOpts[m.formRecIdx]
+}
+
+// formIdentitiesValue maps the identities-only selector to the stored override.
+func (m Model) formIdentitiesValue() string {
+	if m.formIdentIdx <= 0 || m.formIdentIdx >= len(m.formRecOpts) {
+		return ""
+	}
+	return m.formRecOpts[m.formIdentIdx]
+}
+
+// setFormJumps fills the jump host selectors with every host except selfID
+// and selects chain, first hop first.
+func (m *Model) setFormJumps(selfID int, chain []int) {
+	m.formJumpIDs = []int{0}
+	m.formJumpOpts = []string{"none"}
+	for _, h := range m.hosts {
+		if h.ID == selfID {
+			continue
+		}
+		m.formJumpIDs = append(m.formJumpIDs, h.ID)
+		m.formJumpOpts = append(m.formJumpOpts, hostDisplayName(h))
+	}
+	m.formJumpIdx = make([]int, db.MaxProxyHosts)
+	for i, id := range chain {
+		if i >= len(m.formJumpIdx) {
+			break
+		}
+		for j, optID := range m.formJumpIDs {
+			if optID == id {
+				m.formJumpIdx[i] = j
+				break
+			}
+		}
+		if m.formJumpIdx[i] == 0 {
+			break
+		}
+	}
+}
+
+// formJumpChain returns the selected jump host IDs up to the first "none",
+// skipping repeats.
+func (m Model) formJumpChain() []int {
+	var chain []int
+	seen := map[int]bool{}
+	for _, idx := range m.formJumpIdx {
+		if idx <= 0 || idx >= len(m.formJumpIDs) {
+			break
+		}
+		id := m.formJumpIDs[idx]
+		if !seen[id] {
+			seen[id] = true
+			chain = append(chain, id)
+		}
+	}
+	return chain
+}
+
+func (m *Model) initAddHostForm(label, groupName, tags, hostname, username, port, keyType, existingKey, recording string, syncExclude bool) {
 	authIdx := 0
 	switch keyType {
 	case "password":
@@ -1564,7 +3016,7 @@ func (m *Model) initAddHostForm(label, groupName, tags, hostname, username, port
 		}
 	}
 
-	m.formFields = make([]ui.FormField, 6)
+	m.formFields = make([]ui.FormField, 13)
 	m.formFields[ui.FFLabel] = ui.NewFormField("label")
 	m.formFields[ui.FFLabel].SetValue(label)
 	m.formFields[ui.FFTags] = ui.NewFormField("tags")
@@ -1596,6 +3048,27 @@ func (m *Model) initAddHostForm(label, groupName, tags, hostname, username, port
 		}
 	}
 	m.formKeyIdx = keyIdx
+	m.formRecOpts = []string{"default", "on", "off"}
+	m.formRecIdx = 0
+	switch recording {
+	case "on":
+		m.formRecIdx = 1
+	case "off":
+		m.formRecIdx = 2
+	}
+	m.formSyncExcl = syncExclude
+	m.formIdentIdx = 0
+	m.formFields[ui.FFSSHOpts] = ui.NewFormField("ssh options")
+	m.formFields[ui.FFMAC] = ui.NewFormField("wake-on-lan mac")
+	m.formFields[ui.FFBroadcast] = ui.NewFormField("broadcast address")
+	m.formFields[ui.FFKeepAlive] = ui.NewFormField("keepalive (s)")
+	m.formFields[ui.FFKeepAlive].SetValue("0")
+	m.formFields[ui.FFTerm] = ui.NewFormField("TERM")
+	m.formFields[ui.FFIcon] = ui.NewFormField("icon")
+	m.formFields[ui.FFColor] = ui.NewFormField("color")
+	m.formX11 = false
+	m.setFormJumps(0, nil)
+	m.formAdvanced = false
 	m.formFocus = ui.FFLabel
 	m.formEditing = false
 }
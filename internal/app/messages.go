@@ -2,12 +2,23 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/Vansh-Raja/SSHThing/internal/config"
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+	"github.com/Vansh-Raja/SSHThing/internal/ssh"
 	syncpkg "github.com/Vansh-Raja/SSHThing/internal/sync"
+	"github.com/Vansh-Raja/SSHThing/internal/ui"
 	"github.com/Vansh-Raja/SSHThing/internal/update"
+	"github.com/Vansh-Raja/SSHThing/internal/wol"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -20,9 +31,36 @@ type sshFinishedMsg struct {
 	keyType  string
 }
 
+// sshRetryMsg reports an SSH connection that was refused or timed out before
+// the session started; attempt is the retry about to be made, counting from 1.
+type sshRetryMsg struct {
+	host    Host
+	attempt int
+	err     error
+}
+
+// sshRetryTickMsg fires when a pending SSH retry is due.
+type sshRetryTickMsg struct {
+	gen     int
+	host    Host
+	attempt int
+}
+
+type proxyStartedMsg struct {
+	hostID int
+	port   int
+	host   string
+	err    error
+}
+
+type pagerFinishedMsg struct {
+	err error
+}
+
 type mountFinishedMsg struct {
 	action string // "mount" | "unmount"
 	hostID int
+	remote string
 	local  string
 	err    error
 	stderr string
@@ -33,14 +71,166 @@ type syncFinishedMsg struct {
 	result *syncpkg.SyncResult
 }
 
+type syncRollbackMsg struct {
+	result *syncpkg.ImportResult
+	err    error
+}
+
+type autoSyncMsg struct {
+	gen int
+}
+
+// bulkExecTickMsg redraws the bulk command overlay while commands run.
+type bulkExecTickMsg struct{}
+
+// bulkExecDoneMsg reports that every host in a bulk run has finished.
+type bulkExecDoneMsg struct {
+	run *BulkExec
+}
+
+// pingTickMsg fires when the next round of host reachability checks is due.
+type pingTickMsg struct {
+	gen int
+}
+
+// pingDoneMsg reports that a round of reachability checks finished.
+type pingDoneMsg struct{}
+
+// bannerFetchedMsg carries the SSH banner fetched for a host.
+type bannerFetchedMsg struct {
+	hostID int
+	banner string
+	err    error
+}
+
+// bannerFetchTimeout bounds a background banner fetch.
+const bannerFetchTimeout = 5 * time.Second
+
+// wakeSentMsg reports whether the Wake-on-LAN packet was sent.
+type wakeSentMsg struct {
+	runID int
+	err   error
+}
+
+// wakePollMsg fires when the next check of a waking host is due.
+type wakePollMsg struct {
+	runID int
+}
+
+// wakeProbeMsg reports whether a waking host answered on its SSH port.
+type wakeProbeMsg struct {
+	runID int
+	err   error
+}
+
+// keyRotationDeployedMsg reports that a new key pair was generated and its
+// public key added to the host's authorized_keys.
+type keyRotationDeployedMsg struct {
+	run        *KeyRotation
+	oldPublic  string
+	newPrivate string
+	newPublic  string
+	err        error
+}
+
+// keyRotationVerifiedMsg reports whether a login with the new key worked.
+type keyRotationVerifiedMsg struct {
+	run *KeyRotation
+	err error
+}
+
+// keyRotationRevokedMsg reports whether the old key was removed from the
+// host after the new one was saved.
+type keyRotationRevokedMsg struct {
+	run *KeyRotation
+	err error
+}
+
+// keyRotationAbortedMsg reports the cleanup of a new key deployed by a
+// cancelled rotation.
+type keyRotationAbortedMsg struct {
+	host Host
+	err  error
+}
+
+// tunnelsTickMsg refreshes the tunnels overlay.
+type tunnelsTickMsg struct {
+	gen int
+}
+
+// tunnelStoppedMsg reports that a tunnel's ssh process exited after a kill.
+type tunnelStoppedMsg struct {
+	localPort int
+	err       error
+}
+
+// sessionTickMsg fires when the footer's unlock session segment is due for a
+// refresh.
+type sessionTickMsg struct {
+	gen int
+}
+
+type wakeAnimTickMsg struct {
+	runID int
+}
+
+type kdfBenchmarkMsg struct {
+	params db.KDFParams
+}
+
+type kdfRekeyedMsg struct {
+	params  db.KDFParams
+	upgrade bool // the "upgrade key derivation" action, not an edited setting
+	err     error
+}
+
+type syncConflictsAppliedMsg struct {
+	applied int
+	total   int
+	err     error
+}
+
+type syncDryRunMsg struct {
+	result *syncpkg.ImportResult
+	err    error
+}
+
+type webhookTestedMsg struct {
+	err error
+}
+
+type sftpOpenedMsg struct {
+	host    Host
+	session *ssh.SFTPSession
+	path    string
+	entries []os.FileInfo
+	err     error
+}
+
+type sftpListMsg struct {
+	path    string
+	entries []os.FileInfo
+	status  string // shown on success, e.g. after a delete
+	err     error
+}
+
+type sftpTransferMsg struct {
+	action string // "download" | "upload"
+	name   string
+	dest   string
+	bytes  int64
+	err    error
+}
+
 type syncAnimTickMsg struct {
 	runID int
 }
 
 type updateCheckedMsg struct {
-	runID  int
-	result *update.CheckResult
-	err    error
+	runID      int
+	result     *update.CheckResult
+	err        error
+	background bool // startup check: no status message, only a footer notice
 }
 
 type updateAppliedMsg struct {
@@ -50,6 +240,12 @@ type updateAppliedMsg struct {
 	err            error
 }
 
+type changelogFetchedMsg struct {
+	tag  string
+	body string
+	err  error
+}
+
 type updatePathFixedMsg struct {
 	runID      int
 	pathHealth update.PathHealth
@@ -62,8 +258,23 @@ type clearErrMsg struct {
 	seq int
 }
 
+type clearCopyNoticeMsg struct {
+	seq int
+}
+
+type importFinishedMsg struct {
+	summary db.ImportSummary
+	err     error
+}
+
 type tickMsg struct{}
 
+type idleCheckMsg struct {
+	at time.Time
+}
+
+type toastTickMsg struct{}
+
 // ── Command constructors ──────────────────────────────────────────────
 
 func tickCmd() tea.Cmd {
@@ -72,6 +283,21 @@ func tickCmd() tea.Cmd {
 	})
 }
 
+// idleCheckInterval is how often the idle auto-lock timeout is checked.
+const idleCheckInterval = 30 * time.Second
+
+func idleCheckCmd() tea.Cmd {
+	return tea.Tick(idleCheckInterval, func(t time.Time) tea.Msg {
+		return idleCheckMsg{at: t}
+	})
+}
+
+func toastTickCmd() tea.Cmd {
+	return tea.Tick(250*time.Millisecond, func(time.Time) tea.Msg {
+		return toastTickMsg{}
+	})
+}
+
 func runSyncCmd(runID int, mgr *syncpkg.Manager) tea.Cmd {
 	return func() tea.Msg {
 		if mgr == nil {
@@ -81,6 +307,278 @@ func runSyncCmd(runID int, mgr *syncpkg.Manager) tea.Cmd {
 	}
 }
 
+func runSyncRollbackCmd(mgr *syncpkg.Manager) tea.Cmd {
+	return func() tea.Msg {
+		if mgr == nil {
+			return syncRollbackMsg{err: fmt.Errorf("sync manager is nil")}
+		}
+		res, err := mgr.Rollback()
+		return syncRollbackMsg{result: res, err: err}
+	}
+}
+
+func runImportCmd(store *db.Store, hosts []db.HostModel, conflict db.ImportConflict) tea.Cmd {
+	return func() tea.Msg {
+		summary, err := store.ImportHosts(hosts, conflict)
+		return importFinishedMsg{summary: summary, err: err}
+	}
+}
+
+func runPuTTYImportCmd(store *db.Store, path string, dir bool) tea.Cmd {
+	return func() tea.Msg {
+		if dir {
+			summary, err := db.ImportPuTTYDir(store, path)
+			return importFinishedMsg{summary: summary, err: err}
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return importFinishedMsg{err: err}
+		}
+		defer f.Close()
+		summary, err := db.ImportPuTTY(store, f)
+		return importFinishedMsg{summary: summary, err: err}
+	}
+}
+
+func autoSyncTickCmd(gen int, interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return autoSyncMsg{gen: gen}
+	})
+}
+
+func bulkExecTickCmd() tea.Cmd {
+	return tea.Tick(200*time.Millisecond, func(time.Time) tea.Msg {
+		return bulkExecTickMsg{}
+	})
+}
+
+// bulkExecJob is one host of a bulk run with its connection prepared.
+type bulkExecJob struct {
+	host *BulkExecHost
+	conn ssh.Connection
+}
+
+// runBulkExecCmd runs command on every job, at most concurrency at a time.
+func runBulkExecCmd(run *BulkExec, jobs []bulkExecJob, command string, concurrency int) tea.Cmd {
+	return func() tea.Msg {
+		sem := make(chan struct{}, max(1, concurrency))
+		var wg sync.WaitGroup
+		for _, job := range jobs {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(job bulkExecJob) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				runBulkExecHost(run, job, command)
+			}(job)
+		}
+		wg.Wait()
+		return bulkExecDoneMsg{run: run}
+	}
+}
+
+func runBulkExecHost(run *BulkExec, job bulkExecJob, command string) {
+	run.mu.Lock()
+	job.host.Running = true
+	run.mu.Unlock()
+
+	err := func() error {
+		cmd, tempKey, err := ssh.ConnectExec(job.conn, command)
+		if err != nil {
+			return err
+		}
+		if tempKey != nil {
+			defer tempKey.Cleanup()
+		}
+		out := bulkExecOutput{run: run, host: job.host}
+		cmd.Stdin = nil
+		cmd.Stdout = out
+		cmd.Stderr = out
+		return cmd.Run()
+	}()
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	job.host.Running = false
+	job.host.Done = true
+	job.host.Err = err
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		job.host.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		job.host.ExitCode = -1
+	}
+}
+
+func pingTickCmd(gen int, interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return pingTickMsg{gen: gen}
+	})
+}
+
+func runPingCmd(mgr *ssh.HostPingManager, targets []ssh.PingTarget) tea.Cmd {
+	return func() tea.Msg {
+		mgr.CheckAll(targets)
+		return pingDoneMsg{}
+	}
+}
+
+func fetchBannerCmd(hostID int, conn ssh.Connection) tea.Cmd {
+	return func() tea.Msg {
+		banner, err := ssh.FetchBanner(conn, bannerFetchTimeout)
+		return bannerFetchedMsg{hostID: hostID, banner: banner, err: err}
+	}
+}
+
+func runKeyRotationDeployCmd(run *KeyRotation, keyType, comment string) tea.Cmd {
+	conn := run.oldConn
+	return func() tea.Msg {
+		msg := keyRotationDeployedMsg{run: run}
+		msg.oldPublic, msg.err = ssh.GetPublicKeyFromPrivate(conn.PrivateKey)
+		if msg.err != nil {
+			return msg
+		}
+		msg.newPrivate, msg.newPublic, msg.err = ssh.GenerateKey(ssh.KeyType(keyType), comment)
+		if msg.err != nil {
+			return msg
+		}
+		msg.err = ssh.DeployPublicKey(conn, msg.newPublic)
+		return msg
+	}
+}
+
+func runKeyRotationVerifyCmd(run *KeyRotation, conn ssh.Connection) tea.Cmd {
+	return func() tea.Msg {
+		return keyRotationVerifiedMsg{run: run, err: ssh.VerifyKeyLogin(conn)}
+	}
+}
+
+func runKeyRotationRevokeCmd(run *KeyRotation, conn ssh.Connection, oldPublic string) tea.Cmd {
+	return func() tea.Msg {
+		return keyRotationRevokedMsg{run: run, err: ssh.RevokePublicKey(conn, oldPublic)}
+	}
+}
+
+func runKeyRotationAbortCmd(host Host, conn ssh.Connection, newPublic string) tea.Cmd {
+	return func() tea.Msg {
+		return keyRotationAbortedMsg{host: host, err: ssh.RevokePublicKey(conn, newPublic)}
+	}
+}
+
+// tunnelsRefreshInterval is how often the tunnels overlay re-reads the
+// tunnel list, so tunnels whose ssh process died drop out.
+const tunnelsRefreshInterval = 5 * time.Second
+
+func tunnelsTickCmd(gen int) tea.Cmd {
+	return tea.Tick(tunnelsRefreshInterval, func(time.Time) tea.Msg {
+		return tunnelsTickMsg{gen: gen}
+	})
+}
+
+func runStopTunnelCmd(mgr *ssh.TunnelManager, t ssh.Tunnel) tea.Cmd {
+	return func() tea.Msg {
+		return tunnelStoppedMsg{localPort: t.LocalPort, err: mgr.Remove(t.HostID, t.LocalPort)}
+	}
+}
+
+// sessionRefreshInterval is how often the unlock session cache is re-read.
+const sessionRefreshInterval = 60 * time.Second
+
+func sessionTickCmd(gen int) tea.Cmd {
+	return tea.Tick(sessionRefreshInterval, func(time.Time) tea.Msg {
+		return sessionTickMsg{gen: gen}
+	})
+}
+
+func sshRetryTickCmd(gen int, host Host, attempt int, delay time.Duration) tea.Cmd {
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		return sshRetryTickMsg{gen: gen, host: host, attempt: attempt}
+	})
+}
+
+// wakeMaxAttempts and wakePollInterval bound how long a woken host has to
+// start answering on its SSH port.
+const (
+	wakeMaxAttempts  = 30
+	wakePollInterval = 2 * time.Second
+)
+
+func runWakeCmd(runID int, mac, broadcastAddr string) tea.Cmd {
+	return func() tea.Msg {
+		return wakeSentMsg{runID: runID, err: wol.Wake(mac, broadcastAddr)}
+	}
+}
+
+func wakePollCmd(runID int) tea.Cmd {
+	return tea.Tick(wakePollInterval, func(time.Time) tea.Msg {
+		return wakePollMsg{runID: runID}
+	})
+}
+
+func runWakeProbeCmd(runID int, hostname string, port int) tea.Cmd {
+	return func() tea.Msg {
+		return wakeProbeMsg{runID: runID, err: ssh.TestConnection(hostname, port, wakePollInterval)}
+	}
+}
+
+func wakeAnimTickCmd(runID int) tea.Cmd {
+	return tea.Tick(120*time.Millisecond, func(time.Time) tea.Msg {
+		return wakeAnimTickMsg{runID: runID}
+	})
+}
+
+// kdfBenchmarkTargetMillis is the unlock time the settings KDF benchmark
+// aims for.
+const kdfBenchmarkTargetMillis = 200
+
+func runKDFBenchmarkCmd() tea.Cmd {
+	return func() tea.Msg {
+		return kdfBenchmarkMsg{params: db.BenchmarkKDF(kdfBenchmarkTargetMillis)}
+	}
+}
+
+// rekeyKDFCmd re-encrypts host secrets with p off the UI goroutine; with
+// Argon2id at high memory this can take several seconds.
+func rekeyKDFCmd(store *db.Store, password string, p db.KDFParams, upgrade bool) tea.Cmd {
+	return func() tea.Msg {
+		return kdfRekeyedMsg{params: p, upgrade: upgrade, err: store.UpgradeKDF(password, p)}
+	}
+}
+
+// applySyncConflictsCmd switches each conflict in overrides to the side its
+// Resolution names, stopping at the first failure.
+func applySyncConflictsCmd(store *db.Store, overrides []syncpkg.SyncConflict) tea.Cmd {
+	return func() tea.Msg {
+		if store == nil {
+			return syncConflictsAppliedMsg{total: len(overrides), err: fmt.Errorf("database is locked")}
+		}
+		for i, c := range overrides {
+			if err := syncpkg.OverrideConflict(store, c, c.Resolution); err != nil {
+				return syncConflictsAppliedMsg{applied: i, total: len(overrides), err: err}
+			}
+		}
+		return syncConflictsAppliedMsg{applied: len(overrides), total: len(overrides)}
+	}
+}
+
+func runSyncDryRunCmd(mgr *syncpkg.Manager) tea.Cmd {
+	return func() tea.Msg {
+		if mgr == nil {
+			return syncDryRunMsg{err: fmt.Errorf("sync manager is nil")}
+		}
+		res, err := mgr.DryRun()
+		return syncDryRunMsg{result: res, err: err}
+	}
+}
+
+// testWebhookCmd posts a sample "test" payload to the sync webhook.
+func testWebhookCmd(url, secret string) tea.Cmd {
+	return func() tea.Msg {
+		payload := syncpkg.WebhookPayload{Event: "test", Timestamp: time.Now().UTC().Format(time.RFC3339)}
+		return webhookTestedMsg{err: syncpkg.SendWebhook(url, secret, payload)}
+	}
+}
+
 func syncAnimTickCmd(runID int) tea.Cmd {
 	return tea.Tick(120*time.Millisecond, func(time.Time) tea.Msg {
 		return syncAnimTickMsg{runID: runID}
@@ -99,6 +597,26 @@ func runUpdateCheckCmd(runID int, currentVersion string, cfg config.Config) tea.
 	}
 }
 
+// runBackgroundUpdateCheckCmd is runUpdateCheckCmd for the check made after
+// unlock; its result is handled quietly.
+func runBackgroundUpdateCheckCmd(runID int, currentVersion string, cfg config.Config) tea.Cmd {
+	check := runUpdateCheckCmd(runID, currentVersion, cfg)
+	return func() tea.Msg {
+		msg := check().(updateCheckedMsg)
+		msg.background = true
+		return msg
+	}
+}
+
+func runChangelogFetchCmd(tag string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		body, err := update.FetchChangelog(ctx, tag)
+		return changelogFetchedMsg{tag: tag, body: body, err: err}
+	}
+}
+
 func runUpdateApplyCmd(runID int, check update.CheckResult) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
@@ -133,3 +651,107 @@ func runUpdatePathFixCmd(runID int, desiredExe string) tea.Cmd {
 		return updatePathFixedMsg{runID: runID, pathHealth: ph}
 	}
 }
+
+func openSFTPCmd(host Host, session *ssh.SFTPSession) tea.Cmd {
+	return func() tea.Msg {
+		if err := session.Start(); err != nil {
+			_ = session.Close()
+			return sftpOpenedMsg{host: host, err: err}
+		}
+		dir, err := session.Client.Getwd()
+		if err != nil || dir == "" {
+			dir = "."
+		}
+		entries, err := readSFTPDir(session, dir)
+		if err != nil {
+			_ = session.Close()
+			return sftpOpenedMsg{host: host, err: err}
+		}
+		return sftpOpenedMsg{host: host, session: session, path: dir, entries: entries}
+	}
+}
+
+func listSFTPCmd(session *ssh.SFTPSession, dir, status string) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := readSFTPDir(session, dir)
+		return sftpListMsg{path: dir, entries: entries, status: status, err: err}
+	}
+}
+
+func deleteSFTPCmd(session *ssh.SFTPSession, dir string, entry os.FileInfo) tea.Cmd {
+	return func() tea.Msg {
+		target := path.Join(dir, entry.Name())
+		var err error
+		if entry.IsDir() {
+			err = session.Client.RemoveDirectory(target)
+		} else {
+			err = session.Client.Remove(target)
+		}
+		if err != nil {
+			return sftpListMsg{path: dir, err: fmt.Errorf("delete %s: %v", entry.Name(), err)}
+		}
+		entries, err := readSFTPDir(session, dir)
+		return sftpListMsg{path: dir, entries: entries, status: "deleted " + entry.Name(), err: err}
+	}
+}
+
+// readSFTPDir lists dir with directories first, then files, each by name.
+func readSFTPDir(session *ssh.SFTPSession, dir string) ([]os.FileInfo, error) {
+	entries, err := session.Client.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir()
+		}
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// sftpTransfer is a tea.ExecCommand that copies one file over an open SFTP
+// session. Running it through tea.Exec hands the terminal over for the
+// duration of the transfer so progress can be printed.
+type sftpTransfer struct {
+	label  string
+	run    func(progress io.Writer) (int64, error)
+	stdout io.Writer
+	n      int64
+}
+
+func (t *sftpTransfer) Run() error {
+	out := t.stdout
+	if out == nil {
+		out = io.Discard
+	}
+	fmt.Fprintf(out, "%s\n", t.label)
+	n, err := t.run(&transferProgress{w: out})
+	t.n = n
+	if err != nil {
+		fmt.Fprintf(out, "\nfailed: %v\n", err)
+		return err
+	}
+	fmt.Fprintf(out, "\r%s done\n", ui.FormatSize(n))
+	return nil
+}
+
+func (t *sftpTransfer) SetStdin(io.Reader)    {}
+func (t *sftpTransfer) SetStdout(w io.Writer) { t.stdout = w }
+func (t *sftpTransfer) SetStderr(io.Writer)   {}
+
+// transferProgress prints a running byte count, redrawing the same line.
+type transferProgress struct {
+	w       io.Writer
+	n       int64
+	printed int64
+}
+
+func (p *transferProgress) Write(b []byte) (int, error) {
+	p.n += int64(len(b))
+	if p.n-p.printed >= 256*1024 {
+		fmt.Fprintf(p.w, "\r%s", ui.FormatSize(p.n))
+		p.printed = p.n
+	}
+	return len(b), nil
+}
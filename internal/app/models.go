@@ -1,20 +1,50 @@
 package app
 
-import "time"
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+	"github.com/Vansh-Raja/SSHThing/internal/ssh"
+	"github.com/Vansh-Raja/SSHThing/internal/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
 
 // Host represents an SSH host configuration
 type Host struct {
-	ID            int        `json:"id"`
-	Label         string     `json:"label,omitempty"`
-	GroupName     string     `json:"group_name,omitempty"`
-	Tags          []string   `json:"tags,omitempty"`
-	Hostname      string     `json:"hostname"`
-	Username      string     `json:"username"`
-	Port          int        `json:"port"`
-	HasKey        bool       `json:"has_key"`
-	KeyType       string     `json:"key_type"` // "ed25519", "rsa", "ecdsa", or "pasted"
-	CreatedAt     time.Time  `json:"created_at"`
-	LastConnected *time.Time `json:"last_connected,omitempty"`
+	ID               int               `json:"id"`
+	Label            string            `json:"label,omitempty"`
+	GroupName        string            `json:"group_name,omitempty"`
+	Tags             []string          `json:"tags,omitempty"`
+	Hostname         string            `json:"hostname"`
+	Username         string            `json:"username"`
+	Port             int               `json:"port"`
+	HasKey           bool              `json:"has_key"`
+	KeyType          string            `json:"key_type"`            // "ed25519", "rsa", "ecdsa", or "pasted"
+	Recording        string            `json:"recording,omitempty"` // "" (use setting), "on", or "off"
+	SyncExclude      bool              `json:"sync_exclude,omitempty"`
+	SSHOptions       map[string]string `json:"ssh_options,omitempty"`
+	Pinned           bool              `json:"pinned,omitempty"`
+	MACAddress       string            `json:"mac_address,omitempty"`
+	BroadcastAddr    string            `json:"broadcast_addr,omitempty"`
+	KeepAliveSeconds int               `json:"keepalive_seconds,omitempty"` // 0 uses the global setting
+	ForwardX11       bool              `json:"forward_x11,omitempty"`
+	ProxyHosts       []int             `json:"proxy_hosts,omitempty"`
+	TermOverride     string            `json:"term_override,omitempty"`   // "" uses the global TERM setting
+	Icon             string            `json:"icon,omitempty"`            // "" uses the default list marker
+	IdentitiesOnly   string            `json:"identities_only,omitempty"` // "on" | "off" | "" (global setting)
+	ColorOverride    string            `json:"color_override,omitempty"`  // hex list color; "" uses the group's color
+	CreatedAt        time.Time         `json:"created_at"`
+	LastConnected    *time.Time        `json:"last_connected,omitempty"`
+
+	Fingerprint        string `json:"fingerprint,omitempty"`         // last SHA256 host key fingerprint seen
+	FingerprintChanged bool   `json:"fingerprint_changed,omitempty"` // the latest scan saw a different key
+
+	Banner          string    `json:"banner,omitempty"`            // cached pre-auth SSH banner
+	BannerCheckedAt time.Time `json:"banner_checked_at,omitempty"` // zero until the first fetch
 }
 
 // ── Page constants ────────────────────────────────────────────────────
@@ -29,21 +59,80 @@ const (
 // ── Overlay constants ─────────────────────────────────────────────────
 
 const (
-	OverlayNone        = 0
-	OverlayLogin       = 1
-	OverlaySetup       = 2
-	OverlayHelp        = 3
-	OverlaySearch      = 4
-	OverlayAddHost     = 5
-	OverlayDeleteHost  = 6
-	OverlayCreateGroup = 7
-	OverlayRenameGroup = 8
-	OverlayDeleteGroup = 9
-	OverlayQuit        = 10
+	OverlayNone           = 0
+	OverlayLogin          = 1
+	OverlaySetup          = 2
+	OverlayHelp           = 3
+	OverlaySearch         = 4
+	OverlayAddHost        = 5
+	OverlayDeleteHost     = 6
+	OverlayCreateGroup    = 7
+	OverlayRenameGroup    = 8
+	OverlayDeleteGroup    = 9
+	OverlayQuit           = 10
+	OverlayForward        = 11
+	OverlayPassphrase     = 12
+	OverlayMounts         = 13
+	OverlaySyncDryRun     = 14
+	OverlaySyncRollback   = 15
+	OverlaySFTPBrowser    = 16
+	OverlayMoveHosts      = 17
+	OverlayRecent         = 18
+	OverlayCommandPalette = 19
+	OverlayCopyFallback   = 20
+	OverlayImportWizard   = 21
+	OverlayChangelog      = 22
+	OverlayBulkExec       = 23
+	OverlaySessionRenew   = 24
+	OverlayKeyRotation    = 25
+	OverlayTunnels        = 26
+	OverlayHostDetail     = 27
+	OverlayKeyView        = 28
+	OverlayWelcome        = 29
+	OverlaySyncConflicts  = 30
+)
+
+// Host import wizard steps.
+const (
+	importStepPath     = 0
+	importStepPreview  = 1
+	importStepConflict = 2
 )
 
 // ── List types ────────────────────────────────────────────────────────
 
+// SortBy is the host list sort column, cycled with "o" on the home page.
+type SortBy int
+
+const (
+	SortByLabel SortBy = iota
+	SortByHostname
+	SortByLastConnected
+	SortByGroup
+	SortByCreated
+	numSortBy
+)
+
+// sortByNames are the config.UI.DefaultSort values, indexed by SortBy.
+var sortByNames = [numSortBy]string{"label", "hostname", "last_connected", "group", "created"}
+
+func (s SortBy) String() string {
+	if s < 0 || s >= numSortBy {
+		return sortByNames[SortByLabel]
+	}
+	return sortByNames[s]
+}
+
+// parseSortBy maps a config value to a SortBy, defaulting to SortByLabel.
+func parseSortBy(name string) SortBy {
+	for i, n := range sortByNames {
+		if strings.EqualFold(n, strings.TrimSpace(name)) {
+			return SortBy(i)
+		}
+	}
+	return SortByLabel
+}
+
 type ListItemKind int
 
 const (
@@ -58,6 +147,22 @@ type ListItem struct {
 	GroupName string // for groups and host membership (empty means ungrouped)
 	Host      Host   // valid for Kind==ListItemHost
 	Count     int    // host count for group header
+	Depth     int    // nesting depth of the group (or the host's group)
+
+	// Virtual marks a read-only group populated from a config filter (and
+	// the hosts listed under it); Filter/FilterErr describe that filter.
+	Virtual   bool
+	Filter    string
+	FilterErr string
+}
+
+// collapseKey is the key into Model.collapsed for a group header, keeping
+// virtual groups apart from static groups of the same name.
+func (it ListItem) collapseKey() string {
+	if it.Virtual {
+		return "virtual:" + it.GroupName
+	}
+	return it.GroupName
 }
 
 type SpotlightItemKind int
@@ -76,6 +181,53 @@ type SpotlightItem struct {
 	Indent    int
 }
 
+// footerNoticeKind classifies a status message by its leading glyph.
+type footerNoticeKind int
+
+const (
+	noticeError footerNoticeKind = iota
+	noticeSuccess
+	noticeWarning
+	noticeInfo
+)
+
+// noticeKindOf maps a status message to its kind using the same prefixes
+// the footer uses for colouring.
+func noticeKindOf(msg string) footerNoticeKind {
+	switch {
+	case strings.HasPrefix(msg, "\u2713"):
+		return noticeSuccess
+	case strings.HasPrefix(msg, "\u26A0"):
+		return noticeWarning
+	case strings.HasPrefix(msg, "\u2139"):
+		return noticeInfo
+	}
+	return noticeError
+}
+
+// Toast is a queued notification for the result of an async operation.
+type Toast struct {
+	Message   string
+	Kind      footerNoticeKind
+	ExpiresAt time.Time
+}
+
+// UndoEntry records one host mutation so it can be undone and redone.
+// Before is nil for creates and After is nil for deletes; both are snapshots
+// from GetHostByID, so key data stays encrypted.
+type UndoEntry struct {
+	Kind   string // "create", "edit" or "delete"
+	Before *db.HostModel
+	After  *db.HostModel
+}
+
+// CommandEntry is one action listed in the command palette.
+type CommandEntry struct {
+	Label       string
+	Description string
+	Action      func(m *Model) (tea.Model, tea.Cmd)
+}
+
 // ── Token mode constants ──────────────────────────────────────────────
 
 const (
@@ -83,3 +235,116 @@ const (
 	tokenModeCreateName
 	tokenModeCreateScope
 )
+
+// SFTPBrowser is the state of the in-TUI remote file browser opened with S+Enter.
+type SFTPBrowser struct {
+	Host    Host
+	Session *ssh.SFTPSession
+	Path    string        // current remote directory
+	Entries []os.FileInfo // directories first, then files, each sorted by name
+	Cursor  int
+
+	Uploading     bool         // local path input is open
+	UploadField   ui.FormField // local file to upload into Path
+	ConfirmDelete bool
+}
+
+// Selected returns the entry under the cursor, or nil for an empty directory.
+func (b *SFTPBrowser) Selected() os.FileInfo {
+	if b == nil || b.Cursor < 0 || b.Cursor >= len(b.Entries) {
+		return nil
+	}
+	return b.Entries[b.Cursor]
+}
+
+// Key rotation wizard steps.
+const (
+	rotateStepChoose  = 0 // pick the new key type, then generate and deploy it
+	rotateStepVerify  = 1 // new key is in authorized_keys; test a login with it
+	rotateStepConfirm = 2 // new key works; save it and remove the old one
+	rotateStepDone    = 3
+)
+
+// rotateKeyTypes are the key types the rotation wizard can generate.
+var rotateKeyTypes = []string{"ed25519", "rsa", "ecdsa"}
+
+// KeyRotation holds the key rotation wizard for one host. The stored key is
+// only replaced once a login with the new key has worked.
+type KeyRotation struct {
+	Host   Host
+	Step   int
+	KeyIdx int
+	Busy   bool   // a remote step is running
+	Status string // result of the last step
+	Err    error
+
+	oldConn    ssh.Connection // authenticates with the key being rotated out
+	oldPublic  string
+	newPrivate string
+	newPublic  string
+}
+
+// BulkExec holds the bulk command overlay: a command typed once and run on
+// every marked host. Workers append output from other goroutines, so the
+// per-host state is only read or written with mu held.
+type BulkExec struct {
+	Field   ui.FormField // command to run
+	Started bool
+	Cursor  int // index into Hosts; moves between host headers
+
+	mu    sync.Mutex
+	Hosts []*BulkExecHost
+}
+
+// BulkExecHost is one host's run within a BulkExec.
+type BulkExecHost struct {
+	Host      Host
+	Output    bytes.Buffer // interleaved stdout and stderr
+	Running   bool
+	Done      bool
+	Err       error
+	ExitCode  int
+	Collapsed bool
+}
+
+// Progress returns how many hosts have finished, out of the total.
+func (b *BulkExec) Progress() (done, total int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, h := range b.Hosts {
+		if h.Done {
+			done++
+		}
+	}
+	return done, len(b.Hosts)
+}
+
+// Summary counts finished hosts whose command exited zero and those that
+// failed.
+func (b *BulkExec) Summary() (ok, failed int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, h := range b.Hosts {
+		if !h.Done {
+			continue
+		}
+		if h.Err == nil {
+			ok++
+		} else {
+			failed++
+		}
+	}
+	return ok, failed
+}
+
+// bulkExecOutput is the io.Writer a worker streams one host's output into.
+type bulkExecOutput struct {
+	run  *BulkExec
+	host *BulkExecHost
+}
+
+func (w bulkExecOutput) Write(p []byte) (int, error) {
+	w.run.mu.Lock()
+	defer w.run.mu.Unlock()
+	return w.host.Output.Write(p)
+}
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
 	"time"
 
@@ -88,8 +89,9 @@ func createTokenWithID(tokenID string, name string, grants []HostGrant, dbUnlock
 			return "", StoredToken{}, fmt.Errorf("invalid host id in scope")
 		}
 		rec.Hosts = append(rec.Hosts, StoredTokenHost{
-			HostID:       g.HostID,
-			DisplayLabel: strings.TrimSpace(g.DisplayLabel),
+			HostID:          g.HostID,
+			DisplayLabel:    strings.TrimSpace(g.DisplayLabel),
+			AllowedCommands: normalizeCommandPatterns(g.AllowedCommands),
 		})
 	}
 
@@ -193,7 +195,7 @@ func resolveRecord(rawToken string, rec StoredToken, targetLabel string, deviceP
 	}
 
 	h := matches[0]
-	result := ResolveResult{TokenID: rec.TokenID, HostID: h.HostID, HostLabel: h.DisplayLabel}
+	result := ResolveResult{TokenID: rec.TokenID, HostID: h.HostID, HostLabel: h.DisplayLabel, AllowedCommands: h.AllowedCommands}
 
 	if strings.TrimSpace(h.Payload) != "" {
 		salt, err := base64.RawStdEncoding.DecodeString(h.PayloadSalt)
@@ -229,6 +231,62 @@ func resolveRecord(rawToken string, rec StoredToken, targetLabel string, deviceP
 	return result, nil
 }
 
+// shellMetaChars are rejected in commands run under a restricted grant so a
+// glob such as "tail *" cannot be stretched into "tail x; rm -rf /".
+const shellMetaChars = ";&|`$<>\n"
+
+// AllowsCommand reports whether command may run on the resolved host. A host
+// without AllowedCommands accepts any command; otherwise command must not
+// contain shell metacharacters and must equal a pattern or match it as a glob,
+// where * matches any run of ordinary characters (including spaces and
+// slashes) and ? matches one.
+func (r ResolveResult) AllowsCommand(command string) bool {
+	if len(r.AllowedCommands) == 0 {
+		return true
+	}
+	command = strings.TrimSpace(command)
+	if strings.ContainsAny(command, shellMetaChars) {
+		return false
+	}
+	for _, p := range r.AllowedCommands {
+		if p == command || matchCommandGlob(p, command) {
+			return true
+		}
+	}
+	return false
+}
+
+// metaClass is shellMetaChars escaped for use inside a regexp character class.
+const metaClass = ";&|`$<>\\n"
+
+func matchCommandGlob(pattern, command string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString("[^" + metaClass + "]*")
+		case '?':
+			b.WriteString("[^" + metaClass + "]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	return err == nil && re.MatchString(command)
+}
+
+func normalizeCommandPatterns(patterns []string) []string {
+	var out []string
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
 func tokenHash(secret string, salt []byte) []byte {
 	return argon2.IDKey([]byte(secret), salt, argonTime, argonMemoryKiB, argonThreads, argonKeyLen)
 }
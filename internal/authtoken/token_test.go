@@ -1,8 +1,12 @@
 package authtoken
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Vansh-Raja/SSHThing/internal/config"
 )
@@ -97,6 +101,76 @@ func TestVaultRevokeDeleteAndActivation(t *testing.T) {
 	}
 }
 
+func TestCheckExpiringTokens(t *testing.T) {
+	v := &Vault{Version: vaultVersion}
+	soon := time.Now().UTC().Add(time.Hour)
+	later := time.Now().UTC().Add(7 * 24 * time.Hour)
+	for _, tc := range []struct {
+		name      string
+		expiresAt *time.Time
+	}{
+		{name: "soon", expiresAt: &soon},
+		{name: "later", expiresAt: &later},
+		{name: "never", expiresAt: nil},
+	} {
+		raw, rec, err := CreateToken(tc.name, []HostGrant{{HostID: 1, DisplayLabel: "GPU"}}, "pw", CreateOptions{ExpiresAt: tc.expiresAt})
+		if err != nil {
+			t.Fatalf("CreateToken failed: %v", err)
+		}
+		if err := v.AddToken(raw, rec); err != nil {
+			t.Fatalf("AddToken failed: %v", err)
+		}
+	}
+
+	expiring := CheckExpiringTokens(v, 72*time.Hour)
+	if len(expiring) != 1 || expiring[0].Name != "soon" {
+		t.Fatalf("expected only the token expiring in 1h, got %+v", expiring)
+	}
+	if got := CheckExpiringTokens(nil, 72*time.Hour); len(got) != 0 {
+		t.Fatalf("expected no tokens for nil vault, got %d", len(got))
+	}
+}
+
+func TestRecordAuditCapsAndTruncates(t *testing.T) {
+	v := &Vault{Version: vaultVersion}
+	raw, rec, err := CreateToken("deploy", []HostGrant{{HostID: 1, DisplayLabel: "GPU"}}, "pw", CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if err := v.AddToken(raw, rec); err != nil {
+		t.Fatalf("AddToken failed: %v", err)
+	}
+
+	if v.RecordAudit(5, AuditEntry{Command: "ls"}) {
+		t.Fatalf("expected RecordAudit to reject out-of-range index")
+	}
+	for i := 0; i < maxAuditEntries+5; i++ {
+		if !v.RecordAudit(0, AuditEntry{TargetLabel: "GPU", Command: fmt.Sprintf("cmd-%d", i), Success: true}) {
+			t.Fatalf("RecordAudit failed at %d", i)
+		}
+	}
+	log := v.Tokens[0].AuditLog
+	if len(log) != maxAuditEntries {
+		t.Fatalf("expected %d audit entries, got %d", maxAuditEntries, len(log))
+	}
+	if log[0].Command != "cmd-5" || log[len(log)-1].Command != fmt.Sprintf("cmd-%d", maxAuditEntries+4) {
+		t.Fatalf("expected oldest entries evicted, got first=%q last=%q", log[0].Command, log[len(log)-1].Command)
+	}
+
+	v.RecordAudit(0, AuditEntry{Command: strings.Repeat("é", 150)})
+	if got := v.Tokens[0].AuditLog[maxAuditEntries-1].Command; len(got) > maxAuditCommandBytes || !utf8.ValidString(got) {
+		t.Fatalf("expected command truncated to %d valid bytes, got %d", maxAuditCommandBytes, len(got))
+	}
+
+	summaries := v.ListSummaries()
+	if len(summaries[0].RecentAudit) != summaryAuditEntries {
+		t.Fatalf("expected %d recent audit entries, got %d", summaryAuditEntries, len(summaries[0].RecentAudit))
+	}
+	if summaries[0].RecentAudit[1].Command != fmt.Sprintf("cmd-%d", maxAuditEntries+4) {
+		t.Fatalf("expected recent audit newest first, got %q", summaries[0].RecentAudit[1].Command)
+	}
+}
+
 func TestDeleteRevokedTokenRejectsActive(t *testing.T) {
 	v := &Vault{Version: vaultVersion}
 	raw, rec, err := CreateToken("deploy", []HostGrant{{HostID: 1, DisplayLabel: "GPU"}}, "pw", CreateOptions{})
@@ -140,6 +214,62 @@ func TestSyncDefinitionsMerge(t *testing.T) {
 	}
 }
 
+func TestAllowedCommandsRestrictResolve(t *testing.T) {
+	raw, rec, err := CreateToken("deploy", []HostGrant{
+		{HostID: 1, DisplayLabel: "web", AllowedCommands: []string{" systemctl restart app* "}},
+		{HostID: 2, DisplayLabel: "db"},
+	}, "pw", CreateOptions{SyncEnabled: true})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	v := &Vault{Version: vaultVersion}
+	if err := v.AddToken(raw, rec); err != nil {
+		t.Fatalf("AddToken failed: %v", err)
+	}
+
+	res, err := v.Resolve(raw, "web", nil)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	for cmd, want := range map[string]bool{
+		"systemctl restart app":               true,
+		"systemctl restart app-worker":        true,
+		"systemctl stop app":                  false,
+		"rm -rf /":                            false,
+		"systemctl restart app; rm -rf /":     false,
+		"systemctl restart app & rm -rf /":    false,
+		"systemctl restart app | sh":          false,
+		"systemctl restart app`id`":           false,
+		"systemctl restart app$(id)":          false,
+		"systemctl restart app > /etc/motd":   false,
+		"systemctl restart app < /etc/shadow": false,
+		"systemctl restart app\nrm -rf /":     false,
+	} {
+		if got := res.AllowsCommand(cmd); got != want {
+			t.Fatalf("AllowsCommand(%q) = %v, want %v", cmd, got, want)
+		}
+	}
+	if matchCommandGlob("echo *", "echo a; id") || matchCommandGlob("echo ?id", "echo ;id") {
+		t.Fatalf("expected globs not to match shell metacharacters")
+	}
+	res, err = v.Resolve(raw, "db", nil)
+	if err != nil || !res.AllowsCommand("anything; uptime") {
+		t.Fatalf("expected unrestricted host to allow any command (%v)", err)
+	}
+
+	defs := v.ExportSyncDefinitions()
+	if b, _ := json.Marshal(defs); strings.Contains(string(b), "systemctl") {
+		t.Fatalf("allowed commands must not be synced: %s", b)
+	}
+	defs[0].UpdatedAt = defs[0].UpdatedAt.Add(time.Minute)
+	if !v.MergeSyncDefinitions(defs) {
+		t.Fatalf("expected merge to apply newer definition")
+	}
+	if got := v.Tokens[0].Hosts[0].AllowedCommands; len(got) != 1 || got[0] != "systemctl restart app*" {
+		t.Fatalf("expected local command policy to survive merge, got %v", got)
+	}
+}
+
 func TestLoadSaveVault(t *testing.T) {
 	tmp := t.TempDir()
 	t.Setenv("SSHTHING_DATA_DIR", tmp)
@@ -170,3 +300,127 @@ func TestLoadSaveVault(t *testing.T) {
 		t.Fatalf("expected one token, got %d", len(v2.Tokens))
 	}
 }
+
+func TestRotateDevicePepper(t *testing.T) {
+	oldPepper := []byte("0123456789abcdef0123456789abcdef")
+	newPepper := []byte("fedcba9876543210fedcba9876543210")
+	opts := CreateOptions{DevicePepper: oldPepper, BindToDevice: true}
+	rawA, recA, err := CreateToken("a", []HostGrant{{HostID: 1, DisplayLabel: "web"}}, "master-password", opts)
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	rawB, recB, err := CreateToken("b", []HostGrant{{HostID: 1, DisplayLabel: "web"}}, "master-password", opts)
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	v := &Vault{Version: vaultVersion}
+	if err := v.AddToken(rawA, recA); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.AddToken(rawB, recB); err != nil {
+		t.Fatal(err)
+	}
+
+	// A wrong old pepper for any token leaves every token untouched.
+	before := recA.UnlockData
+	if _, err := v.RotateDevicePepper([]string{rawA}, newPepper, oldPepper); err == nil || !strings.Contains(err.Error(), recA.TokenID) {
+		t.Fatalf("expected error naming token %s, got %v", recA.TokenID, err)
+	}
+	for _, tok := range v.Tokens {
+		if tok.TokenID == recA.TokenID && tok.UnlockData != before {
+			t.Fatalf("expected vault to be unchanged after a failed rotation")
+		}
+	}
+
+	rotated, err := v.RotateDevicePepper([]string{rawA}, oldPepper, newPepper)
+	if err != nil {
+		t.Fatalf("RotateDevicePepper failed: %v", err)
+	}
+	if len(rotated) != 1 || rotated[0] != recA.TokenID {
+		t.Fatalf("expected only token a rotated, got %v", rotated)
+	}
+	res, err := v.Resolve(rawA, "web", newPepper)
+	if err != nil || res.DBUnlockSecret != "master-password" {
+		t.Fatalf("expected token a to resolve with the new pepper, got %v", err)
+	}
+	if _, err := v.Resolve(rawB, "web", newPepper); err == nil {
+		t.Fatalf("expected unlisted token b to stay on the old pepper")
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		TokenPrefix + "_abc123_c2VjcmV0",
+		"  " + TokenPrefix + "_abc123_c2VjcmV0\n",
+		"",
+		"_",
+		"__",
+		"wrong_abc123_c2VjcmV0",
+		TokenPrefix + "_abc123",
+		TokenPrefix + "__c2VjcmV0",
+		TokenPrefix + "_abc123_",
+		TokenPrefix + "_abc_123_sec_ret",
+		TokenPrefix + "_abc123_!!not-base64!!",
+		TokenPrefix + "_\x00_\xff",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		id, secret, err := Parse(raw)
+		if err != nil {
+			if id != "" || secret != "" {
+				t.Fatalf("Parse(%q) returned values with an error", raw)
+			}
+			return
+		}
+		if id == "" || secret == "" || strings.Contains(id, "_") {
+			t.Fatalf("Parse(%q) = %q, %q", raw, id, secret)
+		}
+		if got := TokenPrefix + "_" + id + "_" + secret; got != strings.TrimSpace(raw) {
+			t.Fatalf("Parse(%q) does not round-trip: %q", raw, got)
+		}
+	})
+}
+
+func FuzzVerify(f *testing.F) {
+	raw, rec, err := CreateToken("fuzz", []HostGrant{{HostID: 1, DisplayLabel: "web"}}, "master-password", CreateOptions{})
+	if err != nil {
+		f.Fatalf("CreateToken failed: %v", err)
+	}
+	_, secret, err := Parse(raw)
+	if err != nil {
+		f.Fatalf("Parse failed: %v", err)
+	}
+	for _, seed := range []string{raw, raw + "x", strings.ToUpper(raw), TokenPrefix + "_" + rec.TokenID + "_", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, candidate string) {
+		got, ok := Verify(candidate, rec)
+		if !ok {
+			if got != "" {
+				t.Fatalf("Verify(%q) returned a secret without ok", candidate)
+			}
+			return
+		}
+		if got != secret {
+			t.Fatalf("Verify(%q) accepted a different secret", candidate)
+		}
+	})
+}
+
+func FuzzUnwrapDBUnlock(f *testing.F) {
+	salt := []byte("0123456789abcdef")
+	wrapped, err := wrapDBUnlock("token-secret", "master-password", salt, nil, false)
+	if err != nil {
+		f.Fatalf("wrapDBUnlock failed: %v", err)
+	}
+	for _, seed := range []string{wrapped, wrapped[:len(wrapped)-2], "", "AAAA", "!!not-base64!!"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, candidate string) {
+		pt, err := unwrapDBUnlock("token-secret", candidate, salt, nil, false)
+		if err == nil && pt != "master-password" {
+			t.Fatalf("unwrapDBUnlock(%q) returned unexpected plaintext %q", candidate, pt)
+		}
+	})
+}
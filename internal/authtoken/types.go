@@ -5,6 +5,10 @@ import "time"
 const (
 	TokenPrefix  = "stk"
 	vaultVersion = 2
+
+	maxAuditEntries      = 100 // per-token audit log cap; oldest entries are evicted
+	maxAuditCommandBytes = 200
+	summaryAuditEntries  = 5
 )
 
 type Vault struct {
@@ -37,20 +41,35 @@ type StoredToken struct {
 	UnlockBound bool   `json:"unlock_bound,omitempty"`
 
 	Hosts []StoredTokenHost `json:"hosts"`
+
+	AuditLog []AuditEntry `json:"audit_log,omitempty"`
+}
+
+// AuditEntry records one `sshthing exec` run made with a token.
+type AuditEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	TargetLabel string    `json:"target_label"`
+	Command     string    `json:"command"`
+	Success     bool      `json:"success"`
 }
 
 type StoredTokenHost struct {
 	HostID       int    `json:"host_id"`
 	DisplayLabel string `json:"display_label"`
 
+	// AllowedCommands limits exec on this host to matching commands (exact
+	// or glob). It is local policy and never part of sync definitions.
+	AllowedCommands []string `json:"allowed_commands,omitempty"`
+
 	// Legacy v1 fields retained for backward compatibility.
 	PayloadSalt string `json:"payload_salt,omitempty"`
 	Payload     string `json:"payload,omitempty"`
 }
 
 type HostGrant struct {
-	HostID       int
-	DisplayLabel string
+	HostID          int
+	DisplayLabel    string
+	AllowedCommands []string
 }
 
 type CreateOptions struct {
@@ -81,6 +100,8 @@ type ResolveResult struct {
 	HostID     int
 	HostLabel  string
 
+	AllowedCommands []string
+
 	DBUnlockSecret string
 	LegacyPayload  *ExecPayload
 }
@@ -101,6 +122,8 @@ type TokenSummary struct {
 	SyncEnabled bool
 	Usable      bool
 	Legacy      bool
+
+	RecentAudit []AuditEntry // newest first
 }
 
 type SyncTokenDef struct {
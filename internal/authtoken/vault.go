@@ -1,6 +1,7 @@
 package authtoken
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Vansh-Raja/SSHThing/internal/config"
 )
@@ -135,11 +137,27 @@ func (v *Vault) ListSummaries() []TokenSummary {
 			SyncEnabled: t.SyncEnabled,
 			Usable:      t.IsUsable(),
 			Legacy:      t.IsLegacyPayload(),
+			RecentAudit: recentAudit(t.AuditLog, summaryAuditEntries),
 		})
 	}
 	return out
 }
 
+// CheckExpiringTokens returns the usable tokens in v that expire within horizon.
+func CheckExpiringTokens(v *Vault, horizon time.Duration) []TokenSummary {
+	deadline := time.Now().UTC().Add(horizon)
+	var out []TokenSummary
+	for _, s := range v.ListSummaries() {
+		if !s.Usable || s.ExpiresAt == nil {
+			continue
+		}
+		if !s.ExpiresAt.After(deadline) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 func (v *Vault) RevokeToken(tokenID string) bool {
 	if v == nil {
 		return false
@@ -243,6 +261,50 @@ func (v *Vault) Resolve(rawToken string, targetLabel string, devicePepper []byte
 	return ResolveResult{}, fmt.Errorf("token not found")
 }
 
+// RecordAudit appends entry to the token's audit log, evicting the oldest
+// entries once maxAuditEntries is reached.
+func (v *Vault) RecordAudit(tokenIndex int, entry AuditEntry) bool {
+	if v == nil || tokenIndex < 0 || tokenIndex >= len(v.Tokens) {
+		return false
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+	entry.Command = truncateUTF8(entry.Command, maxAuditCommandBytes)
+
+	log := append(v.Tokens[tokenIndex].AuditLog, entry)
+	if over := len(log) - maxAuditEntries; over > 0 {
+		log = append([]AuditEntry(nil), log[over:]...)
+	}
+	v.Tokens[tokenIndex].AuditLog = log
+	return true
+}
+
+func recentAudit(log []AuditEntry, n int) []AuditEntry {
+	if len(log) == 0 {
+		return nil
+	}
+	if n > len(log) {
+		n = len(log)
+	}
+	out := make([]AuditEntry, 0, n)
+	for i := len(log) - 1; i >= len(log)-n; i-- {
+		out = append(out, log[i])
+	}
+	return out
+}
+
+// truncateUTF8 cuts s to at most max bytes without splitting a rune.
+func truncateUTF8(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
 func (v *Vault) MarkUsed(tokenIndex int) {
 	if v == nil || tokenIndex < 0 || tokenIndex >= len(v.Tokens) {
 		return
@@ -268,7 +330,7 @@ func (v *Vault) ActivateToken(tokenID string, dbUnlockSecret string, devicePeppe
 		}
 		grants := make([]HostGrant, 0, len(t.Hosts))
 		for _, h := range t.Hosts {
-			grants = append(grants, HostGrant{HostID: h.HostID, DisplayLabel: h.DisplayLabel})
+			grants = append(grants, HostGrant{HostID: h.HostID, DisplayLabel: h.DisplayLabel, AllowedCommands: h.AllowedCommands})
 		}
 		opts := CreateOptions{DevicePepper: devicePepper, BindToDevice: len(devicePepper) > 0, ExpiresAt: t.ExpiresAt, MaxUses: t.MaxUses, SyncEnabled: t.SyncEnabled}
 		raw, rec, err := createTokenWithID(t.TokenID, t.Name, grants, dbUnlockSecret, opts)
@@ -280,6 +342,7 @@ func (v *Vault) ActivateToken(tokenID string, dbUnlockSecret string, devicePeppe
 		rec.DeletedAt = t.DeletedAt
 		rec.UseCount = t.UseCount
 		rec.LastUsedAt = t.LastUsedAt
+		rec.AuditLog = t.AuditLog
 		v.Tokens[i] = rec
 		return raw, nil
 	}
@@ -366,9 +429,14 @@ func (v *Vault) MergeSyncDefinitions(defs []SyncTokenDef) bool {
 		local.SyncEnabled = true
 		local.RevokedAt = d.RevokedAt
 		local.DeletedAt = d.DeletedAt
+		// Command restrictions are local policy; keep them across the merge.
+		allowed := make(map[int][]string, len(local.Hosts))
+		for _, h := range local.Hosts {
+			allowed[h.HostID] = h.AllowedCommands
+		}
 		local.Hosts = make([]StoredTokenHost, 0, len(d.Hosts))
 		for _, h := range d.Hosts {
-			local.Hosts = append(local.Hosts, StoredTokenHost{HostID: h.HostID, DisplayLabel: strings.TrimSpace(h.DisplayLabel)})
+			local.Hosts = append(local.Hosts, StoredTokenHost{HostID: h.HostID, DisplayLabel: strings.TrimSpace(h.DisplayLabel), AllowedCommands: allowed[h.HostID]})
 		}
 		changed = true
 	}
@@ -377,3 +445,65 @@ func (v *Vault) MergeSyncDefinitions(defs []SyncTokenDef) bool {
 	})
 	return changed
 }
+
+// RotateDevicePepper re-wraps the database unlock secret of each device-bound
+// token in rawTokens from oldPepper to newPepper, so the tokens keep working
+// after the vault moves to a machine with a different device pepper. The raw
+// tokens are needed because the wrapping key is derived from their secrets,
+// which the vault only stores as hashes. Either every listed token is
+// re-wrapped or, on error, the vault is left unchanged. It returns the IDs of
+// the tokens that were re-wrapped.
+func (v *Vault) RotateDevicePepper(rawTokens []string, oldPepper, newPepper []byte) ([]string, error) {
+	if v == nil {
+		return nil, fmt.Errorf("vault is nil")
+	}
+	if len(oldPepper) == 0 || len(newPepper) == 0 {
+		return nil, fmt.Errorf("both the old and new device pepper are required")
+	}
+	tokens := make([]StoredToken, len(v.Tokens))
+	copy(tokens, v.Tokens)
+	now := time.Now().UTC()
+
+	var rotated []string
+	for _, raw := range rawTokens {
+		id, _, err := Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		idx := -1
+		for i, t := range tokens {
+			if t.TokenID == id && t.DeletedAt == nil {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("token %s: not found", id)
+		}
+		t := &tokens[idx]
+		secret, ok := Verify(raw, *t)
+		if !ok {
+			return nil, fmt.Errorf("token %s (%s): raw token does not match the stored hash", id, t.Name)
+		}
+		if !t.UnlockBound || strings.TrimSpace(t.UnlockData) == "" {
+			continue
+		}
+		salt, err := base64.RawStdEncoding.DecodeString(t.UnlockSalt)
+		if err != nil {
+			return nil, fmt.Errorf("token %s (%s): invalid unlock salt", id, t.Name)
+		}
+		unlockSecret, err := unwrapDBUnlock(secret, t.UnlockData, salt, oldPepper, true)
+		if err != nil {
+			return nil, fmt.Errorf("token %s (%s): old pepper does not unlock it", id, t.Name)
+		}
+		wrapped, err := wrapDBUnlock(secret, unlockSecret, salt, newPepper, true)
+		if err != nil {
+			return nil, fmt.Errorf("token %s (%s): %w", id, t.Name, err)
+		}
+		t.UnlockData = wrapped
+		t.UpdatedAt = now
+		rotated = append(rotated, id)
+	}
+	v.Tokens = tokens
+	return rotated, nil
+}
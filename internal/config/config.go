@@ -3,10 +3,17 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
+// ProfileEnv selects a named profile. Each profile gets its own data
+// directory, and therefore its own config, database, and token vault.
+const ProfileEnv = "SSHTHING_PROFILE"
+
 type HostKeyPolicy string
 
 const (
@@ -49,21 +56,48 @@ const (
 type Config struct {
 	Version int `json:"version"`
 
+	// Profile is the active profile name ("" = default). It is derived from
+	// SSHTHING_PROFILE at load time and never persisted.
+	Profile string `json:"-"`
+
 	UI struct {
 		VimMode   bool   `json:"vim_mode"`
 		ShowIcons bool   `json:"show_icons"`
 		Theme     string `json:"theme"`
 		IconSet   string `json:"icon_set"`
+		// HighContrast replaces the theme with white-on-black and pure accent colors.
+		HighContrast bool `json:"high_contrast"`
+		// PingIntervalSeconds checks host reachability this often (0 = disabled).
+		PingIntervalSeconds int `json:"ping_interval_seconds"`
+		// DefaultSort is the host list order: "label", "hostname",
+		// "last_connected", "group" or "created". Empty means "label".
+		DefaultSort string `json:"default_sort,omitempty"`
+		SortDesc    bool   `json:"sort_desc,omitempty"`
 	} `json:"ui"`
 
 	SSH struct {
-		HostKeyPolicy       HostKeyPolicy       `json:"host_key_policy"`
-		KeepAliveSeconds    int                 `json:"keepalive_seconds"`
-		TermMode            TermMode            `json:"term_mode"`
-		TermCustom          string              `json:"term_custom"`
-		PasswordAutoLogin   bool                `json:"password_auto_login"`
-		PasswordNoticeShown bool                `json:"password_notice_shown,omitempty"`
-		PasswordBackendUnix PasswordBackendUnix `json:"password_backend_unix"`
+		HostKeyPolicy        HostKeyPolicy       `json:"host_key_policy"`
+		KeepAliveSeconds     int                 `json:"keepalive_seconds"`
+		TermMode             TermMode            `json:"term_mode"`
+		TermCustom           string              `json:"term_custom"`
+		PasswordAutoLogin    bool                `json:"password_auto_login"`
+		PasswordNoticeShown  bool                `json:"password_notice_shown,omitempty"`
+		PasswordBackendUnix  PasswordBackendUnix `json:"password_backend_unix"`
+		RecordSessions       bool                `json:"record_sessions"`
+		DefaultSocksPort     int                 `json:"default_socks_port"`
+		ControlMasterEnabled bool                `json:"control_master_enabled"`
+		ConnectRetries       int                 `json:"connect_retries"`
+		BulkExecConcurrency  int                 `json:"bulk_exec_concurrency"`
+		WakeAutoConnect      bool                `json:"wake_auto_connect"`
+		ForwardX11           bool                `json:"forward_x11"`
+		TrustX11             bool                `json:"trust_x11"`
+		ForwardAgent         bool                `json:"forward_agent"`
+		// IdentitiesOnly makes ssh offer only a host's stored key, never the
+		// agent's keys, so servers don't count every agent key as a failed login.
+		IdentitiesOnly bool `json:"identities_only"`
+		// BannerRefreshSeconds is how old a host's cached SSH banner may get
+		// before selecting the host fetches it again (0 = never fetch).
+		BannerRefreshSeconds int `json:"banner_refresh_seconds"`
 	} `json:"ssh"`
 
 	Mount struct {
@@ -71,6 +105,7 @@ type Config struct {
 		DefaultRemotePath string            `json:"default_remote_path"`
 		LocalMountPath    string            `json:"local_mount_path,omitempty"`
 		QuitBehavior      MountQuitBehavior `json:"quit_behavior"`
+		DefaultReadOnly   bool              `json:"default_read_only"`
 	} `json:"mount"`
 
 	Sync struct {
@@ -80,6 +115,16 @@ type Config struct {
 		SSHKeyPath string         `json:"ssh_key_path"`
 		Branch     string         `json:"branch"`
 		LocalPath  string         `json:"local_path"`
+		// EncryptPayload writes the sync file as one opaque ciphertext blob.
+		EncryptPayload bool `json:"encrypt_payload"`
+		// PullOnly never pushes local changes; PushOnly never imports remote
+		// ones. At most one is set.
+		PullOnly bool `json:"pull_only,omitempty"`
+		PushOnly bool `json:"push_only,omitempty"`
+		// WebhookURL, when set, receives a signed POST after each
+		// successful sync. WebhookSecret keys the HMAC signature.
+		WebhookURL    string `json:"webhook_url,omitempty"`
+		WebhookSecret string `json:"webhook_secret,omitempty"`
 	} `json:"sync"`
 
 	Updates struct {
@@ -87,17 +132,49 @@ type Config struct {
 		LastSeenVersion string `json:"last_seen_version,omitempty"`
 		LastSeenTag     string `json:"last_seen_tag,omitempty"`
 		ETagLatest      string `json:"etag_latest,omitempty"`
+		// SkippedTag is a release the user chose not to install.
+		SkippedTag string `json:"skipped_tag,omitempty"`
 	} `json:"updates"`
 
 	Automation struct {
-		SyncTokenDefinitions bool `json:"sync_token_definitions"`
-		SessionTTLSeconds    int  `json:"session_ttl_seconds"`
+		SyncTokenDefinitions bool          `json:"sync_token_definitions"`
+		SessionTTLSeconds    int           `json:"session_ttl_seconds"`
+		ExpiryWarningHorizon time.Duration `json:"expiry_warning_horizon"`
+		// AutoSyncIntervalSeconds runs a background sync this often (0 = disabled).
+		AutoSyncIntervalSeconds int `json:"auto_sync_interval_seconds"`
 	} `json:"automation"`
+
+	Metrics struct {
+		// Enabled serves Prometheus metrics on ListenAddr while the TUI runs.
+		Enabled    bool   `json:"enabled"`
+		ListenAddr string `json:"listen_addr"`
+	} `json:"metrics"`
+
+	Security struct {
+		// IdleLockSeconds locks the TUI after this long without input (0 = disabled).
+		IdleLockSeconds int `json:"idle_lock_seconds"`
+	} `json:"security"`
+
+	// DB tunes the local database when it is opened. Edited in config.json
+	// only.
+	DB struct {
+		// WALMode uses SQLite's write-ahead log so reads don't wait on writes.
+		WALMode bool `json:"wal_mode"`
+		// CacheSizeKB is the page cache of each database connection.
+		CacheSizeKB int `json:"cache_size_kb"`
+	} `json:"db"`
+
+	// VirtualGroupFilters maps a read-only group name shown in the host list
+	// to a filter expression, e.g. "prod": "tag:production && hostname:web".
+	// Predicates are tag:<name>, group:<name> and hostname:<prefix>, combined
+	// with && and ||. Edited in config.json only.
+	VirtualGroupFilters map[string]string `json:"virtual_group_filters,omitempty"`
 }
 
 func Default() Config {
 	var c Config
-	c.Version = 2
+	c.Version = 4
+	c.Profile = ActiveProfile()
 	c.UI.VimMode = true
 	c.UI.ShowIcons = true
 	c.UI.Theme = "Catppuccin Mocha"
@@ -105,10 +182,13 @@ func Default() Config {
 
 	c.SSH.HostKeyPolicy = HostKeyAcceptNew
 	c.SSH.KeepAliveSeconds = 60
+	c.SSH.BulkExecConcurrency = 4
 	c.SSH.TermMode = TermAuto
 	c.SSH.TermCustom = ""
 	c.SSH.PasswordAutoLogin = true
 	c.SSH.PasswordBackendUnix = PasswordBackendSSHPassFirst
+	c.SSH.DefaultSocksPort = 1080
+	c.SSH.IdentitiesOnly = true
 
 	c.Mount.Enabled = true
 	c.Mount.DefaultRemotePath = "" // empty means remote home
@@ -123,13 +203,47 @@ func Default() Config {
 
 	c.Automation.SyncTokenDefinitions = false
 	c.Automation.SessionTTLSeconds = 900
+	c.Automation.ExpiryWarningHorizon = 72 * time.Hour
+
+	c.Metrics.ListenAddr = "127.0.0.1:9102"
+
+	c.DB.WALMode = true
+	c.DB.CacheSizeKB = 4096
 	return c
 }
 
+// ActiveProfile returns the profile selected via SSHTHING_PROFILE, or "" for
+// the default profile.
+func ActiveProfile() string {
+	return strings.TrimSpace(os.Getenv(ProfileEnv))
+}
+
+// ValidateProfile checks that name is usable as a directory name.
+func ValidateProfile(name string) error {
+	if name == "" {
+		return fmt.Errorf("profile name is empty")
+	}
+	for _, r := range name {
+		ok := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_'
+		if !ok {
+			return fmt.Errorf("invalid profile name %q: use letters, digits, '-' or '_'", name)
+		}
+	}
+	return nil
+}
+
 // DataDir returns the base data directory for SSHThing.
-// Respects SSHTHING_DATA_DIR env var for testing/custom setups.
+// Respects SSHTHING_DATA_DIR env var for testing/custom setups, and appends
+// the active profile name when one is set.
 func DataDir() (string, error) {
+	profile := ActiveProfile()
+	if profile != "" {
+		if err := ValidateProfile(profile); err != nil {
+			return "", err
+		}
+	}
 	if dir := os.Getenv("SSHTHING_DATA_DIR"); dir != "" {
+		dir = filepath.Join(dir, profile)
 		if err := os.MkdirAll(dir, 0700); err != nil {
 			return "", err
 		}
@@ -139,7 +253,7 @@ func DataDir() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(dir, "sshthing"), nil
+	return filepath.Join(dir, "sshthing", profile), nil
 }
 
 func Path() (string, error) {
@@ -167,6 +281,7 @@ func Load() (Config, error) {
 		return Default(), err
 	}
 	c = withDefaults(c)
+	c.Profile = ActiveProfile()
 	return c, nil
 }
 
@@ -201,8 +316,21 @@ func withDefaults(c Config) Config {
 		c.SSH.PasswordAutoLogin = true
 		c.Version = 2
 	}
+	// v2 → v3: IdentitiesOnly added, on by default
+	if c.Version < 3 {
+		c.SSH.IdentitiesOnly = true
+		c.Version = 3
+	}
+	// v3 → v4: DB.WALMode added, on by default
+	if c.Version < 4 {
+		c.DB.WALMode = true
+		c.Version = 4
+	}
 
 	// Enums / ints: normalize invalid values.
+	if c.UI.PingIntervalSeconds < 0 {
+		c.UI.PingIntervalSeconds = 0
+	}
 	switch c.SSH.HostKeyPolicy {
 	case HostKeyAcceptNew, HostKeyStrict, HostKeyOff:
 	default:
@@ -221,6 +349,21 @@ func withDefaults(c Config) Config {
 	default:
 		c.SSH.PasswordBackendUnix = def.SSH.PasswordBackendUnix
 	}
+	if c.SSH.ConnectRetries < 0 {
+		c.SSH.ConnectRetries = 0
+	}
+	if c.SSH.ConnectRetries > 10 {
+		c.SSH.ConnectRetries = 10
+	}
+	if c.SSH.BulkExecConcurrency <= 0 || c.SSH.BulkExecConcurrency > 32 {
+		c.SSH.BulkExecConcurrency = def.SSH.BulkExecConcurrency
+	}
+	if c.SSH.BannerRefreshSeconds < 0 {
+		c.SSH.BannerRefreshSeconds = 0
+	}
+	if c.SSH.DefaultSocksPort <= 0 || c.SSH.DefaultSocksPort > 65535 {
+		c.SSH.DefaultSocksPort = def.SSH.DefaultSocksPort
+	}
 
 	switch c.Mount.QuitBehavior {
 	case MountQuitPrompt, MountQuitAlwaysUnmount, MountQuitLeaveMounted:
@@ -237,10 +380,28 @@ func withDefaults(c Config) Config {
 	if c.Sync.Branch == "" {
 		c.Sync.Branch = def.Sync.Branch
 	}
+	if c.Sync.PullOnly && c.Sync.PushOnly {
+		c.Sync.PushOnly = false
+	}
 
 	if c.Automation.SessionTTLSeconds <= 0 || c.Automation.SessionTTLSeconds > 86400 {
 		c.Automation.SessionTTLSeconds = def.Automation.SessionTTLSeconds
 	}
+	if c.Automation.ExpiryWarningHorizon <= 0 {
+		c.Automation.ExpiryWarningHorizon = def.Automation.ExpiryWarningHorizon
+	}
+	if c.Automation.AutoSyncIntervalSeconds < 0 {
+		c.Automation.AutoSyncIntervalSeconds = 0
+	}
+	if c.DB.CacheSizeKB <= 0 {
+		c.DB.CacheSizeKB = def.DB.CacheSizeKB
+	}
+	if strings.TrimSpace(c.Metrics.ListenAddr) == "" {
+		c.Metrics.ListenAddr = def.Metrics.ListenAddr
+	}
+	if c.Security.IdleLockSeconds < 0 {
+		c.Security.IdleLockSeconds = 0
+	}
 
 	return c
 }
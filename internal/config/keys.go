@@ -0,0 +1,133 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// enumValues lists the accepted values of the config's string enums.
+var enumValues = map[reflect.Type][]string{
+	reflect.TypeOf(HostKeyAcceptNew):            {string(HostKeyAcceptNew), string(HostKeyStrict), string(HostKeyOff)},
+	reflect.TypeOf(TermAuto):                    {string(TermAuto), string(TermXterm), string(TermCustom)},
+	reflect.TypeOf(PasswordBackendSSHPassFirst): {string(PasswordBackendSSHPassFirst), string(PasswordBackendAskpassFirst)},
+	reflect.TypeOf(MountQuitPrompt):             {string(MountQuitPrompt), string(MountQuitAlwaysUnmount), string(MountQuitLeaveMounted)},
+	reflect.TypeOf(SyncAuthSSHKey):              {string(SyncAuthSSHKey), string(SyncAuthNone)},
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Get returns the value at key, a dot-separated path of JSON names such as
+// "ssh.host_key_policy". A section key ("ssh") returns the section as JSON.
+func Get(c Config, key string) (string, error) {
+	v, err := lookupKey(&c, key)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String(), nil
+	case v.Kind() == reflect.String:
+		return v.String(), nil
+	case v.Kind() == reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case v.Kind() == reflect.Int:
+		return strconv.FormatInt(v.Int(), 10), nil
+	}
+	b, err := json.MarshalIndent(v.Interface(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Set parses value for the setting at key and stores it in c. Enum values
+// must be one of the accepted names, and values that Load would reset to a
+// default (out of range numbers, for example) are rejected; c is left
+// unchanged on error.
+func Set(c *Config, key, value string) error {
+	next := *c
+	v, err := lookupKey(&next, key)
+	if err != nil {
+		return err
+	}
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q for %s (use e.g. 72h)", value, key)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		if allowed, ok := enumValues[v.Type()]; ok && !containsString(allowed, value) {
+			return fmt.Errorf("invalid value %q for %s (use %s)", value, key, strings.Join(allowed, ", "))
+		}
+		v.SetString(value)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s (use true or false)", value, key)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s (use a number)", value, key)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Struct:
+		return fmt.Errorf("%s is a section; set one of its keys, e.g. %s.<name>", key, key)
+	default:
+		return fmt.Errorf("%s cannot be set from the command line; edit config.json", key)
+	}
+
+	set, _ := Get(next, key)
+	if normalized, _ := Get(withDefaults(next), key); normalized != set {
+		return fmt.Errorf("invalid value %q for %s", value, key)
+	}
+	*c = next
+	return nil
+}
+
+// lookupKey walks c by JSON field names and returns the addressable field.
+func lookupKey(c *Config, key string) (reflect.Value, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return reflect.Value{}, fmt.Errorf("config key is empty")
+	}
+	v := reflect.ValueOf(c).Elem()
+	for _, part := range strings.Split(key, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("unknown config key %q", key)
+		}
+		f, ok := fieldByJSONName(v, part)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("unknown config key %q", key)
+		}
+		v = f
+	}
+	return v, nil
+}
+
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if tag != "-" && tag == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package db
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveManifestName is the manifest entry inside a backup archive.
+const ArchiveManifestName = "backup-manifest.json"
+
+// archiveManifestVersion is the format version written to new manifests.
+const archiveManifestVersion = 1
+
+// maxArchiveEntry caps the size of a single archive entry on restore.
+const maxArchiveEntry = 1 << 30
+
+// ArchiveFile is one file in a backup archive: Name is the entry name and
+// Path is where the file lives in the data directory.
+type ArchiveFile struct {
+	Name string
+	Path string
+}
+
+// ArchiveManifest describes the files in a backup archive.
+type ArchiveManifest struct {
+	Version    int                    `json:"version"`
+	AppVersion string                 `json:"app_version,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+	Files      []ArchiveManifestEntry `json:"files"`
+}
+
+// ArchiveManifestEntry records the size and SHA-256 of one archived file.
+type ArchiveManifestEntry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// IsArchivePath reports whether path names a .tar.gz (or .tgz) archive.
+func IsArchivePath(path string) bool {
+	p := strings.ToLower(path)
+	return strings.HasSuffix(p, ".tar.gz") || strings.HasSuffix(p, ".tgz")
+}
+
+// BackupArchive writes files that exist to a gzipped tar at dest, followed
+// by a manifest with their SHA-256 sums. Files are copied as they are on
+// disk, so the database and token vault stay encrypted. Missing files are
+// skipped; it is an error if none exist.
+func BackupArchive(dest, appVersion string, files []ArchiveFile) (ArchiveManifest, error) {
+	manifest := ArchiveManifest{Version: archiveManifestVersion, AppVersion: appVersion, CreatedAt: time.Now().UTC()}
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return manifest, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".sshthing-backup-*")
+	if err != nil {
+		return manifest, err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	defer tmp.Close()
+
+	gz := gzip.NewWriter(tmp)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		data, err := os.ReadFile(f.Path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return manifest, err
+		}
+		if err := checkWALFlushed(f.Path); err != nil {
+			return manifest, err
+		}
+		if err := writeTarEntry(tw, f.Name, data, manifest.CreatedAt); err != nil {
+			return manifest, err
+		}
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, ArchiveManifestEntry{Name: f.Name, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+	}
+	if len(manifest.Files) == 0 {
+		return manifest, fmt.Errorf("nothing to back up: no database, config or token vault found")
+	}
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+	if err := writeTarEntry(tw, ArchiveManifestName, raw, manifest.CreatedAt); err != nil {
+		return manifest, err
+	}
+	if err := tw.Close(); err != nil {
+		return manifest, err
+	}
+	if err := gz.Close(); err != nil {
+		return manifest, err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		return manifest, err
+	}
+	if err := tmp.Close(); err != nil {
+		return manifest, err
+	}
+	return manifest, os.Rename(tmpPath, dest)
+}
+
+func writeTarEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// RestoreArchive verifies every file in the archive at src against its
+// manifest and then writes each one to the matching path in files. Existing
+// files are only replaced when force is set. The store must be closed
+// before calling RestoreArchive.
+func RestoreArchive(src string, files []ArchiveFile, force bool) (ArchiveManifest, error) {
+	var manifest ArchiveManifest
+	contents, err := readArchive(src)
+	if err != nil {
+		return manifest, err
+	}
+	raw, ok := contents[ArchiveManifestName]
+	if !ok {
+		return manifest, fmt.Errorf("%s has no %s", src, ArchiveManifestName)
+	}
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid %s: %w", ArchiveManifestName, err)
+	}
+	if manifest.Version > archiveManifestVersion {
+		return manifest, fmt.Errorf("backup format v%d is newer than this sshthing supports", manifest.Version)
+	}
+
+	paths := make(map[string]string, len(files))
+	for _, f := range files {
+		paths[f.Name] = f.Path
+	}
+	for _, e := range manifest.Files {
+		dest, ok := paths[e.Name]
+		if !ok {
+			return manifest, fmt.Errorf("unexpected file %q in backup", e.Name)
+		}
+		data, ok := contents[e.Name]
+		if !ok {
+			return manifest, fmt.Errorf("backup is missing %s", e.Name)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != strings.ToLower(e.SHA256) {
+			return manifest, fmt.Errorf("checksum mismatch for %s", e.Name)
+		}
+		if !force {
+			exists, err := fileExists(dest)
+			if err != nil {
+				return manifest, err
+			}
+			if exists {
+				return manifest, fmt.Errorf("%s already exists (use --force to replace it)", dest)
+			}
+		}
+	}
+
+	for _, e := range manifest.Files {
+		if err := writeFileAtomic(paths[e.Name], contents[e.Name]); err != nil {
+			return manifest, err
+		}
+		// Drop any log of the replaced database (a no-op for other files).
+		if err := removeWALFiles(paths[e.Name]); err != nil {
+			return manifest, err
+		}
+	}
+	return manifest, nil
+}
+
+// readArchive returns the regular files in a gzipped tar by name.
+func readArchive(src string) (map[string][]byte, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a gzip archive: %w", src, err)
+	}
+	defer gz.Close()
+
+	out := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", src, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if hdr.Size > maxArchiveEntry {
+			return nil, fmt.Errorf("%s is too large", hdr.Name)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxArchiveEntry))
+		if err != nil {
+			return nil, err
+		}
+		out[hdr.Name] = data
+	}
+}
+
+// writeFileAtomic writes data to dest through a temp file in dest's
+// directory.
+func writeFileAtomic(dest string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".sshthing-copy-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, dest)
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ChecksumSuffix is appended to a backup path to name its SHA-256 file.
+const ChecksumSuffix = ".sha256"
+
+// DefaultBackupPath returns a timestamped backup path in a "backups"
+// directory next to the database.
+func DefaultBackupPath(now time.Time) (string, error) {
+	dbPath, err := DBPath()
+	if err != nil {
+		return "", err
+	}
+	name := "hosts-" + now.Format("20060102-150405") + ".db"
+	return filepath.Join(filepath.Dir(dbPath), "backups", name), nil
+}
+
+// Backup copies the encrypted database file to dest as-is and writes a
+// sha256sum-style checksum file next to it. The copy opens with the same
+// master password on any machine with a compatible SQLCipher build. While
+// a store is open in WAL mode, call its Checkpoint first; otherwise the
+// copy is refused rather than missing the latest changes.
+func Backup(dest string) (string, error) {
+	dbPath, err := DBPath()
+	if err != nil {
+		return "", err
+	}
+	exists, err := fileExists(dbPath)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", fmt.Errorf("no database at %s", dbPath)
+	}
+	if err := checkWALFlushed(dbPath); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return "", err
+	}
+	sum, err := copyFileAtomic(dbPath, dest)
+	if err != nil {
+		return "", err
+	}
+	line := sum + "  " + filepath.Base(dest) + "\n"
+	if err := os.WriteFile(dest+ChecksumSuffix, []byte(line), 0600); err != nil {
+		return "", fmt.Errorf("failed to write checksum: %w", err)
+	}
+	return sum, nil
+}
+
+// Restore verifies src against its checksum file and copies it over the
+// active database. An existing database is only replaced when force is set.
+// The store must be closed before calling Restore.
+func Restore(src string, force bool) error {
+	raw, err := os.ReadFile(src + ChecksumSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to read checksum file: %w", err)
+	}
+	fields := strings.Fields(string(raw))
+	if len(fields) == 0 {
+		return fmt.Errorf("checksum file %s is empty", src+ChecksumSuffix)
+	}
+	want := strings.ToLower(fields[0])
+	got, err := fileChecksum(src)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("checksum mismatch for %s", src)
+	}
+
+	dbPath, err := DBPath()
+	if err != nil {
+		return err
+	}
+	exists, err := fileExists(dbPath)
+	if err != nil {
+		return err
+	}
+	if exists && !force {
+		return fmt.Errorf("a database already exists at %s (use --force to replace it)", dbPath)
+	}
+	sum, err := copyFileAtomic(src, dbPath)
+	if err != nil {
+		return err
+	}
+	if sum != want {
+		return fmt.Errorf("checksum mismatch after copying %s", src)
+	}
+	// A log left by the replaced database must not be replayed onto the backup.
+	return removeWALFiles(dbPath)
+}
+
+// checkWALFlushed returns an error when dbPath has changes that are still
+// only in its write-ahead log, e.g. while SSHThing has it open.
+func checkWALFlushed(dbPath string) error {
+	pending, err := walPending(dbPath)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return fmt.Errorf("the database has unsaved changes in %s; close SSHThing (or use Settings \u2192 backup now) and try again", filepath.Base(dbPath)+"-wal")
+	}
+	return nil
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyFileAtomic copies src to dest through a temp file in dest's directory
+// and returns the SHA-256 of the copied bytes.
+func copyFileAtomic(src, dest string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".sshthing-copy-*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), in); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, dest); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DBStats summarizes the contents and on-disk size of the database.
+type DBStats struct {
+	HostCount     int
+	GroupCount    int
+	FileSizeBytes int
+}
+
+// Stats counts hosts and live groups and computes the database size from
+// SQLite's page count and page size.
+func (s *Store) Stats() (DBStats, error) {
+	var st DBStats
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM hosts`).Scan(&st.HostCount); err != nil {
+		return st, err
+	}
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM groups WHERE deleted_at IS NULL`).Scan(&st.GroupCount); err != nil {
+		return st, err
+	}
+	var pageCount, pageSize int
+	if err := s.db.QueryRow(`PRAGMA page_count`).Scan(&pageCount); err != nil {
+		return st, err
+	}
+	if err := s.db.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return st, err
+	}
+	st.FileSizeBytes = pageCount * pageSize
+	return st, nil
+}
+
+// Vacuum rebuilds the database file, reclaiming space left by deleted rows.
+func (s *Store) Vacuum() error {
+	_, err := s.db.Exec("VACUUM")
+	return err
+}
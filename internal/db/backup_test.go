@@ -0,0 +1,211 @@
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	h := &db.HostModel{Label: "web", Hostname: "web.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+	if err := store.CreateHost(h, "hunter2"); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	store.Close()
+
+	backup := filepath.Join(t.TempDir(), "hosts-backup.db")
+	sum, err := db.Backup(backup)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	raw, err := os.ReadFile(backup + db.ChecksumSuffix)
+	if err != nil || !strings.HasPrefix(string(raw), sum+"  hosts-backup.db") {
+		t.Fatalf("unexpected checksum file %q (%v)", raw, err)
+	}
+
+	if err := db.Restore(backup, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected restore over an existing database to need --force, got %v", err)
+	}
+
+	// Restore into a fresh data dir, as on another machine.
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	if err := db.Restore(backup, false); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	restored, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init on restored database failed: %v", err)
+	}
+	defer restored.Close()
+	if secret, err := restored.GetHostSecret(h.ID); err != nil || secret != "hunter2" {
+		t.Fatalf("expected restored host secret, got %q (%v)", secret, err)
+	}
+
+	if err := os.WriteFile(backup, []byte("tampered"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := db.Restore(backup, true); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+}
+
+func TestStatsAndVacuum(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	for _, label := range []string{"web", "db"} {
+		h := &db.HostModel{Label: label, Hostname: label + ".example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+		if err := store.CreateHost(h, "hunter2"); err != nil {
+			t.Fatalf("CreateHost failed: %v", err)
+		}
+	}
+	if err := store.UpsertGroup("prod"); err != nil {
+		t.Fatalf("UpsertGroup failed: %v", err)
+	}
+	if err := store.UpsertGroup("old"); err != nil {
+		t.Fatalf("UpsertGroup failed: %v", err)
+	}
+	if err := store.DeleteGroup("old"); err != nil {
+		t.Fatalf("DeleteGroup failed: %v", err)
+	}
+
+	st, err := store.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if st.HostCount != 2 || st.GroupCount != 1 || st.FileSizeBytes <= 0 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+	if err := store.Vacuum(); err != nil {
+		t.Fatalf("Vacuum failed: %v", err)
+	}
+	if after, err := store.Stats(); err != nil || after.HostCount != 2 {
+		t.Fatalf("expected hosts to survive vacuum, got %+v (%v)", after, err)
+	}
+}
+
+func TestBackupArchiveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SSHTHING_DATA_DIR", dir)
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	h := &db.HostModel{Label: "web", Hostname: "web.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+	if err := store.CreateHost(h, "hunter2"); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	store.Close()
+	dbPath, err := db.DBPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfgPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"version":2}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	files := []db.ArchiveFile{
+		{Name: "hosts.db", Path: dbPath},
+		{Name: "config.json", Path: cfgPath},
+		{Name: "tokens.json", Path: filepath.Join(dir, "tokens.json")},
+	}
+
+	archive := filepath.Join(t.TempDir(), "sshthing.tar.gz")
+	manifest, err := db.BackupArchive(archive, "v1.2.3", files)
+	if err != nil {
+		t.Fatalf("BackupArchive failed: %v", err)
+	}
+	if len(manifest.Files) != 2 || manifest.AppVersion != "v1.2.3" {
+		t.Fatalf("expected the database and config in the manifest, got %+v", manifest)
+	}
+	if _, err := db.RestoreArchive(archive, files, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected restore over existing data to need --force, got %v", err)
+	}
+
+	// Restore into a fresh data dir, as on another machine.
+	fresh := t.TempDir()
+	t.Setenv("SSHTHING_DATA_DIR", fresh)
+	dbPath, err = db.DBPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files[0].Path, files[1].Path = dbPath, filepath.Join(fresh, "config.json")
+	if _, err := db.RestoreArchive(archive, files, false); err != nil {
+		t.Fatalf("RestoreArchive failed: %v", err)
+	}
+	if raw, err := os.ReadFile(files[1].Path); err != nil || string(raw) != `{"version":2}` {
+		t.Fatalf("expected restored config, got %q (%v)", raw, err)
+	}
+	restored, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init on restored database failed: %v", err)
+	}
+	defer restored.Close()
+	if secret, err := restored.GetHostSecret(h.ID); err != nil || secret != "hunter2" {
+		t.Fatalf("expected restored host secret, got %q (%v)", secret, err)
+	}
+
+	if err := os.WriteFile(archive, []byte("tampered"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.RestoreArchive(archive, files, true); err == nil {
+		t.Fatalf("expected a damaged archive to be rejected")
+	}
+}
+
+func TestWALModeAndBackup(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+	dbPath, err := db.DBPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &db.HostModel{Label: "web", Hostname: "web.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+	if err := store.CreateHost(h, "hunter2"); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	if info, err := os.Stat(dbPath + "-wal"); err != nil || info.Size() == 0 {
+		t.Fatalf("expected the write to land in the WAL by default, got %v", err)
+	}
+
+	backup := filepath.Join(t.TempDir(), "hosts-backup.db")
+	if _, err := db.Backup(backup); err == nil {
+		t.Fatalf("expected a backup with pending WAL changes to be refused")
+	}
+	if err := store.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	if _, err := db.Backup(backup); err != nil {
+		t.Fatalf("Backup after a checkpoint failed: %v", err)
+	}
+
+	if err := store.SetPragmas(false, 1024); err != nil {
+		t.Fatalf("SetPragmas failed: %v", err)
+	}
+	if err := store.CreateHost(&db.HostModel{Label: "db", Hostname: "db.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}, "pw"); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	if _, err := os.Stat(dbPath + "-wal"); !os.IsNotExist(err) {
+		t.Fatalf("expected no WAL file with the rollback journal, got %v", err)
+	}
+	if hosts, err := store.GetHosts(); err != nil || len(hosts) != 2 {
+		t.Fatalf("expected both hosts, got %d (%v)", len(hosts), err)
+	}
+}
@@ -8,48 +8,101 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/Vansh-Raja/SSHThing/internal/config"
 	"github.com/Vansh-Raja/SSHThing/internal/crypto"
-	_ "github.com/mutecomm/go-sqlcipher/v4" // SQLCipher driver
 )
 
 // Store handles database operations
 type Store struct {
-	db        *sql.DB
+	db    *sql.DB
+	conns *pragmaConnector // applies per-connection pragmas to db
+
+	// keyMu guards masterKey and kdf. UpgradeKDF replaces both while other
+	// goroutines may be reading or writing host secrets.
+	keyMu     sync.RWMutex
 	masterKey []byte
+	kdf       KDFParams
 }
 
 // HostModel mirrors the Host struct but for DB interactions
 type HostModel struct {
-	ID            int
-	Label         string
-	GroupName     string
-	Tags          []string
-	Hostname      string
-	Username      string
-	Port          int
-	KeyData       string // Encrypted blob
-	KeyType       string
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	LastConnected *time.Time
+	ID               int
+	Label            string
+	GroupName        string
+	Tags             []string
+	Hostname         string
+	Username         string
+	Port             int
+	KeyData          string // Encrypted blob
+	KeyType          string
+	Recording        string            // "" (follow global setting) | "on" | "off"
+	SyncExclude      bool              // never exported to Git sync
+	SSHOptions       map[string]string // extra `ssh -o Key=Value` options
+	Pinned           bool              // listed before unpinned hosts
+	MACAddress       string            // Wake-on-LAN target; "" when not set
+	BroadcastAddr    string            // Wake-on-LAN broadcast address; "" uses the default
+	KeepAliveSeconds int               // ServerAliveInterval in seconds; 0 uses the global setting
+	ForwardX11       bool              // always forward X11, whatever the global setting
+	ProxyHosts       []int             // jump host IDs, first hop first
+	TermOverride     string            // TERM for this host's sessions; "" uses the global setting
+	Icon             string            // emoji shown before the label in the host list; "" uses the default marker
+	IdentitiesOnly   string            // "on" or "off" overrides the global IdentitiesOnly setting; "" inherits it
+	ColorOverride    string            // hex list color, e.g. "#89b4fa"; takes precedence over the group's color
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	LastConnected    *time.Time
 }
 
 // GroupModel represents a named group used to organize hosts.
 // Groups are identified by Name (case-insensitive uniqueness in DB).
 // Deleted groups are tombstoned via DeletedAt and may be garbage collected later.
 type GroupModel struct {
-	Name      string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt *time.Time
+	Name        string
+	ParentGroup string // enclosing group; empty for a top-level group
+	Color       string // hex list color for the group's hosts; "" for none
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   *time.Time
+}
+
+// SessionRecording points at a typescript file captured for one SSH session.
+type SessionRecording struct {
+	ID        int
+	HostID    int
+	Path      string
+	StartedAt time.Time
+}
+
+// HostFingerprint is the last host key fingerprint seen for a host. Previous
+// holds the last accepted fingerprint once a scan sees a different key, and
+// stays set until AcceptHostFingerprint or a scan sees that key again.
+type HostFingerprint struct {
+	HostID      int
+	Fingerprint string
+	Previous    string
+	UpdatedAt   time.Time
+}
+
+// Changed reports whether the host key differs from the last accepted one.
+func (f HostFingerprint) Changed() bool {
+	return f.Previous != "" && f.Previous != f.Fingerprint
+}
+
+// HostBanner is the pre-authentication banner last fetched for a host.
+type HostBanner struct {
+	HostID    int
+	Banner    string
+	CheckedAt time.Time
 }
 
 type MountState struct {
 	HostID     int
 	LocalPath  string
 	RemotePath string
+	ReadOnly   bool
 	MountedAt  time.Time
 }
 
@@ -64,7 +117,12 @@ func DBPath() (string, error) {
 		}
 		return p, nil
 	}
-	if dir := os.Getenv("SSHTHING_DATA_DIR"); dir != "" {
+	if os.Getenv("SSHTHING_DATA_DIR") != "" || config.ActiveProfile() != "" {
+		// Profiles keep their database next to their config.
+		dir, err := config.DataDir()
+		if err != nil {
+			return "", err
+		}
 		if err := os.MkdirAll(dir, 0700); err != nil {
 			return "", err
 		}
@@ -104,10 +162,10 @@ func Delete() error {
 		return err
 	}
 	err = os.Remove(dbPath)
-	if os.IsNotExist(err) {
-		return nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
 	}
-	return err
+	return removeWALFiles(dbPath)
 }
 
 // Exists checks if the database file exists
@@ -177,11 +235,11 @@ func Init(password string) (*Store, error) {
 
 	// Open DB read-write with SQLCipher encryption key.
 	// The _pragma_key is the raw hex key, _pragma_cipher_page_size sets page size.
-	rwDSN := fmt.Sprintf("file:%s?mode=rwc&_pragma_key=x'%s'&_pragma_cipher_page_size=4096", dbPath, keyHex)
-	db, err := sql.Open("sqlite3", rwDSN)
-	if err != nil {
-		return nil, err
-	}
+	// Pooled connections wait for each other's locks (_busy_timeout), and
+	// transactions take the write lock up front (_txlock) so two of them
+	// never deadlock upgrading from a read.
+	rwDSN := fmt.Sprintf("file:%s?mode=rwc&_pragma_key=x'%s'&_pragma_cipher_page_size=4096&_busy_timeout=5000&_txlock=immediate", dbPath, keyHex)
+	db, conns := openPooled(rwDSN)
 
 	// Ensure schema exists (first-run / migrations).
 	if err := createSchema(db); err != nil {
@@ -189,6 +247,14 @@ func Init(password string) (*Store, error) {
 		return nil, err
 	}
 
+	// A missing or unreadable config falls back to the defaults.
+	cfg, _ := config.Load()
+	store := &Store{db: db, conns: conns}
+	if err := store.SetPragmas(cfg.DB.WALMode, cfg.DB.CacheSizeKB); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	// Get or create salt for per-key AES-GCM encryption (second layer)
 	salt, err := getSalt(db)
 	if err != nil {
@@ -196,17 +262,22 @@ func Init(password string) (*Store, error) {
 		return nil, err
 	}
 
+	kdf, err := getKDFParams(db, !exists)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	// Derive per-key encryption key from password + salt
-	perKeyKey, _, err := crypto.DeriveKey(password, salt)
+	perKeyKey, err := deriveSecretKey(password, salt, kdf)
 	if err != nil {
 		db.Close()
 		return nil, err
 	}
 
-	return &Store{
-		db:        db,
-		masterKey: perKeyKey,
-	}, nil
+	store.masterKey = perKeyKey
+	store.kdf = kdf
+	return store, nil
 }
 
 func verifyUnlocked(db *sql.DB) error {
@@ -298,6 +369,21 @@ func createSchema(db *sql.DB) error {
 		port INTEGER DEFAULT 22,
 		key_data TEXT,
 		key_type TEXT,
+		recording TEXT,
+		sync_exclude INTEGER NOT NULL DEFAULT 0,
+		ssh_options TEXT NOT NULL DEFAULT '',
+		pinned INTEGER NOT NULL DEFAULT 0,
+		mac_address TEXT NOT NULL DEFAULT '',
+		broadcast_addr TEXT NOT NULL DEFAULT '',
+		keepalive_seconds INTEGER NOT NULL DEFAULT 0,
+		forward_x11 INTEGER NOT NULL DEFAULT 0,
+		proxy_hosts TEXT NOT NULL DEFAULT '',
+		term_override TEXT NOT NULL DEFAULT '',
+		icon TEXT NOT NULL DEFAULT '',
+		identities_only TEXT NOT NULL DEFAULT '',
+		color_override TEXT NOT NULL DEFAULT '',
+		banner TEXT NOT NULL DEFAULT '',
+		banner_checked_at TIMESTAMP,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		last_connected TIMESTAMP
@@ -340,6 +426,55 @@ func createSchema(db *sql.DB) error {
 		}
 	}
 
+	// Per-host session recording override. Must run after the notes migration,
+	// which rebuilds the hosts table with a fixed column list.
+	if err := ensureColumn(db, "hosts", "recording", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "ssh_options", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "sync_exclude", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "pinned", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "mac_address", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "broadcast_addr", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "keepalive_seconds", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "forward_x11", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "proxy_hosts", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "term_override", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "icon", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "identities_only", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "color_override", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	// SSH banner cache; local to this device and never synced.
+	if err := ensureColumn(db, "hosts", "banner", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "hosts", "banner_checked_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+
 	// Groups table (for organizing hosts)
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS groups (
@@ -352,6 +487,19 @@ func createSchema(db *sql.DB) error {
 	if err != nil {
 		return err
 	}
+	// Groups predating sync tombstones only had a name and created_at.
+	if err := ensureColumn(db, "groups", "updated_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "groups", "deleted_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "groups", "parent_group", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "groups", "color", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
 
 	// Metadata table (for Salt)
 	_, err = db.Exec(`
@@ -367,10 +515,52 @@ func createSchema(db *sql.DB) error {
 	// Mount state table (best-effort persistence for Finder mounts)
 	_, err = db.Exec(`
 	CREATE TABLE IF NOT EXISTS mounts (
-		host_id INTEGER PRIMARY KEY,
+		host_id INTEGER NOT NULL,
 		local_path TEXT NOT NULL,
-		remote_path TEXT,
-		mounted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		remote_path TEXT NOT NULL DEFAULT '',
+		mounted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (host_id, remote_path)
+	);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Older databases keyed mounts by host only; rebuild so a host can have
+	// several mounts with different remote paths.
+	composite, err := isPrimaryKeyColumn(db, "mounts", "remote_path")
+	if err != nil {
+		return err
+	}
+	if !composite {
+		if err := migrateMountsCompositeKey(db); err != nil {
+			return fmt.Errorf("failed to migrate mounts table: %w", err)
+		}
+	}
+	if err := ensureColumn(db, "mounts", "read_only", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Session recordings (typescript files captured via script(1))
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS session_recordings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id INTEGER NOT NULL,
+		path TEXT NOT NULL,
+		started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Host key fingerprints seen by ssh-keyscan, for change detection
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS host_fingerprints (
+		host_id INTEGER PRIMARY KEY,
+		fingerprint TEXT NOT NULL,
+		previous TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
 	`)
 	return err
@@ -402,6 +592,71 @@ func hasColumn(db *sql.DB, table, column string) (bool, error) {
 	return false, nil
 }
 
+func isPrimaryKeyColumn(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid int
+		var name, ctype string
+		var notnull int
+		var dflt sql.NullString
+		var pk int
+		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return pk > 0, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func migrateMountsCompositeKey(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(`
+		CREATE TABLE IF NOT EXISTS mounts_new (
+			host_id INTEGER NOT NULL,
+			local_path TEXT NOT NULL,
+			remote_path TEXT NOT NULL DEFAULT '',
+			mounted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (host_id, remote_path)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT OR REPLACE INTO mounts_new (host_id, local_path, remote_path, mounted_at)
+		SELECT host_id, local_path, COALESCE(remote_path, ''), mounted_at
+		FROM mounts;
+	`)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DROP TABLE mounts;`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`ALTER TABLE mounts_new RENAME TO mounts;`); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func migrateHostsDropNotes(db *sql.DB) error {
 	tx, err := db.Begin()
 	if err != nil {
@@ -525,9 +780,33 @@ func (s *Store) GetSalt() (string, error) {
 	return saltHex, nil
 }
 
-// ReencryptKeyData decrypts key data using a source salt and re-encrypts with local salt.
-// This is used during sync import when the source database had a different salt.
-func (s *Store) ReencryptKeyData(encryptedData string, sourceSaltHex string, password string) (string, error) {
+// SyncSalt returns the hex salt used to derive the key for opaque sync files,
+// creating it on first use. It is separate from the key-encryption salt so
+// the sync key never equals the key protecting host secrets.
+func (s *Store) SyncSalt() (string, error) {
+	var saltHex string
+	err := s.db.QueryRow("SELECT value FROM config WHERE key = 'sync_salt'").Scan(&saltHex)
+	if err == nil {
+		return saltHex, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+	salt, err := crypto.GenerateRandomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	saltHex = hex.EncodeToString(salt)
+	if _, err := s.db.Exec("INSERT INTO config (key, value) VALUES ('sync_salt', ?)", saltHex); err != nil {
+		return "", err
+	}
+	return saltHex, nil
+}
+
+// ReencryptKeyData decrypts key data using a source salt and KDF parameters and
+// re-encrypts with the local key. This is used during sync import when the
+// source database had a different salt.
+func (s *Store) ReencryptKeyData(encryptedData string, sourceSaltHex string, sourceKDF KDFParams, password string) (string, error) {
 	if encryptedData == "" {
 		return "", nil
 	}
@@ -539,7 +818,7 @@ func (s *Store) ReencryptKeyData(encryptedData string, sourceSaltHex string, pas
 	}
 
 	// Derive source key
-	sourceKey, _, err := crypto.DeriveKey(password, sourceSalt)
+	sourceKey, err := deriveSecretKey(password, sourceSalt, sourceKDF)
 	if err != nil {
 		return "", fmt.Errorf("failed to derive source key: %w", err)
 	}
@@ -551,7 +830,9 @@ func (s *Store) ReencryptKeyData(encryptedData string, sourceSaltHex string, pas
 	}
 
 	// Re-encrypt with local key (s.masterKey)
+	s.keyMu.RLock()
 	reencrypted, err := crypto.Encrypt(plaintext, s.masterKey)
+	s.keyMu.RUnlock()
 	if err != nil {
 		return "", fmt.Errorf("failed to re-encrypt key: %w", err)
 	}
@@ -559,20 +840,20 @@ func (s *Store) ReencryptKeyData(encryptedData string, sourceSaltHex string, pas
 	return reencrypted, nil
 }
 
-func (s *Store) UpsertMountState(hostID int, localPath, remotePath string) error {
+func (s *Store) UpsertMountState(hostID int, localPath, remotePath string, readOnly bool) error {
 	_, err := s.db.Exec(`
-		INSERT INTO mounts (host_id, local_path, remote_path, mounted_at)
-		VALUES (?, ?, ?, ?)
-		ON CONFLICT(host_id) DO UPDATE SET
+		INSERT INTO mounts (host_id, local_path, remote_path, read_only, mounted_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(host_id, remote_path) DO UPDATE SET
 			local_path=excluded.local_path,
-			remote_path=excluded.remote_path,
+			read_only=excluded.read_only,
 			mounted_at=excluded.mounted_at
-	`, hostID, localPath, remotePath, time.Now())
+	`, hostID, localPath, remotePath, readOnly, time.Now())
 	return err
 }
 
-func (s *Store) DeleteMountState(hostID int) error {
-	_, err := s.db.Exec(`DELETE FROM mounts WHERE host_id = ?`, hostID)
+func (s *Store) DeleteMountState(hostID int, remotePath string) error {
+	_, err := s.db.Exec(`DELETE FROM mounts WHERE host_id = ? AND remote_path = ?`, hostID, remotePath)
 	return err
 }
 
@@ -583,7 +864,7 @@ func (s *Store) DeleteAllMountStates() error {
 
 func (s *Store) GetMountStates() ([]MountState, error) {
 	rows, err := s.db.Query(`
-		SELECT host_id, local_path, COALESCE(remote_path, ''), mounted_at
+		SELECT host_id, local_path, COALESCE(remote_path, ''), read_only, mounted_at
 		FROM mounts
 		ORDER BY mounted_at DESC
 	`)
@@ -595,7 +876,7 @@ func (s *Store) GetMountStates() ([]MountState, error) {
 	var out []MountState
 	for rows.Next() {
 		var ms MountState
-		if err := rows.Scan(&ms.HostID, &ms.LocalPath, &ms.RemotePath, &ms.MountedAt); err != nil {
+		if err := rows.Scan(&ms.HostID, &ms.LocalPath, &ms.RemotePath, &ms.ReadOnly, &ms.MountedAt); err != nil {
 			return nil, err
 		}
 		out = append(out, ms)
@@ -608,6 +889,11 @@ func (s *Store) GetMountStates() ([]MountState, error) {
 
 // CreateHost adds a new host
 func (s *Store) CreateHost(h *HostModel, plainKey string) error {
+	// Hold the key until the row is written so UpgradeKDF cannot swap it
+	// between encrypting and storing.
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+
 	// Encrypt the key
 	var encryptedKey string
 	var err error
@@ -622,14 +908,23 @@ func (s *Store) CreateHost(h *HostModel, plainKey string) error {
 	if err != nil {
 		return err
 	}
+	optsValue, err := EncodeSSHOptions(h.SSHOptions)
+	if err != nil {
+		return err
+	}
 
 	now := time.Now()
-	_, err = s.db.Exec(`
-		INSERT INTO hosts (label, group_name, tags, hostname, username, port, key_data, key_type, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, h.Label, normalizeGroupName(h.GroupName), tagsValue, h.Hostname, h.Username, h.Port, encryptedKey, h.KeyType, now, now)
-
-	return err
+	res, err := s.db.Exec(`
+		INSERT INTO hosts (label, group_name, tags, hostname, username, port, key_data, key_type, recording, sync_exclude, ssh_options, mac_address, broadcast_addr, keepalive_seconds, forward_x11, proxy_hosts, term_override, icon, identities_only, color_override, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, h.Label, normalizeGroupName(h.GroupName), tagsValue, h.Hostname, h.Username, h.Port, encryptedKey, h.KeyType, normalizeRecording(h.Recording), h.SyncExclude, optsValue, h.MACAddress, h.BroadcastAddr, h.KeepAliveSeconds, h.ForwardX11, EncodeProxyHosts(h.ProxyHosts), strings.TrimSpace(h.TermOverride), strings.TrimSpace(h.Icon), normalizeIdentitiesOnly(h.IdentitiesOnly), normalizeColor(h.ColorOverride), now, now)
+	if err != nil {
+		return err
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		h.ID = int(id)
+	}
+	return nil
 }
 
 // GetHosts returns all hosts
@@ -644,18 +939,20 @@ func (s *Store) GetHosts() ([]HostModel, error) {
 	if hasUpdatedAt {
 		query = `
 			SELECT id, COALESCE(label, ''), COALESCE(group_name, ''), COALESCE(tags, ''), hostname, username, port,
-			       COALESCE(key_type, ''), COALESCE(key_data, ''),
+			       COALESCE(key_type, ''), COALESCE(key_data, ''), COALESCE(recording, ''), sync_exclude, COALESCE(ssh_options, ''), pinned,
+			       COALESCE(mac_address, ''), COALESCE(broadcast_addr, ''), keepalive_seconds, forward_x11, COALESCE(proxy_hosts, ''), COALESCE(term_override, ''), COALESCE(icon, ''), COALESCE(identities_only, ''), COALESCE(color_override, ''),
 			       created_at, COALESCE(updated_at, created_at), last_connected
 			FROM hosts
-			ORDER BY CASE WHEN COALESCE(label, '') != '' THEN label ELSE hostname END
+			ORDER BY pinned DESC, CASE WHEN COALESCE(label, '') != '' THEN label ELSE hostname END
 		`
 	} else {
 		query = `
 			SELECT id, COALESCE(label, ''), COALESCE(group_name, ''), COALESCE(tags, ''), hostname, username, port,
-			       COALESCE(key_type, ''), COALESCE(key_data, ''),
+			       COALESCE(key_type, ''), COALESCE(key_data, ''), COALESCE(recording, ''), sync_exclude, COALESCE(ssh_options, ''), pinned,
+			       COALESCE(mac_address, ''), COALESCE(broadcast_addr, ''), keepalive_seconds, forward_x11, COALESCE(proxy_hosts, ''), COALESCE(term_override, ''), COALESCE(icon, ''), COALESCE(identities_only, ''), COALESCE(color_override, ''),
 			       created_at, created_at, last_connected
 			FROM hosts
-			ORDER BY CASE WHEN COALESCE(label, '') != '' THEN label ELSE hostname END
+			ORDER BY pinned DESC, CASE WHEN COALESCE(label, '') != '' THEN label ELSE hostname END
 		`
 	}
 
@@ -664,18 +961,44 @@ func (s *Store) GetHosts() ([]HostModel, error) {
 		return nil, err
 	}
 	defer rows.Close()
+	return scanHosts(rows)
+}
 
+// GetRecentHosts returns up to limit hosts that have been connected to, most
+// recent first.
+func (s *Store) GetRecentHosts(limit int) ([]HostModel, error) {
+	rows, err := s.db.Query(`
+		SELECT id, COALESCE(label, ''), COALESCE(group_name, ''), COALESCE(tags, ''), hostname, username, port,
+		       COALESCE(key_type, ''), COALESCE(key_data, ''), COALESCE(recording, ''), sync_exclude, COALESCE(ssh_options, ''), pinned,
+			       COALESCE(mac_address, ''), COALESCE(broadcast_addr, ''), keepalive_seconds, forward_x11, COALESCE(proxy_hosts, ''), COALESCE(term_override, ''), COALESCE(icon, ''), COALESCE(identities_only, ''), COALESCE(color_override, ''),
+		       created_at, COALESCE(updated_at, created_at), last_connected
+		FROM hosts
+		WHERE last_connected IS NOT NULL AND last_connected != ''
+		ORDER BY last_connected DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanHosts(rows)
+}
+
+// scanHosts reads rows selected with the column list used by GetHosts.
+func scanHosts(rows *sql.Rows) ([]HostModel, error) {
 	var hosts []HostModel
 	for rows.Next() {
 		var h HostModel
-		var tagsRaw string
+		var tagsRaw, optsRaw, proxyRaw string
 		var createdAtStr, updatedAtStr string
 		var lastConnStr sql.NullString
-		if err := rows.Scan(&h.ID, &h.Label, &h.GroupName, &tagsRaw, &h.Hostname, &h.Username, &h.Port, &h.KeyType, &h.KeyData, &createdAtStr, &updatedAtStr, &lastConnStr); err != nil {
+		if err := rows.Scan(&h.ID, &h.Label, &h.GroupName, &tagsRaw, &h.Hostname, &h.Username, &h.Port, &h.KeyType, &h.KeyData, &h.Recording, &h.SyncExclude, &optsRaw, &h.Pinned, &h.MACAddress, &h.BroadcastAddr, &h.KeepAliveSeconds, &h.ForwardX11, &proxyRaw, &h.TermOverride, &h.Icon, &h.IdentitiesOnly, &h.ColorOverride, &createdAtStr, &updatedAtStr, &lastConnStr); err != nil {
 			return nil, err
 		}
 		h.GroupName = normalizeGroupName(h.GroupName)
 		h.Tags = DecodeTags(tagsRaw)
+		h.SSHOptions = DecodeSSHOptions(optsRaw)
+		h.ProxyHosts = DecodeProxyHosts(proxyRaw)
 		h.CreatedAt = parseTimestamp(createdAtStr)
 		h.UpdatedAt = parseTimestamp(updatedAtStr)
 		if h.UpdatedAt.IsZero() {
@@ -692,11 +1015,27 @@ func (s *Store) GetHosts() ([]HostModel, error) {
 	return hosts, nil
 }
 
+// GetSyncableHosts returns all hosts that are not excluded from Git sync.
+func (s *Store) GetSyncableHosts() ([]HostModel, error) {
+	hosts, err := s.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	out := hosts[:0]
+	for _, h := range hosts {
+		if !h.SyncExclude {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
 // GetHostByID returns one host by stable integer ID.
 func (s *Store) GetHostByID(id int) (*HostModel, error) {
 	rows, err := s.db.Query(`
 		SELECT id, COALESCE(label, ''), COALESCE(group_name, ''), COALESCE(tags, ''), hostname, username, port,
-		       COALESCE(key_type, ''), COALESCE(key_data, ''),
+		       COALESCE(key_type, ''), COALESCE(key_data, ''), COALESCE(recording, ''), sync_exclude, COALESCE(ssh_options, ''), pinned,
+			       COALESCE(mac_address, ''), COALESCE(broadcast_addr, ''), keepalive_seconds, forward_x11, COALESCE(proxy_hosts, ''), COALESCE(term_override, ''), COALESCE(icon, ''), COALESCE(identities_only, ''), COALESCE(color_override, ''),
 		       created_at, COALESCE(updated_at, created_at), last_connected
 		FROM hosts
 		WHERE id = ?
@@ -711,14 +1050,16 @@ func (s *Store) GetHostByID(id int) (*HostModel, error) {
 	}
 
 	var h HostModel
-	var tagsRaw string
+	var tagsRaw, optsRaw, proxyRaw string
 	var createdAtStr, updatedAtStr string
 	var lastConnStr sql.NullString
-	if err := rows.Scan(&h.ID, &h.Label, &h.GroupName, &tagsRaw, &h.Hostname, &h.Username, &h.Port, &h.KeyType, &h.KeyData, &createdAtStr, &updatedAtStr, &lastConnStr); err != nil {
+	if err := rows.Scan(&h.ID, &h.Label, &h.GroupName, &tagsRaw, &h.Hostname, &h.Username, &h.Port, &h.KeyType, &h.KeyData, &h.Recording, &h.SyncExclude, &optsRaw, &h.Pinned, &h.MACAddress, &h.BroadcastAddr, &h.KeepAliveSeconds, &h.ForwardX11, &proxyRaw, &h.TermOverride, &h.Icon, &h.IdentitiesOnly, &h.ColorOverride, &createdAtStr, &updatedAtStr, &lastConnStr); err != nil {
 		return nil, err
 	}
 	h.GroupName = normalizeGroupName(h.GroupName)
 	h.Tags = DecodeTags(tagsRaw)
+	h.SSHOptions = DecodeSSHOptions(optsRaw)
+	h.ProxyHosts = DecodeProxyHosts(proxyRaw)
 	h.CreatedAt = parseTimestamp(createdAtStr)
 	h.UpdatedAt = parseTimestamp(updatedAtStr)
 	if h.UpdatedAt.IsZero() {
@@ -733,6 +1074,66 @@ func (s *Store) GetHostByID(id int) (*HostModel, error) {
 	return &h, nil
 }
 
+// HostExistsByAddress reports whether a host connects to hostname (compared
+// case-insensitively) on port, and returns the lowest such ID. Port 0 is
+// treated as 22.
+func (s *Store) HostExistsByAddress(hostname string, port int) (bool, int, error) {
+	if port == 0 {
+		port = 22
+	}
+	var id int
+	err := s.db.QueryRow(`
+		SELECT id FROM hosts
+		WHERE hostname = ? COLLATE NOCASE AND COALESCE(NULLIF(port, 0), 22) = ?
+		ORDER BY id
+		LIMIT 1
+	`, strings.TrimSpace(hostname), port).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	return true, id, nil
+}
+
+// GetHostByLabel returns the host whose label matches name, falling back to
+// its hostname. Matching is case-insensitive; more than one match is an error.
+func (s *Store) GetHostByLabel(label string) (*HostModel, error) {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return nil, fmt.Errorf("host label is required")
+	}
+	for _, column := range []string{"label", "hostname"} {
+		rows, err := s.db.Query(`
+			SELECT id, COALESCE(label, ''), COALESCE(group_name, ''), COALESCE(tags, ''), hostname, username, port,
+			       COALESCE(key_type, ''), COALESCE(key_data, ''), COALESCE(recording, ''), sync_exclude, COALESCE(ssh_options, ''), pinned,
+			       COALESCE(mac_address, ''), COALESCE(broadcast_addr, ''), keepalive_seconds, forward_x11, COALESCE(proxy_hosts, ''), COALESCE(term_override, ''), COALESCE(icon, ''), COALESCE(identities_only, ''), COALESCE(color_override, ''),
+			       created_at, COALESCE(updated_at, created_at), last_connected
+			FROM hosts
+			WHERE `+column+` = ? COLLATE NOCASE
+			ORDER BY id
+		`, label)
+		if err != nil {
+			return nil, err
+		}
+		hosts, err := scanHosts(rows)
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		switch len(hosts) {
+		case 0:
+			continue
+		case 1:
+			return &hosts[0], nil
+		default:
+			return nil, fmt.Errorf("%d hosts match %s %q", len(hosts), column, label)
+		}
+	}
+	return nil, fmt.Errorf("no host with label or hostname %q", label)
+}
+
 // parseTimestamp attempts to parse a SQLite timestamp string
 func parseTimestamp(s string) time.Time {
 	if s == "" {
@@ -760,6 +1161,9 @@ func parseTimestamp(s string) time.Time {
 // GetHostSecret retrieves decrypted key_data for a host.
 // For key auth this is the private key; for password auth this is the stored password.
 func (s *Store) GetHostSecret(id int) (string, error) {
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+
 	var encryptedKey string
 	err := s.db.QueryRow("SELECT key_data FROM hosts WHERE id = ?", id).Scan(&encryptedKey)
 	if err != nil {
@@ -789,15 +1193,24 @@ func (s *Store) UpdateHost(h *HostModel) error {
 	if err != nil {
 		return err
 	}
+	optsValue, err := EncodeSSHOptions(h.SSHOptions)
+	if err != nil {
+		return err
+	}
 	_, err = s.db.Exec(`
-		UPDATE hosts SET label=?, group_name=?, tags=?, hostname=?, username=?, port=?, key_type=?, updated_at=?
+		UPDATE hosts SET label=?, group_name=?, tags=?, hostname=?, username=?, port=?, key_type=?, recording=?, sync_exclude=?, ssh_options=?, mac_address=?, broadcast_addr=?, keepalive_seconds=?, forward_x11=?, proxy_hosts=?, term_override=?, icon=?, identities_only=?, color_override=?, updated_at=?
 		WHERE id=?
-	`, h.Label, normalizeGroupName(h.GroupName), tagsValue, h.Hostname, h.Username, h.Port, h.KeyType, time.Now(), h.ID)
+	`, h.Label, normalizeGroupName(h.GroupName), tagsValue, h.Hostname, h.Username, h.Port, h.KeyType, normalizeRecording(h.Recording), h.SyncExclude, optsValue, h.MACAddress, h.BroadcastAddr, h.KeepAliveSeconds, h.ForwardX11, EncodeProxyHosts(h.ProxyHosts), strings.TrimSpace(h.TermOverride), strings.TrimSpace(h.Icon), normalizeIdentitiesOnly(h.IdentitiesOnly), normalizeColor(h.ColorOverride), time.Now(), h.ID)
 	return err
 }
 
 // UpdateHostWithKey updates a host including the encrypted key data
 func (s *Store) UpdateHostWithKey(h *HostModel, plainKey string) error {
+	// Hold the key until the row is written so UpgradeKDF cannot swap it
+	// between encrypting and storing.
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+
 	var encryptedKey string
 	var err error
 	if plainKey != "" {
@@ -810,11 +1223,15 @@ func (s *Store) UpdateHostWithKey(h *HostModel, plainKey string) error {
 	if err != nil {
 		return err
 	}
+	optsValue, err := EncodeSSHOptions(h.SSHOptions)
+	if err != nil {
+		return err
+	}
 
 	_, err = s.db.Exec(`
-		UPDATE hosts SET label=?, group_name=?, tags=?, hostname=?, username=?, port=?, key_type=?, key_data=?, updated_at=?
+		UPDATE hosts SET label=?, group_name=?, tags=?, hostname=?, username=?, port=?, key_type=?, key_data=?, recording=?, sync_exclude=?, ssh_options=?, mac_address=?, broadcast_addr=?, keepalive_seconds=?, forward_x11=?, proxy_hosts=?, term_override=?, icon=?, identities_only=?, color_override=?, updated_at=?
 		WHERE id=?
-	`, h.Label, normalizeGroupName(h.GroupName), tagsValue, h.Hostname, h.Username, h.Port, h.KeyType, encryptedKey, time.Now(), h.ID)
+	`, h.Label, normalizeGroupName(h.GroupName), tagsValue, h.Hostname, h.Username, h.Port, h.KeyType, encryptedKey, normalizeRecording(h.Recording), h.SyncExclude, optsValue, h.MACAddress, h.BroadcastAddr, h.KeepAliveSeconds, h.ForwardX11, EncodeProxyHosts(h.ProxyHosts), strings.TrimSpace(h.TermOverride), strings.TrimSpace(h.Icon), normalizeIdentitiesOnly(h.IdentitiesOnly), normalizeColor(h.ColorOverride), time.Now(), h.ID)
 	return err
 }
 
@@ -826,10 +1243,54 @@ func (s *Store) UpdateLastConnected(id int) error {
 
 // DeleteHost deletes a host
 func (s *Store) DeleteHost(id int) error {
-	_, err := s.db.Exec("DELETE FROM hosts WHERE id=?", id)
+	if _, err := s.db.Exec("DELETE FROM hosts WHERE id=?", id); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("DELETE FROM host_fingerprints WHERE host_id=?", id)
 	return err
 }
 
+// SetHostPinned pins or unpins a host. It bumps updated_at so the change syncs.
+func (s *Store) SetHostPinned(id int, pinned bool) error {
+	_, err := s.db.Exec("UPDATE hosts SET pinned=?, updated_at=? WHERE id=?", pinned, time.Now(), id)
+	return err
+}
+
+// BulkDeleteHosts deletes all hosts in ids with a single statement.
+func (s *Store) BulkDeleteHosts(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders, args := idPlaceholders(ids)
+	if _, err := s.db.Exec("DELETE FROM hosts WHERE id IN ("+placeholders+")", args...); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("DELETE FROM host_fingerprints WHERE host_id IN ("+placeholders+")", args...)
+	return err
+}
+
+// MoveHostsToGroup assigns all hosts in ids to groupName ("" = ungrouped).
+func (s *Store) MoveHostsToGroup(ids []int, groupName string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.UpsertGroup(groupName); err != nil {
+		return err
+	}
+	placeholders, args := idPlaceholders(ids)
+	args = append([]interface{}{normalizeGroupName(groupName), time.Now()}, args...)
+	_, err := s.db.Exec("UPDATE hosts SET group_name=?, updated_at=? WHERE id IN ("+placeholders+")", args...)
+	return err
+}
+
+func idPlaceholders(ids []int) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","), args
+}
+
 // CreateHostWithID creates a host with a specific ID (used for sync import).
 // The keyData should already be encrypted.
 func (s *Store) CreateHostWithID(h *HostModel, encryptedKeyData string) error {
@@ -837,10 +1298,32 @@ func (s *Store) CreateHostWithID(h *HostModel, encryptedKeyData string) error {
 	if err != nil {
 		return err
 	}
+	optsValue, err := EncodeSSHOptions(h.SSHOptions)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO hosts (id, label, group_name, tags, hostname, username, port, key_data, key_type, recording, sync_exclude, ssh_options, pinned, mac_address, broadcast_addr, keepalive_seconds, forward_x11, proxy_hosts, term_override, icon, identities_only, color_override, created_at, updated_at, last_connected)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, h.ID, h.Label, normalizeGroupName(h.GroupName), tagsValue, h.Hostname, h.Username, h.Port, encryptedKeyData, h.KeyType, normalizeRecording(h.Recording), h.SyncExclude, optsValue, h.Pinned, h.MACAddress, h.BroadcastAddr, h.KeepAliveSeconds, h.ForwardX11, EncodeProxyHosts(h.ProxyHosts), strings.TrimSpace(h.TermOverride), strings.TrimSpace(h.Icon), normalizeIdentitiesOnly(h.IdentitiesOnly), normalizeColor(h.ColorOverride), h.CreatedAt, h.UpdatedAt, h.LastConnected)
+	return err
+}
+
+// RestoreHost writes a snapshot taken with GetHostByID back over the host,
+// including its already-encrypted key data. Used to undo edits.
+func (s *Store) RestoreHost(h *HostModel) error {
+	tagsValue, err := EncodeTags(h.Tags)
+	if err != nil {
+		return err
+	}
+	optsValue, err := EncodeSSHOptions(h.SSHOptions)
+	if err != nil {
+		return err
+	}
 	_, err = s.db.Exec(`
-		INSERT INTO hosts (id, label, group_name, tags, hostname, username, port, key_data, key_type, created_at, updated_at, last_connected)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, h.ID, h.Label, normalizeGroupName(h.GroupName), tagsValue, h.Hostname, h.Username, h.Port, encryptedKeyData, h.KeyType, h.CreatedAt, h.UpdatedAt, h.LastConnected)
+		UPDATE hosts SET label=?, group_name=?, tags=?, hostname=?, username=?, port=?, key_type=?, key_data=?, recording=?, sync_exclude=?, ssh_options=?, pinned=?, mac_address=?, broadcast_addr=?, keepalive_seconds=?, forward_x11=?, proxy_hosts=?, term_override=?, icon=?, identities_only=?, color_override=?, updated_at=?
+		WHERE id=?
+	`, h.Label, normalizeGroupName(h.GroupName), tagsValue, h.Hostname, h.Username, h.Port, h.KeyType, h.KeyData, normalizeRecording(h.Recording), h.SyncExclude, optsValue, h.Pinned, h.MACAddress, h.BroadcastAddr, h.KeepAliveSeconds, h.ForwardX11, EncodeProxyHosts(h.ProxyHosts), strings.TrimSpace(h.TermOverride), strings.TrimSpace(h.Icon), normalizeIdentitiesOnly(h.IdentitiesOnly), normalizeColor(h.ColorOverride), time.Now(), h.ID)
 	return err
 }
 
@@ -851,13 +1334,145 @@ func (s *Store) UpdateHostFromSync(h *HostModel, encryptedKeyData string, update
 	if err != nil {
 		return err
 	}
+	optsValue, err := EncodeSSHOptions(h.SSHOptions)
+	if err != nil {
+		return err
+	}
 	_, err = s.db.Exec(`
-		UPDATE hosts SET label=?, group_name=?, tags=?, hostname=?, username=?, port=?, key_type=?, key_data=?, updated_at=?, last_connected=?
+		UPDATE hosts SET label=?, group_name=?, tags=?, hostname=?, username=?, port=?, key_type=?, key_data=?, recording=?, ssh_options=?, pinned=?, mac_address=?, broadcast_addr=?, keepalive_seconds=?, forward_x11=?, proxy_hosts=?, term_override=?, icon=?, identities_only=?, color_override=?, updated_at=?, last_connected=?
 		WHERE id=?
-	`, h.Label, normalizeGroupName(h.GroupName), tagsValue, h.Hostname, h.Username, h.Port, h.KeyType, encryptedKeyData, updatedAt, h.LastConnected, h.ID)
+	`, h.Label, normalizeGroupName(h.GroupName), tagsValue, h.Hostname, h.Username, h.Port, h.KeyType, encryptedKeyData, normalizeRecording(h.Recording), optsValue, h.Pinned, h.MACAddress, h.BroadcastAddr, h.KeepAliveSeconds, h.ForwardX11, EncodeProxyHosts(h.ProxyHosts), strings.TrimSpace(h.TermOverride), strings.TrimSpace(h.Icon), normalizeIdentitiesOnly(h.IdentitiesOnly), normalizeColor(h.ColorOverride), updatedAt, h.LastConnected, h.ID)
+	return err
+}
+
+// normalizeRecording maps a per-host recording override to "", "on" or "off".
+func normalizeRecording(v string) string {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "on":
+		return "on"
+	case "off":
+		return "off"
+	default:
+		return ""
+	}
+}
+
+// normalizeIdentitiesOnly accepts the same "on" / "off" / "" values as the
+// recording override.
+func normalizeIdentitiesOnly(v string) string {
+	return normalizeRecording(v)
+}
+
+// normalizeColor trims and lower-cases a hex color like "#89B4FA".
+func normalizeColor(v string) string {
+	return strings.ToLower(strings.TrimSpace(v))
+}
+
+// AddSessionRecording records that a session for hostID is being captured to path.
+func (s *Store) AddSessionRecording(hostID int, path string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO session_recordings (host_id, path, started_at)
+		VALUES (?, ?, ?)
+	`, hostID, path, time.Now())
 	return err
 }
 
+// LatestSessionRecording returns the most recent recording for hostID.
+// Returns sql.ErrNoRows when the host has none.
+func (s *Store) LatestSessionRecording(hostID int) (*SessionRecording, error) {
+	var rec SessionRecording
+	var startedAtStr string
+	err := s.db.QueryRow(`
+		SELECT id, host_id, path, started_at
+		FROM session_recordings
+		WHERE host_id = ?
+		ORDER BY started_at DESC, id DESC
+		LIMIT 1
+	`, hostID).Scan(&rec.ID, &rec.HostID, &rec.Path, &startedAtStr)
+	if err != nil {
+		return nil, err
+	}
+	rec.StartedAt = parseTimestamp(startedAtStr)
+	return &rec, nil
+}
+
+// UpsertHostFingerprint records the host key fingerprint seen for id. When it
+// differs from the stored one, the last accepted value is kept in Previous
+// until the user accepts the new key; a scan that sees the accepted key again
+// clears it.
+func (s *Store) UpsertHostFingerprint(id int, fingerprint string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO host_fingerprints (host_id, fingerprint, previous, updated_at)
+		VALUES (?, ?, '', ?)
+		ON CONFLICT(host_id) DO UPDATE SET
+			previous = CASE
+				WHEN previous = excluded.fingerprint THEN ''
+				WHEN previous = '' AND fingerprint != excluded.fingerprint THEN fingerprint
+				ELSE previous
+			END,
+			fingerprint = excluded.fingerprint,
+			updated_at = excluded.updated_at
+	`, id, fingerprint, time.Now())
+	return err
+}
+
+// AcceptHostFingerprint trusts the latest fingerprint seen for id, clearing
+// the changed-key warning.
+func (s *Store) AcceptHostFingerprint(id int) error {
+	_, err := s.db.Exec(`UPDATE host_fingerprints SET previous='' WHERE host_id=?`, id)
+	return err
+}
+
+// GetHostFingerprints returns the stored fingerprints keyed by host ID.
+func (s *Store) GetHostFingerprints() (map[int]HostFingerprint, error) {
+	rows, err := s.db.Query(`SELECT host_id, fingerprint, previous, updated_at FROM host_fingerprints`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[int]HostFingerprint{}
+	for rows.Next() {
+		var f HostFingerprint
+		var updatedAtStr string
+		if err := rows.Scan(&f.HostID, &f.Fingerprint, &f.Previous, &updatedAtStr); err != nil {
+			return nil, err
+		}
+		f.UpdatedAt = parseTimestamp(updatedAtStr)
+		out[f.HostID] = f
+	}
+	return out, rows.Err()
+}
+
+// SetHostBanner caches the banner fetched for id. It leaves updated_at alone
+// so a refresh does not count as an edit for sync.
+func (s *Store) SetHostBanner(id int, banner string) error {
+	_, err := s.db.Exec(`UPDATE hosts SET banner=?, banner_checked_at=? WHERE id=?`, banner, time.Now(), id)
+	return err
+}
+
+// GetHostBanners returns the cached banners of hosts that have been checked,
+// keyed by host ID.
+func (s *Store) GetHostBanners() (map[int]HostBanner, error) {
+	rows, err := s.db.Query(`SELECT id, banner, banner_checked_at FROM hosts WHERE banner_checked_at IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[int]HostBanner{}
+	for rows.Next() {
+		var b HostBanner
+		var checkedAtStr string
+		if err := rows.Scan(&b.HostID, &b.Banner, &checkedAtStr); err != nil {
+			return nil, err
+		}
+		b.CheckedAt = parseTimestamp(checkedAtStr)
+		out[b.HostID] = b
+	}
+	return out, rows.Err()
+}
+
 func normalizeGroupName(name string) string {
 	name = strings.TrimSpace(name)
 	return name
@@ -900,6 +1515,8 @@ func (s *Store) GetGroupsForSync(retention time.Duration) ([]GroupModel, error)
 	cutoff := time.Now().Add(-retention)
 	rows, err := s.db.Query(`
 		SELECT name,
+		       COALESCE(parent_group, ''),
+		       COALESCE(color, ''),
 		       created_at,
 		       COALESCE(updated_at, created_at),
 		       deleted_at
@@ -917,10 +1534,11 @@ func (s *Store) GetGroupsForSync(retention time.Duration) ([]GroupModel, error)
 		var gm GroupModel
 		var createdStr, updatedStr string
 		var deletedStr sql.NullString
-		if err := rows.Scan(&gm.Name, &createdStr, &updatedStr, &deletedStr); err != nil {
+		if err := rows.Scan(&gm.Name, &gm.ParentGroup, &gm.Color, &createdStr, &updatedStr, &deletedStr); err != nil {
 			return nil, err
 		}
 		gm.Name = normalizeGroupName(gm.Name)
+		gm.ParentGroup = normalizeGroupName(gm.ParentGroup)
 		gm.CreatedAt = parseTimestamp(createdStr)
 		gm.UpdatedAt = parseTimestamp(updatedStr)
 		if deletedStr.Valid && strings.TrimSpace(deletedStr.String) != "" {
@@ -957,8 +1575,164 @@ func (s *Store) UpsertGroup(name string) error {
 	return err
 }
 
+// GetGroupParents maps each non-deleted subgroup to its parent group.
+func (s *Store) GetGroupParents() (map[string]string, error) {
+	rows, err := s.db.Query(`
+		SELECT name, parent_group
+		FROM groups
+		WHERE deleted_at IS NULL AND COALESCE(parent_group, '') != ''
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]string)
+	for rows.Next() {
+		var name, parent string
+		if err := rows.Scan(&name, &parent); err != nil {
+			return nil, err
+		}
+		out[normalizeGroupName(name)] = normalizeGroupName(parent)
+	}
+	return out, rows.Err()
+}
+
+// GetGroupColors maps each non-deleted group that has a color to it.
+func (s *Store) GetGroupColors() (map[string]string, error) {
+	rows, err := s.db.Query(`
+		SELECT name, color
+		FROM groups
+		WHERE deleted_at IS NULL AND COALESCE(color, '') != ''
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]string)
+	for rows.Next() {
+		var name, color string
+		if err := rows.Scan(&name, &color); err != nil {
+			return nil, err
+		}
+		out[normalizeGroupName(name)] = normalizeColor(color)
+	}
+	return out, rows.Err()
+}
+
+// SetGroupColor sets a group's list color; "" clears it.
+func (s *Store) SetGroupColor(name, color string) error {
+	name = normalizeGroupName(name)
+	if name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+	res, err := s.db.Exec(`
+		UPDATE groups
+		SET color=?, updated_at=?
+		WHERE name = ? AND deleted_at IS NULL
+	`, normalizeColor(color), time.Now(), name)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("group %q does not exist", name)
+	}
+	return nil
+}
+
+// UpsertSubgroup creates or revives name as a subgroup of parent. An empty
+// parent makes it a top-level group. A group cannot be moved inside itself
+// or one of its own subgroups.
+func (s *Store) UpsertSubgroup(name, parent string) error {
+	name = normalizeGroupName(name)
+	parent = normalizeGroupName(parent)
+	if name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if parent != "" {
+		var exists int
+		if err := tx.QueryRow(`
+			SELECT COUNT(*) FROM groups WHERE name = ? AND deleted_at IS NULL
+		`, parent).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return fmt.Errorf("parent group %q does not exist", parent)
+		}
+		descendants, err := groupDescendants(tx, name)
+		if err != nil {
+			return err
+		}
+		for _, d := range append(descendants, name) {
+			if strings.EqualFold(d, parent) {
+				return fmt.Errorf("group %q cannot be nested inside itself", name)
+			}
+		}
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`
+		INSERT INTO groups (name, parent_group, created_at, updated_at, deleted_at)
+		VALUES (?, ?, ?, ?, NULL)
+		ON CONFLICT(name) DO UPDATE SET
+			parent_group=excluded.parent_group,
+			updated_at=excluded.updated_at,
+			deleted_at=NULL
+	`, name, parent, now, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// groupDescendants returns every non-deleted group nested under name, at
+// any depth.
+func groupDescendants(tx *sql.Tx, name string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{strings.ToLower(name): true}
+	queue := []string{name}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		rows, err := tx.Query(`
+			SELECT name FROM groups
+			WHERE deleted_at IS NULL AND LOWER(parent_group) = LOWER(?)
+		`, cur)
+		if err != nil {
+			return nil, err
+		}
+		var children []string
+		for rows.Next() {
+			var child string
+			if err := rows.Scan(&child); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			children = append(children, child)
+		}
+		rows.Close()
+		for _, child := range children {
+			if seen[strings.ToLower(child)] {
+				continue
+			}
+			seen[strings.ToLower(child)] = true
+			out = append(out, child)
+			queue = append(queue, child)
+		}
+	}
+	return out, nil
+}
+
 // RenameGroup renames a group and moves any hosts assigned to it.
-// The old name is tombstoned so the deletion propagates via sync.
+// The old name is tombstoned so the deletion propagates via sync, and its
+// subgroups are re-parented to the new name.
 func (s *Store) RenameGroup(oldName, newName string) error {
 	oldName = normalizeGroupName(oldName)
 	newName = normalizeGroupName(newName)
@@ -977,14 +1751,31 @@ func (s *Store) RenameGroup(oldName, newName string) error {
 	defer func() { _ = tx.Rollback() }()
 
 	now := time.Now()
-	// Ensure new group exists and is active.
+	var parent, color string
+	if err := tx.QueryRow(`
+		SELECT COALESCE(parent_group, ''), COALESCE(color, '') FROM groups WHERE name = ?
+	`, oldName).Scan(&parent, &color); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	// Ensure new group exists and is active, in the old group's place.
 	if _, err := tx.Exec(`
-		INSERT INTO groups (name, created_at, updated_at, deleted_at)
-		VALUES (?, ?, ?, NULL)
+		INSERT INTO groups (name, parent_group, color, created_at, updated_at, deleted_at)
+		VALUES (?, ?, ?, ?, ?, NULL)
 		ON CONFLICT(name) DO UPDATE SET
+			parent_group=excluded.parent_group,
+			color=excluded.color,
 			updated_at=excluded.updated_at,
 			deleted_at=NULL
-	`, newName, now, now); err != nil {
+	`, newName, parent, color, now, now); err != nil {
+		return err
+	}
+
+	// Re-parent subgroups.
+	if _, err := tx.Exec(`
+		UPDATE groups
+		SET parent_group=?, updated_at=?
+		WHERE deleted_at IS NULL AND LOWER(parent_group) = LOWER(?)
+	`, newName, now, oldName); err != nil {
 		return err
 	}
 
@@ -1011,7 +1802,8 @@ func (s *Store) RenameGroup(oldName, newName string) error {
 	return tx.Commit()
 }
 
-// DeleteGroup tombstones a group and ungroups all hosts assigned to it.
+// DeleteGroup tombstones a group and its subgroups, and ungroups all hosts
+// assigned to any of them.
 func (s *Store) DeleteGroup(name string) error {
 	name = normalizeGroupName(name)
 	if name == "" {
@@ -1024,23 +1816,29 @@ func (s *Store) DeleteGroup(name string) error {
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	now := time.Now()
-	if _, err := tx.Exec(`
-		UPDATE hosts
-		SET group_name=NULL, updated_at=?
-		WHERE LOWER(COALESCE(group_name, '')) = LOWER(?)
-	`, now, name); err != nil {
+	descendants, err := groupDescendants(tx, name)
+	if err != nil {
 		return err
 	}
+	now := time.Now()
+	for _, g := range append([]string{name}, descendants...) {
+		if _, err := tx.Exec(`
+			UPDATE hosts
+			SET group_name=NULL, updated_at=?
+			WHERE LOWER(COALESCE(group_name, '')) = LOWER(?)
+		`, now, g); err != nil {
+			return err
+		}
 
-	if _, err := tx.Exec(`
-		INSERT INTO groups (name, created_at, updated_at, deleted_at)
-		VALUES (?, ?, ?, ?)
-		ON CONFLICT(name) DO UPDATE SET
-			updated_at=excluded.updated_at,
-			deleted_at=excluded.deleted_at
-	`, name, now, now, now); err != nil {
-		return err
+		if _, err := tx.Exec(`
+			INSERT INTO groups (name, created_at, updated_at, deleted_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(name) DO UPDATE SET
+				updated_at=excluded.updated_at,
+				deleted_at=excluded.deleted_at
+		`, g, now, now, now); err != nil {
+			return err
+		}
 	}
 
 	return tx.Commit()
@@ -1058,8 +1856,9 @@ func (s *Store) PurgeDeletedGroups(olderThan time.Duration) error {
 
 // UpsertGroupFromSync applies group state from a sync payload, preserving timestamps.
 // If deletedAt is non-nil, hosts assigned to the group are ungrouped (hosts updated_at is set to now).
-func (s *Store) UpsertGroupFromSync(name string, createdAt, updatedAt time.Time, deletedAt *time.Time) error {
+func (s *Store) UpsertGroupFromSync(name, parentGroup, color string, createdAt, updatedAt time.Time, deletedAt *time.Time) error {
 	name = normalizeGroupName(name)
+	parentGroup = normalizeGroupName(parentGroup)
 	if name == "" {
 		return nil
 	}
@@ -1071,12 +1870,14 @@ func (s *Store) UpsertGroupFromSync(name string, createdAt, updatedAt time.Time,
 	defer func() { _ = tx.Rollback() }()
 
 	_, err = tx.Exec(`
-		INSERT INTO groups (name, created_at, updated_at, deleted_at)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO groups (name, parent_group, color, created_at, updated_at, deleted_at)
+		VALUES (?, ?, ?, ?, ?, ?)
 		ON CONFLICT(name) DO UPDATE SET
+			parent_group=excluded.parent_group,
+			color=excluded.color,
 			updated_at=excluded.updated_at,
 			deleted_at=excluded.deleted_at
-	`, name, createdAt, updatedAt, deletedAt)
+	`, name, parentGroup, normalizeColor(color), createdAt, updatedAt, deletedAt)
 	if err != nil {
 		return err
 	}
@@ -1,10 +1,15 @@
 package db_test
 
 import (
+	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/Vansh-Raja/SSHThing/internal/crypto"
 	"github.com/Vansh-Raja/SSHThing/internal/db"
 )
 
@@ -197,5 +202,763 @@ func TestDatabaseOperations(t *testing.T) {
 		}
 	})
 
+	// Test 7: Session recordings (per-host override + latest lookup)
+	t.Run("SessionRecordings", func(t *testing.T) {
+		store, err := db.Init("testpassword123")
+		if err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		defer store.Close()
+
+		host := &db.HostModel{
+			Label:     "rec",
+			Hostname:  "rec.example.com",
+			Username:  "ubuntu",
+			Port:      22,
+			KeyType:   "password",
+			Recording: "on",
+		}
+		if err := store.CreateHost(host, ""); err != nil {
+			t.Fatalf("CreateHost failed: %v", err)
+		}
+
+		hosts, err := store.GetHosts()
+		if err != nil {
+			t.Fatalf("GetHosts failed: %v", err)
+		}
+		var hostID int
+		for _, h := range hosts {
+			if h.Hostname == "rec.example.com" {
+				hostID = h.ID
+				if h.Recording != "on" {
+					t.Fatalf("expected recording override 'on', got %q", h.Recording)
+				}
+			}
+		}
+		if hostID == 0 {
+			t.Fatalf("expected to find inserted host")
+		}
+
+		if _, err := store.LatestSessionRecording(hostID); err != sql.ErrNoRows {
+			t.Fatalf("expected sql.ErrNoRows before any recording, got %v", err)
+		}
+		if err := store.AddSessionRecording(hostID, "/tmp/first.txt"); err != nil {
+			t.Fatalf("AddSessionRecording failed: %v", err)
+		}
+		if err := store.AddSessionRecording(hostID, "/tmp/second.txt"); err != nil {
+			t.Fatalf("AddSessionRecording failed: %v", err)
+		}
+		rec, err := store.LatestSessionRecording(hostID)
+		if err != nil {
+			t.Fatalf("LatestSessionRecording failed: %v", err)
+		}
+		if rec.Path != "/tmp/second.txt" {
+			t.Fatalf("expected latest recording path, got %q", rec.Path)
+		}
+	})
+
+	t.Run("MountStatesPerRemotePath", func(t *testing.T) {
+		store, err := db.Init("testpassword123")
+		if err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		defer store.Close()
+		defer store.DeleteAllMountStates()
+
+		if err := store.UpsertMountState(7, "/mnt/a", "", false); err != nil {
+			t.Fatalf("UpsertMountState failed: %v", err)
+		}
+		if err := store.UpsertMountState(7, "/mnt/a_srv", "/srv", true); err != nil {
+			t.Fatalf("UpsertMountState failed: %v", err)
+		}
+		states, err := store.GetMountStates()
+		if err != nil {
+			t.Fatalf("GetMountStates failed: %v", err)
+		}
+		if len(states) != 2 {
+			t.Fatalf("expected 2 mount states for one host, got %d", len(states))
+		}
+		for _, st := range states {
+			if st.ReadOnly != (st.RemotePath == "/srv") {
+				t.Fatalf("unexpected read-only flag for %q: %v", st.RemotePath, st.ReadOnly)
+			}
+		}
+
+		if err := store.DeleteMountState(7, "/srv"); err != nil {
+			t.Fatalf("DeleteMountState failed: %v", err)
+		}
+		states, err = store.GetMountStates()
+		if err != nil {
+			t.Fatalf("GetMountStates failed: %v", err)
+		}
+		if len(states) != 1 || states[0].RemotePath != "" {
+			t.Fatalf("expected only the home mount to remain, got %+v", states)
+		}
+	})
+
+	t.Run("BulkHostOperations", func(t *testing.T) {
+		store, err := db.Init("testpassword123")
+		if err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		defer store.Close()
+
+		for _, name := range []string{"bulk-a", "bulk-b", "bulk-c"} {
+			h := &db.HostModel{Label: name, Hostname: name + ".example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+			if err := store.CreateHost(h, ""); err != nil {
+				t.Fatalf("CreateHost failed: %v", err)
+			}
+		}
+		hosts, err := store.GetHosts()
+		if err != nil {
+			t.Fatalf("GetHosts failed: %v", err)
+		}
+		ids := map[string]int{}
+		for _, h := range hosts {
+			ids[h.Label] = h.ID
+		}
+
+		if err := store.MoveHostsToGroup([]int{ids["bulk-a"], ids["bulk-b"]}, "Bulk"); err != nil {
+			t.Fatalf("MoveHostsToGroup failed: %v", err)
+		}
+		moved, err := store.GetHostByID(ids["bulk-b"])
+		if err != nil || moved.GroupName != "Bulk" {
+			t.Fatalf("expected bulk-b in group Bulk, got %+v (err %v)", moved, err)
+		}
+
+		if err := store.BulkDeleteHosts([]int{ids["bulk-a"], ids["bulk-b"]}); err != nil {
+			t.Fatalf("BulkDeleteHosts failed: %v", err)
+		}
+		hosts, err = store.GetHosts()
+		if err != nil {
+			t.Fatalf("GetHosts failed: %v", err)
+		}
+		for _, h := range hosts {
+			if h.Label == "bulk-a" || h.Label == "bulk-b" {
+				t.Fatalf("expected %s to be deleted", h.Label)
+			}
+		}
+		if _, err := store.GetHostByID(ids["bulk-c"]); err != nil {
+			t.Fatalf("expected bulk-c to survive: %v", err)
+		}
+		fmt.Println("✓ Bulk delete and move work")
+	})
+
+	t.Run("RecentHosts", func(t *testing.T) {
+		store, err := db.Init("testpassword123")
+		if err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		defer store.Close()
+
+		for _, name := range []string{"recent-a", "recent-b"} {
+			h := &db.HostModel{Label: name, Hostname: name + ".example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+			if err := store.CreateHost(h, ""); err != nil {
+				t.Fatalf("CreateHost failed: %v", err)
+			}
+		}
+		hosts, err := store.GetHosts()
+		if err != nil {
+			t.Fatalf("GetHosts failed: %v", err)
+		}
+		var id int
+		for _, h := range hosts {
+			if h.Label == "recent-b" {
+				id = h.ID
+			}
+		}
+		if err := store.UpdateLastConnected(id); err != nil {
+			t.Fatalf("UpdateLastConnected failed: %v", err)
+		}
+
+		recent, err := store.GetRecentHosts(10)
+		if err != nil {
+			t.Fatalf("GetRecentHosts failed: %v", err)
+		}
+		for _, h := range recent {
+			if h.Label == "recent-a" {
+				t.Fatalf("never-connected host must not be listed as recent")
+			}
+		}
+		if len(recent) == 0 || recent[0].ID != id {
+			t.Fatalf("expected recent-b first, got %+v", recent)
+		}
+		fmt.Println("✓ Recent hosts work")
+	})
+
+	t.Run("HostByLabel", func(t *testing.T) {
+		store, err := db.Init("testpassword123")
+		if err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		defer store.Close()
+
+		h := &db.HostModel{Label: "Lookup-Web", Hostname: "lookup.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+		if err := store.CreateHost(h, ""); err != nil {
+			t.Fatalf("CreateHost failed: %v", err)
+		}
+
+		byLabel, err := store.GetHostByLabel("lookup-web")
+		if err != nil || byLabel.ID != h.ID {
+			t.Fatalf("expected label match for host %d, got %+v (%v)", h.ID, byLabel, err)
+		}
+		byHost, err := store.GetHostByLabel("LOOKUP.example.com")
+		if err != nil || byHost.ID != h.ID {
+			t.Fatalf("expected hostname match for host %d, got %+v (%v)", h.ID, byHost, err)
+		}
+		if _, err := store.GetHostByLabel("no-such-host"); err == nil {
+			t.Fatalf("expected error for unknown label")
+		}
+
+		dup := &db.HostModel{Label: "lookup-web", Hostname: "lookup2.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+		if err := store.CreateHost(dup, ""); err != nil {
+			t.Fatalf("CreateHost failed: %v", err)
+		}
+		if _, err := store.GetHostByLabel("lookup-web"); err == nil {
+			t.Fatalf("expected ambiguous label to be an error")
+		}
+		fmt.Println("✓ Host lookup by label works")
+	})
+
+	t.Run("HostFingerprints", func(t *testing.T) {
+		store, err := db.Init("testpassword123")
+		if err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		defer store.Close()
+
+		h := &db.HostModel{Label: "fp", Hostname: "fp.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+		if err := store.CreateHost(h, ""); err != nil {
+			t.Fatalf("CreateHost failed: %v", err)
+		}
+
+		// An empty fp accepts the current key instead of scanning.
+		steps := []struct {
+			fp      string
+			changed bool
+		}{
+			{"SHA256:first", false},
+			{"SHA256:first", false},
+			{"SHA256:second", true},
+			{"SHA256:second", true}, // a second scan keeps the warning
+			{"SHA256:third", true},
+			{"SHA256:first", false}, // back to the accepted key
+			{"SHA256:second", true},
+			{"", false},
+			{"SHA256:second", false},
+			{"SHA256:first", true},
+		}
+		for i, step := range steps {
+			if step.fp == "" {
+				if err := store.AcceptHostFingerprint(h.ID); err != nil {
+					t.Fatalf("AcceptHostFingerprint failed: %v", err)
+				}
+				step.fp = steps[i-1].fp
+			} else if err := store.UpsertHostFingerprint(h.ID, step.fp); err != nil {
+				t.Fatalf("UpsertHostFingerprint failed: %v", err)
+			}
+			fps, err := store.GetHostFingerprints()
+			if err != nil {
+				t.Fatalf("GetHostFingerprints failed: %v", err)
+			}
+			got := fps[h.ID]
+			if got.Fingerprint != step.fp || got.Changed() != step.changed {
+				t.Fatalf("step %d: expected %s changed=%v, got %+v", i, step.fp, step.changed, got)
+			}
+		}
+
+		if err := store.DeleteHost(h.ID); err != nil {
+			t.Fatalf("DeleteHost failed: %v", err)
+		}
+		fps, _ := store.GetHostFingerprints()
+		if _, ok := fps[h.ID]; ok {
+			t.Fatalf("expected fingerprint to be removed with the host")
+		}
+		fmt.Println("✓ Host fingerprint tracking works")
+	})
+
 	fmt.Println("\n✓ All tests passed!")
 }
+
+func TestHostKeepAliveRoundTrip(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	h := &db.HostModel{Label: "vpn", Hostname: "10.8.0.5", Username: "ops", Port: 22, KeyType: "password", KeepAliveSeconds: 15}
+	if err := store.CreateHost(h, ""); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	got, err := store.GetHostByID(h.ID)
+	if err != nil || got.KeepAliveSeconds != 15 {
+		t.Fatalf("expected keepalive 15, got %+v (%v)", got, err)
+	}
+
+	got.KeepAliveSeconds = 0
+	if err := store.UpdateHost(got); err != nil {
+		t.Fatalf("UpdateHost failed: %v", err)
+	}
+	hosts, err := store.GetHosts()
+	if err != nil || len(hosts) != 1 || hosts[0].KeepAliveSeconds != 0 {
+		t.Fatalf("expected keepalive cleared, got %+v (%v)", hosts, err)
+	}
+}
+
+func TestHostForwardX11RoundTrip(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	h := &db.HostModel{Label: "desk", Hostname: "10.0.0.9", Username: "me", Port: 22, KeyType: "password", ForwardX11: true}
+	if err := store.CreateHost(h, ""); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	got, err := store.GetHostByID(h.ID)
+	if err != nil || !got.ForwardX11 {
+		t.Fatalf("expected x11 forwarding on, got %+v (%v)", got, err)
+	}
+
+	got.ForwardX11 = false
+	if err := store.UpdateHost(got); err != nil {
+		t.Fatalf("UpdateHost failed: %v", err)
+	}
+	hosts, err := store.GetHosts()
+	if err != nil || len(hosts) != 1 || hosts[0].ForwardX11 {
+		t.Fatalf("expected x11 forwarding off, got %+v (%v)", hosts, err)
+	}
+}
+
+func TestHostProxyHostsRoundTrip(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	h := &db.HostModel{Label: "app", Hostname: "10.0.0.5", Username: "app", Port: 22, KeyType: "password", ProxyHosts: []int{7, 3}}
+	if err := store.CreateHost(h, ""); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	got, err := store.GetHostByID(h.ID)
+	if err != nil || len(got.ProxyHosts) != 2 || got.ProxyHosts[0] != 7 || got.ProxyHosts[1] != 3 {
+		t.Fatalf("expected jump chain [7 3], got %+v (%v)", got, err)
+	}
+
+	got.ProxyHosts = nil
+	if err := store.UpdateHost(got); err != nil {
+		t.Fatalf("UpdateHost failed: %v", err)
+	}
+	hosts, err := store.GetHosts()
+	if err != nil || len(hosts) != 1 || len(hosts[0].ProxyHosts) != 0 {
+		t.Fatalf("expected jump chain cleared, got %+v (%v)", hosts, err)
+	}
+}
+
+func TestHostTermOverrideRoundTrip(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	h := &db.HostModel{Label: "router", Hostname: "10.0.0.1", Username: "admin", Port: 22, KeyType: "password", TermOverride: " vt100 "}
+	if err := store.CreateHost(h, ""); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	got, err := store.GetHostByID(h.ID)
+	if err != nil || got.TermOverride != "vt100" {
+		t.Fatalf("expected TERM override vt100, got %+v (%v)", got, err)
+	}
+
+	got.TermOverride = ""
+	if err := store.UpdateHost(got); err != nil {
+		t.Fatalf("UpdateHost failed: %v", err)
+	}
+	hosts, err := store.GetHosts()
+	if err != nil || len(hosts) != 1 || hosts[0].TermOverride != "" {
+		t.Fatalf("expected TERM override cleared, got %+v (%v)", hosts, err)
+	}
+}
+
+func TestHostIconRoundTrip(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	h := &db.HostModel{Label: "vault", Hostname: "10.0.0.3", Username: "admin", Port: 22, KeyType: "password", Icon: " \U0001F512 "}
+	if err := store.CreateHost(h, ""); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	got, err := store.GetHostByID(h.ID)
+	if err != nil || got.Icon != "\U0001F512" {
+		t.Fatalf("expected the lock icon, got %+v (%v)", got, err)
+	}
+
+	got.Icon = ""
+	if err := store.UpdateHost(got); err != nil {
+		t.Fatalf("UpdateHost failed: %v", err)
+	}
+	hosts, err := store.GetHosts()
+	if err != nil || len(hosts) != 1 || hosts[0].Icon != "" {
+		t.Fatalf("expected the icon cleared, got %+v (%v)", hosts, err)
+	}
+}
+
+// openRawDB opens the SQLCipher database at path with the key Init derives
+// from password, bypassing Init's schema setup.
+func openRawDB(t *testing.T, path, password string) *sql.DB {
+	t.Helper()
+	key, _, err := crypto.DeriveKey(password, []byte("ssh-manager-sqlcipher-salt-v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dsn := fmt.Sprintf("file:%s?mode=rwc&_pragma_key=x'%s'&_pragma_cipher_page_size=4096", path, hex.EncodeToString(key))
+	raw, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func tableColumns(t *testing.T, raw *sql.DB, table string) map[string]bool {
+	t.Helper()
+	rows, err := raw.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		t.Fatalf("table_info(%s) failed: %v", table, err)
+	}
+	defer rows.Close()
+	cols := map[string]bool{}
+	for rows.Next() {
+		var cid, notnull, pk int
+		var name, ctype string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
+			t.Fatal(err)
+		}
+		cols[name] = true
+	}
+	return cols
+}
+
+func TestDatabaseMigrations(t *testing.T) {
+	const password = "testpassword123"
+	current := map[string][]string{
+		"hosts": {"id", "label", "group_name", "tags", "hostname", "username", "port", "key_data", "key_type",
+			"recording", "sync_exclude", "ssh_options", "pinned", "mac_address", "broadcast_addr",
+			"keepalive_seconds", "forward_x11", "proxy_hosts", "term_override", "icon", "identities_only", "color_override", "banner", "banner_checked_at",
+			"created_at", "updated_at", "last_connected"},
+		"groups":             {"name", "parent_group", "color", "created_at", "updated_at", "deleted_at"},
+		"config":             {"key", "value"},
+		"mounts":             {"host_id", "local_path", "remote_path", "mounted_at", "read_only"},
+		"session_recordings": {"id", "host_id", "path", "started_at"},
+		"host_fingerprints":  {"host_id", "fingerprint", "previous", "updated_at"},
+	}
+	const salt = `INSERT INTO config (key, value) VALUES ('salt', '000102030405060708090a0b0c0d0e0f');`
+
+	cases := []struct {
+		name   string
+		schema string
+		check  func(t *testing.T, store *db.Store)
+	}{
+		{
+			// The oldest databases kept the host's name in `notes` and had
+			// no config table yet.
+			name: "notes before label",
+			schema: `
+				CREATE TABLE hosts (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					notes TEXT,
+					hostname TEXT NOT NULL,
+					username TEXT NOT NULL,
+					port INTEGER DEFAULT 22,
+					key_data TEXT,
+					key_type TEXT,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					last_connected TIMESTAMP
+				);
+				INSERT INTO hosts (notes, hostname, username, port, key_type) VALUES ('web box', 'web.example.com', 'deploy', 2222, 'password');`,
+			check: func(t *testing.T, store *db.Store) {
+				hosts, err := store.GetHosts()
+				if err != nil || len(hosts) != 1 {
+					t.Fatalf("expected the host to survive, got %+v (%v)", hosts, err)
+				}
+				h := hosts[0]
+				if h.Label != "web box" || h.Hostname != "web.example.com" || h.Username != "deploy" || h.Port != 2222 {
+					t.Fatalf("expected notes to become the label, got %+v", h)
+				}
+			},
+		},
+		{
+			// Groups created before sync tombstones had no updated_at or
+			// deleted_at, and mounts were keyed by host only.
+			name: "groups before tombstones",
+			schema: `
+				CREATE TABLE hosts (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					label TEXT,
+					group_name TEXT,
+					hostname TEXT NOT NULL,
+					username TEXT NOT NULL,
+					port INTEGER DEFAULT 22,
+					key_data TEXT,
+					key_type TEXT,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					last_connected TIMESTAMP
+				);
+				CREATE TABLE groups (
+					name TEXT PRIMARY KEY COLLATE NOCASE,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				);
+				CREATE TABLE config (key TEXT PRIMARY KEY, value TEXT);
+				CREATE TABLE mounts (
+					host_id INTEGER PRIMARY KEY,
+					local_path TEXT NOT NULL,
+					remote_path TEXT,
+					mounted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				);
+				INSERT INTO hosts (label, group_name, hostname, username, key_type) VALUES ('db-1', 'Prod', 'db-1.internal', 'postgres', 'password');
+				INSERT INTO groups (name) VALUES ('Prod');
+				INSERT INTO mounts (host_id, local_path, remote_path) VALUES (1, '/mnt/db-1', NULL);
+				` + salt,
+			check: func(t *testing.T, store *db.Store) {
+				hosts, err := store.GetHosts()
+				if err != nil || len(hosts) != 1 || hosts[0].Label != "db-1" || hosts[0].GroupName != "Prod" {
+					t.Fatalf("expected the grouped host to survive, got %+v (%v)", hosts, err)
+				}
+				if groups, err := store.GetGroups(); err != nil || len(groups) != 1 || groups[0] != "Prod" {
+					t.Fatalf("expected group Prod, got %v (%v)", groups, err)
+				}
+				mounts, err := store.GetMountStates()
+				if err != nil || len(mounts) != 1 || mounts[0].LocalPath != "/mnt/db-1" || mounts[0].RemotePath != "" {
+					t.Fatalf("expected the mount to survive, got %+v (%v)", mounts, err)
+				}
+				if err := store.DeleteGroup("Prod"); err != nil {
+					t.Fatalf("DeleteGroup failed: %v", err)
+				}
+				synced, err := store.GetGroupsForSync(time.Hour)
+				if err != nil || len(synced) != 1 || synced[0].DeletedAt == nil {
+					t.Fatalf("expected a Prod tombstone, got %+v (%v)", synced, err)
+				}
+			},
+		},
+		{
+			// The first release with tags, sync timestamps and tombstones.
+			name: "tags and sync timestamps",
+			schema: `
+				CREATE TABLE hosts (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					label TEXT,
+					group_name TEXT,
+					tags TEXT,
+					hostname TEXT NOT NULL,
+					username TEXT NOT NULL,
+					port INTEGER DEFAULT 22,
+					key_data TEXT,
+					key_type TEXT,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					last_connected TIMESTAMP
+				);
+				CREATE TABLE groups (
+					name TEXT PRIMARY KEY COLLATE NOCASE,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					deleted_at TIMESTAMP
+				);
+				CREATE TABLE config (key TEXT PRIMARY KEY, value TEXT);
+				INSERT INTO hosts (label, tags, hostname, username, key_type) VALUES ('cache', '["redis","prod"]', 'cache.internal', 'ubuntu', 'password');
+				INSERT INTO groups (name, deleted_at) VALUES ('Old', CURRENT_TIMESTAMP);
+				` + salt,
+			check: func(t *testing.T, store *db.Store) {
+				hosts, err := store.GetHosts()
+				if err != nil || len(hosts) != 1 || hosts[0].Label != "cache" || len(hosts[0].Tags) != 2 {
+					t.Fatalf("expected the tagged host to survive, got %+v (%v)", hosts, err)
+				}
+				if groups, err := store.GetGroups(); err != nil || len(groups) != 0 {
+					t.Fatalf("expected the tombstoned group to stay deleted, got %v (%v)", groups, err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("SSHTHING_DATA_DIR", dir)
+			path := filepath.Join(dir, "hosts.db")
+
+			raw := openRawDB(t, path, password)
+			if _, err := raw.Exec(tc.schema); err != nil {
+				raw.Close()
+				t.Fatalf("failed to create old schema: %v", err)
+			}
+			raw.Close()
+
+			store, err := db.Init(password)
+			if err != nil {
+				t.Fatalf("Init failed to migrate: %v", err)
+			}
+			tc.check(t, store)
+			store.Close()
+
+			raw = openRawDB(t, path, password)
+			defer raw.Close()
+			for table, want := range current {
+				cols := tableColumns(t, raw, table)
+				for _, col := range want {
+					if !cols[col] {
+						t.Errorf("table %s is missing column %s after migration", table, col)
+					}
+				}
+			}
+		})
+	}
+}
+
+// getHostsBudget is the GetHosts(1000) target on reference hardware.
+const getHostsBudget = 50 * time.Millisecond
+
+func benchStore(b *testing.B, hosts int) *db.Store {
+	b.Helper()
+	b.Setenv("SSHTHING_DATA_DIR", b.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		b.Fatalf("Init failed: %v", err)
+	}
+	b.Cleanup(func() { store.Close() })
+	for i := 0; i < hosts; i++ {
+		if err := store.CreateHost(benchHost(i), "secret"); err != nil {
+			b.Fatalf("CreateHost failed: %v", err)
+		}
+	}
+	return store
+}
+
+func benchHost(i int) *db.HostModel {
+	return &db.HostModel{
+		Label:     fmt.Sprintf("host-%04d", i),
+		GroupName: fmt.Sprintf("group-%d", i%10),
+		Tags:      []string{"bench", fmt.Sprintf("rack-%d", i%4)},
+		Hostname:  fmt.Sprintf("10.0.%d.%d", i/256, i%256),
+		Username:  "ubuntu",
+		Port:      22,
+		KeyType:   "password",
+	}
+}
+
+func benchmarkGetHosts(b *testing.B, n int) {
+	store := benchStore(b, n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hosts, err := store.GetHosts()
+		if err != nil || len(hosts) != n {
+			b.Fatalf("GetHosts returned %d hosts (%v)", len(hosts), err)
+		}
+	}
+}
+
+func BenchmarkGetHosts100(b *testing.B) { benchmarkGetHosts(b, 100) }
+
+func BenchmarkGetHosts1000(b *testing.B) {
+	benchmarkGetHosts(b, 1000)
+	b.StopTimer()
+	if perOp := b.Elapsed() / time.Duration(b.N); perOp > getHostsBudget {
+		// GitHub Actions turns this line into a warning on the run.
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			fmt.Printf("::warning title=GetHosts regression::GetHosts(1000) took %s per call, budget is %s\n", perOp, getHostsBudget)
+		}
+		b.Logf("GetHosts(1000) took %s per call, budget is %s", perOp, getHostsBudget)
+	}
+}
+
+// BenchmarkGetHosts1000DuringWrites lists hosts from parallel readers
+// while one writer keeps stamping last_connected, as background pings and
+// syncs do in the TUI, once per journal mode.
+func BenchmarkGetHosts1000DuringWrites(b *testing.B) {
+	for _, wal := range []bool{false, true} {
+		b.Run(fmt.Sprintf("wal=%v", wal), func(b *testing.B) {
+			store := benchStore(b, 1000)
+			if err := store.SetPragmas(wal, 4096); err != nil {
+				b.Fatalf("SetPragmas failed: %v", err)
+			}
+			done, stopped := make(chan struct{}), make(chan struct{})
+			go func() {
+				defer close(stopped)
+				for {
+					select {
+					case <-done:
+						return
+					default:
+						_ = store.UpdateLastConnected(1)
+					}
+				}
+			}()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := store.GetHosts(); err != nil {
+						b.Errorf("GetHosts failed: %v", err)
+						return
+					}
+				}
+			})
+			b.StopTimer()
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+func BenchmarkCreateHost(b *testing.B) {
+	store := benchStore(b, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := store.CreateHost(benchHost(i), "secret"); err != nil {
+			b.Fatalf("CreateHost failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkUpdateHost(b *testing.B) {
+	store := benchStore(b, 100)
+	hosts, err := store.GetHosts()
+	if err != nil {
+		b.Fatalf("GetHosts failed: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h := hosts[i%len(hosts)]
+		h.Port = 2200 + i%100
+		if err := store.UpdateHost(&h); err != nil {
+			b.Fatalf("UpdateHost failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkDeleteHost(b *testing.B) {
+	store := benchStore(b, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Each iteration deletes a fresh host; only the delete is timed.
+		b.StopTimer()
+		h := benchHost(i)
+		if err := store.CreateHost(h, "secret"); err != nil {
+			b.Fatalf("CreateHost failed: %v", err)
+		}
+		b.StartTimer()
+		if err := store.DeleteHost(h.ID); err != nil {
+			b.Fatalf("DeleteHost failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GroupFilter is a parsed virtual group expression such as
+// "tag:production && hostname:web". Terms are ANDed with && and the
+// resulting clauses ORed with ||; && binds tighter, and there are no
+// parentheses.
+type GroupFilter struct {
+	clauses [][]groupTerm
+}
+
+type groupTerm struct {
+	field string // "tag", "group" or "hostname"
+	value string
+}
+
+// ParseGroupFilter parses a virtual group expression built from
+// tag:<name>, group:<name> and hostname:<prefix> predicates.
+func ParseGroupFilter(expr string) (GroupFilter, error) {
+	var f GroupFilter
+	for _, clause := range strings.Split(expr, "||") {
+		var terms []groupTerm
+		for _, raw := range strings.Split(clause, "&&") {
+			raw = strings.TrimSpace(raw)
+			field, value, ok := strings.Cut(raw, ":")
+			if !ok {
+				return GroupFilter{}, fmt.Errorf("expected field:value, got %q", raw)
+			}
+			field = strings.ToLower(strings.TrimSpace(field))
+			value = strings.TrimSpace(value)
+			switch field {
+			case "tag":
+				value = NormalizeTagToken(value)
+			case "group", "hostname":
+				value = strings.ToLower(value)
+			default:
+				return GroupFilter{}, fmt.Errorf("unknown field %q (use tag, group or hostname)", field)
+			}
+			if value == "" {
+				return GroupFilter{}, fmt.Errorf("empty value for %s", field)
+			}
+			terms = append(terms, groupTerm{field: field, value: value})
+		}
+		f.clauses = append(f.clauses, terms)
+	}
+	return f, nil
+}
+
+// Matches reports whether a host with the given tags, group and hostname
+// satisfies the filter.
+func (f GroupFilter) Matches(tags []string, group, hostname string) bool {
+	for _, clause := range f.clauses {
+		ok := true
+		for _, t := range clause {
+			if !t.matches(tags, group, hostname) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (t groupTerm) matches(tags []string, group, hostname string) bool {
+	switch t.field {
+	case "tag":
+		for _, tag := range NormalizeTags(tags) {
+			if tag == t.value {
+				return true
+			}
+		}
+		return false
+	case "group":
+		return strings.ToLower(strings.TrimSpace(group)) == t.value
+	case "hostname":
+		return strings.HasPrefix(strings.ToLower(hostname), t.value)
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestGroupFilterMatches(t *testing.T) {
+	f, err := db.ParseGroupFilter("tag:production && hostname:web || group:DB")
+	if err != nil {
+		t.Fatalf("ParseGroupFilter failed: %v", err)
+	}
+	cases := []struct {
+		tags     []string
+		group    string
+		hostname string
+		want     bool
+	}{
+		{[]string{"production"}, "", "web1.example.com", true},
+		{[]string{"production"}, "", "api.example.com", false},
+		{nil, "db", "pg.example.com", true},
+		{[]string{"staging"}, "", "web1.example.com", false},
+	}
+	for _, c := range cases {
+		if got := f.Matches(c.tags, c.group, c.hostname); got != c.want {
+			t.Fatalf("Matches(%v, %q, %q) = %v, want %v", c.tags, c.group, c.hostname, got, c.want)
+		}
+	}
+
+	for _, bad := range []string{"", "production", "os:linux", "tag:"} {
+		if _, err := db.ParseGroupFilter(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestGroupAndHostColors(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.UpsertGroup("Prod"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetGroupColor("Prod", " #F38BA8 "); err != nil {
+		t.Fatalf("SetGroupColor failed: %v", err)
+	}
+	if err := store.SetGroupColor("Missing", "#a6e3a1"); err == nil {
+		t.Fatalf("expected a missing group to be rejected")
+	}
+	if err := store.RenameGroup("Prod", "Production"); err != nil {
+		t.Fatalf("RenameGroup failed: %v", err)
+	}
+	colors, err := store.GetGroupColors()
+	if err != nil || len(colors) != 1 || colors["Production"] != "#f38ba8" {
+		t.Fatalf("expected the color to follow the rename, got %v (%v)", colors, err)
+	}
+
+	h := &db.HostModel{Label: "web", Hostname: "web.internal", Username: "u", Port: 22, KeyType: "password", GroupName: "Production", ColorOverride: "#89B4FA"}
+	if err := store.CreateHost(h, "pw"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetHostByID(h.ID)
+	if err != nil || got.ColorOverride != "#89b4fa" {
+		t.Fatalf("expected the host color override, got %+v (%v)", got, err)
+	}
+	got.ColorOverride = ""
+	if err := store.UpdateHost(got); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = store.GetHostByID(h.ID); got.ColorOverride != "" {
+		t.Fatalf("expected the override cleared, got %q", got.ColorOverride)
+	}
+}
+
+func TestSubgroupsCascadeRenameAndDelete(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.UpsertGroup("Eng"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertSubgroup("Backend", "Eng"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertSubgroup("DB", "Backend"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertSubgroup("Orphan", "Missing"); err == nil {
+		t.Fatalf("expected a missing parent to be rejected")
+	}
+	if err := store.UpsertSubgroup("Eng", "DB"); err == nil {
+		t.Fatalf("expected a group nested inside its own subgroup to be rejected")
+	}
+	h := &db.HostModel{Label: "pg", Hostname: "pg.internal", Username: "u", Port: 22, KeyType: "password", GroupName: "DB"}
+	if err := store.CreateHost(h, "pw"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.RenameGroup("Eng", "Engineering"); err != nil {
+		t.Fatalf("RenameGroup failed: %v", err)
+	}
+	parents, err := store.GetGroupParents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parents["Backend"] != "Engineering" || parents["DB"] != "Backend" {
+		t.Fatalf("expected subgroups to follow the rename, got %v", parents)
+	}
+
+	if err := store.DeleteGroup("Engineering"); err != nil {
+		t.Fatalf("DeleteGroup failed: %v", err)
+	}
+	groups, err := store.GetGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 0 {
+		t.Fatalf("expected subgroups to be deleted with their parent, got %v", groups)
+	}
+	got, err := store.GetHostByID(h.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GroupName != "" {
+		t.Fatalf("expected host in a deleted subgroup to be ungrouped, got %q", got.GroupName)
+	}
+
+	synced, err := store.GetGroupsForSync(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range synced {
+		if g.Name == "DB" && (g.DeletedAt == nil || g.ParentGroup != "Backend") {
+			t.Fatalf("expected DB tombstone to keep its parent for sync, got %+v", g)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Host import file formats.
+const (
+	ImportFormatSSHConfig = "ssh_config"
+	ImportFormatJSON      = "json"
+	ImportFormatCSV       = "csv"
+)
+
+// ImportConflict decides what ImportHosts does with a host whose label is
+// already in use, or that connects to the same hostname and port as a saved
+// host.
+type ImportConflict int
+
+const (
+	ImportSkip      ImportConflict = iota // keep the existing host
+	ImportOverwrite                       // update the existing host in place
+	ImportRename                          // add the import too, under a free label if needed
+)
+
+// ImportSummary counts what ImportHosts did.
+type ImportSummary struct {
+	Added    int
+	Updated  int
+	Skipped  int
+	Warnings []string // entries imported partially or not at all
+}
+
+// DetectImportFormat guesses the format of an import file from its extension,
+// falling back to its content.
+func DetectImportFormat(path string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ImportFormatJSON
+	case ".csv":
+		return ImportFormatCSV
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return ImportFormatJSON
+	}
+	firstLine, _, _ := bytes.Cut(trimmed, []byte("\n"))
+	if bytes.Contains(bytes.ToLower(firstLine), []byte("hostname,")) || bytes.Contains(bytes.ToLower(firstLine), []byte(",hostname")) {
+		return ImportFormatCSV
+	}
+	return ImportFormatSSHConfig
+}
+
+// ParseImport parses hosts from data. JSON and CSV use the columns printed by
+// `sshthing list`; imported hosts carry no credentials.
+func ParseImport(data []byte, format string) ([]HostModel, error) {
+	var hosts []HostModel
+	var err error
+	switch format {
+	case ImportFormatSSHConfig:
+		hosts, err = parseSSHConfig(data)
+	case ImportFormatJSON:
+		hosts, err = parseImportJSON(data)
+	case ImportFormatCSV:
+		hosts, err = parseImportCSV(data)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := normalizeImportedHosts(hosts, nil); err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+// ImportJSONHosts imports the JSON written by `sshthing export --json`. Hosts
+// that carry a private_key (from --include-keys) are stored with that key;
+// the rest carry no credentials, as with ParseImport.
+func ImportJSONHosts(store *Store, data []byte, conflict ImportConflict) (ImportSummary, error) {
+	hosts, keys, err := parseImportJSONKeys(data)
+	if err != nil {
+		return ImportSummary{}, err
+	}
+	if err := normalizeImportedHosts(hosts, keys); err != nil {
+		return ImportSummary{}, err
+	}
+	return store.importHosts(hosts, keys, conflict)
+}
+
+// normalizeImportedHosts fills defaults and validates parsed hosts in place.
+// keys is either nil or holds the plaintext private key (or "") per host.
+func normalizeImportedHosts(hosts []HostModel, keys []string) error {
+	defaultUser := ""
+	if u, err := user.Current(); err == nil {
+		defaultUser = u.Username
+		if i := strings.LastIndex(defaultUser, `\`); i >= 0 {
+			defaultUser = defaultUser[i+1:]
+		}
+	}
+	for i := range hosts {
+		h := &hosts[i]
+		h.Hostname = strings.TrimSpace(h.Hostname)
+		h.Label = strings.TrimSpace(h.Label)
+		h.Username = strings.TrimSpace(h.Username)
+		if h.Hostname == "" {
+			return fmt.Errorf("host %d has no hostname", i+1)
+		}
+		if h.Label == "" {
+			h.Label = h.Hostname
+		}
+		if h.Username == "" {
+			h.Username = defaultUser
+		}
+		if h.Port == 0 {
+			h.Port = 22
+		}
+		if h.Port < 1 || h.Port > 65535 {
+			return fmt.Errorf("host %s has invalid port %d", h.Label, h.Port)
+		}
+		hasKey := keys != nil && keys[i] != ""
+		if h.KeyType != "password" && (!hasKey || h.KeyType == "") {
+			h.KeyType = "pasted"
+		}
+		h.GroupName = normalizeGroupName(h.GroupName)
+		h.Tags = NormalizeTags(h.Tags)
+	}
+	return nil
+}
+
+// parseSSHConfig reads Host blocks from an OpenSSH client config. Wildcard
+// patterns and Match blocks are skipped; options other than HostName, User
+// and Port are kept as per-host ssh options.
+func parseSSHConfig(data []byte) ([]HostModel, error) {
+	var hosts []HostModel
+	var block []int // indexes into hosts for the current Host line
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value := splitSSHConfigLine(line)
+		switch strings.ToLower(key) {
+		case "host":
+			block = block[:0]
+			for _, alias := range strings.Fields(value) {
+				if strings.ContainsAny(alias, "*?!") {
+					continue
+				}
+				hosts = append(hosts, HostModel{Label: alias, Hostname: alias})
+				block = append(block, len(hosts)-1)
+			}
+			continue
+		case "match":
+			block = block[:0]
+			continue
+		}
+		for _, idx := range block {
+			h := &hosts[idx]
+			switch strings.ToLower(key) {
+			case "hostname":
+				h.Hostname = value
+			case "user":
+				h.Username = value
+			case "port":
+				port, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("host %s: invalid port %q", h.Label, value)
+				}
+				h.Port = port
+			case "include":
+				// Included files are not followed.
+			default:
+				if h.SSHOptions == nil {
+					h.SSHOptions = map[string]string{}
+				}
+				if _, ok := h.SSHOptions[key]; !ok {
+					h.SSHOptions[key] = value
+				}
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+func splitSSHConfigLine(line string) (string, string) {
+	i := strings.IndexAny(line, " \t=")
+	if i < 0 {
+		return line, ""
+	}
+	key := line[:i]
+	value := strings.TrimSpace(strings.TrimLeft(line[i:], " \t="))
+	return key, strings.Trim(value, `"`)
+}
+
+type importedHost struct {
+	Label      string   `json:"label"`
+	Hostname   string   `json:"hostname"`
+	Username   string   `json:"username"`
+	Port       int      `json:"port"`
+	KeyType    string   `json:"key_type"`
+	Group      string   `json:"group"`
+	Tags       []string `json:"tags"`
+	PrivateKey string   `json:"private_key"`
+}
+
+func parseImportJSON(data []byte) ([]HostModel, error) {
+	hosts, _, err := parseImportJSONKeys(data)
+	return hosts, err
+}
+
+// parseImportJSONKeys parses JSON hosts along with their private_key values.
+func parseImportJSONKeys(data []byte) ([]HostModel, []string, error) {
+	var rows []importedHost
+	if err := json.Unmarshal(data, &rows); err != nil {
+		// Also accept {"hosts": [...]}.
+		var wrapped struct {
+			Hosts []importedHost `json:"hosts"`
+		}
+		if werr := json.Unmarshal(data, &wrapped); werr != nil {
+			return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		rows = wrapped.Hosts
+	}
+	hosts := make([]HostModel, 0, len(rows))
+	keys := make([]string, 0, len(rows))
+	for _, r := range rows {
+		hosts = append(hosts, HostModel{
+			Label:     r.Label,
+			Hostname:  r.Hostname,
+			Username:  r.Username,
+			Port:      r.Port,
+			KeyType:   r.KeyType,
+			GroupName: r.Group,
+			Tags:      r.Tags,
+		})
+		keys = append(keys, r.PrivateKey)
+	}
+	return hosts, keys, nil
+}
+
+func parseImportCSV(data []byte) ([]HostModel, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	col := map[string]int{}
+	for i, name := range records[0] {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := col["hostname"]; !ok {
+		return nil, fmt.Errorf("CSV header has no hostname column")
+	}
+	field := func(rec []string, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	hosts := make([]HostModel, 0, len(records)-1)
+	for n, rec := range records[1:] {
+		h := HostModel{
+			Label:     field(rec, "label"),
+			Hostname:  field(rec, "hostname"),
+			Username:  field(rec, "username"),
+			KeyType:   field(rec, "key_type"),
+			GroupName: field(rec, "group"),
+			Tags:      strings.Fields(field(rec, "tags")),
+		}
+		if p := field(rec, "port"); p != "" {
+			port, err := strconv.Atoi(p)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid port %q", n+2, p)
+			}
+			h.Port = port
+		}
+		hosts = append(hosts, h)
+	}
+	return hosts, nil
+}
+
+// ImportHosts adds hosts parsed by ParseImport. A host conflicts with an
+// existing one when their labels (or the hostname of an unlabelled host)
+// match case-insensitively, or failing that when both connect to the same
+// hostname and port.
+func (s *Store) ImportHosts(hosts []HostModel, conflict ImportConflict) (ImportSummary, error) {
+	return s.importHosts(hosts, nil, conflict)
+}
+
+// importHosts is ImportHosts with an optional plaintext private key per host;
+// keys is either nil or the same length as hosts.
+func (s *Store) importHosts(hosts []HostModel, keys []string, conflict ImportConflict) (ImportSummary, error) {
+	var sum ImportSummary
+	existing, err := s.GetHosts()
+	if err != nil {
+		return sum, err
+	}
+	byLabel := make(map[string]*HostModel, len(existing))
+	byID := make(map[int]*HostModel, len(existing))
+	for i := range existing {
+		byID[existing[i].ID] = &existing[i]
+		label := existing[i].Label
+		if strings.TrimSpace(label) == "" {
+			label = existing[i].Hostname
+		}
+		byLabel[strings.ToLower(label)] = &existing[i]
+	}
+
+	for i, h := range hosts {
+		key := ""
+		if keys != nil {
+			key = keys[i]
+		}
+		if h.GroupName != "" {
+			if err := s.UpsertGroup(h.GroupName); err != nil {
+				return sum, err
+			}
+		}
+		cur, ok := byLabel[strings.ToLower(h.Label)]
+		if !ok {
+			dup, id, err := s.HostExistsByAddress(h.Hostname, h.Port)
+			if err != nil {
+				return sum, err
+			}
+			if dup {
+				cur, ok = byID[id]
+			}
+		}
+		if ok {
+			switch conflict {
+			case ImportSkip:
+				sum.Skipped++
+				continue
+			case ImportOverwrite:
+				cur.Hostname = h.Hostname
+				cur.Username = h.Username
+				cur.Port = h.Port
+				cur.GroupName = h.GroupName
+				cur.Tags = h.Tags
+				if len(h.SSHOptions) > 0 {
+					cur.SSHOptions = h.SSHOptions
+				}
+				if key != "" {
+					cur.KeyType = h.KeyType
+					err = s.UpdateHostWithKey(cur, key)
+				} else {
+					err = s.UpdateHost(cur)
+				}
+				if err != nil {
+					return sum, err
+				}
+				sum.Updated++
+				continue
+			case ImportRename:
+				base := h.Label
+				for n := 2; byLabel[strings.ToLower(h.Label)] != nil; n++ {
+					h.Label = fmt.Sprintf("%s-%d", base, n)
+				}
+			}
+		}
+		if err := s.CreateHost(&h, key); err != nil {
+			return sum, err
+		}
+		byLabel[strings.ToLower(h.Label)] = &h
+		byID[h.ID] = &h
+		sum.Added++
+	}
+	return sum, nil
+}
@@ -0,0 +1,145 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestParseImportFormats(t *testing.T) {
+	config := []byte(`
+Host *
+    ServerAliveInterval 30
+
+Host web web-alias
+    HostName web.example.com
+    User deploy
+    Port 2222
+    IdentityFile ~/.ssh/id_web
+
+Host db
+    User postgres
+`)
+	if got := db.DetectImportFormat("config", config); got != db.ImportFormatSSHConfig {
+		t.Fatalf("expected ssh config, got %q", got)
+	}
+	hosts, err := db.ParseImport(config, db.ImportFormatSSHConfig)
+	if err != nil {
+		t.Fatalf("ParseImport failed: %v", err)
+	}
+	if len(hosts) != 3 {
+		t.Fatalf("expected 3 hosts (wildcards skipped), got %+v", hosts)
+	}
+	web := hosts[0]
+	if web.Label != "web" || web.Hostname != "web.example.com" || web.Username != "deploy" || web.Port != 2222 {
+		t.Fatalf("unexpected web host: %+v", web)
+	}
+	if web.SSHOptions["IdentityFile"] != "~/.ssh/id_web" || web.KeyType != "pasted" {
+		t.Fatalf("expected options to be kept, got %+v", web)
+	}
+	if hosts[2].Hostname != "db" || hosts[2].Port != 22 {
+		t.Fatalf("unexpected db host: %+v", hosts[2])
+	}
+
+	csvData := []byte("label,hostname,username,port,group,tags\napi,api.example.com,ubuntu,22,Prod,linux web\n")
+	if got := db.DetectImportFormat("hosts.txt", csvData); got != db.ImportFormatCSV {
+		t.Fatalf("expected csv, got %q", got)
+	}
+	hosts, err = db.ParseImport(csvData, db.ImportFormatCSV)
+	if err != nil || len(hosts) != 1 || hosts[0].GroupName != "Prod" || len(hosts[0].Tags) != 2 {
+		t.Fatalf("unexpected csv import: %+v (%v)", hosts, err)
+	}
+
+	jsonData := []byte(`[{"label":"cache","hostname":"cache.example.com","username":"ubuntu","port":6380,"key_type":"password"}]`)
+	hosts, err = db.ParseImport(jsonData, db.DetectImportFormat("hosts", jsonData))
+	if err != nil || len(hosts) != 1 || hosts[0].Port != 6380 || hosts[0].KeyType != "password" {
+		t.Fatalf("unexpected json import: %+v (%v)", hosts, err)
+	}
+
+	if _, err := db.ParseImport([]byte("label,username\nx,y\n"), db.ImportFormatCSV); err == nil {
+		t.Fatalf("expected CSV without hostname column to fail")
+	}
+}
+
+func TestImportHostsConflicts(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	existing := &db.HostModel{Label: "web", Hostname: "old.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+	if err := store.CreateHost(existing, "secret"); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	incoming := []db.HostModel{
+		{Label: "Web", Hostname: "new.example.com", Username: "deploy", Port: 22, KeyType: "pasted"},
+		{Label: "db", Hostname: "db.example.com", Username: "deploy", Port: 22, KeyType: "pasted"},
+	}
+
+	sum, err := store.ImportHosts(incoming, db.ImportSkip)
+	if err != nil || sum.Added != 1 || sum.Skipped != 1 {
+		t.Fatalf("unexpected skip summary: %+v (%v)", sum, err)
+	}
+
+	sum, err = store.ImportHosts(incoming[:1], db.ImportRename)
+	if err != nil || sum.Added != 1 {
+		t.Fatalf("unexpected rename summary: %+v (%v)", sum, err)
+	}
+	if h, err := store.GetHostByLabel("Web-2"); err != nil || h.Hostname != "new.example.com" {
+		t.Fatalf("expected renamed copy, got %+v (%v)", h, err)
+	}
+
+	sum, err = store.ImportHosts(incoming[:1], db.ImportOverwrite)
+	if err != nil || sum.Updated != 1 {
+		t.Fatalf("unexpected overwrite summary: %+v (%v)", sum, err)
+	}
+	updated, err := store.GetHostByID(existing.ID)
+	if err != nil || updated.Hostname != "new.example.com" || updated.Username != "deploy" {
+		t.Fatalf("expected existing host to be overwritten, got %+v (%v)", updated, err)
+	}
+	if secret, err := store.GetHostSecret(existing.ID); err != nil || secret != "secret" {
+		t.Fatalf("overwrite must keep the stored secret, got %q (%v)", secret, err)
+	}
+}
+
+func TestImportHostsAddressDuplicates(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	existing := &db.HostModel{Label: "web", Hostname: "web.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+	if err := store.CreateHost(existing, ""); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	if ok, id, err := store.HostExistsByAddress("WEB.example.com", 0); err != nil || !ok || id != existing.ID {
+		t.Fatalf("expected web.example.com:22 to match host %d, got %v %d (%v)", existing.ID, ok, id, err)
+	}
+	if ok, _, err := store.HostExistsByAddress("web.example.com", 2222); err != nil || ok {
+		t.Fatalf("expected no host on port 2222, got %v (%v)", ok, err)
+	}
+
+	incoming := []db.HostModel{{Label: "frontend", Hostname: "web.example.com", Username: "deploy", Port: 22, KeyType: "password"}}
+	sum, err := store.ImportHosts(incoming, db.ImportSkip)
+	if err != nil || sum.Skipped != 1 || sum.Added != 0 {
+		t.Fatalf("expected the same address to be skipped, got %+v (%v)", sum, err)
+	}
+	sum, err = store.ImportHosts(incoming, db.ImportOverwrite)
+	if err != nil || sum.Updated != 1 {
+		t.Fatalf("expected the same address to be overwritten, got %+v (%v)", sum, err)
+	}
+	if h, err := store.GetHostByID(existing.ID); err != nil || h.Username != "deploy" || h.Label != "web" {
+		t.Fatalf("expected the existing host updated in place, got %+v (%v)", h, err)
+	}
+	sum, err = store.ImportHosts(incoming, db.ImportRename)
+	if err != nil || sum.Added != 1 {
+		t.Fatalf("expected rename to keep both hosts, got %+v (%v)", sum, err)
+	}
+	if h, err := store.GetHostByLabel("frontend"); err != nil || h.ID == existing.ID {
+		t.Fatalf("expected a separate frontend host, got %+v (%v)", h, err)
+	}
+}
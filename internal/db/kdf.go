@@ -0,0 +1,233 @@
+package db
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/crypto"
+	"golang.org/x/crypto/argon2"
+)
+
+// KDFParams are the Argon2id costs used to derive the key that encrypts host
+// secrets. They are stored as JSON in the kdf_params config row. The zero
+// value means the legacy PBKDF2 derivation used before the row existed.
+type KDFParams struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // KiB
+	Threads uint8  `json:"threads"`
+}
+
+// DefaultKDFParams are used for new databases and KDF upgrades.
+var DefaultKDFParams = KDFParams{Time: 1, Memory: 19 * 1024, Threads: 1}
+
+// kdfMaxMemory caps the memory BenchmarkKDF will pick (1 GiB).
+const kdfMaxMemory = 1024 * 1024
+
+// Legacy reports whether p is the pre-Argon2 PBKDF2 derivation.
+func (p KDFParams) Legacy() bool {
+	return p.Time == 0
+}
+
+// WeakerThan reports whether p costs an attacker less than o per guess.
+func (p KDFParams) WeakerThan(o KDFParams) bool {
+	return p.Time < o.Time || p.Memory < o.Memory
+}
+
+func (p KDFParams) String() string {
+	if p.Legacy() {
+		return "PBKDF2"
+	}
+	return fmt.Sprintf("argon2id t=%d m=%dMiB p=%d", p.Time, p.Memory/1024, p.Threads)
+}
+
+// deriveSecretKey derives the per-host secret encryption key.
+func deriveSecretKey(password string, salt []byte, p KDFParams) ([]byte, error) {
+	if p.Legacy() {
+		key, _, err := crypto.DeriveKey(password, salt)
+		return key, err
+	}
+	return argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, crypto.KeySize), nil
+}
+
+// getKDFParams reads the kdf_params row. A database without one predates
+// Argon2 unless it is being created now, in which case the defaults are
+// stored.
+func getKDFParams(db *sql.DB, created bool) (KDFParams, error) {
+	var raw string
+	err := db.QueryRow("SELECT value FROM config WHERE key = 'kdf_params'").Scan(&raw)
+	if err == sql.ErrNoRows {
+		if !created {
+			return KDFParams{}, nil
+		}
+		p := DefaultKDFParams
+		b, err := json.Marshal(p)
+		if err != nil {
+			return KDFParams{}, err
+		}
+		if _, err := db.Exec("INSERT INTO config (key, value) VALUES ('kdf_params', ?)", string(b)); err != nil {
+			return KDFParams{}, err
+		}
+		return p, nil
+	} else if err != nil {
+		return KDFParams{}, err
+	}
+
+	var p KDFParams
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		return KDFParams{}, fmt.Errorf("invalid kdf_params: %w", err)
+	}
+	return p, nil
+}
+
+// KDFParams returns the parameters host secrets are currently encrypted with.
+func (s *Store) KDFParams() KDFParams {
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+	return s.kdf
+}
+
+// KDFUpgradeAvailable reports whether the stored parameters are weaker than
+// the compiled-in defaults.
+func (s *Store) KDFUpgradeAvailable() bool {
+	return s.KDFParams().WeakerThan(DefaultKDFParams)
+}
+
+// UpgradeKDF re-derives the secret key with params and a fresh salt and
+// re-encrypts every stored host secret in one transaction. The password is
+// checked against the current key first. Host secrets cannot be read or
+// written while the re-encrypted rows are stored and the key is swapped.
+func (s *Store) UpgradeKDF(password string, params KDFParams) error {
+	if params.Legacy() || params.Memory == 0 || params.Threads == 0 {
+		return fmt.Errorf("invalid KDF parameters: %+v", params)
+	}
+	saltHex, err := s.GetSalt()
+	if err != nil {
+		return err
+	}
+	oldSalt, err := hex.DecodeString(saltHex)
+	if err != nil {
+		return err
+	}
+	s.keyMu.RLock()
+	oldKDF, oldKey := s.kdf, s.masterKey
+	s.keyMu.RUnlock()
+	current, err := deriveSecretKey(password, oldSalt, oldKDF)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(current, oldKey) != 1 {
+		return fmt.Errorf("incorrect password")
+	}
+
+	newSalt, err := crypto.GenerateRandomBytes(crypto.SaltSize)
+	if err != nil {
+		return err
+	}
+	newKey, err := deriveSecretKey(password, newSalt, params)
+	if err != nil {
+		return err
+	}
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+	if subtle.ConstantTimeCompare(current, s.masterKey) != 1 {
+		return fmt.Errorf("key derivation changed while upgrading")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.Query("SELECT id, key_data FROM hosts WHERE COALESCE(key_data, '') != ''")
+	if err != nil {
+		return err
+	}
+	reencrypted := map[int]string{}
+	for rows.Next() {
+		var id int
+		var data string
+		if err := rows.Scan(&id, &data); err != nil {
+			rows.Close()
+			return err
+		}
+		plain, err := crypto.Decrypt(data, current)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to decrypt secret for host %d: %w", id, err)
+		}
+		enc, err := crypto.Encrypt(plain, newKey)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		reencrypted[id] = enc
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, enc := range reencrypted {
+		if _, err := tx.Exec("UPDATE hosts SET key_data = ? WHERE id = ?", enc, id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("UPDATE config SET value = ? WHERE key = 'salt'", fmt.Sprintf("%x", newSalt)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO config (key, value) VALUES ('kdf_params', ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`, string(paramsJSON)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.masterKey = newKey
+	s.kdf = params
+	return nil
+}
+
+// BenchmarkKDF calibrates Argon2id on this machine and returns parameters
+// that take about targetMillis per derivation. Memory is raised first, then
+// passes; the result is never weaker than DefaultKDFParams.
+func BenchmarkKDF(targetMillis int) KDFParams {
+	target := time.Duration(targetMillis) * time.Millisecond
+	password := []byte("sshthing-kdf-benchmark")
+	salt := make([]byte, crypto.SaltSize)
+	measure := func(p KDFParams) time.Duration {
+		start := time.Now()
+		argon2.IDKey(password, salt, p.Time, p.Memory, p.Threads, crypto.KeySize)
+		return time.Since(start)
+	}
+
+	p := DefaultKDFParams
+	elapsed := measure(p)
+	for p.Memory*2 <= kdfMaxMemory && elapsed*2 <= target {
+		next := p
+		next.Memory *= 2
+		d := measure(next)
+		if d > target {
+			break
+		}
+		p, elapsed = next, d
+	}
+
+	perPass := elapsed / time.Duration(p.Time)
+	if perPass > 0 {
+		if passes := uint32(target / perPass); passes > p.Time {
+			p.Time = passes
+		}
+	}
+	return p
+}
@@ -0,0 +1,107 @@
+package db_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestUpgradeKDFReencryptsSecrets(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if store.KDFParams() != db.DefaultKDFParams || store.KDFUpgradeAvailable() {
+		t.Fatalf("expected new database to use default KDF params, got %v", store.KDFParams())
+	}
+	h := &db.HostModel{Label: "web", Hostname: "web.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+	if err := store.CreateHost(h, "hunter2"); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	oldSalt, _ := store.GetSalt()
+
+	stronger := db.KDFParams{Time: 2, Memory: db.DefaultKDFParams.Memory, Threads: 1}
+	if err := store.UpgradeKDF("wrong-password", stronger); err == nil {
+		t.Fatalf("expected wrong password to be rejected")
+	}
+	if err := store.UpgradeKDF("testpassword123", stronger); err != nil {
+		t.Fatalf("UpgradeKDF failed: %v", err)
+	}
+	if newSalt, _ := store.GetSalt(); newSalt == oldSalt {
+		t.Fatalf("expected upgrade to rotate the salt")
+	}
+	store.Close()
+
+	store, err = db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer store.Close()
+	if store.KDFParams() != stronger {
+		t.Fatalf("expected stored params %v, got %v", stronger, store.KDFParams())
+	}
+	secret, err := store.GetHostKey(h.ID)
+	if err != nil || secret != "hunter2" {
+		t.Fatalf("expected secret to survive the re-key, got %q (%v)", secret, err)
+	}
+}
+
+func TestUpgradeKDFWithConcurrentWrites(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	// Hosts saved while the re-key runs must end up under the new key.
+	var wg sync.WaitGroup
+	var ids []int
+	var mu sync.Mutex
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h := &db.HostModel{Hostname: fmt.Sprintf("h%d.example.com", i), Username: "ubuntu", Port: 22, KeyType: "password"}
+			if err := store.CreateHost(h, fmt.Sprintf("secret-%d", i)); err != nil {
+				t.Errorf("CreateHost failed: %v", err)
+				return
+			}
+			mu.Lock()
+			ids = append(ids, h.ID)
+			mu.Unlock()
+		}(i)
+	}
+	stronger := db.KDFParams{Time: 2, Memory: db.DefaultKDFParams.Memory, Threads: 1}
+	if err := store.UpgradeKDF("testpassword123", stronger); err != nil {
+		t.Fatalf("UpgradeKDF failed: %v", err)
+	}
+	wg.Wait()
+	store.Close()
+
+	store, err = db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer store.Close()
+	for _, id := range ids {
+		if _, err := store.GetHostSecret(id); err != nil {
+			t.Fatalf("secret for host %d unreadable after re-key: %v", id, err)
+		}
+	}
+}
+
+func TestKDFParamsComparison(t *testing.T) {
+	legacy := db.KDFParams{}
+	if !legacy.Legacy() || !legacy.WeakerThan(db.DefaultKDFParams) || legacy.String() != "PBKDF2" {
+		t.Fatalf("expected zero params to be legacy PBKDF2")
+	}
+	if db.DefaultKDFParams.WeakerThan(db.DefaultKDFParams) {
+		t.Fatalf("params should not be weaker than themselves")
+	}
+	if p := db.BenchmarkKDF(1); p.WeakerThan(db.DefaultKDFParams) {
+		t.Fatalf("benchmark returned params weaker than defaults: %v", p)
+	}
+}
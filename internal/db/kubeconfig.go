@@ -0,0 +1,196 @@
+package db
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DefaultKubeconfigUser is the SSH username for nodes whose kubeconfig user
+// has no username.
+const DefaultKubeconfigUser = "root"
+
+// kubeNode is one cluster API server found in a kubeconfig.
+type kubeNode struct {
+	Name     string
+	Host     string
+	Username string
+}
+
+// ImportKubeconfig adds a host for each cluster API server in the kubeconfig
+// at kubeconfigPath. Loopback servers (kind, minikube tunnels) and servers
+// that are already a host's hostname are skipped. It returns the number of
+// hosts added.
+func ImportKubeconfig(store *Store, kubeconfigPath string) (int, error) {
+	return ImportKubeconfigUser(store, kubeconfigPath, "")
+}
+
+// ImportKubeconfigUser is ImportKubeconfig with the username used when the
+// cluster's kubeconfig user has none; "" means DefaultKubeconfigUser.
+func ImportKubeconfigUser(store *Store, kubeconfigPath, defaultUser string) (int, error) {
+	data, err := os.ReadFile(kubeconfigPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	if strings.TrimSpace(defaultUser) == "" {
+		defaultUser = DefaultKubeconfigUser
+	}
+	nodes, err := parseKubeconfig(data)
+	if err != nil {
+		return 0, err
+	}
+	existing, err := store.GetHosts()
+	if err != nil {
+		return 0, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, h := range existing {
+		seen[h.Hostname] = true
+	}
+
+	var hosts []HostModel
+	for _, n := range nodes {
+		if seen[n.Host] {
+			continue
+		}
+		seen[n.Host] = true
+		user := n.Username
+		if user == "" {
+			user = defaultUser
+		}
+		hosts = append(hosts, HostModel{
+			Label:    n.Name,
+			Hostname: n.Host,
+			Username: user,
+			Port:     22,
+			KeyType:  "password",
+		})
+	}
+	sum, err := store.ImportHosts(hosts, ImportRename)
+	return sum.Added, err
+}
+
+// parseKubeconfig returns the clusters in a kubeconfig, in file order, with
+// the username of the first context that uses each one.
+func parseKubeconfig(data []byte) ([]kubeNode, error) {
+	sections := parseKubeconfigLists(string(data))
+	clusters := sections["clusters"]
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("no clusters found in kubeconfig")
+	}
+
+	usernames := map[string]string{}
+	for _, u := range sections["users"] {
+		usernames[u["name"]] = u["user.username"]
+	}
+	clusterUser := map[string]string{}
+	for _, c := range sections["contexts"] {
+		cluster := c["context.cluster"]
+		if _, ok := clusterUser[cluster]; !ok {
+			clusterUser[cluster] = usernames[c["context.user"]]
+		}
+	}
+
+	var nodes []kubeNode
+	for _, c := range clusters {
+		host := kubeServerHost(c["cluster.server"])
+		if host == "" {
+			continue
+		}
+		name := c["name"]
+		if name == "" {
+			name = host
+		}
+		nodes = append(nodes, kubeNode{Name: name, Host: host, Username: clusterUser[c["name"]]})
+	}
+	return nodes, nil
+}
+
+// kubeServerHost returns the host of an API server URL, or "" for loopback
+// and unparsable servers.
+func kubeServerHost(server string) string {
+	u, err := url.Parse(strings.TrimSpace(server))
+	if err != nil {
+		return ""
+	}
+	host := u.Hostname()
+	if host == "" || host == "localhost" {
+		return ""
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return ""
+	}
+	return host
+}
+
+// parseKubeconfigLists reads the top-level lists of a kubeconfig (clusters,
+// contexts, users). Each list item becomes a map of dotted key paths to
+// scalar values, e.g. "cluster.server". It understands the block YAML that
+// kubectl writes, not YAML in general.
+func parseKubeconfigLists(doc string) map[string][]map[string]string {
+	type frame struct {
+		indent int
+		key    string
+	}
+	out := map[string][]map[string]string{}
+	section := ""
+	var item map[string]string
+	var stack []frame
+	itemIndent := -1
+
+	for _, raw := range strings.Split(doc, "\n") {
+		line := strings.TrimRight(raw, " \t\r")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+
+		if indent == 0 && !strings.HasPrefix(trimmed, "- ") {
+			key, _, _ := strings.Cut(trimmed, ":")
+			section = strings.TrimSpace(key)
+			item, stack, itemIndent = nil, nil, -1
+			continue
+		}
+		if section == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			item = map[string]string{}
+			out[section] = append(out[section], item)
+			stack = nil
+			// Keys of the item line up with the text after "- ".
+			itemIndent = indent + 2
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			indent = itemIndent
+			if trimmed == "" {
+				continue
+			}
+		}
+		if item == nil || indent < itemIndent {
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		path := key
+		if len(stack) > 0 {
+			path = stack[len(stack)-1].key + "." + key
+		}
+		if value == "" {
+			stack = append(stack, frame{indent: indent, key: path})
+			continue
+		}
+		item[path] = value
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: LS0tLS1CRUdJTg==
+    server: https://10.20.0.5:6443
+  name: prod
+- cluster:
+    server: "https://k8s-staging.example.com"
+  name: staging
+- cluster:
+    server: https://127.0.0.1:41234
+  name: kind-local
+contexts:
+- context:
+    cluster: prod
+    user: prod-admin
+  name: prod
+- context:
+    cluster: staging
+    user: oidc
+  name: staging
+current-context: prod
+users:
+- name: prod-admin
+  user:
+    username: ops
+    password: secret
+- name: oidc
+  user:
+    token: abc
+`
+
+func TestImportKubeconfig(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(kubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := db.ImportKubeconfigUser(store, path, "ubuntu")
+	if err != nil {
+		t.Fatalf("ImportKubeconfigUser failed: %v", err)
+	}
+	if added != 2 {
+		t.Fatalf("expected 2 hosts added, got %d", added)
+	}
+	hosts, err := store.GetHosts()
+	if err != nil {
+		t.Fatalf("GetHosts failed: %v", err)
+	}
+	byLabel := map[string]db.HostModel{}
+	for _, h := range hosts {
+		byLabel[h.Label] = h
+	}
+	if h := byLabel["prod"]; h.Hostname != "10.20.0.5" || h.Username != "ops" || h.Port != 22 {
+		t.Fatalf("unexpected prod host: %+v", h)
+	}
+	if h := byLabel["staging"]; h.Hostname != "k8s-staging.example.com" || h.Username != "ubuntu" {
+		t.Fatalf("unexpected staging host: %+v", h)
+	}
+	if _, ok := byLabel["kind-local"]; ok {
+		t.Fatalf("expected the loopback cluster to be skipped")
+	}
+
+	added, err = db.ImportKubeconfig(store, path)
+	if err != nil || added != 0 {
+		t.Fatalf("expected a second import to add nothing, got %d (%v)", added, err)
+	}
+}
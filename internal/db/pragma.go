@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	sqlite3 "github.com/mutecomm/go-sqlcipher/v4"
+)
+
+// maxOpenConns bounds the connection pool. Several connections let reads
+// like GetHosts run alongside a write in WAL mode; writers still take turns
+// through SQLite's lock, waiting up to the DSN's busy timeout.
+const maxOpenConns = 4
+
+// pragmaConnector opens pooled connections through the SQLCipher driver and
+// applies the per-connection page cache size to each new one. The driver has
+// no DSN parameter for cache_size.
+type pragmaConnector struct {
+	dsn         string
+	cacheSizeKB *atomic.Int64
+	driver      *sqlite3.SQLiteDriver
+}
+
+func newPragmaConnector(dsn string) *pragmaConnector {
+	c := &pragmaConnector{dsn: dsn, cacheSizeKB: new(atomic.Int64)}
+	c.driver = &sqlite3.SQLiteDriver{ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+		kb := c.cacheSizeKB.Load()
+		if kb <= 0 {
+			return nil
+		}
+		// A negative cache_size is in KiB rather than pages.
+		if _, err := conn.Exec(fmt.Sprintf("PRAGMA cache_size=-%d", kb), nil); err != nil {
+			return fmt.Errorf("failed to set cache size: %w", err)
+		}
+		return nil
+	}}
+	return c
+}
+
+func (c *pragmaConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *pragmaConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// openPooled opens dsn with the store's connection pool settings.
+func openPooled(dsn string) (*sql.DB, *pragmaConnector) {
+	c := newPragmaConnector(dsn)
+	db := sql.OpenDB(c)
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxOpenConns)
+	return db, c
+}
+
+// SetPragmas switches the journal mode to WAL (or back to the default
+// rollback journal) and sets the page cache of every connection opened from
+// now on to cacheSizeKB. A cacheSizeKB of 0 or less keeps SQLite's default.
+// Call it while no other queries are running.
+func (s *Store) SetPragmas(walMode bool, cacheSizeKB int) error {
+	mode := "delete"
+	if walMode {
+		mode = "wal"
+	}
+	if s.conns != nil {
+		s.conns.cacheSizeKB.Store(int64(cacheSizeKB))
+	}
+	// Leaving WAL mode needs the only open connection, so drop the idle
+	// ones; the pool reopens them with the new cache size.
+	s.db.SetMaxIdleConns(0)
+	var got string
+	err := s.db.QueryRow("PRAGMA journal_mode=" + mode).Scan(&got)
+	s.db.SetMaxIdleConns(maxOpenConns)
+	if err != nil {
+		return fmt.Errorf("failed to set journal mode: %w", err)
+	}
+	if !strings.EqualFold(got, mode) {
+		return fmt.Errorf("journal mode is %s, not %s", got, mode)
+	}
+	return nil
+}
+
+// Checkpoint copies everything in the write-ahead log back into the
+// database file and empties the log, so the file alone is a complete copy.
+// It is a no-op outside WAL mode.
+func (s *Store) Checkpoint() error {
+	_, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
+}
+
+// walPending reports whether dbPath has a non-empty write-ahead log, i.e.
+// changes that are not yet in the database file itself.
+func walPending(dbPath string) (bool, error) {
+	info, err := os.Stat(dbPath + "-wal")
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return info.Size() > 0, nil
+}
+
+// removeWALFiles deletes the write-ahead log and shared-memory index next
+// to dbPath. Only call it while no connection has the database open.
+func removeWALFiles(dbPath string) error {
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestCacheSizeAppliesToNewConnections(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+	if err := store.SetPragmas(true, 2048); err != nil {
+		t.Fatalf("SetPragmas failed: %v", err)
+	}
+
+	// Hold more connections than were open when SetPragmas ran; each new
+	// one must come up with the cache size.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var conns []*sql.Conn
+	defer func() {
+		for _, c := range conns {
+			c.Close()
+		}
+	}()
+	for i := 0; i < maxOpenConns; i++ {
+		c, err := store.db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Conn %d failed: %v", i, err)
+		}
+		conns = append(conns, c)
+		var size int
+		if err := c.QueryRowContext(ctx, "PRAGMA cache_size").Scan(&size); err != nil {
+			t.Fatalf("PRAGMA cache_size failed: %v", err)
+		}
+		if size != -2048 {
+			t.Fatalf("connection %d: expected cache_size -2048, got %d", i, size)
+		}
+	}
+}
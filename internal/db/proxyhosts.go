@@ -0,0 +1,33 @@
+package db
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// MaxProxyHosts is the longest jump chain a host may have.
+const MaxProxyHosts = 3
+
+// EncodeProxyHosts encodes a host's jump chain (host IDs, first hop first)
+// for DB storage.
+func EncodeProxyHosts(ids []int) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(ids)
+	return string(b)
+}
+
+// DecodeProxyHosts decodes a host's jump chain from DB storage.
+// Malformed values decode to no jump hosts.
+func DecodeProxyHosts(raw string) []int {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	var ids []int
+	if err := json.Unmarshal([]byte(raw), &ids); err != nil || len(ids) == 0 {
+		return nil
+	}
+	return ids
+}
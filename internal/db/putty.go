@@ -0,0 +1,303 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// puttySessionsKey is the registry path PuTTY keeps saved sessions under.
+const puttySessionsKey = `\SimonTatham\PuTTY\Sessions\`
+
+// puttySession holds the fields SSHThing reads from one saved PuTTY session.
+type puttySession struct {
+	Name   string
+	Values map[string]string
+}
+
+func (p puttySession) value(key string) string {
+	return strings.TrimSpace(p.Values[key])
+}
+
+// ImportPuTTY adds the saved sessions in a PuTTY registry export (.reg) or a
+// single PuTTY session file. Sessions whose label is already in use are
+// skipped. Key files are converted to OpenSSH format, using puttygen for
+// .ppk files; sessions that cannot be imported fully are listed in the
+// summary's Warnings.
+func ImportPuTTY(store *Store, input io.Reader) (ImportSummary, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return ImportSummary{}, err
+	}
+	data = decodeRegText(data)
+	var sessions []puttySession
+	if isRegExport(data) {
+		sessions = parsePuTTYReg(data)
+	} else {
+		sessions = []puttySession{parsePuTTYSessionFile("", data)}
+	}
+	return importPuTTYSessions(store, sessions)
+}
+
+// ImportPuTTYDir imports every PuTTY session file in dir (as written to
+// ~/.putty/sessions), naming each host after its file.
+func ImportPuTTYDir(store *Store, dir string) (ImportSummary, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ImportSummary{}, err
+	}
+	var sessions []puttySession
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return ImportSummary{}, err
+		}
+		name := strings.TrimSuffix(e.Name(), ".session")
+		sessions = append(sessions, parsePuTTYSessionFile(name, decodeRegText(data)))
+	}
+	return importPuTTYSessions(store, sessions)
+}
+
+func importPuTTYSessions(store *Store, sessions []puttySession) (ImportSummary, error) {
+	hosts, keys, warnings := puttyHosts(sessions)
+	sum, err := store.importHosts(hosts, keys, ImportSkip)
+	sum.Warnings = append(warnings, sum.Warnings...)
+	return sum, err
+}
+
+// puttyHosts maps sessions to hosts and their plaintext keys. Non-SSH
+// sessions are dropped; sessions whose key cannot be read are kept as
+// password hosts, since they would need Pageant to log in.
+func puttyHosts(sessions []puttySession) ([]HostModel, []string, []string) {
+	var hosts []HostModel
+	var keys, warnings []string
+	for _, sess := range sessions {
+		name := sess.Name
+		hostname := sess.value("HostName")
+		if hostname == "" {
+			if name != "" && name != "Default Settings" {
+				warnings = append(warnings, fmt.Sprintf("%s: no host name", name))
+			}
+			continue
+		}
+		if name == "" {
+			name = hostname
+		}
+		if proto := strings.ToLower(sess.value("Protocol")); proto != "" && proto != "ssh" {
+			warnings = append(warnings, fmt.Sprintf("%s: %s sessions are not supported", name, proto))
+			continue
+		}
+		username := sess.value("UserName")
+		if u, h, ok := strings.Cut(hostname, "@"); ok {
+			if username == "" {
+				username = u
+			}
+			hostname = h
+		}
+		port := 22
+		if p := sess.value("PortNumber"); p != "" {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 1 || n > 65535 {
+				warnings = append(warnings, fmt.Sprintf("%s: invalid port %q", name, p))
+				continue
+			}
+			port = n
+		}
+
+		h := HostModel{Label: name, Hostname: hostname, Username: username, Port: port, KeyType: "password"}
+		key := ""
+		if keyFile := sess.value("PublicKeyFile"); keyFile != "" {
+			k, err := convertPuTTYKey(keyFile)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: key %s not imported (%v); it needs Pageant until the key is added", name, keyFile, err))
+			} else {
+				key = k
+				h.KeyType = "pasted"
+			}
+		}
+		hosts = append(hosts, h)
+		keys = append(keys, key)
+	}
+	return hosts, keys, warnings
+}
+
+// convertPuTTYKey returns the OpenSSH private key stored at path. PuTTY .ppk
+// keys go through `puttygen`, which must be on PATH; encrypted keys fail
+// because puttygen cannot prompt for their passphrase here.
+var convertPuTTYKey = func(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.HasPrefix(data, []byte("PuTTY-User-Key-File-")) {
+		if !bytes.Contains(data, []byte("PRIVATE KEY-----")) {
+			return "", fmt.Errorf("not a private key")
+		}
+		return string(data), nil
+	}
+
+	bin, err := exec.LookPath("puttygen")
+	if err != nil {
+		return "", fmt.Errorf("puttygen not found")
+	}
+	tmpDir, err := os.MkdirTemp("", "sshthing-ppk-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	out := filepath.Join(tmpDir, "key")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, bin, path, "-O", "private-openssh", "-o", out)
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		if s := strings.TrimSpace(string(msg)); s != "" {
+			return "", fmt.Errorf("puttygen: %s", s)
+		}
+		return "", fmt.Errorf("puttygen: %w", err)
+	}
+	converted, err := os.ReadFile(out)
+	if err != nil {
+		return "", err
+	}
+	return string(converted), nil
+}
+
+// decodeRegText converts UTF-16 text (how regedit writes .reg files) to UTF-8
+// and drops a UTF-8 byte order mark.
+func decodeRegText(data []byte) []byte {
+	var bigEndian bool
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		bigEndian = true
+	default:
+		return bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	}
+	data = data[2:]
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+func isRegExport(data []byte) bool {
+	head := bytes.TrimSpace(data)
+	return bytes.HasPrefix(head, []byte("Windows Registry Editor")) || bytes.HasPrefix(head, []byte("REGEDIT4"))
+}
+
+// parsePuTTYReg reads the session keys from a registry export. Hex and
+// multi-line values are ignored; PuTTY stores everything SSHThing needs as
+// strings or dwords.
+func parsePuTTYReg(data []byte) []puttySession {
+	byName := map[string]*puttySession{}
+	var cur *puttySession
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case strings.HasPrefix(line, "["):
+			cur = nil
+			section := strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			i := strings.Index(section, puttySessionsKey)
+			if i < 0 {
+				continue
+			}
+			raw := section[i+len(puttySessionsKey):]
+			if raw == "" || strings.Contains(raw, `\`) {
+				continue
+			}
+			name := puttySessionName(raw)
+			if byName[name] == nil {
+				byName[name] = &puttySession{Name: name, Values: map[string]string{}}
+			}
+			cur = byName[name]
+		case cur != nil && strings.HasPrefix(line, `"`):
+			key, rest, ok := readRegString(line)
+			if !ok || !strings.HasPrefix(rest, "=") {
+				continue
+			}
+			rest = rest[1:]
+			if strings.HasPrefix(rest, `"`) {
+				if v, _, ok := readRegString(rest); ok {
+					cur.Values[key] = v
+				}
+			} else if hexVal, ok := strings.CutPrefix(rest, "dword:"); ok {
+				if n, err := strconv.ParseUint(hexVal, 16, 32); err == nil {
+					cur.Values[key] = strconv.FormatUint(n, 10)
+				}
+			}
+		}
+	}
+
+	sessions := make([]puttySession, 0, len(byName))
+	for _, s := range byName {
+		sessions = append(sessions, *s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Name < sessions[j].Name })
+	return sessions
+}
+
+// readRegString reads a quoted .reg string from the start of s and returns it
+// unescaped along with the text after the closing quote.
+func readRegString(s string) (string, string, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			i++
+			if i < len(s) {
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), s[i+1:], true
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", false
+}
+
+// parsePuTTYSessionFile reads a Key=Value session file as written by PuTTY on
+// Unix.
+func parsePuTTYSessionFile(name string, data []byte) puttySession {
+	sess := puttySession{Name: puttySessionName(name), Values: map[string]string{}}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok {
+			sess.Values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return sess
+}
+
+// puttySessionName undoes PuTTY's %XX escaping of session names.
+func puttySessionName(raw string) string {
+	if name, err := url.PathUnescape(raw); err == nil {
+		return name
+	}
+	return raw
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// EncodeSSHOptions encodes per-host ssh -o options for DB storage.
+func EncodeSSHOptions(opts map[string]string) (string, error) {
+	if len(opts) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(opts)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// DecodeSSHOptions decodes per-host ssh -o options from DB storage.
+// Malformed values decode to no options.
+func DecodeSSHOptions(raw string) map[string]string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	var opts map[string]string
+	if err := json.Unmarshal([]byte(raw), &opts); err != nil || len(opts) == 0 {
+		return nil
+	}
+	return opts
+}
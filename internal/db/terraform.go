@@ -0,0 +1,175 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// terraformState is the part of a Terraform v4 state file SSHThing reads.
+type terraformState struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   any             `json:"index_key"`
+			Attributes json.RawMessage `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// terraformInstance is one compute instance found in a state file.
+type terraformInstance struct {
+	Name     string
+	IP       string
+	Username string
+	Tags     map[string]string
+}
+
+// ImportTerraformState adds the EC2, GCE and Azure Linux VM instances in a
+// Terraform v4 state file that have a public IP. Instances whose IP is
+// already a host's hostname are skipped. It returns the number of hosts added.
+func ImportTerraformState(store *Store, stateJSON []byte) (int, error) {
+	return ImportTerraformStateTagged(store, stateJSON, "", "")
+}
+
+// ImportTerraformStateTagged is ImportTerraformState limited to instances
+// whose tags (labels on GCP) have tagKey set to tagValue. An empty tagKey
+// imports every instance.
+func ImportTerraformStateTagged(store *Store, stateJSON []byte, tagKey, tagValue string) (int, error) {
+	instances, err := parseTerraformState(stateJSON)
+	if err != nil {
+		return 0, err
+	}
+	existing, err := store.GetHosts()
+	if err != nil {
+		return 0, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, h := range existing {
+		seen[h.Hostname] = true
+	}
+
+	var hosts []HostModel
+	for _, inst := range instances {
+		if tagKey != "" && inst.Tags[tagKey] != tagValue {
+			continue
+		}
+		if inst.IP == "" || seen[inst.IP] {
+			continue
+		}
+		seen[inst.IP] = true
+		hosts = append(hosts, HostModel{
+			Label:    inst.Name,
+			Hostname: inst.IP,
+			Username: inst.Username,
+			Port:     22,
+			KeyType:  "password",
+		})
+	}
+	sum, err := store.ImportHosts(hosts, ImportRename)
+	return sum.Added, err
+}
+
+// parseTerraformState returns the supported instances in a state file in the
+// order they appear.
+func parseTerraformState(data []byte) ([]terraformInstance, error) {
+	var state terraformState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid Terraform state: %w", err)
+	}
+	if state.Version != 4 {
+		return nil, fmt.Errorf("unsupported Terraform state version %d (want 4)", state.Version)
+	}
+
+	var out []terraformInstance
+	for _, res := range state.Resources {
+		if res.Mode != "" && res.Mode != "managed" {
+			continue
+		}
+		for _, ri := range res.Instances {
+			var inst terraformInstance
+			var err error
+			switch res.Type {
+			case "aws_instance":
+				inst, err = parseAWSInstance(ri.Attributes)
+			case "google_compute_instance":
+				inst, err = parseGCEInstance(ri.Attributes)
+			case "azurerm_linux_virtual_machine":
+				inst, err = parseAzureVM(ri.Attributes)
+			default:
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", res.Type, res.Name, err)
+			}
+			if inst.Name == "" {
+				inst.Name = res.Name
+				if ri.IndexKey != nil {
+					inst.Name += fmt.Sprintf("-%v", ri.IndexKey)
+				}
+			}
+			out = append(out, inst)
+		}
+	}
+	return out, nil
+}
+
+func parseAWSInstance(raw json.RawMessage) (terraformInstance, error) {
+	var a struct {
+		PublicIP string            `json:"public_ip"`
+		Tags     map[string]string `json:"tags"`
+	}
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return terraformInstance{}, err
+	}
+	return terraformInstance{Name: a.Tags["Name"], IP: a.PublicIP, Username: "ec2-user", Tags: a.Tags}, nil
+}
+
+func parseGCEInstance(raw json.RawMessage) (terraformInstance, error) {
+	var a struct {
+		Name             string            `json:"name"`
+		Labels           map[string]string `json:"labels"`
+		Metadata         map[string]string `json:"metadata"`
+		NetworkInterface []struct {
+			AccessConfig []struct {
+				NatIP string `json:"nat_ip"`
+			} `json:"access_config"`
+		} `json:"network_interface"`
+	}
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return terraformInstance{}, err
+	}
+	inst := terraformInstance{Name: a.Name, Tags: a.Labels}
+	for _, ni := range a.NetworkInterface {
+		for _, ac := range ni.AccessConfig {
+			if inst.IP == "" && ac.NatIP != "" {
+				inst.IP = ac.NatIP
+			}
+		}
+	}
+	// GCE has no fixed login user; the first "user:key" entry in the
+	// ssh-keys metadata is the closest thing to one.
+	for _, line := range strings.Split(a.Metadata["ssh-keys"], "\n") {
+		if u, _, ok := strings.Cut(strings.TrimSpace(line), ":"); ok && u != "" {
+			inst.Username = u
+			break
+		}
+	}
+	return inst, nil
+}
+
+func parseAzureVM(raw json.RawMessage) (terraformInstance, error) {
+	var a struct {
+		Name            string            `json:"name"`
+		PublicIPAddress string            `json:"public_ip_address"`
+		AdminUsername   string            `json:"admin_username"`
+		Tags            map[string]string `json:"tags"`
+	}
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return terraformInstance{}, err
+	}
+	return terraformInstance{Name: a.Name, IP: a.PublicIPAddress, Username: a.AdminUsername, Tags: a.Tags}, nil
+}
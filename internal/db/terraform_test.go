@@ -0,0 +1,93 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+const terraformState = `{
+  "version": 4,
+  "terraform_version": "1.7.5",
+  "resources": [
+    {
+      "mode": "managed", "type": "aws_instance", "name": "web",
+      "instances": [
+        {"index_key": 0, "attributes": {"public_ip": "203.0.113.10", "tags": {"Name": "web-1", "env": "prod"}}},
+        {"index_key": 1, "attributes": {"public_ip": "203.0.113.11", "tags": {"env": "staging"}}},
+        {"index_key": 2, "attributes": {"public_ip": "", "tags": {"Name": "private", "env": "prod"}}}
+      ]
+    },
+    {
+      "mode": "managed", "type": "google_compute_instance", "name": "worker",
+      "instances": [
+        {"attributes": {
+          "name": "gce-worker",
+          "labels": {"env": "prod"},
+          "metadata": {"ssh-keys": "alice:ssh-ed25519 AAAA alice\nbob:ssh-ed25519 BBBB bob"},
+          "network_interface": [{"access_config": [{"nat_ip": "198.51.100.5"}]}]
+        }}
+      ]
+    },
+    {
+      "mode": "managed", "type": "azurerm_linux_virtual_machine", "name": "vm",
+      "instances": [
+        {"attributes": {"name": "az-vm", "public_ip_address": "192.0.2.7", "admin_username": "azureuser", "tags": {"env": "prod"}}}
+      ]
+    },
+    {
+      "mode": "data", "type": "aws_instance", "name": "lookup",
+      "instances": [{"attributes": {"public_ip": "203.0.113.99", "tags": {"Name": "data-source"}}}]
+    },
+    {
+      "mode": "managed", "type": "aws_security_group", "name": "sg",
+      "instances": [{"attributes": {"name": "sg"}}]
+    }
+  ]
+}`
+
+func TestImportTerraformState(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.CreateHost(&db.HostModel{Label: "existing", Hostname: "192.0.2.7", Username: "root", Port: 22, KeyType: "password"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := db.ImportTerraformStateTagged(store, []byte(terraformState), "env", "prod")
+	if err != nil {
+		t.Fatalf("ImportTerraformStateTagged failed: %v", err)
+	}
+	if added != 2 {
+		t.Fatalf("expected 2 prod hosts with new public IPs, got %d", added)
+	}
+	web, err := store.GetHostByLabel("web-1")
+	if err != nil || web.Hostname != "203.0.113.10" || web.Username != "ec2-user" || web.Port != 22 {
+		t.Fatalf("unexpected aws host: %+v (%v)", web, err)
+	}
+	gce, err := store.GetHostByLabel("gce-worker")
+	if err != nil || gce.Hostname != "198.51.100.5" || gce.Username != "alice" {
+		t.Fatalf("unexpected gce host: %+v (%v)", gce, err)
+	}
+
+	added, err = db.ImportTerraformState(store, []byte(terraformState))
+	if err != nil {
+		t.Fatalf("ImportTerraformState failed: %v", err)
+	}
+	if added != 1 {
+		t.Fatalf("expected only the staging host to be new, got %d", added)
+	}
+	// Unnamed instances use the resource name and index, here clashing with
+	// the Name tag of web[0].
+	if h, err := store.GetHostByLabel("web-1-2"); err != nil || h.Hostname != "203.0.113.11" {
+		t.Fatalf("expected staging host under a free label, got %+v (%v)", h, err)
+	}
+
+	if _, err := db.ImportTerraformState(store, []byte(`{"version": 3, "modules": []}`)); err == nil {
+		t.Fatalf("expected a v3 state file to be rejected")
+	}
+}
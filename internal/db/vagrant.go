@@ -0,0 +1,94 @@
+package db
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VagrantTag is added to every host imported from `vagrant ssh-config`.
+const VagrantTag = "vagrant"
+
+// ImportVagrantSSHConfig adds the machines in `vagrant ssh-config` output
+// read from r. Hosts are labelled "vagrant-<name>" and tagged vagrant. The
+// private key named by IdentityFile is stored with the host when it can be
+// read; otherwise the IdentityFile option is kept. Machines whose user,
+// hostname and port match an existing host are skipped. It returns the
+// number of hosts added.
+func ImportVagrantSSHConfig(store *Store, r io.Reader) (int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read vagrant ssh-config: %w", err)
+	}
+	parsed, err := parseSSHConfig(data)
+	if err != nil {
+		return 0, err
+	}
+	if len(parsed) == 0 {
+		return 0, fmt.Errorf("no hosts found in vagrant ssh-config output")
+	}
+
+	existing, err := store.GetHosts()
+	if err != nil {
+		return 0, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, h := range existing {
+		seen[vagrantHostKey(h)] = true
+	}
+
+	var hosts []HostModel
+	var keys []string
+	for _, h := range parsed {
+		h.Label = "vagrant-" + h.Label
+		h.Tags = append(h.Tags, VagrantTag)
+		key := ""
+		for opt, path := range h.SSHOptions {
+			if !strings.EqualFold(opt, "IdentityFile") {
+				continue
+			}
+			if b, err := os.ReadFile(expandHome(strings.Trim(path, `"`))); err == nil {
+				key = string(b)
+				delete(h.SSHOptions, opt)
+			}
+		}
+		hosts = append(hosts, h)
+		keys = append(keys, key)
+	}
+	if err := normalizeImportedHosts(hosts, keys); err != nil {
+		return 0, err
+	}
+
+	var newHosts []HostModel
+	var newKeys []string
+	for i, h := range hosts {
+		if seen[vagrantHostKey(h)] {
+			continue
+		}
+		seen[vagrantHostKey(h)] = true
+		newHosts = append(newHosts, h)
+		newKeys = append(newKeys, keys[i])
+	}
+	sum, err := store.importHosts(newHosts, newKeys, ImportRename)
+	return sum.Added, err
+}
+
+// vagrantHostKey identifies a forwarded Vagrant machine, which usually shares
+// 127.0.0.1 with the others and differs only by port.
+func vagrantHostKey(h HostModel) string {
+	return fmt.Sprintf("%s@%s:%d", strings.ToLower(h.Username), strings.ToLower(h.Hostname), h.Port)
+}
+
+// expandHome replaces a leading "~/" with the user's home directory.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
@@ -0,0 +1,154 @@
+// Package metrics serves SSHThing gauges in the Prometheus text exposition
+// format on a local HTTP endpoint.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultListenAddr is the address the endpoint listens on when none is
+// configured.
+const DefaultListenAddr = "127.0.0.1:9102"
+
+// Snapshot holds the gauges the app publishes. TokenUses maps token IDs to
+// their use counts.
+type Snapshot struct {
+	Hosts           int
+	Mounts          int
+	SyncLastSuccess time.Time
+	TokenUses       map[string]int
+}
+
+// Server serves /metrics until Close is called. The zero of *Server (nil)
+// ignores every call, so callers need not check whether metrics are enabled.
+type Server struct {
+	mu        sync.RWMutex
+	snap      Snapshot
+	connected atomic.Int64
+	tokenUses func() map[string]int
+
+	srv  *http.Server
+	addr string
+}
+
+// Start listens on addr and serves /metrics in a background goroutine.
+// tokenUses, when non-nil, is called on each scrape for the token use
+// counts; it overrides Snapshot.TokenUses.
+func Start(addr string, tokenUses func() map[string]int) (*Server, error) {
+	if strings.TrimSpace(addr) == "" {
+		addr = DefaultListenAddr
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("metrics: %w", err)
+	}
+	s := &Server{tokenUses: tokenUses, addr: ln.Addr().String()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.serveMetrics)
+	s.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = s.srv.Serve(ln) }()
+	return s, nil
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() string {
+	if s == nil {
+		return ""
+	}
+	return s.addr
+}
+
+// Set replaces the published gauges.
+func (s *Server) Set(snap Snapshot) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.snap = snap
+	s.mu.Unlock()
+}
+
+// SessionStarted counts an SSH session as active until SessionEnded.
+func (s *Server) SessionStarted() {
+	if s != nil {
+		s.connected.Add(1)
+	}
+}
+
+// SessionEnded marks an SSH session from SessionStarted as finished.
+func (s *Server) SessionEnded() {
+	if s != nil && s.connected.Add(-1) < 0 {
+		s.connected.Store(0)
+	}
+}
+
+// Close stops the server, waiting briefly for in-flight scrapes.
+func (s *Server) Close() error {
+	if s == nil || s.srv == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err := s.srv.Shutdown(ctx)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	snap := s.snap
+	s.mu.RUnlock()
+	if s.tokenUses != nil {
+		snap.TokenUses = s.tokenUses()
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = Write(w, snap, int(s.connected.Load()))
+}
+
+// Write renders snap and the active session count in the Prometheus text
+// format.
+func Write(w io.Writer, snap Snapshot, connected int) error {
+	var b strings.Builder
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+	}
+	gauge("sshthing_hosts_total", "Number of saved hosts.", float64(snap.Hosts))
+	gauge("sshthing_connected_total", "Number of active SSH sessions.", float64(connected))
+	gauge("sshthing_mounts_total", "Number of active SSHFS mounts.", float64(snap.Mounts))
+	var last float64
+	if !snap.SyncLastSuccess.IsZero() {
+		last = float64(snap.SyncLastSuccess.Unix())
+	}
+	gauge("sshthing_sync_last_success_timestamp", "Unix time of the last successful sync (0 if none).", last)
+
+	b.WriteString("# HELP sshthing_token_use_count Number of times each automation token has been used.\n")
+	b.WriteString("# TYPE sshthing_token_use_count gauge\n")
+	ids := make([]string, 0, len(snap.TokenUses))
+	for id := range snap.TokenUses {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(&b, "sshthing_token_use_count{token_id=\"%s\"} %d\n", escapeLabel(id), snap.TokenUses[id])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerServesGauges(t *testing.T) {
+	s, err := Start("127.0.0.1:0", func() map[string]int {
+		return map[string]int{"tok-b": 2, "tok-a": 7}
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	s.Set(Snapshot{Hosts: 3, Mounts: 1, SyncLastSuccess: time.Unix(1700000000, 0)})
+	s.SessionStarted()
+	s.SessionStarted()
+	s.SessionEnded()
+
+	resp, err := http.Get("http://" + s.Addr() + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	out := string(body)
+	for _, want := range []string{
+		"# TYPE sshthing_hosts_total gauge\nsshthing_hosts_total 3\n",
+		"sshthing_connected_total 1\n",
+		"sshthing_mounts_total 1\n",
+		"sshthing_sync_last_success_timestamp 1.7e+09\n",
+		"sshthing_token_use_count{token_id=\"tok-a\"} 7\nsshthing_token_use_count{token_id=\"tok-b\"} 2\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := http.Get("http://" + s.Addr() + "/metrics"); err == nil {
+		t.Fatalf("expected the server to be stopped after Close")
+	}
+}
+
+func TestNilServerIsNoop(t *testing.T) {
+	var s *Server
+	s.Set(Snapshot{Hosts: 1})
+	s.SessionStarted()
+	s.SessionEnded()
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close on nil server: %v", err)
+	}
+}
@@ -5,10 +5,14 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,18 +21,44 @@ import (
 )
 
 type Mount struct {
+	HostID      int
+	Hostname    string
+	DisplayName string // host label shown in the UI; "" falls back to Hostname
+	LocalPath   string
+	RemotePath  string
+	KeyPath     string
+	PID         int
+	ReadOnly    bool
+}
+
+// Name returns the label to show for mnt: its DisplayName, else the hostname.
+func (mnt Mount) Name() string {
+	if name := strings.TrimSpace(mnt.DisplayName); name != "" {
+		return name
+	}
+	if host := strings.TrimSpace(mnt.Hostname); host != "" {
+		return host
+	}
+	return fmt.Sprintf("host_%d", mnt.HostID)
+}
+
+// MountKey identifies one mount: a host can be mounted several times as long
+// as each mount points at a different remote path ("" means remote home).
+type MountKey struct {
 	HostID     int
-	Hostname   string
-	LocalPath  string
 	RemotePath string
-	KeyPath    string
-	PID        int
+}
+
+// Key returns the MountKey for mnt.
+func (mnt Mount) Key() MountKey {
+	return MountKey{HostID: mnt.HostID, RemotePath: mnt.RemotePath}
 }
 
 type PreparedMount struct {
 	HostID    int
 	Hostname  string
 	LocalPath string
+	ReadOnly  bool
 
 	remoteSpec string
 	remotePath string
@@ -37,15 +67,21 @@ type PreparedMount struct {
 	keyPath   string
 	cmd       *exec.Cmd
 	stderrBuf bytes.Buffer
+
+	maxRetries   int
+	retryBackoff time.Duration
 }
 
 func (p *PreparedMount) Cmd() *exec.Cmd     { return p.cmd }
 func (p *PreparedMount) RemotePath() string { return p.remotePath }
 func (p *PreparedMount) Stderr() string     { return strings.TrimSpace(p.stderrBuf.String()) }
+func (p *PreparedMount) Key() MountKey {
+	return MountKey{HostID: p.HostID, RemotePath: p.remotePath}
+}
 
 type Manager struct {
 	mu         sync.Mutex
-	active     map[int]*Mount
+	active     map[MountKey]*Mount
 	sshfsBin   string
 	diskutil   string
 	fusermount string
@@ -53,17 +89,62 @@ type Manager struct {
 
 func NewManager() *Manager {
 	return &Manager{
-		active: make(map[int]*Mount),
+		active: make(map[MountKey]*Mount),
 	}
 }
 
-func (m *Manager) IsMounted(hostID int) (bool, *Mount) {
+// IsMounted reports whether the mount identified by key is active.
+func (m *Manager) IsMounted(key MountKey) (bool, *Mount) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	mnt, ok := m.active[hostID]
+	mnt, ok := m.active[normalizeKey(key)]
 	return ok, mnt
 }
 
+// MountsForHost returns the active mounts of hostID ordered by remote path.
+func (m *Manager) MountsForHost(hostID int) []Mount {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Mount
+	for k, v := range m.active {
+		if k.HostID == hostID && v != nil {
+			out = append(out, *v)
+		}
+	}
+	sortMounts(out)
+	return out
+}
+
+func normalizeKey(key MountKey) MountKey {
+	key.RemotePath = normalizeRemotePath(key.RemotePath)
+	return key
+}
+
+// normalizeRemotePath trims and cleans a remote path so "/srv/" and "/srv"
+// map to the same mount. An empty path (remote home) stays empty.
+func normalizeRemotePath(remotePath string) string {
+	remotePath = strings.TrimSpace(remotePath)
+	if remotePath == "" {
+		return ""
+	}
+	return path.Clean(remotePath)
+}
+
+func sortMounts(mounts []Mount) {
+	sort.Slice(mounts, func(i, j int) bool {
+		if mounts[i].HostID != mounts[j].HostID {
+			return mounts[i].HostID < mounts[j].HostID
+		}
+		return mounts[i].RemotePath < mounts[j].RemotePath
+	})
+}
+
+// IsLinux reports whether mounts use the Linux code path (sshfs + fusermount,
+// /proc/mounts, no Finder/diskutil integration).
+func IsLinux() bool {
+	return runtime.GOOS == "linux"
+}
+
 func (m *Manager) CheckPrereqs() error {
 	switch runtime.GOOS {
 	case "darwin":
@@ -210,12 +291,18 @@ func remoteSpecFor(conn ssh.Connection, remotePath string) string {
 	return target + ":" + remotePath
 }
 
-func (m *Manager) PrepareMount(hostID int, conn ssh.Connection, remotePath string, displayName string, localMountBase string) (*PreparedMount, error) {
+func (m *Manager) PrepareMount(hostID int, conn ssh.Connection, remotePath string, displayName string, localMountBase string, readOnly bool) (*PreparedMount, error) {
+	remotePath = normalizeRemotePath(remotePath)
+	key := MountKey{HostID: hostID, RemotePath: remotePath}
+
 	m.mu.Lock()
-	_, alreadyMounted := m.active[hostID]
+	_, alreadyMounted := m.active[key]
 	m.mu.Unlock()
 	if alreadyMounted {
-		return nil, fmt.Errorf("⚠ host is already mounted")
+		if remotePath == "" {
+			return nil, fmt.Errorf("⚠ remote home is already mounted for this host")
+		}
+		return nil, fmt.Errorf("⚠ %s is already mounted for this host", remotePath)
 	}
 
 	if err := m.CheckPrereqs(); err != nil {
@@ -241,6 +328,14 @@ func (m *Manager) PrepareMount(hostID int, conn ssh.Connection, remotePath strin
 	}
 	mountName := safeMountName(label, conn.Port)
 	localPath := filepath.Join(root, mountName)
+	if m.localPathInUse(localPath) {
+		// Additional mounts of the same host get a folder named after the remote path.
+		suffix := "home"
+		if remotePath != "" {
+			suffix = safeMountName(remotePath, 0)
+		}
+		localPath = filepath.Join(root, mountName+"_"+suffix)
+	}
 	if err := os.MkdirAll(localPath, 0700); err != nil {
 		return nil, err
 	}
@@ -251,13 +346,39 @@ func (m *Manager) PrepareMount(hostID int, conn ssh.Connection, remotePath strin
 	}
 
 	remoteSpec := remoteSpecFor(conn, remotePath)
+	args := sshfsArgs(conn, remoteSpec, localPath, displayName, keyPath, readOnly)
+
+	cmd := exec.Command(m.sshfsBin, args...)
+	cmd.Env = ssh.AgentEnv(os.Environ(), conn)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
 
-	// Build sshfs args:
-	// sshfs [user@]host:[dir] mountpoint [options]
+	p := &PreparedMount{
+		HostID:     hostID,
+		Hostname:   conn.Hostname,
+		LocalPath:  localPath,
+		ReadOnly:   readOnly,
+		remoteSpec: remoteSpec,
+		remotePath: remotePath,
+		display:    strings.TrimSpace(displayName),
+		keyPath:    keyPath,
+		cmd:        cmd,
+
+		maxRetries:   conn.MaxRetries,
+		retryBackoff: conn.RetryBackoff,
+	}
+	cmd.Stderr = &p.stderrBuf
+
+	return p, nil
+}
+
+// sshfsArgs builds the sshfs command line:
+// sshfs [user@]host:[dir] mountpoint [options]
+func sshfsArgs(conn ssh.Connection, remoteSpec, localPath, displayName, keyPath string, readOnly bool) []string {
 	args := []string{remoteSpec, localPath}
 
 	var mountOpts []string
-	if runtime.GOOS == "linux" {
+	if IsLinux() {
 		mountOpts = []string{"reconnect"}
 	} else {
 		mountOpts = []string{
@@ -266,11 +387,17 @@ func (m *Manager) PrepareMount(hostID int, conn ssh.Connection, remotePath strin
 			"defer_permissions",
 		}
 	}
+	if readOnly {
+		mountOpts = append(mountOpts, "ro")
+	}
 	args = append(args, "-o", strings.Join(mountOpts, ","))
 
 	// SSH options passed through.
 	args = append(args, "-o", "StrictHostKeyChecking="+strictHostKeyChecking(conn.HostKeyPolicy))
 	args = append(args, "-o", fmt.Sprintf("ServerAliveInterval=%d", keepAliveSeconds(conn.KeepAliveSeconds)))
+	if len(conn.ProxyJumps) > 0 {
+		args = append(args, "-o", "ProxyJump="+ssh.ProxyJumpSpec(conn.ProxyJumps))
+	}
 
 	// Port: sshfs supports -p in many builds; this is the most explicit form.
 	if conn.Port != 0 && conn.Port != 22 {
@@ -279,33 +406,46 @@ func (m *Manager) PrepareMount(hostID int, conn ssh.Connection, remotePath strin
 
 	if keyPath != "" {
 		args = append(args, "-o", fmt.Sprintf("IdentityFile=%s", keyPath))
+		if conn.IdentitiesOnly {
+			args = append(args, "-o", "IdentitiesOnly=yes")
+		}
 	}
+	return args
+}
 
-	cmd := exec.Command(m.sshfsBin, args...)
-	cmd.Env = os.Environ()
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-
-	p := &PreparedMount{
-		HostID:     hostID,
-		Hostname:   conn.Hostname,
-		LocalPath:  localPath,
-		remoteSpec: remoteSpec,
-		remotePath: strings.TrimSpace(remotePath),
-		display:    strings.TrimSpace(displayName),
-		keyPath:    keyPath,
-		cmd:        cmd,
+func (m *Manager) localPathInUse(localPath string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, v := range m.active {
+		if v != nil && v.LocalPath == localPath {
+			return true
+		}
 	}
-	cmd.Stderr = &p.stderrBuf
+	return false
+}
 
-	return p, nil
+// cleanupKeyFileIfUnused removes keyPath unless another active mount of the
+// same host still relies on it (all mounts of a host share one key file).
+func (m *Manager) cleanupKeyFileIfUnused(keyPath string) {
+	if keyPath == "" {
+		return
+	}
+	m.mu.Lock()
+	for _, v := range m.active {
+		if v != nil && v.KeyPath == keyPath {
+			m.mu.Unlock()
+			return
+		}
+	}
+	m.mu.Unlock()
+	cleanupKeyFile(keyPath)
 }
 
 func (m *Manager) AbortMount(p *PreparedMount) {
 	if p == nil {
 		return
 	}
-	cleanupKeyFile(p.keyPath)
+	m.cleanupKeyFileIfUnused(p.keyPath)
 }
 
 func (m *Manager) FinalizeMount(p *PreparedMount) error {
@@ -313,7 +453,13 @@ func (m *Manager) FinalizeMount(p *PreparedMount) error {
 		return fmt.Errorf("internal error: missing prepared mount")
 	}
 
+	// A slow server can take longer than the first wait to answer sshfs, so
+	// keep checking with the connection's retry backoff.
 	ok, err := waitMounted(p.LocalPath, 2*time.Second)
+	for attempt := 1; err == nil && !ok && attempt <= p.maxRetries; attempt++ {
+		time.Sleep(ssh.RetryDelay(p.retryBackoff, attempt))
+		ok, err = waitMounted(p.LocalPath, 2*time.Second)
+	}
 	if err != nil {
 		m.AbortMount(p)
 		return err
@@ -329,13 +475,15 @@ func (m *Manager) FinalizeMount(p *PreparedMount) error {
 	}
 
 	m.mu.Lock()
-	m.active[p.HostID] = &Mount{
-		HostID:     p.HostID,
-		Hostname:   p.Hostname,
-		LocalPath:  p.LocalPath,
-		RemotePath: p.remotePath,
-		KeyPath:    p.keyPath,
-		PID:        pid,
+	m.active[p.Key()] = &Mount{
+		HostID:      p.HostID,
+		Hostname:    p.Hostname,
+		DisplayName: p.display,
+		LocalPath:   p.LocalPath,
+		RemotePath:  p.remotePath,
+		KeyPath:     p.keyPath,
+		PID:         pid,
+		ReadOnly:    p.ReadOnly,
 	}
 	m.mu.Unlock()
 
@@ -351,19 +499,19 @@ func (m *Manager) FinalizeMount(p *PreparedMount) error {
 	return nil
 }
 
-func (m *Manager) PrepareUnmount(hostID int) (*exec.Cmd, string, error) {
+func (m *Manager) PrepareUnmount(key MountKey) (*exec.Cmd, string, error) {
 	if err := m.CheckPrereqs(); err != nil {
 		return nil, "", err
 	}
 	m.mu.Lock()
-	mnt, ok := m.active[hostID]
+	mnt, ok := m.active[normalizeKey(key)]
 	m.mu.Unlock()
 	if !ok {
 		return nil, "", fmt.Errorf("⚠ host is not mounted")
 	}
 
 	var cmd *exec.Cmd
-	if runtime.GOOS == "linux" && m.fusermount != "" {
+	if IsLinux() && m.fusermount != "" {
 		cmd = exec.Command(m.fusermount, "-u", mnt.LocalPath)
 	} else {
 		cmd = exec.Command("umount", mnt.LocalPath)
@@ -375,9 +523,10 @@ func (m *Manager) PrepareUnmount(hostID int) (*exec.Cmd, string, error) {
 	return cmd, mnt.LocalPath, nil
 }
 
-func (m *Manager) FinalizeUnmount(hostID int, primaryErr error) error {
+func (m *Manager) FinalizeUnmount(key MountKey, primaryErr error) error {
+	key = normalizeKey(key)
 	m.mu.Lock()
-	mnt, ok := m.active[hostID]
+	mnt, ok := m.active[key]
 	m.mu.Unlock()
 	if !ok {
 		// Already gone; treat as success.
@@ -416,31 +565,31 @@ func (m *Manager) FinalizeUnmount(hostID int, primaryErr error) error {
 		return fmt.Errorf("⚠ unmount did not complete (mount still present)")
 	}
 
-	cleanupKeyFile(mnt.KeyPath)
-
 	m.mu.Lock()
-	delete(m.active, hostID)
+	delete(m.active, key)
 	m.mu.Unlock()
 
+	m.cleanupKeyFileIfUnused(mnt.KeyPath)
+
 	return nil
 }
 
 func (m *Manager) UnmountAll() {
 	m.mu.Lock()
-	ids := make([]int, 0, len(m.active))
-	for id := range m.active {
-		ids = append(ids, id)
+	keys := make([]MountKey, 0, len(m.active))
+	for k := range m.active {
+		keys = append(keys, k)
 	}
 	m.mu.Unlock()
 
-	for _, id := range ids {
-		cmd, _, err := m.PrepareUnmount(id)
+	for _, k := range keys {
+		cmd, _, err := m.PrepareUnmount(k)
 		if err == nil {
 			runErr := cmd.Run()
-			_ = m.FinalizeUnmount(id, runErr)
+			_ = m.FinalizeUnmount(k, runErr)
 			continue
 		}
-		_ = m.FinalizeUnmount(id, err)
+		_ = m.FinalizeUnmount(k, err)
 	}
 }
 
@@ -453,6 +602,7 @@ func (m *Manager) ListActive() []Mount {
 			out = append(out, *v)
 		}
 	}
+	sortMounts(out)
 	return out
 }
 
@@ -473,13 +623,27 @@ func (m *Manager) RestoreMounted(records []Mount) {
 				}
 			}
 			cp := r
-			m.active[r.HostID] = &cp
-		} else {
-			// Clean up stale key file if present.
-			if kp, err := mountKeyPathFor(r.HostID); err == nil {
-				cleanupKeyFile(kp)
+			cp.RemotePath = normalizeRemotePath(cp.RemotePath)
+			m.active[cp.Key()] = &cp
+		}
+	}
+
+	// Clean up stale key files for hosts with no surviving mount.
+	for _, r := range records {
+		kp, err := mountKeyPathFor(r.HostID)
+		if err != nil {
+			continue
+		}
+		inUse := false
+		for k := range m.active {
+			if k.HostID == r.HostID {
+				inUse = true
+				break
 			}
 		}
+		if !inUse {
+			cleanupKeyFile(kp)
+		}
 	}
 }
 
@@ -519,7 +683,7 @@ func waitUnmounted(localPath string, timeout time.Duration) (bool, error) {
 
 func isMounted(localPath string) (bool, error) {
 	// Linux fast-path: read /proc/mounts directly instead of spawning mount(8).
-	if runtime.GOOS == "linux" {
+	if IsLinux() {
 		return isMountedProc(localPath)
 	}
 
@@ -553,18 +717,42 @@ func isMountedProc(localPath string) (bool, error) {
 		return strings.Contains(string(out), " "+localPath+" "), nil
 	}
 	defer f.Close()
+	return procMountsContains(f, localPath)
+}
 
-	scanner := bufio.NewScanner(f)
+// procMountsContains scans /proc/mounts-formatted data for localPath.
+func procMountsContains(r io.Reader, localPath string) (bool, error) {
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		// /proc/mounts format: device mountpoint fstype options ...
 		fields := strings.Fields(scanner.Text())
-		if len(fields) >= 2 && fields[1] == localPath {
+		if len(fields) >= 2 && unescapeProcMountPath(fields[1]) == localPath {
 			return true, nil
 		}
 	}
 	return false, scanner.Err()
 }
 
+// unescapeProcMountPath decodes the octal escapes (\040 for space, \011 for
+// tab, \012 for newline, \134 for backslash) the kernel uses in /proc/mounts.
+func unescapeProcMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
 // IsMounted reports whether a given local mount path is currently mounted.
 func IsMounted(localPath string) (bool, error) {
 	return isMounted(localPath)
@@ -0,0 +1,132 @@
+package mount
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/Vansh-Raja/SSHThing/internal/ssh"
+)
+
+func TestProcMountsContains(t *testing.T) {
+	procMounts := strings.Join([]string{
+		"proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0",
+		"ubuntu@example.com: /home/me/.config/sshthing/mounts/prod fuse.sshfs rw,nosuid,nodev 0 0",
+		`ubuntu@example.com:/srv /home/me/My\040Mounts/web fuse.sshfs rw,nosuid,nodev 0 0`,
+	}, "\n")
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{name: "plain path", path: "/home/me/.config/sshthing/mounts/prod", want: true},
+		{name: "escaped space", path: "/home/me/My Mounts/web", want: true},
+		{name: "prefix only", path: "/home/me/.config/sshthing/mounts", want: false},
+		{name: "not mounted", path: "/home/me/.config/sshthing/mounts/dev", want: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := procMountsContains(strings.NewReader(procMounts), tc.path)
+			if err != nil {
+				t.Fatalf("procMountsContains returned error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("procMountsContains(%q) = %v, want %v", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckPrereqs_Linux(t *testing.T) {
+	if !IsLinux() {
+		t.Skip("Linux-only mount prerequisites")
+	}
+	if _, err := exec.LookPath("sshfs"); err != nil {
+		t.Skip("sshfs not installed")
+	}
+
+	m := NewManager()
+	if err := m.CheckPrereqs(); err != nil {
+		t.Fatalf("CheckPrereqs failed with sshfs installed: %v", err)
+	}
+	if m.sshfsBin == "" || m.fusermount == "" {
+		t.Fatalf("expected sshfs and fusermount to be resolved, got sshfs=%q fusermount=%q", m.sshfsBin, m.fusermount)
+	}
+	if m.diskutil != "" {
+		t.Fatalf("diskutil must not be used on Linux")
+	}
+}
+
+func TestMountsForHost_KeyedByRemotePath(t *testing.T) {
+	m := NewManager()
+	m.active[MountKey{HostID: 1, RemotePath: "/var/log"}] = &Mount{HostID: 1, RemotePath: "/var/log", LocalPath: "/mnt/h_var_log"}
+	m.active[MountKey{HostID: 1, RemotePath: ""}] = &Mount{HostID: 1, LocalPath: "/mnt/h"}
+	m.active[MountKey{HostID: 2, RemotePath: ""}] = &Mount{HostID: 2, LocalPath: "/mnt/other"}
+
+	mounts := m.MountsForHost(1)
+	if len(mounts) != 2 {
+		t.Fatalf("expected 2 mounts for host 1, got %d", len(mounts))
+	}
+	if mounts[0].RemotePath != "" || mounts[1].RemotePath != "/var/log" {
+		t.Fatalf("expected mounts ordered by remote path, got %+v", mounts)
+	}
+
+	if ok, _ := m.IsMounted(MountKey{HostID: 1, RemotePath: "/var/log/"}); !ok {
+		t.Fatalf("expected trailing slash to resolve to the same mount")
+	}
+	if ok, _ := m.IsMounted(MountKey{HostID: 1, RemotePath: "/srv"}); ok {
+		t.Fatalf("expected /srv not to be mounted")
+	}
+	if !m.localPathInUse("/mnt/h") || m.localPathInUse("/mnt/free") {
+		t.Fatalf("localPathInUse returned unexpected result")
+	}
+}
+
+func TestMountName_PrefersDisplayName(t *testing.T) {
+	cases := []struct {
+		mnt  Mount
+		want string
+	}{
+		{Mount{HostID: 1, Hostname: "db.internal", DisplayName: "Prod DB"}, "Prod DB"},
+		{Mount{HostID: 1, Hostname: "db.internal", DisplayName: "  "}, "db.internal"},
+		{Mount{HostID: 7}, "host_7"},
+	}
+	for _, c := range cases {
+		if got := c.mnt.Name(); got != c.want {
+			t.Errorf("Name() = %q, want %q", got, c.want)
+		}
+	}
+}
+
+func TestSshfsArgs_ReadOnly(t *testing.T) {
+	conn := ssh.Connection{Hostname: "example.com", Username: "ubuntu", Port: 2222}
+
+	mountOpts := func(args []string) string {
+		for i, a := range args {
+			if a == "-o" && i+1 < len(args) && strings.HasPrefix(args[i+1], "reconnect") {
+				return args[i+1]
+			}
+		}
+		t.Fatalf("mount options not found in %q", args)
+		return ""
+	}
+
+	ro := sshfsArgs(conn, "ubuntu@example.com:/srv", "/mnt/prod", "prod", "/tmp/key", true)
+	if ro[0] != "ubuntu@example.com:/srv" || ro[1] != "/mnt/prod" {
+		t.Fatalf("expected remote spec and mountpoint first, got %q", ro)
+	}
+	if opts := strings.Split(mountOpts(ro), ","); opts[len(opts)-1] != "ro" {
+		t.Fatalf("expected ro mount option, got %q", mountOpts(ro))
+	}
+	if !strings.Contains(strings.Join(ro, " "), "-p 2222") {
+		t.Fatalf("expected -p 2222 in args, got %q", ro)
+	}
+
+	rw := sshfsArgs(conn, "ubuntu@example.com:/srv", "/mnt/prod", "prod", "/tmp/key", false)
+	for _, opt := range strings.Split(mountOpts(rw), ",") {
+		if opt == "ro" {
+			t.Fatalf("read-write mount must not include ro, got %q", mountOpts(rw))
+		}
+	}
+}
@@ -1,12 +1,29 @@
 package securestore
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 )
 
+// fileStoreKeyLabel salts the keys that encrypt file store entries.
+const fileStoreKeyLabel = "sshthing-keystore-v1"
+
+// sealedPrefix tags encrypted entries. Untagged entries were written in plain
+// text by older versions; ':' never appears in the base64 ciphertext.
+const sealedPrefix = "v1:"
+
 type fileStore struct {
 	mu   sync.Mutex
 	path string
@@ -74,6 +91,60 @@ func (fs *fileStore) save(data fileStoreData) error {
 	return os.WriteFile(fs.path, raw, 0600)
 }
 
+// keyFor derives the AES-256 key for user's entries from the hostname and
+// the process UID. This only keeps the file from being readable as plain
+// text when copied to another machine or account; it is not a substitute for
+// the OS keyring, and entries become unreadable if the hostname changes.
+func (fs *fileStore) keyFor(user string) ([]byte, error) {
+	host, _ := os.Hostname()
+	secret := []byte(fmt.Sprintf("%s:%d", host, os.Getuid()))
+	return hkdf.Key(sha256.New, secret, []byte(fileStoreKeyLabel), user, 32)
+}
+
+func (fs *fileStore) seal(service, user, value string) (string, error) {
+	gcm, err := fs.gcmFor(user)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	ct := gcm.Seal(nonce, nonce, []byte(value), []byte(service+":"+user))
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(ct), nil
+}
+
+func (fs *fileStore) open(service, user, sealed string) (string, error) {
+	raw, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(sealed, sealedPrefix))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := fs.gcmFor(user)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	pt, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], []byte(service+":"+user))
+	if err != nil {
+		return "", err
+	}
+	return string(pt), nil
+}
+
+func (fs *fileStore) gcmFor(user string) (cipher.AEAD, error) {
+	key, err := fs.keyFor(user)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
 func (fs *fileStore) Get(service, user string) (string, error) {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
@@ -87,6 +158,18 @@ func (fs *fileStore) Get(service, user string) (string, error) {
 	if !ok {
 		return "", errNotFound
 	}
+	if strings.HasPrefix(val, sealedPrefix) {
+		pt, err := fs.open(service, user, val)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt %s: %w", key, err)
+		}
+		return pt, nil
+	}
+	// Written by an older version in plain text: encrypt it in place.
+	if sealed, err := fs.seal(service, user, val); err == nil {
+		data.Entries[key] = sealed
+		_ = fs.save(data)
+	}
 	return val, nil
 }
 
@@ -98,8 +181,11 @@ func (fs *fileStore) Set(service, user, value string) error {
 	if err != nil {
 		return err
 	}
-	key := service + ":" + user
-	data.Entries[key] = value
+	sealed, err := fs.seal(service, user, value)
+	if err != nil {
+		return err
+	}
+	data.Entries[service+":"+user] = sealed
 	return fs.save(data)
 }
 
@@ -0,0 +1,73 @@
+package securestore
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStoreEncryptsEntries(t *testing.T) {
+	fs := &fileStore{path: filepath.Join(t.TempDir(), "keystore.json")}
+
+	if err := fs.Set(serviceName, devicePepperUser, "pepper-value"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	raw, err := os.ReadFile(fs.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "pepper-value") {
+		t.Fatalf("expected the value to be encrypted on disk, got %s", raw)
+	}
+	got, err := fs.Get(serviceName, devicePepperUser)
+	if err != nil || got != "pepper-value" {
+		t.Fatalf("expected round trip, got %q (%v)", got, err)
+	}
+	if _, err := fs.Get(serviceName, "missing"); err != errNotFound {
+		t.Fatalf("expected errNotFound, got %v", err)
+	}
+}
+
+func TestFileStoreMigratesPlaintextEntries(t *testing.T) {
+	fs := &fileStore{path: filepath.Join(t.TempDir(), "keystore.json")}
+	legacy, _ := json.Marshal(fileStoreData{Entries: map[string]string{serviceName + ":" + vaultUnlockCacheUser: "old-plain"}})
+	if err := os.WriteFile(fs.path, legacy, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fs.Get(serviceName, vaultUnlockCacheUser)
+	if err != nil || got != "old-plain" {
+		t.Fatalf("expected the plaintext value, got %q (%v)", got, err)
+	}
+	raw, _ := os.ReadFile(fs.path)
+	if strings.Contains(string(raw), "old-plain") || !strings.Contains(string(raw), sealedPrefix) {
+		t.Fatalf("expected the entry to be re-written encrypted, got %s", raw)
+	}
+	if got, err := fs.Get(serviceName, vaultUnlockCacheUser); err != nil || got != "old-plain" {
+		t.Fatalf("expected the migrated value, got %q (%v)", got, err)
+	}
+}
+
+func TestFileStoreRejectsUndecryptableEntries(t *testing.T) {
+	fs := &fileStore{path: filepath.Join(t.TempDir(), "keystore.json")}
+	if err := fs.Set(serviceName, devicePepperUser, "pepper-value"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	// A sealed entry for another user fails authentication under this key.
+	data, _ := fs.load()
+	sealed := data.Entries[serviceName+":"+devicePepperUser]
+	data.Entries[serviceName+":"+vaultUnlockCacheUser] = sealed
+	if err := fs.save(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := fs.Get(serviceName, vaultUnlockCacheUser); err == nil {
+		t.Fatalf("expected a decrypt error, got %q", got)
+	}
+	data, _ = fs.load()
+	if data.Entries[serviceName+":"+vaultUnlockCacheUser] != sealed {
+		t.Fatalf("expected the sealed entry to be left alone, got %q", data.Entries[serviceName+":"+vaultUnlockCacheUser])
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/Vansh-Raja/SSHThing/internal/config"
 	"github.com/zalando/go-keyring"
 )
 
@@ -124,7 +125,13 @@ func GetDevicePepper() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	b, err := base64.RawStdEncoding.DecodeString(strings.TrimSpace(v))
+	return DecodeDevicePepper(v)
+}
+
+// DecodeDevicePepper decodes a device pepper in the base64 form it is kept in
+// the keyring, e.g. as copied from another machine.
+func DecodeDevicePepper(s string) ([]byte, error) {
+	b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(s), "="))
 	if err != nil {
 		return nil, err
 	}
@@ -154,16 +161,24 @@ func GetOrCreateDevicePepper(randReader io.Reader) ([]byte, error) {
 	return b, nil
 }
 
+// sessionUnlockUser scopes the unlock session cache to the active profile.
+func sessionUnlockUser() string {
+	if p := config.ActiveProfile(); p != "" {
+		return vaultUnlockCacheUser + ":" + p
+	}
+	return vaultUnlockCacheUser
+}
+
 func StoreSessionUnlock(value string) error {
-	return kSet(serviceName, vaultUnlockCacheUser, value)
+	return kSet(serviceName, sessionUnlockUser(), value)
 }
 
 func LoadSessionUnlock() (string, error) {
-	return kGet(serviceName, vaultUnlockCacheUser)
+	return kGet(serviceName, sessionUnlockUser())
 }
 
 func ClearSessionUnlock() error {
-	err := kDelete(serviceName, vaultUnlockCacheUser)
+	err := kDelete(serviceName, sessionUnlockUser())
 	if err == keyring.ErrNotFound {
 		return nil
 	}
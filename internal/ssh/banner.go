@@ -0,0 +1,51 @@
+package ssh
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	xssh "golang.org/x/crypto/ssh"
+)
+
+// FetchBanner connects to conn's SSH port and returns the pre-authentication
+// banner the server sends (often /etc/issue.net), or "" when it sends none.
+// Only the "none" auth method is offered, so no credentials leave the
+// machine and the login stops right after the banner.
+func FetchBanner(conn Connection, timeout time.Duration) (string, error) {
+	port := conn.Port
+	if port == 0 {
+		port = 22
+	}
+	addr := net.JoinHostPort(conn.Hostname, strconv.Itoa(port))
+	nc, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return "", err
+	}
+	defer nc.Close()
+	_ = nc.SetDeadline(time.Now().Add(timeout))
+
+	var banner string
+	cfg := &xssh.ClientConfig{
+		User: conn.Username,
+		// Nothing secret is sent, so the host key is not checked here;
+		// real sessions still go through ssh and known_hosts.
+		HostKeyCallback: xssh.InsecureIgnoreHostKey(),
+		BannerCallback: func(message string) error {
+			banner += message
+			return nil
+		},
+		Timeout: timeout,
+	}
+	c, chans, reqs, err := xssh.NewClientConn(nc, addr, cfg)
+	if err == nil {
+		// The server accepted "none" auth; there is nothing more to read.
+		_ = xssh.NewClient(c, chans, reqs).Close()
+	}
+	banner = strings.TrimSpace(strings.ReplaceAll(banner, "\r\n", "\n"))
+	if banner == "" && err != nil && !strings.Contains(err.Error(), "unable to authenticate") {
+		return "", err
+	}
+	return banner, nil
+}
@@ -0,0 +1,64 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	xssh "golang.org/x/crypto/ssh"
+)
+
+func TestFetchBanner(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := xssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve := func(banner string) int {
+		cfg := &xssh.ServerConfig{
+			PasswordCallback: func(xssh.ConnMetadata, []byte) (*xssh.Permissions, error) {
+				return nil, errors.New("denied")
+			},
+			BannerCallback: func(xssh.ConnMetadata) string { return banner },
+		}
+		cfg.AddHostKey(signer)
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { ln.Close() })
+		go func() {
+			for {
+				c, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer c.Close()
+					_, _, _, _ = xssh.NewServerConn(c, cfg)
+				}()
+			}
+		}()
+		return ln.Addr().(*net.TCPAddr).Port
+	}
+
+	port := serve("Authorized use only.\r\nActivity is logged.\r\n")
+	got, err := FetchBanner(Connection{Hostname: "127.0.0.1", Port: port, Username: "ubuntu"}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("FetchBanner returned error: %v", err)
+	}
+	if got != "Authorized use only.\nActivity is logged." {
+		t.Fatalf("unexpected banner %q", got)
+	}
+
+	port = serve("")
+	if got, err := FetchBanner(Connection{Hostname: "127.0.0.1", Port: port, Username: "ubuntu"}, 5*time.Second); err != nil || got != "" {
+		t.Fatalf("expected no banner, got %q (%v)", got, err)
+	}
+}
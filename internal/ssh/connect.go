@@ -1,13 +1,18 @@
 package ssh
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -20,12 +25,55 @@ type Connection struct {
 	PrivateKey string // Decrypted private key content
 	Password   string // Decrypted password secret for password auth
 
+	// PassphrasePrompt supplies the passphrase when PrivateKey is encrypted.
+	PassphrasePrompt PassphrasePromptFunc
+
 	PasswordBackendUnix string // "sshpass_first" | "askpass_first"
 
 	// Options
 	HostKeyPolicy    string // "accept-new" | "strict" | "off"
+	KnownHostsFile   string // optional known_hosts file used instead of the user's and global ones
 	KeepAliveSeconds int
-	Term             string // optional TERM override (env SSHTHING_SSH_TERM still wins)
+	Term             string            // optional TERM override (env SSHTHING_SSH_TERM still wins)
+	Options          map[string]string // per-host `-o Key=Value` overrides
+
+	// Connection sharing. Connect and ConnectSFTP start or reuse a master;
+	// ConnectExec only reuses one that is already running.
+	ControlMaster bool
+	ControlPath   string // ssh ControlPath; may contain ssh tokens such as %C
+
+	// ProxyJumps is the jump host chain, first hop first, passed as -J.
+	// Only Hostname, Username and Port are used: ssh authenticates each hop
+	// with the agent or the user's own keys.
+	ProxyJumps []Connection
+
+	// X11 forwarding (interactive Connect only): -X, or -Y when TrustX11 is
+	// set. Needs $DISPLAY on the client.
+	ForwardX11 bool
+	TrustX11   bool
+
+	// ForwardAgent passes -A to interactive and exec sessions and makes sure
+	// SSH_AUTH_SOCK reaches every ssh and sshfs child. It has no effect when
+	// AgentSocketPath is empty.
+	ForwardAgent bool
+
+	// IdentitiesOnly passes -o IdentitiesOnly=yes when PrivateKey is set, so
+	// ssh offers only that key and not every key loaded in the agent.
+	IdentitiesOnly bool
+
+	// Session recording (interactive Connect only)
+	Recording     bool
+	RecordingPath string // typescript output file, required when Recording is set
+
+	// OnFingerprint, when set, receives the server's SHA256 host key
+	// fingerprint from a background ssh-keyscan (interactive Connect only).
+	// It is called from another goroutine, possibly after the session ends.
+	OnFingerprint func(fingerprint string)
+
+	// Retries (RunSSH and sshfs mounts): refused or timed-out connections are retried up
+	// to MaxRetries times, doubling the wait from RetryBackoff each time.
+	MaxRetries   int
+	RetryBackoff time.Duration
 }
 
 // TempKeyFile manages a temporary file for the SSH private key
@@ -98,6 +146,80 @@ func (t *TempKeyFile) Cleanup() error {
 	return nil
 }
 
+// x11Args returns the X11 forwarding flag for conn, or nil when it is off.
+func x11Args(conn Connection) []string {
+	switch {
+	case !conn.ForwardX11:
+		return nil
+	case conn.TrustX11:
+		return []string{"-Y"}
+	default:
+		return []string{"-X"}
+	}
+}
+
+// AgentSocketPath returns the ssh-agent socket of this process
+// ($SSH_AUTH_SOCK), or "" when no agent is running.
+func AgentSocketPath() string {
+	return os.Getenv("SSH_AUTH_SOCK")
+}
+
+// AgentEnv sets SSH_AUTH_SOCK in env when conn forwards the agent and a
+// socket is available.
+func AgentEnv(env []string, conn Connection) []string {
+	if !conn.ForwardAgent {
+		return env
+	}
+	if sock := AgentSocketPath(); sock != "" {
+		env = setEnv(env, "SSH_AUTH_SOCK", sock)
+	}
+	return env
+}
+
+// agentArgs returns -A when conn forwards the agent and one is running.
+func agentArgs(conn Connection) []string {
+	if !conn.ForwardAgent || AgentSocketPath() == "" {
+		return nil
+	}
+	return []string{"-A"}
+}
+
+// identitiesOnlyArgs returns -o IdentitiesOnly=yes when conn authenticates
+// with a stored key and asks to offer only that key.
+func identitiesOnlyArgs(conn Connection) []string {
+	if !conn.IdentitiesOnly || conn.PrivateKey == "" {
+		return nil
+	}
+	return []string{"-o", "IdentitiesOnly=yes"}
+}
+
+// ProxyJumpSpec formats a jump chain as ssh's -J value:
+// user@host1:port1,user@host2:port2. It returns "" for an empty chain.
+func ProxyJumpSpec(jumps []Connection) string {
+	hops := make([]string, 0, len(jumps))
+	for _, j := range jumps {
+		port := j.Port
+		if port == 0 {
+			port = 22
+		}
+		hop := net.JoinHostPort(j.Hostname, strconv.Itoa(port))
+		if j.Username != "" {
+			hop = j.Username + "@" + hop
+		}
+		hops = append(hops, hop)
+	}
+	return strings.Join(hops, ",")
+}
+
+// proxyJumpArgs returns the -J flag for conn's jump chain, or nil when it
+// has none.
+func proxyJumpArgs(conn Connection) []string {
+	if len(conn.ProxyJumps) == 0 {
+		return nil
+	}
+	return []string{"-J", ProxyJumpSpec(conn.ProxyJumps)}
+}
+
 // Connect establishes an SSH connection.
 // It returns the exec.Cmd that can be used to run the SSH session.
 // The caller is responsible for cleaning up the temp key file after the session ends.
@@ -106,8 +228,13 @@ func Connect(conn Connection) (*exec.Cmd, *TempKeyFile, error) {
 	var args []string
 
 	// Build SSH command arguments
-	args = append(args, "-o", "StrictHostKeyChecking="+strictHostKeyChecking(conn.HostKeyPolicy))
+	args = append(args, customOptionArgs(conn)...)
+	args = append(args, hostKeyArgs(conn)...)
 	args = append(args, "-o", fmt.Sprintf("ServerAliveInterval=%d", keepAliveSeconds(conn.KeepAliveSeconds)))
+	args = append(args, controlMasterArgs(conn)...)
+	args = append(args, x11Args(conn)...)
+	args = append(args, agentArgs(conn)...)
+	args = append(args, proxyJumpArgs(conn)...)
 
 	// Add port if not default
 	if conn.Port != 22 && conn.Port != 0 {
@@ -118,11 +245,12 @@ func Connect(conn Connection) (*exec.Cmd, *TempKeyFile, error) {
 	if conn.PrivateKey != "" {
 		// Key-based authentication
 		var err error
-		tempKey, err = NewTempKeyFile(conn.PrivateKey)
+		tempKey, err = newConnectionKeyFile(conn)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to create temp key file: %w", err)
 		}
 		args = append(args, "-i", tempKey.Path())
+		args = append(args, identitiesOnlyArgs(conn)...)
 	}
 
 	passwordAuth := conn.PrivateKey == "" && conn.Password != ""
@@ -147,10 +275,23 @@ func Connect(conn Connection) (*exec.Cmd, *TempKeyFile, error) {
 	} else {
 		tempKey.merge(cleanupHolder)
 	}
+	if conn.Recording {
+		if strings.TrimSpace(conn.RecordingPath) == "" {
+			_ = tempKey.Cleanup()
+			return nil, nil, fmt.Errorf("recording path is required")
+		}
+		wrapped, err := wrapWithRecorder(cmd, conn.RecordingPath)
+		if err != nil {
+			_ = tempKey.Cleanup()
+			return nil, nil, err
+		}
+		cmd = wrapped
+	}
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
+	emitFingerprint(conn)
 	return cmd, tempKey, nil
 }
 
@@ -166,8 +307,12 @@ func ConnectExec(conn Connection, remoteCommand string) (*exec.Cmd, *TempKeyFile
 	var args []string
 
 	args = append(args, "-T")
-	args = append(args, "-o", "StrictHostKeyChecking="+strictHostKeyChecking(conn.HostKeyPolicy))
+	args = append(args, customOptionArgs(conn)...)
+	args = append(args, hostKeyArgs(conn)...)
 	args = append(args, "-o", fmt.Sprintf("ServerAliveInterval=%d", keepAliveSeconds(conn.KeepAliveSeconds)))
+	args = append(args, multiplexArgs(conn)...)
+	args = append(args, agentArgs(conn)...)
+	args = append(args, proxyJumpArgs(conn)...)
 
 	if conn.Port != 22 && conn.Port != 0 {
 		args = append(args, "-p", fmt.Sprintf("%d", conn.Port))
@@ -175,11 +320,12 @@ func ConnectExec(conn Connection, remoteCommand string) (*exec.Cmd, *TempKeyFile
 
 	if conn.PrivateKey != "" {
 		var err error
-		tempKey, err = NewTempKeyFile(conn.PrivateKey)
+		tempKey, err = newConnectionKeyFile(conn)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to create temp key file: %w", err)
 		}
 		args = append(args, "-i", tempKey.Path())
+		args = append(args, identitiesOnlyArgs(conn)...)
 	}
 
 	passwordAuth := conn.PrivateKey == "" && conn.Password != ""
@@ -218,8 +364,11 @@ func ConnectSFTP(conn Connection) (*exec.Cmd, *TempKeyFile, error) {
 	var args []string
 
 	// Pass SSH options through to the underlying transport.
-	args = append(args, "-o", "StrictHostKeyChecking="+strictHostKeyChecking(conn.HostKeyPolicy))
+	args = append(args, customOptionArgs(conn)...)
+	args = append(args, hostKeyArgs(conn)...)
 	args = append(args, "-o", fmt.Sprintf("ServerAliveInterval=%d", keepAliveSeconds(conn.KeepAliveSeconds)))
+	args = append(args, controlMasterArgs(conn)...)
+	args = append(args, proxyJumpArgs(conn)...)
 
 	// sftp uses -P (uppercase) for port.
 	if conn.Port != 22 && conn.Port != 0 {
@@ -229,11 +378,12 @@ func ConnectSFTP(conn Connection) (*exec.Cmd, *TempKeyFile, error) {
 	// Handle authentication
 	if conn.PrivateKey != "" {
 		var err error
-		tempKey, err = NewTempKeyFile(conn.PrivateKey)
+		tempKey, err = newConnectionKeyFile(conn)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to create temp key file: %w", err)
 		}
 		args = append(args, "-i", tempKey.Path())
+		args = append(args, identitiesOnlyArgs(conn)...)
 	}
 
 	passwordAuth := conn.PrivateKey == "" && conn.Password != ""
@@ -269,7 +419,7 @@ func prepareClientCommand(binary string, args []string, conn Connection, holder
 	password := conn.Password
 	if password == "" || conn.PrivateKey != "" {
 		cmd := exec.Command(binary, args...)
-		cmd.Env = sshEnv(conn.Term)
+		cmd.Env = sshEnv(conn)
 		return cmd, holder, nil
 	}
 
@@ -299,7 +449,7 @@ func prepareClientCommand(binary string, args []string, conn Connection, holder
 	}
 
 	cmd := exec.Command(binary, args...)
-	cmd.Env = sshEnv(conn.Term)
+	cmd.Env = sshEnv(conn)
 	return cmd, holder, nil
 }
 
@@ -329,7 +479,7 @@ func prepareSSHPassCommand(binary string, args []string, conn Connection, holder
 	allArgs := []string{"-d", "3", binary}
 	allArgs = append(allArgs, args...)
 	cmd := exec.Command("sshpass", allArgs...)
-	cmd.Env = sshEnv(conn.Term)
+	cmd.Env = sshEnv(conn)
 	cmd.ExtraFiles = []*os.File{readPipe}
 
 	holder = ensureCleanupHolder(holder)
@@ -350,7 +500,7 @@ func prepareAskpassCommand(binary string, args []string, conn Connection, holder
 	}
 
 	cmd := exec.Command(binary, args...)
-	env := sshEnv(conn.Term)
+	env := sshEnv(conn)
 	env = setEnv(env, "SSH_ASKPASS", exePath)
 	env = setEnv(env, "SSH_ASKPASS_REQUIRE", "force")
 	env = setEnv(env, askpassModeEnv, "1")
@@ -363,8 +513,9 @@ func prepareAskpassCommand(binary string, args []string, conn Connection, holder
 	return cmd, holder, nil
 }
 
-func sshEnv(termOverride string) []string {
-	env := os.Environ()
+func sshEnv(conn Connection) []string {
+	env := AgentEnv(os.Environ(), conn)
+	termOverride := conn.Term
 
 	term := os.Getenv("SSHTHING_SSH_TERM")
 	if term == "" {
@@ -397,6 +548,15 @@ func setEnv(env []string, key, value string) []string {
 	return append(env, prefix+value)
 }
 
+// hostKeyArgs returns the host key checking options for conn.
+func hostKeyArgs(conn Connection) []string {
+	args := []string{"-o", "StrictHostKeyChecking=" + strictHostKeyChecking(conn.HostKeyPolicy)}
+	if conn.KnownHostsFile != "" {
+		args = append(args, "-o", "UserKnownHostsFile="+conn.KnownHostsFile, "-o", "GlobalKnownHostsFile="+os.DevNull)
+	}
+	return args
+}
+
 func strictHostKeyChecking(policy string) string {
 	switch strings.TrimSpace(strings.ToLower(policy)) {
 	case "strict", "yes":
@@ -421,18 +581,62 @@ func keepAliveSeconds(v int) int {
 // RunSSH runs an SSH session and waits for it to complete.
 // It handles cleanup of the temporary key file automatically.
 func RunSSH(conn Connection) error {
-	cmd, tempKey, err := Connect(conn)
-	if err != nil {
-		return err
+	for attempt := 1; ; attempt++ {
+		cmd, tempKey, err := Connect(conn)
+		if err != nil {
+			return err
+		}
+
+		// Keep a copy of ssh's own messages to tell connection failures apart.
+		var stderr bytes.Buffer
+		if attempt <= conn.MaxRetries {
+			cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+		}
+
+		// Run the SSH session
+		err = cmd.Run()
+		if tempKey != nil {
+			tempKey.Cleanup()
+		}
+		if attempt > conn.MaxRetries || !IsRetryableError(err, stderr.String()) {
+			return err
+		}
+		delay := RetryDelay(conn.RetryBackoff, attempt)
+		fmt.Fprintf(os.Stderr, "Connection failed, retrying in %s (%d/%d)...\n", delay, attempt, conn.MaxRetries)
+		time.Sleep(delay)
 	}
+}
 
-	// Ensure cleanup happens
-	if tempKey != nil {
-		defer tempKey.Cleanup()
+// maxRetryBackoff caps the wait between connection attempts.
+const maxRetryBackoff = 30 * time.Second
+
+// RetryDelay returns the wait before retry number attempt (counting from 1):
+// base doubled for every earlier retry, capped at 30s. A zero base means 1s.
+func RetryDelay(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		base = time.Second
 	}
+	delay := base
+	for i := 1; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryBackoff)
+}
 
-	// Run the SSH session
-	return cmd.Run()
+// IsRetryableError reports whether err, together with the ssh client's
+// stderr output, describes a refused or timed-out connection. Failures from
+// the remote side (any exit status other than ssh's own 255) never qualify.
+func IsRetryableError(err error, stderr string) bool {
+	if err == nil {
+		return false
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() != 255 {
+		return false
+	}
+	msg := strings.ToLower(err.Error() + "\n" + stderr)
+	return strings.Contains(msg, "connection refused") ||
+		strings.Contains(msg, "timed out")
 }
 
 // RunSSHExec runs a non-interactive remote command over SSH and waits for completion.
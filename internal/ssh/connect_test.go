@@ -1,9 +1,19 @@
 package ssh
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/ssh/testserver"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 func TestConnectSFTP_WithPortAndKey_AddsArgs(t *testing.T) {
@@ -110,6 +120,221 @@ func TestConnectExec_AppendsRemoteCommand(t *testing.T) {
 	}
 }
 
+// testServerConnection starts a testserver and returns a Connection that
+// reaches it with the real ssh client, pinning its host key in a private
+// known_hosts file so nothing outside the test is read or written.
+func testServerConnection(t *testing.T, tools ...string) Connection {
+	t.Helper()
+	for _, tool := range append([]string{"ssh"}, tools...) {
+		if !HasTool(tool) {
+			t.Skipf("%s is not installed", tool)
+		}
+	}
+	srv, err := testserver.New(t)
+	if err != nil {
+		t.Fatalf("testserver.New failed: %v", err)
+	}
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(fmt.Sprintf("127.0.0.1:%d", srv.Port()))}, srv.HostKey())
+	if err := os.WriteFile(knownHosts, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return Connection{
+		Hostname:       "127.0.0.1",
+		Username:       "tester",
+		Port:           srv.Port(),
+		PrivateKey:     testserver.ClientKey,
+		HostKeyPolicy:  "strict",
+		KnownHostsFile: knownHosts,
+		Options: map[string]string{
+			"BatchMode":      "yes",
+			"IdentitiesOnly": "yes",
+			"IdentityAgent":  "none",
+		},
+	}
+}
+
+func TestConnectExec(t *testing.T) {
+	conn := testServerConnection(t)
+	cmd, tempKey, err := ConnectExec(conn, "echo hello; echo oops >&2; exit 3")
+	if err != nil {
+		t.Fatalf("ConnectExec returned error: %v", err)
+	}
+	defer tempKey.Cleanup()
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = nil
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("expected exit status 3, got %v (stderr %q)", err, stderr.String())
+	}
+	if got := stdout.String(); got != "hello\n" {
+		t.Fatalf("unexpected stdout %q", got)
+	}
+	if !strings.Contains(stderr.String(), "oops") {
+		t.Fatalf("expected remote stderr, got %q", stderr.String())
+	}
+}
+
+func TestConnectSFTP(t *testing.T) {
+	conn := testServerConnection(t, "sftp")
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local.txt")
+	remote := filepath.Join(dir, "remote.txt")
+	back := filepath.Join(dir, "back.txt")
+	if err := os.WriteFile(local, []byte("payload\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, tempKey, err := ConnectSFTP(conn)
+	if err != nil {
+		t.Fatalf("ConnectSFTP returned error: %v", err)
+	}
+	defer tempKey.Cleanup()
+
+	var output bytes.Buffer
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("put %s %s\nget %s %s\n", local, remote, remote, back))
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("sftp failed: %v\n%s", err, output.String())
+	}
+	for _, path := range []string{remote, back} {
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != "payload\n" {
+			t.Fatalf("expected %s to hold the payload, got %q (%v)\n%s", path, data, err, output.String())
+		}
+	}
+}
+
+func TestConnect_X11Forwarding(t *testing.T) {
+	for _, tc := range []struct {
+		forward, trust bool
+		want, notWant  string
+	}{
+		{false, true, "", " -X "},
+		{true, false, " -X ", " -Y "},
+		{true, true, " -Y ", " -X "},
+	} {
+		cmd, tempKey, err := Connect(Connection{
+			Hostname:   "example.com",
+			Username:   "ubuntu",
+			Port:       22,
+			ForwardX11: tc.forward,
+			TrustX11:   tc.trust,
+		})
+		if err != nil {
+			t.Fatalf("Connect returned error: %v", err)
+		}
+		if tempKey != nil {
+			tempKey.Cleanup()
+		}
+		args := strings.Join(cmd.Args, " ") + " "
+		if tc.want != "" && !strings.Contains(args, tc.want) {
+			t.Fatalf("forward=%v trust=%v: expected %q in args, got: %q", tc.forward, tc.trust, tc.want, args)
+		}
+		if strings.Contains(args, tc.notWant) || (!tc.forward && strings.Contains(args, " -Y ")) {
+			t.Fatalf("forward=%v trust=%v: unexpected X11 flag in args: %q", tc.forward, tc.trust, args)
+		}
+	}
+}
+
+func TestConnect_AgentForwarding(t *testing.T) {
+	for _, tc := range []struct {
+		forward bool
+		sock    string
+		want    bool
+	}{
+		{false, "/tmp/agent.sock", false},
+		{true, "", false},
+		{true, "/tmp/agent.sock", true},
+	} {
+		t.Setenv("SSH_AUTH_SOCK", tc.sock)
+		cmd, tempKey, err := Connect(Connection{
+			Hostname:     "example.com",
+			Username:     "ubuntu",
+			Port:         22,
+			ForwardAgent: tc.forward,
+		})
+		if err != nil {
+			t.Fatalf("Connect returned error: %v", err)
+		}
+		if tempKey != nil {
+			tempKey.Cleanup()
+		}
+		args := strings.Join(cmd.Args, " ") + " "
+		if got := strings.Contains(args, " -A "); got != tc.want {
+			t.Fatalf("forward=%v sock=%q: -A in args = %v, want %v: %q", tc.forward, tc.sock, got, tc.want, args)
+		}
+		if env := strings.Join(cmd.Env, "\n"); tc.want && !strings.Contains(env, "SSH_AUTH_SOCK=/tmp/agent.sock") {
+			t.Fatalf("expected SSH_AUTH_SOCK in env, got: %q", env)
+		}
+	}
+}
+
+func TestConnect_IdentitiesOnly(t *testing.T) {
+	for _, tc := range []struct {
+		key  string
+		only bool
+		want bool
+	}{
+		{"dummy-key", true, true},
+		{"dummy-key", false, false},
+		{"", true, false}, // no stored key: ssh keeps using the agent
+	} {
+		cmd, tempKey, err := Connect(Connection{
+			Hostname:       "example.com",
+			Username:       "ubuntu",
+			Port:           22,
+			PrivateKey:     tc.key,
+			IdentitiesOnly: tc.only,
+		})
+		if err != nil {
+			t.Fatalf("Connect returned error: %v", err)
+		}
+		if tempKey != nil {
+			tempKey.Cleanup()
+		}
+		args := strings.Join(cmd.Args, " ")
+		if got := strings.Contains(args, "-o IdentitiesOnly=yes"); got != tc.want {
+			t.Fatalf("key=%q only=%v: IdentitiesOnly in args = %v, want %v: %q", tc.key, tc.only, got, tc.want, args)
+		}
+	}
+}
+
+func TestConnect_ProxyJumpChain(t *testing.T) {
+	cmd, tempKey, err := Connect(Connection{
+		Hostname: "10.0.0.5",
+		Username: "app",
+		Port:     22,
+		ProxyJumps: []Connection{
+			{Hostname: "bastion1.example.com", Username: "ops", Port: 22},
+			{Hostname: "fd00::2", Username: "ops", Port: 2222},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+	if tempKey != nil {
+		tempKey.Cleanup()
+	}
+	args := strings.Join(cmd.Args, " ")
+	if !strings.Contains(args, "-J ops@bastion1.example.com:22,ops@[fd00::2]:2222") {
+		t.Fatalf("expected jump chain in args, got: %q", args)
+	}
+
+	cmd, _, err = Connect(Connection{Hostname: "example.com", Username: "ubuntu", Port: 22})
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+	if strings.Contains(strings.Join(cmd.Args, " "), " -J ") {
+		t.Fatalf("expected no -J without jump hosts, got: %q", cmd.Args)
+	}
+}
+
 func TestConnectSFTP_WithPassword_SSHPassFirstWhenAvailable(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("sshpass backend not used on windows")
@@ -137,3 +362,37 @@ func TestConnectSFTP_WithPassword_SSHPassFirstWhenAvailable(t *testing.T) {
 		t.Fatalf("expected sshpass command prefix, got: %q", args)
 	}
 }
+
+func TestRetryDelay_DoublesAndCaps(t *testing.T) {
+	cases := []struct {
+		base    time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{0, 1, time.Second},
+		{0, 3, 4 * time.Second},
+		{2 * time.Second, 2, 4 * time.Second},
+		{time.Second, 10, 30 * time.Second},
+		{time.Minute, 1, 30 * time.Second},
+	}
+	for _, c := range cases {
+		if got := RetryDelay(c.base, c.attempt); got != c.want {
+			t.Errorf("RetryDelay(%s, %d) = %s, want %s", c.base, c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	if IsRetryableError(nil, "Connection refused") {
+		t.Fatalf("nil error must not be retried")
+	}
+	if !IsRetryableError(errors.New("exit status 255"), "ssh: connect to host example.com port 22: Connection refused\n") {
+		t.Fatalf("expected refused connection to be retryable")
+	}
+	if !IsRetryableError(errors.New("dial tcp: i/o timeout: connection timed out"), "") {
+		t.Fatalf("expected timeout in the error to be retryable")
+	}
+	if IsRetryableError(errors.New("exit status 255"), "Permission denied (publickey).\n") {
+		t.Fatalf("authentication failures must not be retried")
+	}
+}
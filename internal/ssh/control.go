@@ -0,0 +1,97 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// controlMasterArgs returns the ssh options that enable connection sharing
+// for conn, or nil when multiplexing is off.
+func controlMasterArgs(conn Connection) []string {
+	if !conn.ControlMaster || conn.ControlPath == "" {
+		return nil
+	}
+	return []string{"-o", "ControlMaster=auto", "-o", "ControlPath=" + conn.ControlPath}
+}
+
+// controlCheckTimeout bounds the `ssh -O check` probe of an existing master.
+const controlCheckTimeout = 2 * time.Second
+
+// SocketExists reports whether a live master connection is listening on
+// conn's ControlPath, so a new connection can multiplex through it instead
+// of doing a full handshake. Paths with ssh tokens (e.g. %C) are resolved by
+// ssh itself through `ssh -O check`.
+func SocketExists(conn Connection) bool {
+	if !conn.ControlMaster || conn.ControlPath == "" || runtime.GOOS == "windows" {
+		return false
+	}
+
+	// Skip spawning ssh when no socket could possibly match.
+	prefix, _, tokens := strings.Cut(conn.ControlPath, "%")
+	if !tokens {
+		fi, err := os.Stat(conn.ControlPath)
+		return err == nil && fi.Mode()&os.ModeSocket != 0
+	}
+	matches, _ := filepath.Glob(globEscape(prefix) + "*")
+	if len(matches) == 0 {
+		return false
+	}
+
+	args := []string{"-O", "check", "-o", "ControlPath=" + conn.ControlPath}
+	if conn.Port != 22 && conn.Port != 0 {
+		args = append(args, "-p", fmt.Sprintf("%d", conn.Port))
+	}
+	args = append(args, conn.Username+"@"+conn.Hostname)
+
+	ctx, cancel := context.WithTimeout(context.Background(), controlCheckTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, "ssh", args...).Run() == nil
+}
+
+// multiplexArgs returns the ssh options that reuse conn's existing master
+// connection without starting a new one, or nil when there is none.
+func multiplexArgs(conn Connection) []string {
+	if !SocketExists(conn) {
+		return nil
+	}
+	return []string{"-o", "ControlMaster=no", "-o", "ControlPath=" + conn.ControlPath}
+}
+
+func globEscape(s string) string {
+	r := strings.NewReplacer("*", "\\*", "?", "\\?", "[", "\\[")
+	return r.Replace(s)
+}
+
+// CleanupControlSockets removes control sockets in dir whose master
+// connection has gone away. ssh normally unlinks its socket on exit, but a
+// killed master leaves a stale file behind that makes later clients fail.
+func CleanupControlSockets(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		if e.Type()&os.ModeSocket == 0 {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		c, err := net.DialTimeout("unix", path, 200*time.Millisecond)
+		if err == nil {
+			_ = c.Close()
+			continue
+		}
+		_ = os.Remove(path)
+	}
+	return nil
+}
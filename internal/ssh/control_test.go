@@ -0,0 +1,199 @@
+package ssh
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestConnect_ControlMasterArgs(t *testing.T) {
+	cmd, tempKey, err := Connect(Connection{
+		Hostname:      "example.com",
+		Username:      "ubuntu",
+		ControlMaster: true,
+		ControlPath:   "/tmp/sockets/1-%C",
+	})
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+	if tempKey != nil {
+		defer tempKey.Cleanup()
+	}
+
+	args := strings.Join(cmd.Args, " ")
+	if !strings.Contains(args, " -o ControlMaster=auto -o ControlPath=/tmp/sockets/1-%C ") {
+		t.Fatalf("expected control master options, got: %q", args)
+	}
+
+	cmd, tempKey, err = ConnectSFTP(Connection{Hostname: "example.com", Username: "ubuntu"})
+	if err != nil {
+		t.Fatalf("ConnectSFTP returned error: %v", err)
+	}
+	if tempKey != nil {
+		defer tempKey.Cleanup()
+	}
+	if strings.Contains(strings.Join(cmd.Args, " "), "ControlMaster") {
+		t.Fatalf("control master options must be omitted when disabled")
+	}
+}
+
+func TestCleanupControlSockets_RemovesStaleOnly(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("control sockets are not used on Windows")
+	}
+	dir, err := os.MkdirTemp("", "sshthing-sock")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	livePath := filepath.Join(dir, "live")
+	live, err := net.Listen("unix", livePath)
+	if err != nil {
+		t.Fatalf("listen live: %v", err)
+	}
+	defer live.Close()
+
+	stalePath := filepath.Join(dir, "stale")
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: stalePath, Net: "unix"})
+	if err != nil {
+		t.Fatalf("listen stale: %v", err)
+	}
+	stale.SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	if err := CleanupControlSockets(dir); err != nil {
+		t.Fatalf("CleanupControlSockets: %v", err)
+	}
+	if _, err := os.Stat(livePath); err != nil {
+		t.Fatalf("expected live socket to be kept: %v", err)
+	}
+	if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+		t.Fatalf("expected stale socket to be removed, stat err: %v", err)
+	}
+}
+
+func TestConnectExec_ReusesLiveControlSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("control sockets are not used on Windows")
+	}
+	dir, err := os.MkdirTemp("", "sshthing-sock")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	conn := Connection{
+		Hostname:      "example.com",
+		Username:      "ubuntu",
+		ControlMaster: true,
+		ControlPath:   filepath.Join(dir, "1-master"),
+	}
+	cmd, _, err := ConnectExec(conn, "uptime")
+	if err != nil {
+		t.Fatalf("ConnectExec returned error: %v", err)
+	}
+	if strings.Contains(strings.Join(cmd.Args, " "), "ControlPath") {
+		t.Fatalf("expected no control path without a running master, got: %q", cmd.Args)
+	}
+
+	master, err := net.Listen("unix", conn.ControlPath)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer master.Close()
+
+	cmd, _, err = ConnectExec(conn, "uptime")
+	if err != nil {
+		t.Fatalf("ConnectExec returned error: %v", err)
+	}
+	args := strings.Join(cmd.Args, " ")
+	if !strings.Contains(args, " -o ControlMaster=no -o ControlPath="+conn.ControlPath+" ") {
+		t.Fatalf("expected the exec to multiplex through the master, got: %q", args)
+	}
+}
+
+func TestSocketExists_ResolvesTokensWithSSH(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of ssh")
+	}
+	bin := t.TempDir()
+	// Fake ssh: `-O check` succeeds only while the marker file exists.
+	marker := filepath.Join(bin, "master-up")
+	script := "#!/bin/sh\n[ \"$1\" = \"-O\" ] && [ -e " + marker + " ]\n"
+	if err := os.WriteFile(filepath.Join(bin, "ssh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	conn := Connection{
+		Hostname:      "example.com",
+		Username:      "ubuntu",
+		ControlMaster: true,
+		ControlPath:   filepath.Join(dir, "7-%C"),
+	}
+	if err := os.WriteFile(marker, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if SocketExists(conn) {
+		t.Fatalf("expected no master when nothing matches the socket prefix")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "7-0123abcd"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if !SocketExists(conn) {
+		t.Fatalf("expected ssh -O check to find the master")
+	}
+	if err := os.Remove(marker); err != nil {
+		t.Fatal(err)
+	}
+	if SocketExists(conn) {
+		t.Fatalf("expected a failed ssh -O check to report no master")
+	}
+}
+
+func TestControlSocketDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("control sockets are not used on Windows")
+	}
+	// t.TempDir is too long to stay under the length limit, which would
+	// fall back to the shared /tmp.
+	tmp, err := os.MkdirTemp("/tmp", "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmp) })
+	t.Setenv("TMPDIR", tmp)
+	dir, err := ControlSocketDir()
+	if err != nil {
+		t.Fatalf("ControlSocketDir failed: %v", err)
+	}
+	fi, err := os.Lstat(dir)
+	if err != nil || !fi.IsDir() || fi.Mode().Perm() != 0o700 || filepath.Dir(dir) != tmp {
+		t.Fatalf("expected a 0700 directory at %s, got %v (%v)", dir, fi, err)
+	}
+	if len(filepath.Join(dir, strings.Repeat("c", 40))) > 104-1-17 {
+		t.Fatalf("control path under %s is too long for a socket", dir)
+	}
+
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ControlSocketDir(); err == nil {
+		t.Fatalf("expected a group-readable socket dir to be rejected")
+	}
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ControlSocketDir(); err == nil {
+		t.Fatalf("expected a symlinked socket dir to be rejected")
+	}
+}
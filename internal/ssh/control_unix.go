@@ -0,0 +1,44 @@
+//go:build !windows
+
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// maxControlDirLen keeps ControlPath within the 104-byte socket path limit
+// on macOS: the directory, a slash, the 40-character %C hash and the
+// 17-character suffix ssh appends while it creates the socket.
+const maxControlDirLen = 104 - 1 - 1 - 40 - 17
+
+// ControlSocketDir returns the private directory for ssh control sockets,
+// $TMPDIR/sshthing-<uid> (or /tmp/sshthing-<uid> when $TMPDIR is too long
+// for a socket path). It is created 0700 and rejected if another user owns
+// it or it is not a plain directory.
+func ControlSocketDir() (string, error) {
+	name := fmt.Sprintf("sshthing-%d", os.Getuid())
+	dir := filepath.Join(os.TempDir(), name)
+	if len(dir) > maxControlDirLen {
+		dir = filepath.Join("/tmp", name)
+	}
+	if err := os.Mkdir(dir, 0o700); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !fi.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return "", fmt.Errorf("%s is owned by another user", dir)
+	}
+	if fi.Mode().Perm() != 0o700 {
+		return "", fmt.Errorf("%s must have mode 0700, has %o", dir, fi.Mode().Perm())
+	}
+	return dir, nil
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package ssh
+
+import "errors"
+
+// ControlSocketDir reports that ssh connection sharing is unavailable on
+// Windows.
+func ControlSocketDir() (string, error) {
+	return "", errors.New("control sockets are not supported on Windows")
+}
@@ -0,0 +1,97 @@
+package ssh
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	xssh "golang.org/x/crypto/ssh"
+)
+
+// fingerprintScanTimeout bounds a background ssh-keyscan run.
+const fingerprintScanTimeout = 10 * time.Second
+
+// fingerprintKeyOrder is the preference used when a server offers several
+// host keys, so the same key is reported on every scan.
+var fingerprintKeyOrder = []string{
+	xssh.KeyAlgoED25519,
+	xssh.KeyAlgoECDSA256,
+	xssh.KeyAlgoECDSA384,
+	xssh.KeyAlgoECDSA521,
+	xssh.KeyAlgoRSA,
+}
+
+// ScanFingerprint runs ssh-keyscan against host:port and returns the SHA256
+// fingerprint of its preferred host key, e.g. "SHA256:abc...".
+func ScanFingerprint(ctx context.Context, host string, port int) (string, error) {
+	if port == 0 {
+		port = 22
+	}
+	cmd := exec.CommandContext(ctx, "ssh-keyscan", "-T", "5", "-p", strconv.Itoa(port), host)
+	out, err := cmd.Output()
+	if err != nil && len(out) == 0 {
+		return "", fmt.Errorf("ssh-keyscan failed: %w", err)
+	}
+	return parseKeyscanFingerprint(string(out))
+}
+
+func parseKeyscanFingerprint(output string) (string, error) {
+	keys := map[string]xssh.PublicKey{}
+	sc := bufio.NewScanner(strings.NewReader(output))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(fields[2])
+		if err != nil {
+			continue
+		}
+		key, err := xssh.ParsePublicKey(raw)
+		if err != nil {
+			continue
+		}
+		keys[key.Type()] = key
+	}
+	for _, algo := range fingerprintKeyOrder {
+		if key, ok := keys[algo]; ok {
+			return xssh.FingerprintSHA256(key), nil
+		}
+	}
+	if len(keys) == 0 {
+		return "", fmt.Errorf("no host keys found")
+	}
+	types := make([]string, 0, len(keys))
+	for t := range keys {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return xssh.FingerprintSHA256(keys[types[0]]), nil
+}
+
+// emitFingerprint scans the server's host key in the background and hands
+// the fingerprint to conn.OnFingerprint. Failures are dropped; the scan is
+// informational and never blocks the session.
+func emitFingerprint(conn Connection) {
+	if conn.OnFingerprint == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), fingerprintScanTimeout)
+		defer cancel()
+		fp, err := ScanFingerprint(ctx, conn.Hostname, conn.Port)
+		if err == nil {
+			conn.OnFingerprint(fp)
+		}
+	}()
+}
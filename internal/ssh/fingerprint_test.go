@@ -0,0 +1,40 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	xssh "golang.org/x/crypto/ssh"
+)
+
+func TestParseKeyscanFingerprintPrefersEd25519(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := xssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyB64 := base64.StdEncoding.EncodeToString(key.Marshal())
+
+	output := strings.Join([]string{
+		"# example.com:22 SSH-2.0-OpenSSH_9.6",
+		"example.com ssh-rsa not-base64!",
+		"example.com ssh-ed25519 " + keyB64,
+	}, "\n")
+	fp, err := parseKeyscanFingerprint(output)
+	if err != nil {
+		t.Fatalf("parseKeyscanFingerprint returned error: %v", err)
+	}
+	if fp != xssh.FingerprintSHA256(key) || !strings.HasPrefix(fp, "SHA256:") {
+		t.Fatalf("unexpected fingerprint %q", fp)
+	}
+
+	if _, err := parseKeyscanFingerprint("# no keys\n"); err == nil {
+		t.Fatalf("expected error when no keys are present")
+	}
+}
@@ -0,0 +1,102 @@
+package ssh
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ForwardLocal builds a background `ssh -N -L` command that forwards
+// localhost:localPort to remoteHost:remotePort as seen from the SSH server.
+// The command is returned unstarted; the caller owns its lifecycle and must
+// clean up the returned temp key file once the process exits.
+func ForwardLocal(conn Connection, localPort int, remoteHost string, remotePort int) (*exec.Cmd, *TempKeyFile, error) {
+	if err := validateForwardPort(localPort); err != nil {
+		return nil, nil, fmt.Errorf("invalid local port: %w", err)
+	}
+	if err := validateForwardPort(remotePort); err != nil {
+		return nil, nil, fmt.Errorf("invalid remote port: %w", err)
+	}
+	remoteHost = strings.TrimSpace(remoteHost)
+	if remoteHost == "" {
+		return nil, nil, fmt.Errorf("remote host is required")
+	}
+	if strings.ContainsAny(remoteHost, " \t") {
+		return nil, nil, fmt.Errorf("remote host must not contain whitespace")
+	}
+
+	return forwardCommand(conn, "-L", fmt.Sprintf("%d:%s:%d", localPort, remoteHost, remotePort))
+}
+
+// ForwardDynamic builds a background `ssh -N -D` command that exposes a SOCKS5
+// proxy on localhost:localPort, tunnelling traffic out through the SSH server.
+// Lifecycle and cleanup rules match ForwardLocal.
+func ForwardDynamic(conn Connection, localPort int) (*exec.Cmd, *TempKeyFile, error) {
+	if err := validateForwardPort(localPort); err != nil {
+		return nil, nil, fmt.Errorf("invalid local port: %w", err)
+	}
+	return forwardCommand(conn, "-D", fmt.Sprintf("%d", localPort))
+}
+
+func forwardCommand(conn Connection, flag, spec string) (*exec.Cmd, *TempKeyFile, error) {
+	var tempKey *TempKeyFile
+	var args []string
+
+	args = append(args, "-N")
+	args = append(args, "-o", "ExitOnForwardFailure=yes")
+	args = append(args, customOptionArgs(conn)...)
+	args = append(args, hostKeyArgs(conn)...)
+	args = append(args, "-o", fmt.Sprintf("ServerAliveInterval=%d", keepAliveSeconds(conn.KeepAliveSeconds)))
+	args = append(args, proxyJumpArgs(conn)...)
+
+	if conn.Port != 22 && conn.Port != 0 {
+		args = append(args, "-p", fmt.Sprintf("%d", conn.Port))
+	}
+
+	if conn.PrivateKey != "" {
+		var err error
+		tempKey, err = newConnectionKeyFile(conn)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create temp key file: %w", err)
+		}
+		args = append(args, "-i", tempKey.Path())
+		args = append(args, identitiesOnlyArgs(conn)...)
+	}
+
+	passwordAuth := conn.PrivateKey == "" && conn.Password != ""
+	if passwordAuth {
+		args = append(args, "-o", "PreferredAuthentications=password,keyboard-interactive")
+		args = append(args, "-o", "PubkeyAuthentication=no")
+	} else {
+		// Tunnels run detached from the terminal, so never let ssh fall back
+		// to an interactive prompt that would draw over the TUI.
+		args = append(args, "-o", "BatchMode=yes")
+	}
+
+	args = append(args, flag, spec)
+
+	target := conn.Username + "@" + conn.Hostname
+	args = append(args, target)
+
+	cmd, cleanupHolder, err := prepareClientCommand("ssh", args, conn, tempKey)
+	if err != nil {
+		if tempKey != nil {
+			_ = tempKey.Cleanup()
+		}
+		return nil, nil, err
+	}
+	if tempKey == nil {
+		tempKey = cleanupHolder
+	} else {
+		tempKey.merge(cleanupHolder)
+	}
+
+	return cmd, tempKey, nil
+}
+
+func validateForwardPort(port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("port %d out of range (1-65535)", port)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestForwardLocal_BuildsTunnelArgs(t *testing.T) {
+	cmd, tempKey, err := ForwardLocal(Connection{
+		Hostname: "example.com",
+		Username: "ubuntu",
+		Port:     2222,
+	}, 15432, "db.internal", 5432)
+	if err != nil {
+		t.Fatalf("ForwardLocal returned error: %v", err)
+	}
+	if tempKey != nil {
+		defer tempKey.Cleanup()
+	}
+
+	args := strings.Join(cmd.Args, " ")
+	if !strings.HasPrefix(args, "ssh -N ") {
+		t.Fatalf("expected ssh -N prefix, got: %q", args)
+	}
+	if !strings.Contains(args, " -L 15432:db.internal:5432 ") {
+		t.Fatalf("expected -L spec in args, got: %q", args)
+	}
+	if !strings.Contains(args, " -p 2222 ") {
+		t.Fatalf("expected -p 2222 in args, got: %q", args)
+	}
+	if !strings.Contains(args, " -o BatchMode=yes ") {
+		t.Fatalf("expected BatchMode for non-password tunnel, got: %q", args)
+	}
+	if !strings.HasSuffix(args, " ubuntu@example.com") {
+		t.Fatalf("expected target at end, got: %q", args)
+	}
+	if cmd.Stdin != nil || cmd.Stdout != nil {
+		t.Fatalf("tunnel command must not attach to the terminal")
+	}
+}
+
+func TestForwardLocal_RejectsInvalidInput(t *testing.T) {
+	conn := Connection{Hostname: "example.com", Username: "ubuntu"}
+	tests := []struct {
+		name       string
+		localPort  int
+		remoteHost string
+		remotePort int
+	}{
+		{name: "zero local port", localPort: 0, remoteHost: "localhost", remotePort: 80},
+		{name: "local port too large", localPort: 70000, remoteHost: "localhost", remotePort: 80},
+		{name: "zero remote port", localPort: 8080, remoteHost: "localhost", remotePort: 0},
+		{name: "empty remote host", localPort: 8080, remoteHost: "  ", remotePort: 80},
+		{name: "whitespace in remote host", localPort: 8080, remoteHost: "a b", remotePort: 80},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := ForwardLocal(conn, tc.localPort, tc.remoteHost, tc.remotePort); err == nil {
+				t.Fatalf("expected error for %s", tc.name)
+			}
+		})
+	}
+}
+
+func TestForwardDynamic_BuildsSocksArgs(t *testing.T) {
+	cmd, tempKey, err := ForwardDynamic(Connection{
+		Hostname: "example.com",
+		Username: "ubuntu",
+	}, 1080)
+	if err != nil {
+		t.Fatalf("ForwardDynamic returned error: %v", err)
+	}
+	if tempKey != nil {
+		defer tempKey.Cleanup()
+	}
+
+	args := strings.Join(cmd.Args, " ")
+	if !strings.HasPrefix(args, "ssh -N ") {
+		t.Fatalf("expected ssh -N prefix, got: %q", args)
+	}
+	if !strings.Contains(args, " -D 1080 ") {
+		t.Fatalf("expected -D 1080 in args, got: %q", args)
+	}
+	if strings.Contains(args, " -L ") {
+		t.Fatalf("dynamic forward must not include -L, got: %q", args)
+	}
+	if !strings.HasSuffix(args, " ubuntu@example.com") {
+		t.Fatalf("expected target at end, got: %q", args)
+	}
+
+	if _, _, err := ForwardDynamic(Connection{Hostname: "example.com", Username: "ubuntu"}, 0); err == nil {
+		t.Fatalf("expected error for zero local port")
+	}
+}
@@ -0,0 +1,177 @@
+package ssh
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// deniedOptions are ssh options, keyed in lower case, that run local commands,
+// load local code, forward local resources or override host key checking.
+// They are never accepted as per-host options.
+var deniedOptions = map[string]bool{
+	"proxycommand":          true,
+	"proxyusefdpass":        true,
+	"localcommand":          true,
+	"permitlocalcommand":    true,
+	"knownhostscommand":     true,
+	"pkcs11provider":        true,
+	"securitykeyprovider":   true,
+	"stricthostkeychecking": true,
+	"userknownhostsfile":    true,
+	"globalknownhostsfile":  true,
+	"hostkeyalias":          true,
+	"forwardagent":          true,
+	"remoteforward":         true,
+	"include":               true,
+	"match":                 true,
+}
+
+// syncedOptions are the ssh options, keyed in lower case, that are accepted
+// from sync. Synced hosts come from another device, so only options that tune
+// the connection itself are kept.
+var syncedOptions = map[string]bool{
+	"addressfamily":                true,
+	"ciphers":                      true,
+	"compression":                  true,
+	"connectionattempts":           true,
+	"connecttimeout":               true,
+	"gssapiauthentication":         true,
+	"hostkeyalgorithms":            true,
+	"identitiesonly":               true,
+	"ipqos":                        true,
+	"kbdinteractiveauthentication": true,
+	"kexalgorithms":                true,
+	"loglevel":                     true,
+	"macs":                         true,
+	"numberofpasswordprompts":      true,
+	"passwordauthentication":       true,
+	"preferredauthentications":     true,
+	"pubkeyacceptedalgorithms":     true,
+	"pubkeyacceptedkeytypes":       true,
+	"pubkeyauthentication":         true,
+	"rekeylimit":                   true,
+	"requesttty":                   true,
+	"serveralivecountmax":          true,
+	"serveraliveinterval":          true,
+	"tcpkeepalive":                 true,
+	"visualhostkey":                true,
+}
+
+// validOptionName reports whether key is a plain ssh option name. Anything
+// else could smuggle extra words or lines into the ssh command or config.
+func validOptionName(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// OptionAllowed reports whether key=value may be used as a per-host ssh
+// option.
+func OptionAllowed(key, value string) bool {
+	return validOptionName(key) && !strings.ContainsAny(value, "\r\n") && !deniedOptions[strings.ToLower(key)]
+}
+
+// SyncedOptionAllowed reports whether key=value may be accepted from sync.
+func SyncedOptionAllowed(key, value string) bool {
+	return OptionAllowed(key, value) && syncedOptions[strings.ToLower(key)]
+}
+
+// FilterOptions splits opts into the options OptionAllowed accepts and the
+// sorted names of the rejected ones. Names that are not plain option names
+// are quoted.
+func FilterOptions(opts map[string]string) (map[string]string, []string) {
+	return filterOptions(opts, OptionAllowed)
+}
+
+// FilterSyncedOptions is FilterOptions for options that arrived by sync.
+func FilterSyncedOptions(opts map[string]string) (map[string]string, []string) {
+	return filterOptions(opts, SyncedOptionAllowed)
+}
+
+func filterOptions(opts map[string]string, allowed func(key, value string) bool) (map[string]string, []string) {
+	var kept map[string]string
+	var dropped []string
+	for _, k := range sortedOptionKeys(opts) {
+		if !allowed(k, opts[k]) {
+			if !validOptionName(k) {
+				k = fmt.Sprintf("%q", k)
+			}
+			dropped = append(dropped, k)
+			continue
+		}
+		if kept == nil {
+			kept = map[string]string{}
+		}
+		kept[k] = opts[k]
+	}
+	return kept, dropped
+}
+
+// ParseOptions parses `Key=Value` lines (one per line) into ssh -o options.
+// Blank lines and lines starting with # are ignored; options that
+// OptionAllowed rejects are an error.
+func ParseOptions(text string) (map[string]string, error) {
+	opts := map[string]string{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("line %d: expected Key=Value", i+1)
+		}
+		if !validOptionName(key) {
+			return nil, fmt.Errorf("line %d: invalid option name %q", i+1, key)
+		}
+		if !OptionAllowed(key, value) {
+			return nil, fmt.Errorf("line %d: option %s is not allowed", i+1, key)
+		}
+		opts[key] = value
+	}
+	if len(opts) == 0 {
+		return nil, nil
+	}
+	return opts, nil
+}
+
+// FormatOptions renders options as sorted `Key=Value` lines, the inverse of
+// ParseOptions.
+func FormatOptions(opts map[string]string) string {
+	lines := make([]string, 0, len(opts))
+	for _, k := range sortedOptionKeys(opts) {
+		lines = append(lines, k+"="+opts[k])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// customOptionArgs returns `-o Key=Value` arguments for conn's per-host
+// options. ssh uses the first value it sees for an option, so callers place
+// these ahead of the built-in defaults to let hosts override them. Denied
+// options stored before the denylist existed are skipped.
+func customOptionArgs(conn Connection) []string {
+	var args []string
+	opts, _ := FilterOptions(conn.Options)
+	for _, k := range sortedOptionKeys(opts) {
+		args = append(args, "-o", k+"="+opts[k])
+	}
+	return args
+}
+
+func sortedOptionKeys(opts map[string]string) []string {
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,87 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	opts, err := ParseOptions("ServerAliveCountMax=5\n\n# comment\n IPQoS = throughput \nCiphers=aes256-gcm@openssh.com,aes128-ctr")
+	if err != nil {
+		t.Fatalf("ParseOptions returned error: %v", err)
+	}
+	if len(opts) != 3 || opts["IPQoS"] != "throughput" || opts["Ciphers"] != "aes256-gcm@openssh.com,aes128-ctr" {
+		t.Fatalf("unexpected options: %v", opts)
+	}
+	if got := FormatOptions(opts); got != "Ciphers=aes256-gcm@openssh.com,aes128-ctr\nIPQoS=throughput\nServerAliveCountMax=5" {
+		t.Fatalf("unexpected formatted options: %q", got)
+	}
+
+	for _, bad := range []string{"NoEquals", "=value", "Key=", "Bad Key=1", "Proxy-Command=x", "ProxyCommand=nc %h %p", "localcommand=id", "PermitLocalCommand=yes", "KnownHostsCommand=/bin/true", "StrictHostKeyChecking=no", "PKCS11Provider=/tmp/x.so", "UserKnownHostsFile=/dev/null", "ForwardAgent=yes", "RemoteForward=9000 localhost:22", "HostKeyAlias=other"} {
+		if _, err := ParseOptions(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestFilterOptions(t *testing.T) {
+	kept, dropped := FilterOptions(map[string]string{"IPQoS": "throughput", "ProxyCommand": "sh -c id", "LocalCommand": "id"})
+	if len(kept) != 1 || kept["IPQoS"] != "throughput" {
+		t.Fatalf("unexpected kept options: %v", kept)
+	}
+	if strings.Join(dropped, ",") != "LocalCommand,ProxyCommand" {
+		t.Fatalf("unexpected dropped options: %v", dropped)
+	}
+
+	// Synced keys are not checked by ParseOptions, so FilterOptions must
+	// reject names and values that would add words or lines of their own.
+	kept, dropped = FilterOptions(map[string]string{
+		"ProxyCommand sh -c id #": "x",
+		"IPQoS\nProxyCommand":     "id",
+		"Ciphers":                 "aes128-ctr\nProxyCommand id",
+		"ConnectTimeout":          "5",
+	})
+	if len(kept) != 1 || kept["ConnectTimeout"] != "5" {
+		t.Fatalf("unexpected kept options: %v", kept)
+	}
+	if len(dropped) != 3 || !strings.Contains(strings.Join(dropped, ","), `"IPQoS\nProxyCommand"`) {
+		t.Fatalf("unexpected dropped options: %v", dropped)
+	}
+}
+
+func TestFilterSyncedOptions(t *testing.T) {
+	kept, dropped := FilterSyncedOptions(map[string]string{"ServerAliveInterval": "30", "ciphers": "aes128-ctr", "IdentityAgent": "/tmp/agent", "User": "root"})
+	if len(kept) != 2 || kept["ServerAliveInterval"] != "30" || kept["ciphers"] != "aes128-ctr" {
+		t.Fatalf("unexpected kept options: %v", kept)
+	}
+	if strings.Join(dropped, ",") != "IdentityAgent,User" {
+		t.Fatalf("unexpected dropped options: %v", dropped)
+	}
+}
+
+func TestConnect_CustomOptionsPrecedeDefaults(t *testing.T) {
+	cmd, tempKey, err := Connect(Connection{
+		Hostname: "example.com",
+		Username: "ubuntu",
+		Options:  map[string]string{"ServerAliveInterval": "5", "IPQoS": "throughput", "ProxyCommand": "sh -c id"},
+	})
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+	if tempKey != nil {
+		defer tempKey.Cleanup()
+	}
+
+	args := strings.Join(cmd.Args, " ")
+	custom := strings.Index(args, "-o ServerAliveInterval=5")
+	def := strings.Index(args, "-o ServerAliveInterval=60")
+	if custom < 0 || !strings.Contains(args, "-o IPQoS=throughput") {
+		t.Fatalf("expected custom options in args, got: %q", args)
+	}
+	if def >= 0 && def < custom {
+		t.Fatalf("expected custom options before defaults, got: %q", args)
+	}
+	if strings.Contains(args, "ProxyCommand") {
+		t.Fatalf("expected denied option to be dropped, got: %q", args)
+	}
+}
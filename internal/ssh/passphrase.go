@@ -0,0 +1,69 @@
+package ssh
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	xssh "golang.org/x/crypto/ssh"
+)
+
+// PassphrasePromptFunc returns the passphrase for an encrypted private key.
+// The returned slice is zeroed by the caller once the key has been decrypted.
+type PassphrasePromptFunc func() ([]byte, error)
+
+// ErrPassphraseRequired is returned when a private key is passphrase-protected
+// and the connection has no way to obtain the passphrase.
+var ErrPassphraseRequired = errors.New("private key is passphrase-protected")
+
+// IsPassphraseProtected reports whether privateKey is encrypted.
+func IsPassphraseProtected(privateKey string) bool {
+	_, err := xssh.ParsePrivateKey(normalizePrivateKeyForFile(privateKey))
+	var missing *xssh.PassphraseMissingError
+	return errors.As(err, &missing)
+}
+
+// DecryptPrivateKey decrypts an encrypted private key and returns it as an
+// unencrypted OpenSSH PEM block suitable for `ssh -i`.
+func DecryptPrivateKey(privateKey string, passphrase []byte) (string, error) {
+	raw, err := xssh.ParseRawPrivateKeyWithPassphrase(normalizePrivateKeyForFile(privateKey), passphrase)
+	if err != nil {
+		if errors.Is(err, x509.IncorrectPasswordError) {
+			return "", fmt.Errorf("incorrect passphrase")
+		}
+		return "", fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+	block, err := xssh.MarshalPrivateKey(raw, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode decrypted key: %w", err)
+	}
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+// newConnectionKeyFile writes conn's private key to a temp file, decrypting it
+// first when it is passphrase-protected.
+func newConnectionKeyFile(conn Connection) (*TempKeyFile, error) {
+	if !IsPassphraseProtected(conn.PrivateKey) {
+		return NewTempKeyFile(conn.PrivateKey)
+	}
+	if conn.PassphrasePrompt == nil {
+		return nil, ErrPassphraseRequired
+	}
+	passphrase, err := conn.PassphrasePrompt()
+	if err != nil {
+		return nil, err
+	}
+	decrypted, err := DecryptPrivateKey(conn.PrivateKey, passphrase)
+	zeroBytes(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewTempKeyFile(decrypted)
+}
+
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
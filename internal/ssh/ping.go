@@ -0,0 +1,116 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// pingTimeout bounds each reachability check so an unreachable host does not
+// hold up a round.
+const pingTimeout = 2 * time.Second
+
+// maxConcurrentPings limits how many dials a round runs at once.
+const maxConcurrentPings = 16
+
+// PingResult is the outcome of the last reachability check for a host.
+type PingResult struct {
+	Reachable bool
+	Latency   time.Duration // TCP connect time; zero when unreachable
+	CheckedAt time.Time
+}
+
+// PingTarget names the address to check for a host.
+type PingTarget struct {
+	HostID   int
+	Hostname string
+	Port     int
+}
+
+// HostPingManager records whether hosts accept TCP connections on their SSH
+// port. It is safe for concurrent use.
+type HostPingManager struct {
+	mu      sync.Mutex
+	results map[int]PingResult
+	dial    func(network, addr string, timeout time.Duration) (net.Conn, error)
+}
+
+func NewHostPingManager() *HostPingManager {
+	return &HostPingManager{
+		results: make(map[int]PingResult),
+		dial:    net.DialTimeout,
+	}
+}
+
+// Result returns the last result for hostID; ok is false if it was never
+// checked.
+func (m *HostPingManager) Result(hostID int) (PingResult, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.results[hostID]
+	return r, ok
+}
+
+// CheckAll dials the targets concurrently and records the results. Results
+// for hosts not in targets are dropped.
+func (m *HostPingManager) CheckAll(targets []PingTarget) {
+	results := make(map[int]PingResult, len(targets))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentPings)
+	for _, t := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t PingTarget) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r := m.check(t)
+			mu.Lock()
+			results[t.HostID] = r
+			mu.Unlock()
+		}(t)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	m.results = results
+	m.mu.Unlock()
+}
+
+func (m *HostPingManager) check(t PingTarget) PingResult {
+	port := t.Port
+	if port == 0 {
+		port = 22
+	}
+	start := time.Now()
+	conn, err := m.dial("tcp", net.JoinHostPort(t.Hostname, fmt.Sprintf("%d", port)), pingTimeout)
+	if err != nil {
+		return PingResult{CheckedAt: time.Now()}
+	}
+	_ = conn.Close()
+	return PingResult{Reachable: true, Latency: time.Since(start), CheckedAt: time.Now()}
+}
+
+// TestConnection reports whether hostname:port accepts a TCP connection and
+// answers with an SSH version banner within timeout.
+func TestConnection(hostname string, port int, timeout time.Duration) error {
+	if port == 0 {
+		port = 22
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(hostname, fmt.Sprintf("%d", port)), timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	banner := make([]byte, 4)
+	if _, err := io.ReadFull(conn, banner); err != nil {
+		return fmt.Errorf("no SSH banner: %w", err)
+	}
+	if string(banner) != "SSH-" {
+		return fmt.Errorf("unexpected banner %q", banner)
+	}
+	return nil
+}
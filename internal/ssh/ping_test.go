@@ -0,0 +1,72 @@
+package ssh
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestHostPingManager_CheckAll(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	open := ln.Addr().(*net.TCPAddr).Port
+
+	closedLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := closedLn.Addr().(*net.TCPAddr).Port
+	closedLn.Close()
+
+	m := NewHostPingManager()
+	if _, ok := m.Result(1); ok {
+		t.Fatalf("expected no result before the first check")
+	}
+	m.CheckAll([]PingTarget{
+		{HostID: 1, Hostname: "127.0.0.1", Port: open},
+		{HostID: 2, Hostname: "127.0.0.1", Port: closed},
+	})
+
+	if r, ok := m.Result(1); !ok || !r.Reachable || r.CheckedAt.IsZero() {
+		t.Fatalf("expected host 1 reachable, got %+v (%v)", r, ok)
+	}
+	if r, ok := m.Result(2); !ok || r.Reachable {
+		t.Fatalf("expected host 2 unreachable, got %+v (%v)", r, ok)
+	}
+
+	m.CheckAll([]PingTarget{{HostID: 1, Hostname: "127.0.0.1", Port: open}})
+	if _, ok := m.Result(2); ok {
+		t.Fatalf("expected removed host to be dropped")
+	}
+}
+
+func TestTestConnectionRequiresSSHBanner(t *testing.T) {
+	serve := func(greeting string) int {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { ln.Close() })
+		go func() {
+			for {
+				c, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				_, _ = c.Write([]byte(greeting))
+				c.Close()
+			}
+		}()
+		return ln.Addr().(*net.TCPAddr).Port
+	}
+
+	if err := TestConnection("127.0.0.1", serve("SSH-2.0-OpenSSH_9.6\r\n"), time.Second); err != nil {
+		t.Fatalf("expected SSH server to pass, got %v", err)
+	}
+	if err := TestConnection("127.0.0.1", serve("HTTP/1.1 400 Bad Request\r\n"), time.Second); err == nil {
+		t.Fatalf("expected non-SSH server to fail")
+	}
+}
@@ -0,0 +1,65 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// wrapWithRecorder re-launches cmd under script(1) so everything printed to
+// the terminal during the session is captured to path.
+func wrapWithRecorder(cmd *exec.Cmd, path string) (*exec.Cmd, error) {
+	if !HasTool("script") {
+		return nil, fmt.Errorf("session recording requires the 'script' utility")
+	}
+	args, err := recorderArgs(runtime.GOOS, path, cmd.Args)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording file: %w", err)
+	}
+	_ = f.Close()
+
+	wrapped := exec.Command("script", args...)
+	wrapped.Env = cmd.Env
+	wrapped.ExtraFiles = cmd.ExtraFiles
+	return wrapped, nil
+}
+
+// recorderArgs returns the script(1) arguments for the given platform. BSD
+// script (macOS) takes the command as trailing arguments, while util-linux
+// script needs it as a single shell string passed via -c.
+func recorderArgs(goos, path string, command []string) ([]string, error) {
+	switch goos {
+	case "darwin", "freebsd", "openbsd", "netbsd":
+		args := []string{"-q", "-F", path}
+		return append(args, command...), nil
+	case "linux":
+		return []string{"-q", "-f", "-c", shellJoin(command), path}, nil
+	default:
+		return nil, fmt.Errorf("session recording is not supported on %s", goos)
+	}
+}
+
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
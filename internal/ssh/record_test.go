@@ -0,0 +1,48 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecorderArgs_PerPlatform(t *testing.T) {
+	command := []string{"ssh", "-p", "2222", "-o", "ServerAliveInterval=60", "ubuntu@example.com"}
+
+	darwin, err := recorderArgs("darwin", "/tmp/rec.txt", command)
+	if err != nil {
+		t.Fatalf("darwin: unexpected error: %v", err)
+	}
+	if got := strings.Join(darwin, " "); got != "-q -F /tmp/rec.txt ssh -p 2222 -o ServerAliveInterval=60 ubuntu@example.com" {
+		t.Fatalf("darwin: unexpected args: %q", got)
+	}
+
+	linux, err := recorderArgs("linux", "/tmp/rec.txt", command)
+	if err != nil {
+		t.Fatalf("linux: unexpected error: %v", err)
+	}
+	if len(linux) != 5 || linux[2] != "-c" || linux[4] != "/tmp/rec.txt" {
+		t.Fatalf("linux: unexpected args: %q", linux)
+	}
+	if linux[3] != "ssh -p 2222 -o ServerAliveInterval=60 ubuntu@example.com" {
+		t.Fatalf("linux: unexpected command string: %q", linux[3])
+	}
+
+	if _, err := recorderArgs("windows", "C:\\rec.txt", command); err == nil {
+		t.Fatalf("expected error on windows")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":       "plain",
+		"":            "''",
+		"two words":   "'two words'",
+		"it's":        `'it'\''s'`,
+		"/tmp/a b/$x": "'/tmp/a b/$x'",
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Fatalf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// DeployPublicKey appends publicKey to ~/.ssh/authorized_keys on the remote
+// host unless it is already there. conn must still authenticate with a key
+// the host accepts, usually the one being rotated out.
+func DeployPublicKey(conn Connection, publicKey string) error {
+	key := strings.TrimSpace(publicKey)
+	if key == "" {
+		return fmt.Errorf("public key is empty")
+	}
+	q := shellQuote(key)
+	script := "umask 077; mkdir -p ~/.ssh && touch ~/.ssh/authorized_keys && " +
+		"{ grep -qxF " + q + " ~/.ssh/authorized_keys || printf '%s\\n' " + q + " >> ~/.ssh/authorized_keys; }"
+	return runRemote(conn, script)
+}
+
+// RevokePublicKey removes every authorized_keys line holding publicKey's key
+// material, whatever its options or comment. The file is rewritten in place
+// so its permissions are kept.
+func RevokePublicKey(conn Connection, publicKey string) error {
+	material := authorizedKeyMaterial(publicKey)
+	if material == "" {
+		return fmt.Errorf("public key is empty")
+	}
+	script := "f=~/.ssh/authorized_keys; [ -f \"$f\" ] || exit 0; t=$(mktemp) || exit 1; " +
+		"grep -vF " + shellQuote(material) + " \"$f\" > \"$t\"; cat \"$t\" > \"$f\" && rm -f \"$t\""
+	return runRemote(conn, script)
+}
+
+// VerifyKeyLogin checks that conn's private key alone can log in, without
+// falling back to the agent, default identities or a password prompt.
+func VerifyKeyLogin(conn Connection) error {
+	opts := map[string]string{"BatchMode": "yes", "IdentitiesOnly": "yes"}
+	for k, v := range conn.Options {
+		if _, ok := opts[k]; !ok {
+			opts[k] = v
+		}
+	}
+	conn.Options = opts
+	conn.Password = ""
+	return runRemote(conn, "true")
+}
+
+// authorizedKeyMaterial returns the "type base64" part of a public key line.
+func authorizedKeyMaterial(publicKey string) string {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return ""
+	}
+	return fields[0] + " " + fields[1]
+}
+
+// runRemote runs script on the host with stdin closed and returns ssh's
+// error output on failure.
+func runRemote(conn Connection, script string) error {
+	if conn.Password == "" {
+		opts := map[string]string{"BatchMode": "yes"}
+		for k, v := range conn.Options {
+			opts[k] = v
+		}
+		conn.Options = opts
+	}
+	cmd, tempKey, err := ConnectExec(conn, script)
+	if err != nil {
+		return err
+	}
+	if tempKey != nil {
+		defer tempKey.Cleanup()
+	}
+	var stderr bytes.Buffer
+	cmd.Stdin = nil
+	cmd.Stdout = nil
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, lastLine(msg))
+		}
+		return err
+	}
+	return nil
+}
+
+func lastLine(s string) string {
+	if i := strings.LastIndex(s, "\n"); i >= 0 {
+		return strings.TrimSpace(s[i+1:])
+	}
+	return s
+}
@@ -0,0 +1,60 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDeployAndRevokePublicKey(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of ssh")
+	}
+	bin := t.TempDir()
+	// Fake ssh: run the remote command (the last argument) locally.
+	script := "#!/bin/sh\nfor a; do last=$a; done\nexec sh -c \"$last\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "ssh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	authKeys := filepath.Join(home, ".ssh", "authorized_keys")
+
+	conn := Connection{Hostname: "example.com", Username: "deploy"}
+	oldKey := "ssh-ed25519 AAAAold old@laptop"
+	newKey := "ssh-ed25519 AAAAnew new@laptop"
+	if err := DeployPublicKey(conn, oldKey); err != nil {
+		t.Fatalf("DeployPublicKey failed: %v", err)
+	}
+	if err := DeployPublicKey(conn, newKey); err != nil {
+		t.Fatalf("DeployPublicKey failed: %v", err)
+	}
+	if err := DeployPublicKey(conn, newKey); err != nil {
+		t.Fatalf("DeployPublicKey failed: %v", err)
+	}
+	data, err := os.ReadFile(authKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != oldKey+"\n"+newKey+"\n" {
+		t.Fatalf("unexpected authorized_keys after deploy:\n%s", got)
+	}
+	if info, _ := os.Stat(authKeys); info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected authorized_keys mode 0600, got %v", info.Mode().Perm())
+	}
+
+	// The old key may carry options and a different comment on the server.
+	if err := os.WriteFile(authKeys, []byte("from=\"10.0.0.0/8\" "+oldKey+" edited\n"+newKey+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := RevokePublicKey(conn, oldKey); err != nil {
+		t.Fatalf("RevokePublicKey failed: %v", err)
+	}
+	data, _ = os.ReadFile(authKeys)
+	if got := string(data); strings.Contains(got, "AAAAold") || !strings.Contains(got, newKey) {
+		t.Fatalf("unexpected authorized_keys after revoke:\n%s", got)
+	}
+}
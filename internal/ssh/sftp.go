@@ -0,0 +1,191 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/sftp"
+)
+
+// SFTPSession is an SFTP client speaking over the system ssh binary's "sftp"
+// subsystem, so it honours the same auth, host-key and per-host options as
+// interactive sessions.
+type SFTPSession struct {
+	Client *sftp.Client
+
+	cmd     *exec.Cmd
+	tempKey *TempKeyFile
+	stderr  *bytes.Buffer
+}
+
+// NewSFTPSession prepares an `ssh -s <target> sftp` command for conn. Key
+// material is written (and decrypted) here, so the passphrase prompt is only
+// needed until this returns; Start then connects. The caller must Close the
+// session, even when Start fails.
+func NewSFTPSession(conn Connection) (*SFTPSession, error) {
+	cmd, tempKey, err := sftpSubsystemCommand(conn)
+	if err != nil {
+		return nil, err
+	}
+	return &SFTPSession{cmd: cmd, tempKey: tempKey, stderr: &bytes.Buffer{}}, nil
+}
+
+// Start launches ssh in the background and opens an SFTP client over its
+// stdin/stdout.
+func (s *SFTPSession) Start() error {
+	stdin, err := s.cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := s.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	s.cmd.Stderr = s.stderr
+
+	if err := s.cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ssh: %w", err)
+	}
+
+	client, err := sftp.NewClientPipe(stdout, stdin)
+	if err != nil {
+		_ = stdin.Close()
+		_ = s.cmd.Process.Kill()
+		if msg := strings.TrimSpace(s.stderr.String()); msg != "" {
+			return fmt.Errorf("sftp handshake failed: %s", firstLine(msg))
+		}
+		return fmt.Errorf("sftp handshake failed: %w", err)
+	}
+	s.Client = client
+	return nil
+}
+
+// Close shuts down the SFTP client and waits for the ssh process to exit.
+func (s *SFTPSession) Close() error {
+	if s == nil {
+		return nil
+	}
+	var err error
+	if s.Client != nil {
+		err = s.Client.Close()
+	}
+	if s.cmd != nil && s.cmd.Process != nil {
+		_ = s.cmd.Wait()
+	}
+	if s.tempKey != nil {
+		_ = s.tempKey.Cleanup()
+	}
+	return err
+}
+
+// Download copies the remote file to localPath, reporting bytes copied to progress.
+func (s *SFTPSession) Download(remotePath, localPath string, progress io.Writer) (int64, error) {
+	src, err := s.Client.Open(remotePath)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(io.MultiWriter(dst, progress), src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+// Upload copies localPath into remoteDir, keeping the file's base name.
+func (s *SFTPSession) Upload(localPath, remoteDir string, progress io.Writer) (int64, error) {
+	src, err := os.Open(localPath)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.IsDir() {
+		return 0, fmt.Errorf("%s is a directory", localPath)
+	}
+
+	dst, err := s.Client.Create(path.Join(remoteDir, filepath.Base(localPath)))
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(io.MultiWriter(dst, progress), src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+func sftpSubsystemCommand(conn Connection) (*exec.Cmd, *TempKeyFile, error) {
+	var tempKey *TempKeyFile
+	var args []string
+
+	args = append(args, "-T")
+	args = append(args, customOptionArgs(conn)...)
+	args = append(args, hostKeyArgs(conn)...)
+	args = append(args, "-o", fmt.Sprintf("ServerAliveInterval=%d", keepAliveSeconds(conn.KeepAliveSeconds)))
+	args = append(args, controlMasterArgs(conn)...)
+	args = append(args, proxyJumpArgs(conn)...)
+
+	if conn.Port != 22 && conn.Port != 0 {
+		args = append(args, "-p", fmt.Sprintf("%d", conn.Port))
+	}
+
+	if conn.PrivateKey != "" {
+		var err error
+		tempKey, err = newConnectionKeyFile(conn)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create temp key file: %w", err)
+		}
+		args = append(args, "-i", tempKey.Path())
+		args = append(args, identitiesOnlyArgs(conn)...)
+	}
+
+	passwordAuth := conn.PrivateKey == "" && conn.Password != ""
+	if passwordAuth {
+		args = append(args, "-o", "PreferredAuthentications=password,keyboard-interactive")
+		args = append(args, "-o", "PubkeyAuthentication=no")
+	} else {
+		// The TUI keeps the terminal, so ssh must not fall back to a prompt.
+		args = append(args, "-o", "BatchMode=yes")
+	}
+
+	target := conn.Username + "@" + conn.Hostname
+	args = append(args, "-s", target, "sftp")
+
+	cmd, cleanupHolder, err := prepareClientCommand("ssh", args, conn, tempKey)
+	if err != nil {
+		if tempKey != nil {
+			_ = tempKey.Cleanup()
+		}
+		return nil, nil, err
+	}
+	if tempKey == nil {
+		tempKey = cleanupHolder
+	} else {
+		tempKey.merge(cleanupHolder)
+	}
+
+	return cmd, tempKey, nil
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
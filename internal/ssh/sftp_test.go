@@ -0,0 +1,35 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSFTPSubsystemCommand_BuildsArgs(t *testing.T) {
+	cmd, tempKey, err := sftpSubsystemCommand(Connection{
+		Hostname: "example.com",
+		Username: "ubuntu",
+		Port:     2222,
+		Options:  map[string]string{"IPQoS": "throughput"},
+	})
+	if err != nil {
+		t.Fatalf("sftpSubsystemCommand returned error: %v", err)
+	}
+	if tempKey != nil {
+		defer tempKey.Cleanup()
+	}
+
+	args := strings.Join(cmd.Args, " ")
+	if !strings.HasPrefix(args, "ssh -T -o IPQoS=throughput ") {
+		t.Fatalf("expected ssh -T prefix with custom options first, got: %q", args)
+	}
+	if !strings.Contains(args, " -p 2222 ") {
+		t.Fatalf("expected -p 2222 in args, got: %q", args)
+	}
+	if !strings.Contains(args, " -o BatchMode=yes ") {
+		t.Fatalf("expected BatchMode for non-password session, got: %q", args)
+	}
+	if !strings.HasSuffix(args, " -s ubuntu@example.com sftp") {
+		t.Fatalf("expected sftp subsystem request at end, got: %q", args)
+	}
+}
@@ -0,0 +1,77 @@
+package ssh
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+// ConfigKeyFileName is the file in a GenerateSSHConfig key directory that
+// holds the private key of host hostID.
+func ConfigKeyFileName(hostID int) string {
+	return fmt.Sprintf("host_%d", hostID)
+}
+
+// HasConfigKey reports whether GenerateSSHConfig references a key file for h.
+func HasConfigKey(h db.HostModel) bool {
+	return h.KeyData != "" && h.KeyType != "password"
+}
+
+// GenerateSSHConfig renders hosts as ~/.ssh/config blocks. Hosts with a
+// stored key get an IdentityFile in keyDir named by ConfigKeyFileName; the
+// caller is responsible for writing those files. Labels become Host aliases,
+// with spaces replaced by dashes and duplicates numbered.
+func GenerateSSHConfig(hosts []db.HostModel, keyDir string) (string, error) {
+	var b strings.Builder
+	seen := map[string]bool{}
+	for i, h := range hosts {
+		if strings.TrimSpace(h.Hostname) == "" {
+			return "", fmt.Errorf("host %d has no hostname", h.ID)
+		}
+		alias := strings.Join(strings.Fields(h.Label), "-")
+		if alias == "" {
+			alias = h.Hostname
+		}
+		base := alias
+		for n := 2; seen[strings.ToLower(alias)]; n++ {
+			alias = fmt.Sprintf("%s-%d", base, n)
+		}
+		seen[strings.ToLower(alias)] = true
+
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Host %s\n", alias)
+		fmt.Fprintf(&b, "    HostName %s\n", h.Hostname)
+		if h.Username != "" {
+			fmt.Fprintf(&b, "    User %s\n", h.Username)
+		}
+		port := h.Port
+		if port == 0 {
+			port = 22
+		}
+		fmt.Fprintf(&b, "    Port %d\n", port)
+		if HasConfigKey(h) {
+			if keyDir == "" {
+				return "", fmt.Errorf("a key directory is required for %s", alias)
+			}
+			fmt.Fprintf(&b, "    IdentityFile %s\n", configQuote(filepath.Join(keyDir, ConfigKeyFileName(h.ID))))
+			b.WriteString("    IdentitiesOnly yes\n")
+		}
+		opts, _ := FilterOptions(h.SSHOptions)
+		for _, k := range sortedOptionKeys(opts) {
+			fmt.Fprintf(&b, "    %s %s\n", k, opts[k])
+		}
+	}
+	return b.String(), nil
+}
+
+// configQuote double-quotes a path containing spaces, as ssh_config expects.
+func configQuote(s string) string {
+	if strings.ContainsAny(s, " \t") {
+		return `"` + s + `"`
+	}
+	return s
+}
@@ -0,0 +1,38 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestGenerateSSHConfig(t *testing.T) {
+	hosts := []db.HostModel{
+		{ID: 3, Label: "web prod", Hostname: "web.example.com", Username: "deploy", Port: 2222, KeyData: "enc", KeyType: "ed25519",
+			SSHOptions: map[string]string{"ProxyJump": "bastion"}},
+		{ID: 4, Label: "web-prod", Hostname: "web2.example.com", KeyType: "password", KeyData: "enc"},
+	}
+	got, err := GenerateSSHConfig(hosts, "/keys dir")
+	if err != nil {
+		t.Fatalf("GenerateSSHConfig returned error: %v", err)
+	}
+	want := "Host web-prod\n" +
+		"    HostName web.example.com\n" +
+		"    User deploy\n" +
+		"    Port 2222\n" +
+		"    IdentityFile \"/keys dir/host_3\"\n" +
+		"    IdentitiesOnly yes\n" +
+		"    ProxyJump bastion\n" +
+		"\n" +
+		"Host web-prod-2\n" +
+		"    HostName web2.example.com\n" +
+		"    Port 22\n"
+	if got != want {
+		t.Fatalf("unexpected config:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := GenerateSSHConfig(hosts[:1], ""); err == nil || !strings.Contains(err.Error(), "key directory") {
+		t.Fatalf("expected a missing key directory to be rejected, got %v", err)
+	}
+}
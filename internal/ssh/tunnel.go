@@ -0,0 +1,316 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// TunnelKey identifies an active tunnel by owning host and local port.
+type TunnelKey struct {
+	HostID    int
+	LocalPort int
+}
+
+// TunnelType distinguishes the kind of forward a tunnel runs.
+type TunnelType int
+
+const (
+	TunnelTypeLocal   TunnelType = iota // ssh -L
+	TunnelTypeDynamic                   // ssh -D (SOCKS5)
+)
+
+// Tunnel describes a running port forward.
+type Tunnel struct {
+	Type       TunnelType
+	HostID     int
+	Hostname   string
+	LocalPort  int
+	RemoteHost string
+	RemotePort int
+	PID        int
+	StartedAt  time.Time
+}
+
+type activeTunnel struct {
+	info    Tunnel
+	cmd     *exec.Cmd
+	cleanup *TempKeyFile
+	done    chan struct{}
+}
+
+// TunnelManager tracks background `ssh -N` forwarding processes.
+type TunnelManager struct {
+	mu       sync.Mutex
+	active   map[TunnelKey]*activeTunnel
+	reserved map[int]bool // local ports held by an Add that has not started yet
+}
+
+func NewTunnelManager() *TunnelManager {
+	return &TunnelManager{
+		active:   make(map[TunnelKey]*activeTunnel),
+		reserved: make(map[int]bool),
+	}
+}
+
+// Add starts a local forward for hostID and begins tracking it. The tunnel is
+// dropped from the manager automatically when the ssh process exits.
+func (m *TunnelManager) Add(hostID int, conn Connection, localPort int, remoteHost string, remotePort int) (Tunnel, error) {
+	if err := m.reservePort(localPort); err != nil {
+		return Tunnel{}, err
+	}
+	defer m.releasePort(localPort)
+
+	cmd, cleanup, err := ForwardLocal(conn, localPort, remoteHost, remotePort)
+	if err != nil {
+		return Tunnel{}, err
+	}
+
+	return m.start(Tunnel{
+		Type:       TunnelTypeLocal,
+		HostID:     hostID,
+		Hostname:   conn.Hostname,
+		LocalPort:  localPort,
+		RemoteHost: strings.TrimSpace(remoteHost),
+		RemotePort: remotePort,
+	}, cmd, cleanup)
+}
+
+// AddDynamic starts a SOCKS5 proxy (ssh -D) on localPort through hostID.
+func (m *TunnelManager) AddDynamic(hostID int, conn Connection, localPort int) (Tunnel, error) {
+	if err := m.reservePort(localPort); err != nil {
+		return Tunnel{}, err
+	}
+	defer m.releasePort(localPort)
+
+	cmd, cleanup, err := ForwardDynamic(conn, localPort)
+	if err != nil {
+		return Tunnel{}, err
+	}
+
+	return m.start(Tunnel{
+		Type:      TunnelTypeDynamic,
+		HostID:    hostID,
+		Hostname:  conn.Hostname,
+		LocalPort: localPort,
+	}, cmd, cleanup)
+}
+
+// reservePort claims localPort until releasePort, so concurrent Adds for the
+// same port cannot both start. By then a started tunnel is in active. A port
+// another program is already listening on is refused, so whatever answers on
+// it once ssh starts is ssh's forward.
+func (m *TunnelManager) reservePort(localPort int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkPortFree(localPort); err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
+	if err != nil {
+		return fmt.Errorf("local port %d is in use by another program", localPort)
+	}
+	_ = ln.Close()
+	m.reserved[localPort] = true
+	return nil
+}
+
+func (m *TunnelManager) releasePort(localPort int) {
+	m.mu.Lock()
+	delete(m.reserved, localPort)
+	m.mu.Unlock()
+}
+
+// checkPortFree reports whether localPort is free of tracked and reserved
+// tunnels. The caller must hold m.mu.
+func (m *TunnelManager) checkPortFree(localPort int) error {
+	if m.reserved[localPort] {
+		return fmt.Errorf("local port %d is already forwarded", localPort)
+	}
+	for k := range m.active {
+		if k.LocalPort == localPort {
+			return fmt.Errorf("local port %d is already forwarded", localPort)
+		}
+	}
+	return nil
+}
+
+func (m *TunnelManager) start(info Tunnel, cmd *exec.Cmd, cleanup *TempKeyFile) (Tunnel, error) {
+	key := TunnelKey{HostID: info.HostID, LocalPort: info.LocalPort}
+	t := &activeTunnel{
+		info:    info,
+		cmd:     cmd,
+		cleanup: cleanup,
+		done:    make(chan struct{}),
+	}
+
+	if err := cmd.Start(); err != nil {
+		if cleanup != nil {
+			_ = cleanup.Cleanup()
+		}
+		return Tunnel{}, fmt.Errorf("failed to start tunnel: %w", err)
+	}
+	t.info.PID = cmd.Process.Pid
+	t.info.StartedAt = time.Now()
+
+	m.mu.Lock()
+	m.active[key] = t
+	m.mu.Unlock()
+
+	go m.wait(key, t)
+
+	return t.info, nil
+}
+
+func (m *TunnelManager) wait(key TunnelKey, t *activeTunnel) {
+	_ = t.cmd.Wait()
+	if t.cleanup != nil {
+		_ = t.cleanup.Cleanup()
+	}
+
+	m.mu.Lock()
+	if cur, ok := m.active[key]; ok && cur == t {
+		delete(m.active, key)
+	}
+	m.mu.Unlock()
+
+	close(t.done)
+}
+
+// Remove stops the tunnel for (hostID, localPort) and waits for it to exit.
+func (m *TunnelManager) Remove(hostID int, localPort int) error {
+	key := TunnelKey{HostID: hostID, LocalPort: localPort}
+
+	m.mu.Lock()
+	t, ok := m.active[key]
+	if ok {
+		delete(m.active, key)
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no tunnel on local port %d", localPort)
+	}
+	return stopTunnel(t)
+}
+
+// List returns a snapshot of active tunnels ordered by host and local port.
+func (m *TunnelManager) List() []Tunnel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Tunnel, 0, len(m.active))
+	for _, t := range m.active {
+		out = append(out, t.info)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].HostID != out[j].HostID {
+			return out[i].HostID < out[j].HostID
+		}
+		return out[i].LocalPort < out[j].LocalPort
+	})
+	return out
+}
+
+// Dynamic returns the active SOCKS proxy for hostID, if any.
+func (m *TunnelManager) Dynamic(hostID int) (Tunnel, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, t := range m.active {
+		if k.HostID == hostID && t.info.Type == TunnelTypeDynamic {
+			return t.info, true
+		}
+	}
+	return Tunnel{}, false
+}
+
+// WaitListening polls until the tunnel's local port accepts connections while
+// its ssh process is still running. The port was free when the tunnel
+// started, so a listener there belongs to ssh unless ssh has exited (for
+// example because it lost the port and ExitOnForwardFailure stopped it). It
+// returns false once the ssh process exits or the timeout elapses.
+func (m *TunnelManager) WaitListening(t Tunnel, timeout time.Duration) bool {
+	key := TunnelKey{HostID: t.HostID, LocalPort: t.LocalPort}
+	addr := fmt.Sprintf("127.0.0.1:%d", t.LocalPort)
+	deadline := time.Now().Add(timeout)
+	for {
+		c, err := net.DialTimeout("tcp", addr, 250*time.Millisecond)
+		if err == nil {
+			_ = c.Close()
+			// Give an ssh that failed to bind a moment to exit first.
+			time.Sleep(100 * time.Millisecond)
+			return m.running(key, t.PID)
+		}
+		if !m.running(key, t.PID) || time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(150 * time.Millisecond)
+	}
+}
+
+// running reports whether the tunnel at key is still the ssh process pid and
+// has not exited.
+func (m *TunnelManager) running(key TunnelKey, pid int) bool {
+	m.mu.Lock()
+	cur, ok := m.active[key]
+	m.mu.Unlock()
+	if !ok || cur.info.PID != pid {
+		return false
+	}
+	select {
+	case <-cur.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// RemoveAll stops every tracked tunnel.
+func (m *TunnelManager) RemoveAll() {
+	m.mu.Lock()
+	tunnels := make([]*activeTunnel, 0, len(m.active))
+	for k, t := range m.active {
+		tunnels = append(tunnels, t)
+		delete(m.active, k)
+	}
+	m.mu.Unlock()
+
+	for _, t := range tunnels {
+		_ = stopTunnel(t)
+	}
+}
+
+// stopTunnel asks the ssh process to exit with SIGTERM, falling back to a
+// kill where signals are unsupported or the process does not exit in time.
+func stopTunnel(t *activeTunnel) error {
+	if t.cmd.Process != nil {
+		if err := t.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			if err := t.cmd.Process.Kill(); err != nil {
+				select {
+				case <-t.done:
+					return nil
+				default:
+				}
+				return fmt.Errorf("failed to stop tunnel: %w", err)
+			}
+		}
+	}
+	select {
+	case <-t.done:
+		return nil
+	case <-time.After(5 * time.Second):
+	}
+	if t.cmd.Process != nil {
+		_ = t.cmd.Process.Kill()
+	}
+	select {
+	case <-t.done:
+	case <-time.After(2 * time.Second):
+		return fmt.Errorf("timed out waiting for tunnel on port %d to exit", t.info.LocalPort)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package ssh
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// freePort returns a local port nothing is listening on.
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestTunnelManagerReservesPort(t *testing.T) {
+	m := NewTunnelManager()
+	port := freePort(t)
+
+	var wg sync.WaitGroup
+	var won atomic.Int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if m.reservePort(port) == nil {
+				won.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if won.Load() != 1 {
+		t.Fatalf("expected exactly one reservation of the port, got %d", won.Load())
+	}
+
+	m.releasePort(port)
+	if err := m.reservePort(port); err != nil {
+		t.Fatalf("expected a released port to be reservable again: %v", err)
+	}
+}
+
+func TestTunnelManagerReleasesPortOnFailedAdd(t *testing.T) {
+	m := NewTunnelManager()
+	port := freePort(t)
+	if _, err := m.Add(1, Connection{Hostname: "example.com", Username: "ubuntu"}, port, "", 80); err == nil {
+		t.Fatalf("expected an empty remote host to be rejected")
+	}
+	if err := m.reservePort(port); err != nil {
+		t.Fatalf("expected the failed Add to release its port: %v", err)
+	}
+}
+
+func TestTunnelManagerRefusesPortInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	m := NewTunnelManager()
+	if _, err := m.AddDynamic(1, Connection{Hostname: "example.com", Username: "ubuntu"}, port); err == nil {
+		t.Fatalf("expected a port another program listens on to be refused")
+	}
+
+	// A listener that is not the tunnel's ssh process must not count as up.
+	if m.WaitListening(Tunnel{Type: TunnelTypeDynamic, HostID: 1, LocalPort: port, PID: 1}, time.Second) {
+		t.Fatalf("expected WaitListening to ignore a port without a running tunnel")
+	}
+}
@@ -0,0 +1,166 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+	"github.com/Vansh-Raja/SSHThing/internal/ssh"
+)
+
+type ansibleHost struct {
+	alias   string
+	host    db.HostModel
+	keyPath string
+}
+
+// ExportAnsibleInventory writes all hosts as an Ansible YAML inventory. Hosts
+// are grouped by GroupName under all.children; ungrouped hosts sit directly
+// under all.hosts. Hosts with a stored private key get a copy of it in a new
+// private temporary directory, referenced by ansible_ssh_private_key_file.
+// The directory is returned so the caller can tell the user to delete it; it
+// is empty when no host has a key.
+func ExportAnsibleInventory(store *db.Store, w io.Writer) (string, error) {
+	hosts, err := store.GetHosts()
+	if err != nil {
+		return "", fmt.Errorf("failed to get hosts: %w", err)
+	}
+
+	keyDir := ""
+	fail := func(err error) (string, error) {
+		if keyDir != "" {
+			removeAnsibleKeyDir(keyDir)
+		}
+		return "", err
+	}
+
+	ungrouped := []ansibleHost{}
+	groups := map[string][]ansibleHost{}
+	seenAlias := map[string]bool{}
+	for _, h := range hosts {
+		alias := strings.TrimSpace(h.Label)
+		if alias == "" {
+			alias = h.Hostname
+		}
+		base := alias
+		for n := 2; seenAlias[strings.ToLower(alias)]; n++ {
+			alias = fmt.Sprintf("%s-%d", base, n)
+		}
+		seenAlias[strings.ToLower(alias)] = true
+
+		ah := ansibleHost{alias: alias, host: h}
+		if h.KeyData != "" && h.KeyType != "password" {
+			key, err := store.GetHostSecret(h.ID)
+			if err != nil {
+				return fail(fmt.Errorf("failed to decrypt key for %s: %w", alias, err))
+			}
+			if keyDir == "" {
+				// MkdirTemp creates the directory 0700 under a fresh name, so
+				// another user cannot pre-create or link it.
+				if keyDir, err = os.MkdirTemp("", "sshthing-ansible-"); err != nil {
+					return "", err
+				}
+			}
+			ah.keyPath = filepath.Join(keyDir, fmt.Sprintf("host_%d", h.ID))
+			if err := ssh.WritePrivateKeyFile(ah.keyPath, key); err != nil {
+				return fail(err)
+			}
+		}
+
+		group := ansibleGroupName(h.GroupName)
+		if group == "" {
+			ungrouped = append(ungrouped, ah)
+		} else {
+			groups[group] = append(groups[group], ah)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("all:\n")
+	if len(ungrouped) > 0 {
+		b.WriteString("  hosts:\n")
+		writeAnsibleHosts(&b, ungrouped, "    ")
+	}
+	if len(groups) > 0 {
+		names := make([]string, 0, len(groups))
+		for name := range groups {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		b.WriteString("  children:\n")
+		for _, name := range names {
+			b.WriteString("    " + name + ":\n")
+			b.WriteString("      hosts:\n")
+			writeAnsibleHosts(&b, groups[name], "        ")
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fail(err)
+	}
+	return keyDir, nil
+}
+
+// removeAnsibleKeyDir securely deletes the keys in dir, then dir itself.
+func removeAnsibleKeyDir(dir string) {
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if !e.IsDir() {
+			_ = ssh.SecureDeleteFile(filepath.Join(dir, e.Name()))
+		}
+	}
+	_ = os.RemoveAll(dir)
+}
+
+func writeAnsibleHosts(b *strings.Builder, hosts []ansibleHost, indent string) {
+	for _, ah := range hosts {
+		b.WriteString(indent + yamlString(ah.alias) + ":\n")
+		b.WriteString(indent + "  ansible_host: " + yamlString(ah.host.Hostname) + "\n")
+		if ah.host.Username != "" {
+			b.WriteString(indent + "  ansible_user: " + yamlString(ah.host.Username) + "\n")
+		}
+		port := ah.host.Port
+		if port == 0 {
+			port = 22
+		}
+		b.WriteString(fmt.Sprintf("%s  ansible_port: %d\n", indent, port))
+		if ah.keyPath != "" {
+			b.WriteString(indent + "  ansible_ssh_private_key_file: " + yamlString(ah.keyPath) + "\n")
+		}
+	}
+}
+
+// ansibleGroupName turns a group name into a valid Ansible group: letters,
+// digits and underscores, not starting with a digit.
+func ansibleGroupName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+	out := []rune{}
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			out = append(out, r)
+		} else {
+			out = append(out, '_')
+		}
+	}
+	if out[0] >= '0' && out[0] <= '9' {
+		out = append([]rune{'_'}, out...)
+	}
+	if string(out) == "all" || string(out) == "ungrouped" {
+		out = append([]rune("group_"), out...)
+	}
+	return string(out)
+}
+
+// yamlString quotes s as a YAML double-quoted scalar (JSON strings are valid
+// YAML).
+func yamlString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,85 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestOverrideConflict(t *testing.T) {
+	const password = "testpassword123"
+	open := func() *db.Store {
+		t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+		store, err := db.Init(password)
+		if err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	}
+	a := open()
+	b := open()
+
+	web := &db.HostModel{Label: "web", Hostname: "web.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+	if err := a.CreateHost(web, "secret-a"); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	data, err := Export(a)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if _, err := Import(b, data, password); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	// The second device edits the host later, so Import takes its copy.
+	time.Sleep(10 * time.Millisecond)
+	remote, err := b.GetHostByID(web.ID)
+	if err != nil {
+		t.Fatalf("GetHostByID failed: %v", err)
+	}
+	remote.Label = "web-renamed"
+	if err := b.UpdateHostWithKey(remote, "secret-b"); err != nil {
+		t.Fatalf("UpdateHostWithKey failed: %v", err)
+	}
+	data, err = Export(b)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	res, err := Import(a, data, password)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(res.Conflicts) != 1 || res.Conflicts[0].Resolution != "remote" {
+		t.Fatalf("expected one conflict resolved to remote, got %+v", res.Conflicts)
+	}
+	c := res.Conflicts[0]
+
+	if err := OverrideConflict(a, c, "local"); err != nil {
+		t.Fatalf("OverrideConflict failed: %v", err)
+	}
+	got, err := a.GetHostByID(web.ID)
+	if err != nil || got.Label != "web" || !got.UpdatedAt.After(c.RemoteTime) {
+		t.Fatalf("expected the local copy back with a newer timestamp, got %+v (%v)", got, err)
+	}
+	if secret, err := a.GetHostSecret(web.ID); err != nil || secret != "secret-a" {
+		t.Fatalf("expected the local password back, got %q (%v)", secret, err)
+	}
+
+	// Switching back takes the remote copy, re-encrypted for this store.
+	if err := OverrideConflict(a, c, "remote"); err != nil {
+		t.Fatalf("OverrideConflict failed: %v", err)
+	}
+	got, _ = a.GetHostByID(web.ID)
+	if got.Label != "web-renamed" {
+		t.Fatalf("expected the remote copy, got %+v", got)
+	}
+	if secret, err := a.GetHostSecret(web.ID); err != nil || secret != "secret-b" {
+		t.Fatalf("expected the remote password, got %q (%v)", secret, err)
+	}
+
+	if err := OverrideConflict(a, SyncConflict{HostID: web.ID}, "local"); err == nil {
+		t.Fatalf("expected an error for a conflict without copies")
+	}
+}
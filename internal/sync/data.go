@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/Vansh-Raja/SSHThing/internal/authtoken"
+	"github.com/Vansh-Raja/SSHThing/internal/db"
 )
 
 // SyncData represents the portable format for syncing hosts across devices.
@@ -12,6 +13,7 @@ import (
 type SyncData struct {
 	Version   int                      `json:"version"`
 	Salt      string                   `json:"salt"` // Hex-encoded encryption salt from source database
+	KDF       db.KDFParams             `json:"kdf"`  // Source KDF parameters; zero for PBKDF2
 	UpdatedAt time.Time                `json:"updated_at"`
 	Groups    []SyncGroup              `json:"groups,omitempty"`
 	Hosts     []SyncHost               `json:"hosts"`
@@ -37,27 +39,41 @@ type SyncFile struct {
 // SyncGroup represents a named group entry in the sync file.
 // Deleted groups are tombstoned via DeletedAt.
 type SyncGroup struct {
-	Name      string     `json:"name"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Name        string     `json:"name"`
+	ParentGroup string     `json:"parent_group,omitempty"`
+	Color       string     `json:"color,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 }
 
 // SyncHost represents a host entry in the sync file.
 // This mirrors db.HostModel but is designed for JSON serialization.
 type SyncHost struct {
-	ID            int        `json:"id"`
-	Label         string     `json:"label"`
-	GroupName     string     `json:"group_name,omitempty"`
-	Tags          []string   `json:"tags,omitempty"`
-	Hostname      string     `json:"hostname"`
-	Username      string     `json:"username"`
-	Port          int        `json:"port"`
-	KeyData       string     `json:"key_data"` // Encrypted blob (stays encrypted)
-	KeyType       string     `json:"key_type"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
-	LastConnected *time.Time `json:"last_connected,omitempty"`
+	ID               int               `json:"id"`
+	Label            string            `json:"label"`
+	GroupName        string            `json:"group_name,omitempty"`
+	Tags             []string          `json:"tags,omitempty"`
+	Hostname         string            `json:"hostname"`
+	Username         string            `json:"username"`
+	Port             int               `json:"port"`
+	KeyData          string            `json:"key_data"` // Encrypted blob (stays encrypted)
+	KeyType          string            `json:"key_type"`
+	Recording        string            `json:"recording,omitempty"`
+	SSHOptions       map[string]string `json:"ssh_options,omitempty"`
+	Pinned           bool              `json:"pinned,omitempty"`
+	MACAddress       string            `json:"mac_address,omitempty"`
+	BroadcastAddr    string            `json:"broadcast_addr,omitempty"`
+	KeepAliveSeconds int               `json:"keepalive_seconds,omitempty"`
+	ForwardX11       bool              `json:"forward_x11,omitempty"`
+	ProxyHosts       []int             `json:"proxy_hosts,omitempty"`
+	TermOverride     string            `json:"term_override,omitempty"`
+	Icon             string            `json:"icon,omitempty"`
+	ColorOverride    string            `json:"color_override,omitempty"`
+	IdentitiesOnly   string            `json:"identities_only,omitempty"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	LastConnected    *time.Time        `json:"last_connected,omitempty"`
 }
 
 // SyncStatus represents the current state of sync operations
@@ -83,6 +99,9 @@ type SyncResult struct {
 	Conflicts    []SyncConflict
 	Error        error
 	Timestamp    time.Time
+	// Warning is a non-fatal problem after a successful sync, such as
+	// dropped ssh options or a failed webhook call.
+	Warning string
 }
 
 // SyncConflict represents a conflict between local and remote host data
@@ -92,6 +111,12 @@ type SyncConflict struct {
 	LocalTime  time.Time
 	RemoteTime time.Time
 	Resolution string // "local", "remote", or "skipped"
+
+	// Local and Remote are both copies of the host as Import saw them, with
+	// KeyData encrypted for the local store, so OverrideConflict can switch
+	// sides later. Dry runs leave them empty.
+	Local  SyncHost
+	Remote SyncHost
 }
 
 // CurrentSyncVersion is the version of the sync data format
@@ -101,5 +126,9 @@ const CurrentSyncVersion = 4
 // After this window, tombstones may be garbage collected, and very stale devices may resurrect old groups.
 const GroupTombstoneRetention = 90 * 24 * time.Hour
 
+// opaqueSyncMagic starts the header line of a sync file written with
+// Sync.EncryptPayload; the hex salt follows it on the same line.
+const opaqueSyncMagic = "SSHTHING-SYNC-ENC1 "
+
 // SyncFileName is the name of the sync data file in the repository
 const SyncFileName = "sshthing-hosts.json"
@@ -0,0 +1,65 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestDryRunReportsChangesWithoutWriting(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	existing := &db.HostModel{Label: "web", Hostname: "web.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+	if err := store.CreateHost(existing, ""); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	local, err := store.GetHosts()
+	if err != nil || len(local) != 1 {
+		t.Fatalf("GetHosts failed: %v", err)
+	}
+	id := local[0].ID
+
+	remote := &SyncData{
+		Version: CurrentSyncVersion,
+		Groups:  []SyncGroup{{Name: "prod", UpdatedAt: time.Now()}},
+		Hosts: []SyncHost{
+			{ID: id, Hostname: "web2.example.com", Username: "ubuntu", Port: 22, KeyType: "password", UpdatedAt: time.Now().Add(time.Hour)},
+			{ID: id + 100, Hostname: "db.example.com", Username: "ubuntu", Port: 22, KeyType: "password", UpdatedAt: time.Now()},
+		},
+	}
+	result, err := DryRun(store, remote)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if result.Added != 1 || result.Updated != 1 || len(result.Changes) != 2 {
+		t.Fatalf("unexpected dry-run result: %+v", result)
+	}
+	actions := map[string]string{}
+	for _, c := range result.Changes {
+		actions[c.Hostname] = c.Action
+	}
+	if actions["web2.example.com"] != "update" || actions["db.example.com"] != "add" {
+		t.Fatalf("unexpected changes: %+v", result.Changes)
+	}
+
+	hosts, err := store.GetHosts()
+	if err != nil {
+		t.Fatalf("GetHosts failed: %v", err)
+	}
+	if len(hosts) != 1 || hosts[0].Hostname != "web.example.com" {
+		t.Fatalf("dry run must not modify hosts, got %+v", hosts)
+	}
+	groups, err := store.GetGroups()
+	if err != nil {
+		t.Fatalf("GetGroups failed: %v", err)
+	}
+	if len(groups) != 0 {
+		t.Fatalf("dry run must not create groups, got %v", groups)
+	}
+}
@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestImportMatchesHostsByAddress(t *testing.T) {
+	const password = "testpassword123"
+	open := func() *db.Store {
+		t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+		store, err := db.Init(password)
+		if err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	}
+	a := open()
+	b := open()
+
+	// Both devices add the same server on their own. b burns an ID first
+	// so the two copies get different IDs.
+	scratch := &db.HostModel{Label: "scratch", Hostname: "scratch.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+	if err := b.CreateHost(scratch, ""); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	if err := b.DeleteHost(scratch.ID); err != nil {
+		t.Fatalf("DeleteHost failed: %v", err)
+	}
+	local := &db.HostModel{Label: "web", Hostname: "web.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+	if err := a.CreateHost(local, ""); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	remote := &db.HostModel{Label: "web-prod", Hostname: "WEB.example.com", Username: "deploy", Port: 22, KeyType: "password"}
+	if err := b.CreateHost(remote, "secret-b"); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	if remote.ID == local.ID {
+		t.Fatalf("expected different IDs, both are %d", local.ID)
+	}
+
+	data, err := Export(b)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	preview, err := DryRun(a, data)
+	if err != nil || preview.Added != 0 || preview.Updated != 1 {
+		t.Fatalf("expected the dry run to update web in place, got %+v (%v)", preview, err)
+	}
+	res, err := Import(a, data, password)
+	if err != nil || res.Added != 0 || res.Updated != 1 {
+		t.Fatalf("expected web updated in place, got %+v (%v)", res, err)
+	}
+	hosts, err := a.GetHosts()
+	if err != nil || len(hosts) != 1 {
+		t.Fatalf("expected no duplicate host, got %+v (%v)", hosts, err)
+	}
+	got, err := a.GetHostByID(local.ID)
+	if err != nil || got.Label != "web-prod" || got.Username != "deploy" {
+		t.Fatalf("expected the newer remote copy in the local host, got %+v (%v)", got, err)
+	}
+	if secret, err := a.GetHostSecret(local.ID); err != nil || secret != "secret-b" {
+		t.Fatalf("expected the remote password, got %q (%v)", secret, err)
+	}
+
+	// A newer local copy is kept.
+	time.Sleep(10 * time.Millisecond)
+	got.Label = "web-local"
+	if err := a.UpdateHost(got); err != nil {
+		t.Fatalf("UpdateHost failed: %v", err)
+	}
+	res, err = Import(a, data, password)
+	if err != nil || res.Updated != 0 {
+		t.Fatalf("expected nothing updated, got %+v (%v)", res, err)
+	}
+	if got, _ := a.GetHostByID(local.ID); got.Label != "web-local" {
+		t.Fatalf("expected the local copy to win, got %+v", got)
+	}
+}
@@ -0,0 +1,69 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestSyncExcludedHostsStayLocal(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	shared := &db.HostModel{Label: "shared", Hostname: "shared.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}
+	private := &db.HostModel{Label: "private", Hostname: "private.example.com", Username: "ubuntu", Port: 22, KeyType: "password", SyncExclude: true}
+	for _, h := range []*db.HostModel{shared, private} {
+		if err := store.CreateHost(h, ""); err != nil {
+			t.Fatalf("CreateHost failed: %v", err)
+		}
+	}
+
+	data, err := Export(store)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(data.Hosts) != 1 || data.Hosts[0].Hostname != "shared.example.com" {
+		t.Fatalf("expected only the shared host to be exported, got %+v", data.Hosts)
+	}
+
+	local, err := store.GetHosts()
+	if err != nil {
+		t.Fatalf("GetHosts failed: %v", err)
+	}
+	var privateID int
+	for _, h := range local {
+		if h.SyncExclude {
+			privateID = h.ID
+		}
+	}
+	if privateID == 0 {
+		t.Fatalf("expected excluded host to be stored locally")
+	}
+
+	// A newer remote copy of an excluded host must not overwrite it.
+	remote := &SyncData{
+		Version: CurrentSyncVersion,
+		Hosts: []SyncHost{
+			{ID: privateID, Hostname: "leaked.example.com", Username: "root", Port: 22, KeyType: "password", UpdatedAt: time.Now().Add(time.Hour)},
+		},
+	}
+	result, err := Import(store, remote, "testpassword123")
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.Updated != 0 {
+		t.Fatalf("expected no updates, got %d", result.Updated)
+	}
+	h, err := store.GetHostByID(privateID)
+	if err != nil {
+		t.Fatalf("GetHostByID failed: %v", err)
+	}
+	if h.Hostname != "private.example.com" || !h.SyncExclude {
+		t.Fatalf("expected local excluded host to win, got %+v", h)
+	}
+}
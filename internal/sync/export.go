@@ -1,6 +1,7 @@
 package sync
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -13,7 +14,8 @@ import (
 	"github.com/Vansh-Raja/SSHThing/internal/db"
 )
 
-// Export reads all hosts from the database and returns them as SyncData.
+// Export reads all syncable hosts from the database and returns them as SyncData.
+// Hosts marked SyncExclude never leave this device.
 // The key data remains encrypted - we do not decrypt keys during export.
 // The salt is included so importing databases can re-encrypt with their own salt.
 func Export(store *db.Store) (*SyncData, error) {
@@ -23,7 +25,7 @@ func Export(store *db.Store) (*SyncData, error) {
 		return nil, fmt.Errorf("failed to get salt: %w", err)
 	}
 
-	hosts, err := store.GetHosts()
+	hosts, err := store.GetSyncableHosts()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get hosts: %w", err)
 	}
@@ -38,10 +40,12 @@ func Export(store *db.Store) (*SyncData, error) {
 	for _, g := range groups {
 		seenGroups[strings.ToLower(strings.TrimSpace(g.Name))] = true
 		syncGroups = append(syncGroups, SyncGroup{
-			Name:      g.Name,
-			CreatedAt: g.CreatedAt,
-			UpdatedAt: g.UpdatedAt,
-			DeletedAt: g.DeletedAt,
+			Name:        g.Name,
+			ParentGroup: g.ParentGroup,
+			Color:       g.Color,
+			CreatedAt:   g.CreatedAt,
+			UpdatedAt:   g.UpdatedAt,
+			DeletedAt:   g.DeletedAt,
 		})
 	}
 
@@ -55,31 +59,50 @@ func Export(store *db.Store) (*SyncData, error) {
 				seenGroups[k] = true
 			}
 		}
-		syncHosts[i] = SyncHost{
-			ID:            h.ID,
-			Label:         h.Label,
-			GroupName:     h.GroupName,
-			Tags:          append([]string(nil), h.Tags...),
-			Hostname:      h.Hostname,
-			Username:      h.Username,
-			Port:          h.Port,
-			KeyData:       h.KeyData, // Already encrypted
-			KeyType:       h.KeyType,
-			CreatedAt:     h.CreatedAt,
-			UpdatedAt:     h.UpdatedAt,
-			LastConnected: h.LastConnected,
-		}
+		syncHosts[i] = syncHostFromModel(h)
 	}
 
 	return &SyncData{
 		Version:   CurrentSyncVersion,
 		Salt:      salt,
+		KDF:       store.KDFParams(),
 		UpdatedAt: time.Now(),
 		Groups:    syncGroups,
 		Hosts:     syncHosts,
 	}, nil
 }
 
+// syncHostFromModel converts a stored host to its sync form. KeyData stays
+// encrypted with the store's key.
+func syncHostFromModel(h db.HostModel) SyncHost {
+	return SyncHost{
+		ID:               h.ID,
+		Label:            h.Label,
+		GroupName:        h.GroupName,
+		Tags:             append([]string(nil), h.Tags...),
+		Hostname:         h.Hostname,
+		Username:         h.Username,
+		Port:             h.Port,
+		KeyData:          h.KeyData, // Already encrypted
+		KeyType:          h.KeyType,
+		Recording:        h.Recording,
+		SSHOptions:       h.SSHOptions,
+		Pinned:           h.Pinned,
+		MACAddress:       h.MACAddress,
+		BroadcastAddr:    h.BroadcastAddr,
+		KeepAliveSeconds: h.KeepAliveSeconds,
+		ForwardX11:       h.ForwardX11,
+		ProxyHosts:       h.ProxyHosts,
+		TermOverride:     h.TermOverride,
+		Icon:             h.Icon,
+		ColorOverride:    h.ColorOverride,
+		IdentitiesOnly:   h.IdentitiesOnly,
+		CreatedAt:        h.CreatedAt,
+		UpdatedAt:        h.UpdatedAt,
+		LastConnected:    h.LastConnected,
+	}
+}
+
 // ExportToFile exports sync data to an encrypted JSON file at the specified path.
 func ExportToFile(store *db.Store, filePath string, password string) error {
 	data, err := Export(store)
@@ -127,7 +150,41 @@ func ExportDataToFile(data *SyncData, filePath string, password string) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal encrypted sync data: %w", err)
 	}
+	return writeSyncFile(filePath, jsonBytes)
+}
 
+// ExportOpaqueDataToFile writes sync data as a single ciphertext blob with no
+// readable JSON envelope, so the file (and its git diff) reveals nothing but
+// its size. The key is derived from password and saltHex, the database's
+// sync salt; the salt goes on the header line so other devices can decrypt.
+func ExportOpaqueDataToFile(data *SyncData, filePath string, password string, saltHex string) error {
+	if data == nil {
+		return fmt.Errorf("missing sync data")
+	}
+	if strings.TrimSpace(password) == "" {
+		return fmt.Errorf("missing master password for sync encryption")
+	}
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil || len(salt) == 0 {
+		return fmt.Errorf("invalid sync salt")
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync payload: %w", err)
+	}
+	key, _, err := crypto.DeriveKey(password, salt)
+	if err != nil {
+		return fmt.Errorf("failed to derive sync encryption key: %w", err)
+	}
+	blob, err := crypto.Encrypt(payload, key)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt sync payload: %w", err)
+	}
+	return writeSyncFile(filePath, []byte(opaqueSyncMagic+saltHex+"\n"+blob+"\n"))
+}
+
+func writeSyncFile(filePath string, content []byte) error {
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
@@ -135,7 +192,7 @@ func ExportDataToFile(data *SyncData, filePath string, password string) error {
 
 	// Write to temp file first, then rename (atomic write)
 	tmpPath := filePath + ".tmp"
-	if err := os.WriteFile(tmpPath, jsonBytes, 0600); err != nil {
+	if err := os.WriteFile(tmpPath, content, 0600); err != nil {
 		return fmt.Errorf("failed to write sync file: %w", err)
 	}
 
@@ -156,6 +213,14 @@ func LoadFromFile(filePath string, password string) (*SyncData, error) {
 		}
 		return nil, fmt.Errorf("failed to read sync file: %w", err)
 	}
+	return decodeSyncFile(jsonBytes, password)
+}
+
+// decodeSyncFile parses the contents of a sync file.
+func decodeSyncFile(jsonBytes []byte, password string) (*SyncData, error) {
+	if bytes.HasPrefix(jsonBytes, []byte(opaqueSyncMagic)) {
+		return decodeOpaqueSyncFile(jsonBytes, password)
+	}
 
 	var fileData SyncFile
 	if err := json.Unmarshal(jsonBytes, &fileData); err != nil {
@@ -225,3 +290,35 @@ func LoadFromFile(filePath string, password string) (*SyncData, error) {
 	}
 	return &fallback, nil
 }
+
+// decodeOpaqueSyncFile decrypts a file written by ExportOpaqueDataToFile.
+func decodeOpaqueSyncFile(raw []byte, password string) (*SyncData, error) {
+	if strings.TrimSpace(password) == "" {
+		return nil, fmt.Errorf("missing master password for encrypted sync import")
+	}
+	header, blob, _ := strings.Cut(string(raw), "\n")
+	salt, err := hex.DecodeString(strings.TrimSpace(strings.TrimPrefix(header, opaqueSyncMagic)))
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("invalid sync encryption salt")
+	}
+	key, _, err := crypto.DeriveKey(password, salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive sync decryption key: %w", err)
+	}
+	plain, err := crypto.Decrypt(strings.TrimSpace(blob), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt sync payload: %w", err)
+	}
+
+	var data SyncData
+	if err := json.Unmarshal(plain, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted sync payload: %w", err)
+	}
+	if data.Version == 0 {
+		data.Version = CurrentSyncVersion
+	}
+	if data.Hosts == nil {
+		data.Hosts = []SyncHost{}
+	}
+	return &data, nil
+}
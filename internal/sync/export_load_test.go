@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -105,3 +106,41 @@ func TestLoadFromFile_LegacyPlaintext(t *testing.T) {
 		t.Fatalf("expected legacy version 2, got %d", loaded.Version)
 	}
 }
+
+func TestLoadFromFile_OpaquePayload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SyncFileName)
+	now := time.Now().UTC().Truncate(time.Second)
+	payload := &SyncData{
+		Version:   CurrentSyncVersion,
+		Salt:      "abc123",
+		UpdatedAt: now,
+		Hosts: []SyncHost{
+			{ID: 1, Hostname: "prod.example.com", Username: "ubuntu", Port: 22, KeyType: "password", CreatedAt: now, UpdatedAt: now},
+		},
+	}
+	saltHex := hex.EncodeToString([]byte("1234567890abcdef"))
+	if err := ExportOpaqueDataToFile(payload, path, "test-password", saltHex); err != nil {
+		t.Fatalf("export opaque file: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	for _, leak := range []string{"prod.example.com", "ubuntu", "updated_at", "{"} {
+		if strings.Contains(string(raw), leak) {
+			t.Fatalf("opaque sync file leaks %q:\n%s", leak, raw)
+		}
+	}
+
+	loaded, err := LoadFromFile(path, "test-password")
+	if err != nil {
+		t.Fatalf("load opaque file: %v", err)
+	}
+	if loaded == nil || len(loaded.Hosts) != 1 || loaded.Hosts[0].Hostname != "prod.example.com" {
+		t.Fatalf("unexpected payload: %+v", loaded)
+	}
+	if _, err := LoadFromFile(path, "wrong-password"); err == nil {
+		t.Fatalf("expected decrypt error with wrong password")
+	}
+}
@@ -253,14 +253,84 @@ func (gm *GitManager) Pull() error {
 		return nil // No remote configured
 	}
 
-	auth, err := gm.getAuth()
+	w, err := gm.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	remoteRef, err := gm.fetchRemoteRef()
 	if err != nil {
 		return err
 	}
+	if remoteRef == nil {
+		// Remote branch doesn't exist yet - this is fine, we'll push to create it
+		return nil
+	}
 
-	w, err := gm.repo.Worktree()
+	// Reset local to remote (handles diverged histories)
+	err = w.Reset(&git.ResetOptions{
+		Commit: remoteRef.Hash(),
+		Mode:   git.HardReset,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
+		return fmt.Errorf("failed to reset to remote: %w", err)
+	}
+
+	return nil
+}
+
+// ReadRemoteSyncFile fetches the remote branch and returns the raw sync file
+// stored in its head commit, leaving the worktree untouched. When there is no
+// remote branch yet the local sync file is returned instead; nil means no sync
+// file exists at all.
+func (gm *GitManager) ReadRemoteSyncFile() ([]byte, error) {
+	if gm.repo == nil {
+		return nil, fmt.Errorf("repository not initialized")
+	}
+
+	var remoteRef *plumbing.Reference
+	if gm.repoURL != "" {
+		ref, err := gm.fetchRemoteRef()
+		if err != nil {
+			return nil, err
+		}
+		remoteRef = ref
+	}
+	if remoteRef == nil {
+		data, err := os.ReadFile(gm.GetSyncFilePath())
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to read sync file: %w", err)
+		}
+		return data, nil
+	}
+
+	commit, err := gm.repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote commit: %w", err)
+	}
+	file, err := commit.File(SyncFileName)
+	if err != nil {
+		if err == object.ErrFileNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read remote sync file: %w", err)
+	}
+	contents, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote sync file: %w", err)
+	}
+	return []byte(contents), nil
+}
+
+// fetchRemoteRef fetches from origin and resolves the remote branch. It
+// returns a nil reference when the remote branch doesn't exist yet.
+func (gm *GitManager) fetchRemoteRef() (*plumbing.Reference, error) {
+	auth, err := gm.getAuth()
+	if err != nil {
+		return nil, err
 	}
 
 	// Fetch first to get remote refs
@@ -273,7 +343,7 @@ func (gm *GitManager) Pull() error {
 		errStr := err.Error()
 		// Ignore "already up-to-date" and "remote ref not found" errors
 		if errStr != "already up-to-date" && errStr != "reference not found" {
-			return fmt.Errorf("failed to fetch: %w", err)
+			return nil, fmt.Errorf("failed to fetch: %w", err)
 		}
 	}
 
@@ -294,20 +364,55 @@ func (gm *GitManager) Pull() error {
 			}
 		}
 		if err != nil {
-			// Remote branch doesn't exist yet - this is fine, we'll push to create it
-			return nil
+			return nil, nil
 		}
 	}
+	return remoteRef, nil
+}
 
-	// Reset local to remote (handles diverged histories)
-	err = w.Reset(&git.ResetOptions{
-		Commit: remoteRef.Hash(),
-		Mode:   git.HardReset,
-	})
+// CommitInfo describes a commit in the sync repository.
+type CommitInfo struct {
+	Message string
+	When    time.Time
+}
+
+// PreviousCommit returns the parent of HEAD, the commit Rollback resets to.
+func (gm *GitManager) PreviousCommit() (*object.Commit, error) {
+	if gm.repo == nil {
+		return nil, fmt.Errorf("repository not initialized")
+	}
+	head, err := gm.repo.Head()
 	if err != nil {
-		return fmt.Errorf("failed to reset to remote: %w", err)
+		return nil, fmt.Errorf("nothing to roll back: no sync commits yet")
+	}
+	commit, err := gm.repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD commit: %w", err)
 	}
+	if commit.NumParents() == 0 {
+		return nil, fmt.Errorf("nothing to roll back: the sync repository has only one commit")
+	}
+	parent, err := commit.Parent(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read previous commit: %w", err)
+	}
+	return parent, nil
+}
 
+// Rollback hard-resets the sync repository to the commit before HEAD
+// (the equivalent of `git reset --hard HEAD~1`).
+func (gm *GitManager) Rollback() error {
+	parent, err := gm.PreviousCommit()
+	if err != nil {
+		return err
+	}
+	w, err := gm.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if err := w.Reset(&git.ResetOptions{Commit: parent.Hash, Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("failed to reset to previous commit: %w", err)
+	}
 	return nil
 }
 
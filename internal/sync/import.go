@@ -7,6 +7,7 @@ import (
 
 	"github.com/Vansh-Raja/SSHThing/internal/authtoken"
 	"github.com/Vansh-Raja/SSHThing/internal/db"
+	"github.com/Vansh-Raja/SSHThing/internal/ssh"
 )
 
 // ImportResult contains the result of an import operation
@@ -15,6 +16,15 @@ type ImportResult struct {
 	Updated   int
 	Unchanged int
 	Conflicts []SyncConflict
+	Changes   []HostChange // per-host outcome, excluding hosts that are already in sync
+	Warnings  []string     // non-fatal problems, such as ssh options that were dropped
+}
+
+// HostChange describes what an import does (or would do) to one host.
+type HostChange struct {
+	HostID   int
+	Hostname string
+	Action   string // "add", "update", or "keep local"
 }
 
 // Import merges remote sync data into the local database.
@@ -22,12 +32,40 @@ type ImportResult struct {
 // The store must already be opened with the correct master password.
 // If the remote salt differs from local, keys are re-encrypted with the local salt.
 func Import(store *db.Store, remote *SyncData, password string) (*ImportResult, error) {
+	return importData(store, remote, password, false)
+}
+
+// Restore overwrites local hosts with their copies in data regardless of
+// timestamps, used to undo a bad sync. Restored hosts are stamped with the
+// current time so the next sync pushes them instead of pulling the bad data
+// back. Hosts missing from data are left untouched.
+func Restore(store *db.Store, data *SyncData, password string) (*ImportResult, error) {
+	if data == nil {
+		return &ImportResult{}, nil
+	}
+	now := time.Now()
+	restored := *data
+	restored.Hosts = make([]SyncHost, len(data.Hosts))
+	for i, h := range data.Hosts {
+		h.UpdatedAt = now
+		restored.Hosts[i] = h
+	}
+	return importData(store, &restored, password, false)
+}
+
+// DryRun resolves remote sync data against the local database exactly like
+// Import, but performs no database writes.
+func DryRun(store *db.Store, remote *SyncData) (*ImportResult, error) {
+	return importData(store, remote, "", true)
+}
+
+func importData(store *db.Store, remote *SyncData, password string, dryRun bool) (*ImportResult, error) {
 	if remote == nil {
 		return &ImportResult{}, nil
 	}
 
 	// Merge/apply groups first (so tombstoned groups ungroup hosts promptly).
-	if len(remote.Groups) > 0 {
+	if len(remote.Groups) > 0 && !dryRun {
 		localGroups, err := store.GetGroupsForSync(GroupTombstoneRetention)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get local groups: %w", err)
@@ -49,7 +87,7 @@ func Import(store *db.Store, remote *SyncData, password string) (*ImportResult,
 					continue
 				}
 			}
-			if err := store.UpsertGroupFromSync(name, rg.CreatedAt, rg.UpdatedAt, rg.DeletedAt); err != nil {
+			if err := store.UpsertGroupFromSync(name, rg.ParentGroup, rg.Color, rg.CreatedAt, rg.UpdatedAt, rg.DeletedAt); err != nil {
 				return nil, fmt.Errorf("failed to apply group %q: %w", name, err)
 			}
 		}
@@ -60,7 +98,7 @@ func Import(store *db.Store, remote *SyncData, password string) (*ImportResult,
 	if err != nil {
 		return nil, fmt.Errorf("failed to get local salt: %w", err)
 	}
-	needsReencrypt := remote.Salt != "" && remote.Salt != localSalt
+	needsReencrypt := remote.Salt != "" && (remote.Salt != localSalt || remote.KDF != store.KDFParams())
 
 	// Get current local hosts
 	localHosts, err := store.GetHosts()
@@ -73,6 +111,14 @@ func Import(store *db.Store, remote *SyncData, password string) (*ImportResult,
 	for _, h := range localHosts {
 		localByID[h.ID] = h
 	}
+	allLocal := make(map[int]db.HostModel, len(localByID))
+	for id, h := range localByID {
+		allLocal[id] = h
+	}
+	remoteIDs := make(map[int]bool, len(remote.Hosts))
+	for _, h := range remote.Hosts {
+		remoteIDs[h.ID] = true
+	}
 
 	result := &ImportResult{}
 
@@ -81,14 +127,52 @@ func Import(store *db.Store, remote *SyncData, password string) (*ImportResult,
 		if !needsReencrypt || keyData == "" {
 			return keyData, nil
 		}
-		return store.ReencryptKeyData(keyData, remote.Salt, password)
+		return store.ReencryptKeyData(keyData, remote.Salt, remote.KDF, password)
 	}
 
 	for _, remoteHost := range remote.Hosts {
+		if opts, dropped := ssh.FilterSyncedOptions(remoteHost.SSHOptions); len(dropped) > 0 {
+			remoteHost.SSHOptions = opts
+			result.Warnings = append(result.Warnings, fmt.Sprintf("dropped ssh options %s from %s", strings.Join(dropped, ", "), remoteHost.Hostname))
+		}
 		localHost, exists := localByID[remoteHost.ID]
 
 		if !exists {
+			// The same server added on two devices has a different ID on
+			// each. Match it by address, unless the remote already has the
+			// local copy too, and keep whichever copy is newer.
+			dup, dupID, err := store.HostExistsByAddress(remoteHost.Hostname, remoteHost.Port)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check host %d for duplicates: %w", remoteHost.ID, err)
+			}
+			if local, ok := allLocal[dupID]; dup && ok && !remoteIDs[dupID] {
+				if local.SyncExclude || !remoteHost.UpdatedAt.After(local.UpdatedAt) {
+					result.Changes = append(result.Changes, HostChange{HostID: local.ID, Hostname: local.Hostname, Action: "keep local"})
+					result.Unchanged++
+					continue
+				}
+				result.Changes = append(result.Changes, HostChange{HostID: local.ID, Hostname: remoteHost.Hostname, Action: "update"})
+				if !dryRun {
+					keyData, err := getKeyData(remoteHost.KeyData)
+					if err != nil {
+						return nil, fmt.Errorf("failed to re-encrypt key for host %d: %w", remoteHost.ID, err)
+					}
+					merged := remoteHost
+					merged.ID = local.ID
+					if err := updateHostFromSync(store, merged, keyData); err != nil {
+						return nil, fmt.Errorf("failed to update host %d: %w", local.ID, err)
+					}
+				}
+				result.Updated++
+				continue
+			}
+
 			// New host from remote - add it
+			result.Changes = append(result.Changes, HostChange{HostID: remoteHost.ID, Hostname: remoteHost.Hostname, Action: "add"})
+			if dryRun {
+				result.Added++
+				continue
+			}
 			keyData, err := getKeyData(remoteHost.KeyData)
 			if err != nil {
 				return nil, fmt.Errorf("failed to re-encrypt key for host %d: %w", remoteHost.ID, err)
@@ -100,33 +184,46 @@ func Import(store *db.Store, remote *SyncData, password string) (*ImportResult,
 			continue
 		}
 
+		// Hosts excluded from sync are local-only: the local copy always wins.
+		if localHost.SyncExclude {
+			result.Changes = append(result.Changes, HostChange{HostID: remoteHost.ID, Hostname: localHost.Hostname, Action: "keep local"})
+			result.Unchanged++
+			delete(localByID, remoteHost.ID)
+			continue
+		}
+
 		// Host exists locally - check timestamps for conflict resolution
-		if remoteHost.UpdatedAt.After(localHost.UpdatedAt) {
-			// Remote is newer - update local
+		conflict := SyncConflict{
+			HostID:     remoteHost.ID,
+			Hostname:   remoteHost.Hostname,
+			LocalTime:  localHost.UpdatedAt,
+			RemoteTime: remoteHost.UpdatedAt,
+		}
+		if !dryRun && !remoteHost.UpdatedAt.Equal(localHost.UpdatedAt) {
 			keyData, err := getKeyData(remoteHost.KeyData)
 			if err != nil {
 				return nil, fmt.Errorf("failed to re-encrypt key for host %d: %w", remoteHost.ID, err)
 			}
-			if err := updateHostFromSync(store, remoteHost, keyData); err != nil {
-				return nil, fmt.Errorf("failed to update host %d: %w", remoteHost.ID, err)
+			conflict.Local = syncHostFromModel(localHost)
+			conflict.Remote = remoteHost
+			conflict.Remote.KeyData = keyData
+		}
+		if remoteHost.UpdatedAt.After(localHost.UpdatedAt) {
+			// Remote is newer - update local
+			result.Changes = append(result.Changes, HostChange{HostID: remoteHost.ID, Hostname: remoteHost.Hostname, Action: "update"})
+			if !dryRun {
+				if err := updateHostFromSync(store, remoteHost, conflict.Remote.KeyData); err != nil {
+					return nil, fmt.Errorf("failed to update host %d: %w", remoteHost.ID, err)
+				}
 			}
 			result.Updated++
-			result.Conflicts = append(result.Conflicts, SyncConflict{
-				HostID:     remoteHost.ID,
-				Hostname:   remoteHost.Hostname,
-				LocalTime:  localHost.UpdatedAt,
-				RemoteTime: remoteHost.UpdatedAt,
-				Resolution: "remote",
-			})
+			conflict.Resolution = "remote"
+			result.Conflicts = append(result.Conflicts, conflict)
 		} else if localHost.UpdatedAt.After(remoteHost.UpdatedAt) {
 			// Local is newer - keep local (will be pushed on next sync)
-			result.Conflicts = append(result.Conflicts, SyncConflict{
-				HostID:     remoteHost.ID,
-				Hostname:   remoteHost.Hostname,
-				LocalTime:  localHost.UpdatedAt,
-				RemoteTime: remoteHost.UpdatedAt,
-				Resolution: "local",
-			})
+			result.Changes = append(result.Changes, HostChange{HostID: remoteHost.ID, Hostname: localHost.Hostname, Action: "keep local"})
+			conflict.Resolution = "local"
+			result.Conflicts = append(result.Conflicts, conflict)
 			result.Unchanged++
 		} else {
 			// Same timestamp - no change needed
@@ -141,11 +238,34 @@ func Import(store *db.Store, remote *SyncData, password string) (*ImportResult,
 	// We don't delete them as they might be new local additions
 
 	// Best-effort garbage collection of old group tombstones.
-	_ = store.PurgeDeletedGroups(GroupTombstoneRetention)
+	if !dryRun {
+		_ = store.PurgeDeletedGroups(GroupTombstoneRetention)
+	}
 
 	return result, nil
 }
 
+// OverrideConflict replaces the side Import picked for c with resolution:
+// "local" puts back the local copy Import overwrote and "remote" takes the
+// remote copy it skipped. The host is stamped with the current time so the
+// next sync pushes the choice instead of undoing it.
+func OverrideConflict(store *db.Store, c SyncConflict, resolution string) error {
+	var h SyncHost
+	switch resolution {
+	case "local":
+		h = c.Local
+	case "remote":
+		h = c.Remote
+	default:
+		return fmt.Errorf("unknown resolution %q", resolution)
+	}
+	if h.ID != c.HostID {
+		return fmt.Errorf("no %s copy of host %d to restore", resolution, c.HostID)
+	}
+	h.UpdatedAt = time.Now()
+	return updateHostFromSync(store, h, h.KeyData)
+}
+
 // addHostFromSync creates a new host from sync data.
 // Note: We insert with the original ID to maintain consistency across devices.
 func addHostFromSync(store *db.Store, h SyncHost, keyData string) error {
@@ -156,23 +276,42 @@ func addHostFromSync(store *db.Store, h SyncHost, keyData string) error {
 	}
 
 	host := &db.HostModel{
-		ID:            h.ID,
-		Label:         h.Label,
-		GroupName:     h.GroupName,
-		Tags:          db.NormalizeTags(h.Tags),
-		Hostname:      h.Hostname,
-		Username:      h.Username,
-		Port:          h.Port,
-		KeyType:       h.KeyType,
-		CreatedAt:     h.CreatedAt,
-		UpdatedAt:     h.UpdatedAt,
-		LastConnected: h.LastConnected,
+		ID:               h.ID,
+		Label:            h.Label,
+		GroupName:        h.GroupName,
+		Tags:             db.NormalizeTags(h.Tags),
+		Hostname:         h.Hostname,
+		Username:         h.Username,
+		Port:             h.Port,
+		KeyType:          h.KeyType,
+		Recording:        h.Recording,
+		SSHOptions:       allowedOptions(h.SSHOptions),
+		Pinned:           h.Pinned,
+		MACAddress:       h.MACAddress,
+		BroadcastAddr:    h.BroadcastAddr,
+		KeepAliveSeconds: h.KeepAliveSeconds,
+		ForwardX11:       h.ForwardX11,
+		ProxyHosts:       h.ProxyHosts,
+		TermOverride:     h.TermOverride,
+		Icon:             h.Icon,
+		ColorOverride:    h.ColorOverride,
+		IdentitiesOnly:   h.IdentitiesOnly,
+		CreatedAt:        h.CreatedAt,
+		UpdatedAt:        h.UpdatedAt,
+		LastConnected:    h.LastConnected,
 	}
 
 	// Use CreateHostWithID to preserve the remote ID
 	return store.CreateHostWithID(host, keyData)
 }
 
+// allowedOptions keeps only the ssh options accepted from sync. Import
+// reports what it drops; OverrideConflict relies on this alone.
+func allowedOptions(opts map[string]string) map[string]string {
+	kept, _ := ssh.FilterSyncedOptions(opts)
+	return kept
+}
+
 // updateHostFromSync updates an existing host with sync data.
 func updateHostFromSync(store *db.Store, h SyncHost, keyData string) error {
 	if h.GroupName != "" {
@@ -182,17 +321,29 @@ func updateHostFromSync(store *db.Store, h SyncHost, keyData string) error {
 	}
 
 	host := &db.HostModel{
-		ID:            h.ID,
-		Label:         h.Label,
-		GroupName:     h.GroupName,
-		Tags:          db.NormalizeTags(h.Tags),
-		Hostname:      h.Hostname,
-		Username:      h.Username,
-		Port:          h.Port,
-		KeyType:       h.KeyType,
-		CreatedAt:     h.CreatedAt,
-		UpdatedAt:     h.UpdatedAt,
-		LastConnected: h.LastConnected,
+		ID:               h.ID,
+		Label:            h.Label,
+		GroupName:        h.GroupName,
+		Tags:             db.NormalizeTags(h.Tags),
+		Hostname:         h.Hostname,
+		Username:         h.Username,
+		Port:             h.Port,
+		KeyType:          h.KeyType,
+		Recording:        h.Recording,
+		SSHOptions:       allowedOptions(h.SSHOptions),
+		Pinned:           h.Pinned,
+		MACAddress:       h.MACAddress,
+		BroadcastAddr:    h.BroadcastAddr,
+		KeepAliveSeconds: h.KeepAliveSeconds,
+		ForwardX11:       h.ForwardX11,
+		ProxyHosts:       h.ProxyHosts,
+		TermOverride:     h.TermOverride,
+		Icon:             h.Icon,
+		ColorOverride:    h.ColorOverride,
+		IdentitiesOnly:   h.IdentitiesOnly,
+		CreatedAt:        h.CreatedAt,
+		UpdatedAt:        h.UpdatedAt,
+		LastConnected:    h.LastConnected,
 	}
 
 	// Use UpdateHostFromSync to set exact values including encrypted key
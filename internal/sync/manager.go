@@ -23,6 +23,7 @@ type Manager struct {
 	lastResult *SyncResult
 	status     SyncStatus
 	stage      string
+	onStage    func(stage string)
 }
 
 // NewManager creates a new sync manager
@@ -94,110 +95,128 @@ func (m *Manager) Sync() *SyncResult {
 		return result
 	}
 
-	// Step 2: Pull remote changes
-	if m.git.HasRemote() {
-		m.setStage("pulling")
-		if err := m.git.Pull(); err != nil {
+	// PushOnly skips pulling and importing; PullOnly skips exporting,
+	// committing and pushing.
+	var remoteData *SyncData
+	if !m.cfg.Sync.PushOnly {
+		// Step 2: Pull remote changes
+		if m.git.HasRemote() {
+			m.setStage("pulling")
+			if err := m.git.Pull(); err != nil {
+				result.Error = err
+				result.Message = fmt.Sprintf("Pull failed: %v", err)
+				m.setSyncState(SyncStatusError, "", result, false)
+				return result
+			}
+		}
+
+		// Step 3: Load remote data and import
+		m.setStage("loading")
+		loaded, err := LoadFromFile(m.git.GetSyncFilePath(), m.password)
+		if err != nil {
 			result.Error = err
-			result.Message = fmt.Sprintf("Pull failed: %v", err)
+			result.Message = fmt.Sprintf("Load failed: %v", err)
 			m.setSyncState(SyncStatusError, "", result, false)
 			return result
 		}
+		remoteData = loaded
+
+		if remoteData != nil {
+			m.setStage("importing")
+			importResult, err := Import(m.store, remoteData, m.password)
+			if err != nil {
+				result.Error = err
+				result.Message = fmt.Sprintf("Import failed: %v", err)
+				m.setSyncState(SyncStatusError, "", result, false)
+				return result
+			}
+			result.HostsAdded = importResult.Added
+			result.HostsUpdated = importResult.Updated
+			result.HostsPulled = importResult.Added + importResult.Updated
+			result.Conflicts = importResult.Conflicts
+			result.Warning = strings.Join(importResult.Warnings, "; ")
+
+			if m.cfg.Automation.SyncTokenDefinitions {
+				vault, err := authtoken.LoadVault()
+				if err == nil && vault != nil {
+					if vault.MergeSyncDefinitions(remoteData.TokenDefs) {
+						_ = authtoken.SaveVault(vault)
+					}
+				}
+			}
+		}
 	}
 
-	// Step 3: Load remote data and import
-	m.setStage("loading")
-	remoteData, err := LoadFromFile(m.git.GetSyncFilePath(), m.password)
-	if err != nil {
-		result.Error = err
-		result.Message = fmt.Sprintf("Load failed: %v", err)
-		m.setSyncState(SyncStatusError, "", result, false)
-		return result
-	}
-
-	if remoteData != nil {
-		m.setStage("importing")
-		importResult, err := Import(m.store, remoteData, m.password)
+	if !m.cfg.Sync.PullOnly {
+		// Step 4: Export local data
+		m.setStage("exporting")
+		// Best-effort: garbage collect old group tombstones before exporting.
+		if m.store != nil {
+			_ = m.store.PurgeDeletedGroups(GroupTombstoneRetention)
+		}
+		localData, err := Export(m.store)
 		if err != nil {
 			result.Error = err
-			result.Message = fmt.Sprintf("Import failed: %v", err)
+			result.Message = fmt.Sprintf("Export failed: %v", err)
 			m.setSyncState(SyncStatusError, "", result, false)
 			return result
 		}
-		result.HostsAdded = importResult.Added
-		result.HostsUpdated = importResult.Updated
-		result.HostsPulled = importResult.Added + importResult.Updated
-		result.Conflicts = importResult.Conflicts
-
+		result.HostsPushed = computeHostsPushed(localData, remoteData)
 		if m.cfg.Automation.SyncTokenDefinitions {
 			vault, err := authtoken.LoadVault()
 			if err == nil && vault != nil {
-				if vault.MergeSyncDefinitions(remoteData.TokenDefs) {
-					_ = authtoken.SaveVault(vault)
+				if hosts, herr := m.store.GetHosts(); herr == nil {
+					labels := make(map[int]string, len(hosts))
+					for _, h := range hosts {
+						d := strings.TrimSpace(h.Label)
+						if d == "" {
+							d = strings.TrimSpace(h.Hostname)
+						}
+						labels[h.ID] = d
+					}
+					if vault.SyncHostLabels(labels) {
+						_ = authtoken.SaveVault(vault)
+					}
 				}
+				localData.TokenDefs = vault.ExportSyncDefinitions()
 			}
 		}
-	}
-
-	// Step 4: Export local data
-	m.setStage("exporting")
-	// Best-effort: garbage collect old group tombstones before exporting.
-	if m.store != nil {
-		_ = m.store.PurgeDeletedGroups(GroupTombstoneRetention)
-	}
-	localData, err := Export(m.store)
-	if err != nil {
-		result.Error = err
-		result.Message = fmt.Sprintf("Export failed: %v", err)
-		m.setSyncState(SyncStatusError, "", result, false)
-		return result
-	}
-	result.HostsPushed = computeHostsPushed(localData, remoteData)
-	if m.cfg.Automation.SyncTokenDefinitions {
-		vault, err := authtoken.LoadVault()
-		if err == nil && vault != nil {
-			if hosts, herr := m.store.GetHosts(); herr == nil {
-				labels := make(map[int]string, len(hosts))
-				for _, h := range hosts {
-					d := strings.TrimSpace(h.Label)
-					if d == "" {
-						d = strings.TrimSpace(h.Hostname)
-					}
-					labels[h.ID] = d
-				}
-				if vault.SyncHostLabels(labels) {
-					_ = authtoken.SaveVault(vault)
-				}
+		if m.cfg.Sync.EncryptPayload {
+			var saltHex string
+			saltHex, err = m.store.SyncSalt()
+			if err == nil {
+				err = ExportOpaqueDataToFile(localData, m.git.GetSyncFilePath(), m.password, saltHex)
 			}
-			localData.TokenDefs = vault.ExportSyncDefinitions()
+		} else {
+			err = ExportDataToFile(localData, m.git.GetSyncFilePath(), m.password)
+		}
+		if err != nil {
+			result.Error = err
+			result.Message = fmt.Sprintf("Export failed: %v", err)
+			m.setSyncState(SyncStatusError, "", result, false)
+			return result
 		}
-	}
-	if err := ExportDataToFile(localData, m.git.GetSyncFilePath(), m.password); err != nil {
-		result.Error = err
-		result.Message = fmt.Sprintf("Export failed: %v", err)
-		m.setSyncState(SyncStatusError, "", result, false)
-		return result
-	}
-
-	// Step 5: Commit changes
-	m.setStage("committing")
-	commitMsg := fmt.Sprintf("Sync: %s", time.Now().Format(time.RFC3339))
-	if err := m.git.CommitChanges(commitMsg); err != nil {
-		result.Error = err
-		result.Message = fmt.Sprintf("Commit failed: %v", err)
-		m.setSyncState(SyncStatusError, "", result, false)
-		return result
-	}
 
-	// Step 6: Push to remote
-	if m.git.HasRemote() {
-		m.setStage("pushing")
-		if err := m.git.Push(); err != nil {
+		// Step 5: Commit changes
+		m.setStage("committing")
+		commitMsg := fmt.Sprintf("Sync: %s", time.Now().Format(time.RFC3339))
+		if err := m.git.CommitChanges(commitMsg); err != nil {
 			result.Error = err
-			result.Message = fmt.Sprintf("Push failed: %v", err)
+			result.Message = fmt.Sprintf("Commit failed: %v", err)
 			m.setSyncState(SyncStatusError, "", result, false)
 			return result
 		}
+
+		// Step 6: Push to remote
+		if m.git.HasRemote() {
+			m.setStage("pushing")
+			if err := m.git.Push(); err != nil {
+				result.Error = err
+				result.Message = fmt.Sprintf("Push failed: %v", err)
+				m.setSyncState(SyncStatusError, "", result, false)
+				return result
+			}
+		}
 	}
 
 	// Success
@@ -206,6 +225,13 @@ func (m *Manager) Sync() *SyncResult {
 	result.Success = true
 	result.Message = "Sync completed successfully"
 	result.Timestamp = now
+	if url := strings.TrimSpace(m.cfg.Sync.WebhookURL); url != "" {
+		m.setStage("notifying")
+		payload := WebhookPayload{Event: "sync", Added: result.HostsAdded, Updated: result.HostsUpdated, Timestamp: now.UTC().Format(time.RFC3339)}
+		if err := SendWebhook(url, m.cfg.Sync.WebhookSecret, payload); err != nil {
+			result.Warning = joinWarning(result.Warning, fmt.Sprintf("webhook failed: %v", err))
+		}
+	}
 	m.mu.Lock()
 	m.status = SyncStatusSuccess
 	m.stage = ""
@@ -216,6 +242,63 @@ func (m *Manager) Sync() *SyncResult {
 	return result
 }
 
+// DryRun previews what the next sync would import from the remote, without
+// pulling into the worktree or writing to the database.
+func (m *Manager) DryRun() (*ImportResult, error) {
+	if m.GetStatus() == SyncStatusDisabled {
+		return nil, fmt.Errorf("sync is disabled")
+	}
+	if err := m.Init(); err != nil {
+		return nil, fmt.Errorf("init failed: %w", err)
+	}
+	raw, err := m.git.ReadRemoteSyncFile()
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return &ImportResult{}, nil
+	}
+	remoteData, err := decodeSyncFile(raw, m.password)
+	if err != nil {
+		return nil, err
+	}
+	return DryRun(m.store, remoteData)
+}
+
+// RollbackTarget describes the commit Rollback would restore.
+func (m *Manager) RollbackTarget() (CommitInfo, error) {
+	if m.GetStatus() == SyncStatusDisabled {
+		return CommitInfo{}, fmt.Errorf("sync is disabled")
+	}
+	if err := m.Init(); err != nil {
+		return CommitInfo{}, fmt.Errorf("init failed: %w", err)
+	}
+	parent, err := m.git.PreviousCommit()
+	if err != nil {
+		return CommitInfo{}, err
+	}
+	return CommitInfo{Message: strings.TrimSpace(parent.Message), When: parent.Author.When}, nil
+}
+
+// Rollback undoes the last sync: it resets the sync repository to the
+// previous commit and restores the hosts recorded there into the database.
+func (m *Manager) Rollback() (*ImportResult, error) {
+	if m.GetStatus() == SyncStatusDisabled {
+		return nil, fmt.Errorf("sync is disabled")
+	}
+	if err := m.Init(); err != nil {
+		return nil, fmt.Errorf("init failed: %w", err)
+	}
+	if err := m.git.Rollback(); err != nil {
+		return nil, err
+	}
+	data, err := LoadFromFile(m.git.GetSyncFilePath(), m.password)
+	if err != nil {
+		return nil, err
+	}
+	return Restore(m.store, data, m.password)
+}
+
 // GetStatus returns the current sync status
 func (m *Manager) GetStatus() SyncStatus {
 	m.mu.RLock()
@@ -283,10 +366,22 @@ func (m *Manager) StatusString() string {
 	}
 }
 
+// SetStageHook registers fn to be called as each sync stage starts, e.g.
+// "pulling" or "pushing". It runs on the syncing goroutine.
+func (m *Manager) SetStageHook(fn func(stage string)) {
+	m.mu.Lock()
+	m.onStage = fn
+	m.mu.Unlock()
+}
+
 func (m *Manager) setStage(stage string) {
 	m.mu.Lock()
 	m.stage = stage
+	hook := m.onStage
 	m.mu.Unlock()
+	if hook != nil {
+		hook(stage)
+	}
 }
 
 func (m *Manager) setSyncState(status SyncStatus, stage string, result *SyncResult, updateLastSync bool) {
@@ -339,3 +434,11 @@ func computeHostsPushed(local, remote *SyncData) int {
 	}
 	return pushed
 }
+
+// joinWarning appends w to an existing warning.
+func joinWarning(existing, w string) string {
+	if existing == "" {
+		return w
+	}
+	return existing + "; " + w
+}
@@ -19,6 +19,20 @@ func TestStageStringReturnsCurrentStage(t *testing.T) {
 	}
 }
 
+func TestStageHookSeesEachStage(t *testing.T) {
+	m := &Manager{}
+	var got []string
+	m.SetStageHook(func(stage string) { got = append(got, stage) })
+	m.setStage("pulling")
+	m.setStage("pushing")
+	if len(got) != 2 || got[0] != "pulling" || got[1] != "pushing" {
+		t.Fatalf("expected pulling then pushing, got %v", got)
+	}
+	if m.StageString() != "pushing" {
+		t.Fatalf("expected stage to still be recorded, got %q", m.StageString())
+	}
+}
+
 func TestComputeHostsPushed(t *testing.T) {
 	now := time.Now()
 	older := now.Add(-time.Hour)
@@ -0,0 +1,48 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/Vansh-Raja/SSHThing/internal/config"
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestSyncPullOnlyAndPushOnly(t *testing.T) {
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init("testpassword123")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+	if err := store.CreateHost(&db.HostModel{Label: "web", Hostname: "web.example.com", Username: "ubuntu", Port: 22, KeyType: "password"}, ""); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+
+	cfg := config.Default()
+	cfg.Sync.Enabled = true
+	cfg.Sync.LocalPath = t.TempDir()
+	cfg.Sync.PullOnly = true
+	mgr, err := NewManager(&cfg, store, "testpassword123")
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	if res := mgr.Sync(); !res.Success {
+		t.Fatalf("pull-only sync failed: %s", res.Message)
+	}
+	data, err := LoadFromFile(mgr.git.GetSyncFilePath(), "testpassword123")
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	if data != nil && len(data.Hosts) != 0 {
+		t.Fatalf("pull-only sync must not export local hosts, got %+v", data.Hosts)
+	}
+
+	cfg.Sync.PullOnly, cfg.Sync.PushOnly = false, true
+	if res := mgr.Sync(); !res.Success || res.HostsPushed != 1 {
+		t.Fatalf("push-only sync: expected 1 host pushed, got %+v", res)
+	}
+	data, err = LoadFromFile(mgr.git.GetSyncFilePath(), "testpassword123")
+	if err != nil || data == nil || len(data.Hosts) != 1 {
+		t.Fatalf("expected the local host exported, got %+v (%v)", data, err)
+	}
+}
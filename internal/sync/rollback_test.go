@@ -0,0 +1,51 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitManagerRollback(t *testing.T) {
+	dir := t.TempDir()
+	gm := NewGitManager(dir, "", "main", "")
+	if err := gm.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	if err := gm.Rollback(); err == nil || !strings.Contains(err.Error(), "only one commit") {
+		t.Fatalf("expected single-commit rollback to fail, got %v", err)
+	}
+
+	path := filepath.Join(dir, SyncFileName)
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(`{"version":3,"hosts":[]}`), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := gm.CommitChanges("Sync: bad"); err != nil {
+		t.Fatalf("CommitChanges failed: %v", err)
+	}
+
+	prev, err := gm.PreviousCommit()
+	if err != nil {
+		t.Fatalf("PreviousCommit failed: %v", err)
+	}
+	if strings.TrimSpace(prev.Message) != "Initial SSHThing sync" {
+		t.Fatalf("unexpected previous commit message: %q", prev.Message)
+	}
+
+	if err := gm.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(after) != string(before) {
+		t.Fatalf("expected sync file to be restored, got %s", after)
+	}
+}
@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestSyncRoundTrip(t *testing.T) {
+	const password = "testpassword123"
+	open := func() *db.Store {
+		t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+		store, err := db.Init(password)
+		if err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	}
+	a := open()
+	b := open()
+
+	// One local repository stands in for the shared remote.
+	gm := NewGitManager(t.TempDir(), "", "main", "")
+	if err := gm.Init(); err != nil {
+		t.Fatalf("git Init failed: %v", err)
+	}
+	push := func(store *db.Store, msg string) {
+		if err := ExportToFile(store, gm.GetSyncFilePath(), password); err != nil {
+			t.Fatalf("ExportToFile failed: %v", err)
+		}
+		if err := gm.CommitChanges(msg); err != nil {
+			t.Fatalf("CommitChanges failed: %v", err)
+		}
+	}
+	pull := func(store *db.Store) *ImportResult {
+		data, err := LoadFromFile(gm.GetSyncFilePath(), password)
+		if err != nil {
+			t.Fatalf("LoadFromFile failed: %v", err)
+		}
+		res, err := Import(store, data, password)
+		if err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+		return res
+	}
+
+	for _, g := range []string{"Web", "DB"} {
+		if err := a.UpsertGroup(g); err != nil {
+			t.Fatalf("UpsertGroup failed: %v", err)
+		}
+	}
+	for i := 1; i <= 5; i++ {
+		group := "Web"
+		if i > 3 {
+			group = "DB"
+		}
+		h := &db.HostModel{Label: fmt.Sprintf("host-%d", i), Hostname: fmt.Sprintf("10.0.0.%d", i), Username: "ubuntu", Port: 22, KeyType: "password", GroupName: group}
+		if err := a.CreateHost(h, fmt.Sprintf("secret-%d", i)); err != nil {
+			t.Fatalf("CreateHost failed: %v", err)
+		}
+	}
+
+	push(a, "sync from A")
+	if res := pull(b); res.Added != 5 {
+		t.Fatalf("expected 5 hosts added on B, got %+v", res)
+	}
+	groups, err := b.GetGroups()
+	if err != nil || len(groups) != 2 {
+		t.Fatalf("expected both groups on B, got %v (%v)", groups, err)
+	}
+	hostsB, err := b.GetHosts()
+	if err != nil {
+		t.Fatalf("GetHosts failed: %v", err)
+	}
+	for _, h := range hostsB {
+		if secret, err := b.GetHostSecret(h.ID); err != nil || secret == "" {
+			t.Fatalf("expected %s's password to survive re-encryption, got %q (%v)", h.Label, secret, err)
+		}
+	}
+
+	extra := &db.HostModel{Label: "host-b", Hostname: "10.0.1.1", Username: "admin", Port: 2222, KeyType: "password", GroupName: "DB"}
+	if err := b.CreateHost(extra, "secret-b"); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	push(b, "sync from B")
+	pull(a)
+
+	hostsA, err := a.GetHosts()
+	if err != nil {
+		t.Fatalf("GetHosts failed: %v", err)
+	}
+	if len(hostsA) != 6 {
+		t.Fatalf("expected A to see all 6 hosts, got %d", len(hostsA))
+	}
+	for _, h := range hostsA {
+		if h.Label != "host-b" {
+			continue
+		}
+		if h.Hostname != "10.0.1.1" || h.Port != 2222 || h.GroupName != "DB" {
+			t.Fatalf("unexpected synced host on A: %+v", h)
+		}
+		if secret, err := a.GetHostSecret(h.ID); err != nil || secret != "secret-b" {
+			t.Fatalf("expected B's password on A, got %q (%v)", secret, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vansh-Raja/SSHThing/internal/db"
+)
+
+func TestGroupDeletionPropagates(t *testing.T) {
+	open := func() *db.Store {
+		t.Setenv("SSHTHING_DATA_DIR", t.TempDir())
+		store, err := db.Init("testpassword123")
+		if err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	}
+	a := open()
+	b := open()
+
+	h := &db.HostModel{Label: "lab-1", Hostname: "lab-1.example.com", Username: "ubuntu", Port: 22, KeyType: "password", GroupName: "Lab"}
+	if err := a.UpsertGroup("Lab"); err != nil {
+		t.Fatalf("UpsertGroup failed: %v", err)
+	}
+	if err := a.CreateHost(h, ""); err != nil {
+		t.Fatalf("CreateHost failed: %v", err)
+	}
+	push := func(from, to *db.Store) {
+		data, err := Export(from)
+		if err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		if _, err := Import(to, data, "testpassword123"); err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+	}
+	push(a, b)
+	if groups, _ := b.GetGroups(); len(groups) != 1 || groups[0] != "Lab" {
+		t.Fatalf("expected Lab on the second device, got %v", groups)
+	}
+
+	// Keep the deletion strictly newer than the synced group.
+	time.Sleep(10 * time.Millisecond)
+	if err := a.DeleteGroup("Lab"); err != nil {
+		t.Fatalf("DeleteGroup failed: %v", err)
+	}
+	data, err := Export(a)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(data.Groups) != 1 || data.Groups[0].DeletedAt == nil {
+		t.Fatalf("expected the Lab tombstone in the export, got %+v", data.Groups)
+	}
+	if _, err := Import(b, data, "testpassword123"); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if groups, _ := b.GetGroups(); len(groups) != 0 {
+		t.Fatalf("expected Lab to be deleted on the second device, got %v", groups)
+	}
+	hosts, err := b.GetHosts()
+	if err != nil {
+		t.Fatalf("GetHosts failed: %v", err)
+	}
+	if len(hosts) != 1 || hosts[0].GroupName != "" {
+		t.Fatalf("expected the host to be ungrouped, got %+v", hosts)
+	}
+
+	// A group recreated after the deletion wins over the older tombstone.
+	time.Sleep(10 * time.Millisecond)
+	if err := b.UpsertGroup("Lab"); err != nil {
+		t.Fatalf("UpsertGroup failed: %v", err)
+	}
+	if _, err := Import(b, data, "testpassword123"); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if groups, _ := b.GetGroups(); len(groups) != 1 {
+		t.Fatalf("expected the recreated Lab to survive a stale tombstone, got %v", groups)
+	}
+}
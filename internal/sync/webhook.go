@@ -0,0 +1,61 @@
+package sync
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout bounds the whole webhook request, so a slow endpoint
+// never holds up a sync for long.
+const webhookTimeout = 5 * time.Second
+
+// WebhookPayload is the JSON body posted to the sync webhook.
+type WebhookPayload struct {
+	Event     string `json:"event"`
+	Added     int    `json:"added"`
+	Updated   int    `json:"updated"`
+	Timestamp string `json:"timestamp"`
+}
+
+// WebhookSignature returns the X-SSHThing-Signature value for body: the
+// hex HMAC-SHA256 of body keyed by secret, prefixed with "sha256=".
+func WebhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SendWebhook posts p to url as JSON, signed with secret. Any non-2xx
+// response is an error.
+func SendWebhook(url, secret string, p WebhookPayload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sshthing-sync")
+	req.Header.Set("X-SSHThing-Signature", WebhookSignature(secret, body))
+
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("webhook returned %s (%s)", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
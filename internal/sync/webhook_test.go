@@ -0,0 +1,39 @@
+package sync
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendWebhook(t *testing.T) {
+	var got WebhookPayload
+	var sig string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sig = r.Header.Get("X-SSHThing-Signature")
+		if sig != WebhookSignature("s3cret", body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.Unmarshal(body, &got)
+	}))
+	defer srv.Close()
+
+	p := WebhookPayload{Event: "sync", Added: 2, Updated: 1, Timestamp: "2026-01-02T03:04:05Z"}
+	if err := SendWebhook(srv.URL, "s3cret", p); err != nil {
+		t.Fatalf("SendWebhook failed: %v", err)
+	}
+	if got != p {
+		t.Fatalf("expected payload %+v, got %+v", p, got)
+	}
+	if len(sig) != len("sha256=")+64 {
+		t.Fatalf("unexpected signature %q", sig)
+	}
+
+	if err := SendWebhook(srv.URL, "wrong", p); err == nil {
+		t.Fatalf("expected an error for a rejected webhook")
+	}
+}
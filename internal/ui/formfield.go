@@ -123,6 +123,27 @@ func (r *Renderer) RenderInput(f FormField, focused bool, width int, blink bool,
 	return "  " + bar + displayVal
 }
 
+// RenderTextArea renders a multi-line form field, one bar-prefixed row per
+// line of the value. While editing, the cursor is drawn on the line holding it;
+// otherwise the focus underline goes on the last line.
+func (r *Renderer) RenderTextArea(f FormField, focused bool, width int, blink bool, editing bool) string {
+	lines := strings.Split(f.Value, "\n")
+	rows := make([]string, 0, len(lines))
+	offset := 0
+	for i, line := range lines {
+		n := utf8.RuneCountInString(line)
+		row := FormField{Value: line}
+		rowFocused := focused && i == len(lines)-1
+		if editing {
+			rowFocused = focused && f.Cursor >= offset && f.Cursor <= offset+n
+			row.Cursor = f.Cursor - offset
+		}
+		rows = append(rows, r.RenderInput(row, rowFocused, width, blink, editing))
+		offset += n + 1
+	}
+	return strings.Join(rows, "\n")
+}
+
 // RenderModalField renders a field for modal overlays (always-editing when focused).
 func (r *Renderer) RenderModalField(value string, cursor int, masked bool, focused bool, blink bool, bg lipgloss.Color) string {
 	barColor := r.Theme.Surface0
@@ -16,15 +16,30 @@ type SyncActivity struct {
 	Stage    string
 }
 
+// WakeActivity holds the state of a Wake-on-LAN wait for a host.
+type WakeActivity struct {
+	Active      bool
+	Frame       int
+	Host        string
+	Attempt     int
+	MaxAttempts int
+}
+
 // HomeViewParams holds all data needed to render the home page.
 type HomeViewParams struct {
 	Items        []HomeListItem
 	Cursor       int
 	Err          error
 	SyncActivity *SyncActivity
+	WakeActivity *WakeActivity
 	Page         int
 	HostCount    int
 	Connected    int
+	FooterNotice string    // persistent warning shown above the footer (e.g. expiring tokens)
+	MarkedCount  int       // hosts marked for bulk actions
+	SortLabel    string    // current host order, e.g. "sort: label ↑"
+	SessionUntil time.Time // unlock session cache expiry; zero when there is none
+	Breadcrumbs  []string  // group path of the selected row, outermost first; empty at top level
 }
 
 // HomeListItem represents one row in the home list.
@@ -32,8 +47,12 @@ type HomeListItem struct {
 	IsGroup    bool
 	IsNewGroup bool
 	GroupName  string
+	Indent     int // nesting depth of the group (or the host's group)
 	Collapsed  bool
 	HostCount  int
+	Virtual    bool   // read-only group populated by a config filter
+	Filter     string // filter expression of a virtual group
+	FilterErr  string // why the filter could not be parsed
 	// Host fields
 	Label         string
 	Hostname      string
@@ -43,9 +62,36 @@ type HomeListItem struct {
 	Tags          []string
 	Status        int // 0=offline, 1=idle, 2=connected
 	LastSSH       string
-	Mounted       bool
-	MountPath     string
+	Mounts        []HomeMount
+	ProxyPort     int // >0 while a SOCKS proxy is running through this host
 	LastConnected *time.Time
+	Marked        bool // selected for bulk actions
+	Pinned        bool
+	Icon          string // host's own list icon; replaces the focus marker when set
+	Color         string // hex label tint from the host or its group; "" for the theme color
+
+	Fingerprint        string // SHA256 host key fingerprint from the last connect
+	FingerprintChanged bool   // differs from the one seen before; possible MITM
+
+	Ping   HostPing // background reachability check; zero when not checked
+	Banner string   // server's pre-auth SSH banner, when fetched
+
+	MACAddress string   // set when the host can be woken with Wake-on-LAN
+	JumpChain  []string // jump host names, first hop first
+}
+
+// HostPing is the latest reachability check of a host's SSH port.
+type HostPing struct {
+	Checked   bool
+	Reachable bool
+	Latency   time.Duration
+}
+
+// HomeMount describes one active mount of a host.
+type HomeMount struct {
+	LocalPath  string
+	RemotePath string // "" means the remote home directory
+	ReadOnly   bool
 }
 
 // RenderHomeView renders the three-panel home layout (list + detail + sidebar).
@@ -53,10 +99,16 @@ func (r *Renderer) RenderHomeView(p HomeViewParams) string {
 	cw := r.PageContentWidth()
 	pad := r.LeftPad()
 
+	narrowMode := r.SinglePanel()
+
 	listW := cw * 30 / 100
 	if listW < 24 {
 		listW = 24
 	}
+	if narrowMode {
+		listW = cw
+	}
+	plainIcons := listW < CompactListW
 	gapW := 4
 	detailW := cw - listW - gapW
 	if detailW < 20 {
@@ -75,10 +127,11 @@ func (r *Renderer) RenderHomeView(p HomeViewParams) string {
 	if p.SyncActivity != nil && p.SyncActivity.Active {
 		notifCount++
 	}
+	if p.WakeActivity != nil && p.WakeActivity.Active {
+		notifCount++
+	}
 	bodyH -= notifCount
 
-	narrowMode := r.W < 70
-
 	// list column
 	var listLines []string
 	for i, item := range p.Items {
@@ -102,12 +155,16 @@ func (r *Renderer) RenderHomeView(p HomeViewParams) string {
 				nameStyle = lipgloss.NewStyle().Foreground(r.Theme.Accent)
 			}
 			countStr := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render(fmt.Sprintf(" %d", item.HostCount))
+			if item.Virtual {
+				nameStyle = nameStyle.Italic(true)
+				countStr += lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render(" auto")
+			}
 			arrowR := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render(arrow)
 			if sel {
 				arrowR = lipgloss.NewStyle().Foreground(r.Theme.Accent).Render(arrow)
 			}
 			listLines = append(listLines, "")
-			listLines = append(listLines, arrowR+" "+nameStyle.Render(item.GroupName)+countStr)
+			listLines = append(listLines, strings.Repeat("  ", item.Indent)+arrowR+" "+nameStyle.Render(item.GroupName)+countStr)
 		} else {
 			prefix := "    "
 			nameStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext)
@@ -124,19 +181,40 @@ func (r *Renderer) RenderHomeView(p HomeViewParams) string {
 
 			if sel {
 				nameStyle = lipgloss.NewStyle().Foreground(r.Theme.Accent).Bold(true)
-				prefix = lipgloss.NewStyle().Foreground(r.Theme.Accent).Render("  " + r.Icons.Focused + " ")
+				focused := r.Icons.Focused
+				if plainIcons {
+					focused = ">"
+				}
+				prefix = lipgloss.NewStyle().Foreground(r.Theme.Accent).Render("  " + focused + " ")
 			}
-
-			maxLblW := listW - 10
-			if narrowMode {
-				maxLblW = r.W - 16
+			if item.Color != "" {
+				nameStyle = nameStyle.Foreground(lipgloss.Color(item.Color))
+			}
+			if item.Icon != "" {
+				prefix = " " + item.Icon + strings.Repeat(" ", max(1, 3-lipgloss.Width(item.Icon)))
 			}
+
+			maxLblW := listW - 10 - 2*item.Indent
 			lbl := r.TruncStr(item.Label, maxLblW)
 			if lbl == "" {
 				lbl = r.TruncStr(item.Hostname, maxLblW)
 			}
 
-			listLines = append(listLines, prefix+dot+" "+nameStyle.Render(lbl))
+			if item.Marked {
+				dot = lipgloss.NewStyle().Foreground(r.Theme.Accent).Render(r.Icons.Marked)
+			}
+
+			if item.Pinned && !plainIcons {
+				lbl = lipgloss.NewStyle().Foreground(r.Theme.Yellow).Render(r.Icons.Pinned) + " " + nameStyle.Render(r.TruncStr(lbl, maxLblW-2))
+			} else {
+				lbl = nameStyle.Render(lbl)
+			}
+
+			row := strings.Repeat("  ", item.Indent) + prefix + dot + " " + lbl
+			if item.ProxyPort > 0 && !plainIcons {
+				row += " " + lipgloss.NewStyle().Foreground(r.Theme.Green).Render(r.Icons.Proxy)
+			}
+			listLines = append(listLines, row)
 		}
 	}
 
@@ -168,7 +246,17 @@ func (r *Renderer) RenderHomeView(p HomeViewParams) string {
 	}
 
 	// footer keybind bar — always visible
-	footerText := r.RenderFooter("\u2191\u2193 nav  \u23CE connect  S sftp  M mount  Y sync  / search  a add  e edit  d del  , settings  ? help  q quit")
+	footerText := r.RenderFooter("\u2191\u2193 nav  \u23CE connect  S sftp  M mount  T tunnels  Y sync  / search  a add  e edit  d del  , settings  ? help  q quit")
+	if narrowMode {
+		footerText = r.RenderFooter("\u2191\u2193 nav  \u23CE connect  tab details  / search  a add  e edit  ? help  q quit")
+	}
+	if p.MarkedCount > 0 {
+		footerText = r.RenderFooter("space toggle  D delete all  G move to group  E export selected  R run command  esc clear")
+	}
+	if !p.SessionUntil.IsZero() {
+		footerText += "  " + lipgloss.NewStyle().Foreground(r.Theme.Sky).
+			Render("[session: unlocked until "+p.SessionUntil.Format("15:04")+"]")
+	}
 
 	// notification area above footer (err + sync status)
 	var notifLine string
@@ -178,14 +266,58 @@ func (r *Renderer) RenderHomeView(p HomeViewParams) string {
 	if p.SyncActivity != nil && p.SyncActivity.Active {
 		notifLine += r.renderSyncFooter(p.SyncActivity) + "\n"
 	}
+	if p.WakeActivity != nil && p.WakeActivity.Active {
+		notifLine += r.renderWakeFooter(p.WakeActivity) + "\n"
+	}
+	if p.FooterNotice != "" {
+		notifLine += r.renderFooterNotice(p.FooterNotice) + "\n"
+	}
 
 	headerLine := r.RenderHeader("", p.HostCount, p.Connected)
+	headerExtra := ""
+	if p.SortLabel != "" {
+		headerExtra += "  " + lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render(p.SortLabel)
+	}
+	if p.MarkedCount > 0 {
+		headerExtra += "  " + lipgloss.NewStyle().Foreground(r.Theme.Accent).Bold(true).
+			Render(fmt.Sprintf("[%d selected]", p.MarkedCount))
+	}
+	if len(p.Breadcrumbs) > 0 {
+		if avail := cw - lipgloss.Width(headerLine) - lipgloss.Width(headerExtra) - 4; avail >= 8 {
+			headerLine += "    " + r.RenderBreadcrumbs(p.Breadcrumbs, avail)
+		}
+	}
+	headerLine += headerExtra
 	inner := headerLine + "\n\n" + body + "\n\n" + notifLine + footerText
 	padded := r.PadContent(inner, pad)
 
 	return padded
 }
 
+// RenderHostDetailOverlay renders the detail panel of the selected row as a
+// modal, for terminals too narrow to show it beside the list.
+func (r *Renderer) RenderHostDetailOverlay(p HomeViewParams) string {
+	w := r.W - 8
+	if w > 60 {
+		w = 60
+	}
+	if w < 30 {
+		w = 30
+	}
+	h := r.H - 8
+	if h < 6 {
+		h = 6
+	}
+
+	detail := lipgloss.NewStyle().Width(w).Foreground(r.Theme.Subtext).Render(r.renderDetail(p, w, h))
+	content := detail + "\n\n" +
+		lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("any key to close")
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center,
+		lipgloss.NewStyle().Padding(1, 2).Render(content),
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
 func (r *Renderer) renderDetail(p HomeViewParams, w, h int) string {
 	if p.Cursor >= len(p.Items) {
 		return ""
@@ -197,6 +329,17 @@ func (r *Renderer) renderDetail(p HomeViewParams, w, h int) string {
 		return lipgloss.NewStyle().Foreground(r.Theme.Text).Bold(true).Render("new group") + "\n\n" + hint
 	}
 
+	if item.IsGroup && item.Virtual {
+		name := lipgloss.NewStyle().Foreground(r.Theme.Text).Bold(true).Render(item.GroupName)
+		sub := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Render(fmt.Sprintf("%d servers  \u00B7  virtual", item.HostCount))
+		filter := lipgloss.NewStyle().Foreground(r.Theme.Pink).Render(item.Filter)
+		if item.FilterErr != "" {
+			filter += "\n" + lipgloss.NewStyle().Foreground(r.Theme.Red).Render("invalid filter: "+item.FilterErr)
+		}
+		hint := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("enter toggle  \u00B7  edit in config.json")
+		return name + "\n" + sub + "\n\n" + filter + "\n\n" + hint
+	}
+
 	if item.IsGroup {
 		name := lipgloss.NewStyle().Foreground(r.Theme.Text).Bold(true).Render(item.GroupName)
 		sub := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Render(fmt.Sprintf("%d servers", item.HostCount))
@@ -217,6 +360,7 @@ func (r *Renderer) renderDetail(p HomeViewParams, w, h int) string {
 	default:
 		statusR = lipgloss.NewStyle().Foreground(r.Theme.Subtext).Render("offline")
 	}
+	statusR += "  " + r.renderPingBadge(item.Ping)
 
 	connStr := fmt.Sprintf("%s@%s", item.Username, item.Hostname)
 	if item.Port != 22 && item.Port != 0 {
@@ -225,7 +369,7 @@ func (r *Renderer) renderDetail(p HomeViewParams, w, h int) string {
 
 	tagStr := ""
 	for _, t := range item.Tags {
-		tagStr += lipgloss.NewStyle().Foreground(r.Theme.Pink).Render(t) + "  "
+		tagStr += r.renderTagPill(t) + " "
 	}
 	if tagStr == "" {
 		tagStr = lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("no tags")
@@ -245,9 +389,27 @@ func (r *Renderer) renderDetail(p HomeViewParams, w, h int) string {
 		lastSeen = "never"
 	}
 
-	mountLine := ""
-	if item.Mounted {
-		mountLine = kStyle.Render("mount       ") + lipgloss.NewStyle().Foreground(r.Theme.Green).Render(item.MountPath)
+	var mountLines []string
+	for i, mt := range item.Mounts {
+		key := "mount       "
+		if i > 0 {
+			key = "            "
+		}
+		remote := mt.RemotePath
+		if remote == "" {
+			remote = "~"
+		}
+		local := mt.LocalPath
+		if mt.ReadOnly {
+			local += " (ro)"
+		}
+		mountLines = append(mountLines, kStyle.Render(key)+
+			lipgloss.NewStyle().Foreground(r.Theme.Green).Render(local)+
+			dimStyle.Render(" \u2190 "+remote))
+	}
+	proxyLine := ""
+	if item.ProxyPort > 0 {
+		proxyLine = kStyle.Render("socks       ") + lipgloss.NewStyle().Foreground(r.Theme.Green).Render(fmt.Sprintf("localhost:%d", item.ProxyPort))
 	}
 
 	lines := []string{
@@ -260,16 +422,93 @@ func (r *Renderer) renderDetail(p HomeViewParams, w, h int) string {
 		kStyle.Render("group       ") + dimStyle.Render(item.GroupName),
 		kStyle.Render("last seen   ") + dimStyle.Render(lastSeen),
 	}
-	if mountLine != "" {
-		lines = append(lines, mountLine)
+	if item.Pinned {
+		lines = append(lines, kStyle.Render("pinned      ")+vStyle.Render("yes"))
+	}
+	if item.Fingerprint != "" {
+		fp := dimStyle.Render(r.TruncStr(item.Fingerprint, w-12))
+		if item.FingerprintChanged {
+			fp = lipgloss.NewStyle().Foreground(r.Theme.Yellow).Render(r.TruncStr(item.Fingerprint, w-12)) + "\n" +
+				kStyle.Render("            ") + lipgloss.NewStyle().Foreground(r.Theme.Yellow).Render(r.Icons.Warning+" host key changed \u2014 F to accept")
+		}
+		lines = append(lines, kStyle.Render("fingerprint ")+fp)
+	}
+	if len(item.JumpChain) > 0 {
+		chain := strings.Join(append(append([]string{}, item.JumpChain...), displayLabel), " \u2192 ")
+		lines = append(lines, kStyle.Render("jump        ")+dimStyle.Render(r.TruncStr(chain, w-12)))
+	}
+	if item.MACAddress != "" {
+		lines = append(lines, kStyle.Render("mac         ")+dimStyle.Render(item.MACAddress))
+	}
+	for i, l := range bannerLines(item.Banner, 2) {
+		key := "banner      "
+		if i > 0 {
+			key = "            "
+		}
+		lines = append(lines, kStyle.Render(key)+dimStyle.Render(r.TruncStr(l, w-12)))
+	}
+	lines = append(lines, mountLines...)
+	if proxyLine != "" {
+		lines = append(lines, proxyLine)
 	}
 	lines = append(lines, "", kStyle.Render("tags        ")+tagStr)
 	lines = append(lines, "", "")
-	lines = append(lines, lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("enter connect  \u00B7  S sftp  \u00B7  M mount  \u00B7  e edit  \u00B7  d delete"))
+	actions := "enter connect  \u00B7  S sftp  \u00B7  M mount  \u00B7  e edit  \u00B7  d delete"
+	if item.MACAddress != "" {
+		actions += "  \u00B7  [W] Wake"
+	}
+	lines = append(lines, lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render(actions))
 
 	return strings.Join(lines, "\n")
 }
 
+// bannerLines returns the first max non-blank lines of banner, ending the
+// last one with an ellipsis when lines were dropped.
+func bannerLines(banner string, max int) []string {
+	var out []string
+	for _, l := range strings.Split(banner, "\n") {
+		if l = strings.TrimSpace(l); l == "" {
+			continue
+		}
+		if len(out) == max {
+			out[max-1] += " \u2026"
+			break
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// renderTagPill renders tag on a background colour picked from the tag's
+// name, so a tag keeps its colour across hosts.
+func (r *Renderer) renderTagPill(tag string) string {
+	colors := []lipgloss.Color{r.Theme.Pink, r.Theme.Sky, r.Theme.Green, r.Theme.Yellow, r.Theme.Accent}
+	sum := 0
+	for _, c := range tag {
+		sum += int(c)
+	}
+	return lipgloss.NewStyle().
+		Foreground(r.Theme.Base).
+		Background(colors[sum%len(colors)]).
+		Padding(0, 1).
+		Render(tag)
+}
+
+// renderPingBadge renders a dot for the host's reachability: green when its
+// SSH port answered, red when it did not, grey before the first check.
+func (r *Renderer) renderPingBadge(p HostPing) string {
+	switch {
+	case !p.Checked:
+		return lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("\u25CF")
+	case p.Reachable:
+		latency := p.Latency.Round(time.Millisecond)
+		return lipgloss.NewStyle().Foreground(r.Theme.Green).Render("\u25CF") +
+			lipgloss.NewStyle().Foreground(r.Theme.Subtext).Render(" "+latency.String())
+	default:
+		return lipgloss.NewStyle().Foreground(r.Theme.Red).Render("\u25CF unreachable")
+	}
+}
+
 func (r *Renderer) renderSyncFooter(activity *SyncActivity) string {
 	if activity == nil || !activity.Active {
 		return ""
@@ -284,6 +523,23 @@ func (r *Renderer) renderSyncFooter(activity *SyncActivity) string {
 	return lipgloss.NewStyle().Foreground(r.Theme.Sky).Render(label)
 }
 
+func (r *Renderer) renderWakeFooter(activity *WakeActivity) string {
+	frames := []string{"|", "/", "-", "\\"}
+	icon := frames[activity.Frame%len(frames)]
+	label := fmt.Sprintf("%s Waking %s (check %d/%d)", icon, activity.Host, activity.Attempt, activity.MaxAttempts)
+	return lipgloss.NewStyle().Foreground(r.Theme.Sky).Render(label)
+}
+
+func (r *Renderer) renderFooterNotice(notice string) string {
+	if strings.HasPrefix(notice, "\u2713") {
+		return lipgloss.NewStyle().Foreground(r.Theme.Green).Render(notice)
+	}
+	if strings.HasPrefix(notice, "\u2139") || strings.HasPrefix(notice, "\U0001F4A1") {
+		return lipgloss.NewStyle().Foreground(r.Theme.Sky).Render(notice)
+	}
+	return lipgloss.NewStyle().Foreground(r.Theme.Yellow).Render(notice)
+}
+
 func (r *Renderer) renderErrLine(err error) string {
 	if err == nil {
 		return ""
@@ -295,7 +551,7 @@ func (r *Renderer) renderErrLine(err error) string {
 	if strings.HasPrefix(msg, "\u26A0") {
 		return lipgloss.NewStyle().Foreground(r.Theme.Yellow).Render(msg)
 	}
-	if strings.HasPrefix(msg, "\u2139") {
+	if strings.HasPrefix(msg, "\u2139") || strings.HasPrefix(msg, "\u21A9") || strings.HasPrefix(msg, "\u21AA") {
 		return lipgloss.NewStyle().Foreground(r.Theme.Sky).Render(msg)
 	}
 	return lipgloss.NewStyle().Foreground(r.Theme.Red).Render(msg)
@@ -331,3 +587,17 @@ func FormatTimeAgo(t time.Time) string {
 	}
 	return fmt.Sprintf("%d weeks ago", weeks)
 }
+
+// FormatSize formats a byte count using binary units (B, KB, MB, ...).
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
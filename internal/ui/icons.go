@@ -1,6 +1,11 @@
 package ui
 
-import "strings"
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 // IconSet holds all icons used in the UI.
 type IconSet struct {
@@ -9,12 +14,13 @@ type IconSet struct {
 	Home, Settings, Tokens string
 	// Status
 	Connected, Idle, Offline string
+	Proxy, Mounted           string
 	// Markers
 	ActiveMarker, InactiveMarker string
 	// Groups
 	Expanded, Collapsed string
 	// Selection
-	Selected, Focused string
+	Selected, Focused, Marked, Pinned string
 	// Nav
 	LeftArrow, RightArrow string
 	// Input
@@ -27,6 +33,47 @@ type IconSet struct {
 	Save, Cancel, Shield              string
 }
 
+// HostIconPresets are the per-host list icons offered by the add/edit form.
+var HostIconPresets = []string{"\U0001F5A5", "\U0001F310", "\U0001F512", "\U0001F4E6", "\U0001F6E1", "\u2699", "\U0001F4BE", "\U0001F511"}
+
+// ValidHostIcon reports whether icon can be used as a host's list icon: empty,
+// or a single emoji (at most 2 runes, e.g. with a variation selector) no wider
+// than two cells.
+func ValidHostIcon(icon string) bool {
+	if icon == "" {
+		return true
+	}
+	runes := []rune(icon)
+	if len(runes) > 2 || !unicode.Is(unicode.So, runes[0]) {
+		return false
+	}
+	if len(runes) == 2 && !unicode.In(runes[1], unicode.Mn, unicode.Sk) {
+		return false
+	}
+	w := lipgloss.Width(icon)
+	return w >= 1 && w <= 2
+}
+
+// HostColorPresets are the list colors offered for hosts and groups.
+var HostColorPresets = []string{"#f38ba8", "#fab387", "#f9e2af", "#a6e3a1", "#94e2d5", "#89b4fa", "#cba6f7", "#9399b2"}
+
+// ValidHostColor reports whether color can be used as a host or group list
+// color: empty, or a hex code like "#89b4fa".
+func ValidHostColor(color string) bool {
+	if color == "" {
+		return true
+	}
+	if len(color) != 7 || color[0] != '#' {
+		return false
+	}
+	for _, c := range color[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
 // UnicodeIcons is the default icon preset using standard Unicode characters.
 var UnicodeIcons = IconSet{
 	Name:           "Unicode",
@@ -36,12 +83,16 @@ var UnicodeIcons = IconSet{
 	Connected:      "\u25CF",
 	Idle:           "\u25CB",
 	Offline:        "\u00B7",
+	Proxy:          "\u21BA",
+	Mounted:        "\u25A3",
 	ActiveMarker:   "\u2022",
 	InactiveMarker: "\u00B7",
 	Expanded:       "\u25BF",
 	Collapsed:      "\u25B9",
 	Selected:       "\u25B8",
 	Focused:        "\u2192",
+	Marked:         "\u25A0",
+	Pinned:         "\u2605",
 	LeftArrow:      "\u25C4",
 	RightArrow:     "\u25BA",
 	Bar:            "\u258F",
@@ -70,12 +121,16 @@ var NerdFontIcons = IconSet{
 	Connected:      "\uf058",
 	Idle:           "\uf192",
 	Offline:        "\uf10c",
+	Proxy:          "\uf021",
+	Mounted:        "\uf0c1",
 	ActiveMarker:   "\uf111",
 	InactiveMarker: "\uf10c",
 	Expanded:       "\uf078",
 	Collapsed:      "\uf054",
 	Selected:       "\uf0da",
 	Focused:        "\uf061",
+	Marked:         "\uf0c8",
+	Pinned:         "\uf08d",
 	LeftArrow:      "\uf053",
 	RightArrow:     "\uf054",
 	Bar:            "\u258F",
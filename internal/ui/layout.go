@@ -9,6 +9,14 @@ import (
 
 const SidebarW = 4
 
+// LayoutBreakpointSingle is the terminal width below which pages drop their
+// side-by-side detail panel and show a single column.
+const LayoutBreakpointSingle = 80
+
+// CompactListW is the list column width below which host rows drop their
+// decorative icons.
+const CompactListW = 30
+
 // Renderer holds all context needed to render views.
 type Renderer struct {
 	Theme Theme
@@ -49,6 +57,11 @@ func (r *Renderer) ShowSidebar() bool {
 	return r.W >= 60
 }
 
+// SinglePanel returns true when the terminal is too narrow for a detail panel.
+func (r *Renderer) SinglePanel() bool {
+	return r.W < LayoutBreakpointSingle
+}
+
 // PageContentWidth returns the content width for page views (minus sidebar + gap).
 func (r *Renderer) PageContentWidth() int {
 	cw := r.ContentWidth()
@@ -116,6 +129,16 @@ func (r *Renderer) RenderHeader(subtitle string, hostCount, connectedCount int)
 	return title + "    " + meta
 }
 
+// RenderBreadcrumbs renders the group path of the selection as
+// "hosts → parent → group", truncated to maxW columns.
+func (r *Renderer) RenderBreadcrumbs(crumbs []string, maxW int) string {
+	if len(crumbs) == 0 {
+		return ""
+	}
+	text := "hosts \u2192 " + strings.Join(crumbs, " \u2192 ")
+	return lipgloss.NewStyle().Foreground(r.Theme.Subtext).Render(r.TruncStr(text, maxW))
+}
+
 // RenderFooter returns a dimmed footer hint line.
 func (r *Renderer) RenderFooter(text string) string {
 	return lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render(text)
@@ -165,15 +188,16 @@ func (r *Renderer) RenderSidebar(bodyH int, activePage int) string {
 	return lipgloss.NewStyle().Width(SidebarW).Render(strings.Join(lines, "\n"))
 }
 
-// TruncStr truncates a string to width, adding an ellipsis if needed.
+// TruncStr truncates a string to width runes, adding an ellipsis if needed.
 func (r *Renderer) TruncStr(s string, w int) string {
-	if w <= 0 || len(s) <= w {
+	runes := []rune(s)
+	if w <= 0 || len(runes) <= w {
 		return s
 	}
 	if w <= 1 {
 		return r.Icons.Truncation
 	}
-	return s[:w-1] + r.Icons.Truncation
+	return string(runes[:w-1]) + r.Icons.Truncation
 }
 
 // RemoveLastRune removes the last rune from a string.
@@ -3,8 +3,10 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // ── Quit overlay ──────────────────────────────────────────────────────
@@ -82,14 +84,32 @@ func (r *Renderer) RenderHelpOverlay() string {
 		{"\u2191 \u2193  j k", "navigate"},
 		{"enter", "connect or toggle"},
 		{"/", "search"},
-		{"S", "sftp"},
-		{"M", "mount / unmount"},
+		{"ctrl+r", "recent connections"},
+		{"ctrl+p", "command palette"},
+		{"S", "sftp browser"},
+		{"p", "pin / unpin"},
+		{"o / O", "sort column / direction"},
+		{"C", "copy ssh command"},
+		{"K", "view public key"},
+		{"ctrl+z", "undo host change"},
+		{"ctrl+y", "redo host change"},
+		{"M", "mount / manage mounts"},
+		{"T", "tunnels"},
+		{"P", "socks proxy"},
+		{"L", "last recording"},
+		{"F", "accept changed host key"},
+		{"W", "wake on lan"},
+		{"ctrl+l", "lock session"},
 		{"Y", "sync now"},
+		{"Z", "undo last sync"},
 		{",", "settings"},
-		{"ctrl+g", "new group"},
+		{"X", "dismiss update notice"},
+		{"ctrl+g", "new group (subgroup on a group)"},
+		{"I", "import hosts"},
 		{"a", "add host"},
 		{"e", "edit"},
 		{"d", "delete"},
+		{"tab", "details (narrow terminals)"},
 		{"shift+tab", "switch page"},
 		{"?", "help"},
 		{"q", "quit"},
@@ -117,17 +137,24 @@ type SearchResultItem struct {
 	Label     string
 	Hostname  string
 	GroupName string
-	Status    int // 0=offline, 1=idle, 2=connected
+	Hint      string // shown instead of GroupName when set (e.g. "5 minutes ago")
+	Status    int    // 0=offline, 1=idle, 2=connected
+	Mounted   bool   // host has at least one active mount
 }
 
 // SearchViewParams holds data for the search overlay.
 type SearchViewParams struct {
-	Query        string
-	Cursor       int
-	Results      []SearchResultItem
-	ArmedSFTP    bool
-	ArmedMount   bool
-	ArmedUnmount bool
+	Title      string // fixed heading that replaces the search input when set
+	Palette    bool   // command palette: heading above the input, no status dots
+	PadTo      int    // pad results with empty rows up to this many
+	Query      string
+	Cursor     int
+	Results    []SearchResultItem
+	ArmedSFTP  bool
+	ArmedMount bool
+
+	MountPath        string // remote path for the armed mount; "" uses the default
+	EditingMountPath bool   // the remote path input has focus
 }
 
 // RenderSearchOverlay renders the search/spotlight overlay.
@@ -142,7 +169,11 @@ func (r *Renderer) RenderSearchOverlay(p SearchViewParams) string {
 
 	bg := r.Theme.Mantle
 	inputStyle := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg)
-	placeholder := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render("search hosts...")
+	placeholderText := "search hosts..."
+	if p.Palette {
+		placeholderText = "type a command..."
+	}
+	placeholder := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render(placeholderText)
 	inputText := p.Query
 	cursor := ""
 	if r.Tick%2 == 0 {
@@ -152,7 +183,9 @@ func (r *Renderer) RenderSearchOverlay(p SearchViewParams) string {
 	}
 
 	var inputLine string
-	if inputText == "" {
+	if p.Title != "" {
+		inputLine = "  " + lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).Render(p.Title)
+	} else if inputText == "" {
 		inputLine = "  " + placeholder + cursor
 	} else {
 		inputLine = "  " + inputStyle.Render(inputText) + cursor
@@ -162,6 +195,9 @@ func (r *Renderer) RenderSearchOverlay(p SearchViewParams) string {
 
 	results := p.Results
 	maxResults := 8
+	if p.PadTo > maxResults {
+		maxResults = p.PadTo
+	}
 	if len(results) > maxResults {
 		results = results[:maxResults]
 	}
@@ -180,29 +216,66 @@ func (r *Renderer) RenderSearchOverlay(p SearchViewParams) string {
 		}
 
 		nameStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg)
-		groupHint := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render(" " + h.GroupName)
+		hint := h.GroupName
+		if h.Hint != "" {
+			hint = h.Hint
+		}
+		groupHint := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render(" " + hint)
 		prefix := "    "
 		if sel {
 			nameStyle = lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true)
 			prefix = lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Render("  " + r.Icons.Selected + " ")
-			groupHint = lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Render(" " + h.GroupName)
+			groupHint = lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Render(" " + hint)
 		}
 		lbl := h.Label
 		if lbl == "" {
 			lbl = h.Hostname
 		}
+		if p.Palette {
+			resultLines = append(resultLines, prefix+nameStyle.Render(lbl)+groupHint)
+			continue
+		}
+		if h.Mounted {
+			lbl += " " + r.Icons.Mounted
+		}
+		// Spell out what enter will do while sftp or mount is armed.
+		action := ""
+		if sel && p.ArmedMount {
+			action = "mount"
+		} else if sel && p.ArmedSFTP {
+			action = "sftp"
+		}
+		if action != "" {
+			groupHint = lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Render(" " + r.Icons.Focused + " " + action)
+		}
 		resultLines = append(resultLines, prefix+dot+" "+nameStyle.Render(lbl)+groupHint)
 	}
 
 	if len(results) == 0 && p.Query != "" {
 		resultLines = append(resultLines, "  "+lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render("no matches"))
 	}
+	for len(resultLines) < p.PadTo {
+		resultLines = append(resultLines, "")
+	}
+
+	if p.ArmedMount && (p.EditingMountPath || p.MountPath != "") {
+		pathLine := "  " + lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Render("Remote path: ") +
+			inputStyle.Render(p.MountPath)
+		if p.EditingMountPath {
+			pathLine += cursor
+		}
+		resultLines = append(resultLines, "", pathLine)
+	}
 
 	var footerText string
-	if p.ArmedMount {
-		footerText = "esc close  \u00B7  enter mount  \u00B7  M disarm"
-	} else if p.ArmedUnmount {
-		footerText = "esc close  \u00B7  enter unmount  \u00B7  M disarm"
+	if p.Palette {
+		footerText = "esc close  \u00B7  \u2191/\u2193 select  \u00B7  enter run"
+	} else if p.Title != "" {
+		footerText = "esc close  \u00B7  enter connect"
+	} else if p.ArmedMount && p.EditingMountPath {
+		footerText = "esc cancel  \u00B7  enter mount"
+	} else if p.ArmedMount {
+		footerText = "esc close  \u00B7  enter mount  \u00B7  [P] Custom path  \u00B7  M disarm"
 	} else if p.ArmedSFTP {
 		footerText = "esc close  \u00B7  enter sftp  \u00B7  S disarm"
 	} else {
@@ -211,6 +284,9 @@ func (r *Renderer) RenderSearchOverlay(p SearchViewParams) string {
 	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render("  " + footerText)
 
 	var contentParts []string
+	if p.Palette {
+		contentParts = append(contentParts, "  "+lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).Render("\u2318 Command Palette"), "")
+	}
 	contentParts = append(contentParts, inputLine)
 	contentParts = append(contentParts, sep)
 	if len(resultLines) > 0 {
@@ -247,22 +323,86 @@ type AddHostViewParams struct {
 	AuthIdx     int
 	KeyTypes    []string
 	KeyTypeIdx  int
-	Err         error
+	RecordOpts  []string
+	RecordIdx   int
+	SyncExclude bool
+	Advanced    bool // "advanced ssh options" section expanded
+	ForwardX11  bool
+	// IdentitiesOpts/IdentitiesIdx: per-host IdentitiesOnly override,
+	// "default" | "on" | "off".
+	IdentitiesOpts []string
+	IdentitiesIdx  int
+	JumpOptions    []string // "none" followed by the other hosts
+	JumpIdx        []int    // selected option per hop; hops after a "none" are hidden
+	AutoLogin      bool     // password auto-login setting; stored passwords are only replayed when on
+	CanRotate      bool     // editing a host with a stored key
+	Err            error
 }
 
 // Form field indices for add host.
 const (
-	FFLabel    = 0
-	FFTags     = 1
-	FFHostname = 2
-	FFPort     = 3
-	FFUsername = 4
-	FFAuthDet  = 5
-	FFGroup    = 100 // selector, not a text field
-	FFAuthMeth = 101 // selector, not a text field
-	FFSave     = 102 // button
+	FFLabel       = 0
+	FFTags        = 1
+	FFHostname    = 2
+	FFPort        = 3
+	FFUsername    = 4
+	FFAuthDet     = 5
+	FFSSHOpts     = 6   // multi-line, shown only in the advanced section
+	FFMAC         = 7   // advanced section
+	FFBroadcast   = 8   // advanced section
+	FFKeepAlive   = 9   // advanced section
+	FFTerm        = 10  // advanced section
+	FFIcon        = 11  // list icon; ←/→ cycle HostIconPresets
+	FFColor       = 12  // list color; ←/→ cycle HostColorPresets
+	FFGroup       = 100 // selector, not a text field
+	FFAuthMeth    = 101 // selector, not a text field
+	FFSave        = 102 // button
+	FFRecord      = 103 // selector, not a text field
+	FFSyncExclude = 104 // checkbox, not a text field
+	FFAdvanced    = 105 // expand/collapse toggle, not a text field
+	FFX11         = 106 // checkbox in the advanced section
+	FFJump1       = 107 // jump host selectors (advanced section); FFJump1+i is hop i
+	FFJump2       = 108
+	FFJump3       = 109
+	FFIdentities  = 110 // identities-only selector in the advanced section
 )
 
+// renderIconPresets renders the host icon presets with the current one
+// highlighted, plus a hint when the icon field is focused.
+func (r *Renderer) renderIconPresets(current string, focused bool) string {
+	parts := make([]string, 0, len(HostIconPresets))
+	for _, icon := range HostIconPresets {
+		style := lipgloss.NewStyle().Foreground(r.Theme.Overlay)
+		if icon == current {
+			style = lipgloss.NewStyle().Foreground(r.Theme.Accent).Underline(true)
+		}
+		parts = append(parts, style.Render(icon))
+	}
+	line := "  " + strings.Join(parts, " ")
+	if focused {
+		line += "  " + lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render(r.Icons.LeftArrow+"/"+r.Icons.RightArrow+" pick, empty for none")
+	}
+	return line
+}
+
+// renderColorSwatches renders HostColorPresets as colored blocks on bg,
+// with the current one bracketed, plus a hint when hint is set.
+func (r *Renderer) renderColorSwatches(current string, hint bool, bg lipgloss.TerminalColor) string {
+	parts := make([]string, 0, len(HostColorPresets))
+	for _, c := range HostColorPresets {
+		swatch := " \u25A0 "
+		if strings.EqualFold(c, current) {
+			swatch = "[\u25A0]"
+		}
+		parts = append(parts, lipgloss.NewStyle().Foreground(lipgloss.Color(c)).Background(bg).Render(swatch))
+	}
+	line := strings.Join(parts, "")
+	if hint {
+		line += "  " + lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render(r.Icons.LeftArrow+"/"+r.Icons.RightArrow+" pick, empty for none")
+	}
+	return line
+}
+
 // RenderAddHostOverlay renders the add/edit host form as a full-page overlay.
 func (r *Renderer) RenderAddHostOverlay(p AddHostViewParams) string {
 	cw := r.PageContentWidth()
@@ -303,6 +443,24 @@ func (r *Renderer) RenderAddHostOverlay(p AddHostViewParams) string {
 	lines = append(lines, spacer()+r.RenderFormLabel("label", p.Focus == FFLabel))
 	lines = append(lines, r.RenderInput(p.Fields[FFLabel], p.Focus == FFLabel, formW-4, blink, p.Editing))
 
+	// icon
+	if len(p.Fields) > FFIcon {
+		lines = append(lines, spacer()+r.RenderFormLabel("icon", p.Focus == FFIcon))
+		lines = append(lines, r.RenderInput(p.Fields[FFIcon], p.Focus == FFIcon, 8, blink, p.Editing))
+		if !compact || p.Focus == FFIcon {
+			lines = append(lines, r.renderIconPresets(p.Fields[FFIcon].Value, p.Focus == FFIcon))
+		}
+	}
+
+	// color
+	if len(p.Fields) > FFColor {
+		lines = append(lines, spacer()+r.RenderFormLabel("color", p.Focus == FFColor))
+		lines = append(lines, r.RenderInput(p.Fields[FFColor], p.Focus == FFColor, 10, blink, p.Editing))
+		if !compact || p.Focus == FFColor {
+			lines = append(lines, " "+r.renderColorSwatches(p.Fields[FFColor].Value, p.Focus == FFColor, lipgloss.NoColor{}))
+		}
+	}
+
 	// group selector
 	lines = append(lines, spacer()+r.RenderFormLabel("group", p.Focus == FFGroup))
 	gName := ""
@@ -388,6 +546,10 @@ func (r *Renderer) RenderAddHostOverlay(p AddHostViewParams) string {
 	case 0: // password
 		lines = append(lines, spacer()+r.RenderFormLabel("password", p.Focus == FFAuthDet))
 		lines = append(lines, r.RenderInput(p.Fields[FFAuthDet], p.Focus == FFAuthDet, formW-4, blink, p.Editing))
+		lines = append(lines, lipgloss.NewStyle().Foreground(r.Theme.Yellow).Render("  "+r.Icons.Warning+" stored passwords are less secure than keys"))
+		if !compact && !p.AutoLogin {
+			lines = append(lines, lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("  only used with SSH: password auto-login on"))
+		}
 	case 1: // paste key
 		lines = append(lines, spacer()+r.RenderFormLabel("private key", p.Focus == FFAuthDet))
 		lines = append(lines, r.RenderInput(p.Fields[FFAuthDet], p.Focus == FFAuthDet, formW-4, blink, p.Editing))
@@ -404,6 +566,141 @@ func (r *Renderer) RenderAddHostOverlay(p AddHostViewParams) string {
 			"  "+lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("(space to cycle)"))
 	}
 
+	// session recording override selector
+	lines = append(lines, spacer()+r.RenderFormLabel("record sessions", p.Focus == FFRecord))
+	rName := ""
+	if len(p.RecordOpts) > 0 {
+		rName = p.RecordOpts[p.RecordIdx]
+	}
+	if p.Focus == FFRecord {
+		lines = append(lines, "  "+
+			lipgloss.NewStyle().Foreground(r.Theme.Accent).Render(r.Icons.LeftArrow)+
+			" "+lipgloss.NewStyle().Foreground(r.Theme.Text).Render(rName)+
+			" "+lipgloss.NewStyle().Foreground(r.Theme.Accent).Render(r.Icons.RightArrow))
+	} else {
+		lines = append(lines, "  "+
+			lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render(r.Icons.LeftArrow)+
+			" "+lipgloss.NewStyle().Foreground(r.Theme.Subtext).Render(rName)+
+			" "+lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render(r.Icons.RightArrow))
+	}
+
+	// exclude-from-sync checkbox
+	check := "[ ]"
+	if p.SyncExclude {
+		check = "[X]"
+	}
+	checkStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext)
+	if p.Focus == FFSyncExclude {
+		checkStyle = lipgloss.NewStyle().Foreground(r.Theme.Accent)
+	}
+	lines = append(lines, spacer()+"  "+checkStyle.Render(check+" exclude from sync"))
+
+	// advanced ssh options (collapsed by default)
+	var sshOpts FormField
+	if len(p.Fields) > FFSSHOpts {
+		sshOpts = p.Fields[FFSSHOpts]
+	}
+	arrow := "\u25B8"
+	if p.Advanced {
+		arrow = "\u25BE"
+	}
+	advText := arrow + " advanced ssh options"
+	var macField, bcastField FormField
+	if len(p.Fields) > FFBroadcast {
+		macField, bcastField = p.Fields[FFMAC], p.Fields[FFBroadcast]
+	}
+	var keepAliveField FormField
+	if len(p.Fields) > FFKeepAlive {
+		keepAliveField = p.Fields[FFKeepAlive]
+	}
+	keepAliveSet := strings.TrimSpace(keepAliveField.Value) != "" && strings.TrimSpace(keepAliveField.Value) != "0"
+	var termField FormField
+	if len(p.Fields) > FFTerm {
+		termField = p.Fields[FFTerm]
+	}
+	if (strings.TrimSpace(sshOpts.Value) != "" || strings.TrimSpace(macField.Value) != "" || keepAliveSet || strings.TrimSpace(termField.Value) != "" || p.ForwardX11 || p.IdentitiesIdx > 0 || (len(p.JumpIdx) > 0 && p.JumpIdx[0] > 0)) && !p.Advanced {
+		advText += " (set)"
+	}
+	advStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext)
+	if p.Focus == FFAdvanced {
+		advStyle = lipgloss.NewStyle().Foreground(r.Theme.Accent)
+	}
+	lines = append(lines, spacer()+"  "+advStyle.Render(advText))
+	if p.Advanced {
+		lines = append(lines, r.RenderFormLabel("ssh options", p.Focus == FFSSHOpts))
+		lines = append(lines, r.RenderTextArea(sshOpts, p.Focus == FFSSHOpts, formW-4, blink, p.Editing))
+		if !compact {
+			lines = append(lines, lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("  Key=Value, one per line (e.g. ServerAliveInterval=30)"))
+		}
+		for i, idx := range p.JumpIdx {
+			if i > 0 && p.JumpIdx[i-1] == 0 {
+				break
+			}
+			name := ""
+			if idx >= 0 && idx < len(p.JumpOptions) {
+				name = p.JumpOptions[idx]
+			}
+			focused := p.Focus == FFJump1+i
+			lines = append(lines, r.RenderFormLabel(fmt.Sprintf("jump host %d", i+1), focused))
+			arrowColor, nameColor := r.Theme.Overlay, r.Theme.Subtext
+			if focused {
+				arrowColor, nameColor = r.Theme.Accent, r.Theme.Text
+			}
+			lines = append(lines, "  "+
+				lipgloss.NewStyle().Foreground(arrowColor).Render(r.Icons.LeftArrow)+
+				" "+lipgloss.NewStyle().Foreground(nameColor).Render(name)+
+				" "+lipgloss.NewStyle().Foreground(arrowColor).Render(r.Icons.RightArrow))
+		}
+		if len(p.Fields) > FFKeepAlive {
+			lines = append(lines, r.RenderFormLabel("keepalive (s)", p.Focus == FFKeepAlive))
+			lines = append(lines, r.RenderInput(keepAliveField, p.Focus == FFKeepAlive, formW-4, blink, p.Editing))
+			if !compact {
+				lines = append(lines, lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("  0 inherits the global keepalive setting"))
+			}
+		}
+		if len(p.Fields) > FFTerm {
+			lines = append(lines, r.RenderFormLabel("TERM", p.Focus == FFTerm))
+			lines = append(lines, r.RenderInput(termField, p.Focus == FFTerm, formW-4, blink, p.Editing))
+			if !compact {
+				lines = append(lines, lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("  e.g. vt100; empty uses the global TERM mode"))
+			}
+		}
+		x11Check := "[ ]"
+		if p.ForwardX11 {
+			x11Check = "[X]"
+		}
+		x11Style := lipgloss.NewStyle().Foreground(r.Theme.Subtext)
+		if p.Focus == FFX11 {
+			x11Style = lipgloss.NewStyle().Foreground(r.Theme.Accent)
+		}
+		lines = append(lines, spacer()+"  "+x11Style.Render(x11Check+" X11: forward"))
+		if !compact {
+			lines = append(lines, lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("  needs $DISPLAY set on this machine"))
+		}
+		if len(p.IdentitiesOpts) > 0 {
+			lines = append(lines, spacer()+r.RenderFormLabel("identities only", p.Focus == FFIdentities))
+			arrowStyle := lipgloss.NewStyle().Foreground(r.Theme.Overlay)
+			nameStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext)
+			if p.Focus == FFIdentities {
+				arrowStyle = lipgloss.NewStyle().Foreground(r.Theme.Accent)
+				nameStyle = lipgloss.NewStyle().Foreground(r.Theme.Text)
+			}
+			lines = append(lines, "  "+arrowStyle.Render(r.Icons.LeftArrow)+" "+nameStyle.Render(p.IdentitiesOpts[p.IdentitiesIdx])+" "+arrowStyle.Render(r.Icons.RightArrow))
+			if !compact {
+				lines = append(lines, lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("  offer only the stored key, not agent keys"))
+			}
+		}
+		if len(p.Fields) > FFBroadcast {
+			lines = append(lines, r.RenderFormLabel("wake-on-lan mac", p.Focus == FFMAC))
+			lines = append(lines, r.RenderInput(macField, p.Focus == FFMAC, formW-4, blink, p.Editing))
+			lines = append(lines, r.RenderFormLabel("broadcast address", p.Focus == FFBroadcast))
+			lines = append(lines, r.RenderInput(bcastField, p.Focus == FFBroadcast, formW-4, blink, p.Editing))
+			if !compact {
+				lines = append(lines, lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("  defaults to 255.255.255.255:9"))
+			}
+		}
+	}
+
 	// error line
 	if p.Err != nil {
 		lines = append(lines, "")
@@ -431,6 +728,9 @@ func (r *Renderer) RenderAddHostOverlay(p AddHostViewParams) string {
 		footerText = "type to edit  \u00B7  \u2191\u2193 leave  \u00B7  \u2190\u2192 cursor  \u00B7  esc done  \u00B7  tab next"
 	} else {
 		footerText = "\u2191\u2193\u2190\u2192 navigate  \u00B7  enter edit  \u00B7  tab next  \u00B7  esc cancel"
+		if p.CanRotate {
+			footerText += "  \u00B7  R rotate key"
+		}
 	}
 	footer := r.RenderFooter(footerText)
 	lines = append(lines, footer)
@@ -446,6 +746,7 @@ func (r *Renderer) RenderAddHostOverlay(p AddHostViewParams) string {
 // LoginViewParams holds data for the login overlay.
 type LoginViewParams struct {
 	Password FormField
+	Profile  string // active profile name, "" for the default profile
 	Err      string
 }
 
@@ -456,6 +757,9 @@ func (r *Renderer) RenderLoginOverlay(p LoginViewParams) string {
 
 	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
 		Render(r.Icons.Lock + " sshthing")
+	if p.Profile != "" {
+		title += lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Render(" \u00B7 " + p.Profile)
+	}
 
 	label := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render("  password")
 	input := r.RenderModalField(p.Password.Value, p.Password.Cursor, true, true, blink, bg)
@@ -489,6 +793,104 @@ func (r *Renderer) RenderLoginOverlay(p LoginViewParams) string {
 		lipgloss.WithWhitespaceBackground(r.Theme.Base))
 }
 
+// ── Welcome overlay ───────────────────────────────────────────────────
+
+// welcomeLogo is the wordmark shown on the first-run welcome screen.
+var welcomeLogo = []string{
+	"┌─┐┌─┐┬ ┬┌┬┐┬ ┬┬┌┐┌┌─┐",
+	"└─┐└─┐├─┤ │ ├─┤│││││ ┬",
+	"└─┘└─┘┴ ┴ ┴ ┴ ┴┴┘└┘└─┘",
+}
+
+// WelcomeCard is one choice on the welcome screen.
+type WelcomeCard struct {
+	Title string
+	Desc  string
+}
+
+// WelcomeViewParams holds data for the first-run welcome overlay.
+type WelcomeViewParams struct {
+	Cards  []WelcomeCard
+	Cursor int
+}
+
+// RenderWelcomeOverlay renders the first-run welcome screen: the logo, a
+// short description and the setup choices, as a 2x2 grid of cards on wide
+// terminals and a stacked list below LayoutBreakpointSingle columns.
+func (r *Renderer) RenderWelcomeOverlay(p WelcomeViewParams) string {
+	bg := r.Theme.Mantle
+	narrow := r.SinglePanel()
+
+	accent := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true)
+	text := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg)
+	dim := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg)
+
+	var contentParts []string
+	if narrow {
+		contentParts = append(contentParts, accent.Render("sshthing"))
+	} else {
+		for _, l := range welcomeLogo {
+			contentParts = append(contentParts, accent.Render(l))
+		}
+	}
+	contentParts = append(contentParts, "",
+		text.Render("keep your SSH hosts, keys and passwords in an"),
+		text.Render("encrypted local vault and connect with one key"),
+		"")
+
+	if narrow {
+		for i, c := range p.Cards {
+			if i == p.Cursor {
+				contentParts = append(contentParts, accent.Render(r.Icons.Selected+" "+c.Title), dim.Render("  "+c.Desc))
+			} else {
+				contentParts = append(contentParts, text.Render("  "+c.Title))
+			}
+		}
+	} else {
+		const cardW = 30
+		card := func(i int) string {
+			c := p.Cards[i]
+			border, title := r.Theme.Surface0, text
+			if i == p.Cursor {
+				border, title = r.Theme.Accent, accent
+			}
+			return lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(border).
+				BorderBackground(bg).
+				Background(bg).
+				Width(cardW).
+				Height(2).
+				Padding(0, 1).
+				Render(title.Render(fmt.Sprintf("%d  %s", i+1, c.Title)) + "\n" + dim.Render(c.Desc))
+		}
+		gap := lipgloss.NewStyle().Width(2).Background(bg).Render("")
+		for i := 0; i < len(p.Cards); i += 2 {
+			row := card(i)
+			if i+1 < len(p.Cards) {
+				row = lipgloss.JoinHorizontal(lipgloss.Top, row, gap, card(i+1))
+			}
+			contentParts = append(contentParts, row)
+		}
+	}
+
+	contentParts = append(contentParts, "", dim.Render("\u2191\u2193 select  \u00B7  enter choose  \u00B7  esc quit"))
+
+	boxW := 72
+	if narrow {
+		boxW = min(50, r.W-4)
+	}
+	box := lipgloss.NewStyle().
+		Width(boxW).
+		Background(bg).
+		Padding(1, 2).
+		Align(lipgloss.Center).
+		Render(strings.Join(contentParts, "\n"))
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
 // ── Setup overlay ─────────────────────────────────────────────────────
 
 // SetupViewParams holds data for the first-time setup overlay.
@@ -529,7 +931,7 @@ func (r *Renderer) RenderSetupOverlay(p SetupViewParams) string {
 	}
 
 	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).
-		Render("tab next  \u00B7  enter submit  \u00B7  esc quit")
+		Render("tab next  \u00B7  enter submit  \u00B7  esc back")
 
 	var contentParts []string
 	contentParts = append(contentParts, title, "", pwLabel, pwInput, "", cfLabel, cfInput)
@@ -558,6 +960,7 @@ type DeleteHostViewParams struct {
 	Label        string
 	Hostname     string
 	Username     string
+	Count        int // >0 for a bulk delete of that many selected hosts
 	DeleteCursor int // 0=delete, 1=cancel
 }
 
@@ -571,6 +974,13 @@ func (r *Renderer) RenderDeleteHostOverlay(p DeleteHostViewParams) string {
 	hostLabel := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg).Bold(true).Render(p.Label)
 	connStr := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
 		Render(p.Username + "@" + p.Hostname)
+	if p.Count > 0 {
+		title = lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).Bold(true).
+			Render(r.Icons.Warning + " delete hosts")
+		hostLabel = lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg).Bold(true).
+			Render(fmt.Sprintf("%d selected hosts", p.Count))
+		connStr = lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Render("all will be removed")
+	}
 	warn := lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).
 		Render("cannot be undone!")
 
@@ -599,17 +1009,341 @@ func (r *Renderer) RenderDeleteHostOverlay(p DeleteHostViewParams) string {
 		lipgloss.WithWhitespaceBackground(r.Theme.Base))
 }
 
+// MoveHostsViewParams holds data for the bulk move-to-group overlay.
+type MoveHostsViewParams struct {
+	Count  int
+	Groups []string // first entry is "Ungrouped"
+	Cursor int
+}
+
+// RenderMoveHostsOverlay renders the group picker for moving selected hosts.
+func (r *Renderer) RenderMoveHostsOverlay(p MoveHostsViewParams) string {
+	bg := r.Theme.Mantle
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render(r.Icons.Folder + " move to group")
+	sub := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+		Render(fmt.Sprintf("%d selected hosts", p.Count))
+	contentParts := []string{title, sub, ""}
+
+	for i, g := range p.Groups {
+		if i == p.Cursor {
+			contentParts = append(contentParts, lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+				Render(r.Icons.Selected+" "+r.TruncStr(g, 30)))
+		} else {
+			contentParts = append(contentParts, lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg).
+				Render("  "+r.TruncStr(g, 30)))
+		}
+	}
+
+	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).
+		Render("\u2191\u2193 select \u00B7 enter move \u00B7 esc cancel")
+	contentParts = append(contentParts, "", footer)
+
+	box := lipgloss.NewStyle().
+		Width(42).
+		Background(bg).
+		Padding(1, 2).
+		Render(strings.Join(contentParts, "\n"))
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// CopyFallbackViewParams holds data for the manual-copy modal.
+type CopyFallbackViewParams struct {
+	Text string
+}
+
+// RenderCopyFallbackOverlay shows text for manual selection when no
+// clipboard tool is available.
+func (r *Renderer) RenderCopyFallbackOverlay(p CopyFallbackViewParams) string {
+	bg := r.Theme.Mantle
+
+	boxW := 64
+	if boxW > r.W-6 {
+		boxW = r.W - 6
+	}
+	if boxW < 30 {
+		boxW = 30
+	}
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg).Bold(true).Render("copy connection string")
+	warn := lipgloss.NewStyle().Foreground(r.Theme.Yellow).Background(bg).
+		Render(r.Icons.Warning + " no clipboard available \u2014 select and copy it manually")
+	text := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Width(boxW - 4).Render(p.Text)
+	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render("esc close")
+
+	box := lipgloss.NewStyle().
+		Width(boxW).
+		Background(bg).
+		Padding(1, 2).
+		Render(strings.Join([]string{title, "", warn, "", text, "", footer}, "\n"))
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// KeyViewParams holds data for the public key viewer.
+type KeyViewParams struct {
+	HostLabel string
+	PublicKey string
+	Cursor    int // 0=copy, 1=download
+	PathField FormField
+	Err       string // status line; "\u2713"-prefixed messages are successes
+}
+
+// RenderKeyViewModal shows a host's public key with copy and download actions.
+func (r *Renderer) RenderKeyViewModal(p KeyViewParams) string {
+	bg := r.Theme.Mantle
+	blink := r.Tick%2 == 0
+
+	boxW := 72
+	if boxW > r.W-6 {
+		boxW = r.W - 6
+	}
+	if boxW < 30 {
+		boxW = 30
+	}
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).Render("public key")
+	hostLine := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Render(p.HostLabel)
+	key := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg).Width(boxW - 4).Render(p.PublicKey)
+
+	button := func(label string, focused bool) string {
+		if focused {
+			return lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).Render("[ " + label + " ]")
+		}
+		return lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render("[ " + label + " ]")
+	}
+	contentParts := []string{title, hostLine, "", key, "",
+		button("Copy", p.Cursor == 0),
+		"",
+		button("Download", p.Cursor == 1),
+		r.RenderModalField(p.PathField.Value, p.PathField.Cursor, false, p.Cursor == 1, blink, bg),
+	}
+
+	if p.Err != "" {
+		color := r.Theme.Red
+		if strings.HasPrefix(p.Err, "\u2713") {
+			color = r.Theme.Green
+		}
+		contentParts = append(contentParts, "", lipgloss.NewStyle().Foreground(color).Background(bg).Render(p.Err))
+	}
+
+	footerText := "c copy  \u00B7  d download  \u00B7  esc close"
+	if p.Cursor == 1 {
+		footerText = "enter save  \u00B7  tab copy  \u00B7  esc close"
+	}
+	contentParts = append(contentParts, "", lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render(footerText))
+
+	box := lipgloss.NewStyle().
+		Width(boxW).
+		Background(bg).
+		Padding(1, 2).
+		Render(strings.Join(contentParts, "\n"))
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// SyncRollbackViewParams holds data for the sync rollback confirmation.
+type SyncRollbackViewParams struct {
+	Message string // message of the commit that will be restored
+	When    string
+	Cursor  int // 0=rollback, 1=cancel
+}
+
+// RenderSyncRollbackOverlay renders the confirmation for undoing the last sync.
+func (r *Renderer) RenderSyncRollbackOverlay(p SyncRollbackViewParams) string {
+	bg := r.Theme.Mantle
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).Bold(true).
+		Render(r.Icons.Warning + " undo last sync")
+	sub := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+		Render("restore hosts from the previous commit:")
+	msgLine := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg).Bold(true).
+		Render(r.TruncStr(p.Message, 44))
+	whenLine := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Render(p.When)
+
+	okStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Padding(0, 2)
+	cancelStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Padding(0, 2)
+	if p.Cursor == 0 {
+		okStyle = okStyle.Foreground(r.Theme.Base).Background(r.Theme.Red).Bold(true)
+	} else {
+		cancelStyle = cancelStyle.Foreground(r.Theme.Base).Background(r.Theme.Accent).Bold(true)
+	}
+	buttons := okStyle.Render("rollback") + "  " + cancelStyle.Render("cancel")
+
+	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).
+		Render("\u2190\u2192 select \u00B7 enter confirm \u00B7 esc cancel")
+
+	content := strings.Join([]string{title, "", sub, msgLine, whenLine, "", buttons, "", footer}, "\n")
+
+	box := lipgloss.NewStyle().
+		Width(50).
+		Background(bg).
+		Padding(1, 2).
+		Align(lipgloss.Center).
+		Render(content)
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// ── Changelog modal ───────────────────────────────────────────────────
+
+// changelogWrapWidth is the text width release notes are wrapped to.
+const changelogWrapWidth = 60
+
+// ChangelogLine is one wrapped line of release notes.
+type ChangelogLine struct {
+	Text    string
+	Heading bool
+}
+
+// ChangelogLines turns Markdown release notes into wrapped display lines.
+// Headings lose their leading #s and list items are prefixed with "• ".
+func ChangelogLines(body string) []ChangelogLine {
+	var lines []ChangelogLine
+	for _, raw := range strings.Split(body, "\n") {
+		line := strings.TrimRight(raw, " \t")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			text := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			for _, w := range strings.Split(ansi.Wrap(text, changelogWrapWidth, ""), "\n") {
+				lines = append(lines, ChangelogLine{Text: w, Heading: true})
+			}
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			indent := strings.Repeat(" ", len(line)-len(strings.TrimLeft(line, " ")))
+			text := strings.TrimSpace(trimmed[2:])
+			wrapped := strings.Split(ansi.Wrap(text, changelogWrapWidth-len(indent)-2, ""), "\n")
+			for i, w := range wrapped {
+				prefix := indent + "\u2022 "
+				if i > 0 {
+					prefix = indent + "  "
+				}
+				lines = append(lines, ChangelogLine{Text: prefix + w})
+			}
+		default:
+			for _, w := range strings.Split(ansi.Wrap(trimmed, changelogWrapWidth, ""), "\n") {
+				lines = append(lines, ChangelogLine{Text: w})
+			}
+		}
+	}
+	return lines
+}
+
+// ChangelogViewParams holds data for the release notes modal shown before
+// applying an update.
+type ChangelogViewParams struct {
+	Tag     string
+	Lines   []ChangelogLine
+	Loading bool
+	Err     string
+	Scroll  int
+	Cursor  int // 0=apply, 1=skip, 2=cancel
+}
+
+// ChangelogVisibleLines is the number of release note lines the modal shows
+// at once for a terminal of height h.
+func ChangelogVisibleLines(h int) int {
+	n := h - 14
+	if n < 4 {
+		n = 4
+	}
+	return n
+}
+
+// RenderChangelogModal renders the scrollable release notes with apply, skip
+// and cancel buttons.
+func (r *Renderer) RenderChangelogModal(p ChangelogViewParams) string {
+	bg := r.Theme.Mantle
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render("release notes " + p.Tag)
+	contentParts := []string{title, ""}
+
+	visible := ChangelogVisibleLines(r.H)
+	textStyle := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg)
+	dimStyle := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg)
+	switch {
+	case p.Loading:
+		frames := []string{"|", "/", "-", "\\"}
+		contentParts = append(contentParts, dimStyle.Render(frames[r.Tick%len(frames)]+" Fetching release notes\u2026"))
+	case p.Err != "":
+		contentParts = append(contentParts, lipgloss.NewStyle().Foreground(r.Theme.Yellow).Background(bg).
+			Render(r.Icons.Warning+" "+r.TruncStr(p.Err, changelogWrapWidth-2)))
+	case len(p.Lines) == 0:
+		contentParts = append(contentParts, dimStyle.Render("no release notes"))
+	default:
+		end := p.Scroll + visible
+		if end > len(p.Lines) {
+			end = len(p.Lines)
+		}
+		for _, line := range p.Lines[p.Scroll:end] {
+			if line.Heading {
+				contentParts = append(contentParts, textStyle.Bold(true).Render(line.Text))
+			} else {
+				contentParts = append(contentParts, textStyle.Render(line.Text))
+			}
+		}
+		if len(p.Lines) > visible {
+			contentParts = append(contentParts, dimStyle.Render(fmt.Sprintf("%d-%d of %d", p.Scroll+1, end, len(p.Lines))))
+		}
+	}
+
+	var buttons []string
+	for i, label := range []string{"apply", "skip", "cancel"} {
+		st := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Padding(0, 2)
+		if i == p.Cursor {
+			st = st.Foreground(r.Theme.Base).Background(r.Theme.Accent).Bold(true)
+		}
+		buttons = append(buttons, st.Render(label))
+	}
+
+	footer := dimStyle.Render("\u2191\u2193 scroll \u00B7 \u2190\u2192 select \u00B7 enter confirm \u00B7 esc cancel")
+	contentParts = append(contentParts, "", strings.Join(buttons, "  "), "", footer)
+
+	box := lipgloss.NewStyle().
+		Width(changelogWrapWidth+4).
+		Background(bg).
+		Padding(1, 2).
+		Render(strings.Join(contentParts, "\n"))
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
 // ── Create group overlay ──────────────────────────────────────────────
 
 // GroupInputViewParams holds data for create/rename group overlays.
 type GroupInputViewParams struct {
 	Title       string // e.g. "+ new group" or "edit rename group"
+	Parent      string // group the new group is created in; empty for top level
 	InputValue  string
 	InputCursor int
-	Focus       int    // 0=input, 1=action button, 2=cancel
+	Color       string // group list color; "" for none
+	Focus       int    // 0=input, 1=color, 2=action button, 3=cancel
 	ActionLabel string // "create" or "rename"
 }
 
+// renderGroupColorRow renders the color label and swatches of the group
+// overlays. The swatches cycle with ←/→ while the row is focused.
+func (r *Renderer) renderGroupColorRow(p GroupInputViewParams, bg lipgloss.TerminalColor) string {
+	labelStyle := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg)
+	if p.Focus == 1 {
+		labelStyle = labelStyle.Foreground(r.Theme.Accent)
+	}
+	value := "none"
+	if p.Color != "" {
+		value = p.Color
+	}
+	label := labelStyle.Render("  color ") + lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Render(value)
+	return label + "\n" + r.renderColorSwatches(p.Color, false, bg)
+}
+
 // RenderCreateGroupOverlay renders the create group overlay.
 func (r *Renderer) RenderCreateGroupOverlay(p GroupInputViewParams) string {
 	bg := r.Theme.Mantle
@@ -617,15 +1351,19 @@ func (r *Renderer) RenderCreateGroupOverlay(p GroupInputViewParams) string {
 
 	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
 		Render(r.Icons.Add + " new group")
+	if p.Parent != "" {
+		title += "\n" + lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+			Render("inside "+r.TruncStr(p.Parent, 28))
+	}
 
 	label := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render("  name")
 	input := r.RenderModalField(p.InputValue, p.InputCursor, false, p.Focus == 0, blink, bg)
 
 	createStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Padding(0, 2)
 	cancelStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Padding(0, 2)
-	if p.Focus == 1 {
+	if p.Focus == 2 {
 		createStyle = createStyle.Foreground(r.Theme.Base).Background(r.Theme.Accent).Bold(true)
-	} else if p.Focus == 2 {
+	} else if p.Focus == 3 {
 		cancelStyle = cancelStyle.Foreground(r.Theme.Base).Background(r.Theme.Accent).Bold(true)
 	}
 	buttons := createStyle.Render("create") + "  " + cancelStyle.Render("cancel")
@@ -633,7 +1371,7 @@ func (r *Renderer) RenderCreateGroupOverlay(p GroupInputViewParams) string {
 	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).
 		Render("tab nav \u00B7 enter submit \u00B7 esc cancel")
 
-	content := strings.Join([]string{title, "", label, input, "", buttons, "", footer}, "\n")
+	content := strings.Join([]string{title, "", label, input, "", r.renderGroupColorRow(p, bg), "", buttons, "", footer}, "\n")
 
 	box := lipgloss.NewStyle().
 		Width(40).
@@ -661,9 +1399,9 @@ func (r *Renderer) RenderRenameGroupOverlay(p GroupInputViewParams) string {
 
 	renameStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Padding(0, 2)
 	cancelStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Padding(0, 2)
-	if p.Focus == 1 {
+	if p.Focus == 2 {
 		renameStyle = renameStyle.Foreground(r.Theme.Base).Background(r.Theme.Accent).Bold(true)
-	} else if p.Focus == 2 {
+	} else if p.Focus == 3 {
 		cancelStyle = cancelStyle.Foreground(r.Theme.Base).Background(r.Theme.Accent).Bold(true)
 	}
 	buttons := renameStyle.Render("rename") + "  " + cancelStyle.Render("cancel")
@@ -671,7 +1409,7 @@ func (r *Renderer) RenderRenameGroupOverlay(p GroupInputViewParams) string {
 	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).
 		Render("tab nav \u00B7 enter submit \u00B7 esc cancel")
 
-	content := strings.Join([]string{title, "", label, input, "", buttons, "", footer}, "\n")
+	content := strings.Join([]string{title, "", label, input, "", r.renderGroupColorRow(p, bg), "", buttons, "", footer}, "\n")
 
 	box := lipgloss.NewStyle().
 		Width(40).
@@ -690,6 +1428,7 @@ func (r *Renderer) RenderRenameGroupOverlay(p GroupInputViewParams) string {
 type DeleteGroupViewParams struct {
 	GroupName    string
 	HostCount    int
+	Subgroups    int    // nested groups deleted along with it
 	TargetGroup  string // where hosts will be moved
 	DeleteCursor int    // 0=delete, 1=cancel
 }
@@ -706,6 +1445,10 @@ func (r *Renderer) RenderDeleteGroupOverlay(p DeleteGroupViewParams) string {
 
 	hint := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
 		Render(fmt.Sprintf("hosts will move to %q", p.TargetGroup))
+	if p.Subgroups > 0 {
+		hint += "\n" + lipgloss.NewStyle().Foreground(r.Theme.Yellow).Background(bg).
+			Render(fmt.Sprintf("%d subgroup(s) will be deleted too", p.Subgroups))
+	}
 
 	delStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Padding(0, 2)
 	cancelStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Padding(0, 2)
@@ -731,3 +1474,1002 @@ func (r *Renderer) RenderDeleteGroupOverlay(p DeleteGroupViewParams) string {
 	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
 		lipgloss.WithWhitespaceBackground(r.Theme.Base))
 }
+
+// ── Port forward overlay ──────────────────────────────────────────────
+
+// ForwardViewParams holds data for the local port forward overlay.
+type ForwardViewParams struct {
+	HostLabel  string
+	LocalPort  FormField
+	RemoteHost FormField
+	RemotePort FormField
+	Focus      int // 0=local port, 1=remote host, 2=remote port, 3=submit
+	Err        string
+}
+
+// RenderForwardOverlay renders the overlay for starting a local port forward.
+func (r *Renderer) RenderForwardOverlay(p ForwardViewParams) string {
+	bg := r.Theme.Mantle
+	blink := r.Tick%2 == 0
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render(r.Icons.Add + " forward port")
+	hostLine := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+		Render("via " + p.HostLabel)
+
+	label := func(text string) string {
+		return lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render("  " + text)
+	}
+	localInput := r.RenderModalField(p.LocalPort.Value, p.LocalPort.Cursor, false, p.Focus == 0, blink, bg)
+	hostInput := r.RenderModalField(p.RemoteHost.Value, p.RemoteHost.Cursor, false, p.Focus == 1, blink, bg)
+	remoteInput := r.RenderModalField(p.RemotePort.Value, p.RemotePort.Cursor, false, p.Focus == 2, blink, bg)
+
+	var submitLine string
+	if p.Focus == 3 {
+		submitLine = "  " + lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+			Render(r.Icons.Save+" start tunnel")
+	} else {
+		submitLine = "  " + lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).
+			Render("  start tunnel")
+	}
+
+	var errLine string
+	if p.Err != "" {
+		errLine = lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).
+			Render("  " + r.Icons.ErrorIcon + " " + p.Err)
+	}
+
+	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).
+		Render("tab next  \u00B7  enter submit  \u00B7  esc cancel")
+
+	contentParts := []string{
+		title, hostLine, "",
+		label("local port"), localInput, "",
+		label("remote host"), hostInput, "",
+		label("remote port"), remoteInput,
+	}
+	if errLine != "" {
+		contentParts = append(contentParts, "", errLine)
+	}
+	contentParts = append(contentParts, "", submitLine, "", footer)
+
+	content := strings.Join(contentParts, "\n")
+
+	box := lipgloss.NewStyle().
+		Width(50).
+		Background(bg).
+		Padding(1, 2).
+		Align(lipgloss.Center).
+		Render(content)
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// MountsViewParams holds data for the per-host mounts overlay.
+type MountsViewParams struct {
+	HostLabel string
+	Mounts    []HomeMount
+	PathField FormField
+	ReadOnly  bool // read-only flag for the additional mount
+	Cursor    int  // 0..len(Mounts)-1=mount rows, len(Mounts)=add another mount
+	Err       string
+}
+
+// RenderMountsOverlay renders the active mounts of a host and the field for
+// adding another mount at a different remote path.
+func (r *Renderer) RenderMountsOverlay(p MountsViewParams) string {
+	bg := r.Theme.Mantle
+	blink := r.Tick%2 == 0
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render("mounts")
+	hostLine := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+		Render(p.HostLabel)
+
+	contentParts := []string{title, hostLine, ""}
+	for i, mt := range p.Mounts {
+		remote := mt.RemotePath
+		if remote == "" {
+			remote = "~"
+		}
+		row := remote + "  \u2192  " + mt.LocalPath
+		if mt.ReadOnly {
+			row += " (ro)"
+		}
+		if i == p.Cursor {
+			contentParts = append(contentParts, "  "+lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+				Render(r.Icons.Selected+" "+row))
+		} else {
+			contentParts = append(contentParts, "  "+lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg).
+				Render("  "+row))
+		}
+	}
+
+	addFocused := p.Cursor == len(p.Mounts)
+	addStyle := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg)
+	if addFocused {
+		addStyle = lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true)
+	}
+	contentParts = append(contentParts, "",
+		"  "+addStyle.Render(r.Icons.Add+" add another mount"),
+		r.RenderModalField(p.PathField.Value, p.PathField.Cursor, false, addFocused, blink, bg),
+		lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render("  remote path (empty = home)"),
+	)
+	roValue := "off"
+	if p.ReadOnly {
+		roValue = "on"
+	}
+	contentParts = append(contentParts, lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+		Render("  read-only  "+roValue))
+
+	if p.Err != "" {
+		contentParts = append(contentParts, "", lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).
+			Render("  "+r.Icons.ErrorIcon+" "+p.Err))
+	}
+
+	footerText := "\u2191\u2193 select  \u00B7  enter unmount  \u00B7  esc close"
+	if addFocused {
+		footerText = "\u2191\u2193 select  \u00B7  enter mount  \u00B7  ctrl+r read-only  \u00B7  esc close"
+	}
+	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render(footerText)
+	contentParts = append(contentParts, "", footer)
+
+	content := strings.Join(contentParts, "\n")
+
+	box := lipgloss.NewStyle().
+		Width(60).
+		Background(bg).
+		Padding(1, 2).
+		Align(lipgloss.Center).
+		Render(content)
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// ── Tunnels overlay ───────────────────────────────────────────────────
+
+// TunnelRow is one active port forward in the tunnels overlay.
+type TunnelRow struct {
+	LocalPort int
+	Remote    string // "db.internal:5432", or empty for a SOCKS proxy
+	HostLabel string
+	Hostname  string
+	PID       int
+	StartedAt time.Time
+}
+
+// TunnelsViewParams holds data for the tunnels overlay.
+type TunnelsViewParams struct {
+	Tunnels  []TunnelRow
+	Cursor   int    // 0..len(Tunnels)-1=tunnel rows, len(Tunnels)=new tunnel
+	NewVia   string // selected host for a new tunnel; empty when none
+	Stopping int    // local port of the tunnel being stopped, or 0
+	Err      string
+}
+
+// RenderTunnelsOverlay renders the active tunnels on the left and the
+// selected tunnel's details and actions on the right.
+func (r *Renderer) RenderTunnelsOverlay(p TunnelsViewParams) string {
+	bg := r.Theme.Mantle
+	const leftW, rightW = 42, 30
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render("tunnels")
+	dim := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg)
+	text := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg)
+	sel := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true)
+
+	var left []string
+	if len(p.Tunnels) == 0 {
+		left = append(left, dim.Render("  no active tunnels"))
+	}
+	for i, t := range p.Tunnels {
+		remote := t.Remote
+		if remote == "" {
+			remote = "socks"
+		}
+		row := r.TruncStr(fmt.Sprintf(":%d \u2192 %s via %s", t.LocalPort, remote, t.HostLabel), leftW-4)
+		if i == p.Cursor {
+			left = append(left, sel.Render("  "+r.Icons.Selected+" "+row))
+		} else {
+			left = append(left, text.Render("    "+row))
+		}
+	}
+	addRow := r.Icons.Add + " new tunnel"
+	if p.Cursor == len(p.Tunnels) {
+		left = append(left, "", sel.Render("  "+addRow))
+	} else {
+		left = append(left, "", dim.Render("  "+addRow))
+	}
+
+	key := func(k string) string { return dim.Render(fmt.Sprintf("%-8s", k)) }
+	var right []string
+	if p.Cursor < len(p.Tunnels) {
+		t := p.Tunnels[p.Cursor]
+		kind, remote := "local forward", t.Remote
+		if remote == "" {
+			kind, remote = "SOCKS5 proxy", "any (dynamic)"
+		}
+		right = append(right,
+			key("via")+text.Render(r.TruncStr(t.HostLabel, rightW-8)),
+			key("host")+text.Render(r.TruncStr(t.Hostname, rightW-8)),
+			key("type")+text.Render(kind),
+			key("local")+text.Render(fmt.Sprintf("localhost:%d", t.LocalPort)),
+			key("remote")+text.Render(r.TruncStr(remote, rightW-8)),
+			key("pid")+text.Render(fmt.Sprintf("%d", t.PID)),
+			key("started")+text.Render(FormatTimeAgo(t.StartedAt)),
+			"",
+		)
+		if p.Stopping == t.LocalPort {
+			right = append(right, dim.Render("stopping\u2026"))
+		} else {
+			right = append(right, lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).Bold(true).Render("[ Kill ]"))
+		}
+	} else if p.NewVia != "" {
+		right = append(right, text.Render("forward a local port"), dim.Render("via "+r.TruncStr(p.NewVia, rightW-4)))
+	} else {
+		right = append(right, dim.Render("select a host in the list"), dim.Render("to start a new tunnel"))
+	}
+
+	leftBlock := lipgloss.NewStyle().Width(leftW).Background(bg).Render(strings.Join(left, "\n"))
+	rightBlock := lipgloss.NewStyle().Width(rightW).Background(bg).Render(strings.Join(right, "\n"))
+	gap := lipgloss.NewStyle().Width(2).Background(bg).Render("")
+	body := lipgloss.JoinHorizontal(lipgloss.Top, leftBlock, gap, rightBlock)
+
+	contentParts := []string{title, "", body}
+	if p.Err != "" {
+		contentParts = append(contentParts, "", lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).
+			Render("  "+r.Icons.ErrorIcon+" "+p.Err))
+	}
+	footerText := "\u2191\u2193 select  \u00B7  enter kill  \u00B7  esc close"
+	if p.Cursor == len(p.Tunnels) {
+		footerText = "\u2191\u2193 select  \u00B7  enter new tunnel  \u00B7  esc close"
+	}
+	contentParts = append(contentParts, "", dim.Render(footerText))
+
+	content := strings.Join(contentParts, "\n")
+
+	box := lipgloss.NewStyle().
+		Width(leftW+rightW+2+4).
+		Background(bg).
+		Padding(1, 2).
+		Render(content)
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// SyncDryRunRow is one host in the sync dry-run preview.
+type SyncDryRunRow struct {
+	Action   string // "add", "update", or "keep local"
+	Hostname string
+	Conflict bool
+}
+
+// SyncDryRunViewParams holds data for the sync dry-run overlay.
+type SyncDryRunViewParams struct {
+	Rows    []SyncDryRunRow
+	Summary string
+	Cursor  int
+}
+
+// RenderSyncDryRunOverlay renders the scrollable list of changes the next
+// sync would import.
+func (r *Renderer) RenderSyncDryRunOverlay(p SyncDryRunViewParams) string {
+	bg := r.Theme.Mantle
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render("sync dry run")
+	contentParts := []string{title}
+	if p.Summary != "" {
+		contentParts = append(contentParts, lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+			Render(p.Summary))
+	}
+	contentParts = append(contentParts, "")
+
+	maxVisible := r.H - 14
+	if maxVisible < 4 {
+		maxVisible = 4
+	}
+	scrollOff := 0
+	if p.Cursor > maxVisible-1 {
+		scrollOff = p.Cursor - maxVisible + 1
+	}
+
+	for i, row := range p.Rows {
+		if i < scrollOff {
+			continue
+		}
+		if i >= scrollOff+maxVisible {
+			break
+		}
+		actionColor := r.Theme.Subtext
+		switch row.Action {
+		case "add":
+			actionColor = r.Theme.Green
+		case "update":
+			actionColor = r.Theme.Accent
+		}
+		nameStyle := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg)
+		prefix := "  "
+		if i == p.Cursor {
+			nameStyle = nameStyle.Foreground(r.Theme.Accent).Bold(true)
+			prefix = lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Render(r.Icons.Focused + " ")
+		}
+		action := lipgloss.NewStyle().Foreground(actionColor).Background(bg).Width(11).Render(row.Action)
+		line := prefix + action + nameStyle.Render(r.TruncStr(row.Hostname, 36))
+		if row.Conflict {
+			line += "  " + lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).Render("conflict")
+		}
+		contentParts = append(contentParts, line)
+	}
+	if len(p.Rows) == 0 {
+		contentParts = append(contentParts, "  "+lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).
+			Render("nothing to import"))
+	}
+
+	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).
+		Render("\u2191\u2193 scroll  \u00B7  esc close")
+	contentParts = append(contentParts, "", footer)
+
+	content := strings.Join(contentParts, "\n")
+
+	box := lipgloss.NewStyle().
+		Width(64).
+		Background(bg).
+		Padding(1, 2).
+		Render(content)
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// SyncConflictRow is one host whose local and remote copies differed in a sync.
+type SyncConflictRow struct {
+	Hostname   string
+	LocalTime  string
+	RemoteTime string
+	Resolution string // "local" or "remote"
+	Overridden bool   // Resolution was picked by the user, not the sync
+}
+
+// SyncConflictsViewParams holds data for the sync conflicts overlay.
+type SyncConflictsViewParams struct {
+	Rows     []SyncConflictRow
+	Cursor   int
+	Applying bool
+}
+
+// RenderSyncConflictsOverlay renders the conflicts from the last sync with
+// the side each one kept, so the user can switch sides before applying.
+func (r *Renderer) RenderSyncConflictsOverlay(p SyncConflictsViewParams) string {
+	bg := r.Theme.Mantle
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render("sync conflicts")
+	summary := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+		Render(fmt.Sprintf("%d hosts differed from the remote \u00B7 the newer copy was kept", len(p.Rows)))
+	contentParts := []string{title, summary, ""}
+
+	col := func(s string, w int) string {
+		return lipgloss.NewStyle().Background(bg).Width(w).Render(s)
+	}
+	header := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg)
+	contentParts = append(contentParts, "  "+header.Render(col("host", 26)+col("local", 18)+col("remote", 18)+"keep"))
+
+	maxVisible := r.H - 16
+	if maxVisible < 4 {
+		maxVisible = 4
+	}
+	scrollOff := 0
+	if p.Cursor > maxVisible-1 {
+		scrollOff = p.Cursor - maxVisible + 1
+	}
+
+	for i, row := range p.Rows {
+		if i < scrollOff {
+			continue
+		}
+		if i >= scrollOff+maxVisible {
+			break
+		}
+		nameStyle := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg)
+		prefix := "  "
+		if i == p.Cursor {
+			nameStyle = nameStyle.Foreground(r.Theme.Accent).Bold(true)
+			prefix = lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Render(r.Icons.Focused + " ")
+		}
+		timeStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg)
+		resColor := r.Theme.Green
+		if row.Resolution == "remote" {
+			resColor = r.Theme.Accent
+		}
+		resolution := row.Resolution
+		if row.Overridden {
+			resolution += " *"
+		}
+		line := prefix +
+			nameStyle.Width(26).Render(r.TruncStr(row.Hostname, 24)) +
+			timeStyle.Width(18).Render(row.LocalTime) +
+			timeStyle.Width(18).Render(row.RemoteTime) +
+			lipgloss.NewStyle().Foreground(resColor).Background(bg).Bold(row.Overridden).Render(resolution)
+		contentParts = append(contentParts, line)
+	}
+
+	hint := "\u2191\u2193 select  \u00B7  l local  \u00B7  r remote  \u00B7  enter apply  \u00B7  esc keep as synced"
+	if p.Applying {
+		hint = "applying\u2026"
+	}
+	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render(hint)
+	contentParts = append(contentParts, "", footer)
+
+	content := strings.Join(contentParts, "\n")
+
+	box := lipgloss.NewStyle().
+		Width(80).
+		Background(bg).
+		Padding(1, 2).
+		Render(content)
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// ImportPreviewRow is one parsed host in the import wizard preview.
+type ImportPreviewRow struct {
+	Label  string
+	Target string // user@host[:port]
+	Group  string
+	Exists bool // label or hostname:port already used by a saved host
+}
+
+// ImportWizardViewParams holds data for the host import wizard.
+type ImportWizardViewParams struct {
+	Step      int // 0=file path, 1=preview, 2=conflict resolution
+	Path      FormField
+	Format    string // "ssh_config", "json" or "csv"
+	Rows      []ImportPreviewRow
+	Total     int
+	Conflicts int
+	Options   []string
+	Cursor    int
+	Importing bool
+	Err       string
+}
+
+// RenderImportWizardModal renders the current step of the host import wizard.
+func (r *Renderer) RenderImportWizardModal(p ImportWizardViewParams) string {
+	bg := r.Theme.Mantle
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render(r.Icons.Add + " import hosts")
+	stepNames := []string{"file", "preview", "conflicts"}
+	var steps []string
+	for i, name := range stepNames {
+		style := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg)
+		if i == p.Step {
+			style = style.Foreground(r.Theme.Accent).Bold(true)
+		}
+		steps = append(steps, style.Render(fmt.Sprintf("%d %s", i+1, name)))
+	}
+	sep := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render("  \u203A  ")
+	contentParts := []string{title, strings.Join(steps, sep), ""}
+
+	var body []string
+	var footer string
+	switch p.Step {
+	case 0:
+		body, footer = r.renderImportPathStep(p)
+	case 1:
+		body, footer = r.renderImportPreviewStep(p)
+	default:
+		body, footer = r.renderImportConflictStep(p)
+	}
+	contentParts = append(contentParts, body...)
+
+	if p.Err != "" {
+		contentParts = append(contentParts, "", lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).
+			Render("  "+r.Icons.ErrorIcon+" "+p.Err))
+	}
+	contentParts = append(contentParts, "", lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render(footer))
+
+	content := strings.Join(contentParts, "\n")
+
+	box := lipgloss.NewStyle().
+		Width(68).
+		Background(bg).
+		Padding(1, 2).
+		Render(content)
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+func (r *Renderer) renderImportPathStep(p ImportWizardViewParams) ([]string, string) {
+	bg := r.Theme.Mantle
+	hint := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+		Render("  ssh config, JSON or CSV (as printed by sshthing list)")
+	input := r.RenderModalField(p.Path.Value, p.Path.Cursor, false, true, r.Tick%2 == 0, bg)
+	return []string{hint, "", input}, "enter preview  \u00B7  esc cancel"
+}
+
+func (r *Renderer) renderImportPreviewStep(p ImportWizardViewParams) ([]string, string) {
+	bg := r.Theme.Mantle
+	formats := map[string]string{"ssh_config": "ssh config", "json": "JSON", "csv": "CSV"}
+	noun := "hosts"
+	if p.Total == 1 {
+		noun = "host"
+	}
+	header := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg).Bold(true).
+		Render(fmt.Sprintf("%d %s found", p.Total, noun)) +
+		lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Render("  \u00B7  "+formats[p.Format])
+	lines := []string{header, ""}
+
+	colStyle := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg)
+	lines = append(lines, colStyle.Width(20).Render("label")+colStyle.Width(30).Render("target")+colStyle.Render("group"))
+	for _, row := range p.Rows {
+		labelStyle := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg).Width(20)
+		if row.Exists {
+			labelStyle = labelStyle.Foreground(r.Theme.Yellow)
+		}
+		lines = append(lines, labelStyle.Render(r.TruncStr(row.Label, 18))+
+			lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).Width(30).Render(r.TruncStr(row.Target, 28))+
+			lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render(r.TruncStr(row.Group, 12)))
+	}
+	if more := p.Total - len(p.Rows); more > 0 {
+		lines = append(lines, colStyle.Render(fmt.Sprintf("\u2026 and %d more", more)))
+	}
+	if p.Conflicts > 0 {
+		lines = append(lines, "", lipgloss.NewStyle().Foreground(r.Theme.Yellow).Background(bg).
+			Render(fmt.Sprintf("%d already exist", p.Conflicts)))
+	}
+	return lines, "enter next  \u00B7  backspace back  \u00B7  esc cancel"
+}
+
+func (r *Renderer) renderImportConflictStep(p ImportWizardViewParams) ([]string, string) {
+	bg := r.Theme.Mantle
+	if p.Importing {
+		frames := []string{"|", "/", "-", "\\"}
+		line := lipgloss.NewStyle().Foreground(r.Theme.Sky).Background(bg).
+			Render(fmt.Sprintf("%s Importing %d hosts\u2026", frames[r.Tick%len(frames)], p.Total))
+		return []string{line}, "please wait"
+	}
+
+	intro := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+		Render(fmt.Sprintf("when a host already exists (%d of %d):", p.Conflicts, p.Total))
+	lines := []string{intro, ""}
+	for i, opt := range p.Options {
+		prefix := "  "
+		style := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg)
+		if i == p.Cursor {
+			prefix = lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Render(r.Icons.Focused + " ")
+			style = style.Foreground(r.Theme.Accent).Bold(true)
+		}
+		lines = append(lines, prefix+style.Render(opt))
+	}
+	lines = append(lines, "", "  "+lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render(r.Icons.Save+" import"))
+	return lines, "\u2191\u2193 choose  \u00B7  enter import  \u00B7  backspace back  \u00B7  esc cancel"
+}
+
+// BulkExecRow is one host in the bulk command overlay.
+type BulkExecRow struct {
+	Label     string
+	Status    string // "", "queued", "running", "exit N" or an error
+	OK        bool
+	Failed    bool
+	Lines     []string // output so far
+	Collapsed bool
+}
+
+// BulkExecViewParams holds data for the bulk command overlay.
+type BulkExecViewParams struct {
+	Field   FormField
+	Started bool
+	Rows    []BulkExecRow
+	Cursor  int // selected host row
+	Done    int
+	OK      int
+	Failed  int
+	Err     string
+}
+
+// RenderBulkExecOverlay renders the command prompt for a bulk run and, once
+// started, each host's output under a collapsible header.
+func (r *Renderer) RenderBulkExecOverlay(p BulkExecViewParams) string {
+	bg := r.Theme.Mantle
+	width := min(100, max(50, r.W-10))
+	inner := width - 4
+
+	noun := "hosts"
+	if len(p.Rows) == 1 {
+		noun = "host"
+	}
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render(fmt.Sprintf("run on %d %s", len(p.Rows), noun))
+	contentParts := []string{title, ""}
+	footer := "enter run  \u00B7  esc cancel"
+
+	if !p.Started {
+		var names []string
+		for _, row := range p.Rows {
+			names = append(names, row.Label)
+		}
+		contentParts = append(contentParts,
+			lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+				Render(r.TruncStr(strings.Join(names, ", "), inner)),
+			"",
+			r.RenderModalField(p.Field.Value, p.Field.Cursor, false, true, r.Tick%2 == 0, bg))
+	} else {
+		contentParts = append(contentParts, r.renderBulkExecProgress(p, inner), "")
+		contentParts = append(contentParts, r.renderBulkExecRows(p, inner)...)
+		footer = "\u2191\u2193 host  \u00B7  enter collapse  \u00B7  esc close"
+	}
+
+	if p.Err != "" {
+		contentParts = append(contentParts, "", lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).
+			Render("  "+r.Icons.ErrorIcon+" "+p.Err))
+	}
+	contentParts = append(contentParts, "", lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render(footer))
+
+	box := lipgloss.NewStyle().
+		Width(width).
+		Background(bg).
+		Padding(1, 2).
+		Render(strings.Join(contentParts, "\n"))
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// renderBulkExecProgress renders "[bar] X/N done", followed by the exit
+// summary once every host has finished.
+func (r *Renderer) renderBulkExecProgress(p BulkExecViewParams, width int) string {
+	bg := r.Theme.Mantle
+	total := max(1, len(p.Rows))
+	barW := min(30, width/2)
+	filled := barW * p.Done / total
+	bar := lipgloss.NewStyle().Foreground(r.Theme.Green).Background(bg).Render(strings.Repeat("\u2588", filled)) +
+		lipgloss.NewStyle().Foreground(r.Theme.Surface1).Background(bg).Render(strings.Repeat("\u2591", barW-filled))
+	line := bar + lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg).
+		Render(fmt.Sprintf("  %d/%d done", p.Done, len(p.Rows)))
+	if p.Done == len(p.Rows) {
+		summary := lipgloss.NewStyle().Foreground(r.Theme.Green).Background(bg).Render(fmt.Sprintf("  \u00B7  %d ok", p.OK))
+		if p.Failed > 0 {
+			summary += lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).Render(fmt.Sprintf("  %d failed", p.Failed))
+		}
+		line += summary
+	}
+	return line
+}
+
+// renderBulkExecRows lays out each host header with its output beneath,
+// scrolled so the selected header stays visible.
+func (r *Renderer) renderBulkExecRows(p BulkExecViewParams, width int) []string {
+	bg := r.Theme.Mantle
+	var lines []string
+	cursorLine := 0
+	for i, row := range p.Rows {
+		arrow := "\u25BE"
+		if row.Collapsed {
+			arrow = "\u25B8"
+		}
+		labelStyle := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg).Bold(true)
+		prefix := "  "
+		if i == p.Cursor {
+			cursorLine = len(lines)
+			labelStyle = labelStyle.Foreground(r.Theme.Accent)
+			prefix = lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Render(r.Icons.Focused + " ")
+		}
+		statusColor := r.Theme.Subtext
+		switch {
+		case row.OK:
+			statusColor = r.Theme.Green
+		case row.Failed:
+			statusColor = r.Theme.Red
+		case row.Status == "running":
+			statusColor = r.Theme.Sky
+		}
+		header := prefix + labelStyle.Render(arrow+" "+r.TruncStr(row.Label, 30)) +
+			lipgloss.NewStyle().Foreground(statusColor).Background(bg).Render("  "+r.TruncStr(row.Status, width-40))
+		lines = append(lines, header)
+		if row.Collapsed {
+			continue
+		}
+		outStyle := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg)
+		for _, l := range row.Lines {
+			lines = append(lines, outStyle.Render("    "+r.TruncStr(strings.ReplaceAll(l, "\r", ""), width-6)))
+		}
+	}
+
+	maxVisible := max(6, r.H-14)
+	scrollOff := 0
+	if cursorLine > maxVisible-1 {
+		scrollOff = cursorLine - maxVisible + 1
+	}
+	end := min(len(lines), scrollOff+maxVisible)
+	return lines[scrollOff:end]
+}
+
+// PassphraseViewParams holds data for the private key passphrase overlay.
+type PassphraseViewParams struct {
+	HostLabel string
+	Field     FormField
+	Err       string
+}
+
+// RenderPassphraseOverlay renders the prompt for an encrypted private key.
+func (r *Renderer) RenderPassphraseOverlay(p PassphraseViewParams) string {
+	bg := r.Theme.Mantle
+	blink := r.Tick%2 == 0
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render(r.Icons.Lock + " key passphrase")
+	hostLine := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+		Render("private key for " + p.HostLabel + " is encrypted")
+
+	input := r.RenderModalField(p.Field.Value, p.Field.Cursor, true, true, blink, bg)
+
+	var errLine string
+	if p.Err != "" {
+		errLine = lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).
+			Render("  " + r.Icons.ErrorIcon + " " + p.Err)
+	}
+
+	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).
+		Render("enter unlock  \u00B7  esc cancel")
+
+	contentParts := []string{title, hostLine, "", input}
+	if errLine != "" {
+		contentParts = append(contentParts, "", errLine)
+	}
+	contentParts = append(contentParts, "", footer)
+
+	content := strings.Join(contentParts, "\n")
+
+	box := lipgloss.NewStyle().
+		Width(50).
+		Background(bg).
+		Padding(1, 2).
+		Align(lipgloss.Center).
+		Render(content)
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// KeyRotationViewParams holds data for the key rotation wizard.
+type KeyRotationViewParams struct {
+	HostLabel string
+	Step      int // 0 choose type, 1 verify, 2 confirm, 3 done
+	KeyTypes  []string
+	KeyIdx    int
+	Busy      bool
+	Status    string
+	Err       string
+}
+
+// RenderKeyRotationOverlay renders the key rotation wizard as a modal with
+// its steps checked off as they complete.
+func (r *Renderer) RenderKeyRotationOverlay(p KeyRotationViewParams) string {
+	bg := r.Theme.Mantle
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render(r.Icons.Shield + " rotate key")
+	hostLine := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+		Render(p.HostLabel)
+
+	steps := []string{
+		"generate and deploy new " + p.KeyTypes[p.KeyIdx] + " key",
+		"log in with the new key",
+		"save new key and remove the old one",
+	}
+	var stepLines []string
+	for i, s := range steps {
+		marker, style := "  ", lipgloss.NewStyle().Foreground(r.Theme.Overlay)
+		switch {
+		case i < p.Step:
+			marker, style = r.Icons.Success+" ", lipgloss.NewStyle().Foreground(r.Theme.Green)
+		case i == p.Step:
+			marker, style = r.Icons.Focused+" ", lipgloss.NewStyle().Foreground(r.Theme.Text)
+		}
+		stepLines = append(stepLines, style.Background(bg).Render(fmt.Sprintf("%s%d. %s", marker, i+1, s)))
+	}
+
+	parts := []string{title, hostLine, "", lipgloss.JoinVertical(lipgloss.Left, stepLines...)}
+	if p.Step == 0 {
+		var opts []string
+		for i, kt := range p.KeyTypes {
+			style := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg)
+			if i == p.KeyIdx {
+				style = lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true)
+				kt = "[" + kt + "]"
+			}
+			opts = append(opts, style.Render(kt))
+		}
+		parts = append(parts, "", strings.Join(opts, "  "))
+	}
+	if p.Status != "" {
+		parts = append(parts, "", lipgloss.NewStyle().Foreground(r.Theme.Sky).Background(bg).Render(p.Status))
+	}
+	if p.Err != "" {
+		parts = append(parts, "", lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).
+			Render(r.Icons.ErrorIcon+" "+p.Err))
+	}
+
+	var footerText string
+	switch {
+	case p.Busy:
+		footerText = "working\u2026"
+	case p.Step == 0:
+		footerText = "\u2190\u2192 key type  \u00B7  enter deploy  \u00B7  esc cancel"
+	case p.Step == 1:
+		footerText = "enter test login  \u00B7  esc cancel and remove new key"
+	case p.Step == 2:
+		footerText = "enter save and remove old key  \u00B7  esc cancel"
+	default:
+		footerText = "enter close"
+	}
+	parts = append(parts, "", lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render(footerText))
+
+	box := lipgloss.NewStyle().
+		Width(60).
+		Background(bg).
+		Padding(1, 2).
+		Render(strings.Join(parts, "\n"))
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// SessionRenewViewParams holds data for the expired unlock session prompt.
+type SessionRenewViewParams struct {
+	Action string // what continues after renewing, e.g. "sync"
+	Field  FormField
+	Err    string
+}
+
+// RenderSessionRenewOverlay renders the master password prompt shown when the
+// unlock session expired before an action that needs it.
+func (r *Renderer) RenderSessionRenewOverlay(p SessionRenewViewParams) string {
+	bg := r.Theme.Mantle
+	blink := r.Tick%2 == 0
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render(r.Icons.Lock + " session expired")
+	sub := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+		Render("enter the master password to renew it and " + p.Action)
+
+	input := r.RenderModalField(p.Field.Value, p.Field.Cursor, true, true, blink, bg)
+
+	contentParts := []string{title, sub, "", input}
+	if p.Err != "" {
+		contentParts = append(contentParts, "", lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).
+			Render("  "+r.Icons.ErrorIcon+" "+p.Err))
+	}
+	contentParts = append(contentParts, "", lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).
+		Render("enter renew  \u00B7  esc cancel"))
+
+	box := lipgloss.NewStyle().
+		Width(50).
+		Background(bg).
+		Padding(1, 2).
+		Align(lipgloss.Center).
+		Render(strings.Join(contentParts, "\n"))
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
+
+// SFTPBrowserRow is one entry in the remote file browser.
+type SFTPBrowserRow struct {
+	Name    string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+// SFTPBrowserViewParams holds data for the in-TUI SFTP file browser.
+type SFTPBrowserViewParams struct {
+	HostLabel     string
+	Path          string
+	Rows          []SFTPBrowserRow
+	Cursor        int
+	Uploading     bool
+	UploadField   FormField
+	ConfirmDelete bool
+	Err           error
+}
+
+// RenderSFTPBrowserOverlay renders the remote directory listing with name,
+// size and modification time columns.
+func (r *Renderer) RenderSFTPBrowserOverlay(p SFTPBrowserViewParams) string {
+	bg := r.Theme.Mantle
+	blink := r.Tick%2 == 0
+	const boxW = 76
+	nameW := boxW - 4 - 2 - 10 - 18
+
+	title := lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Bold(true).
+		Render("sftp " + r.Icons.Focused + " " + p.HostLabel)
+	pathLine := lipgloss.NewStyle().Foreground(r.Theme.Subtext).Background(bg).
+		Render(r.TruncStr(p.Path, boxW-4))
+	contentParts := []string{title, pathLine, ""}
+
+	maxVisible := r.H - 14
+	if maxVisible < 4 {
+		maxVisible = 4
+	}
+	scrollOff := 0
+	if p.Cursor > maxVisible-1 {
+		scrollOff = p.Cursor - maxVisible + 1
+	}
+
+	for i, row := range p.Rows {
+		if i < scrollOff {
+			continue
+		}
+		if i >= scrollOff+maxVisible {
+			break
+		}
+		name := row.Name
+		size := FormatSize(row.Size)
+		nameStyle := lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg)
+		if row.IsDir {
+			name += "/"
+			size = "-"
+			nameStyle = nameStyle.Foreground(r.Theme.Sky)
+		}
+		prefix := "  "
+		if i == p.Cursor {
+			nameStyle = nameStyle.Foreground(r.Theme.Accent).Bold(true)
+			prefix = lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Render(r.Icons.Focused + " ")
+		}
+		meta := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg)
+		line := prefix +
+			nameStyle.Width(nameW).Render(r.TruncStr(name, nameW-1)) +
+			meta.Width(10).Align(lipgloss.Right).Render(size) +
+			meta.Width(18).Align(lipgloss.Right).Render(row.ModTime.Local().Format("2006-01-02 15:04"))
+		contentParts = append(contentParts, line)
+	}
+	if len(p.Rows) == 0 {
+		contentParts = append(contentParts, "  "+lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).
+			Render("empty directory"))
+	}
+
+	if p.Uploading {
+		contentParts = append(contentParts, "",
+			"  "+lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Render("upload local file"),
+			r.RenderModalField(p.UploadField.Value, p.UploadField.Cursor, false, true, blink, bg),
+		)
+	}
+	if p.ConfirmDelete && p.Cursor < len(p.Rows) {
+		contentParts = append(contentParts, "", "  "+lipgloss.NewStyle().Foreground(r.Theme.Red).Background(bg).Bold(true).
+			Render("delete "+p.Rows[p.Cursor].Name+"? (y/n)"))
+	}
+	if p.Err != nil {
+		contentParts = append(contentParts, "", r.renderErrLine(p.Err))
+	}
+
+	footerText := "enter open  \u00B7  backspace up  \u00B7  d download  \u00B7  u upload  \u00B7  del delete  \u00B7  esc disconnect"
+	switch {
+	case p.Uploading:
+		footerText = "enter upload  \u00B7  esc cancel"
+	case p.ConfirmDelete:
+		footerText = "y delete  \u00B7  n cancel"
+	}
+	footer := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render(footerText)
+	contentParts = append(contentParts, "", footer)
+
+	content := strings.Join(contentParts, "\n")
+
+	box := lipgloss.NewStyle().
+		Width(boxW).
+		Background(bg).
+		Padding(1, 2).
+		Render(content)
+
+	return lipgloss.Place(r.W, r.H, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceBackground(r.Theme.Base))
+}
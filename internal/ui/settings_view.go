@@ -260,6 +260,7 @@ type TokenHostItem struct {
 	Label    string
 	Detail   string // user@host:port
 	Selected bool
+	Command  string // allowed-command pattern; empty allows any command
 }
 
 // TokenCreateNameParams holds data for the token name input overlay.
@@ -270,9 +271,10 @@ type TokenCreateNameParams struct {
 
 // TokenSelectHostsParams holds data for the host picker overlay.
 type TokenSelectHostsParams struct {
-	Hosts  []TokenHostItem
-	Cursor int
-	Err    string
+	Hosts   []TokenHostItem
+	Cursor  int
+	Editing bool // editing the command pattern of the host under the cursor
+	Err     string
 }
 
 // TokenRevealParams holds data for the token reveal/copy overlay.
@@ -289,6 +291,15 @@ type TokenViewItem struct {
 	Scope   string
 	Created string
 	LastUse string
+	Audit   []TokenAuditItem // most recent exec runs, newest first
+}
+
+// TokenAuditItem is one audit log entry shown in the token detail panel.
+type TokenAuditItem struct {
+	When    string
+	Target  string
+	Command string
+	Success bool
 }
 
 // TokensViewParams holds data for the tokens page view.
@@ -324,6 +335,11 @@ func (r *Renderer) RenderTokensView(p TokensViewParams) string {
 		lines = append(lines, r.renderErrLine(p.Err))
 	}
 
+	listW := ruleW + 4
+	detailW := cw - listW - 4
+	showDetail := len(p.Tokens) > 0 && detailW >= CompactListW && !r.SinglePanel()
+	topLines := len(lines)
+
 	if len(p.Tokens) == 0 {
 		lines = append(lines, "  "+lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("no tokens"))
 		lines = append(lines, "")
@@ -368,6 +384,14 @@ func (r *Renderer) RenderTokensView(p TokensViewParams) string {
 		}
 	}
 
+	// right panel: recent audit entries of the selected token
+	if showDetail && p.Cursor >= 0 && p.Cursor < len(p.Tokens) {
+		listBlock := lipgloss.NewStyle().Width(listW).Render(strings.Join(lines[topLines:], "\n"))
+		detailBlock := lipgloss.NewStyle().Width(detailW).Render(r.renderTokenAudit(p.Tokens[p.Cursor], detailW))
+		gapBlock := lipgloss.NewStyle().Width(4).Render("")
+		lines = append(lines[:topLines], lipgloss.JoinHorizontal(lipgloss.Top, listBlock, gapBlock, detailBlock))
+	}
+
 	// footer
 	lines = append(lines, r.RenderFooter("\u2191\u2193 navigate  a create  r revoke  d delete  shift+tab pages  esc home  q quit"))
 
@@ -385,6 +409,24 @@ func (r *Renderer) RenderTokensView(p TokensViewParams) string {
 	return padded
 }
 
+func (r *Renderer) renderTokenAudit(tok TokenViewItem, w int) string {
+	lines := []string{lipgloss.NewStyle().Foreground(r.Theme.Text).Bold(true).Render("recent activity"), ""}
+	if len(tok.Audit) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render("no exec runs recorded"))
+		return strings.Join(lines, "\n")
+	}
+	for _, a := range tok.Audit {
+		icon := lipgloss.NewStyle().Foreground(r.Theme.Green).Render("\u2713")
+		if !a.Success {
+			icon = lipgloss.NewStyle().Foreground(r.Theme.Red).Render("\u2717")
+		}
+		lines = append(lines, icon+" "+lipgloss.NewStyle().Foreground(r.Theme.Subtext).Render(a.When)+"  "+
+			lipgloss.NewStyle().Foreground(r.Theme.Accent).Render(a.Target))
+		lines = append(lines, "  "+lipgloss.NewStyle().Foreground(r.Theme.Overlay).Render(r.TruncStr(a.Command, w-2)))
+	}
+	return strings.Join(lines, "\n")
+}
+
 // ── Token overlays ────────────────────────────────────────────────────
 
 // RenderTokenCreateNameOverlay renders the token name input modal.
@@ -480,6 +522,18 @@ func (r *Renderer) RenderTokenSelectHostsOverlay(p TokenSelectHostsParams) strin
 			line += "  " + detailStyle.Render(h.Detail)
 		}
 		parts = append(parts, line)
+		if h.Selected {
+			cmdStyle := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg)
+			cmd, cursor := h.Command, ""
+			switch {
+			case sel && p.Editing:
+				cmdStyle = lipgloss.NewStyle().Foreground(r.Theme.Text).Background(bg)
+				cursor = lipgloss.NewStyle().Foreground(r.Theme.Accent).Background(bg).Render(r.Icons.Cursor)
+			case cmd == "":
+				cmd = "any command"
+			}
+			parts = append(parts, "      "+cmdStyle.Render("cmd: "+cmd)+cursor)
+		}
 		displayed++
 	}
 
@@ -492,7 +546,7 @@ func (r *Renderer) RenderTokenSelectHostsOverlay(p TokenSelectHostsParams) strin
 		parts = append(parts, lipgloss.NewStyle().Foreground(r.Theme.Yellow).Background(bg).Render(p.Err))
 	}
 
-	hint := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render("↑↓ navigate · space toggle · enter create · esc cancel")
+	hint := lipgloss.NewStyle().Foreground(r.Theme.Overlay).Background(bg).Render("↑↓ navigate · space toggle · tab command · enter create · esc cancel")
 	parts = append(parts, "", hint)
 
 	content := strings.Join(parts, "\n")
@@ -381,6 +381,37 @@ var Themes = []Theme{
 	},
 }
 
+// HighContrastTheme is used instead of the selected theme when high contrast
+// mode is on. Surface0 stays dark gray because toasts draw white text on it.
+var HighContrastTheme = Theme{
+	Name:     "High Contrast",
+	Base:     "#000000",
+	Mantle:   "#000000",
+	Crust:    "#000000",
+	Surface0: "#404040",
+	Surface1: "#FFFFFF",
+	Surface2: "#FFFFFF",
+	Text:     "#FFFFFF",
+	Subtext:  "#FFFFFF",
+	Overlay:  "#FFFFFF",
+	Accent:   "#00FF00",
+	Green:    "#00FF00",
+	Yellow:   "#FFFF00",
+	Red:      "#FF0000",
+	Sky:      "#00FFFF",
+	Pink:     "#FF00FF",
+}
+
+// ThemeFor returns the named theme, or HighContrastTheme when highContrast is
+// set. The index is always that of the named theme.
+func ThemeFor(name string, highContrast bool) (Theme, int) {
+	t, idx := ThemeByName(name)
+	if highContrast {
+		return HighContrastTheme, idx
+	}
+	return t, idx
+}
+
 // ThemeByName returns the theme with the given name, or the default.
 func ThemeByName(name string) (Theme, int) {
 	for i, t := range Themes {
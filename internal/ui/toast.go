@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ToastItem is one notification drawn in the top-right corner.
+type ToastItem struct {
+	Message string
+	Fading  bool // about to expire; drawn dimmed
+}
+
+// OverlayToasts draws toasts over the top-right corner of an already
+// rendered screen, stacked from the top.
+func (r *Renderer) OverlayToasts(screen string, toasts []ToastItem) string {
+	if len(toasts) == 0 || r.W < 30 {
+		return screen
+	}
+	lines := strings.Split(screen, "\n")
+	maxW := min(48, r.W-4)
+	y := 1
+	for _, t := range toasts {
+		box := r.renderToast(t, maxW)
+		boxW := lipgloss.Width(box)
+		x := r.W - 2 - boxW
+		for _, bl := range strings.Split(box, "\n") {
+			if y >= len(lines) {
+				return strings.Join(lines, "\n")
+			}
+			lines[y] = spliceLine(lines[y], bl, x, boxW)
+			y++
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *Renderer) renderToast(t ToastItem, maxW int) string {
+	style := lipgloss.NewStyle().Background(r.Theme.Surface0).Padding(0, 1)
+	msg := ansi.Truncate(t.Message, maxW-2, "…")
+	if t.Fading {
+		return style.Foreground(r.Theme.Overlay).Render(msg)
+	}
+	return style.Render(r.renderFooterNotice(msg))
+}
+
+// spliceLine replaces the cells [x, x+w) of line with insert.
+func spliceLine(line, insert string, x, w int) string {
+	lineW := ansi.StringWidth(line)
+	if lineW < x+w {
+		line += strings.Repeat(" ", x+w-lineW)
+		lineW = x + w
+	}
+	return ansi.Truncate(line, x, "") + insert + ansi.Cut(line, x+w, lineW)
+}
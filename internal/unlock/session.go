@@ -9,12 +9,16 @@ import (
 	"github.com/Vansh-Raja/SSHThing/internal/securestore"
 )
 
+// cacheRecord is the session cache entry. It is stored as one JSON string
+// in the OS keyring, or in securestore's encrypted file when no keyring is
+// available; nothing is written to disk in the clear.
 type cacheRecord struct {
 	Version   int       `json:"version"`
 	ExpiresAt time.Time `json:"expires_at"`
 	Secret    string    `json:"secret"`
 }
 
+// Save caches secret for ttl (15 minutes if ttl <= 0).
 func Save(secret string, ttl time.Duration) error {
 	secret = strings.TrimSpace(secret)
 	if secret == "" {
@@ -35,6 +39,8 @@ func Save(secret string, ttl time.Duration) error {
 	return securestore.StoreSessionUnlock(string(b))
 }
 
+// Load returns the cached secret and its expiry. ok is false when there is
+// no session or it has expired; an expired session is cleared.
 func Load() (secret string, expiresAt time.Time, ok bool, err error) {
 	v, err := securestore.LoadSessionUnlock()
 	if err != nil {
@@ -51,6 +57,7 @@ func Load() (secret string, expiresAt time.Time, ok bool, err error) {
 	return rec.Secret, rec.ExpiresAt, strings.TrimSpace(rec.Secret) != "", nil
 }
 
+// Clear removes the cached session.
 func Clear() error {
 	return securestore.ClearSessionUnlock()
 }
@@ -71,6 +71,12 @@ func applyInstallerMode(ctx context.Context, check CheckResult, currentExe strin
 	if check.Asset.URL == "" || check.Checksums.URL == "" {
 		return ApplyResult{}, fmt.Errorf("missing installer/checksum asset")
 	}
+	if err := checkSigningKey(SigningKeyEmbedded(), releaseBuild); err != nil {
+		return ApplyResult{}, err
+	}
+	if SigningKeyEmbedded() && check.Signature.URL == "" {
+		return ApplyResult{}, fmt.Errorf("missing installer signature asset")
+	}
 	tmpDir, err := os.MkdirTemp("", "sshthing-update-")
 	if err != nil {
 		return ApplyResult{}, err
@@ -84,6 +90,9 @@ func applyInstallerMode(ctx context.Context, check CheckResult, currentExe strin
 	if err := downloadToFile(ctx, check.Checksums.URL, checksumsPath); err != nil {
 		return ApplyResult{}, err
 	}
+	if err := verifyDownloadedSignature(ctx, check, tmpDir, installerPath); err != nil {
+		return ApplyResult{}, err
+	}
 	if err := verifyChecksum(installerPath, checksumsPath, check.Asset.Name); err != nil {
 		return ApplyResult{}, err
 	}
@@ -108,6 +117,12 @@ func applyReplaceMode(ctx context.Context, check CheckResult, currentExe string)
 	if check.Asset.URL == "" || check.Checksums.URL == "" {
 		return ApplyResult{}, fmt.Errorf("missing archive/checksum asset")
 	}
+	if err := checkSigningKey(SigningKeyEmbedded(), releaseBuild); err != nil {
+		return ApplyResult{}, err
+	}
+	if SigningKeyEmbedded() && check.Signature.URL == "" {
+		return ApplyResult{}, fmt.Errorf("missing archive signature asset")
+	}
 	tmpDir, err := os.MkdirTemp("", "sshthing-update-")
 	if err != nil {
 		return ApplyResult{}, err
@@ -121,6 +136,9 @@ func applyReplaceMode(ctx context.Context, check CheckResult, currentExe string)
 	if err := downloadToFile(ctx, check.Checksums.URL, checksumsPath); err != nil {
 		return ApplyResult{}, err
 	}
+	if err := verifyDownloadedSignature(ctx, check, tmpDir, archivePath); err != nil {
+		return ApplyResult{}, err
+	}
 	if err := verifyChecksum(archivePath, checksumsPath, check.Asset.Name); err != nil {
 		return ApplyResult{}, err
 	}
@@ -158,3 +176,17 @@ func runCommand(ctx context.Context, args []string) (string, error) {
 	out, err := cmd.CombinedOutput()
 	return string(out), err
 }
+
+// verifyDownloadedSignature downloads check's detached signature next to
+// assetPath and verifies it. Source builds without a release signing key
+// skip it; release builds are stopped earlier by checkSigningKey.
+func verifyDownloadedSignature(ctx context.Context, check CheckResult, tmpDir, assetPath string) error {
+	if !SigningKeyEmbedded() {
+		return nil
+	}
+	signaturePath := filepath.Join(tmpDir, check.Signature.Name)
+	if err := downloadToFile(ctx, check.Signature.URL, signaturePath); err != nil {
+		return err
+	}
+	return VerifyGPGSignature(assetPath, signaturePath)
+}
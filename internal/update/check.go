@@ -63,6 +63,9 @@ func Check(ctx context.Context, currentVersion string, cfg *config.Config) (Chec
 		result.ReleaseURL = rel.HTMLURL
 		result.Checksums = findAsset(rel.Assets, "SHA256SUMS")
 		result.Asset = resolveReleaseAsset(rel.Assets)
+		if result.Asset.Name != "" {
+			result.Signature = findAsset(rel.Assets, result.Asset.Name+".sig")
+		}
 		if cfg != nil {
 			cfg.Updates.ETagLatest = newETag
 			cfg.Updates.LastSeenTag = result.LatestTag
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -14,6 +16,9 @@ const (
 	githubRepo  = "SSHThing"
 )
 
+// changelogMaxChars caps the release notes returned by FetchChangelog.
+const changelogMaxChars = 5000
+
 type githubReleaseAsset struct {
 	Name string `json:"name"`
 	URL  string `json:"browser_download_url"`
@@ -65,6 +70,52 @@ func fetchLatestRelease(ctx context.Context, etag string) (*githubRelease, strin
 	return &rel, resp.Header.Get("ETag"), false, nil
 }
 
+// FetchChangelog returns the release notes (the release body) for tag,
+// truncated to 5000 characters.
+func FetchChangelog(ctx context.Context, tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", fmt.Errorf("missing release tag")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/tags/%s", githubOwner, githubRepo, url.PathEscape(tag)), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", "sshthing-updater")
+
+	client := &http.Client{Timeout: 20 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("github release %s request failed: %s (%s)", tag, resp.Status, string(body))
+	}
+
+	var rel struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return "", err
+	}
+	return truncateChangelog(rel.Body), nil
+}
+
+func truncateChangelog(body string) string {
+	body = strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+	runes := []rune(body)
+	if len(runes) <= changelogMaxChars {
+		return body
+	}
+	return strings.TrimRight(string(runes[:changelogMaxChars]), " \n") + "\n\u2026"
+}
+
 func findAsset(assets []githubReleaseAsset, name string) AssetInfo {
 	for _, a := range assets {
 		if a.Name == name {
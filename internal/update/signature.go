@@ -0,0 +1,79 @@
+package update
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// releaseSigningKey is the armored public key release assets are signed
+// with. Text before the armor block is ignored. The release workflow appends
+// the public half of its signing key before building and refuses to build
+// without one (see TestReleaseSigningKey).
+//
+//go:embed release_signing_key.asc
+var releaseSigningKey []byte
+
+// releaseBuild is set to "true" by the release workflow with
+// -ldflags "-X github.com/Vansh-Raja/SSHThing/internal/update.releaseBuild=true".
+var releaseBuild string
+
+// SigningKeyEmbedded reports whether this build carries a release signing
+// key.
+func SigningKeyEmbedded() bool {
+	return hasArmoredKey(releaseSigningKey)
+}
+
+// checkSigningKey reports whether updates may be applied with the key this
+// build carries. Release builds must carry one; source builds without one
+// fall back to the SHA-256 check alone.
+func checkSigningKey(embedded bool, release string) error {
+	if !embedded && release == "true" {
+		return fmt.Errorf("this release build has no signing key; refusing to apply an unverified update")
+	}
+	return nil
+}
+
+func hasArmoredKey(key []byte) bool {
+	return bytes.Contains(key, []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----"))
+}
+
+// VerifyGPGSignature checks the detached signature at sigPath (armored or
+// binary) against assetPath using the release signing key compiled into the
+// binary.
+func VerifyGPGSignature(assetPath, sigPath string) error {
+	return verifySignature(releaseSigningKey, assetPath, sigPath)
+}
+
+func verifySignature(armoredKey []byte, assetPath, sigPath string) error {
+	if !hasArmoredKey(armoredKey) {
+		return fmt.Errorf("no release signing key in this build; cannot verify %s", sigPath)
+	}
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(armoredKey))
+	if err != nil {
+		return fmt.Errorf("invalid release signing key: %w", err)
+	}
+
+	asset, err := os.Open(assetPath)
+	if err != nil {
+		return err
+	}
+	defer asset.Close()
+	sig, err := os.ReadFile(sigPath)
+	if err != nil {
+		return err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(sig), []byte("-----BEGIN PGP SIGNATURE-----")) {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyring, asset, bytes.NewReader(sig), nil)
+	} else {
+		_, err = openpgp.CheckDetachedSignature(keyring, asset, bytes.NewReader(sig), nil)
+	}
+	if err != nil {
+		return fmt.Errorf("signature verification failed for %s: %w", assetPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package update
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+func TestVerifySignature(t *testing.T) {
+	entity, err := openpgp.NewEntity("SSHThing Test", "", "test@example.com", nil)
+	if err != nil {
+		t.Fatalf("NewEntity: %v", err)
+	}
+	var pub bytes.Buffer
+	w, err := armor.Encode(&pub, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	w.Close()
+
+	dir := t.TempDir()
+	assetPath := filepath.Join(dir, "sshthing-linux-amd64.tar.gz")
+	sigPath := assetPath + ".sig"
+	if err := os.WriteFile(assetPath, []byte("release archive"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var sig bytes.Buffer
+	if err := openpgp.DetachSign(&sig, entity, strings.NewReader("release archive"), nil); err != nil {
+		t.Fatalf("DetachSign: %v", err)
+	}
+	if err := os.WriteFile(sigPath, sig.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifySignature(pub.Bytes(), assetPath, sigPath); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+
+	if err := os.WriteFile(assetPath, []byte("tampered archive"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifySignature(pub.Bytes(), assetPath, sigPath); err == nil {
+		t.Fatal("expected tampered asset to fail verification")
+	}
+
+	if err := verifySignature(nil, assetPath, sigPath); err == nil || !strings.Contains(err.Error(), "no release signing key") {
+		t.Fatalf("expected missing key error, got %v", err)
+	}
+}
+
+func TestCheckSigningKey(t *testing.T) {
+	if err := checkSigningKey(false, "true"); err == nil {
+		t.Fatal("expected a release build without a key to refuse updates")
+	}
+	if err := checkSigningKey(false, ""); err != nil {
+		t.Fatalf("source build without a key should fall back to SHA-256: %v", err)
+	}
+	if err := checkSigningKey(true, "true"); err != nil {
+		t.Fatalf("release build with a key rejected: %v", err)
+	}
+}
+
+// TestReleaseSigningKey fails release builds that would ship without a
+// usable signing key. The release workflow sets SSHTHING_RELEASE_BUILD.
+func TestReleaseSigningKey(t *testing.T) {
+	if os.Getenv("SSHTHING_RELEASE_BUILD") == "" {
+		t.Skip("only enforced for release builds")
+	}
+	if !SigningKeyEmbedded() {
+		t.Fatal("release_signing_key.asc holds no armored public key")
+	}
+	if _, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(releaseSigningKey)); err != nil {
+		t.Fatalf("release_signing_key.asc is not a valid key: %v", err)
+	}
+}
@@ -50,6 +50,7 @@ type CheckResult struct {
 	Guidance        []string
 	Asset           AssetInfo
 	Checksums       AssetInfo
+	Signature       AssetInfo
 	InstallerExe    string
 }
 
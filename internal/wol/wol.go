@@ -0,0 +1,77 @@
+// Package wol sends Wake-on-LAN magic packets.
+package wol
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DefaultPort is the UDP port magic packets are sent to when the broadcast
+// address does not name one.
+const DefaultPort = "9"
+
+// DefaultBroadcast is used when a host has no broadcast address set.
+const DefaultBroadcast = "255.255.255.255"
+
+// ParseMAC parses a 48-bit MAC address written with ':' or '-' separators.
+func ParseMAC(mac string) (net.HardwareAddr, error) {
+	hw, err := net.ParseMAC(strings.TrimSpace(mac))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAC address %q", mac)
+	}
+	if len(hw) != 6 {
+		return nil, fmt.Errorf("MAC address %q is not 48 bits", mac)
+	}
+	return hw, nil
+}
+
+// BroadcastTarget returns the host:port magic packets for addr go to. An
+// empty addr means the limited broadcast address; a missing port means 9.
+func BroadcastTarget(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		addr = DefaultBroadcast
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, DefaultPort
+	}
+	if host == "" || net.ParseIP(host) == nil && strings.ContainsAny(host, " /") {
+		return "", fmt.Errorf("invalid broadcast address %q", addr)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// MagicPacket builds the 102-byte packet that wakes mac: six 0xFF bytes
+// followed by the address repeated 16 times.
+func MagicPacket(mac net.HardwareAddr) []byte {
+	packet := bytes.Repeat([]byte{0xFF}, 6)
+	for i := 0; i < 16; i++ {
+		packet = append(packet, mac...)
+	}
+	return packet
+}
+
+// Wake sends a magic packet for mac over UDP to broadcastAddr (see
+// BroadcastTarget for the defaults).
+func Wake(mac, broadcastAddr string) error {
+	hw, err := ParseMAC(mac)
+	if err != nil {
+		return err
+	}
+	target, err := BroadcastTarget(broadcastAddr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.Dial("udp", target)
+	if err != nil {
+		return fmt.Errorf("wake-on-lan: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write(MagicPacket(hw)); err != nil {
+		return fmt.Errorf("wake-on-lan: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package wol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMagicPacket(t *testing.T) {
+	hw, err := ParseMAC("aa-bb-cc-dd-ee-ff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := MagicPacket(hw)
+	if len(p) != 102 {
+		t.Fatalf("expected 102 bytes, got %d", len(p))
+	}
+	if !bytes.Equal(p[:6], []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}) {
+		t.Fatalf("expected sync stream, got %x", p[:6])
+	}
+	for i := 6; i < len(p); i += 6 {
+		if !bytes.Equal(p[i:i+6], hw) {
+			t.Fatalf("expected MAC at offset %d, got %x", i, p[i:i+6])
+		}
+	}
+}
+
+func TestParseMACRejectsInvalid(t *testing.T) {
+	for _, mac := range []string{"", "aa:bb:cc", "zz:bb:cc:dd:ee:ff", "00:00:5e:00:53:00:00:01"} {
+		if _, err := ParseMAC(mac); err == nil {
+			t.Errorf("expected %q to be rejected", mac)
+		}
+	}
+}
+
+func TestBroadcastTarget(t *testing.T) {
+	cases := map[string]string{
+		"":                "255.255.255.255:9",
+		"192.168.1.255":   "192.168.1.255:9",
+		"192.168.1.255:7": "192.168.1.255:7",
+		"lan.example.com": "lan.example.com:9",
+	}
+	for in, want := range cases {
+		if got, err := BroadcastTarget(in); err != nil || got != want {
+			t.Errorf("BroadcastTarget(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestWakeSendsPacket(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	if err := Wake("aa:bb:cc:dd:ee:ff", pc.LocalAddr().String()); err != nil {
+		t.Fatalf("Wake failed: %v", err)
+	}
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 200)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no packet received: %v", err)
+	}
+	if n != 102 {
+		t.Fatalf("expected 102-byte packet, got %d", n)
+	}
+}